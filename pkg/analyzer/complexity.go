@@ -0,0 +1,131 @@
+package analyzer
+
+import (
+	"fmt"
+	"slices"
+	"soliton/pkg/metadata"
+	"strings"
+)
+
+// ComplexityLimits 模型复杂度阈值，超过时在报告中给出提示，为 0 表示不检查
+type ComplexityLimits struct {
+	MaxCollections int // 一个聚合根包含的一对多关联实体集合数
+	MaxFields      int // 一个聚合根映射为列的字段数
+	MaxDepth       int // 关联实体的最大包含深度，如 Order → OrderItem → ItemOption 为 2
+	MaxFanIn       int // 引用同一个聚合根的聚合根数
+}
+
+// DefaultComplexityLimits 返回默认的复杂度阈值
+func DefaultComplexityLimits() ComplexityLimits {
+	return ComplexityLimits{
+		MaxCollections: 3,
+		MaxFields:      30,
+		MaxDepth:       3,
+		MaxFanIn:       10,
+	}
+}
+
+// AggregateComplexity 单个聚合根的复杂度指标
+type AggregateComplexity struct {
+	Name        string   `json:"name"`
+	Fields      int      `json:"fields"`      // 映射为列的字段数（不含关联实体字段）
+	FanIn       int      `json:"fanIn"`       // 与本聚合根有关系、且指向本聚合根的其他聚合根数
+	FanOut      int      `json:"fanOut"`      // 本聚合根指向的其他聚合根数
+	Collections []string `json:"collections"` // 一对多关联实体字段，如 ["Items"]
+	Depth       int      `json:"depth"`       // 从本聚合根出发的关联实体最大包含深度
+	DepthPath   []string `json:"depthPath"`   // 最大深度对应的包含路径，如 ["Order", "OrderItem"]
+}
+
+// ComplexityReport 模型复杂度报告，用于架构评审
+type ComplexityReport struct {
+	Aggregates []*AggregateComplexity `json:"aggregates"` // 按聚合根名排序
+	MaxDepth   int                    `json:"maxDepth"`   // 所有聚合根中最大的包含深度
+	Warnings   []string               `json:"warnings"`   // 超过阈值的提示
+}
+
+// ComplexityReport 统计各聚合根的扇入、扇出、一对多集合数和关联实体包含深度，并按阈值给出提示
+//
+// 扇入、扇出按关联的聚合根去重统计，不含自引用；双向关系（见 RelationMetadata.IsBackReference）只按一对多（一对一）一侧计一次。
+// 包含深度只沿关联实体（一对一、一对多）计算，外部引用只保存 ID，不计入；循环包含由 ValidateRelations 报告，这里在回到路径上的聚合根时停止。
+func (a *RelationAnalyzer) ComplexityReport(limits ComplexityLimits) *ComplexityReport {
+	aggregates := a.registry.GetAll()
+
+	fanIn := make(map[string]map[string]bool)
+	fanOut := make(map[string]map[string]bool)
+	children := make(map[string][]string)
+	for _, rel := range a.registry.GetRelations() {
+		if rel.SelfReference || rel.IsBackReference() {
+			continue
+		}
+		if fanOut[rel.SourceAggregate] == nil {
+			fanOut[rel.SourceAggregate] = make(map[string]bool)
+		}
+		if fanIn[rel.TargetAggregate] == nil {
+			fanIn[rel.TargetAggregate] = make(map[string]bool)
+		}
+		fanOut[rel.SourceAggregate][rel.TargetAggregate] = true
+		fanIn[rel.TargetAggregate][rel.SourceAggregate] = true
+
+		if (rel.Type == metadata.RelationTypeOneToOne || rel.Type == metadata.RelationTypeOneToMany) &&
+			a.registry.Exists(rel.TargetAggregate) && !slices.Contains(children[rel.SourceAggregate], rel.TargetAggregate) {
+			children[rel.SourceAggregate] = append(children[rel.SourceAggregate], rel.TargetAggregate)
+		}
+	}
+
+	report := &ComplexityReport{}
+	for _, agg := range aggregates {
+		item := &AggregateComplexity{
+			Name:   agg.Name,
+			FanIn:  len(fanIn[agg.Name]),
+			FanOut: len(fanOut[agg.Name]),
+		}
+		for _, field := range agg.MappedFields() {
+			switch {
+			case field.Annotations.IsEntity && field.IsSlice:
+				item.Collections = append(item.Collections, field.Name)
+			case !field.Annotations.IsEntity:
+				item.Fields++
+			}
+		}
+		item.DepthPath = longestContainment(agg.Name, children, map[string]bool{})
+		item.Depth = len(item.DepthPath) - 1
+		report.MaxDepth = max(report.MaxDepth, item.Depth)
+		report.Aggregates = append(report.Aggregates, item)
+
+		if limits.MaxCollections > 0 && len(item.Collections) > limits.MaxCollections {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("聚合根 %s 包含 %d 个一对多集合（%s），超过 %d 个，考虑拆分聚合或改用外部引用",
+				agg.Name, len(item.Collections), strings.Join(item.Collections, "、"), limits.MaxCollections))
+		}
+		if limits.MaxFields > 0 && item.Fields > limits.MaxFields {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("聚合根 %s 有 %d 个字段，超过 %d 个，考虑提取值对象",
+				agg.Name, item.Fields, limits.MaxFields))
+		}
+		if limits.MaxDepth > 0 && item.Depth > limits.MaxDepth {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("聚合根 %s 的关联实体包含深度为 %d（%s），超过 %d 层，加载和保存整个聚合的开销较大",
+				agg.Name, item.Depth, strings.Join(item.DepthPath, " → "), limits.MaxDepth))
+		}
+		if limits.MaxFanIn > 0 && item.FanIn > limits.MaxFanIn {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("聚合根 %s 被 %d 个聚合根引用，超过 %d 个，修改其结构时影响面较大",
+				agg.Name, item.FanIn, limits.MaxFanIn))
+		}
+	}
+
+	return report
+}
+
+// longestContainment 返回从 name 出发沿关联实体包含关系的最长路径（含起点），遇到路径上已有的聚合根时停止
+func longestContainment(name string, children map[string][]string, onPath map[string]bool) []string {
+	onPath[name] = true
+	defer delete(onPath, name)
+
+	var longest []string
+	for _, child := range children[name] {
+		if onPath[child] {
+			continue
+		}
+		if path := longestContainment(child, children, onPath); len(path) > len(longest) {
+			longest = path
+		}
+	}
+	return append([]string{name}, longest...)
+}
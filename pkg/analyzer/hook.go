@@ -0,0 +1,105 @@
+package analyzer
+
+import (
+	"fmt"
+	"soliton/pkg/metadata"
+	"strings"
+)
+
+// Hook 关系分析钩子
+//
+// 组织内部的建模规范（如"每个聚合根都必须有 TenantID"）通过钩子加入分析流程，而不必修改分析器：
+//   - OnAggregate：AnalyzeRelations 分析完一个聚合根的字段关系和多对多关系后调用，可读取或补充聚合根元数据
+//   - OnRelation：AnalyzeRelations 关联完双向关系后，对注册表中的每条关系调用一次
+//   - OnValidate：ValidateHooks 调用，返回的错误与内置校验一样作为验证错误报告
+//
+// OnAggregate、OnRelation 返回错误时关系分析失败。只关心部分阶段的钩子可以嵌入 NopHook。
+type Hook interface {
+	OnAggregate(agg *metadata.AggregateMetadata) error
+	OnRelation(rel *metadata.RelationMetadata) error
+	OnValidate(registry *metadata.AggregateMetadataRegistry) []error
+}
+
+// NopHook 不做任何处理的钩子，嵌入后只需实现关心的方法
+type NopHook struct{}
+
+// OnAggregate 不做任何处理
+func (NopHook) OnAggregate(*metadata.AggregateMetadata) error { return nil }
+
+// OnRelation 不做任何处理
+func (NopHook) OnRelation(*metadata.RelationMetadata) error { return nil }
+
+// OnValidate 不做任何处理
+func (NopHook) OnValidate(*metadata.AggregateMetadataRegistry) []error { return nil }
+
+// AddHook 添加关系分析钩子，按添加顺序调用
+func (a *RelationAnalyzer) AddHook(hook Hook) {
+	a.hooks = append(a.hooks, hook)
+}
+
+// runAggregateHooks 对聚合根调用各钩子的 OnAggregate
+func (a *RelationAnalyzer) runAggregateHooks(agg *metadata.AggregateMetadata) error {
+	for _, hook := range a.hooks {
+		if err := hook.OnAggregate(agg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runRelationHooks 对注册表中的每条关系调用各钩子的 OnRelation
+func (a *RelationAnalyzer) runRelationHooks() error {
+	if len(a.hooks) == 0 {
+		return nil
+	}
+	for _, rel := range a.registry.GetRelations() {
+		for _, hook := range a.hooks {
+			if err := hook.OnRelation(rel); err != nil {
+				return fmt.Errorf("处理关系 %s → %s 失败: %w", rel.SourceAggregate, rel.TargetAggregate, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateHooks 调用各钩子的 OnValidate，汇总自定义规则的验证错误
+func (a *RelationAnalyzer) ValidateHooks() []error {
+	var errors []error
+	for _, hook := range a.hooks {
+		errors = append(errors, hook.OnValidate(a.registry)...)
+	}
+	return errors
+}
+
+// RequiredFieldsHook 要求每个聚合根都声明指定字段的钩子，如多租户模型的 TenantID
+type RequiredFieldsHook struct {
+	NopHook
+	fields []string
+}
+
+// NewRequiredFieldsHook 创建必备字段钩子，fields 为字段名
+func NewRequiredFieldsHook(fields ...string) *RequiredFieldsHook {
+	return &RequiredFieldsHook{fields: fields}
+}
+
+// OnValidate 报告缺少必备字段的聚合根，+soliton:ignore 标记的字段不计入
+func (h *RequiredFieldsHook) OnValidate(registry *metadata.AggregateMetadataRegistry) []error {
+	var errors []error
+	for _, agg := range registry.GetAll() {
+		declared := make(map[string]bool)
+		for _, field := range agg.MappedFields() {
+			declared[field.Name] = true
+		}
+
+		var missing []string
+		for _, name := range h.fields {
+			if !declared[name] {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			errors = append(errors, errorAt(agg.Pos, fmt.Errorf("聚合根 %s 缺少必备字段 %s", agg.Name, strings.Join(missing, "、"))))
+		}
+	}
+	return errors
+}
@@ -2,19 +2,52 @@ package analyzer
 
 import (
 	"fmt"
+	"go/token"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
 	"soliton/pkg/metadata"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // RelationAnalyzer 关系分析器
 type RelationAnalyzer struct {
-	registry *metadata.AggregateMetadataRegistry
+	registry    *metadata.AggregateMetadataRegistry
+	scalarTypes *metadata.ScalarTypeRegistry // 已知标量类型，按普通列处理而不识别为关系
+	naming      metadata.NamingStrategy      // 表命名策略，用于生成多对多关联表的默认表名
+	hooks       []Hook                       // 自定义规则钩子，见 AddHook
+	externals   map[string]bool              // 其他服务中的聚合根，外部引用它们时不要求在本模型中定义
 }
 
 // NewRelationAnalyzer 创建关系分析器
 func NewRelationAnalyzer(registry *metadata.AggregateMetadataRegistry) *RelationAnalyzer {
 	return &RelationAnalyzer{
-		registry: registry,
+		registry:    registry,
+		scalarTypes: metadata.NewScalarTypeRegistry(),
+		naming:      metadata.DefaultNamingStrategy(),
+		externals:   make(map[string]bool),
+	}
+}
+
+// SetScalarTypes 设置已知标量类型注册表，默认只包含预置类型（见 metadata.NewScalarTypeRegistry）
+func (a *RelationAnalyzer) SetScalarTypes(scalarTypes *metadata.ScalarTypeRegistry) {
+	a.scalarTypes = scalarTypes
+}
+
+// SetNamingStrategy 设置表命名策略，应与解析聚合根时使用的策略一致（见 parser.ASTParser.SetNamingStrategy）
+func (a *RelationAnalyzer) SetNamingStrategy(naming metadata.NamingStrategy) {
+	a.naming = naming
+}
+
+// SetExternalAggregates 声明其他服务（限界上下文）中的聚合根，如 Customer、Payment
+// 引用这些聚合根的外部引用记为跨服务引用，与字段上声明 +soliton:external 效果相同
+func (a *RelationAnalyzer) SetExternalAggregates(names []string) {
+	a.externals = make(map[string]bool, len(names))
+	for _, name := range names {
+		a.externals[name] = true
 	}
 }
 
@@ -31,16 +64,66 @@ func (a *RelationAnalyzer) AnalyzeRelations() error {
 		if err := a.analyzeManyToManyRelations(agg); err != nil {
 			return fmt.Errorf("分析聚合根 %s 的多对多关系失败: %w", agg.Name, err)
 		}
+
+		if err := a.runAggregateHooks(agg); err != nil {
+			return fmt.Errorf("处理聚合根 %s 失败: %w", agg.Name, err)
+		}
 	}
 
-	return nil
+	// 关联双向关系
+	a.linkInverseRelations()
+
+	return a.runRelationHooks()
+}
+
+// linkInverseRelations 将同一关系的两侧关联为双向关系
+//
+// 一对多、一对一关系的外键字段本身声明了 +soliton:ref 指回聚合根时，
+// 如 Order.Items 与 OrderItem.OrderID +soliton:ref(Order)，两条关系描述的是同一组数据：
+// 互相记录为 Inverse，外部引用一侧标记为拥有方（IsOwner，持有外键）。
+// 聚合根持有外键的一对一关系（+soliton:owner）与外键字段上的外部引用方向相同，不构成双向关系。
+func (a *RelationAnalyzer) linkInverseRelations() {
+	for _, rel := range a.registry.GetRelationsByType(metadata.RelationTypeOneToMany, metadata.RelationTypeOneToOne) {
+		if rel.ForeignKey == nil || rel.Inverse != nil || rel.ForeignKeyOnSource() {
+			continue
+		}
+		for _, ref := range a.registry.GetRelationsByAggregate(rel.TargetAggregate, metadata.RelationTypeRef) {
+			if ref.Field != rel.ForeignKey || ref.Inverse != nil || ref.TargetAggregate != rel.SourceAggregate {
+				continue
+			}
+			rel.Inverse, ref.Inverse = ref, rel
+			rel.InverseField, ref.InverseField = ref.Field.Name, rel.Field.Name
+			ref.IsOwner = true
+			break
+		}
+	}
 }
 
 // analyzeAggregateRelations 分析聚合根的字段关系
 func (a *RelationAnalyzer) analyzeAggregateRelations(agg *metadata.AggregateMetadata) error {
-	for _, field := range agg.Fields {
-		// 跳过基础类型字段
-		if a.isBasicType(field.Type) {
+	for _, field := range agg.MappedFields() {
+		// 标记已知标量类型（如 uuid.UUID、decimal.Decimal），与基础类型一样按普通列处理
+		field.ScalarType = a.scalarTypes.LookupField(field)
+		for _, sub := range field.Flattened {
+			sub.ScalarType = a.scalarTypes.LookupField(sub)
+		}
+
+		// 多态关联：为每个可指向的聚合根记录一条关系
+		if field.IsPolymorphic() {
+			for _, target := range field.Annotations.Polymorphic {
+				a.registry.AddRelation(&metadata.RelationMetadata{
+					SourceAggregate: agg.Name,
+					TargetAggregate: target,
+					Type:            metadata.RelationTypePolymorphic,
+					Field:           field,
+					SelfReference:   target == agg.Name,
+				})
+			}
+			continue
+		}
+
+		// 跳过基础类型字段（外部引用字段除外）
+		if (a.isBasicType(field.BasicType()) || field.ScalarType != nil) && !field.Annotations.IsRef {
 			continue
 		}
 
@@ -48,15 +131,50 @@ func (a *RelationAnalyzer) analyzeAggregateRelations(agg *metadata.AggregateMeta
 		relationType := a.identifyRelationType(field)
 
 		if relationType != -1 {
-			// 提取目标聚合根名称
-			targetAggregate := a.extractTargetAggregate(field.Type)
+			// 提取目标聚合根名称：外部引用按注解声明或字段名确定，关联实体按字段类型确定
+			targetAggregate := a.resolveTargetAggregate(field)
+			if relationType == metadata.RelationTypeRef {
+				targetAggregate = field.RefAggregate()
+			}
 
 			// 创建关系元数据
 			relation := &metadata.RelationMetadata{
 				SourceAggregate: agg.Name,
 				TargetAggregate: targetAggregate,
 				Type:            relationType,
+				TargetField:     field.Annotations.RefField,
 				Field:           field,
+				SelfReference:   targetAggregate == agg.Name,
+			}
+			if relationType == metadata.RelationTypeRef {
+				relation.External = field.Annotations.IsExternal || a.externals[targetAggregate]
+			}
+			// 一对多：推断关联实体表中的外键列，无法确定时由 validateForeignKeys 报告
+			if relationType == metadata.RelationTypeOneToMany {
+				if column, foreignKey, err := a.inferForeignKey(agg, field); err == nil {
+					relation.ForeignKeyColumn = column
+					relation.ForeignKey = foreignKey
+				}
+			}
+			// 一对一：声明 +soliton:owner 时外键列位于聚合根表中，否则与一对多一样位于关联实体表中；
+			// 关联实体表中没有外键列时不记录，+soliton:owner 无法确定外键列时由 validateForeignKeys 报告
+			if relationType == metadata.RelationTypeOneToOne {
+				infer := a.inferForeignKey
+				if field.Annotations.IsOwner {
+					relation.IsOwner = true
+					infer = a.inferOwnerForeignKey
+				}
+				if column, foreignKey, err := infer(agg, field); err == nil {
+					relation.ForeignKeyColumn = column
+					relation.ForeignKey = foreignKey
+				}
+			}
+			// 级联行为只在有效时记录，无效的声明由 ValidateCascadeRelations 报告
+			if field.Annotations.Cascade != "" {
+				if foreignKey, err := a.cascadeForeignKey(agg, field); err == nil && foreignKey != nil {
+					relation.Cascade = field.Annotations.Cascade
+					relation.ForeignKey = foreignKey
+				}
 			}
 
 			a.registry.AddRelation(relation)
@@ -72,6 +190,7 @@ func (a *RelationAnalyzer) analyzeAggregateRelations(agg *metadata.AggregateMeta
 //
 //  1. 外部引用：字段类型为基础类型（int64等） + +soliton:ref 注解
 //     示例：UserID int64 `db:"user_id" +soliton:ref`
+//     目标聚合根可通过 +soliton:ref(User) 或 +soliton:ref(User.ID) 声明，未声明时按字段名推断
 //
 //  2. 一对一：字段类型为单个对象（非切片） + +soliton:entity 注解
 //     示例：Profile *UserProfile `db:"-" +soliton:entity`
@@ -83,7 +202,7 @@ func (a *RelationAnalyzer) analyzeAggregateRelations(agg *metadata.AggregateMeta
 func (a *RelationAnalyzer) identifyRelationType(field *metadata.FieldMetadata) metadata.RelationType {
 	// 规则1：外部引用 = 基础类型 + ref注解
 	// 检查顺序：先检查注解，再检查类型
-	if field.Annotations.IsRef && a.isBasicType(field.Type) {
+	if field.Annotations.IsRef && (a.isBasicType(field.BasicType()) || field.ScalarType != nil) {
 		return metadata.RelationTypeRef
 	}
 
@@ -105,8 +224,9 @@ func (a *RelationAnalyzer) identifyRelationType(field *metadata.FieldMetadata) m
 // analyzeManyToManyRelations 分析多对多关系（通过 +soliton:ref 注解）
 // 规则：如果两个聚合根互相引用，则为多对多关系
 func (a *RelationAnalyzer) analyzeManyToManyRelations(agg *metadata.AggregateMetadata) error {
-	// 如果该聚合根标记为 +soliton:manyToMany，则作为聚合根处理（不生成关联表）
+	// 如果该聚合根标记为 +soliton:manyToMany，则作为中间实体处理：关联两端的聚合根（不生成关联表）
 	if agg.Annotations.IsManyToMany {
+		a.analyzeAssociation(agg)
 		return nil
 	}
 
@@ -145,7 +265,25 @@ func (a *RelationAnalyzer) analyzeManyToManyRelations(agg *metadata.AggregateMet
 	return nil
 }
 
-// isBasicType 判断是否为基础类型
+// analyzeAssociation 将中间实体（+soliton:manyToMany）关联的两端记录为一条多对多关系
+// 两端取自 AssociationEnds（前两个 +soliton:ref 字段），字段不足或两端未注册时由 validateAssociations 报告
+func (a *RelationAnalyzer) analyzeAssociation(agg *metadata.AggregateMetadata) {
+	left, right := agg.AssociationEnds()
+	if left == nil || !a.registry.Exists(left.RefAggregate()) || !a.registry.Exists(right.RefAggregate()) {
+		return
+	}
+
+	a.registry.AddRelation(&metadata.RelationMetadata{
+		SourceAggregate: left.RefAggregate(),
+		TargetAggregate: right.RefAggregate(),
+		Type:            metadata.RelationTypeManyToMany,
+		SelfReference:   left.RefAggregate() == right.RefAggregate(),
+		IsOwner:         true,
+		Through:         agg.Name,
+	})
+}
+
+// isBasicType 判断是否为基础类型（内置类型或已知标量类型）
 func (a *RelationAnalyzer) isBasicType(typeName string) bool {
 	basicTypes := map[string]bool{
 		"int":     true,
@@ -165,12 +303,21 @@ func (a *RelationAnalyzer) isBasicType(typeName string) bool {
 	// 去除指针符号
 	typeName = strings.TrimPrefix(typeName, "*")
 
-	// 处理 time.Time
-	if typeName == "time.Time" {
-		return true
+	return basicTypes[typeName] || a.scalarTypes.Lookup(typeName) != nil
+}
+
+// resolveTargetAggregate 确定字段引用的目标聚合根名称
+// 启用类型解析时按完整限定类型匹配注册表中的聚合根（可跨包），否则按类型名推断
+func (a *RelationAnalyzer) resolveTargetAggregate(field *metadata.FieldMetadata) string {
+	if field.QualifiedType != "" {
+		for _, agg := range a.registry.GetAll() {
+			if agg.ImportPath+"."+agg.Name == field.QualifiedType {
+				return agg.Name
+			}
+		}
 	}
 
-	return basicTypes[typeName]
+	return a.extractTargetAggregate(field.Type)
 }
 
 // extractTargetAggregate 提取目标聚合根名称
@@ -191,20 +338,19 @@ func (a *RelationAnalyzer) extractTargetAggregate(typeName string) string {
 
 // GenerateManyToManyTables 生成多对多关联表元数据
 func (a *RelationAnalyzer) GenerateManyToManyTables() error {
-	for _, relation := range a.registry.GetRelations() {
-		if relation.Type == metadata.RelationTypeManyToMany {
-			// 生成关联表元数据
-			table := a.createManyToManyTable(relation)
-			a.registry.AddManyToManyTable(table)
-		}
+	for _, relation := range a.registry.GetRelationsByType(metadata.RelationTypeManyToMany) {
+		// 生成关联表元数据
+		table := a.createManyToManyTable(relation)
+		a.registry.AddManyToManyTable(table)
 	}
 	return nil
 }
 
 // createManyToManyTable 创建多对多关联表元数据
 func (a *RelationAnalyzer) createManyToManyTable(relation *metadata.RelationMetadata) *metadata.ManyToManyTableMetadata {
-	leftAgg := a.registry.Get(relation.SourceAggregate)
-	rightAgg := a.registry.Get(relation.TargetAggregate)
+	if relation.Through != "" {
+		return a.createAssociationTable(relation)
+	}
 
 	// 生成表名：按字母序排列（如 role_user）
 	var tableName string
@@ -220,25 +366,33 @@ func (a *RelationAnalyzer) createManyToManyTable(relation *metadata.RelationMeta
 		rightName = relation.SourceAggregate
 	}
 
-	// 表名：左_右（全小写）
-	tableName = toSnakeCase(leftName) + "_" + toSnakeCase(rightName)
+	// 表名：按命名策略由两端生成（默认为 左_右，全小写），设置了自定义表名的聚合根使用其表名
+	tableName = a.naming.JoinTableName(a.joinTablePart(leftName), a.joinTablePart(rightName))
 
 	// 列名：聚合根名_id
 	leftColumn = toSnakeCase(leftName) + "_id"
 	rightColumn = toSnakeCase(rightName) + "_id"
 
-	// ID 字段名
-	if leftAgg != nil && leftAgg.IDField != nil {
-		leftIDField = leftAgg.IDField.Name
-	} else {
-		leftIDField = "ID"
+	// 自定义关联表：+soliton:manyToMany(table=..., left=..., right=...) 可由任一方声明，left 为声明方的列
+	if join, declarer := a.findJoinTable(leftName, rightName); join != nil {
+		if join.Table != "" {
+			tableName = join.Table
+		}
+		declarerColumn, otherColumn := &leftColumn, &rightColumn
+		if declarer == rightName {
+			declarerColumn, otherColumn = &rightColumn, &leftColumn
+		}
+		if join.Left != "" {
+			*declarerColumn = join.Left
+		}
+		if join.Right != "" {
+			*otherColumn = join.Right
+		}
 	}
 
-	if rightAgg != nil && rightAgg.IDField != nil {
-		rightIDField = rightAgg.IDField.Name
-	} else {
-		rightIDField = "ID"
-	}
+	// ID 字段名
+	leftIDField = a.idFieldName(leftName)
+	rightIDField = a.idFieldName(rightName)
 
 	return &metadata.ManyToManyTableMetadata{
 		TableName:      tableName,
@@ -252,6 +406,185 @@ func (a *RelationAnalyzer) createManyToManyTable(relation *metadata.RelationMeta
 	}
 }
 
+// createAssociationTable 创建中间实体（+soliton:manyToMany）对应的关联表元数据
+// 表和列取自中间实体本身，左右两端按外部引用字段的声明顺序排列
+func (a *RelationAnalyzer) createAssociationTable(relation *metadata.RelationMetadata) *metadata.ManyToManyTableMetadata {
+	agg := a.registry.Get(relation.Through)
+	left, right := agg.AssociationEnds()
+
+	return &metadata.ManyToManyTableMetadata{
+		TableName:      agg.Table(),
+		LeftAggregate:  relation.SourceAggregate,
+		RightAggregate: relation.TargetAggregate,
+		LeftColumn:     left.Column(),
+		RightColumn:    right.Column(),
+		LeftIDField:    a.idFieldName(relation.SourceAggregate),
+		RightIDField:   a.idFieldName(relation.TargetAggregate),
+		GenerationType: "aggregate",
+		Association:    agg.Name,
+	}
+}
+
+// idFieldName 返回聚合根的 ID 字段名，未注册或未识别到 ID 字段时为 "ID"
+func (a *RelationAnalyzer) idFieldName(aggregateName string) string {
+	if agg := a.registry.Get(aggregateName); agg != nil && agg.IDField != nil {
+		return agg.IDField.Name
+	}
+	return "ID"
+}
+
+// findJoinTable 查找两个聚合根之间的关联表配置，返回配置及声明它的聚合根
+// 两侧都声明时使用左侧的配置（冲突由 ValidateRelations 报告）
+func (a *RelationAnalyzer) findJoinTable(left, right string) (*metadata.JoinTableMetadata, string) {
+	if join := a.joinTableOf(left, right); join != nil {
+		return join, left
+	}
+	if join := a.joinTableOf(right, left); join != nil {
+		return join, right
+	}
+	return nil, ""
+}
+
+// joinTableOf 返回聚合根 declarer 为与 other 的多对多关系声明的关联表配置
+// 配置未指定 with 时，只在聚合根只声明了一个 +soliton:ref 时适用于该引用
+func (a *RelationAnalyzer) joinTableOf(declarer, other string) *metadata.JoinTableMetadata {
+	agg := a.registry.Get(declarer)
+	if agg == nil {
+		return nil
+	}
+	refs := agg.Annotations.Refs
+	for _, join := range agg.Annotations.JoinTables {
+		if join.With == other || join.With == "" && len(refs) == 1 && refs[0] == other {
+			return join
+		}
+	}
+	return nil
+}
+
+// validateJoinTables 验证多对多关联表配置（+soliton:manyToMany(table=..., left=..., right=...)）
+//   - 对端须通过 +soliton:ref 声明，且与声明方构成双向引用（多对多）
+//   - 声明了多个 +soliton:ref 时须通过 with 指定对端，两侧不能同时声明配置
+//   - left 与 right 的列名不能相同
+func (a *RelationAnalyzer) validateJoinTables() []error {
+	var errors []error
+
+	for _, agg := range a.registry.GetAll() {
+		refs := agg.Annotations.Refs
+		for _, join := range agg.Annotations.JoinTables {
+			other := join.With
+			if other == "" {
+				if len(refs) != 1 {
+					errors = append(errors, errorAt(agg.AnnotationPos("manyToMany"), fmt.Errorf("聚合根 %s 的 +soliton:manyToMany(table=%s) 未指定 with，且声明了 %d 个 +soliton:ref，无法确定对端聚合根",
+						agg.Name, join.Table, len(refs))))
+					continue
+				}
+				other = refs[0]
+			}
+			if !slices.Contains(refs, other) {
+				errors = append(errors, errorAt(agg.AnnotationPos("manyToMany"), fmt.Errorf("聚合根 %s 为 %s 声明了关联表配置，但未声明 +soliton:ref(%s)", agg.Name, other, other)))
+				continue
+			}
+			if target := a.registry.Get(other); target != nil && !slices.Contains(target.Annotations.Refs, agg.Name) {
+				errors = append(errors, errorAt(agg.AnnotationPos("manyToMany"), fmt.Errorf("聚合根 %s 为 %s 声明了关联表配置，但两者未构成多对多关系（%s 需声明 +soliton:ref(%s)）",
+					agg.Name, other, other, agg.Name)))
+				continue
+			}
+			if agg.Name > other && a.joinTableOf(other, agg.Name) != nil {
+				errors = append(errors, errorAt(agg.AnnotationPos("manyToMany"), fmt.Errorf("聚合根 %s 和 %s 都声明了关联表配置，只能由其中一方声明", other, agg.Name)))
+			}
+			if join.Left != "" && join.Left == join.Right {
+				errors = append(errors, errorAt(agg.AnnotationPos("manyToMany"), fmt.Errorf("聚合根 %s 与 %s 的关联表列名 left 和 right 相同：%s", agg.Name, other, join.Left)))
+			}
+		}
+	}
+
+	return errors
+}
+
+// validateAssociations 验证中间实体（+soliton:manyToMany）
+//   - 须有两个 +soliton:ref 字段分别引用关联两端的聚合根，且不能为指针
+//   - 两端须为已注册的聚合根
+//   - 两端不能再通过双向 +soliton:ref 构成多对多，否则会重复生成关联表
+func (a *RelationAnalyzer) validateAssociations() []error {
+	var errors []error
+
+	for _, agg := range a.registry.GetAll() {
+		if !agg.Annotations.IsManyToMany {
+			continue
+		}
+		left, right := agg.AssociationEnds()
+		if left == nil {
+			errors = append(errors, errorAt(agg.Pos, fmt.Errorf("中间实体 %s 需要两个 +soliton:ref 字段分别引用关联两端的聚合根", agg.Name)))
+			continue
+		}
+
+		for _, end := range []*metadata.FieldMetadata{left, right} {
+			if end.IsPointer {
+				errors = append(errors, errorAt(end.Pos, fmt.Errorf("中间实体 %s 的字段 %s 是关联的一端，不能为指针", agg.Name, end.Name)))
+			}
+			if !a.registry.Exists(end.RefAggregate()) {
+				errors = append(errors, errorAt(end.Pos, fmt.Errorf("中间实体 %s 的字段 %s 引用的 %s 不是已注册的聚合根", agg.Name, end.Name, end.RefAggregate())))
+			}
+		}
+
+		leftAgg, rightAgg := a.registry.Get(left.RefAggregate()), a.registry.Get(right.RefAggregate())
+		if leftAgg != nil && rightAgg != nil &&
+			slices.Contains(leftAgg.Annotations.Refs, rightAgg.Name) && slices.Contains(rightAgg.Annotations.Refs, leftAgg.Name) {
+			errors = append(errors, fmt.Errorf("聚合根 %s 与 %s 已通过中间实体 %s 关联，请移除两者之间的双向 +soliton:ref，避免重复生成关联表",
+				leftAgg.Name, rightAgg.Name, agg.Name))
+		}
+	}
+
+	return errors
+}
+
+// validateTableNames 验证表名在聚合根和生成的多对多关联表之间唯一（不区分大小写）
+//
+// 不同的聚合根对可能生成相同的关联表名（如自定义表名 user_role 的聚合根与 Permission 拼接），
+// 关联表名也可能与某个聚合根自身的表名相同（如 snake 命名策略下的聚合根 RoleUser 与 Role、User 的关联表），
+// 同名的表会生成互相冲突的建表语句。中间实体（+soliton:manyToMany）的关联表即其自身的表，只计一次。
+func (a *RelationAnalyzer) validateTableNames() []error {
+	var errors []error
+
+	owners := make(map[string][]string)
+	var tables []string
+	addTable := func(table, owner string) {
+		key := strings.ToLower(table)
+		if _, ok := owners[key]; !ok {
+			tables = append(tables, table)
+		}
+		owners[key] = append(owners[key], owner)
+	}
+
+	for _, agg := range a.registry.GetAll() {
+		addTable(agg.Table(), "聚合根 "+agg.Name)
+	}
+	for _, table := range a.registry.GetManyToManyTables() {
+		if table.Association != "" {
+			continue
+		}
+		addTable(table.TableName, fmt.Sprintf("%s 与 %s 的关联表", table.LeftAggregate, table.RightAggregate))
+	}
+
+	for _, table := range tables {
+		if names := owners[strings.ToLower(table)]; len(names) > 1 {
+			errors = append(errors, fmt.Errorf("表名 %s 被多个表使用：%s，请通过 +soliton:table(name=...) 或 +soliton:manyToMany(table=...) 指定不同的表名",
+				table, strings.Join(names, "、")))
+		}
+	}
+
+	return errors
+}
+
+// joinTablePart 关联表名中代表聚合根的部分，由命名策略转换后拼接为关联表名
+// 聚合根通过 +soliton:table(name=...) 自定义了表名时使用该表名，否则使用聚合根名
+func (a *RelationAnalyzer) joinTablePart(aggregateName string) string {
+	if agg := a.registry.Get(aggregateName); agg != nil && agg.TableName != "" {
+		return agg.TableName
+	}
+	return aggregateName
+}
+
 // toSnakeCase 转换为蛇形命名
 // Order -> order, OrderItem -> order_item
 func toSnakeCase(s string) string {
@@ -277,26 +610,1353 @@ func toSnakeCase(s string) string {
 }
 
 // ValidateRelations 验证关系的有效性
+//   - 关系的目标聚合根必须存在（跨服务的外部引用除外），复合主键的聚合根不能作为多对多、多态关联的目标
+//   - 声明为其他服务的聚合根不能同时在本模型中定义，见 validateRefTarget
+//   - 多对多关联表配置有效，见 validateJoinTables
+//   - 关联实体（+soliton:entity）之间不能构成循环，见 detectEntityCycles
 func (a *RelationAnalyzer) ValidateRelations() []error {
 	var errors []error
 
 	// 检查所有关系的目标聚合根是否存在
 	for _, relation := range a.registry.GetRelations() {
-		// 外部引用不需要检查目标聚合根是否存在（可能是外部系统的）
+		// 外部引用的目标可以是其他服务的聚合根（+soliton:external、-external），见 validateRefTarget
 		if relation.Type == metadata.RelationTypeRef {
+			errors = append(errors, a.validateRefTarget(relation)...)
 			continue
 		}
 
 		// 检查目标聚合根是否已注册
-		if !a.registry.Exists(relation.TargetAggregate) {
-			errors = append(errors, fmt.Errorf(
+		target := a.registry.Get(relation.TargetAggregate)
+		if target == nil {
+			errors = append(errors, errorAt(relation.Field.Pos, fmt.Errorf(
 				"聚合根 %s 的字段 %s 引用了不存在的聚合根 %s",
 				relation.SourceAggregate,
 				relation.Field.Name,
 				relation.TargetAggregate,
-			))
+			)))
+			continue
+		}
+		// 多对多关联表和多态关联都按单列主键引用目标
+		if relation.Type == metadata.RelationTypeManyToMany && target.IsCompositeKey() {
+			errors = append(errors, errorAt(a.registry.Get(relation.SourceAggregate).AnnotationPos("ref"), fmt.Errorf("聚合根 %s 与 %s 构成多对多关系，复合主键的聚合根不支持多对多关系",
+				relation.SourceAggregate, target.Name)))
+		}
+		if relation.Type == metadata.RelationTypePolymorphic && target.IsCompositeKey() {
+			errors = append(errors, errorAt(relation.Field.AnnotationPos("polymorphic"), fmt.Errorf("聚合根 %s 的字段 %s 多态关联了 %s，复合主键的聚合根不支持多态关联",
+				relation.SourceAggregate, relation.Field.Name, target.Name)))
+		}
+	}
+
+	errors = append(errors, a.validateJoinTables()...)
+	errors = append(errors, a.validateAssociations()...)
+	errors = append(errors, a.validateTableNames()...)
+	for _, name := range slices.Sorted(maps.Keys(a.externals)) {
+		if a.registry.Exists(name) {
+			errors = append(errors, errorAt(a.registry.Get(name).Pos, fmt.Errorf("%s 被声明为其他服务的聚合根，但在本模型中定义了同名聚合根", name)))
+		}
+	}
+	errors = append(errors, a.validateForeignKeys()...)
+	errors = append(errors, a.detectEntityCycles()...)
+
+	return errors
+}
+
+// entityEdge 关联实体关系图中的一条边：聚合根通过 Field 字段包含 Target
+type entityEdge struct {
+	Field  string
+	Target string
+}
+
+// detectEntityCycles 检测关联实体（一对一、一对多）之间的循环
+//
+// 关联实体按包含关系加载和迁移，Order → Customer → Order 这样的循环会导致迁移排序和预加载无法终止，
+// 每个循环报告一次完整路径，如 Order.Customer → Customer.Orders → Order。
+// 聚合根包含自身（树形结构的 Children）是合法的自引用，不视为循环；外部引用只保存 ID，不参与检测。
+func (a *RelationAnalyzer) detectEntityCycles() []error {
+	graph := make(map[string][]entityEdge)
+	for _, relation := range a.registry.GetRelationsByType(metadata.RelationTypeOneToOne, metadata.RelationTypeOneToMany) {
+		if relation.SelfReference || relation.Field == nil || !a.registry.Exists(relation.TargetAggregate) {
+			continue
+		}
+		graph[relation.SourceAggregate] = append(graph[relation.SourceAggregate], entityEdge{
+			Field:  relation.Field.Name,
+			Target: relation.TargetAggregate,
+		})
+	}
+
+	var errors []error
+	reported := make(map[string]bool)
+	done := make(map[string]bool)  // 已完成搜索的聚合根
+	onPath := make(map[string]int) // 当前搜索路径上的聚合根及其在 path 中的位置
+	var path []entityEdge          // 当前搜索路径，path[i] 为从第 i 个聚合根出发的边
+	var nodes []string             // 当前搜索路径上的聚合根
+
+	var visit func(name string)
+	visit = func(name string) {
+		onPath[name] = len(nodes)
+		nodes = append(nodes, name)
+
+		for _, edge := range graph[name] {
+			if start, ok := onPath[edge.Target]; ok {
+				// 回边：从 start 到当前节点再回到 start 构成循环
+				cycle := append(append([]entityEdge{}, path[start:]...), edge)
+				cycleNodes := nodes[start:]
+				if key := cycleKey(cycleNodes, cycle); !reported[key] {
+					reported[key] = true
+					errors = append(errors, fmt.Errorf("关联实体存在循环引用：%s，请将其中一个字段改为 +soliton:ref 外部引用",
+						formatCycle(cycleNodes, cycle)))
+				}
+				continue
+			}
+			if done[edge.Target] {
+				continue
+			}
+			path = append(path, edge)
+			visit(edge.Target)
+			path = path[:len(path)-1]
+		}
+
+		nodes = nodes[:len(nodes)-1]
+		delete(onPath, name)
+		done[name] = true
+	}
+
+	for _, agg := range a.registry.GetAll() {
+		if !done[agg.Name] {
+			visit(agg.Name)
+		}
+	}
+
+	return errors
+}
+
+// cycleKey 返回循环的规范化标识，从名称最小的聚合根开始，同一循环从不同位置发现时只报告一次
+func cycleKey(nodes []string, edges []entityEdge) string {
+	start := 0
+	for i, name := range nodes {
+		if name < nodes[start] {
+			start = i
+		}
+	}
+	parts := make([]string, len(nodes))
+	for i := range nodes {
+		j := (start + i) % len(nodes)
+		parts[i] = nodes[j] + "." + edges[j].Field
+	}
+	return strings.Join(parts, ",")
+}
+
+// formatCycle 格式化循环路径，如 Order.Customer → Customer.Orders → Order
+func formatCycle(nodes []string, edges []entityEdge) string {
+	parts := make([]string, 0, len(nodes)+1)
+	for i, name := range nodes {
+		parts = append(parts, name+"."+edges[i].Field)
+	}
+	parts = append(parts, nodes[0])
+	return strings.Join(parts, " → ")
+}
+
+// validateRefTarget 验证外部引用指向的目标字段
+//   - 未能确定目标聚合根时（字段名不以 ID 结尾且未声明目标）报错
+//   - 目标聚合根已注册时，引用的字段必须存在且是目标主键，类型需与引用字段一致
+//   - 复合主键的聚合根无法用单个字段引用
+func (a *RelationAnalyzer) validateRefTarget(relation *metadata.RelationMetadata) []error {
+	field := relation.Field
+	if relation.TargetAggregate == "" {
+		return []error{errorAt(field.AnnotationPos("ref"), fmt.Errorf("聚合根 %s 的字段 %s 无法推断引用的聚合根，请使用 +soliton:ref(聚合根名) 声明",
+			relation.SourceAggregate, field.Name))}
+	}
+
+	target := a.registry.Get(relation.TargetAggregate)
+	if relation.External {
+		// 其他服务的聚合根不在本模型中，无法检查引用的字段；-external 与本模型冲突时由 ValidateRelations 统一报告
+		if target != nil && field.Annotations.IsExternal {
+			return []error{errorAt(field.AnnotationPos("ref"), fmt.Errorf("聚合根 %s 的字段 %s 声明了 +soliton:external，但 %s 是本模型中的聚合根",
+				relation.SourceAggregate, field.Name, target.Name))}
+		}
+		return nil
+	}
+	if target == nil {
+		return []error{errorAt(field.AnnotationPos("ref"), fmt.Errorf("聚合根 %s 的字段 %s 引用了不存在的聚合根 %s，如为其他服务中的聚合根，请声明 +soliton:external 或通过 -external 指定",
+			relation.SourceAggregate, field.Name, relation.TargetAggregate))}
+	}
+	if target.IsCompositeKey() {
+		return []error{errorAt(field.AnnotationPos("ref"), fmt.Errorf("聚合根 %s 的字段 %s 引用了 %s，复合主键的聚合根不支持单字段外部引用",
+			relation.SourceAggregate, field.Name, target.Name))}
+	}
+	if target.IDField == nil {
+		return nil
+	}
+
+	if relation.TargetField != "" && relation.TargetField != target.IDField.Name {
+		return []error{errorAt(field.AnnotationPos("ref"), fmt.Errorf("聚合根 %s 的字段 %s 引用了 %s.%s，外部引用只能指向目标聚合根的主键 %s",
+			relation.SourceAggregate, field.Name, target.Name, relation.TargetField, target.IDField.Name))}
+	}
+	if field.BasicType() != target.IDField.BasicType() {
+		return []error{errorAt(field.AnnotationPos("ref"), fmt.Errorf("聚合根 %s 的字段 %s 类型为 %s，与引用的 %s.%s 类型 %s 不一致",
+			relation.SourceAggregate, field.Name, field.Type, target.Name, target.IDField.Name, target.IDField.Type))}
+	}
+	return nil
+}
+
+// ValidatePolymorphicRelations 验证多态关联（+soliton:polymorphic）
+//   - ID 字段必须是标量类型（可以是指针，nil 表示未关联），列出的聚合根不能重复（与 +soliton:ref 等注解冲突由 ValidateAnnotationConflicts 检查）
+//   - 类型字段（默认 {名称}Type）必须存在且为 string
+//   - ID 字段类型需与各目标聚合根的主键类型一致
+//
+// 目标聚合根是否存在由 ValidateRelations 检查。
+func (a *RelationAnalyzer) ValidatePolymorphicRelations() []error {
+	var errors []error
+
+	for _, agg := range a.registry.GetAll() {
+		fields := make(map[string]*metadata.FieldMetadata)
+		for _, field := range agg.MappedFields() {
+			fields[field.Name] = field
+		}
+
+		for _, field := range agg.MappedFields() {
+			// 与 +soliton:entity、+soliton:valueObject、+soliton:ref 同时声明由 ValidateAnnotationConflicts 报告
+			if !field.IsPolymorphic() || field.Annotations.IsEntity || field.Annotations.IsValueObject || field.Annotations.IsRef {
+				continue
+			}
+
+			if field.IsSlice || field.IsMap || field.IsArray || !a.isBasicType(field.BasicType()) {
+				errors = append(errors, errorAt(field.AnnotationPos("polymorphic"), fmt.Errorf("聚合根 %s 的字段 %s 类型为 %s，多态关联字段只支持标量类型",
+					agg.Name, field.Name, field.GoType())))
+				continue
+			}
+
+			typeFieldName := field.PolymorphicTypeField()
+			typeField := fields[typeFieldName]
+			switch {
+			case typeField == nil:
+				errors = append(errors, errorAt(field.AnnotationPos("polymorphic"), fmt.Errorf("聚合根 %s 的字段 %s 声明了多态关联，但缺少保存目标类型的字段 %s（可通过 typeField 指定）",
+					agg.Name, field.Name, typeFieldName)))
+			case typeField.GoType() != "string":
+				errors = append(errors, errorAt(typeField.Pos, fmt.Errorf("聚合根 %s 的多态类型字段 %s 类型为 %s，应为 string",
+					agg.Name, typeFieldName, typeField.GoType())))
+			}
+
+			seen := make(map[string]bool)
+			for _, targetName := range field.Annotations.Polymorphic {
+				if seen[targetName] {
+					errors = append(errors, errorAt(field.AnnotationPos("polymorphic"), fmt.Errorf("聚合根 %s 的字段 %s 多态关联的聚合根 %s 重复",
+						agg.Name, field.Name, targetName)))
+					continue
+				}
+				seen[targetName] = true
+
+				target := a.registry.Get(targetName)
+				if target == nil || target.IDField == nil {
+					continue
+				}
+				if field.BasicType() != target.IDField.BasicType() {
+					errors = append(errors, errorAt(field.AnnotationPos("polymorphic"), fmt.Errorf("聚合根 %s 的字段 %s 类型为 %s，与多态关联的 %s.%s 类型 %s 不一致",
+						agg.Name, field.Name, field.Type, target.Name, target.IDField.Name, target.IDField.Type)))
+				}
+			}
+		}
+	}
+
+	return errors
+}
+
+// ValidateAggregateBoundaries 验证聚合边界：聚合根之间只应通过 ID 引用
+//
+// +soliton:entity 字段的目标本身是另一个聚合根时，两个聚合根的一致性边界被合并，属于越界，
+// 应改为 +soliton:ref 外部引用。以下情况不视为越界：
+//   - 聚合根包含自身（树形结构的 Children）
+//   - 字段声明了 +soliton:cascade，显式表示关联实体的生命周期由该聚合根管理
+//   - 目标结构体标记了 +soliton:entity，显式声明为聚合内的关联实体
+func (a *RelationAnalyzer) ValidateAggregateBoundaries() []error {
+	var errors []error
+
+	for _, agg := range a.registry.GetAll() {
+		for _, field := range agg.MappedFields() {
+			if !field.Annotations.IsEntity || field.Annotations.Cascade != "" {
+				continue
+			}
+			target := a.registry.Get(a.resolveTargetAggregate(field))
+			if target == nil || target.Name == agg.Name || target.Annotations.IsEntity {
+				continue
+			}
+
+			var suggestion string
+			if field.IsSlice {
+				suggestion = fmt.Sprintf("在 %s 中声明 %sID %s +soliton:ref(%s) 反向引用 %s",
+					target.Name, agg.Name, agg.IDKeyType(), agg.Name, agg.Name)
+			} else {
+				suggestion = fmt.Sprintf("改为 %sID %s +soliton:ref(%s)", target.Name, target.IDKeyType(), target.Name)
+			}
+			errors = append(errors, errorAt(field.AnnotationPos("entity"), fmt.Errorf("聚合根 %s 的字段 %s 以 +soliton:entity 包含了另一个聚合根 %s，违反聚合边界（聚合根之间只应通过 ID 引用），建议%s",
+				agg.Name, field.Name, target.Name, suggestion)))
+		}
+	}
+
+	return errors
+}
+
+// ValidateReachability 检查模型中没有被使用的部分，帮助保持大型模型整洁
+//   - 孤立实体：标记了 +soliton:entity 的聚合根（聚合内的关联实体）没有被其他聚合根的 +soliton:entity 字段包含
+//   - 孤立聚合根：既不是任何关系的源或目标（一对一、一对多、多对多、外部引用、多态关联），也没有领域行为
+//
+// 这些问题不影响代码生成，调用方应作为建议提示，而不是视为验证错误。
+func (a *RelationAnalyzer) ValidateReachability() []error {
+	var errors []error
+
+	for _, agg := range a.registry.GetAll() {
+		if agg.Annotations.IsEntity {
+			contained := false
+			for _, relation := range a.registry.GetRelationsByTarget(agg.Name, metadata.RelationTypeOneToOne, metadata.RelationTypeOneToMany) {
+				if !relation.SelfReference {
+					contained = true
+					break
+				}
+			}
+			if !contained {
+				errors = append(errors, errorAt(agg.Pos, fmt.Errorf("聚合根 %s 标记为 +soliton:entity，但没有被任何聚合根的 +soliton:entity 字段包含（孤立实体），请在所属聚合根中声明关联字段或删除该实体",
+					agg.Name)))
+			}
+			continue
+		}
+
+		if len(a.registry.GetRelationsByAggregate(agg.Name)) == 0 && len(a.registry.GetRelationsByTarget(agg.Name)) == 0 &&
+			len(agg.Behaviors) == 0 {
+			errors = append(errors, errorAt(agg.Pos, fmt.Errorf("聚合根 %s 没有任何关系，也没有领域行为（孤立聚合根），请确认是否仍在使用", agg.Name)))
+		}
+	}
+
+	return errors
+}
+
+// backReferences 返回目标聚合根中引用源聚合根主键的外部引用字段，如 OrderItem.OrderID +soliton:ref(Order)
+func (a *RelationAnalyzer) backReferences(source *metadata.AggregateMetadata, targetName string) []*metadata.FieldMetadata {
+	target := a.registry.Get(targetName)
+	if target == nil {
+		return nil
+	}
+
+	var fields []*metadata.FieldMetadata
+	for _, field := range target.MappedFields() {
+		if !field.Annotations.IsRef || field.RefAggregate() != source.Name {
+			continue
+		}
+		if field.Annotations.RefField != "" && (source.IDField == nil || field.Annotations.RefField != source.IDField.Name) {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// ValidateCascadeRelations 验证关联实体级联行为（+soliton:cascade）的有效性
+//   - 只能用于 +soliton:entity 字段，行为为 delete、nullify、restrict 之一
+//   - 关联实体须与聚合根位于同一限界上下文，聚合根不能使用复合主键
+//   - 关联实体中能确定引用聚合根主键的外键字段（见 inferForeignKey），nullify 要求该字段为指针（可空列）
+func (a *RelationAnalyzer) ValidateCascadeRelations() []error {
+	var errors []error
+
+	for _, agg := range a.registry.GetWithFieldAnnotation("cascade") {
+		for _, field := range agg.MappedFields() {
+			if field.Annotations.Cascade == "" {
+				continue
+			}
+			if _, err := a.cascadeForeignKey(agg, field); err != nil {
+				errors = append(errors, err)
+			}
 		}
 	}
 
 	return errors
 }
+
+// cascadeForeignKey 校验字段的级联行为，返回关联实体中引用聚合根的外键字段
+// 关联实体不存在时返回 nil, nil（由 ValidateRelations 报告）
+func (a *RelationAnalyzer) cascadeForeignKey(agg *metadata.AggregateMetadata, field *metadata.FieldMetadata) (*metadata.FieldMetadata, error) {
+	cascade := field.Annotations.Cascade
+	if !field.Annotations.IsEntity {
+		return nil, errorAt(field.AnnotationPos("cascade"), fmt.Errorf("聚合根 %s 的字段 %s 不是关联实体，+soliton:cascade 只能用于 +soliton:entity 字段",
+			agg.Name, field.Name))
+	}
+	if field.Annotations.IsOwner {
+		return nil, errorAt(field.AnnotationPos("cascade"), fmt.Errorf("聚合根 %s 的字段 %s 声明了 +soliton:owner，外键列位于聚合根表中，不支持级联行为",
+			agg.Name, field.Name))
+	}
+	switch cascade {
+	case metadata.CascadeDelete, metadata.CascadeNullify, metadata.CascadeRestrict:
+	default:
+		return nil, errorAt(field.AnnotationPos("cascade"), fmt.Errorf("聚合根 %s 的字段 %s 级联行为 %s 无效，可选值：delete、nullify、restrict",
+			agg.Name, field.Name, cascade))
+	}
+
+	targetName := a.resolveTargetAggregate(field)
+	target := a.registry.Get(targetName)
+	if target == nil {
+		return nil, nil
+	}
+	if target.Context() != agg.Context() {
+		return nil, errorAt(field.AnnotationPos("cascade"), fmt.Errorf("聚合根 %s 的字段 %s 声明了级联行为，但关联实体 %s 位于其他限界上下文",
+			agg.Name, field.Name, target.Name))
+	}
+	if agg.IsCompositeKey() {
+		return nil, errorAt(field.AnnotationPos("cascade"), fmt.Errorf("聚合根 %s 使用复合主键，字段 %s 不支持级联行为", agg.Name, field.Name))
+	}
+
+	_, foreignKey, err := a.inferForeignKey(agg, field)
+	if err != nil {
+		return nil, err
+	}
+	if cascade == metadata.CascadeNullify && !foreignKey.IsPointer {
+		return nil, errorAt(field.AnnotationPos("cascade"), fmt.Errorf("聚合根 %s 的字段 %s 级联行为为 nullify，外键字段 %s.%s 应为指针类型以允许置空",
+			agg.Name, field.Name, target.Name, foreignKey.Name))
+	}
+	return foreignKey, nil
+}
+
+// inferForeignKey 推断关联实体表中引用聚合根的外键列，以及关联实体中对应的字段
+//
+// 按以下顺序确定外键列：
+//  1. 字段声明的 +soliton:fk(column=...)
+//  2. 关联实体中唯一引用聚合根的 +soliton:ref 字段（如 OrderItem.OrderID）的列
+//  3. 聚合根名的蛇形形式加 _id，如 Order → order_id
+//
+// 关联实体未注册时只返回列名；关联实体中没有该列，或有多个引用聚合根的字段且未声明 +soliton:fk 时返回错误。
+func (a *RelationAnalyzer) inferForeignKey(agg *metadata.AggregateMetadata, field *metadata.FieldMetadata) (string, *metadata.FieldMetadata, error) {
+	targetName := a.resolveTargetAggregate(field)
+	column := field.Annotations.ForeignKey
+	if column == "" {
+		backRefs := a.backReferences(agg, targetName)
+		switch len(backRefs) {
+		case 0:
+			column = toSnakeCase(agg.Name) + "_id"
+		case 1:
+			return backRefs[0].Column(), backRefs[0], nil
+		default:
+			names := make([]string, len(backRefs))
+			for i, ref := range backRefs {
+				names[i] = ref.Name
+			}
+			return "", nil, errorAt(field.Pos, fmt.Errorf("聚合根 %s 的字段 %s 关联的 %s 有多个引用它的外键字段：%s，请通过 +soliton:fk(column=...) 指定外键列",
+				agg.Name, field.Name, targetName, strings.Join(names, "、")))
+		}
+	}
+
+	target := a.registry.Get(targetName)
+	if target == nil {
+		return column, nil, nil
+	}
+	for _, candidate := range target.MappedFields() {
+		if candidate.Annotations.IsEntity || candidate.Annotations.IsValueObject {
+			continue
+		}
+		if candidate.Column() == column {
+			return column, candidate, nil
+		}
+	}
+	if field.Annotations.ForeignKey != "" {
+		return "", nil, errorAt(field.AnnotationPos("fk"), fmt.Errorf("聚合根 %s 的字段 %s 通过 +soliton:fk 声明的外键列 %s 在 %s 中不存在",
+			agg.Name, field.Name, column, targetName))
+	}
+	return "", nil, errorAt(field.Pos, fmt.Errorf("聚合根 %s 的字段 %s 关联的 %s 中没有外键列 %s，请添加引用 %s 的 +soliton:ref 字段，或通过 +soliton:fk(column=...) 指定外键列",
+		agg.Name, field.Name, targetName, column, agg.Name))
+}
+
+// inferOwnerForeignKey 推断声明了 +soliton:owner 的一对一关系中，聚合根表引用关联实体的外键列，以及聚合根中对应的字段
+//
+// 按以下顺序确定外键列：
+//  1. 字段声明的 +soliton:fk(column=...)
+//  2. 关联字段名的蛇形形式加 _id，如 Shipment → shipment_id（同一关联实体有多个一对一字段时各自对应）
+//  3. 聚合根中唯一引用关联实体的 +soliton:ref 字段（如 Order.DeliveryID +soliton:ref(Shipment)）的列
+//
+// 聚合根中没有该列，或关联实体使用复合主键时返回错误。
+func (a *RelationAnalyzer) inferOwnerForeignKey(agg *metadata.AggregateMetadata, field *metadata.FieldMetadata) (string, *metadata.FieldMetadata, error) {
+	targetName := a.resolveTargetAggregate(field)
+	target := a.registry.Get(targetName)
+	if target != nil && target.IsCompositeKey() {
+		return "", nil, errorAt(field.AnnotationPos("owner"), fmt.Errorf("聚合根 %s 的字段 %s 声明了 +soliton:owner，但关联实体 %s 使用复合主键，无法由单个外键列引用",
+			agg.Name, field.Name, targetName))
+	}
+
+	column := field.Annotations.ForeignKey
+	if column == "" {
+		column = toSnakeCase(field.Name) + "_id"
+	}
+	for _, candidate := range agg.MappedFields() {
+		if candidate.Annotations.IsEntity || candidate.Annotations.IsValueObject {
+			continue
+		}
+		if candidate.Column() == column {
+			return column, candidate, nil
+		}
+	}
+	if field.Annotations.ForeignKey != "" {
+		return "", nil, errorAt(field.AnnotationPos("fk"), fmt.Errorf("聚合根 %s 的字段 %s 通过 +soliton:fk 声明的外键列 %s 在 %s 中不存在",
+			agg.Name, field.Name, column, agg.Name))
+	}
+
+	if target != nil {
+		if refs := a.backReferences(target, agg.Name); len(refs) == 1 {
+			return refs[0].Column(), refs[0], nil
+		}
+	}
+	return "", nil, errorAt(field.AnnotationPos("owner"), fmt.Errorf("聚合根 %s 的字段 %s 声明了 +soliton:owner，但 %s 中没有外键列 %s，请添加引用 %s 的 +soliton:ref 字段，或通过 +soliton:fk(column=...) 指定外键列",
+		agg.Name, field.Name, agg.Name, column, targetName))
+}
+
+// validateForeignKeys 验证一对多、一对一关系的外键列（见 inferForeignKey、inferOwnerForeignKey）
+//   - +soliton:fk 只能用于一对多的关联实体字段，或声明了 +soliton:cascade、+soliton:owner 的一对一关联实体字段
+//   - +soliton:owner 只能用于一对一的关联实体字段，聚合根中必须存在外键列
+//   - 关联实体中必须存在外键列；声明了 +soliton:cascade 的字段由 ValidateCascadeRelations 报告
+func (a *RelationAnalyzer) validateForeignKeys() []error {
+	var errors []error
+
+	for _, agg := range a.registry.GetAll() {
+		for _, field := range agg.MappedFields() {
+			if field.Annotations.IsOwner {
+				if !field.Annotations.IsEntity || field.IsSlice {
+					errors = append(errors, errorAt(field.AnnotationPos("owner"), fmt.Errorf("聚合根 %s 的字段 %s 不是一对一关联实体，+soliton:owner 只能用于单个对象的 +soliton:entity 字段",
+						agg.Name, field.Name)))
+				} else if _, _, err := a.inferOwnerForeignKey(agg, field); err != nil {
+					errors = append(errors, err)
+				}
+				continue
+			}
+			if field.Annotations.ForeignKey != "" && (!field.Annotations.IsEntity || !field.IsSlice && field.Annotations.Cascade == "") {
+				errors = append(errors, errorAt(field.AnnotationPos("fk"), fmt.Errorf("聚合根 %s 的字段 %s 不是一对多关联实体，+soliton:fk 只能用于一对多或声明了 +soliton:cascade、+soliton:owner 的 +soliton:entity 字段",
+					agg.Name, field.Name)))
+				continue
+			}
+			if !field.Annotations.IsEntity || !field.IsSlice || field.Annotations.Cascade != "" {
+				continue
+			}
+			if _, _, err := a.inferForeignKey(agg, field); err != nil {
+				errors = append(errors, err)
+			}
+		}
+	}
+
+	return errors
+}
+
+// ValidateIndexes 验证聚合根上声明的索引（字段上的 +soliton:unique、+soliton:index 和组合索引）
+//   - 至少包含一个字段，且引用的字段必须存在
+//   - 关联实体字段和忽略字段不存储在表中，不能作为索引字段
+//   - 同一聚合根内索引名不能重复
+func (a *RelationAnalyzer) ValidateIndexes() []error {
+	var errors []error
+
+	for _, agg := range a.registry.GetAll() {
+		names := make(map[string]bool)
+		for _, index := range agg.Indexes {
+			pos := index.Pos
+			if !pos.IsValid() {
+				pos = agg.AnnotationPos("uniqueIndex")
+			}
+
+			if names[index.Name] {
+				errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的索引名 %s 重复", agg.Name, index.Name)))
+			}
+			names[index.Name] = true
+
+			if len(index.Fields) == 0 {
+				errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的索引 %s 未指定字段", agg.Name, index.Name)))
+				continue
+			}
+
+			for _, fieldName := range index.Fields {
+				field := agg.FieldByPath(fieldName)
+				if field == nil {
+					errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的索引 %s 引用了不存在的字段 %s", agg.Name, index.Name, fieldName)))
+					continue
+				}
+				if field.Annotations.IsEntity {
+					errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的索引 %s 不能包含关联实体字段 %s", agg.Name, index.Name, fieldName)))
+				}
+				if field.Annotations.IsIgnored {
+					errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的索引 %s 不能包含忽略字段 %s", agg.Name, index.Name, fieldName)))
+				}
+			}
+		}
+	}
+
+	return errors
+}
+
+// ValidateIDStrategies 验证主键策略与 ID 字段类型是否匹配
+//   - 策略必须是 auto、uuid、snowflake、manual 之一
+//   - uuid 要求 string 主键；auto、snowflake 要求整数主键
+//   - 复合主键见 validateCompositeKey
+func (a *RelationAnalyzer) ValidateIDStrategies() []error {
+	var errors []error
+
+	for _, agg := range a.registry.GetAll() {
+		if agg.IsCompositeKey() {
+			errors = append(errors, a.validateCompositeKey(agg)...)
+			continue
+		}
+		if agg.IDField == nil {
+			continue
+		}
+
+		keyType := agg.IDKeyType()
+		switch agg.IDStrategy {
+		case metadata.IDStrategyAuto, metadata.IDStrategySnowflake:
+			if keyType != "int64" {
+				errors = append(errors, errorAt(agg.IDField.AnnotationPos("id"), fmt.Errorf("聚合根 %s 的主键策略 %s 要求整数类型的 ID 字段，实际为 %s",
+					agg.Name, agg.IDStrategy, agg.IDField.Type)))
+			}
+		case metadata.IDStrategyUUID:
+			if keyType != "string" {
+				errors = append(errors, errorAt(agg.IDField.AnnotationPos("id"), fmt.Errorf("聚合根 %s 的主键策略 uuid 要求 string 类型的 ID 字段，实际为 %s",
+					agg.Name, agg.IDField.Type)))
+			}
+		case metadata.IDStrategyManual:
+		default:
+			errors = append(errors, errorAt(agg.IDField.AnnotationPos("id"), fmt.Errorf("聚合根 %s 的主键策略 %s 无效，可选值：auto、uuid、snowflake、manual",
+				agg.Name, agg.IDStrategy)))
+		}
+	}
+
+	return errors
+}
+
+// validateCompositeKey 验证复合主键
+//   - 不能与 +soliton:id 混用，复合主键只能由调用方设置（manual）
+//   - 组成字段必须是非指针的标量类型，关联实体、值对象和集合不能作为主键
+func (a *RelationAnalyzer) validateCompositeKey(agg *metadata.AggregateMetadata) []error {
+	var errors []error
+
+	for _, field := range agg.MappedFields() {
+		if field.Annotations.IsID {
+			errors = append(errors, errorAt(field.AnnotationPos("id"), fmt.Errorf("聚合根 %s 使用 +soliton:pk 声明了复合主键，字段 %s 不能同时标记 +soliton:id",
+				agg.Name, field.Name)))
+		}
+	}
+
+	for _, field := range agg.PrimaryKey {
+		if field.IsPointer || field.IsSlice || field.IsMap || field.IsArray ||
+			field.Annotations.IsEntity || field.Annotations.IsValueObject {
+			errors = append(errors, errorAt(field.Pos, fmt.Errorf("聚合根 %s 的字段 %s 类型为 %s，复合主键字段只支持非指针的标量类型",
+				agg.Name, field.Name, field.GoType())))
+		}
+	}
+
+	return errors
+}
+
+// ValidateFieldRules 验证字段校验规则注解的有效性
+//   - validate(min,max) 只能用于数值字段，length、pattern、email 只能用于字符串字段
+//   - 整数字段的范围边界必须是整数，最小值不能大于最大值，长度不能为负
+//   - pattern 必须是合法的正则表达式
+//
+// 未启用类型解析时无法确定命名类型的底层类型，这类字段不做类型检查。
+func (a *RelationAnalyzer) ValidateFieldRules() []error {
+	var errors []error
+
+	for _, agg := range a.registry.GetAll() {
+		for _, field := range agg.MappedFields() {
+			rules := field.Annotations.Validation
+			if rules == nil {
+				continue
+			}
+
+			kind := ruleKindOf(field)
+			if (rules.Min != nil || rules.Max != nil) && kind != ruleKindNumber && kind != ruleKindUnknown {
+				errors = append(errors, errorAt(field.AnnotationPos("validate"), fmt.Errorf("聚合根 %s 的字段 %s 类型为 %s，不能使用 validate(min/max) 校验",
+					agg.Name, field.Name, field.Type)))
+			}
+			if (rules.MinLength != nil || rules.MaxLength != nil || rules.Pattern != "" || rules.IsEmail) &&
+				kind != ruleKindString && kind != ruleKindUnknown {
+				errors = append(errors, errorAt(field.Pos, fmt.Errorf("聚合根 %s 的字段 %s 类型为 %s，不能使用 length、pattern、email 校验",
+					agg.Name, field.Name, field.Type)))
+			}
+
+			if kind == ruleKindNumber && !strings.HasPrefix(field.BasicType(), "float") {
+				for _, bound := range []*float64{rules.Min, rules.Max} {
+					if bound != nil && *bound != math.Trunc(*bound) {
+						errors = append(errors, errorAt(field.AnnotationPos("validate"), fmt.Errorf("聚合根 %s 的字段 %s 为整数类型，范围边界 %v 必须是整数",
+							agg.Name, field.Name, *bound)))
+					}
+				}
+			}
+			if rules.Min != nil && rules.Max != nil && *rules.Min > *rules.Max {
+				errors = append(errors, errorAt(field.AnnotationPos("validate"), fmt.Errorf("聚合根 %s 的字段 %s 最小值 %v 大于最大值 %v",
+					agg.Name, field.Name, *rules.Min, *rules.Max)))
+			}
+			if (rules.MinLength != nil && *rules.MinLength < 0) || (rules.MaxLength != nil && *rules.MaxLength < 0) {
+				errors = append(errors, errorAt(field.AnnotationPos("length"), fmt.Errorf("聚合根 %s 的字段 %s 长度限制不能为负数", agg.Name, field.Name)))
+			}
+			if rules.MinLength != nil && rules.MaxLength != nil && *rules.MinLength > *rules.MaxLength {
+				errors = append(errors, errorAt(field.AnnotationPos("length"), fmt.Errorf("聚合根 %s 的字段 %s 最小长度 %d 大于最大长度 %d",
+					agg.Name, field.Name, *rules.MinLength, *rules.MaxLength)))
+			}
+			if rules.Pattern != "" {
+				if _, err := regexp.Compile(rules.Pattern); err != nil {
+					errors = append(errors, errorAt(field.AnnotationPos("pattern"), fmt.Errorf("聚合根 %s 的字段 %s 正则表达式无效: %w", agg.Name, field.Name, err)))
+				}
+			}
+		}
+	}
+
+	return errors
+}
+
+// ValidateFieldTypes 验证字段类型能否映射到数据库列
+//   - map 和定长数组字段需要声明 +soliton:valueObject（自动使用 JSON 策略）序列化存储
+func (a *RelationAnalyzer) ValidateFieldTypes() []error {
+	var errors []error
+
+	for _, agg := range a.registry.GetAll() {
+		for _, field := range agg.MappedFields() {
+			if !field.IsMap && !field.IsArray {
+				continue
+			}
+			if field.Annotations.IsEntity || (field.Annotations.IsValueObject && field.Annotations.Strategy == "json") {
+				continue
+			}
+			errors = append(errors, errorAt(field.Pos, fmt.Errorf("聚合根 %s 的字段 %s 类型为 %s，无法直接映射为数据库列，请添加 +soliton:valueObject 以 JSON 存储",
+				agg.Name, field.Name, field.GoType())))
+		}
+	}
+
+	return errors
+}
+
+// annotationConflicts 字段注解兼容性矩阵：同一字段上不能同时声明的注解及原因
+var annotationConflicts = []struct {
+	first, second string
+	reason        string
+}{
+	{"entity", "ref", "关联实体按对象保存在聚合内，外部引用只保存目标的 ID"},
+	{"entity", "valueObject", "关联实体有独立的表和标识，值对象保存在聚合根的列中"},
+	{"entity", "polymorphic", "多态关联只保存目标的 ID 和类型"},
+	{"entity", "id", "主键必须是聚合根自身的列"},
+	{"ref", "valueObject", "外部引用只保存目标的 ID，值对象保存完整的值"},
+	{"ref", "polymorphic", "多态关联的目标由类型字段决定，不能同时固定引用一个聚合根"},
+	{"valueObject", "polymorphic", "多态关联只保存目标的 ID 和类型"},
+	{"valueObject", "id", "主键必须是单列的基础类型"},
+	{"polymorphic", "id", "主键必须是聚合根自身的标识"},
+	{"ignore", "entity", "忽略的字段不参与映射，其他注解不会生效"},
+	{"ignore", "ref", "忽略的字段不参与映射，其他注解不会生效"},
+	{"ignore", "valueObject", "忽略的字段不参与映射，其他注解不会生效"},
+	{"ignore", "polymorphic", "忽略的字段不参与映射，其他注解不会生效"},
+	{"ignore", "id", "忽略的字段不参与映射，其他注解不会生效"},
+}
+
+// fieldRoles 返回字段声明的、参与兼容性检查的注解
+func fieldRoles(field *metadata.FieldMetadata) map[string]bool {
+	return map[string]bool{
+		"entity":      field.Annotations.IsEntity,
+		"ref":         field.Annotations.IsRef,
+		"valueObject": field.Annotations.IsValueObject,
+		"polymorphic": field.IsPolymorphic(),
+		"id":          field.Annotations.IsID || field.Annotations.IsPK,
+		"ignore":      field.Annotations.IsIgnored,
+	}
+}
+
+// ValidateAnnotationConflicts 验证字段注解之间以及注解与字段类型是否兼容，错误信息带字段的源码位置
+//   - 同一字段不能同时声明互斥的注解，见 annotationConflicts（如 +soliton:entity 与 +soliton:ref）
+//   - +soliton:valueObject 的类型不能是已注册的聚合根（含聚合根切片），应使用 +soliton:entity 或 +soliton:ref
+//   - +soliton:entity 的类型必须是结构体（或其切片），不能是基础类型和已知标量类型
+//   - +soliton:ref 字段必须是基础类型或已知标量类型的单个 ID，不能是切片、map 或数组（多态关联字段的类型由 ValidatePolymorphicRelations 检查）
+//
+// 存在互斥注解的字段不再做类型检查，避免同一问题重复报告。
+func (a *RelationAnalyzer) ValidateAnnotationConflicts() []error {
+	var errors []error
+
+	for _, agg := range a.registry.GetAll() {
+		for _, field := range agg.Fields {
+			report := func(format string, args ...any) {
+				message := fmt.Sprintf("聚合根 %s 的字段 %s ", agg.Name, field.Name) + fmt.Sprintf(format, args...)
+				errors = append(errors, errorAt(field.Pos, fmt.Errorf("%s", message)))
+			}
+
+			roles := fieldRoles(field)
+			conflicted := false
+			for _, conflict := range annotationConflicts {
+				if roles[conflict.first] && roles[conflict.second] {
+					report("同时声明了 +soliton:%s 和 +soliton:%s，两者不能同时使用（%s）", conflict.first, conflict.second, conflict.reason)
+					conflicted = true
+				}
+			}
+			if conflicted || field.Annotations.IsIgnored {
+				continue
+			}
+
+			basic := a.isBasicType(field.BasicType()) || field.ScalarType != nil
+			switch {
+			case field.Annotations.IsValueObject && !field.IsMap && a.registry.Exists(a.resolveTargetAggregate(field)):
+				report("的类型 %s 是聚合根，不能声明为 +soliton:valueObject，聚合内的关联实体请使用 +soliton:entity，其他聚合根请使用 +soliton:ref 引用其 ID",
+					field.GoType())
+			case field.Annotations.IsEntity && (basic || field.IsMap):
+				report("的类型 %s 不是结构体，不能声明为 +soliton:entity", field.GoType())
+			case field.Annotations.IsRef && (field.IsSlice || field.IsMap || field.IsArray):
+				report("的类型 %s 是集合，+soliton:ref 只能用于保存单个目标 ID 的字段，引用多个聚合根请在聚合根上声明 +soliton:ref 生成多对多关联",
+					field.GoType())
+			case field.Annotations.IsRef && !basic:
+				report("的类型 %s 不是 ID 类型，+soliton:ref 只能用于保存目标 ID 的基础类型字段", field.GoType())
+			case field.Annotations.IsExternal && !field.Annotations.IsRef:
+				report("声明了 +soliton:external，但不是外部引用，+soliton:external 只能与 +soliton:ref 一起使用")
+			}
+		}
+	}
+
+	return errors
+}
+
+// ValidateValueObjects 验证值对象的存储策略
+//   - 策略只支持 json 和 flatten
+//   - flatten 只能用于能找到定义的结构体类型，展开后的字段只能是普通列，不能再包含值对象、关联实体或集合
+//   - 展开后的列名不能与聚合根的其他列重复
+func (a *RelationAnalyzer) ValidateValueObjects() []error {
+	var errors []error
+
+	for _, agg := range a.registry.GetAll() {
+		columns := make(map[string]string)
+		for _, field := range agg.MappedFields() {
+			if !field.Annotations.IsEntity && field.Annotations.Strategy != metadata.ValueObjectFlatten {
+				columns[field.Column()] = field.Name
+			}
+		}
+
+		for _, field := range agg.MappedFields() {
+			if !field.Annotations.IsValueObject {
+				continue
+			}
+
+			switch field.Annotations.Strategy {
+			case "", metadata.ValueObjectJSON:
+				continue
+			case metadata.ValueObjectFlatten:
+			default:
+				errors = append(errors, errorAt(field.AnnotationPos("valueObject"), fmt.Errorf("聚合根 %s 的字段 %s 值对象策略 %s 无效，只支持 json、flatten",
+					agg.Name, field.Name, field.Annotations.Strategy)))
+				continue
+			}
+
+			// map 和定长数组由 ValidateFieldTypes 报告
+			if field.IsMap || field.IsArray {
+				continue
+			}
+			if field.IsSlice {
+				errors = append(errors, errorAt(field.AnnotationPos("valueObject"), fmt.Errorf("聚合根 %s 的字段 %s 类型为 %s，只有结构体类型的值对象可以展开，请使用 json 策略",
+					agg.Name, field.Name, field.GoType())))
+				continue
+			}
+			if len(field.Flattened) == 0 {
+				errors = append(errors, errorAt(field.AnnotationPos("valueObject"), fmt.Errorf("聚合根 %s 的字段 %s 找不到值对象 %s 的结构体定义或结构体没有字段，无法展开",
+					agg.Name, field.Name, field.Type)))
+				continue
+			}
+
+			for _, sub := range field.Flattened {
+				if sub.Annotations.IsValueObject || sub.Annotations.IsEntity || sub.IsMap || sub.IsArray ||
+					(sub.IsSlice && sub.Type != "byte") {
+					errors = append(errors, errorAt(field.AnnotationPos("valueObject"), fmt.Errorf("聚合根 %s 的字段 %s 展开后的字段 %s 类型为 %s，无法映射为单列",
+						agg.Name, field.Name, sub.Name, sub.GoType())))
+					continue
+				}
+				if sub.Annotations.IsRef || sub.Annotations.IsID {
+					errors = append(errors, errorAt(field.AnnotationPos("valueObject"), fmt.Errorf("聚合根 %s 的字段 %s 展开后的字段 %s 不能声明为主键或外键",
+						agg.Name, field.Name, sub.Name)))
+					continue
+				}
+				if owner, ok := columns[sub.Column()]; ok {
+					errors = append(errors, errorAt(field.AnnotationPos("valueObject"), fmt.Errorf("聚合根 %s 的字段 %s 展开后的列 %s 与字段 %s 重复",
+						agg.Name, field.Name, sub.Column(), owner)))
+					continue
+				}
+				columns[sub.Column()] = field.Name + "." + sub.Name
+			}
+		}
+	}
+
+	return errors
+}
+
+// ValidateDefaults 验证默认值注解的有效性
+//   - 主键、关联实体、值对象以及切片、map、定长数组字段不能声明默认值
+//   - now() 只能用于 time.Time 字段，time.Time 字段也只支持 now()
+//   - 数值字段的默认值必须是数字（整数字段必须是整数），布尔字段必须是 true 或 false
+//   - 默认值需满足枚举和范围、长度校验规则，保证新建的聚合根处于有效状态
+//
+// 未启用类型解析时无法确定命名类型的底层类型，这类字段只检查枚举值。
+func (a *RelationAnalyzer) ValidateDefaults() []error {
+	var errors []error
+
+	for _, agg := range a.registry.GetAll() {
+		for _, field := range agg.MappedFields() {
+			value := field.Annotations.Default
+			if value == "" {
+				continue
+			}
+
+			if agg.InPrimaryKey(field) || field.Annotations.IsEntity || field.Annotations.IsValueObject ||
+				field.IsSlice || field.IsMap || field.IsArray {
+				errors = append(errors, errorAt(field.AnnotationPos("default"), fmt.Errorf("聚合根 %s 的字段 %s 类型为 %s，不能声明默认值",
+					agg.Name, field.Name, field.GoType())))
+				continue
+			}
+
+			basicType := field.BasicType()
+			if value == metadata.DefaultNow || basicType == "time.Time" {
+				if value != metadata.DefaultNow || basicType != "time.Time" {
+					errors = append(errors, errorAt(field.AnnotationPos("default"), fmt.Errorf("聚合根 %s 的字段 %s 默认值 %s 无效，time.Time 字段只支持 now()，now() 也只能用于 time.Time 字段",
+						agg.Name, field.Name, value)))
+				}
+				continue
+			}
+
+			if len(field.Annotations.EnumValues) > 0 && !slices.Contains(field.Annotations.EnumValues, value) {
+				message := fmt.Sprintf("聚合根 %s 的字段 %s 默认值 %s 不在枚举值 %v 中", agg.Name, field.Name, value, field.Annotations.EnumValues)
+				if i := slices.Index(field.Annotations.EnumNames, value); i >= 0 {
+					message += fmt.Sprintf("，整数枚举的默认值需使用编码 %s", field.Annotations.EnumValues[i])
+				}
+				errors = append(errors, errorAt(field.AnnotationPos("default"), fmt.Errorf("%s", message)))
+			}
+
+			rules := field.Annotations.Validation
+			switch kind := ruleKindOf(field); {
+			case basicType == "bool":
+				if value != "true" && value != "false" {
+					errors = append(errors, errorAt(field.AnnotationPos("default"), fmt.Errorf("聚合根 %s 的字段 %s 为布尔类型，默认值 %s 必须是 true 或 false",
+						agg.Name, field.Name, value)))
+				}
+			case kind == ruleKindNumber:
+				number, err := strconv.ParseFloat(value, 64)
+				if err != nil || math.IsInf(number, 0) || math.IsNaN(number) ||
+					(!strings.HasPrefix(basicType, "float") && number != math.Trunc(number)) {
+					errors = append(errors, errorAt(field.AnnotationPos("default"), fmt.Errorf("聚合根 %s 的字段 %s 类型为 %s，默认值 %s 无效",
+						agg.Name, field.Name, field.Type, value)))
+					continue
+				}
+				if rules != nil && ((rules.Min != nil && number < *rules.Min) || (rules.Max != nil && number > *rules.Max)) {
+					errors = append(errors, errorAt(field.AnnotationPos("default"), fmt.Errorf("聚合根 %s 的字段 %s 默认值 %s 超出校验范围",
+						agg.Name, field.Name, value)))
+				}
+			case kind == ruleKindString:
+				length := utf8.RuneCountInString(value)
+				if rules != nil && ((rules.MinLength != nil && length < *rules.MinLength) || (rules.MaxLength != nil && length > *rules.MaxLength)) {
+					errors = append(errors, errorAt(field.AnnotationPos("default"), fmt.Errorf("聚合根 %s 的字段 %s 默认值 %s 不满足长度校验",
+						agg.Name, field.Name, value)))
+				}
+			case kind == ruleKindOther:
+				errors = append(errors, errorAt(field.AnnotationPos("default"), fmt.Errorf("聚合根 %s 的字段 %s 类型为 %s，不支持声明默认值",
+					agg.Name, field.Name, field.Type)))
+			}
+		}
+	}
+
+	return errors
+}
+
+// ValidateImmutableFields 验证不可变字段（+soliton:immutable）
+// 不可变字段只在创建时写入，不能用于主键、忽略字段、关联实体，以及每次更新都要写入的审计字段
+func (a *RelationAnalyzer) ValidateImmutableFields() []error {
+	var errors []error
+
+	for _, agg := range a.registry.GetAll() {
+		// 更新时由框架写入的字段
+		updated := make(map[*metadata.FieldMetadata]bool)
+		if base := agg.BaseEntity; base != nil {
+			for _, field := range []*metadata.FieldMetadata{base.UpdatedAtField, base.UpdatedByField, base.VersionField, base.DeletedAtField} {
+				if field != nil {
+					updated[field] = true
+				}
+			}
+		}
+
+		for _, field := range agg.Fields {
+			if !field.Annotations.IsImmutable {
+				continue
+			}
+
+			switch {
+			case field.Annotations.IsIgnored:
+				errors = append(errors, errorAt(field.AnnotationPos("immutable"), fmt.Errorf("聚合根 %s 的字段 %s 已标记为忽略，不能同时声明为不可变",
+					agg.Name, field.Name)))
+			case agg.InPrimaryKey(field):
+				errors = append(errors, errorAt(field.AnnotationPos("immutable"), fmt.Errorf("聚合根 %s 的字段 %s 是主键，主键本身不会被更新，无需声明为不可变",
+					agg.Name, field.Name)))
+			case field.Annotations.IsEntity:
+				errors = append(errors, errorAt(field.AnnotationPos("immutable"), fmt.Errorf("聚合根 %s 的字段 %s 是关联实体，不映射为列，不能声明为不可变",
+					agg.Name, field.Name)))
+			case updated[field]:
+				errors = append(errors, errorAt(field.AnnotationPos("immutable"), fmt.Errorf("聚合根 %s 的字段 %s 在更新时由框架写入，不能声明为不可变",
+					agg.Name, field.Name)))
+			}
+		}
+	}
+
+	return errors
+}
+
+// ValidateFilterFields 验证查询条件字段（+soliton:filter）
+// 查询条件字段需映射为可按值比较的单列（见 FieldMetadata.Filterable），不能是单列主键和软删除字段；
+// 参数只支持 sort，声明 sort 的字段的值需能比较大小（见 FieldMetadata.Ordered）
+func (a *RelationAnalyzer) ValidateFilterFields() []error {
+	var errors []error
+
+	for _, agg := range a.registry.GetAll() {
+		for _, field := range agg.Fields {
+			node := field.Annotations.Nodes.Get("filter")
+			if node == nil {
+				continue
+			}
+			pos := field.AnnotationPos("filter")
+
+			for _, arg := range node.Args {
+				if arg.Key != "" || arg.Value != "sort" {
+					errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的字段 %s 的 +soliton:filter 参数 %s 无效，只支持 sort",
+						agg.Name, field.Name, node.Raw)))
+					break
+				}
+			}
+
+			switch {
+			case field.Annotations.IsIgnored:
+				errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的字段 %s 已标记为忽略，不能同时声明为查询条件",
+					agg.Name, field.Name)))
+			case field == agg.IDField:
+				errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的字段 %s 是主键，由 FindByID、FindByIDs 查询并且总是可以排序，无需声明为查询条件",
+					agg.Name, field.Name)))
+			case agg.BaseEntity != nil && field == agg.BaseEntity.DeletedAtField:
+				errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的字段 %s 是软删除字段，查询时自动排除已删除的记录，不能声明为查询条件",
+					agg.Name, field.Name)))
+			case field.Annotations.Sensitive != "":
+				errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的字段 %s 是敏感字段，保存的是密文或脱敏值，不能声明为查询条件",
+					agg.Name, field.Name)))
+			case !field.Filterable():
+				errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的字段 %s 类型为 %s，不映射为单列，不能声明为查询条件",
+					agg.Name, field.Name, field.GoType())))
+			case field.Annotations.IsSortable && !field.Ordered():
+				errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的字段 %s 类型为 %s，值不能比较大小，不能声明为排序字段",
+					agg.Name, field.Name, field.GoType())))
+			}
+		}
+	}
+
+	return errors
+}
+
+// ValidateSensitiveFields 验证敏感字段（+soliton:sensitive）
+// 敏感字段只支持字符串类型；存储的是密文或脱敏值，因此不能作为主键、索引、外键，也不能声明默认值
+func (a *RelationAnalyzer) ValidateSensitiveFields() []error {
+	var errors []error
+
+	for _, agg := range a.registry.GetWithFieldAnnotation("sensitive") {
+		for _, field := range agg.MappedFields() {
+			strategy := field.Annotations.Sensitive
+			if strategy == "" {
+				continue
+			}
+
+			if strategy != metadata.SensitiveAES && strategy != metadata.SensitiveMask {
+				errors = append(errors, errorAt(field.AnnotationPos("sensitive"), fmt.Errorf("聚合根 %s 的字段 %s 敏感字段策略 %s 无效，只支持 aes、mask",
+					agg.Name, field.Name, strategy)))
+			}
+			if field.BasicType() != "string" || field.IsSlice || field.IsMap || field.IsArray ||
+				field.Annotations.IsEntity || field.Annotations.IsValueObject {
+				errors = append(errors, errorAt(field.AnnotationPos("sensitive"), fmt.Errorf("聚合根 %s 的字段 %s 类型为 %s，敏感字段只支持字符串类型",
+					agg.Name, field.Name, field.GoType())))
+				continue
+			}
+			if agg.InPrimaryKey(field) || field.Annotations.IsRef || len(agg.IndexesOn(field.Name)) > 0 {
+				errors = append(errors, errorAt(field.AnnotationPos("sensitive"), fmt.Errorf("聚合根 %s 的字段 %s 是敏感字段，不能作为主键、索引或外键",
+					agg.Name, field.Name)))
+			}
+			if field.Annotations.Default != "" {
+				errors = append(errors, errorAt(field.AnnotationPos("sensitive"), fmt.Errorf("聚合根 %s 的字段 %s 是敏感字段，不能声明默认值",
+					agg.Name, field.Name)))
+			}
+		}
+	}
+
+	return errors
+}
+
+// ValidateEnums 验证枚举注解（+soliton:enum）
+//   - 枚举值（整数枚举为编码）和名称不能重复
+//   - 声明了编码的枚举需为每个值声明整数编码和名称，如 +soliton:enum(1=ACTIVE,2=BANNED)
+//   - 整数编码只能用于整数字段，整数字段的枚举也必须声明编码；未解析底层类型的命名类型不检查
+//
+// const 块定义的枚举由编译器保证有效，不在这里检查。
+func (a *RelationAnalyzer) ValidateEnums() []error {
+	var errors []error
+
+	for _, agg := range a.registry.GetWithFieldAnnotation("enum") {
+		for _, field := range agg.MappedFields() {
+			annotations := field.Annotations
+			if len(annotations.EnumValues) == 0 || annotations.EnumType != "" {
+				continue
+			}
+			pos := field.AnnotationPos("enum")
+
+			seenValues, seenNames := make(map[string]bool), make(map[string]bool)
+			for i, value := range annotations.EnumValues {
+				if seenValues[value] {
+					errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的字段 %s 枚举值 %s 重复",
+						agg.Name, field.Name, value)))
+				}
+				seenValues[value] = true
+
+				if !annotations.IsIntEnum() {
+					continue
+				}
+				name := annotations.EnumNames[i]
+				if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+					errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的字段 %s 枚举值 %s 缺少整数编码，声明了编码的枚举需为每个值声明编码，如 1=ACTIVE",
+						agg.Name, field.Name, value)))
+					continue
+				}
+				if name == "" {
+					errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的字段 %s 枚举编码 %s 缺少名称，格式为 编码=名称，如 1=ACTIVE",
+						agg.Name, field.Name, value)))
+					continue
+				}
+				if seenNames[name] {
+					errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的字段 %s 枚举名称 %s 重复",
+						agg.Name, field.Name, name)))
+				}
+				seenNames[name] = true
+			}
+
+			kind, basicType := ruleKindOf(field), field.BasicType()
+			isInteger := kind == ruleKindNumber && !strings.HasPrefix(basicType, "float") && basicType != "time.Duration"
+			switch {
+			case annotations.IsIntEnum() && !isInteger && kind != ruleKindUnknown:
+				errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的字段 %s 类型为 %s，带整数编码的枚举只能用于整数字段",
+					agg.Name, field.Name, field.GoType())))
+			case !annotations.IsIntEnum() && isInteger:
+				errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的字段 %s 类型为 %s，整数字段的枚举需声明编码，如 +soliton:enum(1=ACTIVE,2=BANNED)",
+					agg.Name, field.Name, field.GoType())))
+			case !annotations.IsIntEnum() && kind != ruleKindString && kind != ruleKindUnknown:
+				errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的字段 %s 类型为 %s，枚举只支持字符串和整数字段",
+					agg.Name, field.Name, field.GoType())))
+			}
+		}
+	}
+
+	return errors
+}
+
+// ValidateEvents 验证领域事件（+soliton:event），需在 CollectEvents 之后调用
+//   - 事件名必须是导出的标识符，且不能与聚合根同名
+//   - 专用结构体需通过 aggregate= 声明发布事件的聚合根，且聚合根必须存在
+//   - 事件名和消息主题在整个模型中唯一
+func (a *RelationAnalyzer) ValidateEvents() []error {
+	var errors []error
+
+	names := make(map[string]*metadata.EventMetadata)
+	topics := make(map[string]*metadata.EventMetadata)
+	for _, event := range a.registry.GetEvents() {
+		if !token.IsIdentifier(event.Name) || !token.IsExported(event.Name) {
+			errors = append(errors, errorAt(event.Pos, fmt.Errorf("聚合根 %s 的领域事件 %s 无效，事件名必须是导出的标识符，如 OrderPlaced",
+				event.AggregateName, event.Name)))
+			continue
+		}
+		if a.registry.Exists(event.Name) {
+			errors = append(errors, errorAt(event.Pos, fmt.Errorf("领域事件 %s 与聚合根同名", event.Name)))
+		}
+
+		switch {
+		case event.AggregateName == "":
+			errors = append(errors, errorAt(event.Pos, fmt.Errorf("领域事件 %s 未声明所属聚合根，格式为 +soliton:event(aggregate=Order)",
+				event.Name)))
+		case !a.registry.Exists(event.AggregateName):
+			errors = append(errors, errorAt(event.Pos, fmt.Errorf("领域事件 %s 所属的聚合根 %s 不存在",
+				event.Name, event.AggregateName)))
+		}
+
+		if other, ok := names[event.Name]; ok {
+			errors = append(errors, errorAt(event.Pos, fmt.Errorf("领域事件 %s 重复，已由聚合根 %s 声明",
+				event.Name, other.AggregateName)))
+			continue
+		}
+		names[event.Name] = event
+
+		if other, ok := topics[event.Topic()]; ok {
+			errors = append(errors, errorAt(event.Pos, fmt.Errorf("领域事件 %s 的消息主题 %s 与事件 %s 重复",
+				event.Name, event.Topic(), other.Name)))
+			continue
+		}
+		topics[event.Topic()] = event
+	}
+
+	return errors
+}
+
+// ValidateAPIs 验证 API 暴露注解（+soliton:api）
+//   - 协议只支持 rest、grpc、graphql，操作只支持 create、get、list、update、delete
+//   - 路径必须以 / 开头，通过 REST 暴露的聚合根路径不能重复
+//   - 聚合内的关联实体（+soliton:entity）只能通过聚合根访问，不能单独暴露
+//   - ops 和 exclude 同时声明时至少要保留一个操作
+func (a *RelationAnalyzer) ValidateAPIs() []error {
+	var errors []error
+
+	paths := make(map[string]string)
+	for _, agg := range a.registry.GetWithAnnotation("api") {
+		api := agg.API
+		pos := agg.AnnotationPos("api")
+
+		for _, protocol := range api.Protocols {
+			if !slices.Contains(metadata.APIProtocols, protocol) {
+				errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的 API 协议 %s 无效，只支持 %s",
+					agg.Name, protocol, strings.Join(metadata.APIProtocols, "、"))))
+			}
+		}
+		for _, op := range slices.Concat(api.Ops, api.Exclude) {
+			if !slices.Contains(metadata.APIOps, op) {
+				errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的 API 操作 %s 无效，只支持 %s",
+					agg.Name, op, strings.Join(metadata.APIOps, "、"))))
+			}
+		}
+		if len(api.Operations()) == 0 {
+			errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的 API 没有启用任何操作", agg.Name)))
+		}
+		if agg.Annotations.IsEntity {
+			errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 是聚合内的关联实体，不能单独暴露 API", agg.Name)))
+		}
+
+		if api.Path != "" && !strings.HasPrefix(api.Path, "/") {
+			errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的 API 路径 %s 无效，必须以 / 开头", agg.Name, api.Path)))
+			continue
+		}
+		if !api.Exposes(metadata.APIProtocolREST) {
+			continue
+		}
+		if other, ok := paths[agg.APIPath()]; ok {
+			errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的 API 路径 %s 与聚合根 %s 重复",
+				agg.Name, agg.APIPath(), other)))
+			continue
+		}
+		paths[agg.APIPath()] = agg.Name
+	}
+
+	return errors
+}
+
+// ValidateQueries 验证查询注解（+soliton:query）
+//   - 查询名必须是导出的 Go 标识符，同一限界上下文内不能重复（读模型按上下文生成到同一个包中）
+//   - by、select、orderBy 引用的字段必须存在，且不能是关联实体字段或忽略字段
+//   - 条件和排序字段必须对应单独的列：展开的值对象应写作 Address.City，JSON 值对象和加密字段不能用于比较和排序
+//   - 条件字段不能重复，排序方向只能是 asc、desc
+func (a *RelationAnalyzer) ValidateQueries() []error {
+	var errors []error
+
+	// 各限界上下文中已声明的查询名 → 声明查询的聚合根
+	declared := make(map[string]map[string]string)
+	for _, agg := range a.registry.GetWithAnnotation("query") {
+		names := declared[agg.Context()]
+		if names == nil {
+			names = make(map[string]string)
+			declared[agg.Context()] = names
+		}
+		for _, query := range agg.Queries {
+			pos := query.Pos
+			if !pos.IsValid() {
+				pos = agg.AnnotationPos("query")
+			}
+
+			if !token.IsIdentifier(query.Name) || !token.IsExported(query.Name) {
+				errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的查询名 %q 无效，必须是导出的 Go 标识符，如 ListActiveOrders", agg.Name, query.Name)))
+			} else if owner, ok := names[query.Name]; ok && owner == agg.Name {
+				errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的查询 %s 重复", agg.Name, query.Name)))
+			} else if ok {
+				errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的查询 %s 与同一限界上下文中聚合根 %s 的查询重名，读模型生成到同一个包中", agg.Name, query.Name, owner)))
+			} else {
+				names[query.Name] = agg.Name
+			}
+
+			seen := make(map[string]bool)
+			for _, fieldName := range query.By {
+				if seen[fieldName] {
+					errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的查询 %s 的条件字段 %s 重复", agg.Name, query.Name, fieldName)))
+				}
+				seen[fieldName] = true
+				errors = append(errors, a.validateQueryField(agg, query, pos, fieldName, "条件", true)...)
+			}
+			for _, fieldName := range query.Select {
+				errors = append(errors, a.validateQueryField(agg, query, pos, fieldName, "投影", false)...)
+			}
+			for i, order := range query.Orders() {
+				if strings.Contains(order.Field, " ") {
+					errors = append(errors, errorAt(pos, fmt.Errorf("聚合根 %s 的查询 %s 的排序 %q 无效，方向只支持 asc、desc", agg.Name, query.Name, query.OrderBy[i])))
+					continue
+				}
+				errors = append(errors, a.validateQueryField(agg, query, pos, order.Field, "排序", true)...)
+			}
+		}
+	}
+
+	return errors
+}
+
+// validateQueryField 验证查询引用的字段，role 为字段的用途（条件、投影、排序），compared 表示字段用于比较或排序
+func (a *RelationAnalyzer) validateQueryField(agg *metadata.AggregateMetadata, query *metadata.QueryMetadata, pos token.Position, fieldName, role string, compared bool) []error {
+	field := agg.FieldByPath(fieldName)
+	switch {
+	case field == nil:
+		return []error{errorAt(pos, fmt.Errorf("聚合根 %s 的查询 %s 的%s字段 %s 不存在", agg.Name, query.Name, role, fieldName))}
+	case field.Annotations.IsEntity:
+		return []error{errorAt(pos, fmt.Errorf("聚合根 %s 的查询 %s 不能以关联实体字段 %s 作为%s字段", agg.Name, query.Name, fieldName, role))}
+	case field.Annotations.IsIgnored:
+		return []error{errorAt(pos, fmt.Errorf("聚合根 %s 的查询 %s 不能以忽略字段 %s 作为%s字段", agg.Name, query.Name, fieldName, role))}
+	case !compared:
+		return nil
+	case field.Annotations.IsValueObject && field.Annotations.Strategy == metadata.ValueObjectFlatten:
+		return []error{errorAt(pos, fmt.Errorf("聚合根 %s 的查询 %s 的%s字段 %s 是展开的值对象，请使用其中的字段，如 %s.%s",
+			agg.Name, query.Name, role, fieldName, fieldName, firstFlattened(field)))}
+	case field.Annotations.IsValueObject:
+		return []error{errorAt(pos, fmt.Errorf("聚合根 %s 的查询 %s 的%s字段 %s 是按 JSON 存储的值对象，不能用于比较和排序", agg.Name, query.Name, role, fieldName))}
+	case field.Annotations.Sensitive == metadata.SensitiveAES:
+		return []error{errorAt(pos, fmt.Errorf("聚合根 %s 的查询 %s 的%s字段 %s 是加密字段，不能用于比较和排序", agg.Name, query.Name, role, fieldName))}
+	}
+	return nil
+}
+
+// firstFlattened 返回展开的值对象的第一个字段名，用于提示
+func firstFlattened(field *metadata.FieldMetadata) string {
+	if len(field.Flattened) > 0 {
+		return field.Flattened[0].Name
+	}
+	return "Field"
+}
+
+// ruleKind 校验规则适用的字段类别
+type ruleKind int
+
+const (
+	ruleKindUnknown ruleKind = iota // 未解析的命名类型
+	ruleKindNumber                  // 整数、浮点数
+	ruleKindString                  // 字符串
+	ruleKindOther                   // 其他类型（切片、布尔、时间等）
+)
+
+// ruleKindOf 判断字段适用的校验类别
+func ruleKindOf(field *metadata.FieldMetadata) ruleKind {
+	if field.IsSlice || field.IsArray || field.IsMap {
+		return ruleKindOther
+	}
+
+	switch field.BasicType() {
+	case "int", "int8", "int16", "int32", "int64",
+		"uint", "uint8", "uint16", "uint32", "uint64", "byte", "rune",
+		"float32", "float64", "time.Duration":
+		return ruleKindNumber
+	case "string":
+		return ruleKindString
+	case "bool", "complex64", "complex128", "any", "unknown", "time.Time":
+		return ruleKindOther
+	}
+	return ruleKindUnknown
+}
+
+// errorAt 在错误信息前加上源码位置（file:line:column），位置未知（如从模型定义文件或 JSON 加载的元数据）时原样返回
+func errorAt(pos token.Position, err error) error {
+	if !pos.IsValid() {
+		return err
+	}
+	return fmt.Errorf("%s: %w", pos, err)
+}
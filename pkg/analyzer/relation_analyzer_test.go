@@ -0,0 +1,41 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"soliton/pkg/metadata"
+)
+
+func TestValidateAnnotationConflictsRejectsCollectionRef(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   *metadata.FieldMetadata
+		wantErr bool
+	}{
+		{"单个 ID", &metadata.FieldMetadata{Name: "UserID", Type: "int64"}, false},
+		{"可空 ID", &metadata.FieldMetadata{Name: "UserID", Type: "int64", IsPointer: true}, false},
+		{"切片", &metadata.FieldMetadata{Name: "UserIDs", Type: "int64", IsSlice: true}, true},
+		{"定长数组", &metadata.FieldMetadata{Name: "UserIDs", Type: "int64", IsArray: true, ArrayLen: "2"}, true},
+		{"map", &metadata.FieldMetadata{Name: "UserIDs", Type: "map[string]int64", IsMap: true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.field.Annotations = &metadata.FieldAnnotations{IsRef: true, RefTarget: "User"}
+			registry := metadata.NewAggregateMetadataRegistry()
+			registry.Register(&metadata.AggregateMetadata{
+				Name:   "Order",
+				Fields: []*metadata.FieldMetadata{{Name: "ID", Type: "int64", Annotations: &metadata.FieldAnnotations{}}, tt.field},
+			})
+
+			errs := NewRelationAnalyzer(registry).ValidateAnnotationConflicts()
+			if got := len(errs) > 0; got != tt.wantErr {
+				t.Fatalf("ValidateAnnotationConflicts() = %v, wantErr %v", errs, tt.wantErr)
+			}
+			if tt.wantErr && !strings.Contains(errs[0].Error(), "+soliton:ref 只能用于保存单个目标 ID") {
+				t.Errorf("错误信息 = %q", errs[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,1929 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"soliton/pkg/analyzer"
+	"soliton/pkg/diff"
+	"soliton/pkg/generator"
+	"soliton/pkg/metadata"
+	"soliton/pkg/parser"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// 退出码，便于 CI 区分失败阶段
+const (
+	exitOK              = 0 // 成功
+	exitUsage           = 1 // 参数错误
+	exitParseError      = 2 // 解析失败
+	exitValidationError = 3 // 关系分析或校验失败
+	exitGenerateError   = 4 // 代码生成失败
+	exitBreakingChange  = 5 // 与 -diff 指定的基线相比存在不兼容变更（-validate 模式）
+	exitOutdated        = 6 // 生成结果与磁盘上的文件不一致（-check）
+)
+
+// options 命令行参数
+type options struct {
+	modelDir    string   // 领域模型目录，或模型定义文件（soliton.yaml / soliton.json / .proto）
+	outDir      string   // 输出根目录（-out）
+	only        []string // 只处理指定的聚合根（-only）
+	dryRun      bool     // 预览模式，不写入磁盘（-dry-run）
+	incremental bool     // 只为输入变化的聚合根重新生成（-incremental）
+	check       bool     // 预览并输出生成结果与磁盘文件的差异（-check），隐含 -dry-run
+	verify      bool     // 生成后对生成的包运行 go vet（-verify）
+	validate    bool     // 只校验，不生成代码（-validate）
+	jsonFile    string   // 元数据 JSON 导出文件（-json）
+	metaFile    string   // 元数据 JSON 加载文件（-metadata），代替源码解析和关系分析
+	diffFile    string   // 作为比较基线的元数据 JSON 文件（-diff）
+	erdFile     string   // ER 图导出文件（-erd）
+
+	templatesDir string // 覆盖内置模板的目录（-templates）
+
+	resolveTypes bool     // 通过 go/packages 解析字段类型（-resolve-types）
+	include      []string // 包含的文件模式（-include）
+	exclude      []string // 排除的目录或文件模式（-exclude）
+
+	scalarTypes []*metadata.ScalarType  // 追加的已知标量类型（-scalar，可重复）
+	naming      metadata.NamingStrategy // 表命名策略（-naming、-table-prefix）
+	dialect     metadata.Dialect        // 建表脚本和 DO 列类型使用的数据库方言（-dialect）
+
+	httpFramework string // REST 处理器使用的 Web 框架（-http），为空时不生成
+	grpc          bool   // 生成 gRPC 服务定义和服务端适配器（-grpc）
+	graphql       bool   // 生成 GraphQL schema 和解析器（-graphql）
+	diFramework   string // 依赖注入代码使用的框架（-di），为空时不生成
+	mocks         bool   // 生成仓储和领域服务的模拟实现（-mocks）
+	fixtures      bool   // 生成测试数据构建器（-fixtures）
+	memory        bool   // 生成内存仓储（-memory）
+	integration   bool   // 生成仓储集成测试（-integration）
+	outboxBroker  string // 事务性 outbox 中继使用的消息队列（-outbox），为空时不生成
+
+	plugins       []string                     // 运行的插件（-plugin，可重复），name 或 name=可执行文件路径
+	pluginOptions map[string]map[string]string // 各插件的参数（-plugin-opt）
+
+	requiredFields []string // 每个聚合根都必须声明的字段（-require-fields）
+	externals      []string // 其他服务中的聚合根（-external）
+
+	report     bool                      // 打印模型复杂度报告（-report）
+	complexity analyzer.ComplexityLimits // 复杂度阈值（-max-collections 等）
+
+	strict             bool     // 严格模式，未知注解视为错误（-strict）
+	allowedAnnotations []string // 严格模式下放行的自定义注解（-allow-annotations）
+
+	generationFlags []string // 影响生成内容的参数（name=value），用于计算增量生成的全局摘要
+}
+
+// nonGenerationFlags 不影响生成内容的参数，变化时不必重新生成全部聚合根
+// 可重复的参数（-scalar、-plugin、-plugin-opt）不在 flag.Visit 的值中体现，单独处理
+var nonGenerationFlags = map[string]bool{
+	"only": true, "dry-run": true, "check": true, "verify": true, "incremental": true, "validate": true, "json": true, "diff": true, "erd": true,
+	"report": true, "max-collections": true, "max-fields": true, "max-depth": true, "strict": true,
+	"allow-annotations": true, "require-fields": true, "scalar": true, "plugin": true, "plugin-opt": true,
+}
+
+// parseOptions 解析命令行参数
+func parseOptions(args []string) (*options, error) {
+	opts := &options{complexity: analyzer.DefaultComplexityLimits()}
+	var only, include, exclude, allowedAnnotations, naming, tablePrefix, requiredFields, externals, dialect string
+
+	fs := flag.NewFlagSet("soliton", flag.ContinueOnError)
+	fs.StringVar(&opts.outDir, "out", "", "输出根目录：domain 层代码写入 <out>/domain，基础设施代码写入 <out>/infrastructure（默认在模型目录所在工程中原地生成；Entity 方法始终追加到原领域模型文件）")
+	fs.StringVar(&only, "only", "", "只为指定的聚合根生成代码，逗号分隔，如 User,Order")
+	fs.BoolVar(&opts.dryRun, "dry-run", false, "只解析和分析，列出将要写入的文件，不修改磁盘")
+	fs.BoolVar(&opts.check, "check", false, "检查生成代码是否最新：在内存中生成全部文件（隐含 -dry-run），以 unified diff 输出与磁盘上文件的差异，存在差异时以退出码 6 退出，适用于代码评审和 CI")
+	fs.BoolVar(&opts.verify, "verify", false, "生成后对生成的 Go 代码所在的包运行 go vet（-integration 时带 integration 构建标签），存在编译错误或 vet 问题时以退出码 4 退出；不能与 -dry-run、-check 同时使用")
+	fs.BoolVar(&opts.incremental, "incremental", false, "增量生成：在工程根目录的 "+generator.IncrementalStateFile+" 中记录各聚合根输入（元数据、模型文件、关系另一端的聚合根、枚举和领域事件）的摘要，只为输入变化的聚合根重新生成逐聚合根的文件；soliton 版本、生成参数或覆盖的模板变化时全部重新生成")
+	fs.BoolVar(&opts.validate, "validate", false, "只解析和校验注解与关系，存在注解问题或校验错误时以非零状态退出，不生成代码")
+	fs.StringVar(&opts.jsonFile, "json", "", "将完整的元数据（聚合根、字段、注解、关系、关联表、枚举）导出为 JSON 文件")
+	fs.StringVar(&opts.metaFile, "metadata", "", "从 -json 导出的元数据文件加载聚合根和关系，跳过源码解析和关系分析；模型目录仍决定输出位置")
+	fs.StringVar(&opts.diffFile, "diff", "", "与 -json 导出的基线元数据比较，列出新增、删除和修改的聚合根、字段、索引、关系和关联表，并为已有迁移的上下文生成 ALTER 迁移；-validate 时存在不兼容变更以退出码 5 退出")
+	fs.StringVar(&opts.erdFile, "erd", "", "将聚合根、关系和多对多关联表导出为 ER 图：.dot/.gv 文件为 Graphviz DOT，其他为 Mermaid erDiagram（如 docs/erd.mmd）")
+	fs.StringVar(&opts.templatesDir, "templates", "", "模板目录：其中的 {名称}.tmpl 按名称覆盖渲染生成文件的内置模板（如 repository_impl.tmpl），加载后校验模板引用的字段，存在问题时以退出码 1 退出")
+	fs.BoolVar(&opts.resolveTypes, "resolve-types", false, "通过 go/packages 类型检查模型包，解析类型别名、命名类型和跨包类型（要求模型所在模块可编译）")
+	fs.StringVar(&include, "include", "", "只扫描匹配的文件，逗号分隔的相对路径模式，支持 * 和 **，如 order/**,user/*.go")
+	fs.StringVar(&exclude, "exclude", "", "跳过匹配的目录或文件，逗号分隔；不含 / 的模式匹配任意层级的名称，如 legacy,*_gen.go")
+	fs.BoolVar(&opts.strict, "strict", false, "严格模式：存在未知的 +soliton 注解（多为拼写错误）时解析失败，而不是忽略")
+	fs.StringVar(&allowedAnnotations, "allow-annotations", "", "不视为未知注解的自定义注解名，逗号分隔，如 audit,cache（供其他工具使用的 +soliton:xxx）")
+	fs.Func("scalar", "声明按普通列处理的外部类型（可重复），格式 包路径.类型名[=列类型]，如 github.com/shopspring/decimal.Decimal=DECIMAL(20,4)；uuid.UUID、decimal.Decimal、sql.NullXxx 等已预置", func(value string) error {
+		scalarType, err := metadata.ParseScalarType(value)
+		if err != nil {
+			return err
+		}
+		opts.scalarTypes = append(opts.scalarTypes, scalarType)
+		return nil
+	})
+	fs.Func("plugin", "运行生成插件（可重复）：name 为编译期注册的插件或 PATH 中的可执行文件 soliton-gen-{name}，name=路径 指定插件可执行文件；外部插件从标准输入读取 JSON 元数据，向标准输出写入要生成的文件", func(value string) error {
+		if strings.TrimSpace(strings.SplitN(value, "=", 2)[0]) == "" {
+			return fmt.Errorf("插件名不能为空")
+		}
+		opts.plugins = append(opts.plugins, value)
+		return nil
+	})
+	fs.Func("plugin-opt", "传给插件的参数（可重复），格式 插件名:参数=值，如 audit:table=audit_logs", func(value string) error {
+		name, option, ok := strings.Cut(value, ":")
+		key, val, hasValue := strings.Cut(option, "=")
+		if !ok || !hasValue || name == "" || key == "" {
+			return fmt.Errorf("格式应为 插件名:参数=值: %s", value)
+		}
+		if opts.pluginOptions == nil {
+			opts.pluginOptions = make(map[string]map[string]string)
+		}
+		if opts.pluginOptions[name] == nil {
+			opts.pluginOptions[name] = make(map[string]string)
+		}
+		opts.pluginOptions[name][key] = val
+		return nil
+	})
+	fs.StringVar(&naming, "naming", metadata.NamingSnakePlural, "默认表名的命名策略：snake_plural（order_items）或 snake（order_item）；多对多关联表名始终为单数（role_user）")
+	fs.StringVar(&tablePrefix, "table-prefix", "", "默认表名的前缀，如 t_（生成 t_order_items、t_role_user）；+soliton:table 等显式声明的表名不加前缀")
+	fs.StringVar(&dialect, "dialect", metadata.DialectMySQL, "建表脚本、迁移和 DO 列类型使用的数据库方言：mysql、postgres（IDENTITY 主键、TIMESTAMPTZ、JSONB 值对象，软删除聚合根的唯一索引只约束未删除的记录）或 sqlite（本地开发和集成测试）")
+	fs.StringVar(&opts.httpFramework, "http", "", "生成 REST 处理器、请求和响应 DTO（interfaces/dto）、路由注册和 OpenAPI 3 文档 interfaces/openapi.yaml，指定使用的 Web 框架：gin、echo 或 chi；暴露范围和操作取自 +soliton:api，没有聚合根声明 +soliton:api 时为全部聚合根生成")
+	fs.BoolVar(&opts.grpc, "grpc", false, "生成 gRPC 服务定义 interfaces/rpc/pb/*.proto、服务端适配器和注册全部服务的 RegisterServices；暴露范围和操作取自 +soliton:api（protocols 包含 grpc），没有聚合根声明 +soliton:api 时为全部聚合根生成；.proto 需要通过 protoc 生成 Go 代码")
+	fs.BoolVar(&opts.graphql, "graphql", false, "生成 GraphQL schema interfaces/graph/schema.graphql、gqlgen 配置和调用领域服务的解析器，关联字段按请求批量加载；暴露范围和操作取自 +soliton:api（protocols 包含 graphql），没有聚合根声明 +soliton:api 时为全部聚合根生成；需要通过 gqlgen 生成 generated 包")
+	fs.StringVar(&opts.diFramework, "di", "", "生成各层的依赖注入提供者 providers.go（仓储、领域服务，-http 时还有 REST 处理器）和汇总全部层的 di 包，指定使用的框架：wire（ProviderSet 和 InitializeContainer 注入器）或 fx（Module 和 NewApp）")
+	fs.BoolVar(&opts.fixtures, "fixtures", false, "为每个聚合根生成测试数据构建器 domain/fixtures/{Aggregate}Builder.go：通过工厂函数 New{Aggregate} 创建，必填和唯一字段取按序号区分的测试值，With 方法链式设置字段")
+	fs.BoolVar(&opts.mocks, "mocks", false, "为每个聚合根的仓储接口和领域服务生成基于 testify mock.Mock 的模拟实现 domain/mocks/{Aggregate}Repository.go、{Aggregate}Service.go，供不连接数据库的单元测试使用")
+	fs.BoolVar(&opts.memory, "memory", false, "为每个聚合根生成仓储接口的内存实现 infrastructure/memory/{Aggregate}Repository.go：基于 map，与数据库实现一样处理软删除、乐观锁和唯一约束，供服务层测试使用")
+	fs.StringVar(&opts.outboxBroker, "outbox", "", "使用事务性 outbox 发布领域事件，指定消息队列：kafka（segmentio/kafka-go）或 nats（JetStream）；嵌入 framework.EventRecorder 的聚合根的仓储在写入聚合根的同一事务中将事件写入 outbox 表，为这些聚合根所在的上下文生成 create_outbox 迁移，并生成将待发布事件发布到消息队列的中继 infrastructure/outbox/relay.go（至少一次）")
+	fs.BoolVar(&opts.integration, "integration", false, "为每个聚合根的仓储实现生成集成测试 infrastructure/repository/{Aggregate}RepositoryImpl_integration_test.go 和创建测试数据库的 main_integration_test.go：按 -dialect 由 testcontainers 启动 MySQL、PostgreSQL 容器（sqlite 使用临时文件），执行生成的迁移后验证增删改查、分页、乐观锁和软删除；通过 go test -tags integration 运行")
+	fs.StringVar(&requiredFields, "require-fields", "", "每个聚合根都必须声明的字段，逗号分隔，如 TenantID；缺少时报告验证错误")
+	fs.StringVar(&externals, "external", "", "其他服务中的聚合根，逗号分隔，如 Customer,Payment；引用它们的 +soliton:ref 不要求在本模型中定义")
+	fs.BoolVar(&opts.report, "report", false, "打印模型复杂度报告：各聚合根的字段数、扇入、扇出、一对多集合数和关联实体包含深度，超过阈值时给出提示")
+	fs.IntVar(&opts.complexity.MaxCollections, "max-collections", opts.complexity.MaxCollections, "复杂度报告中一个聚合根最多包含的一对多集合数，0 表示不检查")
+	fs.IntVar(&opts.complexity.MaxFields, "max-fields", opts.complexity.MaxFields, "复杂度报告中一个聚合根最多的字段数，0 表示不检查")
+	fs.IntVar(&opts.complexity.MaxDepth, "max-depth", opts.complexity.MaxDepth, "复杂度报告中关联实体的最大包含深度，0 表示不检查")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "使用方法: soliton [选项] <领域模型目录|模型定义文件>")
+		fmt.Fprintln(fs.Output(), "示例: soliton -only User,Order -dry-run ./domain/model")
+		fmt.Fprintln(fs.Output(), "      soliton ./domain/model/soliton.yaml（目录中存在 soliton.yaml / soliton.yml / soliton.json 时也按定义文件解析）")
+		fmt.Fprintln(fs.Output(), "      soliton ./domain/model/shop.proto（带 soliton 选项的 Protobuf 消息定义）")
+		fmt.Fprintln(fs.Output(), "      soliton import -driver mysql -dsn ... ./domain/model（从已有数据库导入模型，详见 soliton import -h）")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "选项:")
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "退出码: 0 成功, 1 参数错误, 2 解析失败, 3 校验失败, 4 生成失败, 5 存在不兼容变更（-diff）, 6 生成结果不是最新（-check）")
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if fs.NArg() < 1 {
+		fs.Usage()
+		return nil, fmt.Errorf("缺少领域模型目录参数")
+	}
+	opts.modelDir = fs.Arg(0)
+	if opts.verify && (opts.dryRun || opts.check) {
+		return nil, fmt.Errorf("-verify 需要写入生成的文件，不能与 -dry-run、-check 同时使用")
+	}
+	if opts.check {
+		opts.dryRun = true
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if !nonGenerationFlags[f.Name] {
+			opts.generationFlags = append(opts.generationFlags, f.Name+"="+f.Value.String())
+		}
+	})
+
+	opts.only = splitList(only)
+	opts.include = splitList(include)
+	opts.exclude = splitList(exclude)
+	opts.allowedAnnotations = splitList(allowedAnnotations)
+	opts.requiredFields = splitList(requiredFields)
+	opts.externals = splitList(externals)
+
+	var err error
+	if opts.naming, err = metadata.ParseNamingStrategy(naming, tablePrefix); err != nil {
+		return nil, err
+	}
+	if opts.dialect, err = metadata.ParseDialect(dialect); err != nil {
+		return nil, err
+	}
+	if opts.httpFramework != "" && !slices.Contains(generator.HTTPFrameworks, opts.httpFramework) {
+		return nil, fmt.Errorf("-http 不支持 %s，可选 %s", opts.httpFramework, strings.Join(generator.HTTPFrameworks, "、"))
+	}
+	if opts.diFramework != "" && !slices.Contains(generator.DIFrameworks, opts.diFramework) {
+		return nil, fmt.Errorf("-di 不支持 %s，可选 %s", opts.diFramework, strings.Join(generator.DIFrameworks, "、"))
+	}
+	for name := range opts.pluginOptions {
+		if !slices.ContainsFunc(opts.plugins, func(plugin string) bool { return pluginName(plugin) == name }) {
+			return nil, fmt.Errorf("-plugin-opt 指定的插件 %s 没有通过 -plugin 启用", name)
+		}
+	}
+	if opts.outboxBroker != "" && !slices.Contains(generator.OutboxBrokers, opts.outboxBroker) {
+		return nil, fmt.Errorf("-outbox 不支持 %s，可选 %s", opts.outboxBroker, strings.Join(generator.OutboxBrokers, "、"))
+	}
+
+	return opts, nil
+}
+
+// pluginName 返回 -plugin 参数中的插件名
+func pluginName(spec string) string {
+	name, _, _ := strings.Cut(spec, "=")
+	return name
+}
+
+// splitList 拆分逗号分隔的参数值，去除空白和重复项
+func splitList(value string) []string {
+	var items []string
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" || seen[item] {
+			continue
+		}
+		seen[item] = true
+		items = append(items, item)
+	}
+	return items
+}
+
+// Run 执行代码生成流程，args 为不含命令名的命令行参数，返回退出码
+//
+// cmd/soliton 直接调用 Run；需要编译期插件时，在自己的命令中空导入注册插件的包后调用 Run：
+//
+//	import (
+//		_ "example.com/soliton-audit" // init 中调用 generator.RegisterPlugin
+//		"soliton/pkg/cli"
+//	)
+//
+//	func main() {
+//		os.Exit(cli.Run(os.Args[1:]))
+//	}
+func Run(args []string) int {
+	// 子命令：从数据库导入模型
+	if len(args) > 0 && args[0] == "import" {
+		return runImport(args[1:])
+	}
+
+	fmt.Println("🚀 Soliton 代码生成器 v5.0")
+	fmt.Println("=" + repeat("=", 50))
+
+	opts, err := parseOptions(args)
+	if err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return fail(exitUsage, "参数错误: %v", err)
+	}
+
+	// 加载并校验模板，模板有误时在解析模型之前退出
+	templates, err := generator.LoadTemplates(opts.templatesDir)
+	if err != nil {
+		return fail(exitUsage, "加载模板失败: %v", err)
+	}
+	if errs := templates.Validate(); len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Printf("  ❌ %v\n", err)
+		}
+		return fail(exitUsage, "模板校验失败: 发现 %d 个问题", len(errs))
+	}
+	if overrides := templates.Overrides(); len(overrides) > 0 {
+		fmt.Printf("🧩 使用自定义模板: %s\n\n", joinStrings(overrides, ", "))
+	}
+
+	// 查找插件，插件不存在时在解析模型之前退出
+	plugins := make([]generator.Generator, 0, len(opts.plugins))
+	for _, spec := range opts.plugins {
+		if name, path, ok := strings.Cut(spec, "="); ok {
+			plugins = append(plugins, generator.NewExecPlugin(name, path))
+			continue
+		}
+		plugin, err := generator.LookupPlugin(spec)
+		if err != nil {
+			return fail(exitUsage, "参数错误: %v", err)
+		}
+		plugins = append(plugins, plugin)
+	}
+
+	modelDir := opts.modelDir
+
+	// 创建解析器
+	astParser := parser.NewASTParser()
+	astParser.SetResolveTypes(opts.resolveTypes)
+	astParser.SetStrict(opts.strict)
+	astParser.SetAllowedAnnotations(opts.allowedAnnotations)
+	astParser.SetNamingStrategy(opts.naming)
+	if err := astParser.SetIncludePatterns(opts.include); err != nil {
+		return fail(exitUsage, "参数错误: %v", err)
+	}
+	if err := astParser.SetExcludePatterns(opts.exclude); err != nil {
+		return fail(exitUsage, "参数错误: %v", err)
+	}
+
+	// 解析模型定义文件或目录，或加载已导出的元数据
+	var aggregates []*metadata.AggregateMetadata
+	var loaded *metadata.AggregateMetadataRegistry
+	if opts.metaFile != "" {
+		fmt.Printf("📂 正在加载元数据: %s\n\n", opts.metaFile)
+		if loaded, err = readMetadataJSON(opts.metaFile); err == nil {
+			aggregates = loaded.GetAll()
+		}
+	} else if schemaFile := parser.FindSchemaFile(modelDir); schemaFile != "" {
+		fmt.Printf("📂 正在解析模型定义文件: %s\n\n", schemaFile)
+		aggregates, err = astParser.ParseSchema(schemaFile)
+		// 领域模型生成在定义文件所在目录
+		modelDir = filepath.Dir(schemaFile)
+	} else {
+		fmt.Printf("📂 正在解析目录: %s\n\n", modelDir)
+		aggregates, err = astParser.ParseDirectory(modelDir)
+	}
+	if err != nil {
+		return fail(exitParseError, "解析失败: %v", err)
+	}
+
+	fmt.Printf("✅ 成功解析 %d 个聚合根\n\n", len(aggregates))
+
+	// 注解语法问题：拼写错误、括号不匹配的注解在解析时被忽略，需要提示
+	diagnostics := astParser.Diagnostics()
+	if len(diagnostics) > 0 {
+		fmt.Printf("⚠️  发现 %d 个注解问题:\n", len(diagnostics))
+		for _, diagnostic := range diagnostics {
+			fmt.Printf("  - %v\n", diagnostic)
+		}
+		fmt.Println()
+	}
+
+	// 打印每个聚合根的摘要信息
+	for i, agg := range aggregates {
+		printAggregateSummary(i+1, agg)
+	}
+
+	fmt.Println("=" + repeat("=", 50))
+	fmt.Println()
+
+	// ==================== 阶段二：关系分析 ====================
+	fmt.Println("🔍 开始关系分析...")
+	fmt.Println()
+
+	// 构建全局元数据注册表；加载的元数据已包含关系和多对多关联表
+	registry := loaded
+	if registry == nil {
+		registry = metadata.NewAggregateMetadataRegistry()
+		for _, agg := range aggregates {
+			registry.Register(agg)
+		}
+		registry.SetDeclaredEnums(astParser.Enums())
+		registry.SetDeclaredEvents(astParser.Events())
+		// 后续阶段按注册表顺序（聚合根名）处理，与加载元数据时一致
+		aggregates = registry.GetAll()
+	}
+
+	// 校验 -only 指定的聚合根
+	var selected map[string]bool
+	if len(opts.only) > 0 {
+		selected = make(map[string]bool, len(opts.only))
+		var missing []string
+		for _, name := range opts.only {
+			if !registry.Exists(name) {
+				missing = append(missing, name)
+			}
+			selected[name] = true
+		}
+		if len(missing) > 0 {
+			return fail(exitUsage, "-only 指定的聚合根不存在: %s", joinStrings(missing, ", "))
+		}
+	}
+
+	// 创建关系分析器
+	relationAnalyzer := analyzer.NewRelationAnalyzer(registry)
+	relationAnalyzer.SetNamingStrategy(opts.naming)
+	relationAnalyzer.SetExternalAggregates(opts.externals)
+	if len(opts.scalarTypes) > 0 {
+		scalarTypes := metadata.NewScalarTypeRegistry()
+		for _, scalarType := range opts.scalarTypes {
+			scalarTypes.Register(scalarType)
+		}
+		relationAnalyzer.SetScalarTypes(scalarTypes)
+	}
+	if len(opts.requiredFields) > 0 {
+		relationAnalyzer.AddHook(analyzer.NewRequiredFieldsHook(opts.requiredFields...))
+	}
+
+	if loaded == nil {
+		// 分析关系
+		if err := relationAnalyzer.AnalyzeRelations(); err != nil {
+			return fail(exitValidationError, "关系分析失败: %v", err)
+		}
+
+		// 生成多对多关联表
+		if err := relationAnalyzer.GenerateManyToManyTables(); err != nil {
+			return fail(exitValidationError, "生成多对多关联表失败: %v", err)
+		}
+	}
+
+	// 收集领域事件（校验事件名和主题需要完整的事件列表）
+	registry.CollectEvents()
+
+	// 验证注解冲突、关系、聚合边界、多态关联、索引、主键策略、字段类型、值对象、字段校验规则、枚举、领域事件、API、默认值、不可变字段、敏感字段和自定义规则
+	validationErrors := relationAnalyzer.ValidateAnnotationConflicts()
+	validationErrors = append(validationErrors, relationAnalyzer.ValidateRelations()...)
+	validationErrors = append(validationErrors, relationAnalyzer.ValidateAggregateBoundaries()...)
+	validationErrors = append(validationErrors, relationAnalyzer.ValidatePolymorphicRelations()...)
+	validationErrors = append(validationErrors, relationAnalyzer.ValidateCascadeRelations()...)
+	validationErrors = append(validationErrors, relationAnalyzer.ValidateIndexes()...)
+	validationErrors = append(validationErrors, relationAnalyzer.ValidateIDStrategies()...)
+	validationErrors = append(validationErrors, relationAnalyzer.ValidateFieldTypes()...)
+	validationErrors = append(validationErrors, relationAnalyzer.ValidateValueObjects()...)
+	validationErrors = append(validationErrors, relationAnalyzer.ValidateFieldRules()...)
+	validationErrors = append(validationErrors, relationAnalyzer.ValidateEnums()...)
+	validationErrors = append(validationErrors, relationAnalyzer.ValidateEvents()...)
+	validationErrors = append(validationErrors, relationAnalyzer.ValidateAPIs()...)
+	validationErrors = append(validationErrors, relationAnalyzer.ValidateQueries()...)
+	validationErrors = append(validationErrors, relationAnalyzer.ValidateDefaults()...)
+	validationErrors = append(validationErrors, relationAnalyzer.ValidateImmutableFields()...)
+	validationErrors = append(validationErrors, relationAnalyzer.ValidateSensitiveFields()...)
+	validationErrors = append(validationErrors, relationAnalyzer.ValidateFilterFields()...)
+	validationErrors = append(validationErrors, relationAnalyzer.ValidateHooks()...)
+	if len(validationErrors) > 0 {
+		fmt.Printf("⚠️  发现 %d 个验证错误:\n", len(validationErrors))
+		for _, err := range validationErrors {
+			fmt.Printf("  - %v\n", err)
+		}
+		fmt.Println()
+	}
+
+	// 模型整理建议：孤立实体和孤立聚合根，不计入验证错误
+	if suggestions := relationAnalyzer.ValidateReachability(); len(suggestions) > 0 {
+		fmt.Printf("💡 %d 条模型整理建议:\n", len(suggestions))
+		for _, suggestion := range suggestions {
+			fmt.Printf("  - %v\n", suggestion)
+		}
+		fmt.Println()
+	}
+
+	// 收集枚举
+	registry.CollectEnums()
+
+	fmt.Println("✅ 关系分析完成！")
+	fmt.Println()
+
+	printRelationSummary(registry)
+	printEventSummary(registry)
+
+	if opts.report {
+		printComplexityReport(relationAnalyzer.ComplexityReport(opts.complexity))
+	}
+
+	// 与基线元数据比较
+	var breaking []*diff.Change
+	var baseline *metadata.AggregateMetadataRegistry
+	if opts.diffFile != "" {
+		var err error
+		baseline, err = readMetadataJSON(opts.diffFile)
+		if err != nil {
+			return fail(exitUsage, "加载基线元数据失败: %v", err)
+		}
+		report := diff.Compare(baseline, registry)
+		printDiffReport(opts.diffFile, report)
+		breaking = report.Breaking()
+	}
+
+	fmt.Println("=" + repeat("=", 50))
+	fmt.Println()
+
+	// 导出元数据 JSON
+	if opts.jsonFile != "" {
+		if err := writeMetadataJSON(registry, opts.jsonFile); err != nil {
+			return fail(exitGenerateError, "导出元数据失败: %v", err)
+		}
+		fmt.Printf("🧾 元数据已导出: %s\n\n", opts.jsonFile)
+	}
+
+	// 表结构元数据：ER 图、SQL 脚本和 DO 共用同一份计算结果
+	schema := metadata.NewSchema(registry, opts.dialect)
+
+	// 导出 ER 图
+	if opts.erdFile != "" {
+		erdGenerator := generator.NewERDGenerator(registry)
+		erdGenerator.SetSchema(schema)
+		erdGenerator.SetFormat(generator.ERDFormatOf(opts.erdFile))
+		erdGenerator.SetTemplates(templates)
+		if err := erdGenerator.Generate(opts.erdFile); err != nil {
+			return fail(exitGenerateError, "导出 ER 图失败: %v", err)
+		}
+		fmt.Printf("🗺️  ER 图已导出: %s\n\n", opts.erdFile)
+	}
+
+	// 校验模式：不生成代码
+	if opts.validate {
+		if len(diagnostics) > 0 || len(validationErrors) > 0 {
+			return fail(exitValidationError, "校验失败: 发现 %d 个注解问题、%d 个验证错误", len(diagnostics), len(validationErrors))
+		}
+		if len(breaking) > 0 {
+			return fail(exitBreakingChange, "校验失败: 与基线 %s 相比存在 %d 项不兼容变更", opts.diffFile, len(breaking))
+		}
+		fmt.Println("✅ 校验通过")
+		return exitOK
+	}
+
+	// 确定输出目录（项目根目录）
+	outputDir := filepath.Dir(modelDir)
+	if opts.outDir != "" {
+		outputDir = filepath.Join(opts.outDir, "domain")
+	}
+
+	// 预览模式：生成器写入内存，不修改磁盘
+	var writer generator.FileWriter = generator.DiskWriter{}
+	var dryRunWriter *generator.DryRunWriter
+	if opts.dryRun {
+		dryRunWriter = generator.NewDryRunWriter()
+		writer = dryRunWriter
+	}
+	// -verify：记录写出的文件，生成后检查所在的包
+	var recorder *recordingWriter
+	if opts.verify {
+		recorder = &recordingWriter{FileWriter: writer}
+		writer = recorder
+	}
+
+	if selected != nil {
+		fmt.Printf("🎯 只处理聚合根: %s（SQL 脚本和枚举仍包含全部聚合根）\n", joinStrings(opts.only, ", "))
+		fmt.Println()
+	}
+
+	// ==================== 阶段三：SQL 脚本生成 ====================
+	fmt.Println("💾 开始生成 SQL 建表脚本...")
+	fmt.Println()
+
+	sqlGenerator := generator.NewSQLGenerator(registry)
+	sqlGenerator.SetSchema(schema)
+	sqlGenerator.SetWriter(writer)
+	sqlGenerator.SetTemplates(templates)
+	if err := sqlGenerator.Generate(outputDir); err != nil {
+		return fail(exitGenerateError, "SQL 脚本生成失败: %v", err)
+	}
+
+	sqlContexts := sqlGenerator.Contexts()
+	for _, boundedContext := range sqlContexts {
+		fmt.Printf("✅ SQL 脚本生成完成：%s\n", filepath.ToSlash(filepath.Join(boundedContext, "sql", "schema.sql")))
+	}
+
+	migrationGenerator := generator.NewMigrationGenerator(sqlGenerator)
+	migrationGenerator.SetWriter(writer)
+	migrationGenerator.SetTemplates(templates)
+	migrationGenerator.SetOutbox(opts.outboxBroker != "")
+	if baseline != nil {
+		migrationGenerator.SetBaseline(metadata.NewSchema(baseline, opts.dialect))
+	}
+	if err := migrationGenerator.Generate(outputDir); err != nil {
+		return fail(exitGenerateError, "迁移脚本生成失败: %v", err)
+	}
+	for _, path := range migrationGenerator.Written() {
+		if rel, err := filepath.Rel(outputDir, path); err == nil {
+			path = rel
+		}
+		fmt.Printf("✅ 迁移脚本生成完成：%s\n", filepath.ToSlash(path))
+	}
+	for _, review := range migrationGenerator.Reviews() {
+		fmt.Printf("⚠️  迁移需人工确认：%s\n", review)
+	}
+	if len(migrationGenerator.Written()) < 2*len(sqlContexts) {
+		if baseline != nil {
+			fmt.Println("⏭️  与基线相比表结构没有变化的上下文不再生成迁移")
+		} else {
+			fmt.Println("⏭️  sql/migrations 中已有迁移文件的上下文不再生成建表迁移，使用 -diff 指定基线时生成 ALTER 迁移")
+		}
+	}
+	fmt.Println()
+
+	fmt.Println("=" + repeat("=", 50))
+	fmt.Println()
+
+	// ==================== 阶段四：代码生成 ====================
+	fmt.Println("🔨 开始代码生成...")
+	fmt.Println()
+
+	// 创建生成器
+	entityGenerator := generator.NewEntityGenerator()
+	enumGenerator := generator.NewEnumGenerator()
+	eventGenerator := generator.NewEventGenerator()
+	doGenerator := generator.NewDOGenerator()
+	queryFieldGenerator := generator.NewQueryFieldGenerator()
+	columnGenerator := generator.NewColumnGenerator()
+	convertorGenerator := generator.NewConvertorGenerator()
+	repoInterfaceGenerator := generator.NewRepositoryInterfaceGenerator()
+	repoImplGenerator := generator.NewRepositoryImplGenerator()
+	serviceImplGenerator := generator.NewServiceImplGenerator()
+	readModelGenerator := generator.NewReadModelGenerator()
+	dtoGenerator := generator.NewDTOGenerator()
+	httpHandlerGenerator := generator.NewHTTPHandlerGenerator()
+	openAPIGenerator := generator.NewOpenAPIGenerator()
+	grpcGenerator := generator.NewGRPCGenerator()
+	graphQLGenerator := generator.NewGraphQLGenerator()
+	diGenerator := generator.NewDIGenerator()
+	mockGenerator := generator.NewMockGenerator()
+	fixtureGenerator := generator.NewFixtureGenerator()
+	memoryRepoGenerator := generator.NewMemoryRepositoryGenerator()
+	integrationTestGenerator := generator.NewIntegrationTestGenerator()
+	outboxGenerator := generator.NewOutboxGenerator()
+	pluginGenerator := generator.NewPluginGenerator(registry)
+
+	entityGenerator.SetWriter(writer)
+	enumGenerator.SetWriter(writer)
+	eventGenerator.SetWriter(writer)
+	doGenerator.SetWriter(writer)
+	queryFieldGenerator.SetWriter(writer)
+	columnGenerator.SetWriter(writer)
+	convertorGenerator.SetWriter(writer)
+	repoInterfaceGenerator.SetWriter(writer)
+	repoImplGenerator.SetWriter(writer)
+	serviceImplGenerator.SetWriter(writer)
+	readModelGenerator.SetWriter(writer)
+	dtoGenerator.SetWriter(writer)
+	httpHandlerGenerator.SetWriter(writer)
+	openAPIGenerator.SetWriter(writer)
+	grpcGenerator.SetWriter(writer)
+	graphQLGenerator.SetWriter(writer)
+	diGenerator.SetWriter(writer)
+	mockGenerator.SetWriter(writer)
+	fixtureGenerator.SetWriter(writer)
+	memoryRepoGenerator.SetWriter(writer)
+	integrationTestGenerator.SetWriter(writer)
+	outboxGenerator.SetWriter(writer)
+	pluginGenerator.SetWriter(writer)
+	// 生成的 Go 代码写出前整理导入，引用了但未导入的包按元数据中的包补充
+	imports := generator.PackageImports(registry)
+	for _, g := range []interface {
+		SetTemplates(*generator.Templates)
+		SetImports(map[string]string)
+	}{
+		entityGenerator, enumGenerator, eventGenerator, doGenerator, queryFieldGenerator, columnGenerator, convertorGenerator,
+		repoInterfaceGenerator, repoImplGenerator, serviceImplGenerator, readModelGenerator, dtoGenerator,
+		httpHandlerGenerator, openAPIGenerator, grpcGenerator, graphQLGenerator, diGenerator, mockGenerator,
+		fixtureGenerator, memoryRepoGenerator, integrationTestGenerator, outboxGenerator, pluginGenerator,
+	} {
+		g.SetTemplates(templates)
+		g.SetImports(imports)
+	}
+	repoInterfaceGenerator.SetRegistry(registry)
+	repoImplGenerator.SetRegistry(registry)
+	repoImplGenerator.SetOutbox(opts.outboxBroker != "")
+	serviceImplGenerator.SetRegistry(registry)
+	openAPIGenerator.SetRegistry(registry)
+	grpcGenerator.SetRegistry(registry)
+	graphQLGenerator.SetRegistry(registry)
+	diGenerator.SetRegistry(registry)
+	mockGenerator.SetRegistry(registry)
+	fixtureGenerator.SetRegistry(registry)
+	memoryRepoGenerator.SetRegistry(registry)
+	integrationTestGenerator.SetRegistry(registry)
+	integrationTestGenerator.SetDialect(opts.dialect.Name())
+	pluginGenerator.SetDialect(opts.dialect.Name())
+	doGenerator.SetSchema(schema)
+	columnGenerator.SetSchema(schema)
+	if opts.httpFramework != "" {
+		if err := httpHandlerGenerator.SetFramework(opts.httpFramework); err != nil {
+			return fail(exitUsage, "参数错误: %v", err)
+		}
+	}
+	if opts.diFramework != "" {
+		if err := diGenerator.SetFramework(opts.diFramework); err != nil {
+			return fail(exitUsage, "参数错误: %v", err)
+		}
+	}
+	if opts.outboxBroker != "" {
+		if err := outboxGenerator.SetBroker(opts.outboxBroker); err != nil {
+			return fail(exitUsage, "参数错误: %v", err)
+		}
+	}
+
+	// 参与生成的聚合根
+	targets := filterAggregates(registry.GetAll(), selected)
+
+	// 增量生成：逐聚合根的文件只为输入变化的聚合根重新生成，按上下文和全局生成的文件仍全部生成（内容不变时不重写）
+	regenerate := selected
+	var incremental *generator.Incremental
+	if opts.incremental {
+		fingerprint, err := incrementalFingerprint(opts, templates)
+		if err != nil {
+			return fail(exitGenerateError, "增量生成失败: %v", err)
+		}
+		if incremental, err = generator.LoadIncremental(filepath.Dir(outputDir), fingerprint, registry); err != nil {
+			return fail(exitGenerateError, "增量生成失败: %v（可删除状态文件后重新生成全部聚合根）", err)
+		}
+		regenerate = make(map[string]bool, len(targets))
+		var unchanged []string
+		for _, agg := range targets {
+			changed, err := incremental.Changed(agg)
+			if err != nil {
+				return fail(exitGenerateError, "增量生成失败: %v", err)
+			}
+			if changed {
+				regenerate[agg.Name] = true
+			} else {
+				unchanged = append(unchanged, agg.Name)
+			}
+		}
+		if len(unchanged) > 0 {
+			fmt.Printf("⏭️  输入未变化，跳过 %d 个聚合根: %s\n", len(unchanged), joinStrings(unchanged, ", "))
+			fmt.Println()
+		}
+	}
+	changedTargets := filterAggregates(targets, regenerate)
+
+	// 生成统计
+	modelCount := 0
+	entityCount := 0
+	enumCount := 0
+	eventCount := 0
+	doCount := 0
+	queryFieldCount := 0
+	columnCount := 0
+	convertorCount := 0
+	repoInterfaceCount := 0
+	repoImplCount := 0
+	serviceImplCount := 0
+	readModelCount := 0
+	dtoCount := 0
+	httpHandlerCount := 0
+	grpcServerCount := 0
+	graphQLResolverCount := 0
+	diCount := 0
+	mockCount := 0
+	fixtureCount := 0
+	memoryRepoCount := 0
+	integrationTestCount := 0
+	pluginFileCount := 0
+	failedCount := 0
+
+	// 模型定义文件渲染的领域模型，需要在追加 Entity 方法之前写出
+	// 未选中的聚合根保留原文件，避免覆盖其中已追加的 Entity 方法
+	if schemaFiles := astParser.SchemaFiles(); len(schemaFiles) > 0 {
+		unselected := make(map[string]bool)
+		for _, agg := range aggregates {
+			if selected != nil && !selected[agg.Name] {
+				unselected[agg.FilePath] = true
+			}
+		}
+		paths := make([]string, 0, len(schemaFiles))
+		for path := range schemaFiles {
+			if !unselected[path] {
+				paths = append(paths, path)
+			}
+		}
+		sort.Strings(paths)
+
+		fmt.Println("📝 生成领域模型（来自模型定义文件）:")
+		for i, path := range paths {
+			fmt.Printf("%d. %s", i+1, filepath.Base(path))
+
+			if err := writer.WriteFile(path, schemaFiles[path]); err != nil {
+				fmt.Printf(" ⚠️  失败: %v\n", err)
+				failedCount++
+				continue
+			}
+
+			modelCount++
+			fmt.Printf(" ✅\n")
+		}
+		fmt.Println()
+	}
+
+	// 0. 生成 Entity 接口实现（追加到原领域模型文件）
+	// 同一模型文件中的聚合根依次追加 Entity 方法，先在内存中得到完整内容再写出，避免写出只含部分方法的中间内容
+	var entityBuffer *generator.DryRunWriter
+	if !opts.dryRun {
+		entityBuffer = generator.NewDryRunWriter()
+		entityGenerator.SetWriter(entityBuffer)
+	}
+	fmt.Println("📝 生成 Entity 接口实现:")
+	for i, agg := range filterAggregates(aggregates, selected) {
+		fmt.Printf("%d. %s.go", i+1, toLowerFirst(agg.Name))
+
+		if err := entityGenerator.Generate(agg); err != nil {
+			fmt.Printf(" ⚠️  失败: %v\n", err)
+			failedCount++
+			continue
+		}
+
+		entityCount++
+		fmt.Printf(" ✅\n")
+	}
+	if entityBuffer != nil {
+		for _, path := range entityBuffer.Files() {
+			content, _ := entityBuffer.Content(path)
+			if err := writer.WriteFile(path, content); err != nil {
+				fmt.Printf("⚠️  写入 %s 失败: %v\n", displayPath(path), err)
+				failedCount++
+			}
+		}
+	}
+	fmt.Println()
+
+	// 1. 生成枚举类型
+	enums := registry.GetEnums()
+	if len(enums) > 0 {
+		fmt.Println("📝 生成枚举类型:")
+		if err := enumGenerator.Generate(registry, outputDir); err != nil {
+			fmt.Printf("   ⚠️  失败: %v\n", err)
+			failedCount++
+		} else {
+			for _, enum := range enums {
+				// const 块定义的枚举已有类型定义，不生成文件
+				if enum.IsDeclared() {
+					continue
+				}
+				enumCount++
+				fmt.Printf("%d. %s.go ✅\n", enumCount, toLowerFirst(enum.Name))
+			}
+		}
+		fmt.Println()
+	}
+
+	// 生成领域事件（与领域模型位于同一个包）
+	var eventAggregates []*metadata.AggregateMetadata
+	for _, agg := range filterAggregates(aggregates, regenerate) {
+		if len(registry.GetEventsByAggregate(agg.Name)) > 0 {
+			eventAggregates = append(eventAggregates, agg)
+		}
+	}
+	if len(eventAggregates) > 0 {
+		fmt.Println("📝 生成领域事件:")
+		for i, agg := range eventAggregates {
+			fmt.Printf("%d. %sEvents.go", i+1, toLowerFirst(agg.Name))
+
+			if err := eventGenerator.Generate(agg, registry.GetEventsByAggregate(agg.Name)); err != nil {
+				fmt.Printf(" ⚠️  失败: %v\n", err)
+				failedCount++
+				continue
+			}
+
+			eventCount++
+			fmt.Printf(" ✅\n")
+		}
+		fmt.Println()
+	}
+
+	// 2. 生成数据对象（DO）
+	fmt.Println("📝 生成数据对象（DO）:")
+	for i, agg := range changedTargets {
+		fmt.Printf("%d. %sDO.go", i+1, agg.Name)
+
+		if err := doGenerator.Generate(agg, outputDir); err != nil {
+			fmt.Printf(" ⚠️  失败: %v\n", err)
+			failedCount++
+			continue
+		}
+
+		doCount++
+		fmt.Printf(" ✅\n")
+	}
+	fmt.Println()
+
+	// 3. 生成查询字段（Query Fields）
+	fmt.Println("📝 生成查询字段（类型安全查询）:")
+	// 先生成通用字段类型定义（每个限界上下文的 query 包各一份）
+	for _, boundedContext := range targetContexts(targets) {
+		fmt.Printf("0. %s", filepath.ToSlash(filepath.Join(boundedContext, "field_types.go")))
+		if err := queryFieldGenerator.GenerateFieldTypes(outputDir, boundedContext); err != nil {
+			fmt.Printf(" ⚠️  失败: %v\n", err)
+			failedCount++
+		} else {
+			fmt.Printf(" ✅\n")
+		}
+	}
+	// 为每个聚合根生成查询字段
+	for i, agg := range changedTargets {
+		fmt.Printf("%d. %sFields.go", i+1, agg.Name)
+
+		if err := queryFieldGenerator.Generate(agg, outputDir); err != nil {
+			fmt.Printf(" ⚠️  失败: %v\n", err)
+			failedCount++
+			continue
+		}
+
+		queryFieldCount++
+		fmt.Printf(" ✅\n")
+	}
+	fmt.Println()
+
+	// 生成列名常量（供手写的扩展查询引用）
+	fmt.Println("📝 生成列名常量:")
+	for i, agg := range changedTargets {
+		fmt.Printf("%d. %sColumns.go", i+1, agg.Name)
+
+		if err := columnGenerator.Generate(agg, outputDir); err != nil {
+			fmt.Printf(" ⚠️  失败: %v\n", err)
+			failedCount++
+			continue
+		}
+
+		columnCount++
+		fmt.Printf(" ✅\n")
+	}
+	fmt.Println()
+
+	// 4. 生成转换器
+	fmt.Println("📝 生成转换器:")
+	for i, agg := range changedTargets {
+		fmt.Printf("%d. %sConvertor.go", i+1, agg.Name)
+
+		if err := convertorGenerator.Generate(agg, outputDir); err != nil {
+			fmt.Printf(" ⚠️  失败: %v\n", err)
+			failedCount++
+			continue
+		}
+
+		convertorCount++
+		fmt.Printf(" ✅\n")
+	}
+	fmt.Println()
+
+	// 5. 生成仓储接口
+	fmt.Println("📝 生成仓储接口:")
+	for i, agg := range changedTargets {
+		fmt.Printf("%d. %sRepository.go", i+1, agg.Name)
+
+		if err := repoInterfaceGenerator.Generate(agg, outputDir); err != nil {
+			fmt.Printf(" ⚠️  失败: %v\n", err)
+			failedCount++
+			continue
+		}
+
+		repoInterfaceCount++
+		fmt.Printf(" ✅\n")
+	}
+	fmt.Println()
+
+	// 6. 生成仓储实现
+	fmt.Println("📝 生成仓储实现:")
+	for i, agg := range changedTargets {
+		fmt.Printf("%d. %sRepositoryImpl.go", i+1, agg.Name)
+
+		if err := repoImplGenerator.Generate(agg, outputDir); err != nil {
+			fmt.Printf(" ⚠️  失败: %v\n", err)
+			failedCount++
+			continue
+		}
+
+		repoImplCount++
+		fmt.Printf(" ✅\n")
+	}
+	fmt.Println()
+
+	// 7. 生成领域服务实现
+	fmt.Println("📝 生成领域服务实现:")
+	for i, agg := range changedTargets {
+		fmt.Printf("%d. %sServiceImpl.go", i+1, agg.Name)
+
+		if err := serviceImplGenerator.Generate(agg, outputDir); err != nil {
+			fmt.Printf(" ⚠️  失败: %v\n", err)
+			failedCount++
+			continue
+		}
+
+		serviceImplCount++
+		fmt.Printf(" ✅\n")
+	}
+	fmt.Println()
+
+	// 生成读模型（+soliton:query 声明的查询）
+	var queried []*metadata.AggregateMetadata
+	for _, agg := range changedTargets {
+		if len(agg.Queries) > 0 {
+			queried = append(queried, agg)
+		}
+	}
+	if len(queried) > 0 {
+		fmt.Println("📝 生成读模型:")
+		for i, agg := range queried {
+			fmt.Printf("%d. %sQueries.go、%sReadRepositoryImpl.go", i+1, agg.Name, agg.Name)
+
+			if err := readModelGenerator.Generate(agg, outputDir); err != nil {
+				fmt.Printf(" ⚠️  失败: %v\n", err)
+				failedCount++
+				continue
+			}
+
+			readModelCount++
+			fmt.Printf(" ✅\n")
+		}
+		fmt.Println()
+	}
+
+	// 8. 生成 REST 处理器
+	if opts.httpFramework != "" {
+		fmt.Printf("📝 生成 REST 处理器（%s）:\n", opts.httpFramework)
+		// 路由注册包含上下文内全部对外暴露的聚合根，-only 只影响重新生成的处理器
+		exposed := exposedAggregates(registry, metadata.APIProtocolREST)
+		for _, boundedContext := range targetContexts(exposed) {
+			fmt.Printf("0. %s", filepath.ToSlash(filepath.Join(boundedContext, "router.go")))
+			if err := httpHandlerGenerator.GenerateRouter(exposed, outputDir, boundedContext); err != nil {
+				fmt.Printf(" ⚠️  失败: %v\n", err)
+				failedCount++
+			} else {
+				fmt.Printf(" ✅\n")
+			}
+		}
+		for i, agg := range filterAggregates(exposed, regenerate) {
+			fmt.Printf("%d. %sDTO.go、%sHandler.go", i+1, agg.Name, agg.Name)
+
+			// 处理器只通过 DTO 读写领域对象，二者一同生成
+			if err := dtoGenerator.Generate(agg, outputDir); err != nil {
+				fmt.Printf(" ⚠️  失败: %v\n", err)
+				failedCount++
+				continue
+			}
+			dtoCount++
+
+			if err := httpHandlerGenerator.Generate(agg, outputDir); err != nil {
+				fmt.Printf(" ⚠️  失败: %v\n", err)
+				failedCount++
+				continue
+			}
+
+			httpHandlerCount++
+			fmt.Printf(" ✅\n")
+		}
+
+		// 接口文档与处理器一同生成，始终包含全部对外暴露的聚合根
+		if len(exposed) > 0 {
+			fmt.Printf("%d. openapi.yaml", len(filterAggregates(exposed, regenerate))+1)
+			if err := openAPIGenerator.Generate(exposed, outputDir); err != nil {
+				fmt.Printf(" ⚠️  失败: %v\n", err)
+				failedCount++
+			} else {
+				fmt.Printf(" ✅\n")
+			}
+		}
+		fmt.Println()
+	}
+
+	// 9. 生成 gRPC 服务
+	if opts.grpc {
+		fmt.Println("📝 生成 gRPC 服务:")
+		// .proto 和服务注册包含上下文内全部对外暴露的聚合根，-only 只影响重新生成的适配器
+		exposed := exposedAggregates(registry, metadata.APIProtocolGRPC)
+		for _, boundedContext := range targetContexts(exposed) {
+			fmt.Printf("0. %s", filepath.ToSlash(filepath.Join(boundedContext, "rpc")))
+			if err := grpcGenerator.GenerateService(exposed, outputDir, boundedContext); err != nil {
+				fmt.Printf(" ⚠️  失败: %v\n", err)
+				failedCount++
+			} else {
+				fmt.Printf(" ✅\n")
+			}
+		}
+		for i, agg := range filterAggregates(exposed, regenerate) {
+			fmt.Printf("%d. %sServer.go", i+1, agg.Name)
+
+			if err := grpcGenerator.Generate(agg, outputDir); err != nil {
+				fmt.Printf(" ⚠️  失败: %v\n", err)
+				failedCount++
+				continue
+			}
+
+			grpcServerCount++
+			fmt.Printf(" ✅\n")
+		}
+		fmt.Println()
+	}
+
+	// 10. 生成 GraphQL
+	if opts.graphql {
+		fmt.Println("📝 生成 GraphQL:")
+		// schema 和共用的解析器包含上下文内全部对外暴露的聚合根，-only 只影响重新生成的解析器
+		exposed := exposedAggregates(registry, metadata.APIProtocolGraphQL)
+		for _, boundedContext := range targetContexts(exposed) {
+			fmt.Printf("0. %s", filepath.ToSlash(filepath.Join(boundedContext, "graph")))
+			if err := graphQLGenerator.GenerateService(exposed, outputDir, boundedContext); err != nil {
+				fmt.Printf(" ⚠️  失败: %v\n", err)
+				failedCount++
+			} else {
+				fmt.Printf(" ✅\n")
+			}
+		}
+		for i, agg := range filterAggregates(exposed, regenerate) {
+			fmt.Printf("%d. %sResolver.go", i+1, agg.Name)
+
+			if err := graphQLGenerator.Generate(agg, outputDir); err != nil {
+				fmt.Printf(" ⚠️  失败: %v\n", err)
+				failedCount++
+				continue
+			}
+
+			graphQLResolverCount++
+			fmt.Printf(" ✅\n")
+		}
+		fmt.Println()
+	}
+
+	// 11. 生成依赖注入
+	if opts.diFramework != "" {
+		fmt.Printf("📝 生成依赖注入（%s）:\n", opts.diFramework)
+		// 提供者汇总上下文内的全部聚合根，-only 不影响
+		all := registry.GetAll()
+		var exposed []*metadata.AggregateMetadata
+		if opts.httpFramework != "" {
+			exposed = exposedAggregates(registry, metadata.APIProtocolREST)
+		}
+		for i, boundedContext := range targetContexts(all) {
+			fmt.Printf("%d. %s", i+1, filepath.ToSlash(filepath.Join(boundedContext, "providers.go")))
+			if err := diGenerator.GenerateContext(all, exposed, outputDir, boundedContext); err != nil {
+				fmt.Printf(" ⚠️  失败: %v\n", err)
+				failedCount++
+				continue
+			}
+
+			diCount++
+			fmt.Printf(" ✅\n")
+		}
+		fmt.Printf("%d. di/container.go", len(targetContexts(all))+1)
+		if err := diGenerator.GenerateContainer(all, exposed, outputDir); err != nil {
+			fmt.Printf(" ⚠️  失败: %v\n", err)
+			failedCount++
+		} else {
+			fmt.Printf(" ✅\n")
+		}
+		fmt.Println()
+	}
+
+	// 12. 生成模拟实现
+	if opts.mocks {
+		fmt.Println("📝 生成模拟实现:")
+		for i, agg := range changedTargets {
+			fmt.Printf("%d. %sRepository.go、%sService.go", i+1, agg.Name, agg.Name)
+
+			if err := mockGenerator.Generate(agg, outputDir); err != nil {
+				fmt.Printf(" ⚠️  失败: %v\n", err)
+				failedCount++
+				continue
+			}
+
+			mockCount++
+			fmt.Printf(" ✅\n")
+		}
+		fmt.Println()
+	}
+
+	// 生成测试数据构建器
+	if opts.fixtures {
+		fmt.Println("📝 生成测试数据构建器:")
+		for i, agg := range changedTargets {
+			fmt.Printf("%d. %sBuilder.go", i+1, agg.Name)
+
+			if err := fixtureGenerator.Generate(agg, outputDir); err != nil {
+				fmt.Printf(" ⚠️  失败: %v\n", err)
+				failedCount++
+				continue
+			}
+
+			fixtureCount++
+			fmt.Printf(" ✅\n")
+		}
+		fmt.Println()
+	}
+
+	// 13. 生成内存仓储
+	if opts.memory {
+		fmt.Println("📝 生成内存仓储:")
+		for i, agg := range changedTargets {
+			fmt.Printf("%d. %sRepository.go", i+1, agg.Name)
+
+			if err := memoryRepoGenerator.Generate(agg, outputDir); err != nil {
+				fmt.Printf(" ⚠️  失败: %v\n", err)
+				failedCount++
+				continue
+			}
+
+			memoryRepoCount++
+			fmt.Printf(" ✅\n")
+		}
+		fmt.Println()
+	}
+
+	// 14. 生成仓储集成测试
+	if opts.integration {
+		fmt.Printf("📝 生成仓储集成测试（%s）:\n", opts.dialect.Name())
+		for _, boundedContext := range targetContexts(targets) {
+			fmt.Printf("0. %s", filepath.ToSlash(filepath.Join(boundedContext, "main_integration_test.go")))
+			if err := integrationTestGenerator.GenerateContext(targets, outputDir, boundedContext); err != nil {
+				fmt.Printf(" ⚠️  失败: %v\n", err)
+				failedCount++
+			} else {
+				fmt.Printf(" ✅\n")
+			}
+		}
+		for i, agg := range changedTargets {
+			fmt.Printf("%d. %sRepositoryImpl_integration_test.go", i+1, agg.Name)
+
+			if err := integrationTestGenerator.Generate(agg, outputDir); err != nil {
+				fmt.Printf(" ⚠️  失败: %v\n", err)
+				failedCount++
+				continue
+			}
+
+			integrationTestCount++
+			fmt.Printf(" ✅\n")
+		}
+		fmt.Println()
+	}
+
+	// 15. 生成 outbox 中继
+	if opts.outboxBroker != "" {
+		fmt.Printf("📝 生成 outbox 中继（%s）:\n", opts.outboxBroker)
+		fmt.Printf("1. outbox/relay.go")
+		if err := outboxGenerator.Generate(outputDir); err != nil {
+			fmt.Printf(" ⚠️  失败: %v\n", err)
+			failedCount++
+		} else {
+			fmt.Printf(" ✅\n")
+		}
+		fmt.Println()
+	}
+
+	// 16. 运行插件（插件读取全部聚合根，不受 -only 限制）
+	if len(plugins) > 0 {
+		fmt.Println("📝 运行插件:")
+		for i, plugin := range plugins {
+			// 外部插件的标准错误转发到终端，运行结束后再输出结果行，避免输出交错
+			written, err := pluginGenerator.Generate(plugin, opts.pluginOptions[plugin.Name()], outputDir)
+			pluginFileCount += len(written)
+			if err != nil {
+				fmt.Printf("%d. %s ⚠️  失败: %v\n", i+1, plugin.Name(), err)
+				failedCount++
+			} else {
+				fmt.Printf("%d. %s ✅ %d 个文件\n", i+1, plugin.Name(), len(written))
+			}
+		}
+		fmt.Println()
+	}
+
+	// 全部生成成功时记录本次重新生成的聚合根的输入摘要，失败的聚合根下次仍会重新生成
+	if incremental != nil && !opts.dryRun && failedCount == 0 {
+		if err := incremental.Save(changedTargets); err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+			failedCount++
+		}
+	}
+
+	fmt.Println("=" + repeat("=", 50))
+	if opts.dryRun {
+		fmt.Println("✨ 预览完成！（-dry-run，未写入任何文件）")
+	} else {
+		fmt.Println("✨ 代码生成完成！")
+	}
+	fmt.Println()
+	fmt.Println("📊 生成统计:")
+	if modelCount > 0 {
+		fmt.Printf("   - 领域模型: %d 个\n", modelCount)
+	}
+	fmt.Printf("   - SQL 建表脚本: %d 个\n", len(sqlContexts))
+	fmt.Printf("   - Entity 接口实现: %d 个\n", entityCount)
+	fmt.Printf("   - 枚举类型: %d 个\n", enumCount)
+	if eventCount > 0 {
+		fmt.Printf("   - 领域事件: %d 个聚合根\n", eventCount)
+	}
+	fmt.Printf("   - 数据对象（DO）: %d 个\n", doCount)
+	fmt.Printf("   - 查询字段: %d 个\n", queryFieldCount)
+	fmt.Printf("   - 列名常量: %d 个\n", columnCount)
+	fmt.Printf("   - 转换器: %d 个\n", convertorCount)
+	fmt.Printf("   - 仓储接口: %d 个\n", repoInterfaceCount)
+	fmt.Printf("   - 仓储实现: %d 个\n", repoImplCount)
+	fmt.Printf("   - 服务实现: %d 个\n", serviceImplCount)
+	if readModelCount > 0 {
+		fmt.Printf("   - 读模型: %d 个聚合根\n", readModelCount)
+	}
+	if opts.httpFramework != "" {
+		fmt.Printf("   - DTO: %d 个\n", dtoCount)
+		fmt.Printf("   - REST 处理器: %d 个\n", httpHandlerCount)
+	}
+	if opts.grpc {
+		fmt.Printf("   - gRPC 服务: %d 个\n", grpcServerCount)
+	}
+	if opts.graphql {
+		fmt.Printf("   - GraphQL 解析器: %d 个\n", graphQLResolverCount)
+	}
+	if opts.diFramework != "" {
+		fmt.Printf("   - 依赖注入提供者: %d 个上下文\n", diCount)
+	}
+	if opts.mocks {
+		fmt.Printf("   - 模拟实现: %d 个聚合根\n", mockCount)
+	}
+	if opts.fixtures {
+		fmt.Printf("   - 测试数据构建器: %d 个\n", fixtureCount)
+	}
+	if opts.memory {
+		fmt.Printf("   - 内存仓储: %d 个\n", memoryRepoCount)
+	}
+	if opts.integration {
+		fmt.Printf("   - 仓储集成测试: %d 个\n", integrationTestCount)
+	}
+	if len(plugins) > 0 {
+		fmt.Printf("   - 插件生成文件: %d 个\n", pluginFileCount)
+	}
+	fmt.Println()
+
+	var outdated []string
+	if opts.check {
+		var err error
+		if outdated, err = printDiffs(dryRunWriter); err != nil {
+			return fail(exitGenerateError, "比较生成结果失败: %v", err)
+		}
+	} else if dryRunWriter != nil {
+		files := dryRunWriter.Files()
+		fmt.Printf("📄 将要写入 %d 个文件:\n", len(files))
+		for _, file := range files {
+			fmt.Printf("   - %s\n", displayPath(file))
+		}
+		fmt.Println()
+	} else {
+		fmt.Println("📂 生成目录:")
+		fmt.Printf("   - SQL 脚本: %s\n", filepath.Join(outputDir, "sql"))
+		fmt.Printf("   - Entity 接口实现: %s（已追加到原领域模型文件）\n", modelDir)
+		fmt.Printf("   - 枚举类型: %s\n", filepath.Join(outputDir, "enum"))
+		fmt.Printf("   - DO: %s\n", filepath.Join(filepath.Dir(outputDir), "infrastructure/do"))
+		fmt.Printf("   - 查询字段: %s\n", filepath.Join(filepath.Dir(outputDir), "infrastructure/query"))
+		fmt.Printf("   - 列名常量: %s\n", filepath.Join(filepath.Dir(outputDir), "infrastructure/columns"))
+		fmt.Printf("   - 转换器: %s\n", filepath.Join(filepath.Dir(outputDir), "infrastructure/convertor"))
+		fmt.Printf("   - 仓储接口: %s\n", filepath.Join(outputDir, "repository"))
+		fmt.Printf("   - 仓储实现: %s\n", filepath.Join(filepath.Dir(outputDir), "infrastructure/repository"))
+		fmt.Printf("   - 服务实现: %s\n", filepath.Join(outputDir, "service/impl"))
+		if readModelCount > 0 {
+			fmt.Printf("   - 读模型: %s（只读仓储实现位于仓储实现目录）\n", filepath.Join(outputDir, "readmodel"))
+		}
+		if opts.httpFramework != "" {
+			fmt.Printf("   - DTO: %s\n", filepath.Join(filepath.Dir(outputDir), "interfaces/dto"))
+			fmt.Printf("   - REST 处理器: %s\n", filepath.Join(filepath.Dir(outputDir), "interfaces/handler"))
+			fmt.Printf("   - OpenAPI 文档: %s\n", filepath.Join(filepath.Dir(outputDir), "interfaces/openapi.yaml"))
+		}
+		if opts.grpc {
+			fmt.Printf("   - gRPC 服务: %s\n", filepath.Join(filepath.Dir(outputDir), "interfaces/rpc"))
+		}
+		if opts.graphql {
+			fmt.Printf("   - GraphQL: %s\n", filepath.Join(filepath.Dir(outputDir), "interfaces/graph"))
+		}
+		if opts.diFramework != "" {
+			fmt.Printf("   - 依赖注入: %s（各层的 providers.go 位于对应目录）\n", filepath.Join(filepath.Dir(outputDir), "di"))
+		}
+		if opts.mocks {
+			fmt.Printf("   - 模拟实现: %s\n", filepath.Join(outputDir, "mocks"))
+		}
+		if opts.fixtures {
+			fmt.Printf("   - 测试数据构建器: %s\n", filepath.Join(outputDir, "fixtures"))
+		}
+		if opts.memory {
+			fmt.Printf("   - 内存仓储: %s\n", filepath.Join(filepath.Dir(outputDir), "infrastructure/memory"))
+		}
+		if opts.integration {
+			fmt.Printf("   - 仓储集成测试: %s（go test -tags integration）\n", filepath.Join(filepath.Dir(outputDir), "infrastructure/repository"))
+		}
+		if opts.outboxBroker != "" {
+			fmt.Printf("   - outbox 中继: %s\n", filepath.Join(filepath.Dir(outputDir), "infrastructure/outbox"))
+		}
+		if contexts := registry.Contexts(); len(contexts) > 0 {
+			fmt.Printf("   - 限界上下文: %s（以上目录下按上下文划分子目录）\n", joinStrings(contexts, ", "))
+		}
+		fmt.Println()
+	}
+
+	if failedCount > 0 {
+		return fail(exitGenerateError, "代码生成失败: %d 个文件生成失败", failedCount)
+	}
+	if recorder != nil {
+		fmt.Println("🔎 检查生成的代码（go vet）...")
+		if err := verifyPackages(recorder.paths, opts.integration); err != nil {
+			return fail(exitGenerateError, "生成的代码未通过检查: %v", err)
+		}
+		fmt.Println("✅ 生成的代码通过 go vet")
+		fmt.Println()
+	}
+
+	if len(outdated) > 0 {
+		return fail(exitOutdated, "生成结果与磁盘上的 %d 个文件不一致，请重新生成: %s", len(outdated), joinStrings(outdated, ", "))
+	}
+
+	if !opts.dryRun {
+		fmt.Println("💡 完成！所有DDD基础设施代码已生成")
+	}
+	return exitOK
+}
+
+// exposedAggregates 返回通过协议 protocol 对外暴露的聚合根；没有聚合根声明 +soliton:api 时返回全部聚合根
+func exposedAggregates(registry *metadata.AggregateMetadataRegistry, protocol string) []*metadata.AggregateMetadata {
+	all := registry.GetAll()
+	if slices.ContainsFunc(all, func(agg *metadata.AggregateMetadata) bool { return agg.API != nil }) {
+		return registry.GetExposed(protocol)
+	}
+	return all
+}
+
+// targetContexts 返回待生成聚合根涉及的限界上下文（按名称排序，空字符串表示未声明上下文）
+func targetContexts(targets []*metadata.AggregateMetadata) []string {
+	seen := make(map[string]bool)
+	var contexts []string
+	for _, agg := range targets {
+		if !seen[agg.Context()] {
+			seen[agg.Context()] = true
+			contexts = append(contexts, agg.Context())
+		}
+	}
+	sort.Strings(contexts)
+	return contexts
+}
+
+// printAggregateSummary 打印聚合根摘要信息
+func printAggregateSummary(index int, agg *metadata.AggregateMetadata) {
+	fmt.Printf("%d. 📦 %s\n", index, agg.Name)
+	fmt.Printf("   包名: %s\n", agg.PackageName)
+	if agg.Context() != "" {
+		fmt.Printf("   🗂️  限界上下文: %s\n", agg.Context())
+	}
+
+	// 打印 ID 字段
+	if agg.IDField != nil {
+		fmt.Printf("   🔑 ID 字段: %s (%s)\n", agg.IDField.Name, agg.IDField.Type)
+	} else if agg.IsCompositeKey() {
+		keyFields := make([]string, len(agg.PrimaryKey))
+		for i, field := range agg.PrimaryKey {
+			keyFields[i] = fmt.Sprintf("%s (%s)", field.Name, field.Type)
+		}
+		fmt.Printf("   🔑 复合主键: %s\n", strings.Join(keyFields, ", "))
+	}
+
+	// 打印 BaseEntity 特性
+	baseFeatures := []string{}
+	if agg.BaseEntity.HasDeletedAt {
+		baseFeatures = append(baseFeatures, "软删除")
+	}
+	if agg.BaseEntity.HasVersion {
+		baseFeatures = append(baseFeatures, "乐观锁")
+	}
+	if agg.BaseEntity.HasCreatedAt || agg.BaseEntity.HasUpdatedAt {
+		baseFeatures = append(baseFeatures, "审计")
+	}
+
+	if len(baseFeatures) > 0 {
+		fmt.Printf("   🛡️  特性: %s\n", joinStrings(baseFeatures, ", "))
+	}
+
+	// 统计字段注解
+	uniqueCount := 0
+	refCount := 0
+	requiredCount := 0
+	entityCount := 0
+	ignoredCount := 0
+
+	for _, field := range agg.Fields {
+		if field.Annotations.IsUnique {
+			uniqueCount++
+		}
+		if field.Annotations.IsRef {
+			refCount++
+		}
+		if field.Annotations.IsRequired {
+			requiredCount++
+		}
+		if field.Annotations.IsEntity {
+			entityCount++
+		}
+		if field.Annotations.IsIgnored {
+			ignoredCount++
+		}
+	}
+
+	fmt.Printf("   📊 字段统计: %d 个字段", len(agg.Fields))
+	if uniqueCount > 0 {
+		fmt.Printf(", %d 个唯一索引", uniqueCount)
+	}
+	if refCount > 0 {
+		fmt.Printf(", %d 个外键", refCount)
+	}
+	if requiredCount > 0 {
+		fmt.Printf(", %d 个必填", requiredCount)
+	}
+	if entityCount > 0 {
+		fmt.Printf(", %d 个关联实体", entityCount)
+	}
+	if ignoredCount > 0 {
+		fmt.Printf(", %d 个忽略", ignoredCount)
+	}
+	fmt.Println()
+
+	// 打印领域行为
+	if len(agg.Behaviors) > 0 {
+		fmt.Printf("   ⚙️  领域行为: %d 个", len(agg.Behaviors))
+		if commands := agg.Commands(); len(commands) > 0 {
+			fmt.Printf("，其中 %d 个命令", len(commands))
+		}
+		fmt.Println()
+	}
+
+	// 打印对外暴露的 API
+	if agg.API != nil {
+		fmt.Printf("   🌐 API: %s %s (%s)\n", strings.Join(agg.API.Protocols, "/"), agg.APIPath(), strings.Join(agg.API.Operations(), ", "))
+	}
+
+	// 打印声明的查询
+	for _, query := range agg.Queries {
+		fmt.Printf("   🔎 查询: %s(%s)\n", query.Name, strings.Join(query.By, ", "))
+	}
+
+	// 打印关联关系
+	if len(agg.Annotations.Refs) > 0 {
+		fmt.Printf("   🔗 多对多关联: %v\n", agg.Annotations.Refs)
+	}
+
+	fmt.Println()
+}
+
+// printComplexityReport 打印模型复杂度报告
+func printComplexityReport(report *analyzer.ComplexityReport) {
+	// 表头的中文占两列宽度，聚合根名按 ASCII 宽度对齐
+	nameWidth := 6
+	for _, item := range report.Aggregates {
+		nameWidth = max(nameWidth, len(item.Name))
+	}
+
+	fmt.Println("📐 模型复杂度:")
+	fmt.Printf("   聚合根%s  字段  扇入  扇出  集合  深度\n", repeat(" ", nameWidth-6))
+	for _, item := range report.Aggregates {
+		fmt.Printf("   %-*s  %4d  %4d  %4d  %4d  %4d\n", nameWidth, item.Name, item.Fields, item.FanIn, item.FanOut, len(item.Collections), item.Depth)
+	}
+	fmt.Printf("   最大包含深度: %d\n", report.MaxDepth)
+	fmt.Println()
+
+	if len(report.Warnings) > 0 {
+		fmt.Printf("⚠️  %d 条复杂度提示:\n", len(report.Warnings))
+		for _, warning := range report.Warnings {
+			fmt.Printf("  - %s\n", warning)
+		}
+		fmt.Println()
+	}
+}
+
+// printDiffReport 打印与基线元数据的差异
+func printDiffReport(baseline string, report *diff.Report) {
+	if !report.HasChanges() {
+		fmt.Printf("🔀 与基线 %s 相比没有变更\n\n", baseline)
+		return
+	}
+
+	fmt.Printf("🔀 与基线 %s 相比有 %d 项变更（%d 项不兼容）:\n", baseline, len(report.Changes), len(report.Breaking()))
+	for _, change := range report.Changes {
+		marker := "  "
+		if change.Breaking {
+			marker = "⚠️ "
+		}
+		fmt.Printf("  %s %s\n", marker, change)
+	}
+	fmt.Println()
+}
+
+// printEventSummary 打印领域事件及其消息主题，没有事件时不输出
+func printEventSummary(registry *metadata.AggregateMetadataRegistry) {
+	events := registry.GetEvents()
+	if len(events) == 0 {
+		return
+	}
+
+	fmt.Printf("📣 领域事件: %d 个\n", len(events))
+	for _, event := range events {
+		fmt.Printf("   - %s.%s → %s\n", event.AggregateName, event.Name, event.Topic())
+	}
+	fmt.Println()
+}
+
+// printRelationSummary 打印关系统计和详情
+func printRelationSummary(registry *metadata.AggregateMetadataRegistry) {
+	relations := registry.GetRelations()
+	manyToManyTables := registry.GetManyToManyTables()
+
+	fmt.Printf("📊 关系统计:\n")
+	fmt.Printf("   - 总关系数: %d\n", len(relations))
+
+	// 统计各类关系
+	oneToOneCount := 0
+	oneToManyCount := 0
+	manyToManyCount := 0
+	refCount := 0
+	polymorphicCount := 0
+	bidirectionalCount := 0
+
+	for _, rel := range relations {
+		if rel.IsBackReference() {
+			bidirectionalCount++
+		}
+		switch rel.Type {
+		case metadata.RelationTypeOneToOne:
+			oneToOneCount++
+		case metadata.RelationTypeOneToMany:
+			oneToManyCount++
+		case metadata.RelationTypeManyToMany:
+			manyToManyCount++
+		case metadata.RelationTypeRef:
+			refCount++
+		case metadata.RelationTypePolymorphic:
+			polymorphicCount++
+		}
+	}
+
+	fmt.Printf("   - 一对一: %d\n", oneToOneCount)
+	fmt.Printf("   - 一对多: %d\n", oneToManyCount)
+	fmt.Printf("   - 多对多: %d\n", manyToManyCount)
+	fmt.Printf("   - 外部引用: %d\n", refCount)
+	fmt.Printf("   - 多态关联: %d\n", polymorphicCount)
+	fmt.Printf("   - 双向关联: %d\n", bidirectionalCount)
+	fmt.Printf("   - 关联表: %d\n", len(manyToManyTables))
+	fmt.Println()
+
+	// 打印详细关系信息
+	if len(relations) > 0 {
+		fmt.Println("🔗 关系详情:")
+		for i, rel := range relations {
+			typeName := relationTypeName(rel.Type)
+			if rel.SelfReference {
+				typeName += "，自引用"
+			}
+			fmt.Printf("%d. %s → %s (%s)\n",
+				i+1,
+				rel.SourceAggregate,
+				rel.TargetAggregate,
+				typeName)
+			if rel.Field != nil {
+				fmt.Printf("   字段: %s\n", rel.Field.Name)
+			}
+			if rel.ForeignKeyColumn != "" {
+				holder := rel.TargetAggregate
+				if rel.ForeignKeyOnSource() {
+					holder = rel.SourceAggregate
+				}
+				fmt.Printf("   外键列: %s.%s\n", holder, rel.ForeignKeyColumn)
+			}
+			if rel.Cascade != "" {
+				fmt.Printf("   级联: %s（外键 %s.%s）\n", rel.Cascade, rel.TargetAggregate, rel.ForeignKey.Name)
+			}
+			if rel.Through != "" {
+				fmt.Printf("   中间实体: %s\n", rel.Through)
+			}
+			if rel.Inverse != nil {
+				owner := "对端持有外键"
+				if rel.IsOwner {
+					owner = "本端持有外键"
+				}
+				fmt.Printf("   双向: %s.%s（%s）\n", rel.TargetAggregate, rel.InverseField, owner)
+			}
+		}
+		fmt.Println()
+	}
+
+	// 打印多对多关联表
+	if len(manyToManyTables) > 0 {
+		fmt.Println("📋 多对多关联表:")
+		for i, table := range manyToManyTables {
+			fmt.Printf("%d. %s (%s ↔ %s)\n",
+				i+1,
+				table.TableName,
+				table.LeftAggregate,
+				table.RightAggregate)
+			fmt.Printf("   列: %s, %s\n", table.LeftColumn, table.RightColumn)
+			if table.Association != "" {
+				fmt.Printf("   中间实体: %s（表结构随聚合根生成）\n", table.Association)
+			}
+		}
+		fmt.Println()
+	}
+}
+
+// writeMetadataJSON 将注册表快照导出为 JSON 文件
+func writeMetadataJSON(registry *metadata.AggregateMetadataRegistry, path string) error {
+	data, err := json.MarshalIndent(registry.Snapshot(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// readMetadataJSON 从 writeMetadataJSON 导出的 JSON 文件加载注册表
+func readMetadataJSON(path string) (*metadata.AggregateMetadataRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return metadata.LoadFromJSON(data)
+}
+
+// filterAggregates 按 -only 过滤聚合根，selected 为 nil 时返回全部
+func filterAggregates(aggregates []*metadata.AggregateMetadata, selected map[string]bool) []*metadata.AggregateMetadata {
+	if selected == nil {
+		return aggregates
+	}
+
+	result := make([]*metadata.AggregateMetadata, 0, len(selected))
+	for _, agg := range aggregates {
+		if selected[agg.Name] {
+			result = append(result, agg)
+		}
+	}
+	return result
+}
+
+// incrementalFingerprint 计算增量生成的全局摘要：soliton 可执行文件（含内置模板和编译期插件）、影响生成内容的参数和覆盖的模板
+func incrementalFingerprint(opts *options, templates *generator.Templates) (string, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("定位 soliton 可执行文件失败: %w", err)
+	}
+	binary, err := os.ReadFile(executable)
+	if err != nil {
+		return "", fmt.Errorf("读取 soliton 可执行文件失败: %w", err)
+	}
+
+	hash := sha256.New()
+	hash.Write(binary)
+	input, err := json.Marshal(struct {
+		Flags       []string               `json:"flags"`
+		ScalarTypes []*metadata.ScalarType `json:"scalarTypes"`
+		Templates   string                 `json:"templates"`
+	}{opts.generationFlags, opts.scalarTypes, templates.Digest()})
+	if err != nil {
+		return "", fmt.Errorf("序列化生成参数失败: %w", err)
+	}
+	hash.Write(input)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// printDiffs 以 unified diff 输出预览写入器中与磁盘上内容不同的文件，返回这些文件的路径
+// 文件名相对当前目录并加上 a/、b/ 前缀，输出可以通过 git apply 应用
+func printDiffs(dryRunWriter *generator.DryRunWriter) ([]string, error) {
+	var outdated []string
+	var sb strings.Builder
+	for _, path := range dryRunWriter.Files() {
+		content, _ := dryRunWriter.Content(path)
+		previous, err := os.ReadFile(path)
+		exists := err == nil
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		name := filepath.ToSlash(displayPath(path))
+		oldName, newName := name, name
+		if !filepath.IsAbs(name) {
+			oldName, newName = "a/"+name, "b/"+name
+		}
+		if !exists {
+			oldName = "/dev/null"
+		}
+		if diff := generator.UnifiedDiff(oldName, newName, string(previous), string(content)); diff != "" {
+			outdated = append(outdated, name)
+			sb.WriteString(diff)
+		}
+	}
+
+	if len(outdated) == 0 {
+		fmt.Printf("✅ 生成结果与磁盘上的 %d 个文件一致\n", len(dryRunWriter.Files()))
+		fmt.Println()
+		return nil, nil
+	}
+	fmt.Printf("📝 %d 个文件与生成结果不一致:\n", len(outdated))
+	fmt.Println()
+	fmt.Print(sb.String())
+	fmt.Println()
+	return outdated, nil
+}
+
+// recordingWriter 记录写出的文件路径，供 -verify 检查生成的包
+type recordingWriter struct {
+	generator.FileWriter
+	paths []string
+}
+
+// WriteFile 写出文件并记录路径
+func (w *recordingWriter) WriteFile(path string, content []byte) error {
+	if err := w.FileWriter.WriteFile(path, content); err != nil {
+		return err
+	}
+	w.paths = append(w.paths, path)
+	return nil
+}
+
+// verifyPackages 对 paths 中 Go 文件所在的包运行 go vet，按所在模块（go.mod 所在目录）分别检查
+func verifyPackages(paths []string, integration bool) error {
+	modules := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		if !strings.HasSuffix(path, ".go") {
+			continue
+		}
+		dir, err := filepath.Abs(filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		if seen[dir] {
+			continue
+		}
+		seen[dir] = true
+
+		root := dir
+		for {
+			if _, err := os.Stat(filepath.Join(root, "go.mod")); err == nil {
+				break
+			}
+			parent := filepath.Dir(root)
+			if parent == root {
+				return fmt.Errorf("%s 不在 Go 模块中（找不到 go.mod）", displayPath(dir))
+			}
+			root = parent
+		}
+		rel, err := filepath.Rel(root, dir)
+		if err != nil {
+			return err
+		}
+		modules[root] = append(modules[root], "./"+filepath.ToSlash(rel))
+	}
+
+	roots := make([]string, 0, len(modules))
+	for root := range modules {
+		roots = append(roots, root)
+	}
+	sort.Strings(roots)
+	for _, root := range roots {
+		args := []string{"vet"}
+		if integration {
+			args = append(args, "-tags", "integration")
+		}
+		pkgs := modules[root]
+		sort.Strings(pkgs)
+		cmd := exec.Command("go", append(args, pkgs...)...)
+		cmd.Dir = root
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("在模块 %s 中运行 go vet 失败: %w", displayPath(root), err)
+		}
+	}
+	return nil
+}
+
+// displayPath 将路径转换为相对当前目录的形式，无法转换时原样返回
+func displayPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(cwd, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return abs
+	}
+	return rel
+}
+
+// fail 输出错误信息并返回退出码
+func fail(code int, format string, args ...interface{}) int {
+	fmt.Fprintf(os.Stderr, "❌ "+format+"\n", args...)
+	return code
+}
+
+func toLowerFirst(s string) string {
+	if len(s) == 0 {
+		return s
+	}
+	runes := []rune(s)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+func relationTypeName(t metadata.RelationType) string {
+	switch t {
+	case metadata.RelationTypeOneToOne:
+		return "一对一"
+	case metadata.RelationTypeOneToMany:
+		return "一对多"
+	case metadata.RelationTypeManyToMany:
+		return "多对多"
+	case metadata.RelationTypeRef:
+		return "外部引用"
+	case metadata.RelationTypePolymorphic:
+		return "多态关联"
+	default:
+		return "未知"
+	}
+}
+
+func repeat(s string, count int) string {
+	return strings.Repeat(s, count)
+}
+
+func joinStrings(strs []string, sep string) string {
+	return strings.Join(strs, sep)
+}
@@ -0,0 +1,354 @@
+package diff
+
+import (
+	"fmt"
+	"slices"
+	"soliton/pkg/metadata"
+	"strings"
+)
+
+// Kind 变更类型
+type Kind string
+
+const (
+	KindAdded    Kind = "added"    // 新增
+	KindRemoved  Kind = "removed"  // 删除
+	KindModified Kind = "modified" // 修改
+)
+
+// Object 变更的对象
+type Object string
+
+const (
+	ObjectAggregate Object = "aggregate" // 聚合根（表）
+	ObjectField     Object = "field"     // 字段（列）
+	ObjectIndex     Object = "index"     // 索引
+	ObjectRelation  Object = "relation"  // 关系
+	ObjectJoinTable Object = "joinTable" // 多对多关联表
+)
+
+// Change 一项元数据变更
+type Change struct {
+	Kind      Kind   `json:"kind"`
+	Object    Object `json:"object"`
+	Aggregate string `json:"aggregate,omitempty"` // 所属聚合根，关联表为空
+	Name      string `json:"name"`                // 聚合根名、字段名（展开的值对象字段为 Address.City）、索引名、关系（如 Order.Items → OrderItem）或关联表名
+	Old       string `json:"old,omitempty"`       // 变更前的定义，如 "order_no VARCHAR(255) NOT NULL"，新增时为空
+	New       string `json:"new,omitempty"`       // 变更后的定义，删除时为空
+	Breaking  bool   `json:"breaking"`            // 是否为不兼容变更：可能丢失数据、已有数据不满足新约束，或已有调用方失效
+}
+
+// String 返回变更的描述，如 "修改字段 Order.Amount: amount BIGINT NOT NULL → amount DOUBLE NOT NULL"
+func (c *Change) String() string {
+	subject := c.Name
+	if c.Aggregate != "" && c.Object != ObjectAggregate && c.Object != ObjectRelation {
+		subject = c.Aggregate + "." + c.Name
+	}
+
+	var sb strings.Builder
+	sb.WriteString(kindLabels[c.Kind] + objectLabels[c.Object] + " " + subject)
+	switch c.Kind {
+	case KindAdded:
+		if c.New != "" {
+			sb.WriteString(": " + c.New)
+		}
+	case KindRemoved:
+		if c.Old != "" {
+			sb.WriteString(": " + c.Old)
+		}
+	case KindModified:
+		sb.WriteString(": " + c.Old + " → " + c.New)
+	}
+	return sb.String()
+}
+
+var kindLabels = map[Kind]string{
+	KindAdded:    "新增",
+	KindRemoved:  "删除",
+	KindModified: "修改",
+}
+
+var objectLabels = map[Object]string{
+	ObjectAggregate: "聚合根",
+	ObjectField:     "字段",
+	ObjectIndex:     "索引",
+	ObjectRelation:  "关系",
+	ObjectJoinTable: "关联表",
+}
+
+// Report 两份元数据之间的差异
+type Report struct {
+	Changes []*Change `json:"changes"` // 按聚合根名排序，同一聚合根内依次为聚合根、字段、索引、关系，关联表在最后
+}
+
+// HasChanges 判断是否存在变更
+func (r *Report) HasChanges() bool {
+	return len(r.Changes) > 0
+}
+
+// Breaking 返回不兼容变更
+func (r *Report) Breaking() []*Change {
+	var result []*Change
+	for _, change := range r.Changes {
+		if change.Breaking {
+			result = append(result, change)
+		}
+	}
+	return result
+}
+
+// Compare 比较两份元数据，报告从 previous 到 current 的聚合根、字段、索引、关系和多对多关联表的增删改
+//
+// previous 通常由 metadata.LoadFromJSON 从上次导出的元数据加载，current 为当前解析和分析的结果。
+// 列和索引取自按默认方言计算的表结构（见 metadata.NewSchema），与生成的 DDL 一致。
+// 字段和关系按 Go 字段名对应，重命名表现为删除加新增；新增或删除的聚合根只报告聚合根本身，不再逐项报告其字段、索引和关系。
+//
+// 不兼容变更：删除聚合根、字段、关系或关联表，修改表名、列名、列类型，列由可空改为不可空，
+// 新增或修改唯一索引（已有数据可能不满足），修改关系的外键列或关联表的列。
+func Compare(previous, current *metadata.AggregateMetadataRegistry) *Report {
+	report := &Report{}
+
+	var names []string
+	for _, agg := range previous.GetAll() {
+		names = append(names, agg.Name)
+	}
+	for _, agg := range current.GetAll() {
+		if !previous.Exists(agg.Name) {
+			names = append(names, agg.Name)
+		}
+	}
+	slices.Sort(names)
+
+	beforeSchema := metadata.NewSchema(previous, metadata.DefaultDialect())
+	afterSchema := metadata.NewSchema(current, metadata.DefaultDialect())
+
+	for _, name := range names {
+		before, after := previous.Get(name), current.Get(name)
+		switch {
+		case before == nil:
+			report.add(&Change{Kind: KindAdded, Object: ObjectAggregate, Aggregate: name, Name: name, New: after.Table()})
+		case after == nil:
+			report.add(&Change{Kind: KindRemoved, Object: ObjectAggregate, Aggregate: name, Name: name, Old: before.Table(), Breaking: true})
+		default:
+			if before.Table() != after.Table() {
+				report.add(&Change{Kind: KindModified, Object: ObjectAggregate, Aggregate: name, Name: name, Old: before.Table(), New: after.Table(), Breaking: true})
+			}
+			report.compareFields(beforeSchema.Table(name), afterSchema.Table(name))
+			report.compareIndexes(beforeSchema.Table(name), afterSchema.Table(name))
+			report.compareRelations(name, previous, current)
+		}
+	}
+
+	report.compareJoinTables(previous.GetManyToManyTables(), current.GetManyToManyTables())
+	return report
+}
+
+// add 记录一项变更
+func (r *Report) add(change *Change) {
+	r.Changes = append(r.Changes, change)
+}
+
+// column 字段映射的列
+type column struct {
+	name     string // 字段名，展开的值对象字段为 Address.City
+	column   string // 列名
+	sqlType  string // SQL 类型
+	nullable bool   // 是否可空
+}
+
+// String 返回列定义，如 "order_no VARCHAR(255) NOT NULL"
+func (c column) String() string {
+	if c.nullable {
+		return c.column + " " + c.sqlType + " NULL"
+	}
+	return c.column + " " + c.sqlType + " NOT NULL"
+}
+
+// columns 返回表中字段映射的列，按表结构中的顺序排列（主键列在前）
+// 关联实体字段由关系比较，不映射为列
+func columns(table *metadata.TableMetadata) []column {
+	result := make([]column, 0, len(table.Columns))
+	for _, c := range table.Columns {
+		result = append(result, column{name: c.FieldPath, column: c.Name, sqlType: c.Type, nullable: c.Nullable})
+	}
+	return result
+}
+
+// compareFields 比较同一聚合根前后的字段
+func (r *Report) compareFields(before, after *metadata.TableMetadata) {
+	old := make(map[string]column)
+	for _, c := range columns(before) {
+		old[c.name] = c
+	}
+	seen := make(map[string]bool)
+
+	for _, c := range columns(after) {
+		seen[c.name] = true
+		prev, ok := old[c.name]
+		switch {
+		case !ok:
+			r.add(&Change{Kind: KindAdded, Object: ObjectField, Aggregate: after.Aggregate, Name: c.name, New: c.String()})
+		case prev != c:
+			breaking := prev.column != c.column || prev.sqlType != c.sqlType || prev.nullable && !c.nullable
+			r.add(&Change{Kind: KindModified, Object: ObjectField, Aggregate: after.Aggregate, Name: c.name, Old: prev.String(), New: c.String(), Breaking: breaking})
+		}
+	}
+	for _, c := range columns(before) {
+		if !seen[c.name] {
+			r.add(&Change{Kind: KindRemoved, Object: ObjectField, Aggregate: before.Aggregate, Name: c.name, Old: c.String(), Breaking: true})
+		}
+	}
+}
+
+// index 表上的索引
+type index struct {
+	name    string
+	columns string // 逗号分隔的列名，按索引顺序
+	unique  bool
+	where   string // 部分索引的条件
+}
+
+// String 返回索引定义，如 "UNIQUE (tenant_id, email)"、"INDEX (status) WHERE deleted_at IS NULL"
+func (i index) String() string {
+	definition := "INDEX (" + i.columns + ")"
+	if i.unique {
+		definition = "UNIQUE (" + i.columns + ")"
+	}
+	if i.where != "" {
+		definition += " WHERE " + i.where
+	}
+	return definition
+}
+
+// indexes 返回表上的索引：字段上的 +soliton:unique、+soliton:index、+soliton:ref，组合唯一索引，
+// 以及由关系和软删除产生的索引，索引名与生成的 DDL 一致，如 uk_users_email、idx_orders_user_id
+func indexes(table *metadata.TableMetadata) []index {
+	result := make([]index, 0, len(table.Indexes))
+	for _, i := range table.Indexes {
+		result = append(result, index{name: i.Name, columns: strings.Join(i.Columns, ", "), unique: i.Unique, where: i.Where})
+	}
+	return result
+}
+
+// compareIndexes 比较同一聚合根前后的索引，按索引名对应
+func (r *Report) compareIndexes(before, after *metadata.TableMetadata) {
+	old := make(map[string]index)
+	for _, i := range indexes(before) {
+		old[i.name] = i
+	}
+	seen := make(map[string]bool)
+
+	for _, i := range indexes(after) {
+		seen[i.name] = true
+		prev, ok := old[i.name]
+		switch {
+		case !ok:
+			r.add(&Change{Kind: KindAdded, Object: ObjectIndex, Aggregate: after.Aggregate, Name: i.name, New: i.String(), Breaking: i.unique})
+		case prev != i:
+			r.add(&Change{Kind: KindModified, Object: ObjectIndex, Aggregate: after.Aggregate, Name: i.name, Old: prev.String(), New: i.String(), Breaking: i.unique})
+		}
+	}
+	for _, i := range indexes(before) {
+		if !seen[i.name] {
+			r.add(&Change{Kind: KindRemoved, Object: ObjectIndex, Aggregate: before.Aggregate, Name: i.name, Old: i.String()})
+		}
+	}
+}
+
+// relationKey 返回用于对应前后关系的键：关联字段、目标聚合根、关系类型和中间实体
+func relationKey(rel *metadata.RelationMetadata) string {
+	field := ""
+	if rel.Field != nil {
+		field = rel.Field.Name
+	}
+	return strings.Join([]string{field, rel.TargetAggregate, rel.Type.String(), rel.Through}, "|")
+}
+
+// relationName 返回关系的名称，如 "Order.Items → OrderItem"，聚合根级别的多对多关系为 "User ↔ Role"
+func relationName(rel *metadata.RelationMetadata) string {
+	if rel.Field == nil {
+		return rel.SourceAggregate + " ↔ " + rel.TargetAggregate
+	}
+	return rel.SourceAggregate + "." + rel.Field.Name + " → " + rel.TargetAggregate
+}
+
+// relationDetail 返回关系的定义，如 "one_to_many, fk=order_id, cascade=delete"
+func relationDetail(rel *metadata.RelationMetadata) string {
+	parts := []string{rel.Type.String()}
+	if rel.ForeignKeyColumn != "" {
+		parts = append(parts, "fk="+rel.ForeignKeyColumn)
+	}
+	if rel.Cascade != "" {
+		parts = append(parts, "cascade="+rel.Cascade)
+	}
+	if rel.Through != "" {
+		parts = append(parts, "through="+rel.Through)
+	}
+	if rel.External {
+		parts = append(parts, "external")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// compareRelations 比较以指定聚合根为源的前后关系
+func (r *Report) compareRelations(name string, previous, current *metadata.AggregateMetadataRegistry) {
+	old := make(map[string]*metadata.RelationMetadata)
+	for _, rel := range previous.GetRelationsByAggregate(name) {
+		old[relationKey(rel)] = rel
+	}
+	seen := make(map[string]bool)
+
+	for _, rel := range current.GetRelationsByAggregate(name) {
+		key := relationKey(rel)
+		seen[key] = true
+		prev, ok := old[key]
+		switch {
+		case !ok:
+			r.add(&Change{Kind: KindAdded, Object: ObjectRelation, Aggregate: name, Name: relationName(rel), New: relationDetail(rel)})
+		case relationDetail(prev) != relationDetail(rel):
+			r.add(&Change{Kind: KindModified, Object: ObjectRelation, Aggregate: name, Name: relationName(rel),
+				Old: relationDetail(prev), New: relationDetail(rel), Breaking: prev.ForeignKeyColumn != rel.ForeignKeyColumn})
+		}
+	}
+	for _, rel := range previous.GetRelationsByAggregate(name) {
+		if !seen[relationKey(rel)] {
+			r.add(&Change{Kind: KindRemoved, Object: ObjectRelation, Aggregate: name, Name: relationName(rel), Old: relationDetail(rel), Breaking: true})
+		}
+	}
+}
+
+// joinTableDetail 返回关联表的定义，如 "role_id → Role, user_id → User"
+func joinTableDetail(table *metadata.ManyToManyTableMetadata) string {
+	return fmt.Sprintf("%s → %s, %s → %s", table.LeftColumn, table.LeftAggregate, table.RightColumn, table.RightAggregate)
+}
+
+// compareJoinTables 比较前后的多对多关联表，按表名对应
+// 由中间实体（+soliton:manyToMany）生成表结构的关联表已作为聚合根比较，这里跳过
+func (r *Report) compareJoinTables(previous, current []*metadata.ManyToManyTableMetadata) {
+	old := make(map[string]*metadata.ManyToManyTableMetadata)
+	for _, table := range previous {
+		if table.Association == "" {
+			old[table.TableName] = table
+		}
+	}
+	seen := make(map[string]bool)
+
+	for _, table := range current {
+		if table.Association != "" {
+			continue
+		}
+		seen[table.TableName] = true
+		prev, ok := old[table.TableName]
+		switch {
+		case !ok:
+			r.add(&Change{Kind: KindAdded, Object: ObjectJoinTable, Name: table.TableName, New: joinTableDetail(table)})
+		case joinTableDetail(prev) != joinTableDetail(table):
+			r.add(&Change{Kind: KindModified, Object: ObjectJoinTable, Name: table.TableName, Old: joinTableDetail(prev), New: joinTableDetail(table), Breaking: true})
+		}
+	}
+	for _, table := range previous {
+		if table.Association == "" && !seen[table.TableName] {
+			r.add(&Change{Kind: KindRemoved, Object: ObjectJoinTable, Name: table.TableName, Old: joinTableDetail(table), Breaking: true})
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package framework
+
+import "context"
+
+// operatorKey 上下文中操作人的键
+type operatorKey struct{}
+
+// WithOperator 在上下文中保存当前操作人
+//
+// BaseRepository 的 Add/Update 会读取操作人并填充 CreatedBy/UpdatedBy：
+//
+//	ctx = framework.WithOperator(ctx, currentUserID)
+//	err := repo.Add(ctx, order) // order.CreatedBy = order.UpdatedBy = currentUserID
+func WithOperator(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, operatorKey{}, userID)
+}
+
+// OperatorFromContext 从上下文中获取当前操作人
+func OperatorFromContext(ctx context.Context) (int64, bool) {
+	userID, ok := ctx.Value(operatorKey{}).(int64)
+	return userID, ok
+}
+
+// CreatedBySetter 可设置创建人的实体
+type CreatedBySetter interface {
+	SetCreatedBy(operator int64)
+}
+
+// UpdatedBySetter 可设置更新人的实体
+type UpdatedBySetter interface {
+	SetUpdatedBy(operator int64)
+}
+
+// Auditable 可审计实体
+//
+// 实体实现此接口后，BaseRepository 会根据上下文中的操作人自动填充审计字段。
+// 只有 CreatedBy 或只有 UpdatedBy 的实体可以只实现对应的单个接口。
+type Auditable interface {
+	CreatedBySetter
+	UpdatedBySetter
+}
+
+// auditInfoSetter 可设置审计时间的实体（嵌入 BaseEntity 即可获得）
+type auditInfoSetter interface {
+	SetAuditInfo(isNew bool)
+}
+
+// applyAudit 填充审计信息
+// 上下文中没有操作人时不修改 CreatedBy/UpdatedBy，避免把已有值清零
+func applyAudit(ctx context.Context, entity any, isNew bool) {
+	if setter, ok := entity.(auditInfoSetter); ok {
+		setter.SetAuditInfo(isNew)
+	}
+
+	operator, ok := OperatorFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	if isNew {
+		if setter, ok := entity.(CreatedBySetter); ok {
+			setter.SetCreatedBy(operator)
+		}
+	}
+	if setter, ok := entity.(UpdatedBySetter); ok {
+		setter.SetUpdatedBy(operator)
+	}
+}
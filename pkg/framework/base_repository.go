@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 )
 
 // 仓储错误定义
@@ -18,167 +21,444 @@ var (
 
 	// ErrNoRowsAffected 没有行被影响
 	ErrNoRowsAffected = errors.New("操作失败：没有行被影响")
+
+	// ErrSoftDeleteNotSupported DO 没有 DeletedAt 字段，不支持软删除相关操作
+	ErrSoftDeleteNotSupported = errors.New("不支持软删除：数据对象没有 DeletedAt 字段")
+
+	// ErrInvalidSort 排序方式不在生成的 {Aggregate}Sort 取值中，FindByFilter 不会把它拼接到查询中
+	ErrInvalidSort = errors.New("无效的排序方式")
 )
 
-// BaseRepository 泛型仓储实现基类
+// BaseRepositoryOf 泛型仓储实现基类（支持任意主键类型）
 //
-// 双泛型参数：
-//   - T: 领域模型类型（聚合根），必须实现 Entity 接口
+// 泛型参数：
+//   - T: 领域模型类型（聚合根），必须实现 EntityOf[K] 接口
 //   - D: 数据对象类型（DO），用于数据库持久化
+//   - K: 主键类型，如 int64、string（UUID）
 //
 // 职责：
-//  1. 实现 Repository[T] 接口的所有方法
+//  1. 实现 RepositoryOf[T, K] 接口的所有方法
 //  2. 提供对象转换功能（领域对象 ↔ 数据对象）
 //  3. 处理软删除、乐观锁等通用逻辑
+//  4. 执行生命周期钩子，自动填充审计字段
 //
 // 具体仓储通过嵌入此基类，自动获得所有 CRUD 实现：
 //
-//	type OrderRepositoryImpl struct {
-//	    BaseRepository[Order, OrderDO]  // 嵌入基类
+//	type DeviceRepositoryImpl struct {
+//	    BaseRepositoryOf[*Device, DeviceDO, string]  // UUID 主键
 //	}
 //
-//	// 只需实现扩展方法
-//	func (r *OrderRepositoryImpl) GetByOrderNo(ctx context.Context, orderNo string) (*Order, error) {
-//	    // 自定义查询逻辑
-//	}
-type BaseRepository[T Entity, D any] struct {
-	db       *gorm.DB   // GORM 数据库实例
-	toDO     func(T) *D // 领域对象 → 数据对象转换函数（返回指针）
-	toDomain func(*D) T // 数据对象 → 领域对象转换函数（接收指针）
+// int64 主键的聚合根直接使用 BaseRepository[T, D]。
+type BaseRepositoryOf[T EntityOf[K], D any, K comparable] struct {
+	db       *gorm.DB            // GORM 数据库实例
+	toDO     func(T) (*D, error) // 领域对象 → 数据对象转换函数（返回指针，JSON 值对象序列化失败时返回错误）
+	toDomain func(*D) (T, error) // 数据对象 → 领域对象转换函数（接收指针，JSON 值对象反序列化失败时返回错误）
+	hooks    *hookRegistry[T]    // 生命周期钩子（与事务仓储实例共享）
+
+	idGenerator IDGenerator[K]  // 主键生成器，为 nil 时由数据库自增或实体自身生成
+	codec       EncryptionCodec // 敏感字段编解码器，DO 没有敏感字段时不需要
+	cascades    []cascadeRule   // 关联实体的级联规则，删除时在同一事务中处理
+	outbox      bool            // 是否将聚合根记录的领域事件写入 outbox 表
 }
 
-// NewBaseRepository 创建基础仓储实例
-func NewBaseRepository[T Entity, D any](
+// NewBaseRepositoryOf 创建基础仓储实例
+func NewBaseRepositoryOf[T EntityOf[K], D any, K comparable](
 	db *gorm.DB,
-	toDO func(T) *D,
-	toDomain func(*D) T,
-) *BaseRepository[T, D] {
-	return &BaseRepository[T, D]{
+	toDO func(T) (*D, error),
+	toDomain func(*D) (T, error),
+) *BaseRepositoryOf[T, D, K] {
+	return &BaseRepositoryOf[T, D, K]{
 		db:       db,
 		toDO:     toDO,
 		toDomain: toDomain,
+		hooks:    newHookRegistry[T](),
 	}
 }
 
 // DB 获取数据库实例（用于扩展方法）
-func (r *BaseRepository[T, D]) DB() *gorm.DB {
+func (r *BaseRepositoryOf[T, D, K]) DB() *gorm.DB {
 	return r.db
 }
 
-// Add 添加实体
-func (r *BaseRepository[T, D]) Add(ctx context.Context, entity T) error {
-	do := r.toDO(entity)
-	result := r.db.WithContext(ctx).Create(do)
-	if result.Error != nil {
-		return result.Error
+// RegisterHook 注册生命周期钩子
+//
+// 同一阶段的多个钩子按注册顺序执行，任一钩子返回错误都会中止当前操作。
+// 注册了 After 钩子的操作会在事务中执行，After 钩子失败时写入一并回滚：
+//
+//	repo.RegisterHook(framework.BeforeAdd, func(ctx context.Context, order *Order) error {
+//	    if order.TotalAmount < 0 {
+//	        return errors.New("订单金额不能为负")
+//	    }
+//	    return nil
+//	})
+//
+// 钩子在通过 Transaction / WithTx 创建的事务仓储实例上同样生效。
+func (r *BaseRepositoryOf[T, D, K]) RegisterHook(phase HookPhase, fn HookFunc[T]) {
+	r.hooks.register(phase, fn)
+}
+
+// SetIDGenerator 设置主键生成器
+//
+// 用于数据库自增以外的主键策略（snowflake、uuid、manual），生成的仓储代码会按
+// +soliton:id(strategy=...) 自动设置；传入 nil 恢复默认行为。
+// 通过 Transaction / WithTx 创建的事务仓储实例沿用同一个生成器。
+func (r *BaseRepositoryOf[T, D, K]) SetIDGenerator(generator IDGenerator[K]) {
+	r.idGenerator = generator
+}
+
+// SetEncryptionCodec 设置敏感字段编解码器
+//
+// DO 中标记了 `sensitive` 标签的字段（由 +soliton:sensitive 生成）在写入前编码、读取后解码；
+// DO 有敏感字段但未设置编解码器时，读写操作返回 ErrEncryptionCodecRequired。
+// 通过 Transaction / WithTx 创建的事务仓储实例沿用同一个编解码器。
+func (r *BaseRepositoryOf[T, D, K]) SetEncryptionCodec(codec EncryptionCodec) {
+	r.codec = codec
+}
+
+// SetOutbox 设置是否使用事务性 outbox
+//
+// 启用后 Add、AddBatch、Update、UpdateBatch 在事务中执行，实体实现 EventSource（嵌入了 EventRecorder）时，
+// 取出记录的领域事件，与实体在同一事务中写入 outbox 表（OutboxTableName），由 OutboxRelay 发布到消息队列；
+// 实现 AggregateEvent[K] 的事件先回填实体 ID。写入失败时事务回滚，已取出的事件随之丢弃。
+// 通过 Transaction / WithTx 创建的事务仓储实例沿用该设置。
+func (r *BaseRepositoryOf[T, D, K]) SetOutbox(enabled bool) {
+	r.outbox = enabled
+}
+
+// ToData 将领域对象转换为数据对象，并对敏感字段编码，转换或编码失败时返回错误
+func (r *BaseRepositoryOf[T, D, K]) ToData(entity T) (*D, error) {
+	do, err := r.toDO(entity)
+	if err != nil {
+		return nil, err
 	}
+	if err := r.convertSensitive(do, true); err != nil {
+		return nil, err
+	}
+	return do, nil
+}
 
-	// 回填生成的 ID
-	// 通过反射获取 DO 的 ID 字段值并设置到 entity
-	id := r.extractIDFromDO(do)
-	if id > 0 {
+// ToDomain 对数据对象的敏感字段解码，并转换为领域对象，解码或转换失败时返回错误
+// 扩展查询方法应通过它转换查询结果，而不是直接调用转换器
+func (r *BaseRepositoryOf[T, D, K]) ToDomain(do *D) (T, error) {
+	if err := r.convertSensitive(do, false); err != nil {
+		var zero T
+		return zero, err
+	}
+	return r.toDomain(do)
+}
+
+// toDomainList 批量转换查询结果
+func (r *BaseRepositoryOf[T, D, K]) toDomainList(dos []D) ([]T, error) {
+	entities := make([]T, len(dos))
+	for i := range dos {
+		entity, err := r.ToDomain(&dos[i])
+		if err != nil {
+			return nil, err
+		}
+		entities[i] = entity
+	}
+	return entities, nil
+}
+
+// convertSensitive 对 DO 的敏感字段编码（encode 为 true）或解码，DO 没有敏感字段时不做处理
+func (r *BaseRepositoryOf[T, D, K]) convertSensitive(do *D, encode bool) error {
+	if len(sensitiveFieldsOf(reflect.TypeOf(do).Elem())) == 0 {
+		return nil
+	}
+	if r.codec == nil {
+		return ErrEncryptionCodecRequired
+	}
+
+	transform := r.codec.Decode
+	if encode {
+		transform = r.codec.Encode
+	}
+	return applyCodec(do, transform)
+}
+
+// assignID 为新实体分配 ID
+//
+// 按以下顺序确定主键：
+//  1. 已注册 IDGenerator 时，为 IsNew() 的实体调用 NextID
+//  2. 实体实现了 IDEnsurer 时调用 EnsureID
+//  3. string 主键的新实体生成 UUID
+//
+// 以上都不满足时（如 int64 自增主键）不做处理，由数据库生成。
+func (r *BaseRepositoryOf[T, D, K]) assignID(ctx context.Context, entity T) error {
+	if r.idGenerator != nil {
+		if !entity.IsNew() {
+			return nil
+		}
+		id, err := r.idGenerator.NextID(ctx)
+		if err != nil {
+			return err
+		}
 		entity.SetID(id)
+		return nil
 	}
 
+	if ensurer, ok := any(entity).(IDEnsurer); ok {
+		ensurer.EnsureID()
+		return nil
+	}
+	if e, ok := any(entity).(EntityOf[string]); ok && e.IsNew() {
+		e.SetID(NewUUID())
+	}
 	return nil
 }
 
+// Add 添加实体
+// 自动填充审计信息（CreatedAt/UpdatedAt/Version，以及上下文中的操作人）
+// 新实体在 BeforeAdd 钩子之前分配主键（见 SetIDGenerator）
+func (r *BaseRepositoryOf[T, D, K]) Add(ctx context.Context, entity T) error {
+	if err := r.assignID(ctx, entity); err != nil {
+		return err
+	}
+	applyAudit(ctx, entity, true)
+
+	return r.runWithHooks(ctx, AfterAdd, func(db *gorm.DB) error {
+		if err := r.hooks.run(ctx, BeforeAdd, entity); err != nil {
+			return err
+		}
+
+		do, err := r.ToData(entity)
+		if err != nil {
+			return err
+		}
+		result := db.Create(do)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// 回填生成的 ID
+		// 通过反射获取 DO 的 ID 字段值并设置到 entity
+		if id, ok := r.extractIDFromDO(do); ok {
+			entity.SetID(id)
+		}
+		if err := r.saveEvents(db, entity); err != nil {
+			return err
+		}
+
+		return r.hooks.run(ctx, AfterAdd, entity)
+	})
+}
+
+// runWithHooks 执行写操作
+// 如果注册了 After 钩子或启用了 outbox，则在事务中执行，保证钩子或写入 outbox 失败时写入回滚
+func (r *BaseRepositoryOf[T, D, K]) runWithHooks(ctx context.Context, afterPhase HookPhase, fn func(db *gorm.DB) error) error {
+	if !r.hooks.has(afterPhase) && !r.outbox {
+		return fn(r.db.WithContext(ctx))
+	}
+	return r.db.WithContext(ctx).Transaction(fn)
+}
+
+// saveEvents 启用 outbox 时，在 db（写入实体的事务）中保存实体记录的领域事件
+func (r *BaseRepositoryOf[T, D, K]) saveEvents(db *gorm.DB, entity T) error {
+	source, ok := any(entity).(EventSource)
+	if !r.outbox || !ok {
+		return nil
+	}
+
+	id := entity.GetID()
+	events := source.PullEvents()
+	for _, event := range events {
+		if aggregateEvent, ok := event.(AggregateEvent[K]); ok {
+			aggregateEvent.SetAggregateID(id)
+		}
+	}
+	return saveOutbox(db, outboxKey(id), events)
+}
+
 // extractIDFromDO 从数据对象中提取 ID
 // 支持多种常见的 ID 字段命名：ID, Id, id
-func (r *BaseRepository[T, D]) extractIDFromDO(do *D) int64 {
+// 字段类型与 K 不同但可转换时（如 int → int64）自动转换；ID 为零值时返回 false
+func (r *BaseRepositoryOf[T, D, K]) extractIDFromDO(do *D) (K, bool) {
+	var zero K
+
+	// 复合主键由调用方设置，不存在数据库生成的值
+	if _, ok := any(zero).(CompositeKey); ok {
+		return zero, false
+	}
+
 	val := reflect.ValueOf(do)
 	if val.Kind() == reflect.Ptr {
 		val = val.Elem()
 	}
 
 	if val.Kind() != reflect.Struct {
-		return 0
+		return zero, false
 	}
 
 	// 尝试常见的 ID 字段名
+	keyType := reflect.TypeOf(zero)
 	idFieldNames := []string{"ID", "Id", "id"}
 	for _, name := range idFieldNames {
 		field := val.FieldByName(name)
-		if field.IsValid() && field.CanInt() {
-			return field.Int()
+		if !field.IsValid() || !field.Type().ConvertibleTo(keyType) {
+			continue
+		}
+		// 避免数字与字符串之间的转换（int → string 会得到字符而不是数字文本）
+		if (field.Kind() == reflect.String) != (keyType.Kind() == reflect.String) {
+			continue
 		}
+
+		id := field.Convert(keyType).Interface().(K)
+		return id, id != zero
 	}
 
-	return 0
+	return zero, false
 }
 
 // Update 更新实体（支持乐观锁）
 //
-// 如果 DO 有 Version 字段，GORM 会自动实现乐观锁：
+// 如果 DO 有 Version 字段，按实体读取时的版本号更新：
 //   - 更新时 WHERE 条件会包含当前版本号
-//   - 更新成功后 Version 会自动 +1
-//   - 如果版本号不匹配（被其他事务修改），RowsAffected = 0
+//   - 更新成功后 Version +1，并写回实体
+//   - 如果版本号不匹配（被其他事务修改），RowsAffected = 0，返回 ErrVersionConflict
 //
 // 乐观锁工作原理：
 //
-//	UPDATE table SET field=?, version=version+1 WHERE id=? AND version=?
-func (r *BaseRepository[T, D]) Update(ctx context.Context, entity T) error {
-	do := r.toDO(entity)
+//	UPDATE table SET field=?, version=? + 1 WHERE id=? AND version=?
+func (r *BaseRepositoryOf[T, D, K]) Update(ctx context.Context, entity T) error {
+	applyAudit(ctx, entity, false)
 
-	// 使用 Updates 方法更新（只更新非零值字段）
-	// GORM 会自动处理 Version 字段的乐观锁逻辑
-	result := r.db.WithContext(ctx).Updates(do)
-	if result.Error != nil {
-		return result.Error
-	}
+	return r.runWithHooks(ctx, AfterUpdate, func(db *gorm.DB) error {
+		if err := r.hooks.run(ctx, BeforeUpdate, entity); err != nil {
+			return err
+		}
 
-	// 如果没有行被影响，可能是记录不存在或版本冲突
-	if result.RowsAffected == 0 {
-		// 尝试判断是记录不存在还是版本冲突
-		var check D
-		if err := r.db.WithContext(ctx).First(&check, entity.GetID()).Error; err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return ErrRecordNotFound
+		do, err := r.ToData(entity)
+		if err != nil {
+			return err
+		}
+
+		// 使用 Updates 方法更新（只更新非零值字段）
+		query := db
+		version, locked := r.versionOf(do)
+		if locked {
+			query = db.Where(version.column+" = ?", version.Int())
+			version.SetInt(version.Int() + 1)
+		}
+		result := query.Updates(do)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// 如果没有行被影响，可能是记录不存在或版本冲突
+		if result.RowsAffected == 0 {
+			// 尝试判断是记录不存在还是版本冲突
+			var check D
+			if err := r.whereID(db, entity.GetID()).First(&check).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrRecordNotFound
+				}
+				return err
 			}
+			// 记录存在但未更新，说明是版本冲突
+			return ErrVersionConflict
+		}
+		if locked {
+			incrementVersion(entity)
+		}
+		if err := r.saveEvents(db, entity); err != nil {
 			return err
 		}
-		// 记录存在但未更新，说明是版本冲突
-		return ErrVersionConflict
-	}
 
-	return nil
+		return r.hooks.run(ctx, AfterUpdate, entity)
+	})
 }
 
-// Delete 硬删除实体
-func (r *BaseRepository[T, D]) Delete(ctx context.Context, id int64) error {
-	var do D
-	result := r.db.WithContext(ctx).Delete(&do, id)
-	if result.Error != nil {
-		return result.Error
-	}
+// Delete 硬删除实体（包括已软删除的记录），DO 有 DeletedAt 字段时同样从表中删除该行
+func (r *BaseRepositoryOf[T, D, K]) Delete(ctx context.Context, id K) error {
+	return r.deleteWithHooks(ctx, []K{id}, true, func(db *gorm.DB) error {
+		var do D
+		result := r.whereID(db.Unscoped(), id).Delete(&do)
+		if result.Error != nil {
+			return result.Error
+		}
 
-	if result.RowsAffected == 0 {
-		return errors.New("删除失败：记录不存在")
-	}
+		if result.RowsAffected == 0 {
+			return errors.New("删除失败：记录不存在")
+		}
 
-	return nil
+		return nil
+	})
 }
 
 // Remove 软删除实体
 // 注意：只有当 DO 有 DeletedAt 字段时，GORM 才会执行软删除
-func (r *BaseRepository[T, D]) Remove(ctx context.Context, id int64) error {
-	var do D
-	result := r.db.WithContext(ctx).Delete(&do, id)
-	if result.Error != nil {
-		return result.Error
-	}
+func (r *BaseRepositoryOf[T, D, K]) Remove(ctx context.Context, id K) error {
+	return r.deleteWithHooks(ctx, []K{id}, false, func(db *gorm.DB) error {
+		var do D
+		result := r.whereID(db, id).Delete(&do)
+		if result.Error != nil {
+			return result.Error
+		}
 
-	if result.RowsAffected == 0 {
-		return errors.New("软删除失败：记录不存在")
+		if result.RowsAffected == 0 {
+			return errors.New("软删除失败：记录不存在")
+		}
+
+		return nil
+	})
+}
+
+// deleteWithHooks 执行删除操作并触发删除钩子、处理关联实体的级联规则
+//
+// 删除钩子需要实体对象，因此只有注册了删除钩子时才会先加载待删除的记录，
+// 钩子、级联处理、删除语句在同一事务中执行。hard 表示硬删除，决定关联实体的删除方式。
+func (r *BaseRepositoryOf[T, D, K]) deleteWithHooks(ctx context.Context, ids []K, hard bool, fn func(db *gorm.DB) error) error {
+	hasHooks := r.hooks.has(BeforeDelete, AfterDelete)
+	if !hasHooks && len(r.cascades) == 0 {
+		return fn(r.db.WithContext(ctx))
 	}
 
-	return nil
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var entities []T
+		if hasHooks {
+			// 硬删除同时删除已软删除的记录，钩子也要收到这些记录
+			query := tx
+			if hard {
+				query = tx.Unscoped()
+			}
+			var dos []D
+			if err := r.whereIDs(query, ids).Find(&dos).Error; err != nil {
+				return err
+			}
+
+			var err error
+			entities, err = r.toDomainList(dos)
+			if err != nil {
+				return err
+			}
+		}
+		for _, entity := range entities {
+			if err := r.hooks.run(ctx, BeforeDelete, entity); err != nil {
+				return err
+			}
+		}
+
+		if err := r.applyCascades(tx, ids, hard, make(map[K]bool)); err != nil {
+			return err
+		}
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		for _, entity := range entities {
+			if err := r.hooks.run(ctx, AfterDelete, entity); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // FindByID 根据 ID 查询实体
-func (r *BaseRepository[T, D]) FindByID(ctx context.Context, id int64) (T, error) {
+func (r *BaseRepositoryOf[T, D, K]) FindByID(ctx context.Context, id K) (T, error) {
 	var do D
-	result := r.db.WithContext(ctx).First(&do, id)
+	result := r.whereID(r.db.WithContext(ctx), id).First(&do)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -189,13 +469,13 @@ func (r *BaseRepository[T, D]) FindByID(ctx context.Context, id int64) (T, error
 		return zero, result.Error
 	}
 
-	return r.toDomain(&do), nil
+	return r.ToDomain(&do)
 }
 
 // FindByIDWithDeleted 根据 ID 查询实体（包含已删除）
-func (r *BaseRepository[T, D]) FindByIDWithDeleted(ctx context.Context, id int64) (T, error) {
+func (r *BaseRepositoryOf[T, D, K]) FindByIDWithDeleted(ctx context.Context, id K) (T, error) {
 	var do D
-	result := r.db.WithContext(ctx).Unscoped().First(&do, id)
+	result := r.whereID(r.db.WithContext(ctx).Unscoped(), id).First(&do)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -206,11 +486,11 @@ func (r *BaseRepository[T, D]) FindByIDWithDeleted(ctx context.Context, id int64
 		return zero, result.Error
 	}
 
-	return r.toDomain(&do), nil
+	return r.ToDomain(&do)
 }
 
 // FindAll 查询所有实体
-func (r *BaseRepository[T, D]) FindAll(ctx context.Context) ([]T, error) {
+func (r *BaseRepositoryOf[T, D, K]) FindAll(ctx context.Context) ([]T, error) {
 	var dos []D
 	result := r.db.WithContext(ctx).Find(&dos)
 
@@ -219,16 +499,11 @@ func (r *BaseRepository[T, D]) FindAll(ctx context.Context) ([]T, error) {
 	}
 
 	// 转换为领域对象列表
-	entities := make([]T, len(dos))
-	for i := range dos {
-		entities[i] = r.toDomain(&dos[i])
-	}
-
-	return entities, nil
+	return r.toDomainList(dos)
 }
 
 // FindPage 分页查询
-func (r *BaseRepository[T, D]) FindPage(ctx context.Context, page, pageSize int) ([]T, int64, error) {
+func (r *BaseRepositoryOf[T, D, K]) FindPage(ctx context.Context, page, pageSize int) ([]T, int64, error) {
 	var dos []D
 	var total int64
 
@@ -252,19 +527,19 @@ func (r *BaseRepository[T, D]) FindPage(ctx context.Context, page, pageSize int)
 	}
 
 	// 转换为领域对象列表
-	entities := make([]T, len(dos))
-	for i := range dos {
-		entities[i] = r.toDomain(&dos[i])
+	entities, err := r.toDomainList(dos)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	return entities, total, nil
 }
 
 // Exists 检查实体是否存在
-func (r *BaseRepository[T, D]) Exists(ctx context.Context, id int64) (bool, error) {
+func (r *BaseRepositoryOf[T, D, K]) Exists(ctx context.Context, id K) (bool, error) {
 	var count int64
 	var do D
-	result := r.db.WithContext(ctx).Model(&do).Where("id = ?", id).Count(&count)
+	result := r.whereID(r.db.WithContext(ctx).Model(&do), id).Count(&count)
 
 	if result.Error != nil {
 		return false, result.Error
@@ -287,15 +562,10 @@ func (r *BaseRepository[T, D]) Exists(ctx context.Context, id int64) (bool, erro
 //	    }
 //	    return nil  // 自动提交
 //	})
-func (r *BaseRepository[T, D]) Transaction(ctx context.Context, fn func(*BaseRepository[T, D]) error) error {
+func (r *BaseRepositoryOf[T, D, K]) Transaction(ctx context.Context, fn func(*BaseRepositoryOf[T, D, K]) error) error {
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// 创建使用事务 DB 的新仓储实例
-		txRepo := &BaseRepository[T, D]{
-			db:       tx,
-			toDO:     r.toDO,
-			toDomain: r.toDomain,
-		}
-		return fn(txRepo)
+		return fn(r.WithTx(tx))
 	})
 }
 
@@ -312,11 +582,19 @@ func (r *BaseRepository[T, D]) Transaction(ctx context.Context, fn func(*BaseRep
 //	}
 //
 //	tx.Commit()
-func (r *BaseRepository[T, D]) WithTx(tx *gorm.DB) *BaseRepository[T, D] {
-	return &BaseRepository[T, D]{
+//
+// 返回的仓储实例与原实例共享已注册的钩子和级联规则。
+func (r *BaseRepositoryOf[T, D, K]) WithTx(tx *gorm.DB) *BaseRepositoryOf[T, D, K] {
+	return &BaseRepositoryOf[T, D, K]{
 		db:       tx,
 		toDO:     r.toDO,
 		toDomain: r.toDomain,
+		hooks:    r.hooks,
+
+		idGenerator: r.idGenerator,
+		codec:       r.codec,
+		cascades:    r.cascades,
+		outbox:      r.outbox,
 	}
 }
 
@@ -324,107 +602,452 @@ func (r *BaseRepository[T, D]) WithTx(tx *gorm.DB) *BaseRepository[T, D] {
 
 // AddBatch 批量添加实体
 // batchSize 为每批次插入的数量，0 或负数表示一次性插入所有
+// 所有批次在同一个事务中执行，任一批次失败都会整体回滚
 // 会自动回填生成的 ID 到每个 entity
-func (r *BaseRepository[T, D]) AddBatch(ctx context.Context, entities []T, batchSize int) error {
+func (r *BaseRepositoryOf[T, D, K]) AddBatch(ctx context.Context, entities []T, batchSize int) error {
 	if len(entities) == 0 {
 		return nil
 	}
 
-	// 转换为数据对象
-	dos := make([]*D, len(entities))
-	for i, entity := range entities {
-		dos[i] = r.toDO(entity)
-	}
-
 	// 确定批次大小
 	if batchSize <= 0 {
-		batchSize = len(dos)
+		batchSize = len(entities)
 	}
 
-	// 分批插入
-	result := r.db.WithContext(ctx).CreateInBatches(dos, batchSize)
-	if result.Error != nil {
-		return result.Error
-	}
+	// 分批插入（显式事务：SkipDefaultTransaction 时 GORM 不会自动包裹事务）
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 填充审计信息、执行 BeforeAdd 钩子并转换为数据对象
+		dos := make([]*D, len(entities))
+		for i, entity := range entities {
+			if err := r.assignID(ctx, entity); err != nil {
+				return err
+			}
+			applyAudit(ctx, entity, true)
+			if err := r.hooks.run(ctx, BeforeAdd, entity); err != nil {
+				return err
+			}
+			do, err := r.ToData(entity)
+			if err != nil {
+				return err
+			}
+			dos[i] = do
+		}
 
-	// 回填 ID
-	for i, do := range dos {
-		id := r.extractIDFromDO(do)
-		if id > 0 {
-			entities[i].SetID(id)
+		if err := tx.CreateInBatches(dos, batchSize).Error; err != nil {
+			return err
 		}
-	}
 
-	return nil
+		// 回填 ID 并执行 AfterAdd 钩子
+		for i, do := range dos {
+			if id, ok := r.extractIDFromDO(do); ok {
+				entities[i].SetID(id)
+			}
+			if err := r.saveEvents(tx, entities[i]); err != nil {
+				return err
+			}
+			if err := r.hooks.run(ctx, AfterAdd, entities[i]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 }
 
 // UpdateBatch 批量更新实体
 // 注意：批量更新使用事务保证原子性，但不支持乐观锁检测
-func (r *BaseRepository[T, D]) UpdateBatch(ctx context.Context, entities []T) error {
+func (r *BaseRepositoryOf[T, D, K]) UpdateBatch(ctx context.Context, entities []T) error {
 	if len(entities) == 0 {
 		return nil
 	}
 
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		for _, entity := range entities {
-			do := r.toDO(entity)
+			applyAudit(ctx, entity, false)
+			if err := r.hooks.run(ctx, BeforeUpdate, entity); err != nil {
+				return err
+			}
+
+			do, err := r.ToData(entity)
+			if err != nil {
+				return err
+			}
 			if err := tx.Updates(do).Error; err != nil {
 				return err
 			}
+			if err := r.saveEvents(tx, entity); err != nil {
+				return err
+			}
+
+			if err := r.hooks.run(ctx, AfterUpdate, entity); err != nil {
+				return err
+			}
 		}
 		return nil
 	})
 }
 
-// DeleteBatch 批量硬删除实体
-func (r *BaseRepository[T, D]) DeleteBatch(ctx context.Context, ids []int64) error {
+// DeleteByIDs 批量硬删除实体
+// 返回实际删除的行数，调用方可据此判断是否有记录不存在
+func (r *BaseRepositoryOf[T, D, K]) DeleteByIDs(ctx context.Context, ids []K) (int64, error) {
 	if len(ids) == 0 {
-		return nil
+		return 0, nil
 	}
 
-	var do D
-	result := r.db.WithContext(ctx).Delete(&do, ids)
-	if result.Error != nil {
+	var affected int64
+	err := r.deleteWithHooks(ctx, ids, true, func(db *gorm.DB) error {
+		var do D
+		result := r.whereIDs(db.Unscoped(), ids).Delete(&do)
+		affected = result.RowsAffected
 		return result.Error
+	})
+	if err != nil {
+		return 0, err
 	}
 
-	return nil
+	return affected, nil
 }
 
-// RemoveBatch 批量软删除实体
-// 注意：只有当 DO 有 DeletedAt 字段时，GORM 才会执行软删除
-func (r *BaseRepository[T, D]) RemoveBatch(ctx context.Context, ids []int64) error {
+// RemoveByIDs 批量软删除实体
+// 如果 DO 有 DeletedAt 字段，只设置删除时间（已删除的记录不会重复计数）；
+// 否则与 Remove 一致，退化为硬删除
+// 返回实际影响的行数
+func (r *BaseRepositoryOf[T, D, K]) RemoveByIDs(ctx context.Context, ids []K) (int64, error) {
 	if len(ids) == 0 {
-		return nil
+		return 0, nil
+	}
+
+	column, ok := r.softDeleteColumn()
+	if !ok {
+		return r.DeleteByIDs(ctx, ids)
+	}
+
+	var affected int64
+	err := r.deleteWithHooks(ctx, ids, false, func(db *gorm.DB) error {
+		var do D
+		result := r.whereIDs(db.Model(&do), ids).
+			Where(column+" IS NULL").
+			Update(column, time.Now())
+		affected = result.RowsAffected
+		return result.Error
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return affected, nil
+}
+
+// DeleteBatch 批量硬删除实体
+func (r *BaseRepositoryOf[T, D, K]) DeleteBatch(ctx context.Context, ids []K) error {
+	_, err := r.DeleteByIDs(ctx, ids)
+	return err
+}
+
+// RemoveBatch 批量软删除实体
+// 注意：只有当 DO 有 DeletedAt 字段时才会执行软删除
+func (r *BaseRepositoryOf[T, D, K]) RemoveBatch(ctx context.Context, ids []K) error {
+	_, err := r.RemoveByIDs(ctx, ids)
+	return err
+}
+
+// Restore 恢复已软删除的实体
+//
+// 清空 DeletedAt，并与乐观锁行为保持一致：刷新 UpdatedAt、Version 加 1（DO 有对应字段时）。
+// 记录不存在或未被删除时返回 ErrRecordNotFound；DO 没有 DeletedAt 字段时返回 ErrSoftDeleteNotSupported。
+func (r *BaseRepositoryOf[T, D, K]) Restore(ctx context.Context, id K) error {
+	column, ok := r.softDeleteColumn()
+	if !ok {
+		return ErrSoftDeleteNotSupported
+	}
+
+	updates := map[string]interface{}{column: nil}
+	if updatedAt, ok := r.columnOf("UpdatedAt"); ok {
+		updates[updatedAt] = time.Now()
+	}
+	if version, ok := r.columnOf("Version"); ok {
+		updates[version] = gorm.Expr(version + " + 1")
 	}
 
 	var do D
-	result := r.db.WithContext(ctx).Delete(&do, ids)
+	result := r.whereID(r.db.WithContext(ctx).Unscoped().Model(&do), id).
+		Where(column + " IS NOT NULL").
+		Updates(updates)
 	if result.Error != nil {
 		return result.Error
 	}
 
+	if result.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
 	return nil
 }
 
+// FindAllDeleted 查询所有已软删除的实体
+func (r *BaseRepositoryOf[T, D, K]) FindAllDeleted(ctx context.Context) ([]T, error) {
+	column, ok := r.softDeleteColumn()
+	if !ok {
+		return nil, ErrSoftDeleteNotSupported
+	}
+
+	var dos []D
+	result := r.db.WithContext(ctx).Unscoped().Where(column + " IS NOT NULL").Find(&dos)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	// 转换为领域对象列表
+	return r.toDomainList(dos)
+}
+
+// FindPageDeleted 分页查询已软删除的实体
+// 返回：实体列表、已删除记录总数、错误
+func (r *BaseRepositoryOf[T, D, K]) FindPageDeleted(ctx context.Context, page, pageSize int) ([]T, int64, error) {
+	column, ok := r.softDeleteColumn()
+	if !ok {
+		return nil, 0, ErrSoftDeleteNotSupported
+	}
+
+	var dos []D
+	var total int64
+
+	// 计算偏移量
+	offset := (page - 1) * pageSize
+
+	// 查询总数
+	var doModel D
+	if err := r.db.WithContext(ctx).Unscoped().Model(&doModel).Where(column + " IS NOT NULL").Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 分页查询
+	result := r.db.WithContext(ctx).Unscoped().
+		Where(column + " IS NOT NULL").
+		Offset(offset).
+		Limit(pageSize).
+		Find(&dos)
+
+	if result.Error != nil {
+		return nil, 0, result.Error
+	}
+
+	// 转换为领域对象列表
+	entities, err := r.toDomainList(dos)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entities, total, nil
+}
+
+// PurgeDeleted 物理删除软删除时间早于 olderThan 之前的记录
+//
+// 例如 PurgeDeleted(ctx, 30*24*time.Hour) 清理 30 天前删除的记录，olderThan 为 0 表示清理所有已删除记录。
+// 记录在软删除时已触发过删除钩子，清理时不再触发。返回实际删除的行数。
+func (r *BaseRepositoryOf[T, D, K]) PurgeDeleted(ctx context.Context, olderThan time.Duration) (int64, error) {
+	column, ok := r.softDeleteColumn()
+	if !ok {
+		return 0, ErrSoftDeleteNotSupported
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+
+	var do D
+	result := r.db.WithContext(ctx).Unscoped().
+		Where(column+" IS NOT NULL AND "+column+" <= ?", cutoff).
+		Delete(&do)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	return result.RowsAffected, nil
+}
+
 // FindByIDs 批量根据 ID 查询实体
-func (r *BaseRepository[T, D]) FindByIDs(ctx context.Context, ids []int64) ([]T, error) {
+//
+// 只执行一次 WHERE id IN (?) 查询，返回 ID → 实体 的映射。
+// 不存在的 ID 不会报错，调用方可以通过检查 map 判断缺失的记录：
+//
+//	found, err := repo.FindByIDs(ctx, ids)
+//	for _, id := range ids {
+//	    if _, ok := found[id]; !ok {
+//	        // 记录不存在
+//	    }
+//	}
+func (r *BaseRepositoryOf[T, D, K]) FindByIDs(ctx context.Context, ids []K) (map[K]T, error) {
 	if len(ids) == 0 {
-		return []T{}, nil
+		return map[K]T{}, nil
 	}
 
 	var dos []D
-	result := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&dos)
+	result := r.whereIDs(r.db.WithContext(ctx), ids).Find(&dos)
 
 	if result.Error != nil {
 		return nil, result.Error
 	}
 
-	// 转换为领域对象列表
-	entities := make([]T, len(dos))
+	// 转换为领域对象映射
+	entities := make(map[K]T, len(dos))
 	for i := range dos {
-		entities[i] = r.toDomain(&dos[i])
+		entity, err := r.ToDomain(&dos[i])
+		if err != nil {
+			return nil, err
+		}
+		entities[entity.GetID()] = entity
 	}
 
 	return entities, nil
 }
+
+// whereID 按主键过滤
+// 复合主键（K 实现 CompositeKey）按各列逐一过滤：WHERE tenant_id = ? AND order_no = ?
+func (r *BaseRepositoryOf[T, D, K]) whereID(db *gorm.DB, id K) *gorm.DB {
+	if key, ok := any(id).(CompositeKey); ok {
+		values := key.KeyValues()
+		for i, column := range key.KeyColumns() {
+			db = db.Where(column+" = ?", values[i])
+		}
+		return db
+	}
+	return db.Where(r.primaryKeyColumn()+" = ?", id)
+}
+
+// whereIDs 按主键列表过滤
+// 复合主键使用行值比较：WHERE (tenant_id, order_no) IN ((?, ?), (?, ?))
+func (r *BaseRepositoryOf[T, D, K]) whereIDs(db *gorm.DB, ids []K) *gorm.DB {
+	var zero K
+	if key, ok := any(zero).(CompositeKey); ok {
+		values := make([][]any, len(ids))
+		for i, id := range ids {
+			values[i] = any(id).(CompositeKey).KeyValues()
+		}
+		return db.Where("("+strings.Join(key.KeyColumns(), ", ")+") IN ?", values)
+	}
+	return db.Where(r.primaryKeyColumn()+" IN ?", ids)
+}
+
+// primaryKeyColumn 获取主键列名
+// 通过 GORM 解析 DO 的 schema，无法解析时默认为 "id"
+//
+// 所有按 ID 查询的方法都通过 whereID / whereIDs 显式使用 WHERE <主键列> = ?，
+// 而不依赖 GORM 的 First(&do, id) 位置参数写法（该写法对字符串主键会生成错误的 SQL）。
+func (r *BaseRepositoryOf[T, D, K]) primaryKeyColumn() string {
+	s, err := r.parseSchema()
+	if err != nil || s.PrioritizedPrimaryField == nil {
+		return "id"
+	}
+	return s.PrioritizedPrimaryField.DBName
+}
+
+// softDeleteColumn 获取软删除列名
+// 通过 GORM 解析 DO 的 schema，查找 DeletedAt 字段
+func (r *BaseRepositoryOf[T, D, K]) softDeleteColumn() (string, bool) {
+	return r.columnOf("DeletedAt")
+}
+
+// columnOf 获取 DO 字段对应的列名，字段不存在时返回 false
+func (r *BaseRepositoryOf[T, D, K]) columnOf(fieldName string) (string, bool) {
+	s, err := r.parseSchema()
+	if err != nil {
+		return "", false
+	}
+
+	field := s.LookUpField(fieldName)
+	if field == nil || field.DBName == "" {
+		return "", false
+	}
+
+	return field.DBName, true
+}
+
+// versionField DO 的乐观锁版本号字段
+type versionField struct {
+	reflect.Value
+	column string
+}
+
+// versionOf 返回 DO 的整数 Version 字段及其列名，没有时返回 false
+func (r *BaseRepositoryOf[T, D, K]) versionOf(do *D) (versionField, bool) {
+	column, ok := r.columnOf("Version")
+	if !ok {
+		return versionField{}, false
+	}
+	field := reflect.ValueOf(do).Elem().FieldByName("Version")
+	if !field.IsValid() || !field.CanInt() {
+		return versionField{}, false
+	}
+	return versionField{Value: field, column: column}, true
+}
+
+// incrementVersion 更新成功后将实体的版本号加 1，与数据库中的版本号保持一致
+func incrementVersion(entity any) {
+	if incrementer, ok := entity.(interface{ IncrementVersion() }); ok {
+		incrementer.IncrementVersion()
+		return
+	}
+	value := reflect.ValueOf(entity)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return
+	}
+	if field := value.Elem().FieldByName("Version"); field.CanSet() && field.CanInt() {
+		field.SetInt(field.Int() + 1)
+	}
+}
+
+// parseSchema 解析 DO 的 GORM schema（GORM 内部有缓存）
+func (r *BaseRepositoryOf[T, D, K]) parseSchema() (*schema.Schema, error) {
+	var do D
+	stmt := &gorm.Statement{DB: r.db}
+	if err := stmt.Parse(&do); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
+}
+
+// ==================== int64 主键 ====================
+
+// BaseRepository 泛型仓储实现基类（int64 主键）
+//
+// 双泛型参数：
+//   - T: 领域模型类型（聚合根），必须实现 Entity 接口
+//   - D: 数据对象类型（DO），用于数据库持久化
+//
+// 所有 CRUD 实现来自 BaseRepositoryOf[T, D, int64]，
+// 具体仓储通过嵌入此基类，自动获得所有 CRUD 实现：
+//
+//	type OrderRepositoryImpl struct {
+//	    BaseRepository[Order, OrderDO]  // 嵌入基类
+//	}
+//
+//	// 只需实现扩展方法
+//	func (r *OrderRepositoryImpl) GetByOrderNo(ctx context.Context, orderNo string) (*Order, error) {
+//	    // 自定义查询逻辑
+//	}
+type BaseRepository[T Entity, D any] struct {
+	BaseRepositoryOf[T, D, int64]
+}
+
+// NewBaseRepository 创建基础仓储实例（int64 主键）
+func NewBaseRepository[T Entity, D any](
+	db *gorm.DB,
+	toDO func(T) (*D, error),
+	toDomain func(*D) (T, error),
+) *BaseRepository[T, D] {
+	return &BaseRepository[T, D]{
+		BaseRepositoryOf: *NewBaseRepositoryOf[T, D, int64](db, toDO, toDomain),
+	}
+}
+
+// Transaction 执行事务
+func (r *BaseRepository[T, D]) Transaction(ctx context.Context, fn func(*BaseRepository[T, D]) error) error {
+	return r.BaseRepositoryOf.Transaction(ctx, func(txRepo *BaseRepositoryOf[T, D, int64]) error {
+		return fn(&BaseRepository[T, D]{BaseRepositoryOf: *txRepo})
+	})
+}
+
+// WithTx 在现有事务中创建仓储实例
+func (r *BaseRepository[T, D]) WithTx(tx *gorm.DB) *BaseRepository[T, D] {
+	return &BaseRepository[T, D]{BaseRepositoryOf: *r.BaseRepositoryOf.WithTx(tx)}
+}
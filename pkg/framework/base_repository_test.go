@@ -0,0 +1,443 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testOrder 测试用的聚合根
+type testOrder struct {
+	ID     int64
+	No     string
+	Status string
+}
+
+func (o *testOrder) GetID() int64   { return o.ID }
+func (o *testOrder) SetID(id int64) { o.ID = id }
+func (o *testOrder) IsNew() bool    { return o.ID == 0 }
+
+// testOrderDO 测试用的数据对象，带软删除字段
+type testOrderDO struct {
+	ID        int64  `gorm:"column:id;primaryKey;autoIncrement"`
+	No        string `gorm:"column:no;uniqueIndex"`
+	Status    string `gorm:"column:status"`
+	DeletedAt gorm.DeletedAt
+}
+
+func (testOrderDO) TableName() string { return "test_orders" }
+
+// openTestDB 打开独立的内存 SQLite 数据库并建表
+func openTestDB(t testing.TB, models ...any) *gorm.DB {
+	t.Helper()
+	// 每个连接各自是一个内存数据库，限制为一个连接使事务和查询看到同一份数据
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取连接池失败: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(models...); err != nil {
+		t.Fatalf("建表失败: %v", err)
+	}
+	return db
+}
+
+// newTestOrderRepository 创建 testOrder 的仓储
+func newTestOrderRepository(db *gorm.DB) *BaseRepository[*testOrder, testOrderDO] {
+	return NewBaseRepository(db,
+		func(o *testOrder) (*testOrderDO, error) {
+			return &testOrderDO{ID: o.ID, No: o.No, Status: o.Status}, nil
+		},
+		func(do *testOrderDO) (*testOrder, error) {
+			return &testOrder{ID: do.ID, No: do.No, Status: do.Status}, nil
+		},
+	)
+}
+
+// countRows 返回表中的行数，包括已软删除的行
+func countRows(t testing.TB, db *gorm.DB) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Unscoped().Model(&testOrderDO{}).Count(&count).Error; err != nil {
+		t.Fatalf("统计行数失败: %v", err)
+	}
+	return count
+}
+
+func TestDeleteRemovesRow(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t, &testOrderDO{})
+	repo := newTestOrderRepository(db)
+
+	order := &testOrder{No: "A"}
+	if err := repo.Add(ctx, order); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := repo.Delete(ctx, order.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if n := countRows(t, db); n != 0 {
+		t.Fatalf("Delete 后表中还有 %d 行，应为硬删除", n)
+	}
+	if err := repo.Delete(ctx, order.ID); err == nil {
+		t.Fatal("删除不存在的记录应返回错误")
+	}
+}
+
+func TestDeleteRemovesSoftDeletedRow(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t, &testOrderDO{})
+	repo := newTestOrderRepository(db)
+
+	order := &testOrder{No: "A"}
+	if err := repo.Add(ctx, order); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := repo.Remove(ctx, order.ID); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if n := countRows(t, db); n != 1 {
+		t.Fatalf("Remove 后表中有 %d 行，应保留软删除的行", n)
+	}
+
+	var deleted []int64
+	repo.RegisterHook(BeforeDelete, func(ctx context.Context, o *testOrder) error {
+		deleted = append(deleted, o.ID)
+		return nil
+	})
+	if err := repo.Delete(ctx, order.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if n := countRows(t, db); n != 0 {
+		t.Fatalf("Delete 后表中还有 %d 行", n)
+	}
+	if len(deleted) != 1 || deleted[0] != order.ID {
+		t.Fatalf("BeforeDelete 钩子收到 %v，应为已软删除的记录 %d", deleted, order.ID)
+	}
+}
+
+func TestRemoveKeepsRow(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t, &testOrderDO{})
+	repo := newTestOrderRepository(db)
+
+	order := &testOrder{No: "A"}
+	if err := repo.Add(ctx, order); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := repo.Remove(ctx, order.ID); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := repo.FindByID(ctx, order.ID); !errors.Is(err, ErrRecordNotFound) {
+		t.Fatalf("FindByID 软删除的记录返回 %v，应为 ErrRecordNotFound", err)
+	}
+	if err := repo.Restore(ctx, order.ID); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if _, err := repo.FindByID(ctx, order.ID); err != nil {
+		t.Fatalf("恢复后 FindByID: %v", err)
+	}
+}
+
+func TestQueriesReturnToDomainError(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t, &testOrderDO{})
+	errCorrupt := errors.New("状态数据损坏")
+	repo := NewBaseRepository(db,
+		func(o *testOrder) (*testOrderDO, error) {
+			return &testOrderDO{ID: o.ID, No: o.No, Status: o.Status}, nil
+		},
+		func(do *testOrderDO) (*testOrder, error) {
+			if do.Status == "corrupt" {
+				return nil, errCorrupt
+			}
+			return &testOrder{ID: do.ID, No: do.No, Status: do.Status}, nil
+		},
+	)
+
+	order := &testOrder{No: "A", Status: "corrupt"}
+	if err := repo.Add(ctx, order); err != nil {
+		t.Fatalf("Add 失败: %v", err)
+	}
+
+	if _, err := repo.FindByID(ctx, order.ID); !errors.Is(err, errCorrupt) {
+		t.Errorf("FindByID() error = %v, want %v", err, errCorrupt)
+	}
+	if _, err := repo.FindAll(ctx); !errors.Is(err, errCorrupt) {
+		t.Errorf("FindAll() error = %v, want %v", err, errCorrupt)
+	}
+	if _, _, err := repo.FindPage(ctx, 1, 10); !errors.Is(err, errCorrupt) {
+		t.Errorf("FindPage() error = %v, want %v", err, errCorrupt)
+	}
+}
+
+// statementCounter 统计通过 GORM 执行的查询和插入语句数
+type statementCounter struct {
+	queries int
+	creates int
+}
+
+// countStatements 在 db 上注册回调，统计之后执行的查询和插入语句
+func countStatements(t testing.TB, db *gorm.DB) *statementCounter {
+	t.Helper()
+	counter := &statementCounter{}
+	if err := db.Callback().Query().After("gorm:query").Register("test:count_query", func(*gorm.DB) { counter.queries++ }); err != nil {
+		t.Fatalf("注册回调失败: %v", err)
+	}
+	if err := db.Callback().Create().After("gorm:create").Register("test:count_create", func(*gorm.DB) { counter.creates++ }); err != nil {
+		t.Fatalf("注册回调失败: %v", err)
+	}
+	return counter
+}
+
+// newTestOrders 创建 n 个编号不同的 testOrder
+func newTestOrders(n int) []*testOrder {
+	orders := make([]*testOrder, n)
+	for i := range orders {
+		orders[i] = &testOrder{No: fmt.Sprintf("NO-%d", i), Status: "PENDING"}
+	}
+	return orders
+}
+
+func TestAddBatchInsertsInBatchesAndRunsHooks(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t, &testOrderDO{})
+	repo := newTestOrderRepository(db)
+	counter := countStatements(t, db)
+
+	var before, after []string
+	repo.RegisterHook(BeforeAdd, func(ctx context.Context, o *testOrder) error {
+		before = append(before, o.No)
+		return nil
+	})
+	repo.RegisterHook(AfterAdd, func(ctx context.Context, o *testOrder) error {
+		if o.ID == 0 {
+			t.Errorf("AfterAdd 钩子收到的 %s 未回填 ID", o.No)
+		}
+		after = append(after, o.No)
+		return nil
+	})
+
+	orders := newTestOrders(5)
+	if err := repo.AddBatch(ctx, orders, 2); err != nil {
+		t.Fatalf("AddBatch 失败: %v", err)
+	}
+
+	// 5 条记录按每批 2 条插入
+	if counter.creates != 3 {
+		t.Errorf("插入语句数 = %d, want 3", counter.creates)
+	}
+	if got := countRows(t, db); got != 5 {
+		t.Errorf("行数 = %d, want 5", got)
+	}
+	ids := make(map[int64]bool)
+	for _, order := range orders {
+		if order.ID == 0 || ids[order.ID] {
+			t.Errorf("%s 的 ID = %d，应回填不重复的自增 ID", order.No, order.ID)
+		}
+		ids[order.ID] = true
+	}
+	if len(before) != 5 || len(after) != 5 {
+		t.Errorf("BeforeAdd 执行 %d 次、AfterAdd 执行 %d 次, want 各 5 次", len(before), len(after))
+	}
+}
+
+func TestAddBatchRollsBackOnHookError(t *testing.T) {
+	errRejected := errors.New("拒绝写入")
+	tests := []struct {
+		name  string
+		phase HookPhase
+	}{
+		{"BeforeAdd", BeforeAdd},
+		{"AfterAdd", AfterAdd},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := openTestDB(t, &testOrderDO{})
+			repo := newTestOrderRepository(db)
+			repo.RegisterHook(tt.phase, func(ctx context.Context, o *testOrder) error {
+				if o.No == "NO-3" {
+					return errRejected
+				}
+				return nil
+			})
+
+			if err := repo.AddBatch(ctx, newTestOrders(5), 2); !errors.Is(err, errRejected) {
+				t.Fatalf("AddBatch() error = %v, want %v", err, errRejected)
+			}
+			if got := countRows(t, db); got != 0 {
+				t.Errorf("钩子失败后行数 = %d，应全部回滚", got)
+			}
+		})
+	}
+}
+
+func TestFindByIDsUsesSingleQuery(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t, &testOrderDO{})
+	repo := newTestOrderRepository(db)
+
+	orders := newTestOrders(5)
+	if err := repo.AddBatch(ctx, orders, 0); err != nil {
+		t.Fatalf("AddBatch 失败: %v", err)
+	}
+	if err := repo.Remove(ctx, orders[1].ID); err != nil {
+		t.Fatalf("Remove 失败: %v", err)
+	}
+	counter := countStatements(t, db)
+
+	missing := orders[4].ID + 100
+	found, err := repo.FindByIDs(ctx, []int64{orders[0].ID, orders[1].ID, orders[2].ID, missing})
+	if err != nil {
+		t.Fatalf("FindByIDs 失败: %v", err)
+	}
+
+	if counter.queries != 1 {
+		t.Errorf("查询语句数 = %d, want 1", counter.queries)
+	}
+	// 已软删除和不存在的 ID 不在结果中
+	if len(found) != 2 || found[orders[0].ID] == nil || found[orders[2].ID] == nil {
+		t.Errorf("FindByIDs() = %v, want %d、%d", found, orders[0].ID, orders[2].ID)
+	}
+	if found[orders[2].ID].No != orders[2].No {
+		t.Errorf("found[%d].No = %q, want %q", orders[2].ID, found[orders[2].ID].No, orders[2].No)
+	}
+
+	empty, err := repo.FindByIDs(ctx, nil)
+	if err != nil || len(empty) != 0 || counter.queries != 1 {
+		t.Errorf("FindByIDs(nil) = %v, %v，应返回空结果且不查询数据库", empty, err)
+	}
+}
+
+// benchmarkSize 基准测试中的记录数
+const benchmarkSize = 100
+
+func BenchmarkAddBatch(b *testing.B) {
+	ctx := context.Background()
+	db := openTestDB(b, &testOrderDO{})
+	repo := newTestOrderRepository(db)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		db.Exec("DELETE FROM test_orders")
+		orders := newTestOrders(benchmarkSize)
+		b.StartTimer()
+		if err := repo.AddBatch(ctx, orders, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAddLoop(b *testing.B) {
+	ctx := context.Background()
+	db := openTestDB(b, &testOrderDO{})
+	repo := newTestOrderRepository(db)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		db.Exec("DELETE FROM test_orders")
+		orders := newTestOrders(benchmarkSize)
+		b.StartTimer()
+		for _, order := range orders {
+			if err := repo.Add(ctx, order); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// seedBenchmarkOrders 写入 benchmarkSize 条记录，返回它们的 ID
+func seedBenchmarkOrders(b *testing.B, repo *BaseRepository[*testOrder, testOrderDO]) []int64 {
+	b.Helper()
+	orders := newTestOrders(benchmarkSize)
+	if err := repo.AddBatch(context.Background(), orders, 0); err != nil {
+		b.Fatal(err)
+	}
+	ids := make([]int64, len(orders))
+	for i, order := range orders {
+		ids[i] = order.ID
+	}
+	return ids
+}
+
+func BenchmarkFindByIDs(b *testing.B) {
+	ctx := context.Background()
+	repo := newTestOrderRepository(openTestDB(b, &testOrderDO{}))
+	ids := seedBenchmarkOrders(b, repo)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := repo.FindByIDs(ctx, ids); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFindByIDLoop(b *testing.B) {
+	ctx := context.Background()
+	repo := newTestOrderRepository(openTestDB(b, &testOrderDO{}))
+	ids := seedBenchmarkOrders(b, repo)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, id := range ids {
+			if _, err := repo.FindByID(ctx, id); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestWritesReturnToDataError(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t, &testOrderDO{})
+	errUnencodable := errors.New("状态无法序列化")
+	repo := NewBaseRepository(db,
+		func(o *testOrder) (*testOrderDO, error) {
+			if o.Status == "unencodable" {
+				return nil, errUnencodable
+			}
+			return &testOrderDO{ID: o.ID, No: o.No, Status: o.Status}, nil
+		},
+		func(do *testOrderDO) (*testOrder, error) {
+			return &testOrder{ID: do.ID, No: do.No, Status: do.Status}, nil
+		},
+	)
+
+	if err := repo.Add(ctx, &testOrder{No: "A", Status: "unencodable"}); !errors.Is(err, errUnencodable) {
+		t.Errorf("Add() error = %v, want %v", err, errUnencodable)
+	}
+	if all, err := repo.FindAll(ctx); err != nil || len(all) != 0 {
+		t.Errorf("序列化失败时不应写入记录: %d 条, err = %v", len(all), err)
+	}
+
+	order := &testOrder{No: "B", Status: "PAID"}
+	if err := repo.Add(ctx, order); err != nil {
+		t.Fatalf("Add 失败: %v", err)
+	}
+	order.Status = "unencodable"
+	if err := repo.Update(ctx, order); !errors.Is(err, errUnencodable) {
+		t.Errorf("Update() error = %v, want %v", err, errUnencodable)
+	}
+	stored, err := repo.FindByID(ctx, order.ID)
+	if err != nil {
+		t.Fatalf("FindByID 失败: %v", err)
+	}
+	if stored.Status != "PAID" {
+		t.Errorf("序列化失败时不应更新记录: Status = %q", stored.Status)
+	}
+}
@@ -2,14 +2,15 @@ package framework
 
 import (
 	"context"
+	"fmt"
 )
 
-// BaseService 泛型领域服务实现基类
+// BaseServiceOf 泛型领域服务实现基类
 //
-// 泛型参数 T 约束为 Entity。
+// 泛型参数 T 约束为 EntityOf[K]，K 为主键类型。
 //
 // 职责：
-//  1. 实现 Service[T] 接口的所有方法
+//  1. 实现 ServiceOf[T, K] 接口的所有方法
 //  2. 封装基础业务规则和校验逻辑
 //  3. 委托仓储层进行数据持久化
 //
@@ -24,13 +25,13 @@ import (
 //	func (s *OrderServiceImpl) PlaceOrder(ctx context.Context, order *Order) error {
 //	    // 业务逻辑
 //	}
-type BaseService[T Entity] struct {
-	repository Repository[T] // 仓储依赖
+type BaseServiceOf[T EntityOf[K], K comparable] struct {
+	repository RepositoryOf[T, K] // 仓储依赖
 }
 
-// NewBaseService 创建基础服务实例
-func NewBaseService[T Entity](repository Repository[T]) *BaseService[T] {
-	return &BaseService[T]{
+// NewBaseServiceOf 创建基础服务实例
+func NewBaseServiceOf[T EntityOf[K], K comparable](repository RepositoryOf[T, K]) *BaseServiceOf[T, K] {
+	return &BaseServiceOf[T, K]{
 		repository: repository,
 	}
 }
@@ -38,20 +39,25 @@ func NewBaseService[T Entity](repository Repository[T]) *BaseService[T] {
 // Add 添加实体
 // 执行基础校验后调用仓储层
 // 具体的校验逻辑由生成器根据字段注解生成
-func (s *BaseService[T]) Add(ctx context.Context, entity T) error {
+func (s *BaseServiceOf[T, K]) Add(ctx context.Context, entity T) error {
 	// 基础校验在生成的具体服务中实现
 	// 这里直接调用仓储
 	return s.repository.Add(ctx, entity)
 }
 
+// AddBatch 批量添加实体
+func (s *BaseServiceOf[T, K]) AddBatch(ctx context.Context, entities []T, batchSize int) error {
+	return s.repository.AddBatch(ctx, entities, batchSize)
+}
+
 // Update 更新实体
-func (s *BaseService[T]) Update(ctx context.Context, entity T) error {
+func (s *BaseServiceOf[T, K]) Update(ctx context.Context, entity T) error {
 	// 基础校验在生成的具体服务中实现
 	return s.repository.Update(ctx, entity)
 }
 
 // Delete 删除实体
-func (s *BaseService[T]) Delete(ctx context.Context, id int64) error {
+func (s *BaseServiceOf[T, K]) Delete(ctx context.Context, id K) error {
 	// 检查实体是否存在
 	exists, err := s.repository.Exists(ctx, id)
 	if err != nil {
@@ -64,31 +70,65 @@ func (s *BaseService[T]) Delete(ctx context.Context, id int64) error {
 	return s.repository.Remove(ctx, id)
 }
 
+// DeleteByIDs 批量删除实体
+// 不存在的 ID 会被忽略，返回实际删除的数量
+func (s *BaseServiceOf[T, K]) DeleteByIDs(ctx context.Context, ids []K) (int64, error) {
+	return s.repository.RemoveByIDs(ctx, ids)
+}
+
+// Restore 恢复已删除的实体
+func (s *BaseServiceOf[T, K]) Restore(ctx context.Context, id K) error {
+	return s.repository.Restore(ctx, id)
+}
+
 // GetByID 根据 ID 获取实体
-func (s *BaseService[T]) GetByID(ctx context.Context, id int64) (T, error) {
+func (s *BaseServiceOf[T, K]) GetByID(ctx context.Context, id K) (T, error) {
 	return s.repository.FindByID(ctx, id)
 }
 
+// GetByIDs 批量根据 ID 获取实体
+func (s *BaseServiceOf[T, K]) GetByIDs(ctx context.Context, ids []K) (map[K]T, error) {
+	return s.repository.FindByIDs(ctx, ids)
+}
+
 // GetAll 获取所有实体
-func (s *BaseService[T]) GetAll(ctx context.Context) ([]T, error) {
+func (s *BaseServiceOf[T, K]) GetAll(ctx context.Context) ([]T, error) {
 	return s.repository.FindAll(ctx)
 }
 
+// GetAllDeleted 获取所有已删除的实体
+func (s *BaseServiceOf[T, K]) GetAllDeleted(ctx context.Context) ([]T, error) {
+	return s.repository.FindAllDeleted(ctx)
+}
+
 // GetPage 分页获取实体
-func (s *BaseService[T]) GetPage(ctx context.Context, page, pageSize int) ([]T, int64, error) {
+func (s *BaseServiceOf[T, K]) GetPage(ctx context.Context, page, pageSize int) ([]T, int64, error) {
 	return s.repository.FindPage(ctx, page, pageSize)
 }
 
 // Exists 检查实体是否存在
-func (s *BaseService[T]) Exists(ctx context.Context, id int64) (bool, error) {
+func (s *BaseServiceOf[T, K]) Exists(ctx context.Context, id K) (bool, error) {
 	return s.repository.Exists(ctx, id)
 }
 
+// BaseService int64 主键的泛型领域服务实现基类
+type BaseService[T Entity] struct {
+	BaseServiceOf[T, int64]
+}
+
+// NewBaseService 创建基础服务实例（int64 主键）
+func NewBaseService[T Entity](repository Repository[T]) *BaseService[T] {
+	return &BaseService[T]{
+		BaseServiceOf: *NewBaseServiceOf[T, int64](repository),
+	}
+}
+
 // 常用错误定义
 var (
 	ErrEntityNotFound      = NewServiceError("实体不存在")
 	ErrEntityAlreadyExists = NewServiceError("实体已存在")
 	ErrValidationFailed    = NewServiceError("校验失败")
+	ErrBadRequest          = NewServiceError("请求无效")
 )
 
 // ServiceError 服务层错误
@@ -103,3 +143,38 @@ func NewServiceError(message string) *ServiceError {
 func (e *ServiceError) Error() string {
 	return e.Message
 }
+
+// FieldError 与字段有关的错误，Err 为错误类别，可以通过 errors.Is 判断：
+//   - ErrValidationFailed：字段值不满足校验规则（必填、枚举、范围、格式、外键不存在）
+//   - ErrEntityAlreadyExists：字段值违反唯一性约束
+//   - ErrBadRequest：请求无法解析（请求体格式错误、路径或查询参数无效）
+//
+// 生成的领域服务以 FieldError 返回校验失败，REST 接口据此映射状态码并在错误响应中给出字段，见 HTTPError。
+type FieldError struct {
+	Field   string // 字段名，如 "TotalAmount"；与具体字段无关时为空
+	Message string // 错误信息，如 "TotalAmount 不能为空"
+	Err     error  // 错误类别
+}
+
+// NewValidationError 创建字段校验失败的错误
+func NewValidationError(field, format string, args ...any) *FieldError {
+	return &FieldError{Field: field, Message: fmt.Sprintf(format, args...), Err: ErrValidationFailed}
+}
+
+// NewDuplicateError 创建违反唯一性约束的错误
+func NewDuplicateError(field, format string, args ...any) *FieldError {
+	return &FieldError{Field: field, Message: fmt.Sprintf(format, args...), Err: ErrEntityAlreadyExists}
+}
+
+// NewBadRequestError 创建请求无法解析的错误
+func NewBadRequestError(field, format string, args ...any) *FieldError {
+	return &FieldError{Field: field, Message: fmt.Sprintf(format, args...), Err: ErrBadRequest}
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,181 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CascadeAction 关联实体的级联行为（+soliton:cascade）
+type CascadeAction string
+
+const (
+	CascadeDelete   CascadeAction = "delete"   // 删除聚合根时一并删除关联实体
+	CascadeNullify  CascadeAction = "nullify"  // 删除聚合根时将关联实体的外键置空
+	CascadeRestrict CascadeAction = "restrict" // 存在关联实体时禁止删除聚合根
+)
+
+// ErrCascadeRestricted 存在关联实体，禁止删除（+soliton:cascade(restrict)）
+var ErrCascadeRestricted = errors.New("存在关联实体，禁止删除")
+
+// cascadeRule 关联实体的级联规则
+type cascadeRule struct {
+	name   string        // 关联实体字段名，如 "Items"
+	action CascadeAction // 级联行为
+	model  any           // 关联实体的数据对象指针，如 &OrderItemDO{}
+	column string        // 关联实体中引用聚合根主键的外键列，如 "order_id"
+}
+
+// newModel 创建关联实体数据对象的新实例，避免 GORM 回写共享的注册对象
+func (c cascadeRule) newModel() any {
+	return reflect.New(reflect.TypeOf(c.model).Elem()).Interface()
+}
+
+// RegisterCascade 注册关联实体的级联规则
+//
+// 生成的仓储代码会按 +soliton:cascade 自动注册：
+//
+//	repo.RegisterCascade("Items", framework.CascadeDelete, &do.OrderItemDO{}, "order_id")
+//
+// 删除聚合根（Delete、Remove 及批量删除）时，在同一事务中先按注册顺序处理关联实体：
+//   - CascadeDelete：硬删除时一并硬删除关联实体；软删除时关联实体有 DeletedAt 字段则一并软删除，否则硬删除。
+//     关联实体与聚合根是同一类型（树形结构）时逐层处理子孙节点
+//   - CascadeNullify：将关联实体的外键列置为 NULL
+//   - CascadeRestrict：存在未删除的关联实体时返回 ErrCascadeRestricted，不删除任何记录
+//
+// 只处理直接关联的实体，关联实体自身声明的级联规则由其仓储或数据库外键约束负责；
+// Restore 不会恢复被一并软删除的关联实体。
+func (r *BaseRepositoryOf[T, D, K]) RegisterCascade(name string, action CascadeAction, model any, column string) {
+	r.cascades = append(r.cascades, cascadeRule{
+		name:   name,
+		action: action,
+		model:  model,
+		column: column,
+	})
+}
+
+// applyCascades 在事务中按级联规则处理待删除聚合根的关联实体
+// hard 表示硬删除；visited 记录已处理的聚合根，避免树形数据存在环时无限递归
+func (r *BaseRepositoryOf[T, D, K]) applyCascades(tx *gorm.DB, ids []K, hard bool, visited map[K]bool) error {
+	for _, id := range ids {
+		visited[id] = true
+	}
+
+	for _, rule := range r.cascades {
+		children := func() *gorm.DB {
+			return tx.Model(rule.newModel()).Where(rule.column+" IN ?", ids)
+		}
+
+		switch rule.action {
+		case CascadeRestrict:
+			query := children()
+			if column, ok := modelColumn(tx, rule.model, "DeletedAt"); ok {
+				query = query.Where(column + " IS NULL")
+			}
+			var count int64
+			if err := query.Count(&count).Error; err != nil {
+				return fmt.Errorf("检查关联实体 %s 失败: %w", rule.name, err)
+			}
+			if count > 0 {
+				return fmt.Errorf("%w: %s 仍有 %d 条记录", ErrCascadeRestricted, rule.name, count)
+			}
+
+		case CascadeNullify:
+			if err := children().Update(rule.column, nil).Error; err != nil {
+				return fmt.Errorf("级联置空 %s 失败: %w", rule.name, err)
+			}
+
+		case CascadeDelete:
+			targets := children()
+			// 自引用（树形结构）：先处理子节点自身的子节点，只删除尚未处理过的节点，数据存在环时不会删除正在删除的祖先节点
+			if _, self := rule.model.(*D); self {
+				var childIDs []K
+				if err := children().Pluck(r.primaryKeyColumn(), &childIDs).Error; err != nil {
+					return fmt.Errorf("查询关联实体 %s 失败: %w", rule.name, err)
+				}
+				var pending []K
+				for _, id := range childIDs {
+					if !visited[id] {
+						pending = append(pending, id)
+					}
+				}
+				if len(pending) == 0 {
+					continue
+				}
+				if err := r.applyCascades(tx, pending, hard, visited); err != nil {
+					return err
+				}
+				targets = r.whereIDs(tx.Model(rule.newModel()), pending)
+			}
+
+			column, soft := modelColumn(tx, rule.model, "DeletedAt")
+			var err error
+			if soft && !hard {
+				err = targets.Where(column+" IS NULL").Update(column, time.Now()).Error
+			} else {
+				err = targets.Unscoped().Delete(rule.newModel()).Error
+			}
+			if err != nil {
+				return fmt.Errorf("级联删除 %s 失败: %w", rule.name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// modelColumn 获取数据对象字段对应的列名，字段不存在时返回 false
+func modelColumn(db *gorm.DB, model any, fieldName string) (string, bool) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return "", false
+	}
+
+	field := stmt.Schema.LookUpField(fieldName)
+	if field == nil || field.DBName == "" {
+		return "", false
+	}
+
+	return field.DBName, true
+}
+
+// SaveChildren 通过关联实体的仓储保存聚合根的一对多关联实体，生成的仓储在 AddWithChildren、UpdateWithChildren 中调用
+//
+// children 为聚合根当前的关联实体（外键已回填为聚合根主键），existing 为已保存的属于该聚合根的关联实体：
+//   - IsNew() 的关联实体新增；其余更新，记录不存在时（如手动指定的主键）新增
+//   - existing 中已不在 children 里的关联实体，action 为 CascadeDelete 时删除（有软删除字段时软删除），其他情况保留
+//
+// 数据库仓储传入使用聚合根事务的仓储，任一关联实体保存失败时与聚合根一并回滚。
+func SaveChildren[C EntityOf[K], K comparable](ctx context.Context, repo RepositoryOf[C, K], children, existing []C, action CascadeAction) error {
+	kept := make(map[K]bool, len(children))
+	for _, child := range children {
+		err := ErrRecordNotFound
+		if !child.IsNew() {
+			err = repo.Update(ctx, child)
+		}
+		if errors.Is(err, ErrRecordNotFound) {
+			err = repo.Add(ctx, child)
+		}
+		if err != nil {
+			return fmt.Errorf("保存关联实体失败: %w", err)
+		}
+		kept[child.GetID()] = true
+	}
+
+	if action != CascadeDelete {
+		return nil
+	}
+	for _, child := range existing {
+		if kept[child.GetID()] {
+			continue
+		}
+		if err := repo.Remove(ctx, child.GetID()); err != nil {
+			return fmt.Errorf("删除关联实体失败: %w", err)
+		}
+	}
+	return nil
+}
@@ -1,10 +1,15 @@
 package framework
 
-import "time"
+import (
+	"time"
 
-// Entity 实体接口 - 作为泛型约束
+	"gorm.io/gorm"
+)
+
+// EntityOf 实体接口 - 作为泛型约束
 //
 // 所有聚合根必须实现此接口，以便能够在泛型 Repository 和 Service 中使用。
+// 泛型参数 K 为主键类型，如 int64（自增主键）、string（UUID 主键）。
 //
 // 用途：
 //  1. 泛型约束：确保所有传入泛型仓储、泛型服务的类型 T 都满足基本要求
@@ -14,27 +19,60 @@ import "time"
 // 示例：
 //
 //	// 泛型仓储可以调用 entity 的方法
-//	type Repository[T Entity] interface {
+//	type RepositoryOf[T EntityOf[K], K comparable] interface {
 //	    Add(ctx context.Context, entity T) error
 //	}
 //
-//	func (r *BaseRepository[T, D]) Add(ctx context.Context, entity T) error {
+//	func (r *BaseRepositoryOf[T, D, K]) Add(ctx context.Context, entity T) error {
 //	    if entity.IsNew() {  // 可以安全调用 Entity 接口的方法
 //	        entity.SetID(generatedID)
 //	    }
 //	    // ...
 //	}
-type Entity interface {
+type EntityOf[K comparable] interface {
 	// GetID 获取实体ID
-	GetID() int64
+	GetID() K
 
 	// SetID 设置实体ID
-	SetID(id int64)
+	SetID(id K)
 
-	// IsNew 判断是否为新实体（ID为0表示新实体）
+	// IsNew 判断是否为新实体（ID为零值表示新实体）
 	IsNew() bool
 }
 
+// Entity int64 主键的实体接口
+type Entity = EntityOf[int64]
+
+// IDEnsurer 可自行生成 ID 的实体
+//
+// 仓储未注册 IDGenerator 时，在 Add/AddBatch 中、执行 BeforeAdd 钩子之前调用 EnsureID，
+// 用于实体自带的主键生成逻辑（如 BaseEntityUUID 生成 UUID）。
+type IDEnsurer interface {
+	EnsureID()
+}
+
+// CompositeKey 复合主键
+//
+// 由多个列共同组成主键的聚合根（+soliton:pk 标记多个字段）使用生成的主键结构体作为 EntityOf[K] 的 K，
+// 结构体实现此接口，仓储据此按各列查询：
+//
+//	type OrderLineKey struct {
+//	    TenantID int64
+//	    OrderNo  string
+//	}
+//
+//	func (k OrderLineKey) KeyColumns() []string { return []string{"tenant_id", "order_no"} }
+//	func (k OrderLineKey) KeyValues() []any     { return []any{k.TenantID, k.OrderNo} }
+//
+// 复合主键由调用方设置，仓储不会为其生成 ID。
+type CompositeKey interface {
+	// KeyColumns 返回组成主键的列名，顺序与 KeyValues 一致
+	KeyColumns() []string
+
+	// KeyValues 返回各主键列的值
+	KeyValues() []any
+}
+
 // BaseEntity 基础实体
 //
 // 包含所有聚合根的通用字段和方法，聚合根通过嵌入此结构体自动实现 Entity 接口。
@@ -108,3 +146,97 @@ func (e *BaseEntity) SetAuditInfo(isNew bool) {
 		e.UpdatedAt = now
 	}
 }
+
+// BaseEntityUUID 基础实体（UUID 主键）
+//
+// 与 BaseEntity 相同，但 ID 为字符串类型的 UUID，
+// 聚合根嵌入后实现 EntityOf[string] 接口：
+//
+//	type Device struct {
+//	    framework.BaseEntityUUID
+//	    SerialNo string
+//	}
+//
+// 仓储在插入前自动调用 EnsureID 生成 UUID，已设置的 ID 保持不变。
+type BaseEntityUUID struct {
+	ID        string     `db:"id" json:"id"`
+	CreatedAt time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
+	Version   int        `db:"version" json:"version"`
+	DeletedAt *time.Time `db:"deleted_at" json:"deleted_at,omitempty"`
+}
+
+// GetID 获取实体ID
+func (e *BaseEntityUUID) GetID() string {
+	return e.ID
+}
+
+// SetID 设置实体ID
+func (e *BaseEntityUUID) SetID(id string) {
+	e.ID = id
+}
+
+// IsNew 判断是否为新实体
+func (e *BaseEntityUUID) IsNew() bool {
+	return e.ID == ""
+}
+
+// EnsureID ID 为空时生成新的 UUID
+func (e *BaseEntityUUID) EnsureID() {
+	if e.ID == "" {
+		e.ID = NewUUID()
+	}
+}
+
+// IsDeleted 判断是否已软删除
+func (e *BaseEntityUUID) IsDeleted() bool {
+	return e.DeletedAt != nil
+}
+
+// MarkDeleted 标记为已删除
+func (e *BaseEntityUUID) MarkDeleted() {
+	now := time.Now()
+	e.DeletedAt = &now
+}
+
+// Restore 恢复已删除的实体
+func (e *BaseEntityUUID) Restore() {
+	e.DeletedAt = nil
+}
+
+// IncrementVersion 增加版本号（用于乐观锁）
+func (e *BaseEntityUUID) IncrementVersion() {
+	e.Version++
+}
+
+// SetAuditInfo 设置审计信息
+func (e *BaseEntityUUID) SetAuditInfo(isNew bool) {
+	now := time.Now()
+	if isNew {
+		e.CreatedAt = now
+		e.UpdatedAt = now
+		e.Version = 1
+	} else {
+		e.UpdatedAt = now
+	}
+}
+
+// SoftDeleteOf 将领域对象的删除时间转换为数据对象的软删除字段，nil 或零值表示未删除
+//
+// 生成的 DO 以 gorm.DeletedAt 保存删除时间：GORM 据此把 Delete 改为写入删除时间，
+// 并在查询时自动过滤已删除的记录（Unscoped 查询除外）。
+func SoftDeleteOf(deletedAt *time.Time) gorm.DeletedAt {
+	if deletedAt == nil || deletedAt.IsZero() {
+		return gorm.DeletedAt{}
+	}
+	return gorm.DeletedAt{Time: *deletedAt, Valid: true}
+}
+
+// DeletedAtOf 将数据对象的软删除字段转换为领域对象的删除时间，未删除时为 nil
+func DeletedAtOf(deletedAt gorm.DeletedAt) *time.Time {
+	if !deletedAt.Valid {
+		return nil
+	}
+	t := deletedAt.Time
+	return &t
+}
@@ -0,0 +1,86 @@
+package framework
+
+import (
+	"context"
+	"fmt"
+)
+
+// DomainEvent 领域事件
+//
+// soliton 为 +soliton:event 声明的事件生成该接口的实现，EventName 为事件名，Topic 为消息主题。
+type DomainEvent interface {
+	EventName() string // 事件名，如 "OrderPlaced"
+	Topic() string     // 消息主题，如 "ordering.order.placed"
+}
+
+// AggregateEvent 携带聚合根 ID 的领域事件
+// 生成的事件结构体实现该接口，发布前由 PublishEvents 回填聚合根 ID（新聚合根的 ID 在保存后才生成）
+type AggregateEvent[K comparable] interface {
+	DomainEvent
+	SetAggregateID(id K)
+}
+
+// EventBus 领域事件总线，由应用实现（如写入消息队列或 outbox 表）
+type EventBus interface {
+	// Publish 发布领域事件，events 按记录顺序排列
+	Publish(ctx context.Context, events ...DomainEvent) error
+}
+
+// EventSource 记录了领域事件的聚合根
+type EventSource interface {
+	// PullEvents 取出记录的领域事件并清空
+	PullEvents() []DomainEvent
+}
+
+// EventRecorder 聚合根记录领域事件的容器，零值可用
+//
+// 聚合根嵌入 EventRecorder 后即可记录领域事件，生成的领域服务在仓储写入成功后发布：
+//
+//	type Order struct {
+//	    framework.BaseEntity
+//	    framework.EventRecorder
+//	    ...
+//	}
+//
+//	func (o *Order) Place() {
+//	    o.Status = "placed"
+//	    o.RecordOrderPlaced() // 生成的事件记录方法，也可以直接调用 o.RecordEvent(&OrderPlaced{...})
+//	}
+//
+// 记录的事件不参与列映射，也不随聚合根持久化。
+type EventRecorder struct {
+	events []DomainEvent
+}
+
+// RecordEvent 记录领域事件
+func (r *EventRecorder) RecordEvent(event DomainEvent) {
+	r.events = append(r.events, event)
+}
+
+// PullEvents 取出记录的领域事件并清空
+func (r *EventRecorder) PullEvents() []DomainEvent {
+	events := r.events
+	r.events = nil
+	return events
+}
+
+// PublishEvents 取出聚合根记录的领域事件并通过 bus 发布，id 为聚合根 ID
+//
+// 实现 AggregateEvent[K] 的事件在发布前回填 id。bus 为 nil 时事件被丢弃，
+// 保证未配置事件总线时重复保存同一聚合根不会累积事件。
+func PublishEvents[K comparable](ctx context.Context, bus EventBus, source EventSource, id K) error {
+	events := source.PullEvents()
+	if bus == nil || len(events) == 0 {
+		return nil
+	}
+
+	for _, event := range events {
+		if aggregateEvent, ok := event.(AggregateEvent[K]); ok {
+			aggregateEvent.SetAggregateID(id)
+		}
+	}
+	if err := bus.Publish(ctx, events...); err != nil {
+		return fmt.Errorf("发布领域事件失败: %w", err)
+	}
+	return nil
+}
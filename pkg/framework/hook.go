@@ -0,0 +1,92 @@
+package framework
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// HookPhase 仓储钩子触发阶段
+type HookPhase int
+
+const (
+	BeforeAdd    HookPhase = iota // 插入前
+	AfterAdd                      // 插入后（与插入处于同一事务）
+	BeforeUpdate                  // 更新前
+	AfterUpdate                   // 更新后（与更新处于同一事务）
+	BeforeDelete                  // 删除前（硬删除和软删除均触发）
+	AfterDelete                   // 删除后（与删除处于同一事务）
+)
+
+// String 返回阶段名称
+func (p HookPhase) String() string {
+	switch p {
+	case BeforeAdd:
+		return "BeforeAdd"
+	case AfterAdd:
+		return "AfterAdd"
+	case BeforeUpdate:
+		return "BeforeUpdate"
+	case AfterUpdate:
+		return "AfterUpdate"
+	case BeforeDelete:
+		return "BeforeDelete"
+	case AfterDelete:
+		return "AfterDelete"
+	default:
+		return fmt.Sprintf("HookPhase(%d)", int(p))
+	}
+}
+
+// HookFunc 仓储钩子函数
+// 返回错误会中止当前操作；如果操作处于事务中，整个事务回滚
+type HookFunc[T any] func(ctx context.Context, entity T) error
+
+// hookRegistry 钩子注册表
+//
+// 由 BaseRepository 及其通过 Transaction / WithTx 创建的事务仓储实例共享，
+// 保证钩子在事务中同样生效。
+type hookRegistry[T any] struct {
+	mu    sync.RWMutex
+	hooks map[HookPhase][]HookFunc[T]
+}
+
+// newHookRegistry 创建钩子注册表
+func newHookRegistry[T any]() *hookRegistry[T] {
+	return &hookRegistry[T]{
+		hooks: make(map[HookPhase][]HookFunc[T]),
+	}
+}
+
+// register 注册钩子
+func (h *hookRegistry[T]) register(phase HookPhase, fn HookFunc[T]) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hooks[phase] = append(h.hooks[phase], fn)
+}
+
+// has 判断指定阶段是否注册了钩子
+func (h *hookRegistry[T]) has(phases ...HookPhase) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, phase := range phases {
+		if len(h.hooks[phase]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// run 按注册顺序执行钩子，遇到第一个错误立即返回
+func (h *hookRegistry[T]) run(ctx context.Context, phase HookPhase, entity T) error {
+	h.mu.RLock()
+	hooks := h.hooks[phase]
+	h.mu.RUnlock()
+
+	for _, fn := range hooks {
+		if err := fn(ctx, entity); err != nil {
+			return fmt.Errorf("%s 钩子执行失败: %w", phase, err)
+		}
+	}
+	return nil
+}
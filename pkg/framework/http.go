@@ -0,0 +1,107 @@
+package framework
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// REST 接口错误响应中的错误码
+const (
+	ErrorCodeBadRequest = "BAD_REQUEST"       // 请求无法解析，400
+	ErrorCodeValidation = "VALIDATION_FAILED" // 字段校验失败，422
+	ErrorCodeNotFound   = "NOT_FOUND"         // 实体不存在，404
+	ErrorCodeConflict   = "CONFLICT"          // 违反唯一性约束、版本冲突或存在关联实体，409
+	ErrorCodeInternal   = "INTERNAL_ERROR"    // 其他错误，500
+)
+
+// 分页参数
+const (
+	DefaultPageSize = 20  // 未指定 pageSize 时的每页数量
+	MaxPageSize     = 100 // pageSize 的上限
+)
+
+// ErrorResponse REST 接口统一的错误响应
+//
+//	{"error": {"code": "VALIDATION_FAILED", "message": "TotalAmount 不能为空", "field": "TotalAmount"}}
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody 错误响应的内容
+type ErrorBody struct {
+	Code    string `json:"code"`            // 错误码，见 ErrorCodeValidation 等常量
+	Message string `json:"message"`         // 错误信息
+	Field   string `json:"field,omitempty"` // 出错的字段，与具体字段无关时省略
+}
+
+// PageResponse REST 接口的分页响应
+type PageResponse[T any] struct {
+	Items    []T   `json:"items"`
+	Total    int64 `json:"total"`
+	Page     int   `json:"page"`
+	PageSize int   `json:"pageSize"`
+}
+
+// HTTPError 将领域服务和仓储返回的错误转换为 HTTP 状态码和统一的错误响应
+//
+// 未识别的错误按 500 处理，响应中不包含原始错误信息，避免泄露 SQL 等内部细节，需要由调用方记录日志。
+func HTTPError(err error) (int, ErrorResponse) {
+	status, body := http.StatusInternalServerError, ErrorBody{Code: ErrorCodeInternal, Message: "服务器内部错误"}
+
+	switch {
+	case errors.Is(err, ErrBadRequest):
+		status, body = http.StatusBadRequest, ErrorBody{Code: ErrorCodeBadRequest, Message: err.Error()}
+	case errors.Is(err, ErrValidationFailed):
+		status, body = http.StatusUnprocessableEntity, ErrorBody{Code: ErrorCodeValidation, Message: err.Error()}
+	case errors.Is(err, ErrEntityNotFound), errors.Is(err, ErrRecordNotFound):
+		status, body = http.StatusNotFound, ErrorBody{Code: ErrorCodeNotFound, Message: err.Error()}
+	case errors.Is(err, ErrEntityAlreadyExists), errors.Is(err, ErrVersionConflict), errors.Is(err, ErrCascadeRestricted):
+		status, body = http.StatusConflict, ErrorBody{Code: ErrorCodeConflict, Message: err.Error()}
+	}
+
+	var fieldErr *FieldError
+	if status != http.StatusInternalServerError && errors.As(err, &fieldErr) {
+		body.Field = fieldErr.Field
+	}
+	return status, ErrorResponse{Error: body}
+}
+
+// ParsePage 解析分页查询参数 page、pageSize
+// 为空时分别为 1 和 DefaultPageSize，pageSize 超过 MaxPageSize 时按 MaxPageSize 处理，不是正整数时返回 ErrBadRequest
+func ParsePage(page, pageSize string) (int, int, error) {
+	pageNum, size := 1, DefaultPageSize
+	if page != "" {
+		n, err := strconv.Atoi(page)
+		if err != nil || n < 1 {
+			return 0, 0, NewBadRequestError("page", "page 必须是正整数: %q", page)
+		}
+		pageNum = n
+	}
+	if pageSize != "" {
+		n, err := strconv.Atoi(pageSize)
+		if err != nil || n < 1 {
+			return 0, 0, NewBadRequestError("pageSize", "pageSize 必须是正整数: %q", pageSize)
+		}
+		size = min(n, MaxPageSize)
+	}
+	return pageNum, size, nil
+}
+
+// NormalizePage 校验数值形式的分页参数（如 gRPC 请求中的 page、page_size）并补全默认值
+// 0 表示未指定，分别按 1 和 DefaultPageSize 处理，pageSize 超过 MaxPageSize 时按 MaxPageSize 处理，负数返回 ErrBadRequest
+func NormalizePage(page, pageSize int) (int, int, error) {
+	if page < 0 {
+		return 0, 0, NewBadRequestError("page", "page 不能为负数: %d", page)
+	}
+	if pageSize < 0 {
+		return 0, 0, NewBadRequestError("pageSize", "pageSize 不能为负数: %d", pageSize)
+	}
+	if page == 0 {
+		page = 1
+	}
+	if pageSize == 0 {
+		pageSize = DefaultPageSize
+	}
+	return page, min(pageSize, MaxPageSize), nil
+}
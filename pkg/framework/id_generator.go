@@ -0,0 +1,139 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// IDStrategy 主键生成策略，对应 +soliton:id(strategy=...) 注解
+type IDStrategy string
+
+const (
+	IDStrategyAuto      IDStrategy = "auto"      // 数据库自增（int64 主键的默认策略）
+	IDStrategyUUID      IDStrategy = "uuid"      // 应用生成 UUID（string 主键的默认策略）
+	IDStrategySnowflake IDStrategy = "snowflake" // 应用生成雪花 ID（int64 主键）
+	IDStrategyManual    IDStrategy = "manual"    // 由调用方设置，仓储不生成
+)
+
+// ErrIDRequired 手动指定主键的实体在添加时未设置 ID
+var ErrIDRequired = errors.New("实体 ID 未设置：主键策略为 manual，需要在添加前设置 ID")
+
+// IDGenerator 主键生成器
+//
+// 通过 BaseRepositoryOf.SetIDGenerator 注册后，仓储在 Add/AddBatch 时
+// 为 IsNew() 的实体调用 NextID 生成主键，用于数据库自增以外的策略：
+//
+//	repo.SetIDGenerator(framework.DefaultSnowflakeGenerator())
+type IDGenerator[K comparable] interface {
+	NextID(ctx context.Context) (K, error)
+}
+
+// IDGeneratorFunc 函数形式的主键生成器
+type IDGeneratorFunc[K comparable] func(ctx context.Context) (K, error)
+
+// NextID 生成主键
+func (f IDGeneratorFunc[K]) NextID(ctx context.Context) (K, error) {
+	return f(ctx)
+}
+
+// UUIDGenerator 返回生成随机 UUID 的主键生成器
+func UUIDGenerator() IDGenerator[string] {
+	return IDGeneratorFunc[string](func(ctx context.Context) (string, error) {
+		return NewUUID(), nil
+	})
+}
+
+// ManualIDGenerator 返回手动主键策略的生成器
+// 添加未设置 ID 的实体时返回 ErrIDRequired，避免写入零值主键
+func ManualIDGenerator[K comparable]() IDGenerator[K] {
+	return IDGeneratorFunc[K](func(ctx context.Context) (K, error) {
+		var zero K
+		return zero, ErrIDRequired
+	})
+}
+
+// ==================== 雪花 ID ====================
+
+const (
+	snowflakeNodeBits     = 10
+	snowflakeSequenceBits = 12
+	snowflakeMaxNode      = 1<<snowflakeNodeBits - 1
+	snowflakeMaxSequence  = 1<<snowflakeSequenceBits - 1
+)
+
+// snowflakeEpoch 雪花 ID 的起始时间（2024-01-01 UTC），41 位毫秒时间戳约可使用 69 年
+var snowflakeEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+
+// SnowflakeGenerator 雪花 ID 生成器
+//
+// 生成的 int64 ID 由 41 位毫秒时间戳、10 位节点号和 12 位序列号组成，
+// 同一节点内单调递增。多实例部署时每个实例需要使用不同的节点号。
+type SnowflakeGenerator struct {
+	mu       sync.Mutex
+	node     int64
+	lastTime int64
+	sequence int64
+}
+
+// NewSnowflakeGenerator 创建雪花 ID 生成器，node 取值范围 0-1023
+func NewSnowflakeGenerator(node int64) (*SnowflakeGenerator, error) {
+	if node < 0 || node > snowflakeMaxNode {
+		return nil, fmt.Errorf("雪花 ID 节点号超出范围 [0, %d]: %d", snowflakeMaxNode, node)
+	}
+	return &SnowflakeGenerator{node: node}, nil
+}
+
+// NextID 生成下一个雪花 ID
+//
+// 同一毫秒内序列号用尽时等待下一毫秒；系统时钟回拨时沿用上次的时间戳继续递增，保证不重复。
+func (g *SnowflakeGenerator) NextID(ctx context.Context) (int64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now().UnixMilli() - snowflakeEpoch
+	if now < g.lastTime {
+		now = g.lastTime
+	}
+
+	if now == g.lastTime {
+		g.sequence = (g.sequence + 1) & snowflakeMaxSequence
+		if g.sequence == 0 {
+			// 当前毫秒序列号用尽，等待下一毫秒
+			for now <= g.lastTime {
+				if err := ctx.Err(); err != nil {
+					return 0, err
+				}
+				time.Sleep(100 * time.Microsecond)
+				now = time.Now().UnixMilli() - snowflakeEpoch
+			}
+		}
+	} else {
+		g.sequence = 0
+	}
+
+	g.lastTime = now
+	return now<<(snowflakeNodeBits+snowflakeSequenceBits) | g.node<<snowflakeSequenceBits | g.sequence, nil
+}
+
+// defaultSnowflake 进程内共享的雪花 ID 生成器
+var defaultSnowflake = &SnowflakeGenerator{}
+
+// DefaultSnowflakeGenerator 返回进程内共享的雪花 ID 生成器
+// 生成的仓储代码在 snowflake 策略下使用它，节点号默认为 0，可通过 SetDefaultSnowflakeNode 设置
+func DefaultSnowflakeGenerator() *SnowflakeGenerator {
+	return defaultSnowflake
+}
+
+// SetDefaultSnowflakeNode 设置共享雪花 ID 生成器的节点号，应在应用启动时、生成 ID 之前调用
+func SetDefaultSnowflakeNode(node int64) error {
+	if node < 0 || node > snowflakeMaxNode {
+		return fmt.Errorf("雪花 ID 节点号超出范围 [0, %d]: %d", snowflakeMaxNode, node)
+	}
+	defaultSnowflake.mu.Lock()
+	defaultSnowflake.node = node
+	defaultSnowflake.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,94 @@
+package framework
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// VersionedValue 带版本的 JSON 值对象（+soliton:valueObject(strategy=json)）
+//
+// 值对象的结构发生不兼容的变化（字段改名、拆分、类型变化）时实现此接口并递增版本号。
+// 序列化结果带上版本号 {"_v":2,"_data":{...}}，读取旧版本的数据时先由 UpgradeJSON 升级为当前结构再反序列化，
+// 不会因字段对不上而得到零值并在下次保存时覆盖原有数据：
+//
+//	func (Address) JSONVersion() int { return 2 }
+//
+//	// 版本 1 的 street 拆分为 line1、line2
+//	func (Address) UpgradeJSON(version int, data []byte) ([]byte, error) {
+//	    var v1 struct{ Street string `json:"street"` }
+//	    if err := json.Unmarshal(data, &v1); err != nil {
+//	        return nil, err
+//	    }
+//	    line1, line2, _ := strings.Cut(v1.Street, "\n")
+//	    return json.Marshal(Address{Line1: line1, Line2: line2})
+//	}
+//
+// 实现此接口之前写入的数据没有版本号，按版本 1 处理。
+type VersionedValue interface {
+	// JSONVersion 返回值对象当前的结构版本，从 1 开始
+	JSONVersion() int
+	// UpgradeJSON 将版本为 version（小于当前版本）的数据升级为当前版本的结构
+	UpgradeJSON(version int, data []byte) ([]byte, error)
+}
+
+// versionedJSON 带版本的值对象的存储格式
+type versionedJSON struct {
+	Version *int            `json:"_v"`
+	Data    json.RawMessage `json:"_data"`
+}
+
+// versionedValueOf 返回值对象实现的 VersionedValue，方法声明在值或指针接收者上均可
+func versionedValueOf[T any](value *T) (VersionedValue, bool) {
+	if versioned, ok := any(*value).(VersionedValue); ok {
+		return versioned, true
+	}
+	versioned, ok := any(value).(VersionedValue)
+	return versioned, ok
+}
+
+// MarshalJSONValue 序列化 JSON 策略的值对象，写入 DO 的字符串列
+// 值对象实现了 VersionedValue 时带上当前版本号
+func MarshalJSONValue[T any](value T) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	if versioned, ok := versionedValueOf(&value); ok {
+		version := versioned.JSONVersion()
+		if data, err = json.Marshal(versionedJSON{Version: &version, Data: data}); err != nil {
+			return "", err
+		}
+	}
+	return string(data), nil
+}
+
+// UnmarshalJSONValue 反序列化 DO 字符串列中的值对象，空字符串和 null 保持 value 不变
+//
+// 值对象实现了 VersionedValue 时，低于当前版本的数据先经 UpgradeJSON 升级；
+// 高于当前版本的数据（如滚动发布期间由新版本写入）返回错误，避免按旧结构读取后丢失新字段。
+func UnmarshalJSONValue[T any](data string, value *T) error {
+	if data == "" || data == "null" {
+		return nil
+	}
+
+	raw := []byte(data)
+	if versioned, ok := versionedValueOf(value); ok {
+		version, current := 1, versioned.JSONVersion()
+		var stored versionedJSON
+		if json.Unmarshal(raw, &stored) == nil && stored.Version != nil && stored.Data != nil {
+			version, raw = *stored.Version, stored.Data
+		}
+
+		switch {
+		case version > current:
+			return fmt.Errorf("%T 的数据版本 %d 高于当前版本 %d", *value, version, current)
+		case version < current:
+			upgraded, err := versioned.UpgradeJSON(version, raw)
+			if err != nil {
+				return fmt.Errorf("%T 的数据从版本 %d 升级到 %d 失败: %w", *value, version, current, err)
+			}
+			raw = upgraded
+		}
+	}
+	return json.Unmarshal(raw, value)
+}
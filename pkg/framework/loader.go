@@ -0,0 +1,130 @@
+package framework
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 数据加载器的默认参数
+const (
+	DefaultLoaderWait     = 2 * time.Millisecond // 收集一批键的等待时间
+	DefaultLoaderMaxBatch = 500                  // 一批最多的键数，达到后立即查询
+)
+
+// LoaderFunc 按一批键查询数据，返回键 → 值，不存在的键不在结果中
+type LoaderFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Loader 数据加载器（dataloader 模式）
+//
+// 将等待时间内对 Load 的多次调用合并为一次 LoaderFunc 调用，用于 GraphQL 等逐个解析对象的场景，
+// 避免为每个对象单独查询关联（N+1）：
+//
+//	roles := framework.NewLoader(func(ctx context.Context, userIDs []int64) (map[int64][]*Role, error) {
+//	    ...
+//	})
+//	userRoles, err := roles.Load(ctx, user.ID)
+//
+// 加载结果（包括错误）会缓存在加载器中，同一个键只查询一次，因此加载器应按请求创建。
+// 一批查询使用这一批中第一次 Load 的 ctx。
+type Loader[K comparable, V any] struct {
+	fetch    LoaderFunc[K, V]
+	wait     time.Duration
+	maxBatch int
+
+	mu      sync.Mutex
+	cache   map[K]*loaderCall[V]
+	pending *loaderBatch[K, V]
+}
+
+// loaderCall 一个键的加载结果，done 关闭后 value、err 可读
+type loaderCall[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// loaderBatch 等待查询的一批键
+type loaderBatch[K comparable, V any] struct {
+	ctx        context.Context
+	keys       []K
+	calls      []*loaderCall[V]
+	dispatched bool
+}
+
+// NewLoader 创建数据加载器，等待时间和批量大小取默认值
+func NewLoader[K comparable, V any](fetch LoaderFunc[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{
+		fetch:    fetch,
+		wait:     DefaultLoaderWait,
+		maxBatch: DefaultLoaderMaxBatch,
+		cache:    make(map[K]*loaderCall[V]),
+	}
+}
+
+// SetWait 设置收集一批键的等待时间
+func (l *Loader[K, V]) SetWait(wait time.Duration) {
+	l.wait = wait
+}
+
+// SetMaxBatch 设置一批最多的键数，0 表示不限制
+func (l *Loader[K, V]) SetMaxBatch(maxBatch int) {
+	l.maxBatch = maxBatch
+}
+
+// Load 加载一个键对应的值，键不存在时返回零值
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	call, ok := l.cache[key]
+	if !ok {
+		call = &loaderCall[V]{done: make(chan struct{})}
+		l.cache[key] = call
+		l.enqueue(ctx, key, call)
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// enqueue 将键加入当前批次，调用方需持有锁
+func (l *Loader[K, V]) enqueue(ctx context.Context, key K, call *loaderCall[V]) {
+	if l.pending == nil {
+		batch := &loaderBatch[K, V]{ctx: ctx}
+		l.pending = batch
+		time.AfterFunc(l.wait, func() { l.dispatch(batch) })
+	}
+
+	batch := l.pending
+	batch.keys = append(batch.keys, key)
+	batch.calls = append(batch.calls, call)
+	if l.maxBatch > 0 && len(batch.keys) >= l.maxBatch {
+		l.pending = nil
+		go l.dispatch(batch)
+	}
+}
+
+// dispatch 查询一批键并通知等待的调用方，同一批次只查询一次
+func (l *Loader[K, V]) dispatch(batch *loaderBatch[K, V]) {
+	l.mu.Lock()
+	if batch.dispatched {
+		l.mu.Unlock()
+		return
+	}
+	batch.dispatched = true
+	if l.pending == batch {
+		l.pending = nil
+	}
+	l.mu.Unlock()
+
+	values, err := l.fetch(batch.ctx, batch.keys)
+	for i, call := range batch.calls {
+		call.value, call.err = values[batch.keys[i]], err
+		close(call.done)
+	}
+}
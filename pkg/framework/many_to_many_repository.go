@@ -0,0 +1,269 @@
+package framework
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ManyToManyRepository 多对多关联表仓储
+//
+// 泛型参数：
+//   - L: 左侧聚合根类型（如 *User）
+//   - R: 右侧聚合根类型（如 *Role）
+//
+// 只管理关联表中的关联行，不加载两侧的聚合根。
+// 表名和列名直接取自分析器生成的 ManyToManyTableMetadata：
+//
+//	userRoles := framework.NewManyToManyRepository[*User, *Role](db, "role_user", "user_id", "role_id")
+//	err := userRoles.Attach(ctx, userID, roleID)
+//
+// 关联表需要在 (leftColumn, rightColumn) 上有唯一索引（SQL 生成器会自动创建），
+// Attach 依赖该索引实现幂等和并发安全。
+type ManyToManyRepository[L Entity, R Entity] struct {
+	db          *gorm.DB // GORM 数据库实例
+	tableName   string   // 关联表名，如 "role_user"
+	leftColumn  string   // 左侧外键列名，如 "user_id"
+	rightColumn string   // 右侧外键列名，如 "role_id"
+}
+
+// NewManyToManyRepository 创建多对多关联表仓储
+func NewManyToManyRepository[L Entity, R Entity](
+	db *gorm.DB,
+	tableName string,
+	leftColumn string,
+	rightColumn string,
+) *ManyToManyRepository[L, R] {
+	return &ManyToManyRepository[L, R]{
+		db:          db,
+		tableName:   tableName,
+		leftColumn:  leftColumn,
+		rightColumn: rightColumn,
+	}
+}
+
+// DB 获取数据库实例（用于扩展方法）
+func (r *ManyToManyRepository[L, R]) DB() *gorm.DB {
+	return r.db
+}
+
+// TableName 获取关联表名
+func (r *ManyToManyRepository[L, R]) TableName() string {
+	return r.tableName
+}
+
+// Attach 建立关联
+// 幂等：关联已存在时不报错
+func (r *ManyToManyRepository[L, R]) Attach(ctx context.Context, leftID, rightID int64) error {
+	return r.insertLinks(r.db.WithContext(ctx), leftID, []int64{rightID})
+}
+
+// Detach 解除关联
+// 关联不存在时不报错
+func (r *ManyToManyRepository[L, R]) Detach(ctx context.Context, leftID, rightID int64) error {
+	return r.table(ctx).
+		Where(r.leftColumn+" = ? AND "+r.rightColumn+" = ?", leftID, rightID).
+		Delete(map[string]interface{}{}).Error
+}
+
+// DetachAllForLeft 解除左侧实体的所有关联
+// 通常在删除左侧聚合根时调用
+func (r *ManyToManyRepository[L, R]) DetachAllForLeft(ctx context.Context, leftID int64) error {
+	return r.table(ctx).
+		Where(r.leftColumn+" = ?", leftID).
+		Delete(map[string]interface{}{}).Error
+}
+
+// ListRightIDs 查询左侧实体关联的所有右侧 ID（升序）
+func (r *ManyToManyRepository[L, R]) ListRightIDs(ctx context.Context, leftID int64) ([]int64, error) {
+	return r.pluckIDs(r.table(ctx), r.rightColumn, r.leftColumn, leftID)
+}
+
+// ListLeftIDs 查询右侧实体关联的所有左侧 ID（升序）
+func (r *ManyToManyRepository[L, R]) ListLeftIDs(ctx context.Context, rightID int64) ([]int64, error) {
+	return r.pluckIDs(r.table(ctx), r.leftColumn, r.rightColumn, rightID)
+}
+
+// ListRightIDsByLeft 批量查询多个左侧实体关联的右侧 ID，返回左侧 ID → 右侧 ID 列表（升序），没有关联的左侧 ID 不在结果中
+func (r *ManyToManyRepository[L, R]) ListRightIDsByLeft(ctx context.Context, leftIDs []int64) (map[int64][]int64, error) {
+	return r.groupIDs(r.table(ctx), r.rightColumn, r.leftColumn, leftIDs)
+}
+
+// ListLeftIDsByRight 批量查询多个右侧实体关联的左侧 ID，返回右侧 ID → 左侧 ID 列表（升序），没有关联的右侧 ID 不在结果中
+func (r *ManyToManyRepository[L, R]) ListLeftIDsByRight(ctx context.Context, rightIDs []int64) (map[int64][]int64, error) {
+	return r.groupIDs(r.table(ctx), r.leftColumn, r.rightColumn, rightIDs)
+}
+
+// AreLinked 检查两个实体是否已关联
+func (r *ManyToManyRepository[L, R]) AreLinked(ctx context.Context, leftID, rightID int64) (bool, error) {
+	var count int64
+	err := r.table(ctx).
+		Where(r.leftColumn+" = ? AND "+r.rightColumn+" = ?", leftID, rightID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// ReplaceRights 将左侧实体的关联替换为 rightIDs
+//
+// 在同一事务中比较现有关联与目标关联，只删除多余的、只插入缺少的，
+// 未变化的关联行保持不动。rightIDs 为空表示解除所有关联。
+func (r *ManyToManyRepository[L, R]) ReplaceRights(ctx context.Context, leftID int64, rightIDs []int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current, err := r.pluckIDs(tx.Table(r.tableName), r.rightColumn, r.leftColumn, leftID)
+		if err != nil {
+			return err
+		}
+
+		// 计算差异
+		desired := make(map[int64]bool, len(rightIDs))
+		for _, id := range rightIDs {
+			desired[id] = true
+		}
+		existing := make(map[int64]bool, len(current))
+		var toRemove []int64
+		for _, id := range current {
+			existing[id] = true
+			if !desired[id] {
+				toRemove = append(toRemove, id)
+			}
+		}
+		var toAdd []int64
+		for _, id := range rightIDs {
+			if !existing[id] {
+				toAdd = append(toAdd, id)
+				existing[id] = true // 去重
+			}
+		}
+
+		if len(toRemove) > 0 {
+			err := tx.Table(r.tableName).
+				Where(r.leftColumn+" = ? AND "+r.rightColumn+" IN ?", leftID, toRemove).
+				Delete(map[string]interface{}{}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return r.insertLinks(tx, leftID, toAdd)
+	})
+}
+
+// Transaction 执行事务
+func (r *ManyToManyRepository[L, R]) Transaction(ctx context.Context, fn func(*ManyToManyRepository[L, R]) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(r.WithTx(tx))
+	})
+}
+
+// WithTx 在现有事务中创建关联表仓储实例
+//
+// 用于与聚合根写操作共享同一事务：
+//
+//	err := db.Transaction(func(tx *gorm.DB) error {
+//	    if err := userRepo.WithTx(tx).Add(ctx, user); err != nil {
+//	        return err
+//	    }
+//	    return userRoles.WithTx(tx).Attach(ctx, user.ID, roleID)
+//	})
+func (r *ManyToManyRepository[L, R]) WithTx(tx *gorm.DB) *ManyToManyRepository[L, R] {
+	return &ManyToManyRepository[L, R]{
+		db:          tx,
+		tableName:   r.tableName,
+		leftColumn:  r.leftColumn,
+		rightColumn: r.rightColumn,
+	}
+}
+
+// table 获取关联表查询
+func (r *ManyToManyRepository[L, R]) table(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).Table(r.tableName)
+}
+
+// insertLinks 批量插入关联行，已存在的关联忽略（依赖唯一索引）
+//
+// SQLite、PostgreSQL 使用 ON CONFLICT DO NOTHING。MySQL 不支持该语法，GORM 的 MySQL 驱动只为带模型的语句
+// 将其改写为 ON DUPLICATE KEY UPDATE，按表名插入 map 时没有模型，因此单独生成语句（见 mysqlInsertLinks）。
+func (r *ManyToManyRepository[L, R]) insertLinks(db *gorm.DB, leftID int64, rightIDs []int64) error {
+	if len(rightIDs) == 0 {
+		return nil
+	}
+
+	if db.Dialector.Name() == "mysql" {
+		sql, args := r.mysqlInsertLinks(leftID, rightIDs)
+		return db.Exec(sql, args...).Error
+	}
+
+	rows := make([]map[string]interface{}, len(rightIDs))
+	for i, rightID := range rightIDs {
+		rows[i] = map[string]interface{}{
+			r.leftColumn:  leftID,
+			r.rightColumn: rightID,
+		}
+	}
+
+	return db.Table(r.tableName).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&rows).Error
+}
+
+// mysqlInsertLinks 返回 MySQL 批量插入关联行的语句和参数，已存在的关联通过 ON DUPLICATE KEY UPDATE 保持原值
+// 不使用 INSERT IGNORE，避免外键约束失败等其他错误也被降级为警告
+func (r *ManyToManyRepository[L, R]) mysqlInsertLinks(leftID int64, rightIDs []int64) (string, []interface{}) {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("INSERT INTO `%s` (`%s`, `%s`) VALUES ", r.tableName, r.leftColumn, r.rightColumn))
+	args := make([]interface{}, 0, 2*len(rightIDs))
+	for i, rightID := range rightIDs {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(?, ?)")
+		args = append(args, leftID, rightID)
+	}
+	sb.WriteString(fmt.Sprintf(" ON DUPLICATE KEY UPDATE `%s` = `%s`", r.leftColumn, r.leftColumn))
+	return sb.String(), args
+}
+
+// pluckIDs 按条件查询某一列的 ID 列表
+func (r *ManyToManyRepository[L, R]) pluckIDs(db *gorm.DB, column, whereColumn string, whereID int64) ([]int64, error) {
+	ids := make([]int64, 0)
+	err := db.Where(whereColumn+" = ?", whereID).
+		Order(column).
+		Pluck(column, &ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// groupIDs 以 IN 查询一次取出 whereIDs 关联的 column 列，按 whereColumn 分组
+func (r *ManyToManyRepository[L, R]) groupIDs(db *gorm.DB, column, whereColumn string, whereIDs []int64) (map[int64][]int64, error) {
+	result := make(map[int64][]int64)
+	if len(whereIDs) == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		Owner int64
+		ID    int64
+	}
+	err := db.Select(whereColumn+" AS owner, "+column+" AS id").
+		Where(whereColumn+" IN ?", whereIDs).
+		Order(column).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		result[row.Owner] = append(result[row.Owner], row.ID)
+	}
+	return result, nil
+}
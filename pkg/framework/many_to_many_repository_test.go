@@ -0,0 +1,216 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testUserRoleDO 测试用的多对多关联表，(user_id, role_id) 上有唯一索引
+type testUserRoleDO struct {
+	ID     int64 `gorm:"column:id;primaryKey;autoIncrement"`
+	UserID int64 `gorm:"column:user_id;uniqueIndex:uk_test_user_roles,priority:1"`
+	RoleID int64 `gorm:"column:role_id;uniqueIndex:uk_test_user_roles,priority:2"`
+}
+
+func (testUserRoleDO) TableName() string { return "test_user_roles" }
+
+// newTestUserRoles 创建 test_user_roles 的关联表仓储
+func newTestUserRoles(db *gorm.DB) *ManyToManyRepository[*testOrder, *testOrder] {
+	return NewManyToManyRepository[*testOrder, *testOrder](db, "test_user_roles", "user_id", "role_id")
+}
+
+// linkRows 返回关联表中的所有行，按主键排序
+func linkRows(t *testing.T, db *gorm.DB) []testUserRoleDO {
+	t.Helper()
+	var rows []testUserRoleDO
+	if err := db.Order("id").Find(&rows).Error; err != nil {
+		t.Fatalf("查询关联行失败: %v", err)
+	}
+	return rows
+}
+
+func TestAttachIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t, &testUserRoleDO{})
+	userRoles := newTestUserRoles(db)
+
+	for i := 0; i < 3; i++ {
+		if err := userRoles.Attach(ctx, 1, 10); err != nil {
+			t.Fatalf("第 %d 次 Attach 失败: %v", i+1, err)
+		}
+	}
+	if err := userRoles.Attach(ctx, 1, 20); err != nil {
+		t.Fatalf("Attach 失败: %v", err)
+	}
+
+	if rows := linkRows(t, db); len(rows) != 2 {
+		t.Errorf("关联行 = %v，重复 Attach 不应插入新行", rows)
+	}
+	ids, err := userRoles.ListRightIDs(ctx, 1)
+	if err != nil || !reflect.DeepEqual(ids, []int64{10, 20}) {
+		t.Errorf("ListRightIDs() = %v, %v, want [10 20]", ids, err)
+	}
+}
+
+func TestReplaceRightsKeepsUnchangedLinks(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t, &testUserRoleDO{})
+	userRoles := newTestUserRoles(db)
+
+	for _, roleID := range []int64{1, 2, 3} {
+		if err := userRoles.Attach(ctx, 1, roleID); err != nil {
+			t.Fatalf("Attach 失败: %v", err)
+		}
+	}
+	if err := userRoles.Attach(ctx, 2, 1); err != nil {
+		t.Fatalf("Attach 失败: %v", err)
+	}
+	kept := linkRows(t, db)[2] // (1, 3)
+
+	if err := userRoles.ReplaceRights(ctx, 1, []int64{3, 4, 4}); err != nil {
+		t.Fatalf("ReplaceRights 失败: %v", err)
+	}
+
+	ids, err := userRoles.ListRightIDs(ctx, 1)
+	if err != nil || !reflect.DeepEqual(ids, []int64{3, 4}) {
+		t.Errorf("ListRightIDs(1) = %v, %v, want [3 4]", ids, err)
+	}
+	// 未变化的关联行保持不动，其他左侧实体的关联不受影响
+	var row testUserRoleDO
+	if err := db.Where("user_id = ? AND role_id = ?", 1, 3).First(&row).Error; err != nil || row.ID != kept.ID {
+		t.Errorf("关联 (1, 3) 的行 = %+v, %v，应保留原来的行 %d", row, err, kept.ID)
+	}
+	if ids, err := userRoles.ListRightIDs(ctx, 2); err != nil || !reflect.DeepEqual(ids, []int64{1}) {
+		t.Errorf("ListRightIDs(2) = %v, %v, want [1]", ids, err)
+	}
+
+	if err := userRoles.ReplaceRights(ctx, 1, nil); err != nil {
+		t.Fatalf("ReplaceRights 失败: %v", err)
+	}
+	if ids, err := userRoles.ListRightIDs(ctx, 1); err != nil || len(ids) != 0 {
+		t.Errorf("ReplaceRights(nil) 后 ListRightIDs(1) = %v, %v，应解除所有关联", ids, err)
+	}
+}
+
+func TestReplaceRightsRollsBackOnInsertError(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t, &testUserRoleDO{})
+	userRoles := newTestUserRoles(db)
+
+	for _, roleID := range []int64{1, 2} {
+		if err := userRoles.Attach(ctx, 1, roleID); err != nil {
+			t.Fatalf("Attach 失败: %v", err)
+		}
+	}
+
+	// 删除多余关联之后的插入失败
+	errInsert := errors.New("插入失败")
+	err := db.Callback().Create().Before("gorm:create").Register("test:fail_create", func(tx *gorm.DB) {
+		tx.AddError(errInsert)
+	})
+	if err != nil {
+		t.Fatalf("注册回调失败: %v", err)
+	}
+
+	if err := userRoles.ReplaceRights(ctx, 1, []int64{3}); !errors.Is(err, errInsert) {
+		t.Fatalf("ReplaceRights() error = %v, want %v", err, errInsert)
+	}
+	if ids, err := userRoles.ListRightIDs(ctx, 1); err != nil || !reflect.DeepEqual(ids, []int64{1, 2}) {
+		t.Errorf("ListRightIDs() = %v, %v，插入失败后删除应一并回滚", ids, err)
+	}
+}
+
+func TestAttachConcurrent(t *testing.T) {
+	ctx := context.Background()
+	// 文件数据库允许多个连接并发写入，busy_timeout 使写锁冲突时等待而不是立即失败
+	dsn := filepath.Join(t.TempDir(), "links.db") + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("获取连接池失败: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	if err := db.AutoMigrate(&testUserRoleDO{}); err != nil {
+		t.Fatalf("建表失败: %v", err)
+	}
+	userRoles := newTestUserRoles(db)
+
+	// 8 个 goroutine 同时为同一组关联执行 Attach
+	var wg sync.WaitGroup
+	errs := make(chan error, 8*5)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for roleID := int64(1); roleID <= 5; roleID++ {
+				if err := userRoles.Attach(ctx, 1, roleID); err != nil {
+					errs <- fmt.Errorf("Attach(1, %d): %w", roleID, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if rows := linkRows(t, db); len(rows) != 5 {
+		t.Errorf("关联行数 = %d, want 5", len(rows))
+	}
+}
+
+// mysqlDialector 将 SQLite 方言报告为 mysql，用于检查 MySQL 下生成的语句
+type mysqlDialector struct {
+	gorm.Dialector
+}
+
+func (mysqlDialector) Name() string { return "mysql" }
+
+func TestInsertLinksMySQL(t *testing.T) {
+	db, err := gorm.Open(mysqlDialector{sqlite.Open(":memory:")}, &gorm.Config{Logger: logger.Discard, DryRun: true})
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	var statements []string
+	var vars [][]interface{}
+	err = db.Callback().Raw().Before("gorm:raw").Register("test:capture", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+		vars = append(vars, tx.Statement.Vars)
+	})
+	if err != nil {
+		t.Fatalf("注册回调失败: %v", err)
+	}
+
+	if err := newTestUserRoles(db).Attach(context.Background(), 1, 10); err != nil {
+		t.Fatalf("Attach 失败: %v", err)
+	}
+
+	want := "INSERT INTO `test_user_roles` (`user_id`, `role_id`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `user_id` = `user_id`"
+	if len(statements) != 1 || statements[0] != want {
+		t.Fatalf("执行的语句 = %q, want %q", statements, want)
+	}
+	if !reflect.DeepEqual(vars[0], []interface{}{int64(1), int64(10)}) {
+		t.Errorf("参数 = %v, want [1 10]", vars[0])
+	}
+
+	sql, args := newTestUserRoles(db).mysqlInsertLinks(1, []int64{10, 20})
+	if want := "INSERT INTO `test_user_roles` (`user_id`, `role_id`) VALUES (?, ?), (?, ?) ON DUPLICATE KEY UPDATE `user_id` = `user_id`"; sql != want {
+		t.Errorf("mysqlInsertLinks() = %q, want %q", sql, want)
+	}
+	if !reflect.DeepEqual(args, []interface{}{int64(1), int64(10), int64(1), int64(20)}) {
+		t.Errorf("mysqlInsertLinks() 参数 = %v", args)
+	}
+}
@@ -0,0 +1,100 @@
+package framework
+
+import (
+	"slices"
+	"sync"
+)
+
+// MemoryLinks 多对多关联表的内存实现，保存左右两侧 ID 的关联，供生成的内存仓储实现关联管理方法
+//
+// 关联两侧的内存仓储共享同一个实例时，一侧建立的关联在另一侧可见：
+//
+//	users, roles := memory.NewUserRepository(), memory.NewRoleRepository()
+//	roles.RoleUserLinks = users.RoleUserLinks
+//
+// 与 ManyToManyRepository 一致，建立和解除关联都是幂等的，查询的 ID 按升序返回。
+type MemoryLinks struct {
+	mu    sync.Mutex
+	links map[[2]int64]struct{} // (左侧 ID, 右侧 ID)
+}
+
+// NewMemoryLinks 创建空的内存关联表
+func NewMemoryLinks() *MemoryLinks {
+	return &MemoryLinks{links: make(map[[2]int64]struct{})}
+}
+
+// Attach 建立关联，关联已存在时不报错
+func (l *MemoryLinks) Attach(leftID, rightID int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.links[[2]int64{leftID, rightID}] = struct{}{}
+}
+
+// Detach 解除关联，关联不存在时不报错
+func (l *MemoryLinks) Detach(leftID, rightID int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.links, [2]int64{leftID, rightID})
+}
+
+// RightIDs 查询左侧实体关联的所有右侧 ID（升序）
+func (l *MemoryLinks) RightIDs(leftID int64) []int64 {
+	return l.ids(0, leftID)
+}
+
+// LeftIDs 查询右侧实体关联的所有左侧 ID（升序）
+func (l *MemoryLinks) LeftIDs(rightID int64) []int64 {
+	return l.ids(1, rightID)
+}
+
+// ReplaceRights 将左侧实体的关联替换为 rightIDs，rightIDs 为空表示解除所有关联
+func (l *MemoryLinks) ReplaceRights(leftID int64, rightIDs []int64) {
+	l.replace(0, leftID, rightIDs)
+}
+
+// ReplaceLefts 将右侧实体的关联替换为 leftIDs，leftIDs 为空表示解除所有关联
+func (l *MemoryLinks) ReplaceLefts(rightID int64, leftIDs []int64) {
+	l.replace(1, rightID, leftIDs)
+}
+
+// ids 返回 side 侧（0 为左侧，1 为右侧）ID 为 id 的关联中另一侧的 ID（升序）
+func (l *MemoryLinks) ids(side int, id int64) []int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var result []int64
+	for link := range l.links {
+		if link[side] == id {
+			result = append(result, link[1-side])
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+// replace 删除 side 侧 ID 为 id 的全部关联，再与 others 逐个建立关联
+func (l *MemoryLinks) replace(side int, id int64, others []int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for link := range l.links {
+		if link[side] == id {
+			delete(l.links, link)
+		}
+	}
+	for _, other := range others {
+		var link [2]int64
+		link[side], link[1-side] = id, other
+		l.links[link] = struct{}{}
+	}
+}
+
+// PageLinked 按 ids 的顺序分页返回 linked 中的实体，page 从 1 开始，返回当前页和总数
+// 不在 linked 中（不存在或已删除）的 ID 被跳过，用于内存仓储的多对多关联分页查询
+func PageLinked[T any](ids []int64, linked map[int64]T, page, pageSize int) ([]T, int64) {
+	entities := make([]T, 0, len(linked))
+	for _, id := range ids {
+		if entity, ok := linked[id]; ok {
+			entities = append(entities, entity)
+		}
+	}
+	return pageOf(entities, page, pageSize), int64(len(entities))
+}
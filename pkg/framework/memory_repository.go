@@ -0,0 +1,525 @@
+package framework
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryModel 内存仓储读写实体软删除时间、版本号和唯一约束的方式
+//
+// 生成的内存仓储按聚合根的字段提供，为 nil 的函数表示实体没有对应字段：
+//
+//	framework.NewMemoryRepositoryOf[*model.Order, int64](framework.MemoryModel[*model.Order]{
+//	    DeletedAt:    func(e *model.Order) *time.Time { return e.DeletedAt },
+//	    SetDeletedAt: func(e *model.Order, t *time.Time) { e.DeletedAt = t },
+//	    Version:      func(e *model.Order) int64 { return int64(e.Version) },
+//	    SetVersion:   func(e *model.Order, v int64) { e.Version = int(v) },
+//	    Uniques: []framework.MemoryUnique[*model.Order]{
+//	        {Field: "OrderNo", Key: func(e *model.Order) []any { return []any{e.OrderNo} }},
+//	    },
+//	})
+type MemoryModel[T any] struct {
+	DeletedAt    func(entity T) *time.Time            // 软删除时间，未删除时返回 nil
+	SetDeletedAt func(entity T, deletedAt *time.Time) // 设置软删除时间，nil 表示恢复
+	Version      func(entity T) int64                 // 乐观锁版本号
+	SetVersion   func(entity T, version int64)        // 设置乐观锁版本号
+	Uniques      []MemoryUnique[T]                    // 唯一约束（+soliton:unique、+soliton:uniqueIndex）
+}
+
+// MemoryUnique 内存仓储检查的唯一约束
+type MemoryUnique[T any] struct {
+	Field string               // 约束的字段名，组合唯一索引为逗号分隔的各字段名，用于错误信息
+	Key   func(entity T) []any // 约束的各字段值
+}
+
+// MemoryRepositoryOf 基于 map 的内存仓储，实现 RepositoryOf[T, K]，用于不连接数据库的服务层测试
+//
+// 与 BaseRepositoryOf 的行为保持一致：
+//   - Add 分配主键（IDEnsurer、string 主键生成 UUID、整数主键自增，复合主键由调用方设置）并填充审计信息
+//   - Update 要求记录存在且未删除，有版本号时校验乐观锁（不一致返回 ErrVersionConflict）并加 1
+//   - Delete 系列硬删除，Remove 系列软删除（没有软删除字段时退化为硬删除），查询默认跳过已软删除的记录
+//   - 新增、更新和恢复时检查唯一约束，与未删除的其他记录冲突时返回 ErrEntityAlreadyExists 类别的 FieldError；
+//     约束字段包含 nil 指针时不检查，与数据库中 NULL 互不冲突一致
+//   - AddBatch、UpdateBatch 整体成功或整体失败
+//
+// 仓储保存和返回的都是实体的浅拷贝，修改返回值不会影响已保存的记录，切片、map 等字段仍共享底层数据。
+// Update 以实体整体替换已保存的记录。列表按插入顺序返回；不执行钩子和级联规则。
+type MemoryRepositoryOf[T EntityOf[K], K comparable] struct {
+	mu      sync.Mutex
+	model   MemoryModel[T]
+	records map[K]T // 主键 → 已保存的实体
+	keys    []K     // 按插入顺序排列的主键
+	nextID  int64   // 整数主键的最大值，用于自增
+}
+
+// NewMemoryRepositoryOf 创建内存仓储
+func NewMemoryRepositoryOf[T EntityOf[K], K comparable](model MemoryModel[T]) *MemoryRepositoryOf[T, K] {
+	return &MemoryRepositoryOf[T, K]{
+		model:   model,
+		records: make(map[K]T),
+	}
+}
+
+// Add 添加实体，主键已存在或违反唯一约束时返回错误
+func (r *MemoryRepositoryOf[T, K]) Add(ctx context.Context, entity T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.add(ctx, entity)
+}
+
+// add 添加实体，调用方持有锁
+func (r *MemoryRepositoryOf[T, K]) add(ctx context.Context, entity T) error {
+	r.assignID(entity)
+	applyAudit(ctx, entity, true)
+	if r.model.Version != nil && r.model.Version(entity) == 0 {
+		r.model.SetVersion(entity, 1)
+	}
+
+	id := entity.GetID()
+	if _, ok := r.records[id]; ok {
+		return NewDuplicateError("ID", "主键已存在: %v", id)
+	}
+	if err := r.checkUnique(entity); err != nil {
+		return err
+	}
+
+	r.records[id] = clone(entity)
+	r.keys = append(r.keys, id)
+	return nil
+}
+
+// assignID 为新实体分配主键，规则与 BaseRepositoryOf 一致，整数主键按已有的最大值自增
+func (r *MemoryRepositoryOf[T, K]) assignID(entity T) {
+	if ensurer, ok := any(entity).(IDEnsurer); ok {
+		ensurer.EnsureID()
+	}
+
+	id := reflect.ValueOf(entity.GetID())
+	if !id.CanInt() {
+		if e, ok := any(entity).(EntityOf[string]); ok && e.IsNew() {
+			e.SetID(NewUUID())
+		}
+		return
+	}
+
+	if entity.IsNew() {
+		r.nextID++
+		next := reflect.New(id.Type()).Elem()
+		next.SetInt(r.nextID)
+		entity.SetID(next.Interface().(K))
+	} else if id.Int() > r.nextID {
+		r.nextID = id.Int()
+	}
+}
+
+// checkUnique 检查实体是否与其他未删除的记录违反唯一约束
+func (r *MemoryRepositoryOf[T, K]) checkUnique(entity T) error {
+	for _, unique := range r.model.Uniques {
+		key := unique.Key(entity)
+		if slices.ContainsFunc(key, isNilValue) {
+			continue
+		}
+		for id, record := range r.records {
+			if id == entity.GetID() || r.deleted(record) {
+				continue
+			}
+			if reflect.DeepEqual(unique.Key(record), key) {
+				values := make([]string, len(key))
+				for i, value := range key {
+					values[i] = fmt.Sprint(reflect.Indirect(reflect.ValueOf(value)))
+				}
+				return NewDuplicateError(unique.Field, "%s 已存在: %s", unique.Field, strings.Join(values, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// isNilValue 判断值是否为 nil 或 nil 指针
+func isNilValue(value any) bool {
+	v := reflect.ValueOf(value)
+	return !v.IsValid() || v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// clone 返回实体的浅拷贝
+func clone[T any](entity T) T {
+	v := reflect.ValueOf(entity)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return entity
+	}
+	copied := reflect.New(v.Elem().Type())
+	copied.Elem().Set(v.Elem())
+	return copied.Interface().(T)
+}
+
+// deleted 判断已保存的记录是否已软删除
+func (r *MemoryRepositoryOf[T, K]) deleted(record T) bool {
+	return r.model.DeletedAt != nil && r.model.DeletedAt(record) != nil
+}
+
+// live 返回未删除的记录
+func (r *MemoryRepositoryOf[T, K]) live(id K) (T, bool) {
+	record, ok := r.records[id]
+	if !ok || r.deleted(record) {
+		var zero T
+		return zero, false
+	}
+	return record, true
+}
+
+// atomic 执行批量操作，失败时恢复执行前的全部记录
+func (r *MemoryRepositoryOf[T, K]) atomic(fn func() error) error {
+	records, keys, nextID := make(map[K]T, len(r.records)), slices.Clone(r.keys), r.nextID
+	for id, record := range r.records {
+		records[id] = clone(record)
+	}
+	if err := fn(); err != nil {
+		r.records, r.keys, r.nextID = records, keys, nextID
+		return err
+	}
+	return nil
+}
+
+// AddBatch 批量添加实体，任一实体失败时不添加任何实体；内存中没有批次，忽略 batchSize
+func (r *MemoryRepositoryOf[T, K]) AddBatch(ctx context.Context, entities []T, batchSize int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.atomic(func() error {
+		for _, entity := range entities {
+			if err := r.add(ctx, entity); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Update 更新实体（支持乐观锁），记录不存在或已删除时返回 ErrRecordNotFound
+func (r *MemoryRepositoryOf[T, K]) Update(ctx context.Context, entity T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.update(ctx, entity, true)
+}
+
+// update 更新实体，调用方持有锁；optimistic 为 false 时不校验版本号（与 BaseRepositoryOf.UpdateBatch 一致）
+func (r *MemoryRepositoryOf[T, K]) update(ctx context.Context, entity T, optimistic bool) error {
+	applyAudit(ctx, entity, false)
+
+	record, ok := r.live(entity.GetID())
+	if !ok {
+		return ErrRecordNotFound
+	}
+	if r.model.Version != nil {
+		if optimistic && r.model.Version(record) != r.model.Version(entity) {
+			return ErrVersionConflict
+		}
+		r.model.SetVersion(entity, r.model.Version(record)+1)
+	}
+	if err := r.checkUnique(entity); err != nil {
+		return err
+	}
+
+	r.records[entity.GetID()] = clone(entity)
+	return nil
+}
+
+// UpdateBatch 批量更新实体，任一实体失败时不更新任何实体；与 BaseRepositoryOf 一致，不校验乐观锁
+func (r *MemoryRepositoryOf[T, K]) UpdateBatch(ctx context.Context, entities []T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.atomic(func() error {
+		for _, entity := range entities {
+			if err := r.update(ctx, entity, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete 硬删除实体，记录不存在时返回 ErrRecordNotFound
+func (r *MemoryRepositoryOf[T, K]) Delete(ctx context.Context, id K) error {
+	affected, err := r.DeleteByIDs(ctx, []K{id})
+	if err == nil && affected == 0 {
+		return ErrRecordNotFound
+	}
+	return err
+}
+
+// DeleteBatch 批量硬删除实体
+func (r *MemoryRepositoryOf[T, K]) DeleteBatch(ctx context.Context, ids []K) error {
+	_, err := r.DeleteByIDs(ctx, ids)
+	return err
+}
+
+// DeleteByIDs 批量硬删除实体（包括已软删除的记录），返回实际删除的记录数
+func (r *MemoryRepositoryOf[T, K]) DeleteByIDs(ctx context.Context, ids []K) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.delete(func(id K, record T) bool { return slices.Contains(ids, id) }), nil
+}
+
+// delete 硬删除 match 匹配的记录，调用方持有锁，返回删除的记录数
+func (r *MemoryRepositoryOf[T, K]) delete(match func(id K, record T) bool) int64 {
+	var affected int64
+	r.keys = slices.DeleteFunc(r.keys, func(id K) bool {
+		if !match(id, r.records[id]) {
+			return false
+		}
+		delete(r.records, id)
+		affected++
+		return true
+	})
+	return affected
+}
+
+// Remove 软删除实体，记录不存在或已删除时返回 ErrRecordNotFound
+func (r *MemoryRepositoryOf[T, K]) Remove(ctx context.Context, id K) error {
+	affected, err := r.RemoveByIDs(ctx, []K{id})
+	if err == nil && affected == 0 {
+		return ErrRecordNotFound
+	}
+	return err
+}
+
+// RemoveBatch 批量软删除实体
+func (r *MemoryRepositoryOf[T, K]) RemoveBatch(ctx context.Context, ids []K) error {
+	_, err := r.RemoveByIDs(ctx, ids)
+	return err
+}
+
+// RemoveByIDs 批量软删除实体，已删除的记录不重复计数；没有软删除字段时退化为硬删除
+func (r *MemoryRepositoryOf[T, K]) RemoveByIDs(ctx context.Context, ids []K) (int64, error) {
+	if r.model.DeletedAt == nil {
+		return r.DeleteByIDs(ctx, ids)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var affected int64
+	now := time.Now()
+	for _, id := range ids {
+		if record, ok := r.live(id); ok {
+			r.model.SetDeletedAt(record, &now)
+			affected++
+		}
+	}
+	return affected, nil
+}
+
+// Restore 恢复已软删除的实体，刷新更新时间，有版本号时加 1；与未删除的记录违反唯一约束时返回错误
+// 记录不存在或未被删除时返回 ErrRecordNotFound；没有软删除字段时返回 ErrSoftDeleteNotSupported
+func (r *MemoryRepositoryOf[T, K]) Restore(ctx context.Context, id K) error {
+	if r.model.DeletedAt == nil {
+		return ErrSoftDeleteNotSupported
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, ok := r.records[id]
+	if !ok || !r.deleted(record) {
+		return ErrRecordNotFound
+	}
+	if err := r.checkUnique(record); err != nil {
+		return err
+	}
+	r.model.SetDeletedAt(record, nil)
+	if setter, ok := any(record).(auditInfoSetter); ok {
+		setter.SetAuditInfo(false)
+	}
+	if r.model.Version != nil {
+		r.model.SetVersion(record, r.model.Version(record)+1)
+	}
+	return nil
+}
+
+// FindByID 根据 ID 查询实体，不存在或已删除时返回 ErrRecordNotFound
+func (r *MemoryRepositoryOf[T, K]) FindByID(ctx context.Context, id K) (T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, ok := r.live(id)
+	if !ok {
+		var zero T
+		return zero, ErrRecordNotFound
+	}
+	return clone(record), nil
+}
+
+// FindByIDs 批量根据 ID 查询实体，不存在或已删除的 ID 不在结果中
+func (r *MemoryRepositoryOf[T, K]) FindByIDs(ctx context.Context, ids []K) (map[K]T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make(map[K]T, len(ids))
+	for _, id := range ids {
+		if record, ok := r.live(id); ok {
+			result[id] = clone(record)
+		}
+	}
+	return result, nil
+}
+
+// FindByIDWithDeleted 根据 ID 查询实体（包含已删除）
+func (r *MemoryRepositoryOf[T, K]) FindByIDWithDeleted(ctx context.Context, id K) (T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, ok := r.records[id]
+	if !ok {
+		var zero T
+		return zero, ErrRecordNotFound
+	}
+	return clone(record), nil
+}
+
+// FindAllDeleted 查询所有已软删除的实体
+func (r *MemoryRepositoryOf[T, K]) FindAllDeleted(ctx context.Context) ([]T, error) {
+	if r.model.DeletedAt == nil {
+		return nil, ErrSoftDeleteNotSupported
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.collect(r.deleted), nil
+}
+
+// FindPageDeleted 分页查询已软删除的实体，返回当前页和已删除记录总数
+func (r *MemoryRepositoryOf[T, K]) FindPageDeleted(ctx context.Context, page, pageSize int) ([]T, int64, error) {
+	deleted, err := r.FindAllDeleted(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return pageOf(deleted, page, pageSize), int64(len(deleted)), nil
+}
+
+// PurgeDeleted 硬删除软删除时间早于 olderThan 之前的记录，返回删除的记录数
+func (r *MemoryRepositoryOf[T, K]) PurgeDeleted(ctx context.Context, olderThan time.Duration) (int64, error) {
+	if r.model.DeletedAt == nil {
+		return 0, ErrSoftDeleteNotSupported
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cutoff := time.Now().Add(-olderThan)
+	return r.delete(func(id K, record T) bool {
+		deletedAt := r.model.DeletedAt(record)
+		return deletedAt != nil && !deletedAt.After(cutoff)
+	}), nil
+}
+
+// FindAll 查询所有未删除的实体
+func (r *MemoryRepositoryOf[T, K]) FindAll(ctx context.Context) ([]T, error) {
+	return r.FindWhere(ctx, func(T) bool { return true })
+}
+
+// FindPage 分页查询未删除的实体，page 从 1 开始，返回当前页和总数
+func (r *MemoryRepositoryOf[T, K]) FindPage(ctx context.Context, page, pageSize int) ([]T, int64, error) {
+	return r.FindPageWhere(ctx, func(T) bool { return true }, page, pageSize)
+}
+
+// Exists 检查未删除的实体是否存在
+func (r *MemoryRepositoryOf[T, K]) Exists(ctx context.Context, id K) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, ok := r.live(id)
+	return ok, nil
+}
+
+// FindWhere 按插入顺序返回满足 match 的未删除实体，供生成的内存仓储实现扩展方法
+func (r *MemoryRepositoryOf[T, K]) FindWhere(ctx context.Context, match func(entity T) bool) ([]T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.collect(func(record T) bool { return !r.deleted(record) && match(record) }), nil
+}
+
+// FindFirstWhere 返回第一个满足 match 的未删除实体，不存在时返回 ErrRecordNotFound
+func (r *MemoryRepositoryOf[T, K]) FindFirstWhere(ctx context.Context, match func(entity T) bool) (T, error) {
+	entities, err := r.FindWhere(ctx, match)
+	if err != nil || len(entities) == 0 {
+		var zero T
+		if err == nil {
+			err = ErrRecordNotFound
+		}
+		return zero, err
+	}
+	return entities[0], nil
+}
+
+// FindPageWhere 分页返回满足 match 的未删除实体，page 从 1 开始，返回当前页和总数
+func (r *MemoryRepositoryOf[T, K]) FindPageWhere(ctx context.Context, match func(entity T) bool, page, pageSize int) ([]T, int64, error) {
+	entities, err := r.FindWhere(ctx, match)
+	if err != nil {
+		return nil, 0, err
+	}
+	return pageOf(entities, page, pageSize), int64(len(entities)), nil
+}
+
+// FindPageSorted 分页返回满足 match 的未删除实体，page 从 1 开始，返回当前页和总数
+// 实体依次按 compares 排序，前面的比较结果相同时才使用后面的比较，全部相同时保持插入顺序
+func (r *MemoryRepositoryOf[T, K]) FindPageSorted(ctx context.Context, match func(entity T) bool, compares []func(a, b T) int, page, pageSize int) ([]T, int64, error) {
+	entities, err := r.FindWhere(ctx, match)
+	if err != nil {
+		return nil, 0, err
+	}
+	slices.SortStableFunc(entities, func(a, b T) int {
+		for _, compare := range compares {
+			if c := compare(a, b); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	return pageOf(entities, page, pageSize), int64(len(entities)), nil
+}
+
+// CompareNullable 以 compare 比较可为 nil 的值，nil 小于任何非 nil 的值，与 MySQL、SQLite 升序排列时 NULL 在前一致
+func CompareNullable[V any](a, b *V, compare func(a, b V) int) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	default:
+		return compare(*a, *b)
+	}
+}
+
+// collect 按插入顺序返回满足 match 的记录的拷贝，调用方持有锁
+func (r *MemoryRepositoryOf[T, K]) collect(match func(record T) bool) []T {
+	var result []T
+	for _, id := range r.keys {
+		if record := r.records[id]; match(record) {
+			result = append(result, clone(record))
+		}
+	}
+	return result
+}
+
+// pageOf 返回第 page 页（从 1 开始），与数据库的 OFFSET/LIMIT 一致：页码小于 1 时从头开始，pageSize 为负数时不限制条数
+func pageOf[T any](items []T, page, pageSize int) []T {
+	offset := min(max((page-1)*pageSize, 0), len(items))
+	items = items[offset:]
+	if pageSize >= 0 && pageSize < len(items) {
+		items = items[:pageSize]
+	}
+	return items
+}
@@ -0,0 +1,176 @@
+package framework
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OutboxTableName 事务性 outbox 表名，表结构由 soliton -outbox 生成的 create_outbox 迁移创建
+const OutboxTableName = "outbox_messages"
+
+// OutboxMessage outbox 表中的一条领域事件
+//
+// 仓储在写入聚合根的同一事务中插入（见 BaseRepositoryOf.SetOutbox），由 OutboxRelay 发布到消息队列。
+type OutboxMessage struct {
+	ID          int64      `gorm:"column:id;primaryKey;autoIncrement"`
+	Topic       string     `gorm:"column:topic"`        // 消息主题，即 DomainEvent.Topic()
+	EventName   string     `gorm:"column:event_name"`   // 事件名，即 DomainEvent.EventName()
+	AggregateID string     `gorm:"column:aggregate_id"` // 聚合根 ID，复合主键为各主键值以 : 连接
+	Payload     string     `gorm:"column:payload"`      // 事件的 JSON 编码
+	Attempts    int        `gorm:"column:attempts"`     // 发布失败的次数
+	LastError   *string    `gorm:"column:last_error"`   // 最近一次发布失败的原因
+	CreatedAt   time.Time  `gorm:"column:created_at"`
+	PublishedAt *time.Time `gorm:"column:published_at"` // 发布成功的时间，为空表示待发布
+}
+
+// TableName 实现 GORM 的 Tabler
+func (OutboxMessage) TableName() string {
+	return OutboxTableName
+}
+
+// saveOutbox 在 db（写入聚合根的事务）中将领域事件插入 outbox 表
+func saveOutbox(db *gorm.DB, aggregateID string, events []DomainEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	messages := make([]*OutboxMessage, len(events))
+	now := time.Now()
+	for i, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("序列化领域事件 %s 失败: %w", event.EventName(), err)
+		}
+		messages[i] = &OutboxMessage{
+			Topic:       event.Topic(),
+			EventName:   event.EventName(),
+			AggregateID: aggregateID,
+			Payload:     string(payload),
+			CreatedAt:   now,
+		}
+	}
+	if err := db.Create(messages).Error; err != nil {
+		return fmt.Errorf("写入 outbox 失败: %w", err)
+	}
+	return nil
+}
+
+// outboxKey 返回聚合根 ID 在 outbox 消息中的文本形式
+func outboxKey(id any) string {
+	if key, ok := id.(CompositeKey); ok {
+		values := make([]string, len(key.KeyValues()))
+		for i, value := range key.KeyValues() {
+			values[i] = fmt.Sprint(value)
+		}
+		return strings.Join(values, ":")
+	}
+	return fmt.Sprint(id)
+}
+
+// OutboxPublisher 将 outbox 消息发布到消息队列（Kafka、NATS 等）
+// 返回 nil 表示消息已被消息队列确认接收
+type OutboxPublisher interface {
+	Publish(ctx context.Context, message *OutboxMessage) error
+}
+
+// OutboxRelay outbox 中继，按写入顺序将待发布的消息发布到消息队列
+//
+// 消息在发布成功后才标记为已发布，进程在两步之间退出时会重复发布（至少一次），
+// 消费者应按消息 ID 或事件内容去重。某条消息发布失败时记录失败次数和原因，
+// 本轮停止发布后续消息，保证同一 outbox 中的消息不乱序；下一轮从失败的消息重试。
+//
+//	relay := framework.NewOutboxRelay(db, publisher)
+//	go relay.Run(ctx)
+//
+// 多个实例同时运行时同一条消息可能被各实例各发布一次，通常每个数据库只运行一个中继。
+type OutboxRelay struct {
+	db        *gorm.DB
+	publisher OutboxPublisher
+	batchSize int
+	interval  time.Duration
+	onError   func(error)
+}
+
+// NewOutboxRelay 创建 outbox 中继，默认每轮最多发布 100 条消息，没有待发布消息时每秒检查一次
+func NewOutboxRelay(db *gorm.DB, publisher OutboxPublisher) *OutboxRelay {
+	return &OutboxRelay{
+		db:        db,
+		publisher: publisher,
+		batchSize: 100,
+		interval:  time.Second,
+		onError: func(err error) {
+			log.Printf("outbox 中继: %v", err)
+		},
+	}
+}
+
+// SetBatchSize 设置每轮最多发布的消息数
+func (r *OutboxRelay) SetBatchSize(batchSize int) {
+	r.batchSize = batchSize
+}
+
+// SetInterval 设置没有待发布消息时的检查间隔
+func (r *OutboxRelay) SetInterval(interval time.Duration) {
+	r.interval = interval
+}
+
+// SetErrorHandler 设置 Run 中发布失败时的处理函数，默认写入标准日志
+func (r *OutboxRelay) SetErrorHandler(onError func(error)) {
+	r.onError = onError
+}
+
+// RelayOnce 发布一轮待发布的消息，返回发布成功的数量
+func (r *OutboxRelay) RelayOnce(ctx context.Context) (int, error) {
+	db := r.db.WithContext(ctx)
+
+	var messages []*OutboxMessage
+	if err := db.Where("published_at IS NULL").Order("id").Limit(r.batchSize).Find(&messages).Error; err != nil {
+		return 0, fmt.Errorf("查询待发布的 outbox 消息失败: %w", err)
+	}
+
+	for i, message := range messages {
+		if err := r.publisher.Publish(ctx, message); err != nil {
+			reason := err.Error()
+			db.Model(&OutboxMessage{}).Where("id = ?", message.ID).Updates(map[string]any{
+				"attempts":   gorm.Expr("attempts + 1"),
+				"last_error": reason,
+			})
+			return i, fmt.Errorf("发布 outbox 消息 %d（%s）失败: %w", message.ID, message.EventName, err)
+		}
+
+		if err := db.Model(&OutboxMessage{}).Where("id = ?", message.ID).Update("published_at", time.Now()).Error; err != nil {
+			return i, fmt.Errorf("标记 outbox 消息 %d 已发布失败: %w", message.ID, err)
+		}
+	}
+	return len(messages), nil
+}
+
+// Run 持续发布待发布的消息，直到 ctx 取消，返回 ctx.Err()
+// 一轮发布满 batchSize 条时立即开始下一轮，否则等待检查间隔；发布失败交给 SetErrorHandler 设置的函数处理后继续
+func (r *OutboxRelay) Run(ctx context.Context) error {
+	for {
+		published, err := r.RelayOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.onError(err)
+		}
+
+		if err == nil && published == r.batchSize {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.interval):
+		}
+	}
+}
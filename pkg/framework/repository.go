@@ -1,10 +1,16 @@
 package framework
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
-// Repository 泛型仓储接口
+// RepositoryOf 泛型仓储接口
+//
+// 泛型参数：
+//   - T: 聚合根类型，约束为 EntityOf[K]，确保类型安全
+//   - K: 主键类型，如 int64、string（UUID）
 //
-// 泛型参数 T 约束为 Entity，确保类型安全。
 // 所有具体的聚合根仓储接口都应该继承此接口。
 //
 // 优势：
@@ -25,7 +31,7 @@ import "context"
 //	// 使用时类型自动推导
 //	var repo OrderRepository
 //	order, err := repo.FindByID(ctx, 123)  // 返回 *Order，不是 interface{}
-type Repository[T Entity] interface {
+type RepositoryOf[T EntityOf[K], K comparable] interface {
 	// Add 添加实体
 	// 会自动回填生成的 ID 到 entity
 	Add(ctx context.Context, entity T) error
@@ -33,6 +39,7 @@ type Repository[T Entity] interface {
 	// AddBatch 批量添加实体
 	// 会自动回填生成的 ID 到每个 entity
 	// batchSize 为每批次插入的数量，0 表示一次性插入所有
+	// 所有批次在同一个事务中执行，任一批次失败整体回滚
 	AddBatch(ctx context.Context, entities []T, batchSize int) error
 
 	// Update 更新实体
@@ -45,28 +52,53 @@ type Repository[T Entity] interface {
 
 	// Delete 删除实体（硬删除）
 	// 如果实体有 DeletedAt 字段，应使用 Remove 方法（软删除）
-	Delete(ctx context.Context, id int64) error
+	Delete(ctx context.Context, id K) error
 
 	// DeleteBatch 批量硬删除实体
-	DeleteBatch(ctx context.Context, ids []int64) error
+	DeleteBatch(ctx context.Context, ids []K) error
+
+	// DeleteByIDs 批量硬删除实体
+	// 返回实际删除的行数
+	DeleteByIDs(ctx context.Context, ids []K) (int64, error)
 
 	// Remove 软删除实体（仅当实体有 DeletedAt 字段时生成）
 	// 设置 DeletedAt 为当前时间，不实际删除记录
-	Remove(ctx context.Context, id int64) error
+	Remove(ctx context.Context, id K) error
 
 	// RemoveBatch 批量软删除实体
-	RemoveBatch(ctx context.Context, ids []int64) error
+	RemoveBatch(ctx context.Context, ids []K) error
+
+	// RemoveByIDs 批量软删除实体
+	// 没有 DeletedAt 字段时退化为硬删除，返回实际影响的行数
+	RemoveByIDs(ctx context.Context, ids []K) (int64, error)
 
 	// FindByID 根据 ID 查询实体
 	// 自动过滤已软删除的记录（如果有 DeletedAt 字段）
-	FindByID(ctx context.Context, id int64) (T, error)
+	FindByID(ctx context.Context, id K) (T, error)
 
 	// FindByIDs 批量根据 ID 查询实体
-	FindByIDs(ctx context.Context, ids []int64) ([]T, error)
+	// 返回 ID → 实体 的映射，不存在的 ID 不会出现在结果中
+	FindByIDs(ctx context.Context, ids []K) (map[K]T, error)
 
 	// FindByIDWithDeleted 根据 ID 查询实体（包含已删除）
 	// 仅当实体有 DeletedAt 字段时生成
-	FindByIDWithDeleted(ctx context.Context, id int64) (T, error)
+	FindByIDWithDeleted(ctx context.Context, id K) (T, error)
+
+	// Restore 恢复已软删除的实体
+	// 清空 DeletedAt，刷新 UpdatedAt 并递增 Version
+	// 记录不存在或未被删除时返回 ErrRecordNotFound；没有 DeletedAt 字段时返回 ErrSoftDeleteNotSupported
+	Restore(ctx context.Context, id K) error
+
+	// FindAllDeleted 查询所有已软删除的实体
+	FindAllDeleted(ctx context.Context) ([]T, error)
+
+	// FindPageDeleted 分页查询已软删除的实体
+	// 返回：实体列表、总数、错误
+	FindPageDeleted(ctx context.Context, page, pageSize int) ([]T, int64, error)
+
+	// PurgeDeleted 物理删除软删除时间早于 olderThan 之前的记录
+	// 返回实际删除的行数
+	PurgeDeleted(ctx context.Context, olderThan time.Duration) (int64, error)
 
 	// FindAll 查询所有实体
 	// 自动过滤已软删除的记录
@@ -77,5 +109,12 @@ type Repository[T Entity] interface {
 	FindPage(ctx context.Context, page, pageSize int) ([]T, int64, error)
 
 	// Exists 检查实体是否存在
-	Exists(ctx context.Context, id int64) (bool, error)
+	Exists(ctx context.Context, id K) (bool, error)
+}
+
+// Repository int64 主键的泛型仓储接口
+//
+// 等价于 RepositoryOf[T, int64]，所有具体的聚合根仓储接口都应该继承此接口。
+type Repository[T Entity] interface {
+	RepositoryOf[T, int64]
 }
@@ -0,0 +1,187 @@
+package framework
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// SensitiveStrategy 敏感字段处理策略，对应 +soliton:sensitive(strategy=...) 注解
+type SensitiveStrategy string
+
+const (
+	SensitiveAES  SensitiveStrategy = "aes"  // 写入前加密，读取后解密
+	SensitiveMask SensitiveStrategy = "mask" // 写入前脱敏，数据库只保存脱敏后的值，无法还原
+)
+
+// SensitiveTag 生成的 DO 中标记敏感字段的结构体标签，如 `sensitive:"aes"`
+const SensitiveTag = "sensitive"
+
+// ErrEncryptionCodecRequired 数据对象包含敏感字段，但仓储未设置编解码器
+var ErrEncryptionCodecRequired = errors.New("数据对象包含敏感字段，需要先通过 SetEncryptionCodec 设置编解码器")
+
+// EncryptionCodec 敏感字段编解码器
+//
+// 通过 BaseRepositoryOf.SetEncryptionCodec 注册后，仓储在写入前对 DO 中标记了
+// `sensitive` 标签的字段调用 Encode，读取后调用 Decode：
+//
+//	codec, err := framework.NewAESCodec(key)
+//	if err != nil {
+//	    return err
+//	}
+//	repo.SetEncryptionCodec(codec)
+//
+// 空字符串不会经过编解码器，与列的默认值（空字符串）保持一致。
+type EncryptionCodec interface {
+	// Encode 写入数据库前转换字段值
+	Encode(strategy SensitiveStrategy, value string) (string, error)
+	// Decode 从数据库读取后转换字段值
+	Decode(strategy SensitiveStrategy, value string) (string, error)
+}
+
+// AESCodec 基于 AES-GCM 的编解码器
+//
+// aes 策略加密后以 base64 保存（随机 nonce，相同明文每次得到不同密文，因此不能按密文查询）；
+// mask 策略写入时使用 MaskString 脱敏，读取时原样返回。
+type AESCodec struct {
+	aead cipher.AEAD
+}
+
+// NewAESCodec 创建 AES-GCM 编解码器，key 长度必须为 16、24 或 32 字节
+func NewAESCodec(key []byte) (*AESCodec, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("创建 AES 密钥失败: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("创建 GCM 模式失败: %w", err)
+	}
+	return &AESCodec{aead: aead}, nil
+}
+
+// Encode 加密或脱敏字段值
+func (c *AESCodec) Encode(strategy SensitiveStrategy, value string) (string, error) {
+	switch strategy {
+	case SensitiveAES:
+		nonce := make([]byte, c.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", fmt.Errorf("生成 nonce 失败: %w", err)
+		}
+		sealed := c.aead.Seal(nonce, nonce, []byte(value), nil)
+		return base64.StdEncoding.EncodeToString(sealed), nil
+	case SensitiveMask:
+		return MaskString(value), nil
+	}
+	return "", fmt.Errorf("不支持的敏感字段策略: %s", strategy)
+}
+
+// Decode 解密字段值，脱敏字段原样返回
+func (c *AESCodec) Decode(strategy SensitiveStrategy, value string) (string, error) {
+	switch strategy {
+	case SensitiveAES:
+		sealed, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return "", fmt.Errorf("解码密文失败: %w", err)
+		}
+		nonceSize := c.aead.NonceSize()
+		if len(sealed) < nonceSize {
+			return "", errors.New("解密失败: 密文长度不足")
+		}
+		plaintext, err := c.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+		if err != nil {
+			return "", fmt.Errorf("解密失败: %w", err)
+		}
+		return string(plaintext), nil
+	case SensitiveMask:
+		return value, nil
+	}
+	return "", fmt.Errorf("不支持的敏感字段策略: %s", strategy)
+}
+
+// MaskString 脱敏字符串：保留首尾各约四分之一的字符，中间替换为 *
+//
+//	MaskString("13812345678") // "13*******78"
+//	MaskString("ab")          // "**"
+func MaskString(value string) string {
+	runes := []rune(value)
+	keep := len(runes) / 4
+	for i := keep; i < len(runes)-keep; i++ {
+		runes[i] = '*'
+	}
+	return string(runes)
+}
+
+// sensitiveField DO 中标记了 sensitive 标签的字段
+type sensitiveField struct {
+	index    []int
+	strategy SensitiveStrategy
+}
+
+// sensitiveFieldCache DO 类型 → 敏感字段列表
+var sensitiveFieldCache sync.Map
+
+// sensitiveFieldsOf 返回 DO 类型中标记了 sensitive 标签的 string / *string 字段
+func sensitiveFieldsOf(t reflect.Type) []sensitiveField {
+	if cached, ok := sensitiveFieldCache.Load(t); ok {
+		return cached.([]sensitiveField)
+	}
+
+	var fields []sensitiveField
+	for _, field := range reflect.VisibleFields(t) {
+		strategy, ok := field.Tag.Lookup(SensitiveTag)
+		if !ok || field.Anonymous {
+			continue
+		}
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() != reflect.String {
+			continue
+		}
+		fields = append(fields, sensitiveField{index: field.Index, strategy: SensitiveStrategy(strategy)})
+	}
+
+	sensitiveFieldCache.Store(t, fields)
+	return fields
+}
+
+// applyCodec 对 DO 中的敏感字段逐个执行 transform，nil 指针和空字符串保持不变
+//
+// 指针字段写入新分配的指针，避免修改与领域对象共享的字符串。
+func applyCodec(do any, transform func(strategy SensitiveStrategy, value string) (string, error)) error {
+	val := reflect.ValueOf(do).Elem()
+	for _, field := range sensitiveFieldsOf(val.Type()) {
+		target := val.FieldByIndex(field.index)
+		value := target
+		if target.Kind() == reflect.Ptr {
+			if target.IsNil() {
+				continue
+			}
+			value = target.Elem()
+		}
+		if value.String() == "" {
+			continue
+		}
+
+		converted, err := transform(field.strategy, value.String())
+		if err != nil {
+			return err
+		}
+
+		if target.Kind() == reflect.Ptr {
+			ptr := reflect.New(value.Type())
+			ptr.Elem().SetString(converted)
+			target.Set(ptr)
+		} else {
+			target.SetString(converted)
+		}
+	}
+	return nil
+}
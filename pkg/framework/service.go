@@ -2,14 +2,14 @@ package framework
 
 import "context"
 
-// Service 泛型领域服务接口
+// ServiceOf 泛型领域服务接口
 //
 // 领域服务负责封装基础业务规则和校验，位于领域层。
 // 与应用服务的区别：
 //   - 领域服务：封装基础校验（唯一性、必填、枚举等），依赖仓储接口
 //   - 应用服务：用例编排、权限控制、事务管理，依赖领域服务
 //
-// 泛型参数 T 约束为 Entity，确保类型安全。
+// 泛型参数 T 约束为 EntityOf[K]，K 为主键类型，确保类型安全。
 //
 // 示例：
 //
@@ -20,32 +20,57 @@ import "context"
 //	    // 扩展业务方法
 //	    PlaceOrder(ctx context.Context, order *Order) error
 //	}
-type Service[T Entity] interface {
+type ServiceOf[T EntityOf[K], K comparable] interface {
 	// Add 添加实体
 	// 执行基础校验：
 	//  - 必填字段校验（+soliton:required）
 	//  - 唯一性校验（+soliton:unique）
 	//  - 外键存在性校验（+soliton:ref）
 	//  - 枚举值校验（+soliton:enum）
+	//  - 字段规则校验（+soliton:validate、length、pattern、email）
+	// 未设置的可选字段（空值、nil）不做唯一性、外键和枚举校验
 	Add(ctx context.Context, entity T) error
 
+	// AddBatch 批量添加实体
+	// 所有实体在同一个事务中插入
+	AddBatch(ctx context.Context, entities []T, batchSize int) error
+
 	// Update 更新实体
-	// 执行基础校验（排除自己的唯一性校验）
+	// 执行与 Add 相同的校验，唯一性校验排除实体自身
 	Update(ctx context.Context, entity T) error
 
 	// Delete 删除实体
 	// 如果有 DeletedAt 字段，使用软删除
-	Delete(ctx context.Context, id int64) error
+	Delete(ctx context.Context, id K) error
+
+	// DeleteByIDs 批量删除实体
+	// 与 Delete 一致，有 DeletedAt 字段时使用软删除，返回实际删除的数量
+	DeleteByIDs(ctx context.Context, ids []K) (int64, error)
+
+	// Restore 恢复已删除的实体
+	Restore(ctx context.Context, id K) error
 
 	// GetByID 根据 ID 获取实体
-	GetByID(ctx context.Context, id int64) (T, error)
+	GetByID(ctx context.Context, id K) (T, error)
+
+	// GetByIDs 批量根据 ID 获取实体
+	// 返回 ID → 实体 的映射，不存在的 ID 不会出现在结果中
+	GetByIDs(ctx context.Context, ids []K) (map[K]T, error)
 
 	// GetAll 获取所有实体
 	GetAll(ctx context.Context) ([]T, error)
 
+	// GetAllDeleted 获取所有已删除的实体
+	GetAllDeleted(ctx context.Context) ([]T, error)
+
 	// GetPage 分页获取实体
 	GetPage(ctx context.Context, page, pageSize int) ([]T, int64, error)
 
 	// Exists 检查实体是否存在
-	Exists(ctx context.Context, id int64) (bool, error)
+	Exists(ctx context.Context, id K) (bool, error)
+}
+
+// Service int64 主键的泛型领域服务接口
+type Service[T Entity] interface {
+	ServiceOf[T, int64]
 }
@@ -0,0 +1,21 @@
+package framework
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// NewUUID 生成随机 UUID（RFC 4122 版本 4）
+//
+// 格式：xxxxxxxx-xxxx-4xxx-yxxx-xxxxxxxxxxxx，共 36 个字符
+func NewUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("生成 UUID 失败: %v", err))
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40 // 版本 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 变体
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,105 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"soliton/pkg/metadata"
+	"strconv"
+	"strings"
+)
+
+// ColumnGenerator 列名常量生成器
+//
+// 为每个聚合根生成一个只包含常量的包，如 ordercol.Table = "orders"、ordercol.OrderNo = "order_no"，
+// 手写的扩展查询（如 OrderRepositoryImpl_ext.go）引用这些常量代替字符串字面量，列名改变后重新生成即可在编译时发现失效的引用。
+//
+// 生成文件：infrastructure/columns/{aggregate}col/{AggregateName}Columns.go
+//
+// 表名和列名取自表结构元数据（见 metadata.Schema），与 SQL 建表脚本和 DO 的 GORM 标签一致。
+type ColumnGenerator struct {
+	fileOutput
+	schema *metadata.Schema
+}
+
+// NewColumnGenerator 创建列名常量生成器
+func NewColumnGenerator() *ColumnGenerator {
+	return &ColumnGenerator{}
+}
+
+// SetSchema 设置表结构元数据，未设置时按单个聚合根计算
+func (g *ColumnGenerator) SetSchema(schema *metadata.Schema) {
+	g.schema = schema
+}
+
+// table 返回聚合根对应的表
+func (g *ColumnGenerator) table(agg *metadata.AggregateMetadata) *metadata.TableMetadata {
+	if g.schema != nil {
+		if table := g.schema.Table(agg.Name); table != nil {
+			return table
+		}
+	}
+	registry := metadata.NewAggregateMetadataRegistry()
+	registry.Register(agg)
+	return metadata.NewSchema(registry, metadata.DefaultDialect()).Table(agg.Name)
+}
+
+// Generate 为聚合根生成列名常量
+func (g *ColumnGenerator) Generate(agg *metadata.AggregateMetadata, outputDir string) error {
+	// 输出目录（infrastructure 与 domain 平级）
+	columnDir := filepath.Join(infrastructureDir(agg, outputDir), "columns", columnPackage(agg))
+
+	fileName := fmt.Sprintf("%sColumns.go", agg.Name)
+	filePath := filepath.Join(columnDir, fileName)
+
+	if err := g.writeTemplate(filePath, "columns", g.generateFile(agg)); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+
+	return nil
+}
+
+// columnPackage 返回聚合根列名常量所在的包名，如 Order -> ordercol
+func columnPackage(agg *metadata.AggregateMetadata) string {
+	return strings.ToLower(agg.Name) + "col"
+}
+
+// generateFile 生成列名常量文件的模板数据
+//
+// 常量名为字段名，展开的值对象中的字段与 DO 字段同名（如 Address.City → AddressCity），关联实体不映射为列。
+// 表名常量为 Table，聚合根存在名为 Table 的字段时不生成表名常量。
+func (g *ColumnGenerator) generateFile(agg *metadata.AggregateMetadata) *GoFileData {
+	table := g.table(agg)
+	file := &GoFileData{
+		TemplateData: TemplateData{Aggregate: agg, Context: agg.Context()},
+		Package:      columnPackage(agg),
+	}
+
+	names := make(map[string]bool, len(table.Columns))
+	for _, column := range table.Columns {
+		names[columnConstName(column)] = true
+	}
+	if !names["Table"] {
+		file.addValues(declConst, fmt.Sprintf("Table %s 对应的表名", agg.Name), &GoField{Name: "Table", Value: strconv.Quote(table.Name)})
+	}
+
+	example := table.Columns[len(table.Columns)-1]
+	for _, column := range table.Columns {
+		if !column.PrimaryKey {
+			example = column
+			break
+		}
+	}
+	columns := make([]*GoField, len(table.Columns))
+	for i, column := range table.Columns {
+		columns[i] = &GoField{Name: columnConstName(column), Value: strconv.Quote(column.Name), Comment: column.Comment}
+	}
+	file.addValues(declConst, fmt.Sprintf("%s 的列名，主键列在前，其余按字段声明顺序，如 db.Where(%s.%s+\" = ?\", value)",
+		agg.Name, columnPackage(agg), columnConstName(example)), columns...)
+
+	return file
+}
+
+// columnConstName 返回列对应的常量名，即字段路径去掉分隔符，如 OrderNo、AddressCity
+func columnConstName(column *metadata.ColumnMetadata) string {
+	return strings.ReplaceAll(column.FieldPath, ".", "")
+}
@@ -2,7 +2,6 @@ package generator
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"soliton/pkg/metadata"
 	"strings"
@@ -11,16 +10,18 @@ import (
 // ConvertorGenerator 转换器生成器
 //
 // 生成领域对象和数据对象之间的双向转换器：
-//   - ToDomain(do) -> domain：数据对象 → 领域对象
-//   - ToData(domain) -> do：领域对象 → 数据对象
+//   - ToDomain(do) -> (domain, error)：数据对象 → 领域对象，JSON 值对象反序列化失败时返回错误
+//   - ToData(domain) -> (do, error)：领域对象 → 数据对象，JSON 值对象序列化失败时返回错误
 //
 // 转换规则：
 //  1. 简单类型：直接赋值
-//  2. 值对象：根据策略展开或JSON反序列化
+//  2. 值对象：根据策略展开，或由生成的 encode/decode 函数序列化为 JSON（支持带版本的值对象，见 framework.VersionedValue）
 //  3. 关联实体：跳过，不转换（保持聚合边界）
 //
 // 生成文件：infrastructure/persistence/convertor/{AggregateName}Convertor.go
-type ConvertorGenerator struct{}
+type ConvertorGenerator struct {
+	fileOutput
+}
 
 // NewConvertorGenerator 创建转换器生成器
 func NewConvertorGenerator() *ConvertorGenerator {
@@ -31,29 +32,23 @@ func NewConvertorGenerator() *ConvertorGenerator {
 func (g *ConvertorGenerator) Generate(agg *metadata.AggregateMetadata, outputDir string) error {
 	// 获取绝对路径
 	absOutputDir, _ := filepath.Abs(outputDir)
-	parentDir := filepath.Dir(absOutputDir)
+	infraDir := infrastructureDir(agg, absOutputDir)
 
-	// 创建输出目录（infrastructure 与 domain 平级）
-	convertorDir := filepath.Join(parentDir, "infrastructure", "convertor")
-	if err := os.MkdirAll(convertorDir, 0755); err != nil {
-		return fmt.Errorf("创建输出目录失败: %w", err)
-	}
+	// 输出目录（infrastructure 与 domain 平级）
+	convertorDir := filepath.Join(infraDir, "convertor")
 
 	// 计算各个依赖的 import 路径
 	imports := &convertorImports{
 		model: agg.ImportPath,
-		do:    calculateImportPath(agg.ModuleName, agg.ModuleRoot, filepath.Join(parentDir, "infrastructure", "do")),
+		do:    calculateImportPath(agg.ModuleName, agg.ModuleRoot, filepath.Join(infraDir, "do")),
 	}
 
 	// 生成文件路径
 	fileName := fmt.Sprintf("%sConvertor.go", agg.Name)
 	filePath := filepath.Join(convertorDir, fileName)
 
-	// 生成代码
-	code := g.generateCode(agg, imports)
-
 	// 写入文件
-	if err := os.WriteFile(filePath, []byte(code), 0644); err != nil {
+	if err := g.writeTemplate(filePath, "convertor", g.generateFile(agg, imports)); err != nil {
 		return fmt.Errorf("写入文件失败: %w", err)
 	}
 
@@ -66,186 +61,269 @@ type convertorImports struct {
 	do    string
 }
 
-// generateCode 生成转换器代码
-func (g *ConvertorGenerator) generateCode(agg *metadata.AggregateMetadata, imports *convertorImports) string {
-	var sb strings.Builder
-
+// generateFile 生成转换器文件的模板数据
+func (g *ConvertorGenerator) generateFile(agg *metadata.AggregateMetadata, imports *convertorImports) *GoFileData {
 	// 检查是否需要 JSON 包（有值对象且策略为 JSON）
 	needJSON := false
-	for _, field := range agg.Fields {
+	for _, field := range agg.MappedFields() {
 		if field.Annotations.IsValueObject && field.Annotations.Strategy == "json" {
 			needJSON = true
 			break
 		}
 	}
 
-	// 文件头
-	sb.WriteString("// Code generated by soliton. DO NOT EDIT.\n\n")
-	sb.WriteString("package convertor\n\n")
+	// 软删除字段的时间类型需要通过 framework 转换为 gorm.DeletedAt，JSON 值对象通过 framework 序列化
+	softDelete := softDeleteField(agg)
+	needFramework := needJSON || softDelete != nil && softDelete.GoType() != "gorm.DeletedAt"
+
+	file := &GoFileData{
+		TemplateData: TemplateData{Aggregate: agg, Context: agg.Context()},
+		Package:      "convertor",
+	}
 
 	// 导入（使用动态计算的 import 路径）
-	sb.WriteString("import (\n")
 	if needJSON {
-		sb.WriteString("\t\"encoding/json\"\n")
-		sb.WriteString("\t\"log\"\n")
+		file.addImports("fmt")
+	}
+	file.addImports(imports.model, imports.do)
+	if needFramework {
+		file.addImports("soliton/pkg/framework")
 	}
-	sb.WriteString(fmt.Sprintf("\t\"%s\"\n", imports.model))
-	sb.WriteString(fmt.Sprintf("\t\"%s\"\n", imports.do))
-	sb.WriteString(")\n\n")
 
-	// ToDomain 方法
-	sb.WriteString(g.generateToDomainMethod(agg))
-	sb.WriteString("\n")
+	// ToDomain、ToData 方法
+	file.addFunc(g.generateToDomainMethod(agg))
+	file.addFunc(g.generateToDataMethod(agg))
 
-	// ToData 方法
-	sb.WriteString(g.generateToDataMethod(agg))
+	// JSON 值对象的序列化函数
+	for _, field := range agg.MappedFields() {
+		if field.Annotations.IsValueObject && field.Annotations.Strategy == "json" {
+			encode, decode := g.generateJSONCodec(agg, field)
+			file.addFunc(encode)
+			file.addFunc(decode)
+		}
+	}
 
-	return sb.String()
+	return file
 }
 
 // generateToDomainMethod 生成 ToDomain 方法（数据对象 → 领域对象）
-func (g *ConvertorGenerator) generateToDomainMethod(agg *metadata.AggregateMetadata) string {
+func (g *ConvertorGenerator) generateToDomainMethod(agg *metadata.AggregateMetadata) *GoFunc {
 	var sb strings.Builder
 
 	doType := fmt.Sprintf("do.%sDO", agg.Name)
 	domainType := fmt.Sprintf("%s.%s", agg.PackageName, agg.Name)
 
-	// 收集值对象字段（JSON 策略）
-	var jsonValueObjects []*metadata.FieldMetadata
-	for _, field := range agg.Fields {
-		if field.Annotations.IsValueObject && field.Annotations.Strategy == "json" {
-			jsonValueObjects = append(jsonValueObjects, field)
-		}
+	// 函数名包含实体名称，避免同包内冲突
+	fn := &GoFunc{
+		Doc:     fmt.Sprintf("%sToDomain 数据对象转领域对象，JSON 值对象反序列化失败时返回错误", agg.Name),
+		Name:    agg.Name + "ToDomain",
+		Params:  "dataObj *" + doType,
+		Results: fmt.Sprintf("(*%s, error)", domainType),
 	}
 
-	// 方法签名（函数名包含实体名称，避免同包内冲突）
-	sb.WriteString(fmt.Sprintf("// %sToDomain 数据对象转领域对象\n", agg.Name))
-	sb.WriteString(fmt.Sprintf("func %sToDomain(dataObj *%s) *%s {\n", agg.Name, doType, domainType))
-
 	// nil 检查
 	sb.WriteString("\tif dataObj == nil {\n")
-	sb.WriteString("\t\treturn nil\n")
+	sb.WriteString("\t\treturn nil, nil\n")
 	sb.WriteString("\t}\n\n")
 
-	// 如果有 JSON 值对象，先进行反序列化
-	if len(jsonValueObjects) > 0 {
-		for _, field := range jsonValueObjects {
-			fieldType := field.Type
-			if field.IsPointer {
-				// 指针类型的值对象
-				sb.WriteString(fmt.Sprintf("\t// 反序列化 %s\n", field.Name))
-				sb.WriteString(fmt.Sprintf("\tvar %s %s\n", toLowerFirst(field.Name), fieldType))
-				sb.WriteString(fmt.Sprintf("\tif dataObj.%s != \"\" {\n", field.Name))
-				sb.WriteString(fmt.Sprintf("\t\tif err := json.Unmarshal([]byte(dataObj.%s), &%s); err != nil {\n",
-					field.Name, toLowerFirst(field.Name)))
-				sb.WriteString(fmt.Sprintf("\t\t\tlog.Printf(\"警告: 反序列化 %s.%s 失败: %%v\", err)\n", agg.Name, field.Name))
-				sb.WriteString("\t\t}\n")
-				sb.WriteString("\t}\n\n")
-			} else {
-				// 非指针类型的值对象
-				sb.WriteString(fmt.Sprintf("\t// 反序列化 %s\n", field.Name))
-				sb.WriteString(fmt.Sprintf("\tvar %s %s.%s\n", toLowerFirst(field.Name), agg.PackageName, fieldType))
-				sb.WriteString(fmt.Sprintf("\tif dataObj.%s != \"\" {\n", field.Name))
-				sb.WriteString(fmt.Sprintf("\t\tif err := json.Unmarshal([]byte(dataObj.%s), &%s); err != nil {\n",
-					field.Name, toLowerFirst(field.Name)))
-				sb.WriteString(fmt.Sprintf("\t\t\tlog.Printf(\"警告: 反序列化 %s.%s 失败: %%v\", err)\n", agg.Name, field.Name))
-				sb.WriteString("\t\t}\n")
-				sb.WriteString("\t}\n\n")
-			}
+	// 嵌入结构体中的提升字段不能出现在复合字面量中，JSON 值对象的反序列化可能失败，都在创建后逐个赋值
+	var promoted, decoded []*metadata.FieldMetadata
+	for _, field := range agg.MappedFields() {
+		switch {
+		case field.Annotations.IsEntity:
+		case field.Annotations.IsValueObject && field.Annotations.Strategy == "json":
+			decoded = append(decoded, field)
+		case field.EmbeddedIn != "":
+			promoted = append(promoted, field)
 		}
 	}
 
 	// 创建领域对象
-	sb.WriteString(fmt.Sprintf("\treturn &%s{\n", domainType))
+	assigned := len(promoted) > 0 || len(decoded) > 0
+	if assigned {
+		sb.WriteString(fmt.Sprintf("\tdomainObj := &%s{\n", domainType))
+	} else {
+		sb.WriteString(fmt.Sprintf("\treturn &%s{\n", domainType))
+	}
 
 	// 转换字段
-	for _, field := range agg.Fields {
+	for _, field := range agg.MappedFields() {
 		// 跳过关联实体字段
 		if field.Annotations.IsEntity {
 			sb.WriteString(fmt.Sprintf("\t\t// %s: 关联实体，不转换\n", field.Name))
 			continue
 		}
 
+		// 提升字段在后面赋值
+		if field.EmbeddedIn != "" {
+			continue
+		}
+
 		// 值对象处理
 		if field.Annotations.IsValueObject {
 			if field.Annotations.Strategy == "json" {
-				// JSON 策略：使用前面反序列化的变量
-				if field.IsPointer {
-					sb.WriteString(fmt.Sprintf("\t\t%s: &%s,\n", field.Name, toLowerFirst(field.Name)))
-				} else {
-					sb.WriteString(fmt.Sprintf("\t\t%s: %s,\n", field.Name, toLowerFirst(field.Name)))
-				}
+				// JSON 策略：在后面由生成的反序列化函数转换
+				continue
+			} else if field.Annotations.Strategy == metadata.ValueObjectFlatten {
+				// 展开策略：由展开的各列组装值对象
+				sb.WriteString(fmt.Sprintf("\t\t%s: %s,\n", field.Name, flattenedValueObject(field, agg.PackageName, "\t\t")))
 			} else {
-				// 展开策略：暂不支持，生成注释
-				sb.WriteString(fmt.Sprintf("\t\t// %s: 值对象展开策略暂不支持自动转换\n", field.Name))
+				// 未声明策略：不映射为列，生成注释
+				sb.WriteString(fmt.Sprintf("\t\t// %s: 值对象未声明存储策略，不自动转换\n", field.Name))
 			}
 			continue
 		}
 
-		// 简单类型直接赋值
-		sb.WriteString(fmt.Sprintf("\t\t%s: dataObj.%s,\n", field.Name, field.Name))
+		// 简单类型直接赋值，软删除字段由 gorm.DeletedAt 转换
+		sb.WriteString(fmt.Sprintf("\t\t%s: %s,\n", field.Name, softDeleteToDomain(agg, field)))
 	}
 
+	if !assigned {
+		sb.WriteString("\t}, nil\n")
+		fn.Body = sb.String()
+		return fn
+	}
 	sb.WriteString("\t}\n")
-	sb.WriteString("}\n")
 
+	for _, field := range promoted {
+		switch {
+		case !field.Annotations.IsValueObject:
+			sb.WriteString(fmt.Sprintf("\tdomainObj.%s = %s\n", field.Name, softDeleteToDomain(agg, field)))
+		case field.Annotations.Strategy == metadata.ValueObjectFlatten:
+			sb.WriteString(fmt.Sprintf("\tdomainObj.%s = %s\n", field.Name, flattenedValueObject(field, agg.PackageName, "\t")))
+		default:
+			sb.WriteString(fmt.Sprintf("\t// %s: 值对象未声明存储策略，不自动转换\n", field.Name))
+		}
+	}
+	if len(decoded) > 0 {
+		sb.WriteString("\tvar err error\n")
+	}
+	for _, field := range decoded {
+		sb.WriteString(fmt.Sprintf("\tif domainObj.%s, err = %s(dataObj.%s); err != nil {\n", field.Name, jsonCodecName("decode", agg, field), field.Name))
+		sb.WriteString("\t\treturn nil, err\n")
+		sb.WriteString("\t}\n")
+	}
+	sb.WriteString("\treturn domainObj, nil\n")
+	fn.Body = sb.String()
+
+	return fn
+}
+
+// flattenedValueObject 返回由数据对象中展开的各列组装值对象的表达式，如 model.Address{City: dataObj.AddressCity}
+// 指针值对象总是创建新对象，无法区分数据库中各列均为空的情况
+func flattenedValueObject(field *metadata.FieldMetadata, packageName, indent string) string {
+	var sb strings.Builder
+	if isPointerValueObject(field) {
+		sb.WriteString("&")
+	}
+	sb.WriteString(qualifyType(field.Type, packageName) + "{\n")
+	for _, sub := range field.Flattened {
+		sb.WriteString(fmt.Sprintf("%s\t%s: %s,\n", indent, sub.Name, toDomainValue(sub, "dataObj."+field.FlattenedName(sub), packageName)))
+	}
+	sb.WriteString(indent + "}")
 	return sb.String()
 }
 
+// softDeleteToDomain 返回数据对象字段赋给领域对象的表达式，软删除字段由 gorm.DeletedAt 转换为领域对象的时间类型
+func softDeleteToDomain(agg *metadata.AggregateMetadata, field *metadata.FieldMetadata) string {
+	if field != softDeleteField(agg) {
+		return toDomainValue(field, "dataObj."+field.Name, agg.PackageName)
+	}
+	switch field.GoType() {
+	case "*time.Time":
+		return fmt.Sprintf("framework.DeletedAtOf(dataObj.%s)", field.Name)
+	case "time.Time":
+		return fmt.Sprintf("dataObj.%s.Time", field.Name)
+	}
+	return "dataObj." + field.Name
+}
+
+// softDeleteToData 返回领域对象字段赋给数据对象的表达式，软删除字段转换为 gorm.DeletedAt
+func softDeleteToData(agg *metadata.AggregateMetadata, field *metadata.FieldMetadata) string {
+	if field != softDeleteField(agg) {
+		return toDataValue(field, "domain."+field.Name)
+	}
+	switch field.GoType() {
+	case "*time.Time":
+		return fmt.Sprintf("framework.SoftDeleteOf(domain.%s)", field.Name)
+	case "time.Time":
+		return fmt.Sprintf("framework.SoftDeleteOf(&domain.%s)", field.Name)
+	}
+	return "domain." + field.Name
+}
+
+// toDomainValue 将数据对象字段的表达式转换为领域对象的字段类型
+// 命名类型（如枚举 OrderStatus）在数据对象中为底层类型（见 doFieldType），需要转换，如 model.OrderStatus(dataObj.Status)；
+// 指针与底层类型相同，可直接转换，如 (*model.OrderStatus)(dataObj.PrevStatus)
+func toDomainValue(field *metadata.FieldMetadata, expr, packageName string) string {
+	if !isNamedBasicType(field) {
+		return expr
+	}
+	if field.IsPointer {
+		return fmt.Sprintf("(*%s)(%s)", qualifyType(field.Type, packageName), expr)
+	}
+	return fmt.Sprintf("%s(%s)", qualifyType(field.Type, packageName), expr)
+}
+
+// toDataValue 将领域对象字段的表达式转换为数据对象的字段类型，如 string(domain.Status)、(*string)(domain.PrevStatus)
+func toDataValue(field *metadata.FieldMetadata, expr string) string {
+	if !isNamedBasicType(field) {
+		return expr
+	}
+	if field.IsPointer {
+		return fmt.Sprintf("(*%s)(%s)", field.UnderlyingType, expr)
+	}
+	return fmt.Sprintf("%s(%s)", field.UnderlyingType, expr)
+}
+
+// isPointerValueObject 判断值对象字段本身是否为指针（如 *Address）
+// 切片、定长数组的 IsPointer 表示元素为指针，字段本身不是指针
+func isPointerValueObject(field *metadata.FieldMetadata) bool {
+	return field.IsPointer && !field.IsSlice && !field.IsArray
+}
+
 // generateToDataMethod 生成 ToData 方法（领域对象 → 数据对象）
-func (g *ConvertorGenerator) generateToDataMethod(agg *metadata.AggregateMetadata) string {
+func (g *ConvertorGenerator) generateToDataMethod(agg *metadata.AggregateMetadata) *GoFunc {
 	var sb strings.Builder
 
 	doType := fmt.Sprintf("do.%sDO", agg.Name)
 	domainType := fmt.Sprintf("%s.%s", agg.PackageName, agg.Name)
 
-	// 收集值对象字段（JSON 策略）
-	var jsonValueObjects []*metadata.FieldMetadata
-	for _, field := range agg.Fields {
-		if field.Annotations.IsValueObject && field.Annotations.Strategy == "json" {
-			jsonValueObjects = append(jsonValueObjects, field)
-		}
+	// 函数名包含实体名称，避免同包内冲突
+	fn := &GoFunc{
+		Doc:     fmt.Sprintf("%sToData 领域对象转数据对象，JSON 值对象序列化失败时返回错误", agg.Name),
+		Name:    agg.Name + "ToData",
+		Params:  "domain *" + domainType,
+		Results: fmt.Sprintf("(*%s, error)", doType),
 	}
 
-	// 方法签名（函数名包含实体名称，避免同包内冲突）
-	sb.WriteString(fmt.Sprintf("// %sToData 领域对象转数据对象\n", agg.Name))
-	sb.WriteString(fmt.Sprintf("func %sToData(domain *%s) *%s {\n", agg.Name, domainType, doType))
-
 	// nil 检查
 	sb.WriteString("\tif domain == nil {\n")
-	sb.WriteString("\t\treturn nil\n")
+	sb.WriteString("\t\treturn nil, nil\n")
 	sb.WriteString("\t}\n\n")
 
-	// 如果有 JSON 值对象，先进行序列化
-	if len(jsonValueObjects) > 0 {
-		for _, field := range jsonValueObjects {
-			sb.WriteString(fmt.Sprintf("\t// 序列化 %s\n", field.Name))
-			sb.WriteString(fmt.Sprintf("\tvar %sJSON string\n", toLowerFirst(field.Name)))
-			if field.IsPointer {
-				// 指针类型需要先判空
-				sb.WriteString(fmt.Sprintf("\tif domain.%s != nil {\n", field.Name))
-				sb.WriteString(fmt.Sprintf("\t\tif bytes, err := json.Marshal(domain.%s); err == nil {\n", field.Name))
-				sb.WriteString(fmt.Sprintf("\t\t\t%sJSON = string(bytes)\n", toLowerFirst(field.Name)))
-				sb.WriteString("\t\t} else {\n")
-				sb.WriteString(fmt.Sprintf("\t\t\tlog.Printf(\"警告: 序列化 %s.%s 失败: %%v\", err)\n", agg.Name, field.Name))
-				sb.WriteString("\t\t}\n")
-				sb.WriteString("\t}\n\n")
-			} else {
-				// 非指针类型直接序列化
-				sb.WriteString(fmt.Sprintf("\tif bytes, err := json.Marshal(domain.%s); err == nil {\n", field.Name))
-				sb.WriteString(fmt.Sprintf("\t\t%sJSON = string(bytes)\n", toLowerFirst(field.Name)))
-				sb.WriteString("\t} else {\n")
-				sb.WriteString(fmt.Sprintf("\t\tlog.Printf(\"警告: 序列化 %s.%s 失败: %%v\", err)\n", agg.Name, field.Name))
-				sb.WriteString("\t}\n\n")
-			}
+	// 指针值对象展开的列需要先判空，JSON 值对象的序列化可能失败，都在创建后赋值
+	var pointerFlattened, encoded []*metadata.FieldMetadata
+	for _, field := range agg.MappedFields() {
+		switch {
+		case field.Annotations.IsEntity || !field.Annotations.IsValueObject:
+		case field.Annotations.Strategy == "json":
+			encoded = append(encoded, field)
+		case field.Annotations.Strategy == metadata.ValueObjectFlatten && isPointerValueObject(field):
+			pointerFlattened = append(pointerFlattened, field)
 		}
 	}
 
 	// 创建数据对象
-	sb.WriteString(fmt.Sprintf("\treturn &%s{\n", doType))
+	assigned := len(pointerFlattened) > 0 || len(encoded) > 0
+	if assigned {
+		sb.WriteString(fmt.Sprintf("\tdataObj := &%s{\n", doType))
+	} else {
+		sb.WriteString(fmt.Sprintf("\treturn &%s{\n", doType))
+	}
 
 	// 转换字段
-	for _, field := range agg.Fields {
+	for _, field := range agg.MappedFields() {
 		// 跳过关联实体字段
 		if field.Annotations.IsEntity {
 			sb.WriteString(fmt.Sprintf("\t\t// %s: 关联实体，不转换\n", field.Name))
@@ -255,21 +333,129 @@ func (g *ConvertorGenerator) generateToDataMethod(agg *metadata.AggregateMetadat
 		// 值对象处理
 		if field.Annotations.IsValueObject {
 			if field.Annotations.Strategy == "json" {
-				// JSON 策略：使用前面序列化的变量
-				sb.WriteString(fmt.Sprintf("\t\t%s: %sJSON,\n", field.Name, toLowerFirst(field.Name)))
+				// JSON 策略：在后面由生成的序列化函数转换
+				continue
+			} else if field.Annotations.Strategy == metadata.ValueObjectFlatten {
+				// 展开策略：值对象的各字段分别写入对应列，指针值对象在后面判空赋值
+				if !isPointerValueObject(field) {
+					for _, sub := range field.Flattened {
+						sb.WriteString(fmt.Sprintf("\t\t%s: %s,\n", field.FlattenedName(sub), toDataValue(sub, "domain."+field.Name+"."+sub.Name)))
+					}
+				}
 			} else {
-				// 展开策略：暂不支持，生成注释
-				sb.WriteString(fmt.Sprintf("\t\t// %s: 值对象展开策略暂不支持自动转换\n", field.Name))
+				// 未声明策略：不映射为列，生成注释
+				sb.WriteString(fmt.Sprintf("\t\t// %s: 值对象未声明存储策略，不自动转换\n", field.Name))
 			}
 			continue
 		}
 
-		// 简单类型直接赋值
-		sb.WriteString(fmt.Sprintf("\t\t%s: domain.%s,\n", field.Name, field.Name))
+		// 简单类型直接赋值，软删除字段转换为 gorm.DeletedAt
+		sb.WriteString(fmt.Sprintf("\t\t%s: %s,\n", field.Name, softDeleteToData(agg, field)))
 	}
 
+	if !assigned {
+		sb.WriteString("\t}, nil\n")
+		fn.Body = sb.String()
+		return fn
+	}
 	sb.WriteString("\t}\n")
-	sb.WriteString("}\n")
 
-	return sb.String()
+	for _, field := range pointerFlattened {
+		sb.WriteString(fmt.Sprintf("\tif domain.%s != nil {\n", field.Name))
+		for _, sub := range field.Flattened {
+			sb.WriteString(fmt.Sprintf("\t\tdataObj.%s = %s\n", field.FlattenedName(sub), toDataValue(sub, "domain."+field.Name+"."+sub.Name)))
+		}
+		sb.WriteString("\t}\n")
+	}
+	if len(encoded) > 0 {
+		sb.WriteString("\tvar err error\n")
+	}
+	for _, field := range encoded {
+		sb.WriteString(fmt.Sprintf("\tif dataObj.%s, err = %s(domain.%s); err != nil {\n", field.Name, jsonCodecName("encode", agg, field), field.Name))
+		sb.WriteString("\t\treturn nil, err\n")
+		sb.WriteString("\t}\n")
+	}
+	sb.WriteString("\treturn dataObj, nil\n")
+	fn.Body = sb.String()
+
+	return fn
+}
+
+// jsonCodecName 返回 JSON 值对象的序列化（encode）或反序列化（decode）函数名，如 decodeOrderAddress
+// 同一限界上下文的转换器在同一个包中，函数名带上聚合根名避免冲突
+func jsonCodecName(direction string, agg *metadata.AggregateMetadata, field *metadata.FieldMetadata) string {
+	return direction + agg.Name + field.Name
+}
+
+// generateJSONCodec 生成 JSON 值对象的序列化和反序列化函数
+//
+// 通过 framework.MarshalJSONValue、framework.UnmarshalJSONValue 读写字符串列，值对象实现了 framework.VersionedValue 时
+// 带版本号存储，读取旧版本的数据先升级。序列化和反序列化失败时返回带字段名的错误，
+// 分别由 ToData、ToDomain 返回给仓储的调用方。
+// 指针值对象为 nil 时写入空字符串，空字符串和 null 读取为 nil。
+func (g *ConvertorGenerator) generateJSONCodec(agg *metadata.AggregateMetadata, field *metadata.FieldMetadata) (encode, decode *GoFunc) {
+	fieldName := agg.Name + "." + field.Name
+	pointer := isPointerValueObject(field)
+
+	// 值对象类型：指针值对象取元素类型
+	valueType := qualifyType(field.GoType(), agg.PackageName)
+	if pointer {
+		valueType = qualifyType(field.Type, agg.PackageName)
+	}
+	fieldType := valueType
+	if pointer {
+		fieldType = "*" + valueType
+	}
+
+	// 序列化
+	var sb strings.Builder
+	argument := "value"
+	if pointer {
+		sb.WriteString("\tif value == nil {\n")
+		sb.WriteString("\t\treturn \"\", nil\n")
+		sb.WriteString("\t}\n")
+		argument = "*value"
+	}
+	sb.WriteString(fmt.Sprintf("\tdata, err := framework.MarshalJSONValue(%s)\n", argument))
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString(fmt.Sprintf("\t\treturn \"\", fmt.Errorf(\"序列化 %s 失败: %%w\", err)\n", fieldName))
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn data, nil\n")
+	encode = &GoFunc{
+		Doc:     fmt.Sprintf("%s 序列化 %s（JSON 策略的值对象）", jsonCodecName("encode", agg, field), fieldName),
+		Name:    jsonCodecName("encode", agg, field),
+		Params:  "value " + fieldType,
+		Results: "(string, error)",
+		Body:    sb.String(),
+	}
+
+	// 反序列化
+	sb.Reset()
+	if pointer {
+		sb.WriteString("\tif data == \"\" || data == \"null\" {\n")
+		sb.WriteString("\t\treturn nil, nil\n")
+		sb.WriteString("\t}\n")
+	}
+	sb.WriteString(fmt.Sprintf("\tvar value %s\n", valueType))
+	sb.WriteString("\tif err := framework.UnmarshalJSONValue(data, &value); err != nil {\n")
+	if pointer {
+		sb.WriteString(fmt.Sprintf("\t\treturn nil, fmt.Errorf(\"反序列化 %s 失败: %%w\", err)\n", fieldName))
+	} else {
+		sb.WriteString(fmt.Sprintf("\t\treturn value, fmt.Errorf(\"反序列化 %s 失败: %%w\", err)\n", fieldName))
+	}
+	sb.WriteString("\t}\n")
+	if pointer {
+		sb.WriteString("\treturn &value, nil\n")
+	} else {
+		sb.WriteString("\treturn value, nil\n")
+	}
+	decode = &GoFunc{
+		Doc:     fmt.Sprintf("%s 反序列化 %s（JSON 策略的值对象）", jsonCodecName("decode", agg, field), fieldName),
+		Name:    jsonCodecName("decode", agg, field),
+		Params:  "data string",
+		Results: fmt.Sprintf("(%s, error)", fieldType),
+		Body:    sb.String(),
+	}
+
+	return encode, decode
 }
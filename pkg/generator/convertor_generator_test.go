@@ -0,0 +1,80 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+var testConvertorImports = &convertorImports{model: "sample/domain/model", do: "sample/infrastructure/do"}
+
+func TestConvertorGeneratorConvertsEnums(t *testing.T) {
+	agg := parseTestModel(t, "Device", enumModelSource)
+
+	code := renderTestTemplate(t, "convertor", NewConvertorGenerator().generateFile(agg, testConvertorImports))
+	assertGoSource(t, code,
+		"State: model.DeviceState(dataObj.State),",
+		"Level: model.Level(dataObj.Level),",
+		"PrevState: (*model.DeviceState)(dataObj.PrevState),",
+		"State: string(domain.State),",
+		"Level: int(domain.Level),",
+		"PrevState: (*string)(domain.PrevState),",
+	)
+}
+
+// jsonValueObjectModel 包含值、指针两种 JSON 值对象字段的订单模型
+const jsonValueObjectModel = `package model
+
+// Address 地址
+type Address struct {
+	City string
+}
+
+// Order 订单
+//
+// +soliton:aggregate
+type Order struct {
+	ID int64 ` + "`db:\"id\"`" + `
+	// +soliton:valueObject(strategy=json)
+	Address Address ` + "`db:\"address\"`" + `
+	// +soliton:valueObject(strategy=json)
+	BillTo *Address ` + "`db:\"bill_to\"`" + `
+}
+`
+
+func TestConvertorGeneratorReturnsDecodeError(t *testing.T) {
+	agg := parseTestModel(t, "Order", jsonValueObjectModel)
+
+	code := renderTestTemplate(t, "convertor", NewConvertorGenerator().generateFile(agg, testConvertorImports))
+	assertGoSource(t, code,
+		"func OrderToDomain(dataObj *do.OrderDO) (*model.Order, error) {",
+		"if domainObj.Address, err = decodeOrderAddress(dataObj.Address); err != nil { return nil, err }",
+		"if domainObj.BillTo, err = decodeOrderBillTo(dataObj.BillTo); err != nil { return nil, err }",
+		"return domainObj, nil",
+		"func decodeOrderAddress(data string) (model.Address, error) {",
+		`return value, fmt.Errorf("反序列化 Order.Address 失败: %w", err)`,
+		"func decodeOrderBillTo(data string) (*model.Address, error) {",
+		`return nil, fmt.Errorf("反序列化 Order.BillTo 失败: %w", err)`,
+	)
+	if strings.Contains(code, "反序列化 Order.Address 失败: %v") {
+		t.Errorf("反序列化失败不应只记录日志:\n%s", code)
+	}
+}
+
+func TestConvertorGeneratorReturnsEncodeError(t *testing.T) {
+	agg := parseTestModel(t, "Order", jsonValueObjectModel)
+
+	code := renderTestTemplate(t, "convertor", NewConvertorGenerator().generateFile(agg, testConvertorImports))
+	assertGoSource(t, code,
+		"func OrderToData(domain *model.Order) (*do.OrderDO, error) {",
+		"if dataObj.Address, err = encodeOrderAddress(domain.Address); err != nil { return nil, err }",
+		"if dataObj.BillTo, err = encodeOrderBillTo(domain.BillTo); err != nil { return nil, err }",
+		"return dataObj, nil",
+		"func encodeOrderAddress(value model.Address) (string, error) {",
+		`return "", fmt.Errorf("序列化 Order.Address 失败: %w", err)`,
+		"func encodeOrderBillTo(value *model.Address) (string, error) {",
+		`if value == nil { return "", nil }`,
+	)
+	if strings.Contains(code, "log.Printf") {
+		t.Errorf("序列化失败不应只记录日志:\n%s", code)
+	}
+}
@@ -0,0 +1,144 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 自定义代码区域标记，注释前缀可以是 //、# 或 --（SQL），标记后可以跟区域名，如 // soliton:begin-custom helpers
+const (
+	customBeginMarker = "soliton:begin-custom"
+	customEndMarker   = "soliton:end-custom"
+)
+
+// customMarkerPattern 匹配独占一行的自定义代码区域标记，子匹配依次为标记和区域名
+var customMarkerPattern = regexp.MustCompile(`^\s*(?://|#|--)\s*(soliton:(?:begin|end)-custom)\b[ \t]*(.*?)\s*$`)
+
+// customRegion 文件中的一个自定义代码区域
+type customRegion struct {
+	key   string // 区域名，未命名的区域为按出现顺序编号的 #1、#2
+	begin int    // 开始标记所在行的下标
+	end   int    // 结束标记所在行的下标
+}
+
+// parseCustomRegions 解析文件中的自定义代码区域，标记不成对、嵌套或区域名重复时返回错误
+func parseCustomRegions(lines []string) ([]customRegion, error) {
+	var regions []customRegion
+	seen := make(map[string]bool)
+	unnamed := 0
+	open := -1
+	for i, line := range lines {
+		match := customMarkerPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if match[1] == customEndMarker {
+			if open < 0 {
+				return nil, fmt.Errorf("第 %d 行的 %s 没有对应的 %s", i+1, customEndMarker, customBeginMarker)
+			}
+			regions[len(regions)-1].end = i
+			open = -1
+			continue
+		}
+
+		if open >= 0 {
+			return nil, fmt.Errorf("第 %d 行的 %s 没有对应的 %s", open+1, customBeginMarker, customEndMarker)
+		}
+		key := match[2]
+		if key == "" {
+			unnamed++
+			key = fmt.Sprintf("#%d", unnamed)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("第 %d 行的自定义代码区域 %s 重复", i+1, key)
+		}
+		seen[key] = true
+		regions = append(regions, customRegion{key: key, begin: i})
+		open = i
+	}
+	if open >= 0 {
+		return nil, fmt.Errorf("第 %d 行的 %s 没有对应的 %s", open+1, customBeginMarker, customEndMarker)
+	}
+	return regions, nil
+}
+
+// preserveCustomRegions 将已有文件 previous 中自定义代码区域的内容保留到新生成的内容 content 中
+//
+// 区域按名称（未命名的按出现顺序）对应：content 中存在同名区域时替换其内容，否则将整个区域（含标记）追加到文件末尾，
+// 因此在生成的文件中任何位置手写的代码都不会因重新生成而丢失。previous 中的标记不完整时返回错误，不覆盖文件。
+func preserveCustomRegions(previous, content string) (string, error) {
+	if !strings.Contains(previous, customBeginMarker) {
+		return content, nil
+	}
+	oldLines := strings.Split(previous, "\n")
+	oldRegions, err := parseCustomRegions(oldLines)
+	if err != nil {
+		return "", fmt.Errorf("已有文件的自定义代码区域有误: %w", err)
+	}
+	if len(oldRegions) == 0 {
+		return content, nil
+	}
+
+	newLines := strings.Split(content, "\n")
+	newRegions, err := parseCustomRegions(newLines)
+	if err != nil {
+		return "", fmt.Errorf("生成内容的自定义代码区域有误: %w", err)
+	}
+	placed := make(map[string]bool, len(newRegions))
+	for _, region := range newRegions {
+		placed[region.key] = true
+	}
+
+	var sb strings.Builder
+	var appended []customRegion
+	next := 0
+	for _, region := range oldRegions {
+		if !placed[region.key] {
+			appended = append(appended, region)
+		}
+	}
+	for _, region := range newRegions {
+		old, ok := findRegion(oldRegions, region.key)
+		if !ok {
+			continue
+		}
+		// 保留生成内容中的标记行，只替换标记之间的内容
+		writeLines(&sb, newLines[next:region.begin+1])
+		writeLines(&sb, oldLines[old.begin+1:old.end])
+		next = region.end
+	}
+	rest := newLines[next:]
+	if len(appended) == 0 {
+		sb.WriteString(strings.Join(rest, "\n"))
+		return sb.String(), nil
+	}
+
+	// 没有对应位置的区域追加到末尾，与前面的内容空一行
+	body := strings.TrimRight(strings.Join(rest, "\n"), "\n")
+	sb.WriteString(body)
+	for _, region := range appended {
+		sb.WriteString("\n\n")
+		sb.WriteString(strings.Join(oldLines[region.begin:region.end+1], "\n"))
+	}
+	sb.WriteString("\n")
+	return sb.String(), nil
+}
+
+// findRegion 按名称查找区域
+func findRegion(regions []customRegion, key string) (customRegion, bool) {
+	for _, region := range regions {
+		if region.key == key {
+			return region, true
+		}
+	}
+	return customRegion{}, false
+}
+
+// writeLines 逐行写出，每行以换行结尾
+func writeLines(sb *strings.Builder, lines []string) {
+	for _, line := range lines {
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+}
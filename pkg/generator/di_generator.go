@@ -0,0 +1,435 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"soliton/pkg/metadata"
+	"sort"
+	"strings"
+)
+
+// 生成依赖注入代码使用的框架（-di）
+const (
+	DIFrameworkWire = "wire"
+	DIFrameworkFx   = "fx"
+)
+
+// DIFrameworks 支持的依赖注入框架
+var DIFrameworks = []string{DIFrameworkWire, DIFrameworkFx}
+
+// DIGenerator 依赖注入代码生成器
+//
+// 为每个限界上下文的各层生成 providers.go，并生成汇总全部层的 di 包，应用只需一次调用即可组装：
+//   - infrastructure/repository/providers.go：Provide{AggregateName}Repository(db) 以仓储接口提供仓储实现，
+//     声明了 +soliton:query 的聚合根还有 Provide{AggregateName}ReadRepository(db) 提供只读仓储
+//   - domain/service/impl/providers.go：Provide{AggregateName}Service 以 framework.ServiceOf 提供领域服务，
+//     参数与 New{AggregateName}Service 相同（本聚合根和外键引用的聚合根的仓储）
+//   - domain/readmodel/providers.go（有 +soliton:query 时）：New{Query}Handler 和汇总查询处理器的 ProvideQueries
+//   - interfaces/handler/providers.go（-http 时）：New{AggregateName}Handler 和汇总处理器的 ProvideHandlers
+//   - di/container.go：包含全部领域服务、各上下文查询处理器和 REST 处理器的 Container
+//
+// wire 时每层导出 ProviderSet，di 包的 ProviderSet 汇总全部层，并生成 wireinject 构建标签下的
+// InitializeContainer(db)，由 wire 生成实现；fx 时每层导出 Module，di 包提供 Module 和 NewApp(db, options...)。
+// 数据库连接 *gorm.DB 由应用提供。声明了 +soliton:context 的聚合根的 providers.go 输出到对应上下文的子目录。
+type DIGenerator struct {
+	fileOutput
+	framework string
+	registry  *metadata.AggregateMetadataRegistry
+}
+
+// NewDIGenerator 创建依赖注入代码生成器，默认生成 wire 的 ProviderSet
+func NewDIGenerator() *DIGenerator {
+	return &DIGenerator{framework: DIFrameworkWire}
+}
+
+// SetFramework 设置依赖注入框架，见 DIFrameworks
+func (g *DIGenerator) SetFramework(framework string) error {
+	if !slices.Contains(DIFrameworks, framework) {
+		return fmt.Errorf("不支持的依赖注入框架 %s，可选 %s", framework, strings.Join(DIFrameworks, "、"))
+	}
+	g.framework = framework
+	return nil
+}
+
+// SetRegistry 设置聚合根注册表，用于确定领域服务依赖的其他限界上下文的仓储
+func (g *DIGenerator) SetRegistry(registry *metadata.AggregateMetadataRegistry) {
+	g.registry = registry
+}
+
+// GenerateContext 为限界上下文 boundedContext 生成各层的 providers.go
+// aggregates 为全部聚合根，exposed 为生成了 REST 处理器的聚合根，为空时不生成处理器层
+func (g *DIGenerator) GenerateContext(aggregates, exposed []*metadata.AggregateMetadata, outputDir, boundedContext string) error {
+	members, handlers := contextMembers(aggregates, boundedContext), contextMembers(exposed, boundedContext)
+	if len(members) == 0 {
+		return fmt.Errorf("限界上下文 %q 中没有聚合根", boundedContext)
+	}
+
+	absOutputDir, _ := filepath.Abs(outputDir)
+	files := map[string]*GoFileData{
+		filepath.Join(infrastructureDir(members[0], absOutputDir), "repository", "providers.go"): g.generateRepositories(members, absOutputDir),
+		filepath.Join(domainDir(members[0], absOutputDir), "service", "impl", "providers.go"):    g.generateServices(members, absOutputDir),
+	}
+	if queried := queriedMembers(members); len(queried) > 0 {
+		files[filepath.Join(readModelDir(members[0], absOutputDir), "providers.go")] = g.generateReadModels(queried)
+	}
+	if len(handlers) > 0 {
+		files[filepath.Join(interfacesDir(handlers[0], absOutputDir), "handler", "providers.go")] = g.generateHandlers(handlers)
+	}
+	for filePath, file := range files {
+		file.Aggregates, file.Context = members, boundedContext
+		if err := g.writeTemplate(filePath, "di_providers", file); err != nil {
+			return fmt.Errorf("写入文件失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// GenerateContainer 生成汇总全部限界上下文的 di 包，aggregates、exposed 与 GenerateContext 相同
+func (g *DIGenerator) GenerateContainer(aggregates, exposed []*metadata.AggregateMetadata, outputDir string) error {
+	if len(aggregates) == 0 {
+		return fmt.Errorf("没有聚合根")
+	}
+
+	absOutputDir, _ := filepath.Abs(outputDir)
+	dir := filepath.Join(filepath.Dir(absOutputDir), "di")
+	if err := g.writeTemplate(filepath.Join(dir, "container.go"), "di_container", g.generateContainer(aggregates, exposed, absOutputDir)); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	if g.framework == DIFrameworkWire {
+		if err := g.writeTemplate(filepath.Join(dir, "wire.go"), "di_injector", &TemplateData{Aggregates: aggregates}); err != nil {
+			return fmt.Errorf("写入文件失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// contextMembers 返回 aggregates 中属于限界上下文 boundedContext 的聚合根
+func contextMembers(aggregates []*metadata.AggregateMetadata, boundedContext string) []*metadata.AggregateMetadata {
+	var members []*metadata.AggregateMetadata
+	for _, agg := range aggregates {
+		if agg.Context() == boundedContext {
+			members = append(members, agg)
+		}
+	}
+	return members
+}
+
+// queriedMembers 返回 members 中声明了查询（+soliton:query）的聚合根
+func queriedMembers(members []*metadata.AggregateMetadata) []*metadata.AggregateMetadata {
+	var queried []*metadata.AggregateMetadata
+	for _, agg := range members {
+		if len(readQueries(agg)) > 0 {
+			queried = append(queried, agg)
+		}
+	}
+	return queried
+}
+
+// contextsOf 返回聚合根涉及的限界上下文，按名称排序，空字符串表示未声明上下文
+func contextsOf(aggregates []*metadata.AggregateMetadata) []string {
+	var contexts []string
+	for _, agg := range aggregates {
+		if !slices.Contains(contexts, agg.Context()) {
+			contexts = append(contexts, agg.Context())
+		}
+	}
+	sort.Strings(contexts)
+	return contexts
+}
+
+// frameworkImport 返回依赖注入框架的 import 路径
+func (g *DIGenerator) frameworkImport() string {
+	if g.framework == DIFrameworkFx {
+		return "go.uber.org/fx"
+	}
+	return "github.com/google/wire"
+}
+
+// addProviderSet 向 file 添加一层的 ProviderSet（wire）或 Module（fx）声明，name 为 fx 模块名，items 为提供者或其他集合
+func (g *DIGenerator) addProviderSet(file *GoFileData, comment, name string, items []string) {
+	var value strings.Builder
+	set := &GoField{Name: "ProviderSet"}
+	if g.framework == DIFrameworkFx {
+		set.Name = "Module"
+		value.WriteString(fmt.Sprintf("fx.Module(%q,\n", name))
+	} else {
+		value.WriteString("wire.NewSet(\n")
+	}
+	for _, item := range items {
+		value.WriteString(fmt.Sprintf("\t%s,\n", item))
+	}
+	value.WriteString(")")
+	set.Value = value.String()
+	file.addValues(declVar, set.Name+" "+comment, set)
+}
+
+// addDIImport 向 file 添加以 alias 为引用名的导入，alias 为空或与包目录名相同时不使用别名
+func addDIImport(file *GoFileData, alias, importPath string) {
+	if alias == filepath.Base(importPath) {
+		alias = ""
+	}
+	file.addImport(alias, importPath)
+}
+
+// provide 返回提供者列表：wire 直接列出，fx 包装为一个 fx.Provide
+func (g *DIGenerator) provide(providers []string) []string {
+	if g.framework == DIFrameworkFx {
+		return []string{fmt.Sprintf("fx.Provide(\n\t\t%s,\n\t)", strings.Join(providers, ",\n\t\t"))}
+	}
+	return providers
+}
+
+// moduleName 返回限界上下文中一层的 fx 模块名，如 ordering.repository
+func moduleName(boundedContext, layer string) string {
+	if boundedContext == "" {
+		return layer
+	}
+	return boundedContext + "." + layer
+}
+
+// generateRepositories 生成仓储层的 providers.go
+func (g *DIGenerator) generateRepositories(members []*metadata.AggregateMetadata, absOutputDir string) *GoFileData {
+	agg := members[0]
+	file := &GoFileData{Package: "repository"}
+	file.addImports(calculateImportPath(agg.ModuleName, agg.ModuleRoot, filepath.Join(domainDir(agg, absOutputDir), "repository")), "gorm.io/gorm", g.frameworkImport())
+
+	var providers []string
+	for _, agg := range members {
+		provider := fmt.Sprintf("Provide%sRepository", agg.Name)
+		providers = append(providers, provider)
+		file.addFunc(&GoFunc{
+			Doc:     fmt.Sprintf("%s 以仓储接口提供 %s 仓储", provider, agg.Name),
+			Name:    provider,
+			Params:  "db *gorm.DB",
+			Results: fmt.Sprintf("repository.%sRepository", agg.Name),
+			Body:    fmt.Sprintf("\treturn New%sRepository(db)\n", agg.Name),
+		})
+	}
+	queried := queriedMembers(members)
+	for _, agg := range queried {
+		provider := fmt.Sprintf("Provide%sReadRepository", agg.Name)
+		providers = append(providers, provider)
+		file.addFunc(&GoFunc{
+			Doc:     fmt.Sprintf("%s 以只读仓储接口提供 %s 只读仓储", provider, agg.Name),
+			Name:    provider,
+			Params:  "db *gorm.DB",
+			Results: fmt.Sprintf("readmodel.%sReadRepository", agg.Name),
+			Body:    fmt.Sprintf("\treturn New%sReadRepository(db)\n", agg.Name),
+		})
+	}
+	if len(queried) > 0 {
+		file.addImports(calculateImportPath(agg.ModuleName, agg.ModuleRoot, readModelDir(agg, absOutputDir)))
+	}
+	g.addProviderSet(file, "本上下文的全部仓储", moduleName(agg.Context(), "repository"), g.provide(providers))
+
+	return file
+}
+
+// generateServices 生成领域服务层的 providers.go
+func (g *DIGenerator) generateServices(members []*metadata.AggregateMetadata, absOutputDir string) *GoFileData {
+	services := &ServiceImplGenerator{registry: g.registry}
+
+	file := &GoFileData{Package: "impl"}
+	file.addImports("soliton/pkg/framework", g.frameworkImport())
+
+	var providers []string
+	for _, agg := range members {
+		file.addImports(agg.ImportPath, calculateImportPath(agg.ModuleName, agg.ModuleRoot, filepath.Join(domainDir(agg, absOutputDir), "repository")))
+
+		params := []string{fmt.Sprintf("repo repository.%sRepository", agg.Name)}
+		args := []string{"repo"}
+		// 与领域服务构造函数的参数一致，引用其他服务中的聚合根时不注入仓储
+		for _, ref := range services.collectRefFields(agg, absOutputDir) {
+			params = append(params, fmt.Sprintf("%s %s.%sRepository", ref.RepoFieldName, ref.RepoPackage, ref.RefAggregate))
+			args = append(args, ref.RepoFieldName)
+			if ref.RepoImport != "" {
+				addDIImport(file, ref.RepoPackage, ref.RepoImport)
+			}
+		}
+
+		provider := fmt.Sprintf("Provide%sService", agg.Name)
+		providers = append(providers, provider)
+		file.addFunc(&GoFunc{
+			Doc:     fmt.Sprintf("%s 以 framework.ServiceOf 提供 %s 领域服务", provider, agg.Name),
+			Name:    provider,
+			Params:  strings.Join(params, ", "),
+			Results: serviceOfType(agg),
+			Body:    fmt.Sprintf("\treturn New%sService(%s)\n", agg.Name, strings.Join(args, ", ")),
+		})
+	}
+	g.addProviderSet(file, "本上下文的全部领域服务", moduleName(members[0].Context(), "service"), g.provide(providers))
+
+	return file
+}
+
+// serviceOfType 返回领域服务对外的接口类型，如 framework.ServiceOf[*model.Order, int64]
+func serviceOfType(agg *metadata.AggregateMetadata) string {
+	return fmt.Sprintf("framework.ServiceOf[*%s.%s, %s]", agg.PackageName, agg.Name, qualifiedKeyType(agg))
+}
+
+// generateHandlers 生成 REST 处理器层的 providers.go
+func (g *DIGenerator) generateHandlers(members []*metadata.AggregateMetadata) *GoFileData {
+	file := &GoFileData{Package: "handler"}
+	file.addImports(g.frameworkImport())
+
+	var providers, params []string
+	var body strings.Builder
+	body.WriteString("\treturn Handlers{\n")
+	for _, agg := range members {
+		param := toLowerFirst(agg.Name) + "Handler"
+		providers = append(providers, fmt.Sprintf("New%sHandler", agg.Name))
+		params = append(params, fmt.Sprintf("%s *%sHandler", param, agg.Name))
+		body.WriteString(fmt.Sprintf("\t\t%s: %s,\n", agg.Name, param))
+	}
+	body.WriteString("\t}\n")
+
+	file.addFunc(&GoFunc{
+		Doc:     "ProvideHandlers 汇总本上下文的 REST 处理器，供 RegisterRoutes 注册路由",
+		Name:    "ProvideHandlers",
+		Params:  strings.Join(params, ", "),
+		Results: "Handlers",
+		Body:    body.String(),
+	})
+	g.addProviderSet(file, "本上下文的全部 REST 处理器", moduleName(members[0].Context(), "handler"),
+		g.provide(append(providers, "ProvideHandlers")))
+
+	return file
+}
+
+// generateReadModels 生成读模型层的 providers.go，queried 为上下文中声明了查询的聚合根
+func (g *DIGenerator) generateReadModels(queried []*metadata.AggregateMetadata) *GoFileData {
+	var queries []*readQuery
+	for _, agg := range queried {
+		queries = append(queries, readQueries(agg)...)
+	}
+
+	file := &GoFileData{Package: "readmodel"}
+	file.addImports(g.frameworkImport())
+
+	var providers, params []string
+	var fields []*GoField
+	var body strings.Builder
+	body.WriteString("\treturn Queries{\n")
+	for _, q := range queries {
+		param := toLowerFirst(q.Name) + "Handler"
+		providers = append(providers, fmt.Sprintf("New%sHandler", q.Name))
+		params = append(params, fmt.Sprintf("%s *%sHandler", param, q.Name))
+		fields = append(fields, &GoField{Name: q.Name, Type: "*" + q.Name + "Handler"})
+		body.WriteString(fmt.Sprintf("\t\t%s: %s,\n", q.Name, param))
+	}
+	body.WriteString("\t}\n")
+
+	file.addType("Queries 本上下文的全部查询处理器", "Queries", "struct", fields...)
+	file.addFunc(&GoFunc{
+		Doc:     "ProvideQueries 汇总本上下文的查询处理器",
+		Name:    "ProvideQueries",
+		Params:  strings.Join(params, ", "),
+		Results: "Queries",
+		Body:    body.String(),
+	})
+	g.addProviderSet(file, "本上下文的全部查询处理器", moduleName(queried[0].Context(), "readmodel"),
+		g.provide(append(providers, "ProvideQueries")))
+
+	return file
+}
+
+// diLayer di 包引用的一个限界上下文中的一层
+type diLayer struct {
+	alias      string // 包的引用名，如 "orderingrepository"；未声明上下文时为包名
+	importPath string
+}
+
+// layerOf 返回限界上下文中一层（dir 下名为 pkg 的包）在 di 包中的引用
+func layerOf(agg *metadata.AggregateMetadata, dir, pkg string) diLayer {
+	alias := pkg
+	if agg.Context() != "" {
+		alias = strings.ToLower(agg.Context()) + pkg
+	}
+	return diLayer{alias: alias, importPath: calculateImportPath(agg.ModuleName, agg.ModuleRoot, dir)}
+}
+
+// generateContainer 生成 di/container.go
+func (g *DIGenerator) generateContainer(aggregates, exposed []*metadata.AggregateMetadata, absOutputDir string) *GoFileData {
+	file := &GoFileData{TemplateData: TemplateData{Aggregates: aggregates}, Package: "di"}
+	file.addImports("soliton/pkg/framework", g.frameworkImport())
+	if g.framework == DIFrameworkFx {
+		file.addImports("gorm.io/gorm")
+	}
+
+	// 各上下文的层，按上下文名称排序
+	var sets []string
+	type containerField struct{ name, goType, param string }
+	var fields []containerField
+	for _, boundedContext := range contextsOf(aggregates) {
+		members := contextMembers(aggregates, boundedContext)
+		agg := members[0]
+		for _, layer := range []diLayer{
+			layerOf(agg, filepath.Join(infrastructureDir(agg, absOutputDir), "repository"), "repository"),
+			layerOf(agg, filepath.Join(domainDir(agg, absOutputDir), "service", "impl"), "impl"),
+		} {
+			addDIImport(file, layer.alias, layer.importPath)
+			sets = append(sets, layer.alias+"."+g.setName())
+		}
+		for _, member := range members {
+			file.addImports(member.ImportPath)
+			fields = append(fields, containerField{member.Name + "Service", serviceOfType(member), toLowerFirst(member.Name) + "Service"})
+		}
+		if len(queriedMembers(members)) > 0 {
+			layer := layerOf(agg, readModelDir(agg, absOutputDir), "readmodel")
+			addDIImport(file, layer.alias, layer.importPath)
+			sets = append(sets, layer.alias+"."+g.setName())
+			name := toUpperFirst(boundedContext) + "Queries"
+			fields = append(fields, containerField{name, layer.alias + ".Queries", toLowerFirst(name)})
+		}
+	}
+	for _, boundedContext := range contextsOf(exposed) {
+		agg := contextMembers(exposed, boundedContext)[0]
+		layer := layerOf(agg, filepath.Join(interfacesDir(agg, absOutputDir), "handler"), "handler")
+		addDIImport(file, layer.alias, layer.importPath)
+		sets = append(sets, layer.alias+"."+g.setName())
+		name := toUpperFirst(boundedContext) + "Handlers"
+		fields = append(fields, containerField{name, layer.alias + ".Handlers", toLowerFirst(name)})
+	}
+
+	container := file.addType("Container 组装好的全部领域服务、查询处理器（{Context}Queries）和 REST 处理器（{Context}Handlers，用于注册路由）", "Container", "struct")
+	var params, body strings.Builder
+	params.WriteString("\n")
+	body.WriteString("\treturn &Container{\n")
+	for _, f := range fields {
+		container.Fields = append(container.Fields, &GoField{Name: f.name, Type: f.goType})
+		params.WriteString(fmt.Sprintf("\t%s %s,\n", f.param, f.goType))
+		body.WriteString(fmt.Sprintf("\t\t%s: %s,\n", f.name, f.param))
+	}
+	body.WriteString("\t}\n")
+	file.addFunc(&GoFunc{
+		Doc:     "NewContainer 创建 Container，参数由依赖注入框架按类型提供",
+		Name:    "NewContainer",
+		Params:  params.String(),
+		Results: "*Container",
+		Body:    body.String(),
+	})
+
+	g.addProviderSet(file, "全部限界上下文的仓储、领域服务、查询处理器、REST 处理器和 Container", "app",
+		append(sets, g.provide([]string{"NewContainer"})...))
+
+	if g.framework == DIFrameworkFx {
+		file.addFunc(&GoFunc{
+			Doc:     "NewApp 创建组装了 Module 的 fx 应用，db 为仓储使用的数据库连接，options 为追加的选项，\n如 fx.Invoke(func(c *Container) { ... }) 注册路由",
+			Name:    "NewApp",
+			Params:  "db *gorm.DB, options ...fx.Option",
+			Results: "*fx.App",
+			Body:    "\treturn fx.New(append([]fx.Option{fx.Supply(db), Module}, options...)...)\n",
+		})
+	}
+
+	return file
+}
+
+// setName 返回每层导出的集合名：wire 为 ProviderSet，fx 为 Module
+func (g *DIGenerator) setName() string {
+	if g.framework == DIFrameworkFx {
+		return "Module"
+	}
+	return "ProviderSet"
+}
@@ -2,8 +2,8 @@ package generator
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
+	"slices"
 	"soliton/pkg/metadata"
 	"strings"
 )
@@ -15,145 +15,186 @@ import (
 //  2. 关联实体字段不存储（只存储外键ID）
 //  3. 值对象可以展开为多个字段或序列化为JSON
 //  4. 添加 GORM 标签用于数据库映射
+//  5. 软删除字段 DeletedAt 使用 gorm.DeletedAt，Delete 只写入删除时间，查询自动过滤已删除的记录
 //
 // 生成文件：infrastructure/persistence/do/{AggregateName}DO.go
-type DOGenerator struct{}
+//
+// 列名、主键和索引标签取自表结构元数据（见 metadata.Schema），索引名与 SQL 建表脚本一致。
+type DOGenerator struct {
+	fileOutput
+	schema *metadata.Schema
+}
 
 // NewDOGenerator 创建 DO 生成器
 func NewDOGenerator() *DOGenerator {
 	return &DOGenerator{}
 }
 
+// SetSchema 设置表结构元数据，未设置时按单个聚合根计算（不含其他聚合根关系产生的外键索引）
+func (g *DOGenerator) SetSchema(schema *metadata.Schema) {
+	g.schema = schema
+}
+
+// table 返回聚合根对应的表
+func (g *DOGenerator) table(agg *metadata.AggregateMetadata) *metadata.TableMetadata {
+	if g.schema != nil {
+		if table := g.schema.Table(agg.Name); table != nil {
+			return table
+		}
+	}
+	registry := metadata.NewAggregateMetadataRegistry()
+	registry.Register(agg)
+	return metadata.NewSchema(registry, metadata.DefaultDialect()).Table(agg.Name)
+}
+
 // Generate 为聚合根生成数据对象
 func (g *DOGenerator) Generate(agg *metadata.AggregateMetadata, outputDir string) error {
-	// 创建输出目录（infrastructure 与 domain 平级）
-	doDir := filepath.Join(filepath.Dir(outputDir), "infrastructure", "do")
-	if err := os.MkdirAll(doDir, 0755); err != nil {
-		return fmt.Errorf("创建输出目录失败: %w", err)
-	}
+	// 输出目录（infrastructure 与 domain 平级）
+	doDir := filepath.Join(infrastructureDir(agg, outputDir), "do")
 
 	// 生成文件路径
 	fileName := fmt.Sprintf("%sDO.go", agg.Name)
 	filePath := filepath.Join(doDir, fileName)
 
-	// 生成代码
-	code := g.generateCode(agg)
-
 	// 写入文件
-	if err := os.WriteFile(filePath, []byte(code), 0644); err != nil {
+	if err := g.writeTemplate(filePath, "do", g.generateFile(agg)); err != nil {
 		return fmt.Errorf("写入文件失败: %w", err)
 	}
 
 	return nil
 }
 
-// generateCode 生成 DO 代码
-func (g *DOGenerator) generateCode(agg *metadata.AggregateMetadata) string {
-	var sb strings.Builder
-
-	// 文件头
-	sb.WriteString("// Code generated by soliton. DO NOT EDIT.\n\n")
-	sb.WriteString("package do\n\n")
+// generateFile 生成 DO 文件的模板数据
+func (g *DOGenerator) generateFile(agg *metadata.AggregateMetadata) *GoFileData {
+	file := &GoFileData{
+		TemplateData: TemplateData{Aggregate: agg, Context: agg.Context()},
+		Package:      "do",
+	}
 
-	// 导入
-	needTimeImport := false
-	for _, field := range agg.Fields {
-		if field.Type == "time.Time" || field.Type == "*time.Time" {
-			needTimeImport = true
-			break
+	// 导入：time.Time、gorm.DeletedAt 以及已知标量类型（如 uuid.UUID）所在的包
+	softDelete := softDeleteField(agg)
+	importSet := make(map[string]bool)
+	for _, field := range agg.MappedFields() {
+		if field.Annotations.IsEntity {
+			continue
+		}
+		if field == softDelete {
+			importSet["gorm.io/gorm"] = true
+			continue
+		}
+		columnFields := []*metadata.FieldMetadata{field}
+		if field.Annotations.IsValueObject {
+			// 展开的值对象按其字段生成列
+			columnFields = field.Flattened
+		}
+		for _, f := range columnFields {
+			if f.Type == "time.Time" {
+				importSet["time"] = true
+			}
+			if f.ScalarType != nil && f.ScalarType.ImportPath != "" {
+				importSet[f.ScalarType.ImportPath] = true
+			}
 		}
 	}
-
-	if needTimeImport {
-		sb.WriteString("import \"time\"\n\n")
+	for importPath := range importSet {
+		file.addImports(importPath)
 	}
 
 	// 结构体定义
-	sb.WriteString(fmt.Sprintf("// %sDO %s 数据对象\n", agg.Name, agg.Name))
-	sb.WriteString(fmt.Sprintf("type %sDO struct {\n", agg.Name))
-
-	// 生成字段
-	for _, field := range agg.Fields {
+	var fields []*GoField
+	table := g.table(agg)
+	for _, field := range agg.MappedFields() {
 		// 跳过关联实体字段（+soliton:entity）
 		if field.Annotations.IsEntity {
 			continue
 		}
 
-		// 生成字段
-		fieldCode := g.generateField(field, agg)
-		if fieldCode != "" {
-			sb.WriteString(fieldCode)
+		// 软删除字段
+		if field == softDelete {
+			fields = append(fields, &GoField{
+				Name: field.Name,
+				Type: "gorm.DeletedAt",
+				Tag:  fmt.Sprintf(`gorm:"column:%s%s"`, field.Column(), g.generateGORMTags(field, table)),
+			})
+			continue
 		}
-	}
 
-	sb.WriteString("}\n\n")
+		fields = append(fields, g.generateFields(field, table)...)
+	}
+	file.addType(fmt.Sprintf("%sDO %s 数据对象", agg.Name, agg.Name), agg.Name+"DO", "struct", fields...)
 
 	// TableName 方法
-	tableName := toSnakeCase(agg.Name) + "s"
-	sb.WriteString(fmt.Sprintf("// TableName 指定表名\n"))
-	sb.WriteString(fmt.Sprintf("func (%sDO) TableName() string {\n", agg.Name))
-	sb.WriteString(fmt.Sprintf("\treturn \"%s\"\n", tableName))
-	sb.WriteString("}\n")
-
-	return sb.String()
+	file.addFunc(&GoFunc{
+		Doc:      "TableName 指定表名",
+		Receiver: agg.Name + "DO",
+		Name:     "TableName",
+		Results:  "string",
+		Body:     fmt.Sprintf("\treturn \"%s\"\n", agg.Table()),
+	})
+
+	return file
 }
 
-// generateField 生成单个字段
-func (g *DOGenerator) generateField(field *metadata.FieldMetadata, agg *metadata.AggregateMetadata) string {
-	var sb strings.Builder
-
-	// 字段注释
-	if field.Annotations.IsRef {
-		sb.WriteString(fmt.Sprintf("\t%s %s `gorm:\"column:%s",
-			field.Name, field.Type, field.DBTag))
-	} else if field.Annotations.IsValueObject {
+// generateFields 生成字段对应的 DO 字段，展开的值对象对应多个字段
+func (g *DOGenerator) generateFields(field *metadata.FieldMetadata, table *metadata.TableMetadata) []*GoField {
+	if field.Annotations.IsValueObject {
 		// 值对象处理
-		return g.generateValueObjectField(field)
-	} else {
-		// 普通字段
-		fieldType := field.Type
-		if field.IsPointer {
-			fieldType = "*" + fieldType
-		}
-
-		sb.WriteString(fmt.Sprintf("\t%s %s `gorm:\"column:%s",
-			field.Name, fieldType, field.DBTag))
+		return g.generateValueObjectFields(field, table)
 	}
 
-	// 添加 GORM 标签
-	tags := g.generateGORMTags(field, agg)
-	if tags != "" {
-		sb.WriteString(tags)
+	// 普通字段和外部引用（可空的外部引用，如树形结构根节点的 ParentID，保留指针）
+	tag := fmt.Sprintf(`gorm:"column:%s%s"`, field.Column(), g.generateGORMTags(field, table))
+
+	// 敏感字段标签，仓储读写时由 framework.EncryptionCodec 编解码
+	if field.Annotations.Sensitive != "" {
+		tag += fmt.Sprintf(` sensitive:"%s"`, field.Annotations.Sensitive)
 	}
 
-	sb.WriteString("\"`\n")
+	return []*GoField{{Name: field.Name, Type: doFieldType(field), Tag: tag}}
+}
 
-	return sb.String()
+// doFieldType 返回字段在数据对象中的类型
+// 领域模型包中基于基础类型的命名类型（如 const 块定义的枚举 OrderStatus）在 do 包中不可见，使用其底层类型，由转换器转换
+func doFieldType(field *metadata.FieldMetadata) string {
+	if !isNamedBasicType(field) {
+		return field.GoType()
+	}
+	if field.IsPointer {
+		return "*" + field.UnderlyingType
+	}
+	return field.UnderlyingType
+}
+
+// isNamedBasicType 判断字段是否为领域模型包中基于基础类型的命名类型（含指针），如 OrderStatus、*Level
+func isNamedBasicType(field *metadata.FieldMetadata) bool {
+	return field.UnderlyingType != "" && field.Type != field.UnderlyingType && !strings.Contains(field.Type, ".") &&
+		!field.IsSlice && !field.IsMap && !field.IsArray
 }
 
 // generateGORMTags 生成 GORM 标签
-func (g *DOGenerator) generateGORMTags(field *metadata.FieldMetadata, agg *metadata.AggregateMetadata) string {
+func (g *DOGenerator) generateGORMTags(field *metadata.FieldMetadata, table *metadata.TableMetadata) string {
 	var tags []string
 
-	// 主键
-	if agg.IDField != nil && field.Name == agg.IDField.Name {
-		tags = append(tags, "primaryKey")
-		tags = append(tags, "autoIncrement")
-	}
-
-	// 唯一索引
-	if field.Annotations.IsUnique {
-		tags = append(tags, fmt.Sprintf("uniqueIndex:idx_%s", field.DBTag))
+	column := table.ColumnOf(field)
+	if column != nil {
+		// 主键（复合主键的每个组成字段都标记 primaryKey）
+		if column.PrimaryKey {
+			tags = append(tags, "primaryKey")
+		}
+		// 只有 auto 策略使用数据库自增，uuid/snowflake/manual 由应用生成
+		if column.AutoIncrement {
+			tags = append(tags, "autoIncrement")
+		}
 	}
 
-	// 普通索引
-	if field.Annotations.IsIndex {
-		tags = append(tags, fmt.Sprintf("index:idx_%s", field.DBTag))
+	// 自定义列类型
+	if field.ColumnType != "" {
+		tags = append(tags, fmt.Sprintf("type:%s", field.ColumnType))
 	}
 
-	// 外键索引
-	if field.Annotations.IsRef {
-		tags = append(tags, fmt.Sprintf("index:idx_%s", field.DBTag))
+	// 索引：唯一索引、组合唯一索引、普通索引、外键列和软删除列的索引
+	if column != nil {
+		tags = append(tags, indexTags(table, column.Name)...)
 	}
 
 	// 必填字段
@@ -161,9 +202,9 @@ func (g *DOGenerator) generateGORMTags(field *metadata.FieldMetadata, agg *metad
 		tags = append(tags, "not null")
 	}
 
-	// 软删除字段
-	if field.Name == "DeletedAt" {
-		tags = append(tags, "index:idx_deleted_at")
+	// 不可变字段：只允许创建时写入，Update/UpdateBatch 不会覆盖
+	if field.Annotations.IsImmutable {
+		tags = append(tags, "<-:create")
 	}
 
 	if len(tags) == 0 {
@@ -173,39 +214,72 @@ func (g *DOGenerator) generateGORMTags(field *metadata.FieldMetadata, agg *metad
 	return ";" + strings.Join(tags, ";")
 }
 
-// generateValueObjectField 生成值对象字段
-func (g *DOGenerator) generateValueObjectField(field *metadata.FieldMetadata) string {
-	// 如果策略是 JSON，则序列化为字符串
-	if field.Annotations.Strategy == "json" {
-		return fmt.Sprintf("\t%s string `gorm:\"column:%s;type:text\"`\n",
-			field.Name, field.DBTag)
+// indexTags 返回列所在索引的 GORM 标签，如 uniqueIndex:uk_users_email、index:idx_orders_user_id
+// 组合索引标注在每个组成列上，priority 决定列顺序
+func indexTags(table *metadata.TableMetadata, column string) []string {
+	var tags []string
+	for _, index := range table.IndexesOf(column) {
+		tag := "index:" + index.Name
+		if index.Unique {
+			tag = "uniqueIndex:" + index.Name
+		}
+		if len(index.Columns) > 1 {
+			tag += fmt.Sprintf(",priority:%d", slices.Index(index.Columns, column)+1)
+		}
+		tags = append(tags, tag)
 	}
-
-	// 默认策略：展开为多个字段
-	// 这里简化处理，实际应该递归解析值对象的字段
-	// 暂时返回空，表示跳过
-	return ""
+	return tags
 }
 
-// toSnakeCase 转换为蛇形命名
-func toSnakeCase(s string) string {
-	var result []rune
-	runes := []rune(s)
+// generateValueObjectFields 生成值对象字段
+func (g *DOGenerator) generateValueObjectFields(field *metadata.FieldMetadata, table *metadata.TableMetadata) []*GoField {
+	// 如果策略是 JSON，则序列化为字符串，列类型与建表脚本一致（MySQL、SQLite 为 text，PostgreSQL 为 jsonb）
+	if field.Annotations.Strategy == "json" {
+		columnType := "text"
+		if column := table.ColumnOf(field); column != nil {
+			columnType = strings.ToLower(column.Type)
+		}
+		permission := ""
+		if field.Annotations.IsImmutable {
+			permission = ";<-:create"
+		}
+		return []*GoField{{
+			Name: field.Name,
+			Type: "string",
+			Tag:  fmt.Sprintf(`gorm:"column:%s;type:%s%s"`, field.Column(), columnType, permission),
+		}}
+	}
+
+	// 展开策略：值对象的每个字段对应一列，字段名和列名带上值对象字段的前缀
+	if field.Annotations.Strategy == metadata.ValueObjectFlatten {
+		var fields []*GoField
+		for _, sub := range field.Flattened {
+			var tags []string
+			if sub.ColumnType != "" {
+				tags = append(tags, fmt.Sprintf("type:%s", sub.ColumnType))
+			}
+			tags = append(tags, indexTags(table, sub.Column())...)
+			// 指针值对象为 nil 时各列均为空，不能加非空约束
+			if sub.Annotations.IsRequired && !field.IsPointer {
+				tags = append(tags, "not null")
+			}
+			if field.Annotations.IsImmutable || sub.Annotations.IsImmutable {
+				tags = append(tags, "<-:create")
+			}
 
-	for i := 0; i < len(runes); i++ {
-		r := runes[i]
-		if r >= 'A' && r <= 'Z' {
-			if i > 0 && runes[i-1] >= 'a' && runes[i-1] <= 'z' {
-				result = append(result, '_')
+			gormTag := "column:" + sub.Column()
+			if len(tags) > 0 {
+				gormTag += ";" + strings.Join(tags, ";")
 			}
-			if i > 0 && i < len(runes)-1 &&
-				runes[i-1] >= 'A' && runes[i-1] <= 'Z' &&
-				runes[i+1] >= 'a' && runes[i+1] <= 'z' {
-				result = append(result, '_')
+			tag := fmt.Sprintf(`gorm:"%s"`, gormTag)
+			if sub.Annotations.Sensitive != "" {
+				tag += fmt.Sprintf(` sensitive:"%s"`, sub.Annotations.Sensitive)
 			}
+			fields = append(fields, &GoField{Name: field.FlattenedName(sub), Type: doFieldType(sub), Tag: tag})
 		}
-		result = append(result, r)
+		return fields
 	}
 
-	return strings.ToLower(string(result))
+	// 未声明策略的结构体值对象暂不映射为列
+	return nil
 }
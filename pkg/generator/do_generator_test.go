@@ -0,0 +1,49 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+const enumModelSource = `package model
+
+// DeviceState 设备状态
+type DeviceState string
+
+const (
+	DeviceStateOnline  DeviceState = "ONLINE"
+	DeviceStateOffline DeviceState = "OFFLINE"
+)
+
+// Level 告警级别
+type Level int
+
+const (
+	LevelLow Level = iota + 1
+	LevelHigh
+)
+
+// Device 设备
+//
+// +soliton:aggregate
+type Device struct {
+	ID        int64        ` + "`db:\"id\"`" + `
+	State     DeviceState  ` + "`db:\"state\"`" + `
+	Level     Level        ` + "`db:\"level\"`" + `
+	PrevState *DeviceState ` + "`db:\"prev_state\"`" + `
+}
+`
+
+func TestDOGeneratorUsesEnumUnderlyingType(t *testing.T) {
+	agg := parseTestModel(t, "Device", enumModelSource)
+
+	code := renderTestTemplate(t, "do", NewDOGenerator().generateFile(agg))
+	assertGoSource(t, code,
+		"State string `gorm:\"column:state\"`",
+		"Level int `gorm:\"column:level\"`",
+		"PrevState *string `gorm:\"column:prev_state\"`",
+	)
+	if strings.Contains(code, "DeviceState") {
+		t.Errorf("DO 不应引用领域模型中的枚举类型:\n%s", code)
+	}
+}
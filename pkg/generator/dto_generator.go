@@ -0,0 +1,272 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"soliton/pkg/metadata"
+	"strings"
+)
+
+// DTOGenerator 数据传输对象（DTO）生成器
+//
+// 为通过 REST 暴露的聚合根生成与领域对象分离的请求、响应结构体及映射函数，处理器只通过它们读写领域对象：
+//   - Create{AggregateName}Request：新增的请求体，主键（manual 策略、复合主键）和 +soliton:immutable 字段只在新增时写入
+//   - Update{AggregateName}Request：更新的请求体，不含主键和 +soliton:immutable 字段
+//   - {AggregateName}Response：响应，不含版本号、软删除字段和关联实体，+soliton:sensitive 字段以 framework.MaskString 脱敏
+//
+// 请求中不含关联实体和由仓储维护的审计字段（创建和更新时间、创建人、更新人、版本、删除时间）。
+// 请求体先由 New{...}Request 以实体的当前值填充再解码 JSON，因此请求体中未出现的字段保持原值（新增时为默认值）；
+// ApplyTo 将请求体写回实体。值对象和枚举字段沿用领域模型中的类型。
+//
+// 生成文件：interfaces/dto/{AggregateName}DTO.go，声明了 +soliton:context 的聚合根输出到 interfaces/{context}/dto。
+type DTOGenerator struct {
+	fileOutput
+}
+
+// NewDTOGenerator 创建 DTO 生成器
+func NewDTOGenerator() *DTOGenerator {
+	return &DTOGenerator{}
+}
+
+// Generate 为聚合根生成 DTO 和映射函数
+func (g *DTOGenerator) Generate(agg *metadata.AggregateMetadata, outputDir string) error {
+	absOutputDir, _ := filepath.Abs(outputDir)
+	filePath := filepath.Join(dtoDir(agg, absOutputDir), fmt.Sprintf("%sDTO.go", agg.Name))
+
+	if err := g.writeTemplate(filePath, "dto", g.generateFile(agg)); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return nil
+}
+
+// dtoDir 返回聚合根 DTO 所在的目录，如 interfaces/ordering/dto
+func dtoDir(agg *metadata.AggregateMetadata, outputDir string) string {
+	return filepath.Join(interfacesDir(agg, outputDir), "dto")
+}
+
+// dtoField 请求或响应中的字段
+type dtoField struct {
+	field    *metadata.FieldMetadata
+	goType   string // 带包名的类型，如 "*model.Address"
+	readOnly bool   // 只在新增时写入：主键和 +soliton:immutable 字段
+}
+
+// requestFields 返回请求体中的字段：关联实体、审计字段（创建和更新时间、创建人、更新人、版本、删除时间）不可写入，
+// 主键只在由调用方设置（manual 策略、复合主键）时可以写入
+func requestFields(agg *metadata.AggregateMetadata) []*dtoField {
+	var fields []*dtoField
+	for _, field := range agg.MappedFields() {
+		if field.Annotations.IsEntity || isAuditField(agg, field) {
+			continue
+		}
+		isKey := agg.InPrimaryKey(field)
+		if isKey && agg.IDStrategy != metadata.IDStrategyManual {
+			continue
+		}
+		fields = append(fields, &dtoField{
+			field:    field,
+			goType:   qualifyType(field.GoType(), agg.PackageName),
+			readOnly: isKey || field.Annotations.IsImmutable,
+		})
+	}
+	return fields
+}
+
+// updateFields 返回更新请求体中的字段：requestFields 中只在新增时写入的字段之外的字段
+func updateFields(agg *metadata.AggregateMetadata) []*dtoField {
+	var fields []*dtoField
+	for _, f := range requestFields(agg) {
+		if !f.readOnly {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// responseFields 返回响应中的字段：关联实体和软删除字段之外的全部字段
+func responseFields(agg *metadata.AggregateMetadata) []*dtoField {
+	var fields []*dtoField
+	for _, field := range agg.MappedFields() {
+		if field.Annotations.IsEntity || agg.BaseEntity != nil && field == agg.BaseEntity.DeletedAtField {
+			continue
+		}
+		fields = append(fields, &dtoField{field: field, goType: qualifyType(field.GoType(), agg.PackageName)})
+	}
+	return fields
+}
+
+// responseDTOFields 返回 {AggregateName}Response 中的字段：responseFields 中除版本号（乐观锁由仓储维护）之外的字段
+func responseDTOFields(agg *metadata.AggregateMetadata) []*dtoField {
+	var fields []*dtoField
+	for _, f := range responseFields(agg) {
+		if agg.BaseEntity != nil && f.field == agg.BaseEntity.VersionField {
+			continue
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// isAuditField 判断字段是否为由仓储维护的基础实体字段（创建和更新时间、创建人、更新人、版本、删除时间）
+func isAuditField(agg *metadata.AggregateMetadata, field *metadata.FieldMetadata) bool {
+	base := agg.BaseEntity
+	if base == nil {
+		return false
+	}
+	return slices.Contains([]*metadata.FieldMetadata{
+		base.CreatedAtField, base.UpdatedAtField, base.CreatedByField, base.UpdatedByField, base.VersionField, base.DeletedAtField,
+	}, field)
+}
+
+// generateFile 生成聚合根的请求、响应结构体和映射函数的模板数据
+func (g *DTOGenerator) generateFile(agg *metadata.AggregateMetadata) *GoFileData {
+	ops := apiOperations(agg)
+	creates, updates, responses := requestFields(agg), updateFields(agg), responseDTOFields(agg)
+	hasCreate, hasUpdate := slices.Contains(ops, metadata.APIOpCreate), slices.Contains(ops, metadata.APIOpUpdate)
+
+	file := &GoFileData{
+		TemplateData: TemplateData{Aggregate: agg, Context: agg.Context()},
+		Package:      "dto",
+	}
+
+	// 导入：领域模型，字段中的 time.Time 和已知标量类型（如 uuid.UUID），敏感字段脱敏用到的 framework
+	file.addImports(agg.ImportPath)
+	for _, f := range append(slices.Clone(creates), responses...) {
+		if strings.HasPrefix(f.field.Type, "time.") {
+			file.addImports("time")
+		}
+		if f.field.ScalarType != nil && f.field.ScalarType.ImportPath != "" {
+			file.addImports(f.field.ScalarType.ImportPath)
+		}
+	}
+	if slices.ContainsFunc(responses, isSensitiveDTOField) {
+		file.addImports("soliton/pkg/framework")
+	}
+
+	entityType := fmt.Sprintf("*%s.%s", agg.PackageName, agg.Name)
+
+	// 结构体
+	if hasCreate {
+		file.addType(fmt.Sprintf("Create%sRequest 新增 %s 的请求体", agg.Name, agg.Name), "Create"+agg.Name+"Request", "struct", dtoStructFields(creates)...)
+	}
+	if hasUpdate {
+		file.addType(fmt.Sprintf("Update%sRequest 更新 %s 的请求体，主键和 +soliton:immutable 字段不可修改", agg.Name, agg.Name),
+			"Update"+agg.Name+"Request", "struct", dtoStructFields(updates)...)
+	}
+	file.addType(fmt.Sprintf("%sResponse %s 的响应，不含版本号和软删除字段，敏感字段已脱敏", agg.Name, agg.Name), agg.Name+"Response", "struct", dtoStructFields(responses)...)
+
+	// 映射函数
+	for _, request := range []struct {
+		enabled bool
+		name    string
+		action  string
+		fields  []*dtoField
+	}{
+		{hasCreate, "Create" + agg.Name + "Request", "新增", creates},
+		{hasUpdate, "Update" + agg.Name + "Request", "更新", updates},
+	} {
+		if !request.enabled {
+			continue
+		}
+		var literal strings.Builder
+		writeDTOLiteral(&literal, request.name, request.fields, nil)
+		file.addFunc(&GoFunc{
+			Doc:     fmt.Sprintf("New%s 以实体的当前值创建%s请求体，解码时请求体中未出现的字段保持原值", request.name, request.action),
+			Name:    "New" + request.name,
+			Params:  "entity " + entityType,
+			Results: request.name,
+			Body:    literal.String(),
+		})
+
+		var apply strings.Builder
+		for _, f := range request.fields {
+			apply.WriteString(fmt.Sprintf("\tentity.%s = r.%s\n", f.field.Name, f.field.Name))
+		}
+		file.addFunc(&GoFunc{
+			Doc:      "ApplyTo 将请求体写入实体",
+			Receiver: "r *" + request.name,
+			Name:     "ApplyTo",
+			Params:   "entity " + entityType,
+			Body:     apply.String(),
+		})
+	}
+
+	var literal strings.Builder
+	writeDTOLiteral(&literal, agg.Name+"Response", responses, isSensitiveDTOField)
+	file.addFunc(&GoFunc{
+		Doc:     fmt.Sprintf("New%sResponse 将实体转换为响应", agg.Name),
+		Name:    fmt.Sprintf("New%sResponse", agg.Name),
+		Params:  "entity " + entityType,
+		Results: agg.Name + "Response",
+		Body:    literal.String(),
+	})
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("\tresponses := make([]%sResponse, len(entities))\n", agg.Name))
+	sb.WriteString("\tfor i, entity := range entities {\n")
+	sb.WriteString(fmt.Sprintf("\t\tresponses[i] = New%sResponse(entity)\n", agg.Name))
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn responses\n")
+	file.addFunc(&GoFunc{
+		Doc:     fmt.Sprintf("New%sResponses 将实体列表转换为响应", agg.Name),
+		Name:    fmt.Sprintf("New%sResponses", agg.Name),
+		Params:  "entities []" + entityType,
+		Results: fmt.Sprintf("[]%sResponse", agg.Name),
+		Body:    sb.String(),
+	})
+
+	return file
+}
+
+// isSensitiveDTOField 判断字段是否为敏感字段（+soliton:sensitive），敏感字段只能是 string 或 *string
+func isSensitiveDTOField(f *dtoField) bool {
+	return f.field.Annotations.Sensitive != ""
+}
+
+// dtoStructFields 返回请求或响应结构体的字段，字段的 JSON 名称见 jsonName
+func dtoStructFields(fields []*dtoField) []*GoField {
+	structFields := make([]*GoField, len(fields))
+	for i, f := range fields {
+		structFields[i] = &GoField{Name: f.field.Name, Type: f.goType, Tag: fmt.Sprintf(`json:"%s"`, jsonName(f.field.Name))}
+	}
+	return structFields
+}
+
+// writeDTOLiteral 写出由实体字段构造请求或响应并返回的语句，masked 为 nil 或返回 false 的字段原样复制，
+// 其余字段以 framework.MaskString 脱敏（指针字段为 nil 时保持 nil）
+func writeDTOLiteral(sb *strings.Builder, name string, fields []*dtoField, masked func(*dtoField) bool) {
+	var lines [][2]string
+	var pointers []*dtoField
+	for _, f := range fields {
+		switch {
+		case masked == nil || !masked(f):
+			lines = append(lines, [2]string{f.field.Name, "entity." + f.field.Name})
+		case f.field.IsPointer:
+			pointers = append(pointers, f)
+		default:
+			lines = append(lines, [2]string{f.field.Name, fmt.Sprintf("framework.MaskString(entity.%s)", f.field.Name)})
+		}
+	}
+
+	literal := name + "{}"
+	if len(lines) > 0 {
+		var body strings.Builder
+		for _, line := range lines {
+			body.WriteString(fmt.Sprintf("\t\t%s: %s,\n", line[0], line[1]))
+		}
+		literal = fmt.Sprintf("%s{\n%s\t}", name, body.String())
+	}
+	if len(pointers) == 0 {
+		sb.WriteString(fmt.Sprintf("\treturn %s\n", literal))
+		return
+	}
+	sb.WriteString(fmt.Sprintf("\tresult := %s\n", literal))
+	for _, f := range pointers {
+		sb.WriteString(fmt.Sprintf("\tif entity.%s != nil {\n", f.field.Name))
+		sb.WriteString(fmt.Sprintf("\t\tmasked := framework.MaskString(*entity.%s)\n", f.field.Name))
+		sb.WriteString(fmt.Sprintf("\t\tresult.%s = &masked\n", f.field.Name))
+		sb.WriteString("\t}\n")
+	}
+	sb.WriteString("\treturn result\n")
+}
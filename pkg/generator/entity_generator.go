@@ -3,7 +3,11 @@ package generator
 import (
 	"bufio"
 	"fmt"
+	"go/token"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"soliton/pkg/metadata"
 	"strings"
 )
@@ -12,16 +16,20 @@ import (
 //
 // 为每个聚合根自动生成 Entity 接口的实现方法：
 //   - GetID() int64
-
 //   - SetID(id int64)
 //   - IsNew() bool
+//   - SetCreatedBy/SetUpdatedBy（存在 CreatedBy/UpdatedBy 字段时）
+//   - New{AggregateName}(必填字段...) 工厂函数：按 +soliton:default 初始化字段、初始化切片和 map（未自行定义同名函数时）
 //
-// 生成策略：直接追加到聚合根文件末尾（充血模型）
-type EntityGenerator struct{}
+// 生成策略：直接追加到聚合根文件末尾（充血模型），同一文件中的多个聚合根的生成代码依次追加
+type EntityGenerator struct {
+	fileOutput
+	generated map[string]string // 本次运行中已追加到各文件的生成代码（不含分隔标记）
+}
 
 // NewEntityGenerator 创建 Entity 生成器
 func NewEntityGenerator() *EntityGenerator {
-	return &EntityGenerator{}
+	return &EntityGenerator{generated: make(map[string]string)}
 }
 
 // Generate 为聚合根生成 Entity 接口实现（追加到原文件）
@@ -35,23 +43,39 @@ func (g *EntityGenerator) Generate(agg *metadata.AggregateMetadata) error {
 	// 移除旧的生成代码（如果存在）
 	content = g.removeGeneratedCode(content)
 
-	// 生成新的 Entity 方法代码
-	generatedCode := g.generateEntityMethods(agg)
+	// 生成新的 Entity 方法代码，接在同一文件中先生成的聚合根之后
+	file := &GoFileData{
+		TemplateData: TemplateData{Path: agg.FilePath, Aggregate: agg, Context: agg.Context()},
+		Package:      agg.PackageName,
+	}
+	g.generateEntityMethods(file, agg)
+	if !g.hasFactory(agg, content) {
+		file.addFunc(g.generateFactory(agg))
+	}
+	rendered, err := g.render("entity", file)
+	if err != nil {
+		return err
+	}
+	generatedCode := g.generated[agg.FilePath] + rendered
+	g.generated[agg.FilePath] = generatedCode
 
-	// 追加到文件末尾
-	finalContent := content + "\n" + generatedCode
+	// 追加到文件末尾，与手写代码之间空一行
+	finalContent := content + "\n\n" + generatedMarker + "\n// Code generated by soliton. DO NOT EDIT.\n" + generatedCode
 
 	// 写回文件
 	if err := g.writeFile(agg.FilePath, finalContent); err != nil {
 		return fmt.Errorf("写入文件失败: %w", err)
 	}
 
-	fmt.Printf("✅ 已为 %s 生成 Entity 方法\n", agg.Name)
 	return nil
 }
 
-// readFile 读取文件内容
+// readFile 读取文件内容，预览模式下优先读取本次运行中已记录的内容
 func (g *EntityGenerator) readFile(filePath string) (string, error) {
+	if content, ok := g.pendingContent(filePath); ok {
+		return string(content), nil
+	}
+
 	file, err := os.Open(filePath)
 	if err != nil {
 		return "", err
@@ -72,34 +96,46 @@ func (g *EntityGenerator) readFile(filePath string) (string, error) {
 	return sb.String(), nil
 }
 
-// writeFile 写入文件
-func (g *EntityGenerator) writeFile(filePath string, content string) error {
-	return os.WriteFile(filePath, []byte(content), 0644)
-}
+// generatedMarker 领域模型文件中生成代码的分隔标记，标记之后的内容在每次生成时重写
+const generatedMarker = "// ========== 以下代码由 soliton 自动生成，请勿手动修改 =========="
 
 // removeGeneratedCode 移除旧的生成代码
 func (g *EntityGenerator) removeGeneratedCode(content string) string {
-	marker := "// ========== 以下代码由 soliton 自动生成，请勿手动修改 =========="
-
 	// 查找分隔标记
-	if idx := strings.Index(content, marker); idx != -1 {
+	if idx := strings.Index(content, generatedMarker); idx != -1 {
 		// 删除标记及其之后的所有内容
 		content = content[:idx]
-		// 移除末尾多余的空行
-		content = strings.TrimRight(content, "\n")
 	}
 
-	return content
+	// 移除末尾多余的空行，无论是否已有生成代码，重新生成的结果都相同
+	return strings.TrimRight(content, "\n")
 }
 
-// generateEntityMethods 生成 Entity 接口实现代码
-func (g *EntityGenerator) generateEntityMethods(agg *metadata.AggregateMetadata) string {
-	var sb strings.Builder
+// generateEntityMethods 将 Entity 接口的实现添加到 file
+func (g *EntityGenerator) generateEntityMethods(file *GoFileData, agg *metadata.AggregateMetadata) {
+	// 接收者名称（聚合根名称首字母小写）
+	receiver := strings.ToLower(string(agg.Name[0]))
 
-	// 分隔标记
-	sb.WriteString("// ========== 以下代码由 soliton 自动生成，请勿手动修改 ==========\n")
-	sb.WriteString("// Code generated by soliton. DO NOT EDIT.\n\n")
+	if agg.IsCompositeKey() {
+		g.generateCompositeKeyMethods(file, agg, receiver)
+	} else {
+		g.generateIDMethods(file, agg, receiver)
+	}
 
+	// 审计人方法（实现 framework.CreatedBySetter / UpdatedBySetter）
+	if agg.BaseEntity != nil {
+		if agg.BaseEntity.HasCreatedBy {
+			g.generateOperatorSetter(file, agg, receiver, "SetCreatedBy", "设置创建人", agg.BaseEntity.CreatedByField)
+		}
+		// 不可变的 UpdatedBy 不生成设置方法（字段校验会报告该冲突）
+		if updatedBy := agg.BaseEntity.UpdatedByField; agg.BaseEntity.HasUpdatedBy && (updatedBy == nil || !updatedBy.Annotations.IsImmutable) {
+			g.generateOperatorSetter(file, agg, receiver, "SetUpdatedBy", "设置更新人", agg.BaseEntity.UpdatedByField)
+		}
+	}
+}
+
+// generateIDMethods 将单一主键的 GetID、SetID、IsNew 方法添加到 file
+func (g *EntityGenerator) generateIDMethods(file *GoFileData, agg *metadata.AggregateMetadata, receiver string) {
 	// 确定 ID 字段名称和类型
 	idFieldName := "ID"
 	idFieldType := "int64"
@@ -107,37 +143,288 @@ func (g *EntityGenerator) generateEntityMethods(agg *metadata.AggregateMetadata)
 		idFieldName = agg.IDField.Name
 		idFieldType = agg.IDField.Type
 	}
+	// 主键类型（EntityOf[K] 中的 K）
+	keyType := agg.IDKeyType()
+	zeroValue := "0"
+	if keyType == "string" {
+		zeroValue = `""`
+	}
 
-	// 接收者名称（聚合根名称首字母小写）
-	receiver := strings.ToLower(string(agg.Name[0]))
+	// GetID 方法
+	getID := fmt.Sprintf("\treturn %s.%s\n", receiver, idFieldName)
+	if idFieldType != keyType {
+		// 如果 ID 字段不是主键类型（如 int），需要类型转换
+		getID = fmt.Sprintf("\treturn %s(%s.%s)\n", keyType, receiver, idFieldName)
+	}
+	file.addFunc(entityMethod(agg, receiver, "GetID 获取实体ID", "GetID", "", keyType, getID))
+
+	// SetID 方法
+	setID := fmt.Sprintf("\t%s.%s = id\n", receiver, idFieldName)
+	if idFieldType != keyType {
+		// 如果 ID 字段不是主键类型，需要类型转换
+		setID = fmt.Sprintf("\t%s.%s = %s(id)\n", receiver, idFieldName, idFieldType)
+	}
+	file.addFunc(entityMethod(agg, receiver, "SetID 设置实体ID", "SetID", "id "+keyType, "", setID))
+
+	// IsNew 方法
+	file.addFunc(entityMethod(agg, receiver, "IsNew 判断是否为新实体", "IsNew", "", "bool",
+		fmt.Sprintf("\treturn %s.%s == %s\n", receiver, idFieldName, zeroValue)))
+}
+
+// entityMethod 返回聚合根的方法，receiver 为接收者名
+func entityMethod(agg *metadata.AggregateMetadata, receiver, doc, name, params, results, body string) *GoFunc {
+	return &GoFunc{
+		Doc:      doc,
+		Receiver: fmt.Sprintf("%s *%s", receiver, agg.Name),
+		Name:     name,
+		Params:   params,
+		Results:  results,
+		Body:     body,
+	}
+}
+
+// generateCompositeKeyMethods 将复合主键结构体及 GetID、SetID、IsNew 方法添加到 file
+//
+// 主键结构体实现 framework.CompositeKey，作为 EntityOf[K] 的 K；所有主键字段均为零值时视为新实体。
+func (g *EntityGenerator) generateCompositeKeyMethods(file *GoFileData, agg *metadata.AggregateMetadata, receiver string) {
+	keyType := agg.IDKeyType()
+
+	// 主键结构体
+	fields := make([]*GoField, len(agg.PrimaryKey))
+	for i, field := range agg.PrimaryKey {
+		fields[i] = &GoField{Name: field.Name, Type: field.Type}
+	}
+	file.addType(fmt.Sprintf("%s %s 的复合主键", keyType, agg.Name), keyType, "struct", fields...)
+
+	columns := make([]string, len(agg.PrimaryKey))
+	values := make([]string, len(agg.PrimaryKey))
+	for i, field := range agg.PrimaryKey {
+		columns[i] = fmt.Sprintf("%q", field.Column())
+		values[i] = "k." + field.Name
+	}
+
+	file.addFunc(&GoFunc{
+		Doc:      "KeyColumns 返回组成主键的列名",
+		Receiver: "k " + keyType,
+		Name:     "KeyColumns",
+		Results:  "[]string",
+		Body:     fmt.Sprintf("\treturn []string{%s}\n", strings.Join(columns, ", ")),
+	})
+	file.addFunc(&GoFunc{
+		Doc:      "KeyValues 返回各主键列的值",
+		Receiver: "k " + keyType,
+		Name:     "KeyValues",
+		Results:  "[]any",
+		Body:     fmt.Sprintf("\treturn []any{%s}\n", strings.Join(values, ", ")),
+	})
 
 	// GetID 方法
-	sb.WriteString("// GetID 获取实体ID\n")
-	sb.WriteString(fmt.Sprintf("func (%s *%s) GetID() int64 {\n", receiver, agg.Name))
-	if idFieldType == "int64" {
-		sb.WriteString(fmt.Sprintf("\treturn %s.%s\n", receiver, idFieldName))
-	} else {
-		// 如果 ID 字段不是 int64，需要类型转换
-		sb.WriteString(fmt.Sprintf("\treturn int64(%s.%s)\n", receiver, idFieldName))
+	var getID strings.Builder
+	getID.WriteString(fmt.Sprintf("\treturn %s{\n", keyType))
+	for _, field := range agg.PrimaryKey {
+		getID.WriteString(fmt.Sprintf("\t\t%s: %s.%s,\n", field.Name, receiver, field.Name))
 	}
-	sb.WriteString("}\n\n")
+	getID.WriteString("\t}\n")
+	file.addFunc(entityMethod(agg, receiver, "GetID 获取实体ID", "GetID", "", keyType, getID.String()))
 
 	// SetID 方法
-	sb.WriteString("// SetID 设置实体ID\n")
-	sb.WriteString(fmt.Sprintf("func (%s *%s) SetID(id int64) {\n", receiver, agg.Name))
-	if idFieldType == "int64" {
-		sb.WriteString(fmt.Sprintf("\t%s.%s = id\n", receiver, idFieldName))
-	} else {
-		// 如果 ID 字段不是 int64，需要类型转换
-		sb.WriteString(fmt.Sprintf("\t%s.%s = %s(id)\n", receiver, idFieldName, idFieldType))
+	var setID strings.Builder
+	for _, field := range agg.PrimaryKey {
+		setID.WriteString(fmt.Sprintf("\t%s.%s = id.%s\n", receiver, field.Name, field.Name))
 	}
-	sb.WriteString("}\n\n")
+	file.addFunc(entityMethod(agg, receiver, "SetID 设置实体ID", "SetID", "id "+keyType, "", setID.String()))
 
 	// IsNew 方法
-	sb.WriteString("// IsNew 判断是否为新实体\n")
-	sb.WriteString(fmt.Sprintf("func (%s *%s) IsNew() bool {\n", receiver, agg.Name))
-	sb.WriteString(fmt.Sprintf("\treturn %s.%s == 0\n", receiver, idFieldName))
-	sb.WriteString("}\n")
+	file.addFunc(entityMethod(agg, receiver, "IsNew 判断是否为新实体", "IsNew", "", "bool",
+		fmt.Sprintf("\treturn %s.GetID() == %s{}\n", receiver, keyType)))
+}
+
+// generateOperatorSetter 将审计人设置方法添加到 file
+// 仅支持整数类型的审计字段，其他类型需要用户自行实现
+func (g *EntityGenerator) generateOperatorSetter(file *GoFileData, agg *metadata.AggregateMetadata, receiver, methodName, comment string, field *metadata.FieldMetadata) {
+	if field == nil || field.IsPointer || !isIntegerType(field.Type) {
+		return
+	}
+
+	body := fmt.Sprintf("\t%s.%s = operator\n", receiver, field.Name)
+	if field.Type != "int64" {
+		body = fmt.Sprintf("\t%s.%s = %s(operator)\n", receiver, field.Name, field.Type)
+	}
+	file.addFunc(entityMethod(agg, receiver, methodName+" "+comment, methodName, "operator int64", "", body))
+}
+
+// generateFactory 生成工厂函数 New{AggregateName}：必填字段由参数传入，其他字段按 +soliton:default 初始化，
+// 切片和 map 字段初始化为空集合（[]byte 除外）
+//
+// 指针字段的默认值先赋给局部变量再取地址，字面量的默认类型与字段类型不同时先转换为字段类型；
+// 嵌入结构体提升的字段不能出现在复合字面量中，创建后再逐个赋值。
+func (g *EntityGenerator) generateFactory(agg *metadata.AggregateMetadata) *GoFunc {
+	type assignment struct {
+		name  string
+		value string
+	}
+
+	var locals []assignment
+	var assignments, promoted []assignment
+	assign := func(field *metadata.FieldMetadata, value string) {
+		if field.EmbeddedIn != "" {
+			promoted = append(promoted, assignment{name: field.Name, value: value})
+		} else {
+			assignments = append(assignments, assignment{name: field.Name, value: value})
+		}
+	}
+
+	var description []string
+	params := factoryParams(agg)
+	if len(params) > 0 {
+		description = append(description, "必填字段由参数传入")
+	}
+	defaults, collections := false, false
+	for _, field := range agg.MappedFields() {
+		if i := slices.IndexFunc(params, func(p factoryParam) bool { return p.field == field }); i >= 0 {
+			assign(field, params[i].name)
+			continue
+		}
+		if agg.InPrimaryKey(field) {
+			continue
+		}
 
-	return sb.String()
+		if literal, ok := factoryDefault(field); ok {
+			if field.IsPointer {
+				local := "default" + field.Name
+				locals = append(locals, assignment{name: local, value: typedLiteral(literal, field.Type)})
+				literal = "&" + local
+			}
+			assign(field, literal)
+			defaults = true
+		} else if field.IsSlice && field.GoType() != "[]byte" || field.IsMap {
+			assign(field, field.GoType()+"{}")
+			collections = true
+		}
+	}
+	if defaults {
+		description = append(description, "按 +soliton:default 注解设置默认值")
+	}
+	if collections {
+		description = append(description, "切片和 map 初始化为空")
+	}
+
+	signature := make([]string, len(params))
+	for i, p := range params {
+		signature[i] = p.name + " " + p.field.GoType()
+	}
+	doc := fmt.Sprintf("New%s 创建 %s", agg.Name, agg.Name)
+	if len(description) > 0 {
+		doc += "，" + strings.Join(description, "，")
+	}
+
+	var sb strings.Builder
+	for _, local := range locals {
+		sb.WriteString(fmt.Sprintf("\t%s := %s\n", local.name, local.value))
+	}
+
+	literal := "&" + agg.Name + "{}"
+	if len(assignments) > 0 {
+		var fields strings.Builder
+		fields.WriteString("&" + agg.Name + "{\n")
+		for _, a := range assignments {
+			fields.WriteString(fmt.Sprintf("\t\t%s: %s,\n", a.name, a.value))
+		}
+		fields.WriteString("\t}")
+		literal = fields.String()
+	}
+	if len(promoted) == 0 {
+		sb.WriteString("\treturn " + literal + "\n")
+	} else {
+		sb.WriteString("\tentity := " + literal + "\n")
+		for _, a := range promoted {
+			sb.WriteString(fmt.Sprintf("\tentity.%s = %s\n", a.name, a.value))
+		}
+		sb.WriteString("\treturn entity\n")
+	}
+	return &GoFunc{
+		Doc:     doc,
+		Name:    "New" + agg.Name,
+		Params:  strings.Join(signature, ", "),
+		Results: "*" + agg.Name,
+		Body:    sb.String(),
+	}
+}
+
+// factoryParam 工厂函数 New{AggregateName} 的参数
+type factoryParam struct {
+	name  string                  // 参数名，如 totalAmount
+	field *metadata.FieldMetadata // 对应的必填字段
+}
+
+// qualifierPattern 匹配类型或表达式中的包名限定，如 time.Now() 中的 time
+var qualifierPattern = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)\.`)
+
+// factoryParams 返回工厂函数的参数：按声明顺序的必填字段（+soliton:required），主键、关联实体和有默认值的字段除外
+// 参数名为字段的 JSON 名称（如 userID），与关键字、工厂函数中用到的包名或局部变量重名时加后缀 Value
+func factoryParams(agg *metadata.AggregateMetadata) []factoryParam {
+	reserved := map[string]bool{"entity": true}
+	for _, field := range agg.MappedFields() {
+		reserved["default"+field.Name] = true
+		expr := field.GoType()
+		if literal, ok := factoryDefault(field); ok {
+			expr += " " + literal
+		}
+		for _, match := range qualifierPattern.FindAllStringSubmatch(expr, -1) {
+			reserved[match[1]] = true
+		}
+	}
+
+	var params []factoryParam
+	for _, field := range agg.MappedFields() {
+		if !field.Annotations.IsRequired || agg.InPrimaryKey(field) || field.Annotations.IsEntity {
+			continue
+		}
+		if _, ok := factoryDefault(field); ok {
+			continue
+		}
+		name := jsonName(field.Name)
+		if token.IsKeyword(name) || reserved[name] {
+			name += "Value"
+		}
+		params = append(params, factoryParam{name: name, field: field})
+	}
+	return params
+}
+
+// factoryDefault 返回工厂函数为字段设置的默认值表达式，值对象、集合和关联实体字段不设置默认值
+func factoryDefault(field *metadata.FieldMetadata) (string, bool) {
+	if field.Annotations.IsEntity || field.Annotations.IsValueObject || field.IsSlice || field.IsMap || field.IsArray {
+		return "", false
+	}
+	return field.DefaultLiteral()
+}
+
+// hasFactory 判断聚合根所在包是否已自行定义 New{AggregateName} 函数
+// content 为已移除生成代码的聚合根文件内容
+func (g *EntityGenerator) hasFactory(agg *metadata.AggregateMetadata, content string) bool {
+	declaration := fmt.Sprintf("func New%s(", agg.Name)
+	if strings.Contains(content, declaration) {
+		return true
+	}
+
+	dir := filepath.Dir(agg.FilePath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if entry.IsDir() || !strings.HasSuffix(path, ".go") || path == filepath.Clean(agg.FilePath) {
+			continue
+		}
+		other, err := g.readFile(path)
+		if err != nil {
+			continue
+		}
+		if strings.Contains(g.removeGeneratedCode(other), declaration) {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,89 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"soliton/pkg/metadata"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// EnumGenerator 枚举生成器
+//
+// 为 +soliton:enum 声明的每个枚举生成类型安全的定义，包含：
+//   - 以字段类型为底层类型的枚举类型和常量，如 OrderStatus、OrderStatusPending
+//   - IsValid()、String()、Label()（声明了值说明时）、{Name}Values() 和 Parse{Name}()
+//   - JSON 编解码：字符串枚举编码为值本身，整数枚举编码为名称，解码时同时接受名称和编码，拒绝无效值
+//   - database/sql 的 Scanner、Valuer：以值（整数枚举为编码）存取，读取时不校验，数据库中的旧值不会导致查询失败
+//
+// 生成文件：enum/{enumName}.go，如 enum/orderStatus.go
+//
+// 来自 const 块的枚举（EnumMetadata.IsDeclared）在代码中已有类型定义，不生成。
+type EnumGenerator struct {
+	fileOutput
+}
+
+// NewEnumGenerator 创建枚举生成器
+func NewEnumGenerator() *EnumGenerator {
+	return &EnumGenerator{}
+}
+
+// Generate 为注册表中的枚举生成类型定义
+func (g *EnumGenerator) Generate(registry *metadata.AggregateMetadataRegistry, outputDir string) error {
+	enumDir := filepath.Join(outputDir, "enum")
+
+	for _, enum := range registry.GetEnums() {
+		if enum.IsDeclared() {
+			continue
+		}
+
+		filePath := filepath.Join(enumDir, toLowerFirst(enum.Name)+".go")
+		if err := g.writeTemplate(filePath, "enum", g.generateEnum(enum)); err != nil {
+			return fmt.Errorf("写入文件失败: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// generateEnum 返回单个枚举的模板数据
+func (g *EnumGenerator) generateEnum(enum *metadata.EnumMetadata) *EnumData {
+	data := &EnumData{Enum: enum, BaseType: strings.TrimPrefix(enum.GoType, "*"), Int: enum.IsInt()}
+	for i, value := range enum.Values {
+		literal := strconv.Quote(value)
+		if data.Int {
+			literal = value
+		}
+		data.Values = append(data.Values, &EnumValue{
+			Constant: enum.Name + enumConstantSuffix(enum.ValueName(i)),
+			Name:     enum.ValueName(i),
+			Value:    value,
+			Literal:  literal,
+			Label:    enum.ValueLabel(i),
+		})
+	}
+	return data
+}
+
+// enumConstantSuffix 将枚举值的名称转换为常量名后缀，如 PENDING → Pending、in_progress → InProgress
+// 名称中的非字母数字字符作为单词分隔；全部由分隔符组成时返回 Value
+func enumConstantSuffix(value string) string {
+	var sb strings.Builder
+	words := strings.FieldsFunc(value, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		runes := []rune(strings.ToLower(word))
+		// 原本大小写混合的单词（如 inProgress）保留原样，只大写首字母
+		if word != strings.ToUpper(word) {
+			runes = []rune(word)
+		}
+		runes[0] = unicode.ToUpper(runes[0])
+		sb.WriteString(string(runes))
+	}
+	if sb.Len() == 0 {
+		return "Value"
+	}
+	return sb.String()
+}
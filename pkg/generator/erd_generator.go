@@ -0,0 +1,228 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"soliton/pkg/metadata"
+	"strings"
+)
+
+// ER 图格式
+const (
+	ERDFormatMermaid = "mermaid" // Mermaid erDiagram，可直接嵌入 Markdown
+	ERDFormatDOT     = "dot"     // Graphviz DOT，用 dot -Tsvg 渲染
+)
+
+// ERDFormatOf 按文件扩展名确定 ER 图格式：.dot、.gv 为 Graphviz DOT，其他为 Mermaid
+func ERDFormatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".dot", ".gv":
+		return ERDFormatDOT
+	default:
+		return ERDFormatMermaid
+	}
+}
+
+// ERDGenerator 关系图（ER 图）导出器
+//
+// 遍历注册表中的聚合根、关系和多对多关联表，导出 Mermaid erDiagram 或 Graphviz DOT：
+//   - 每个聚合根是一个实体，属性为表中的列（见 metadata.Schema），标注 PK（主键）、FK（外键）、UK（单列唯一索引）
+//   - 一对一、一对多、外部引用、多态关联按关联字段连线，并标注两端的基数；
+//     双向关系（见 RelationMetadata.Inverse）只连一条线，标签为两侧的字段名，如 Items / OrderID
+//   - 纯关联表作为独立实体，与两端聚合根各连一条一对多的线；
+//     中间实体（+soliton:manyToMany）本身是聚合根，通过其外部引用字段连线
+//
+// 实体和连线按名称排序，相同的模型总是得到相同的输出。
+type ERDGenerator struct {
+	fileOutput
+	registry *metadata.AggregateMetadataRegistry
+	schema   *metadata.Schema
+	format   string
+}
+
+// NewERDGenerator 创建 ER 图导出器，默认导出 Mermaid
+func NewERDGenerator(registry *metadata.AggregateMetadataRegistry) *ERDGenerator {
+	return &ERDGenerator{
+		registry: registry,
+		format:   ERDFormatMermaid,
+	}
+}
+
+// SetFormat 设置导出格式，见 ERDFormatMermaid、ERDFormatDOT
+func (g *ERDGenerator) SetFormat(format string) {
+	g.format = format
+}
+
+// SetSchema 设置表结构元数据，未设置时按注册表计算
+func (g *ERDGenerator) SetSchema(schema *metadata.Schema) {
+	g.schema = schema
+}
+
+// Schema 返回实体属性使用的表结构元数据
+func (g *ERDGenerator) Schema() *metadata.Schema {
+	if g.schema == nil {
+		g.schema = metadata.NewSchema(g.registry, metadata.DefaultDialect())
+	}
+	return g.schema
+}
+
+// Generate 导出 ER 图到指定文件
+func (g *ERDGenerator) Generate(path string) error {
+	if g.format != ERDFormatMermaid && g.format != ERDFormatDOT {
+		return fmt.Errorf("不支持的 ER 图格式: %s", g.format)
+	}
+
+	entities, edges := g.buildModel()
+	data := &ERDData{
+		TemplateData: TemplateData{Aggregates: g.registry.GetAll()},
+		Format:       g.format,
+		Entities:     entities,
+		Edges:        edges,
+	}
+	if err := g.writeTemplate(path, "erd", data); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return nil
+}
+
+// erdCardinality 连线一端的基数
+type erdCardinality string
+
+const (
+	erdExactlyOne erdCardinality = "one"        // 恰好一个
+	erdZeroOrOne  erdCardinality = "zeroOrOne"  // 零或一个
+	erdZeroOrMore erdCardinality = "zeroOrMore" // 零或多个
+)
+
+// buildModel 从注册表构建实体和连线
+func (g *ERDGenerator) buildModel() ([]*ERDEntity, []*ERDEdge) {
+	snapshot := g.registry.Snapshot()
+
+	// 聚合根持有外键的一对一关系（+soliton:owner），外键字段上的外部引用与之重复
+	ownedKeys := make(map[*metadata.FieldMetadata]bool)
+	for _, rel := range snapshot.Relations {
+		if rel.ForeignKey != nil && rel.ForeignKeyOnSource() {
+			ownedKeys[rel.ForeignKey] = true
+		}
+	}
+
+	schema := g.Schema()
+	var entities []*ERDEntity
+	for _, agg := range snapshot.Aggregates {
+		table := schema.Table(agg.Name)
+		if table == nil {
+			continue
+		}
+		entity := &ERDEntity{Name: agg.Name, Table: table.Name}
+		for _, column := range table.Columns {
+			var keys []string
+			if column.PrimaryKey {
+				keys = append(keys, "PK")
+			} else {
+				if column.ForeignKey {
+					keys = append(keys, "FK")
+				}
+				if table.IsUnique(column.Name) {
+					keys = append(keys, "UK")
+				}
+			}
+			entity.Attributes = append(entity.Attributes, &ERDAttribute{Type: erdType(column.Field), Column: column.Name, Keys: keys})
+		}
+		entities = append(entities, entity)
+	}
+
+	var edges []*ERDEdge
+	for _, rel := range snapshot.Relations {
+		// 双向关系只按一对多（一对一）一侧连线，聚合根持有外键的一对一关系只按关联实体字段连线
+		if rel.IsBackReference() || rel.Type == metadata.RelationTypeRef && ownedKeys[rel.Field] {
+			continue
+		}
+
+		edge := &ERDEdge{From: rel.SourceAggregate, To: rel.TargetAggregate}
+		if rel.Field != nil {
+			edge.Label = rel.Field.Name
+		}
+		if rel.InverseField != "" {
+			edge.Label += " / " + rel.InverseField
+		}
+
+		switch rel.Type {
+		case metadata.RelationTypeOneToOne:
+			edge.FromCard, edge.ToCard = erdExactlyOne, erdExactlyOne
+			if rel.Field != nil && rel.Field.IsPointer {
+				edge.ToCard = erdZeroOrOne
+			}
+		case metadata.RelationTypeOneToMany:
+			edge.FromCard, edge.ToCard = erdExactlyOne, erdZeroOrMore
+			// 外键可空（如树形结构的 ParentID *int64）时关联实体可以没有所属的聚合根
+			if rel.Inverse != nil && rel.Inverse.Field.IsPointer {
+				edge.FromCard = erdZeroOrOne
+			}
+		case metadata.RelationTypeRef:
+			edge.FromCard, edge.ToCard = erdZeroOrMore, erdExactlyOne
+			if rel.Field != nil && rel.Field.IsPointer {
+				edge.ToCard = erdZeroOrOne
+			}
+		case metadata.RelationTypePolymorphic:
+			edge.FromCard, edge.ToCard = erdZeroOrMore, erdZeroOrOne
+			edge.Label += " (polymorphic)"
+		default:
+			// 多对多通过关联表连线
+			continue
+		}
+		edges = append(edges, edge)
+	}
+
+	// 纯关联表：两端聚合根各与关联表构成一对多
+	for _, table := range snapshot.ManyToManyTables {
+		if table.Association != "" {
+			continue
+		}
+		entities = append(entities, &ERDEntity{
+			Name:  table.TableName,
+			Table: table.TableName,
+			Attributes: []*ERDAttribute{
+				{Type: g.idType(table.LeftAggregate), Column: table.LeftColumn, Keys: []string{"FK"}},
+				{Type: g.idType(table.RightAggregate), Column: table.RightColumn, Keys: []string{"FK"}},
+			},
+		})
+		edges = append(edges,
+			&ERDEdge{From: table.LeftAggregate, To: table.TableName, FromCard: erdExactlyOne, ToCard: erdZeroOrMore, Label: table.LeftColumn},
+			&ERDEdge{From: table.RightAggregate, To: table.TableName, FromCard: erdExactlyOne, ToCard: erdZeroOrMore, Label: table.RightColumn},
+		)
+	}
+
+	return entities, edges
+}
+
+// idType 返回聚合根主键的类型，聚合根未注册或使用复合主键时为 int64
+func (g *ERDGenerator) idType(aggregateName string) string {
+	if agg := g.registry.Get(aggregateName); agg != nil && agg.IDField != nil {
+		return erdType(agg.IDField)
+	}
+	return "int64"
+}
+
+// dotEscape 转义 record 标签中的特殊字符
+func dotEscape(s string) string {
+	replacer := strings.NewReplacer(`"`, `\"`, "{", `\{`, "}", `\}`, "|", `\|`, "<", `\<`, ">", `\>`)
+	return replacer.Replace(s)
+}
+
+// erdType 返回属性类型：去掉指针和包限定，切片、数组写作 string[]、byte[32]，map 写作 map
+// Mermaid 的属性类型只允许字母、数字、下划线、连字符和方括号
+func erdType(field *metadata.FieldMetadata) string {
+	typ := field.Type
+	if i := strings.LastIndex(typ, "."); i >= 0 {
+		typ = typ[i+1:]
+	}
+	switch {
+	case field.IsMap || strings.HasPrefix(typ, "map["):
+		return "map"
+	case field.IsSlice:
+		return typ + "[]"
+	case field.IsArray:
+		return typ + "[" + field.ArrayLen + "]"
+	}
+	return typ
+}
@@ -0,0 +1,59 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"soliton/pkg/metadata"
+)
+
+// EventGenerator 领域事件生成器
+//
+// 为发布领域事件的聚合根在其所在包中生成：
+//   - 在聚合根上列出的事件（不带字段）的结构体，携带 AggregateID 和 OccurredAt，实现 framework.AggregateEvent
+//   - 事件的 EventName()、Topic() 方法，实现 framework.DomainEvent
+//   - 聚合根嵌入 framework.EventRecorder 时，为不带字段的事件生成记录方法 Record{EventName}()
+//
+// 专用结构体定义的事件只在与聚合根位于同一个包时生成 EventName()、Topic()，
+// 其他包中的事件结构体需要自行实现 framework.DomainEvent。
+//
+// 模板为 event。
+//
+// 生成文件：聚合根所在目录的 {aggregateName}Events.go，如 orderEvents.go
+type EventGenerator struct {
+	fileOutput
+}
+
+// NewEventGenerator 创建领域事件生成器
+func NewEventGenerator() *EventGenerator {
+	return &EventGenerator{}
+}
+
+// Generate 为聚合根生成领域事件，events 为聚合根发布的事件，没有需要生成的代码时不写文件
+func (g *EventGenerator) Generate(agg *metadata.AggregateMetadata, events []*metadata.EventMetadata) error {
+	var local []*metadata.EventMetadata
+	for _, event := range events {
+		if !event.IsDeclared() || event.ImportPath == agg.ImportPath {
+			local = append(local, event)
+		}
+	}
+	if len(local) == 0 {
+		return nil
+	}
+
+	data := &EventData{
+		TemplateData: TemplateData{Aggregate: agg, Context: agg.Context()},
+		KeyType:      agg.IDKeyType(),
+		Events:       local,
+	}
+	for _, event := range local {
+		if !event.IsDeclared() {
+			data.Structs = append(data.Structs, event)
+		}
+	}
+
+	filePath := filepath.Join(filepath.Dir(agg.FilePath), toLowerFirst(agg.Name)+"Events.go")
+	if err := g.writeTemplate(filePath, "event", data); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package generator
+
+import (
+	"fmt"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"soliton/pkg/metadata"
+	"strconv"
+	"strings"
+)
+
+// FixtureGenerator 测试数据构建器生成器
+//
+// 为每个聚合根生成链式构建器 {AggregateName}Builder，供测试构造聚合根：
+//   - New{AggregateName}Builder() 通过工厂函数 New{AggregateName} 创建聚合根（应用默认值、初始化集合），
+//     必填字段、手动分配的主键和唯一约束中的字段取按构建器序号区分的测试值
+//   - 每个字段一个 With{Field} 方法，切片字段为可变参数
+//   - Build() 返回构建的聚合根
+//
+// 自行定义了 New{AggregateName} 的聚合根从零值创建，再为必填字段赋测试值。
+//
+// 生成文件：domain/fixtures/{AggregateName}Builder.go
+type FixtureGenerator struct {
+	fileOutput
+	registry *metadata.AggregateMetadataRegistry
+}
+
+// NewFixtureGenerator 创建测试数据构建器生成器
+func NewFixtureGenerator() *FixtureGenerator {
+	return &FixtureGenerator{}
+}
+
+// SetRegistry 设置聚合根注册表，用于识别中间实体的关联两端等唯一约束
+func (g *FixtureGenerator) SetRegistry(registry *metadata.AggregateMetadataRegistry) {
+	g.registry = registry
+}
+
+// Generate 为聚合根生成测试数据构建器
+func (g *FixtureGenerator) Generate(agg *metadata.AggregateMetadata, outputDir string) error {
+	absOutputDir, _ := filepath.Abs(outputDir)
+	filePath := filepath.Join(domainDir(agg, absOutputDir), "fixtures", agg.Name+"Builder.go")
+
+	// 工厂函数由 EntityGenerator 追加到领域模型文件，自行定义同名函数时不生成
+	entities := &EntityGenerator{fileOutput: g.fileOutput}
+	content, err := entities.readFile(agg.FilePath)
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %w", err)
+	}
+	custom := entities.hasFactory(agg, entities.removeGeneratedCode(content))
+
+	if err := g.writeTemplate(filePath, "fixture", g.generateBuilder(agg, custom)); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return nil
+}
+
+// generateBuilder 生成聚合根构建器文件的模板数据，custom 表示聚合根自行定义了 New{AggregateName}
+func (g *FixtureGenerator) generateBuilder(agg *metadata.AggregateMetadata, custom bool) *GoFileData {
+	name := agg.Name + "Builder"
+	entityType := fmt.Sprintf("*%s.%s", agg.PackageName, agg.Name)
+	variable := fixtureParam(jsonName(agg.Name))
+	sequence := toLowerFirst(agg.Name) + "Sequence"
+
+	file := &GoFileData{
+		TemplateData: TemplateData{Aggregate: agg, Context: agg.Context()},
+		Package:      "fixtures",
+	}
+	file.addImports(agg.ImportPath, "sync/atomic")
+
+	file.addValues(declVar, fmt.Sprintf("%s %s 测试数据的序号，每个构建器取下一个", sequence, agg.Name), &GoField{Name: sequence, Type: "atomic.Int64"})
+	file.addType(fmt.Sprintf("%s 构造 %s 测试数据的构建器\n\n", name, agg.Name)+
+		"必填字段、手动分配的主键和唯一约束中的字段取按构建器序号 n 区分的测试值：枚举为第一个值，邮箱为 user{n}@example.com，\n"+
+		"字符串为 \"{字段名}-{n}\"，数值为 n；测试值不保证满足 pattern 等校验规则，需要时用 With 方法覆盖。",
+		name, "struct", &GoField{Name: variable, Type: entityType})
+
+	// 构造函数：调用工厂函数，再为主键和唯一约束中的字段赋测试值
+	var locals, args, assignments []string
+	value := func(path string, field *metadata.FieldMetadata) string {
+		expr, ok := fixtureValue(agg, field)
+		if !ok {
+			if field.IsPointer && !field.IsSlice {
+				return fmt.Sprintf("new(%s)", qualifyType(field.Type, agg.PackageName))
+			}
+			return fmt.Sprintf("*new(%s)", qualifyType(field.GoType(), agg.PackageName))
+		}
+		if field.IsPointer {
+			local := toLowerFirst(strings.ReplaceAll(path, ".", ""))
+			locals = append(locals, fmt.Sprintf("%s := %s", local, expr))
+			return "&" + local
+		}
+		return expr
+	}
+
+	assigned := map[string]bool{}
+	params := factoryParams(agg)
+	for _, p := range params {
+		if custom {
+			assignments = append(assignments, fmt.Sprintf("%s.%s = %s", variable, p.field.Name, value(p.field.Name, p.field)))
+		} else {
+			args = append(args, value(p.field.Name, p.field))
+		}
+		assigned[p.field.Name] = true
+	}
+
+	var keys []string
+	if agg.IsCompositeKey() {
+		for _, field := range agg.PrimaryKey {
+			keys = append(keys, field.Name)
+		}
+	} else if agg.IDStrategy == metadata.IDStrategyManual && agg.IDField != nil {
+		keys = append(keys, agg.IDField.Name)
+	}
+	for _, path := range append(keys, uniqueFields(g.registry, agg)...) {
+		field := agg.FieldByPath(path)
+		if assigned[path] || field == nil || !assignable(agg, path) {
+			continue
+		}
+		if _, ok := fixtureValue(agg, field); !ok {
+			continue
+		}
+		assignments = append(assignments, fmt.Sprintf("%s.%s = %s", variable, path, value(path, field)))
+		assigned[path] = true
+	}
+
+	var body strings.Builder
+	if slices.ContainsFunc(slices.Concat(locals, args, assignments), sequenceUsed.MatchString) {
+		body.WriteString(fmt.Sprintf("\tn := int(%s.Add(1))\n", sequence))
+	} else {
+		body.WriteString(fmt.Sprintf("\t%s.Add(1)\n", sequence))
+	}
+	for _, local := range locals {
+		body.WriteString("\t" + local + "\n")
+	}
+	if custom {
+		body.WriteString(fmt.Sprintf("\t%s := &%s.%s{}\n", variable, agg.PackageName, agg.Name))
+	} else {
+		body.WriteString(fmt.Sprintf("\t%s := %s.New%s(%s)\n", variable, agg.PackageName, agg.Name, strings.Join(args, ", ")))
+	}
+	for _, assignment := range assignments {
+		body.WriteString("\t" + assignment + "\n")
+	}
+	body.WriteString(fmt.Sprintf("\treturn &%s{%s: %s}\n", name, variable, variable))
+	file.addFunc(&GoFunc{
+		Doc:     fmt.Sprintf("New%s 创建 %s 构建器，每次调用取下一个序号", name, agg.Name),
+		Name:    "New" + name,
+		Results: "*" + name,
+		Body:    body.String(),
+	})
+	// 测试值用到的 fmt、time
+	if strings.Contains(body.String(), "fmt.Sprintf") {
+		file.addImports("fmt")
+	}
+	if strings.Contains(body.String(), "time.") {
+		file.addImports("time")
+	}
+	for _, p := range params {
+		file.addImports(fixtureImports(agg, p.field)...)
+	}
+
+	// With 方法
+	for _, field := range agg.MappedFields() {
+		param := fixtureParam(jsonName(field.Name))
+		paramType := qualifyType(field.GoType(), agg.PackageName)
+		if field.IsSlice && field.GoType() != "[]byte" {
+			paramType = "..." + strings.TrimPrefix(paramType, "[]")
+		}
+		file.addImports(fixtureImports(agg, field)...)
+		file.addFunc(&GoFunc{
+			Doc:      fmt.Sprintf("With%s 设置 %s", field.Name, field.Name),
+			Receiver: "b *" + name,
+			Name:     "With" + field.Name,
+			Params:   param + " " + paramType,
+			Results:  "*" + name,
+			Body:     fmt.Sprintf("\tb.%s.%s = %s\n\treturn b\n", variable, field.Name, param),
+		})
+	}
+
+	file.addFunc(&GoFunc{
+		Doc:      fmt.Sprintf("Build 返回构建的 %s，构建器之后的修改同样作用于返回的对象", agg.Name),
+		Receiver: "b *" + name,
+		Name:     "Build",
+		Results:  entityType,
+		Body:     fmt.Sprintf("\treturn b.%s\n", variable),
+	})
+
+	return file
+}
+
+// sequenceUsed 匹配用到构建器序号 n 的测试值
+var sequenceUsed = regexp.MustCompile(`\bn\b`)
+
+// fixtureParam 返回构建器方法的参数名，与关键字或接收者 b 重名时加后缀 Value
+func fixtureParam(name string) string {
+	if token.IsKeyword(name) || name == "b" {
+		return name + "Value"
+	}
+	return name
+}
+
+// fixtureValue 返回字段第 n 个测试值的表达式（不含指针）：枚举为第一个值，邮箱为 user{n}@example.com，
+// 字符串为 "{字段名}-{n}"，数值为 n，bool 为 true，time.Time 为当前时间；集合、值对象和其他包中的类型返回 false
+func fixtureValue(agg *metadata.AggregateMetadata, field *metadata.FieldMetadata) (string, bool) {
+	if field.IsSlice || field.IsMap || field.IsArray || field.Annotations.IsValueObject || field.Annotations.IsEntity {
+		return "", false
+	}
+	if field.Type == "time.Time" {
+		return "time.Now()", true
+	}
+	if strings.Contains(field.Type, ".") {
+		return "", false
+	}
+
+	typeName := qualifyType(field.Type, agg.PackageName)
+	annotations := field.Annotations
+	switch basicType := field.BasicType(); {
+	case len(annotations.EnumValues) > 0:
+		literal := annotations.EnumValues[0]
+		if !annotations.IsIntEnum() {
+			literal = strconv.Quote(literal)
+		}
+		return typedLiteral(literal, typeName), true
+	case basicType == "string":
+		value := fmt.Sprintf("fmt.Sprintf(\"%s-%%d\", n)", field.Name)
+		if annotations.Validation != nil && annotations.Validation.IsEmail {
+			value = "fmt.Sprintf(\"user%d@example.com\", n)"
+		}
+		return typedLiteral(value, typeName), true
+	case isIntegerType(basicType), basicType == "float32", basicType == "float64":
+		return typedLiteral("n", typeName), true
+	case basicType == "bool":
+		return typedLiteral("true", typeName), true
+	}
+	return "", false
+}
+
+// fixtureImports 返回字段类型用到的 import 路径：领域模型、time、gorm、framework 和已知标量类型（如 uuid.UUID）
+func fixtureImports(agg *metadata.AggregateMetadata, field *metadata.FieldMetadata) []string {
+	var imports []string
+	for _, match := range qualifierPattern.FindAllStringSubmatch(qualifyType(field.GoType(), agg.PackageName), -1) {
+		switch match[1] {
+		case agg.PackageName:
+			imports = append(imports, agg.ImportPath)
+		case "time":
+			imports = append(imports, "time")
+		case "gorm":
+			imports = append(imports, "gorm.io/gorm")
+		case "framework":
+			imports = append(imports, "soliton/pkg/framework")
+		}
+	}
+	if field.ScalarType != nil && field.ScalarType.ImportPath != "" {
+		imports = append(imports, field.ScalarType.ImportPath)
+	}
+	return imports
+}
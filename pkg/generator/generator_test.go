@@ -0,0 +1,83 @@
+package generator
+
+import (
+	"go/format"
+	"os"
+	"path/filepath"
+	"soliton/pkg/analyzer"
+	"soliton/pkg/metadata"
+	"soliton/pkg/parser"
+	"strings"
+	"testing"
+)
+
+// parseTestModel 将 src 写入临时模块 sample 的 domain/model 包，解析并分析后返回名为 name 的聚合根
+func parseTestModel(t *testing.T, name, src string) *metadata.AggregateMetadata {
+	t.Helper()
+
+	agg := parseTestRegistry(t, src).Get(name)
+	if agg == nil {
+		t.Fatalf("未解析到聚合根 %s", name)
+	}
+	return agg
+}
+
+// parseTestRegistry 将 src 写入临时模块 sample 的 domain/model 包，解析并分析后返回注册表
+// externals 为其他服务中的聚合根，同 -external
+func parseTestRegistry(t *testing.T, src string, externals ...string) *metadata.AggregateMetadataRegistry {
+	t.Helper()
+
+	dir := t.TempDir()
+	modelDir := filepath.Join(dir, "domain", "model")
+	if err := os.MkdirAll(modelDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module sample\n\ngo 1.21\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(modelDir, "model.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	aggregates, err := parser.NewASTParser().ParseDirectory(modelDir)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	registry := metadata.NewAggregateMetadataRegistry()
+	for _, agg := range aggregates {
+		registry.Register(agg)
+	}
+	relationAnalyzer := analyzer.NewRelationAnalyzer(registry)
+	relationAnalyzer.SetExternalAggregates(externals)
+	if err := relationAnalyzer.AnalyzeRelations(); err != nil {
+		t.Fatalf("关系分析失败: %v", err)
+	}
+	return registry
+}
+
+// assertGoSource 检查生成的代码语法正确并包含 want 中的每一行（忽略行首缩进和对齐空格）
+func assertGoSource(t *testing.T, code string, want ...string) {
+	t.Helper()
+
+	formatted, err := format.Source([]byte(code))
+	if err != nil {
+		t.Fatalf("生成的代码无法格式化: %v\n%s", err, code)
+	}
+	normalized := strings.Join(strings.Fields(string(formatted)), " ")
+	for _, line := range want {
+		if !strings.Contains(normalized, strings.Join(strings.Fields(line), " ")) {
+			t.Errorf("生成的代码缺少 %q:\n%s", line, formatted)
+		}
+	}
+}
+
+// renderTestTemplate 以内置模板 name 渲染 data
+func renderTestTemplate(t *testing.T, name string, data any) string {
+	t.Helper()
+
+	code, err := defaultTemplates().Execute(name, data)
+	if err != nil {
+		t.Fatalf("渲染模板 %s 失败: %v", name, err)
+	}
+	return code
+}
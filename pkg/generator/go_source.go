@@ -0,0 +1,232 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"soliton/pkg/metadata"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// stdImports 生成代码（含覆盖的模板）常用的标准库包，包名 -> import 路径，引用了但未导入时自动补充
+var stdImports = map[string]string{
+	"big":     "math/big",
+	"bytes":   "bytes",
+	"context": "context",
+	"driver":  "database/sql/driver",
+	"errors":  "errors",
+	"fmt":     "fmt",
+	"http":    "net/http",
+	"io":      "io",
+	"json":    "encoding/json",
+	"log":     "log",
+	"maps":    "maps",
+	"math":    "math",
+	"netip":   "net/netip",
+	"os":      "os",
+	"reflect": "reflect",
+	"regexp":  "regexp",
+	"slices":  "slices",
+	"slog":    "log/slog",
+	"sort":    "sort",
+	"sql":     "database/sql",
+	"strconv": "strconv",
+	"strings": "strings",
+	"sync":    "sync",
+	"testing": "testing",
+	"time":    "time",
+	"unicode": "unicode",
+	"url":     "net/url",
+}
+
+// PackageImports 返回元数据中出现的包（聚合根、const 块枚举和专用事件结构体所在的包），包名 -> import 路径
+// 供格式化生成代码时补充引用了但未导入的包，同名的包只保留按名称排序后第一个聚合根所在的包
+func PackageImports(registry *metadata.AggregateMetadataRegistry) map[string]string {
+	imports := make(map[string]string)
+	add := func(importPath string) {
+		if importPath == "" {
+			return
+		}
+		if name, _ := guessPackageName(importPath); name != "" && imports[name] == "" {
+			imports[name] = importPath
+		}
+	}
+	for _, agg := range registry.GetAll() {
+		if agg.PackageName != "" && agg.ImportPath != "" && imports[agg.PackageName] == "" {
+			imports[agg.PackageName] = agg.ImportPath
+		}
+	}
+	for _, enum := range registry.GetEnums() {
+		add(enum.ImportPath)
+	}
+	for _, event := range registry.GetEvents() {
+		add(event.ImportPath)
+	}
+	return imports
+}
+
+// formatGoSource 整理生成的 Go 代码的导入并按 gofmt 格式化
+//
+// 与 goimports 类似：删除未使用的导入，为引用了但未导入的包补充导入（先按 known，再按常用标准库查找包名）。
+// 包名无法由 import 路径确定的导入（如 gopkg.in/yaml.v3、.../echo/v4）即使看起来未使用也保留。
+// 代码存在语法错误时返回错误。
+func formatGoSource(filename, src string, known map[string]string) (string, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return "", fmt.Errorf("生成的代码存在语法错误: %w", err)
+	}
+
+	// 文件中未解析到声明的标识符作为选择器的接收者时，视为引用了包
+	used := make(map[string]bool)
+	ast.Inspect(file, func(node ast.Node) bool {
+		if sel, ok := node.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok && ident.Obj == nil {
+				used[ident.Name] = true
+			}
+		}
+		return true
+	})
+
+	imported := make(map[string]bool)
+	var unused []*ast.ImportSpec
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		name, exact := guessPackageName(importPath)
+		if spec.Name != nil {
+			name, exact = spec.Name.Name, true
+		}
+		if name == "_" || name == "." || importPath == "C" {
+			continue
+		}
+		if exact && !used[name] {
+			unused = append(unused, spec)
+			continue
+		}
+		imported[name] = true
+	}
+	for _, spec := range unused {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		astutil.DeleteNamedImport(fset, file, importName(spec), importPath)
+	}
+
+	names := make([]string, 0, len(used))
+	for name := range used {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var declared map[string]bool
+	for _, name := range names {
+		if imported[name] || file.Scope.Lookup(name) != nil {
+			continue
+		}
+		importPath, ok := known[name]
+		if !ok {
+			importPath, ok = stdImports[name]
+		}
+		if !ok {
+			continue
+		}
+		// 同一包的其他文件中声明的同名标识符不是包引用
+		if declared == nil {
+			declared = packageDecls(filename)
+		}
+		if declared[name] {
+			continue
+		}
+		if guessed, _ := guessPackageName(importPath); guessed == name {
+			astutil.AddImport(fset, file, importPath)
+		} else {
+			astutil.AddNamedImport(fset, file, name, importPath)
+		}
+	}
+	ast.SortImports(fset, file)
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return "", fmt.Errorf("格式化生成的代码失败: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// packageDecls 返回 filename 所在目录中其他 Go 文件的顶层声明名称
+func packageDecls(filename string) map[string]bool {
+	declared := make(map[string]bool)
+	files, _ := filepath.Glob(filepath.Join(filepath.Dir(filename), "*.go"))
+	for _, other := range files {
+		if filepath.Base(other) == filepath.Base(filename) {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), other, nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil {
+					declared[decl.Name.Name] = true
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						declared[spec.Name.Name] = true
+					case *ast.ValueSpec:
+						for _, name := range spec.Names {
+							declared[name.Name] = true
+						}
+					}
+				}
+			}
+		}
+	}
+	return declared
+}
+
+// importName 返回导入声明中显式指定的包名，未指定时为空
+func importName(spec *ast.ImportSpec) string {
+	if spec.Name == nil {
+		return ""
+	}
+	return spec.Name.Name
+}
+
+// guessPackageName 由 import 路径推断包名，exact 表示路径的最后一段就是合法的包名
+// 否则按 goimports 的惯例推断：跳过 /v2 等版本后缀，去掉 go- 前缀和第一个非标识符字符之后的部分，
+// 如 github.com/segmentio/kafka-go -> kafka、gopkg.in/yaml.v3 -> yaml、github.com/labstack/echo/v4 -> echo
+func guessPackageName(importPath string) (name string, exact bool) {
+	base := path.Base(importPath)
+	if token.IsIdentifier(base) && !isMajorVersion(base) {
+		return base, true
+	}
+	if isMajorVersion(base) {
+		base = path.Base(path.Dir(importPath))
+	}
+	base = strings.TrimPrefix(base, "go-")
+	if idx := strings.IndexFunc(base, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	}); idx != -1 {
+		base = base[:idx]
+	}
+	return base, false
+}
+
+// isMajorVersion 判断路径段是否为模块的主版本后缀，如 v2
+func isMajorVersion(segment string) bool {
+	if len(segment) < 2 || segment[0] != 'v' {
+		return false
+	}
+	_, err := strconv.Atoi(segment[1:])
+	return err == nil
+}
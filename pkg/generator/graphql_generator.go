@@ -0,0 +1,1640 @@
+package generator
+
+import (
+	"fmt"
+	"go/token"
+	"path/filepath"
+	"slices"
+	"soliton/pkg/framework"
+	"soliton/pkg/metadata"
+	"strings"
+	"unicode"
+)
+
+// GraphQLGenerator GraphQL 服务生成器
+//
+// 为通过 GraphQL 暴露的聚合根生成 schema.graphql、gqlgen 配置，以及调用领域服务的解析器（resolver）。
+// 解析器实现 gqlgen 按 schema 生成的接口，编译前需要先运行 gqlgen 生成 generated 包（命令见 schema.graphql 的文件头）。
+// 与 HTTPHandlerGenerator 共用暴露范围、操作和请求/响应字段的计算，按 +soliton:api 的 ops、exclude 生成启用的字段：
+//   - Query.{aggregateName}：按主键查询，不存在时为 null；单列主键的参数为 id，复合主键为各主键字段
+//   - Query.{pluralName}：分页查询，page、pageSize 为空时取默认值
+//   - Mutation.create{AggregateName}：新增，声明了 +soliton:default 的字段在 Create{AggregateName}Input 中可以为 null，此时取默认值
+//   - Mutation.update{AggregateName}：更新，Update{AggregateName}Input 中为 null 的字段保持原值；主键和 +soliton:immutable 字段不可修改
+//   - Mutation.delete{AggregateName}：删除
+//
+// 关联：同一限界上下文内对外暴露的一对多关联实体（见 loadableRelations）和纯关联表的多对多关联（两侧均为 int64 主键）
+// 生成为返回 {Target}Connection 的字段，按 first、after 游标分页。同一请求内对关联字段的加载通过 framework.Loader 合并：
+// 一对多调用仓储的 Load{Field}，多对多先查关联表再调用 GetByIDs，避免逐个查询（N+1）。
+//
+// 类型映射：单列主键为 ID（Go 中为 string），整数为 Int（uint、uint64 除外），浮点数为 Float，time.Time 为 Time，
+// 指针可以为 null，切片为列表，结构体值对象为 {Name} 和 {Name}Input，uuid.UUID 等其他已知标量类型为 String，
+// 枚举字段的取值写在字段说明里。无法映射的字段（map、定长数组、sql.NullXxx 等）不出现在类型中，列在类型的说明里。
+// 领域服务返回的错误转换为 GraphQL 错误，extensions.code 与 REST 接口的错误码一致。
+//
+// 生成文件（声明了 +soliton:context 的聚合根输出到 interfaces/{context}/graph）：
+//   - interfaces/graph/schema.graphql、gqlgen.yml：限界上下文内全部类型和字段，以及 gqlgen 的配置
+//   - interfaces/graph/types/types.go：各类型的 Go 结构体，在 gqlgen.yml 中绑定为类型的模型
+//   - interfaces/graph/resolver.go：Resolver 及其依赖的领域服务、仓储和关联表
+//   - interfaces/graph/{AggregateName}Resolver.go：查询、修改字段的解析器和转换函数
+//   - interfaces/graph/relations.go、convert.go：关联字段的解析器和数据加载器、转换辅助函数和错误转换
+type GraphQLGenerator struct {
+	fileOutput
+	registry *metadata.AggregateMetadataRegistry
+}
+
+// NewGraphQLGenerator 创建 GraphQL 服务生成器
+func NewGraphQLGenerator() *GraphQLGenerator {
+	return &GraphQLGenerator{}
+}
+
+// SetRegistry 设置聚合根注册表，用于生成关联字段和在 schema 中注明枚举字段的取值
+func (g *GraphQLGenerator) SetRegistry(registry *metadata.AggregateMetadataRegistry) {
+	g.registry = registry
+}
+
+// Generate 为聚合根生成查询、修改字段的解析器
+func (g *GraphQLGenerator) Generate(agg *metadata.AggregateMetadata, outputDir string) error {
+	absOutputDir, _ := filepath.Abs(outputDir)
+	scope := newGraphScope([]*metadata.AggregateMetadata{agg}, absOutputDir)
+
+	file, err := g.generateResolver(scope, agg)
+	if err != nil {
+		return err
+	}
+
+	filePath := filepath.Join(graphDir(agg, absOutputDir), fmt.Sprintf("%sResolver.go", agg.Name))
+	if err := g.writeTemplate(filePath, "graphql_resolver", file); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return nil
+}
+
+// GenerateService 为限界上下文 boundedContext 生成 schema、gqlgen 配置、类型和共用的解析器文件，aggregates 为全部对外暴露的聚合根
+func (g *GraphQLGenerator) GenerateService(aggregates []*metadata.AggregateMetadata, outputDir, boundedContext string) error {
+	var members []*metadata.AggregateMetadata
+	for _, agg := range aggregates {
+		if agg.Context() == boundedContext {
+			members = append(members, agg)
+		}
+	}
+	if len(members) == 0 {
+		return fmt.Errorf("限界上下文 %q 中没有对外暴露的聚合根", boundedContext)
+	}
+
+	absOutputDir, _ := filepath.Abs(outputDir)
+	dir := graphDir(members[0], absOutputDir)
+	relations := g.relations(members)
+
+	schema, err := g.generateSchema(newGraphScope(members, absOutputDir), members, relations, filepath.Join(dir, "gqlgen.yml"))
+	if err != nil {
+		return err
+	}
+	schema.Aggregates, schema.Context = members, boundedContext
+	if err := g.writeTemplate(filepath.Join(dir, "schema.graphql"), "graphql_schema", schema); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	config := g.generateConfig(newGraphScope(members, absOutputDir), members, relations)
+	config.Aggregates, config.Context = members, boundedContext
+	if err := g.writeTemplate(filepath.Join(dir, "gqlgen.yml"), "graphql_config", config); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+
+	goFiles := []struct {
+		name     string
+		template string
+		file     *GoFileData
+	}{
+		{filepath.Join("types", "types.go"), "graphql_types", g.generateTypes(newGraphScope(members, absOutputDir), members, relations)},
+		{"resolver.go", "graphql_root", g.generateRoot(newGraphScope(members, absOutputDir), members, relations)},
+		{"relations.go", "graphql_relations", g.generateRelations(newGraphScope(members, absOutputDir), relations)},
+		{"convert.go", "graphql_convert", g.generateConvert(newGraphScope(members, absOutputDir))},
+	}
+	for _, f := range goFiles {
+		f.file.Aggregates, f.file.Context = members, boundedContext
+		if err := g.writeTemplate(filepath.Join(dir, f.name), f.template, f.file); err != nil {
+			return fmt.Errorf("写入文件失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// graphDir 返回聚合根的 GraphQL 解析器所在目录，如 interfaces/ordering/graph
+func graphDir(agg *metadata.AggregateMetadata, outputDir string) string {
+	return filepath.Join(interfacesDir(agg, outputDir), "graph")
+}
+
+// graphInitialisms gqlgen 生成 Go 名称时整体大写的缩写
+var graphInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "CSV": true, "DNS": true, "EOF": true,
+	"GUID": true, "HTML": true, "HTTP": true, "HTTPS": true, "ICMP": true, "ID": true, "IP": true, "JSON": true,
+	"KVK": true, "LHS": true, "PDF": true, "PGP": true, "QPS": true, "QR": true, "RAM": true, "RHS": true,
+	"RPC": true, "SLA": true, "SMTP": true, "SQL": true, "SSH": true, "SVG": true, "TCP": true, "TLS": true,
+	"TTL": true, "UDP": true, "UI": true, "UID": true, "URI": true, "URL": true, "UTF8": true, "UUID": true,
+	"VM": true, "XML": true, "XMPP": true, "XSRF": true, "XSS": true,
+}
+
+// graphGoName 返回 gqlgen 为 GraphQL 字段名生成的 Go 名称（解析器的方法名），如 createOrder → CreateOrder、userIDs → UserIDs
+func graphGoName(name string) string {
+	runes := []rune(name)
+	var words []string
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		boundary := i == len(runes) || runes[i] == '_' ||
+			unicode.IsUpper(runes[i]) && (!unicode.IsUpper(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1]))
+		if !boundary {
+			continue
+		}
+		if word := strings.Trim(string(runes[start:i]), "_"); word != "" {
+			words = append(words, word)
+		}
+		start = i
+	}
+
+	var sb strings.Builder
+	for _, word := range words {
+		upper := strings.ToUpper(word)
+		switch {
+		case graphInitialisms[upper]:
+			sb.WriteString(upper)
+		case len(upper) > 1 && strings.HasSuffix(upper, "S") && graphInitialisms[upper[:len(upper)-1]]:
+			sb.WriteString(upper[:len(upper)-1] + "s")
+		default:
+			sb.WriteString(toUpperFirst(word))
+		}
+	}
+	return sb.String()
+}
+
+// graphParam 返回 GraphQL 参数在 Go 代码中的参数名，与关键字冲突时加后缀 Arg
+func graphParam(name string) string {
+	if token.IsKeyword(name) {
+		return name + "Arg"
+	}
+	return name
+}
+
+// graphScope 生成一个文件时的上下文：值对象和用到的包与 gRPC 生成器的计算相同
+type graphScope struct {
+	*protoScope
+	typesImport     string // 类型所在 types 包的 import 路径
+	generatedImport string // gqlgen 生成的 generated 包的 import 路径
+	objectFields    map[string][]*graphField
+}
+
+// newGraphScope 创建 graphScope，收集 aggregates 字段中定义在领域模型包内的结构体值对象（含嵌套的值对象）
+func newGraphScope(aggregates []*metadata.AggregateMetadata, outputDir string) *graphScope {
+	agg := aggregates[0]
+	dir := graphDir(agg, outputDir)
+	return &graphScope{
+		protoScope:      newProtoScope(aggregates, outputDir),
+		typesImport:     calculateImportPath(agg.ModuleName, agg.ModuleRoot, filepath.Join(dir, "types")),
+		generatedImport: calculateImportPath(agg.ModuleName, agg.ModuleRoot, filepath.Join(dir, "generated")),
+		objectFields:    make(map[string][]*graphField),
+	}
+}
+
+// fieldsOf 返回值对象中可以映射的字段，没有可以映射的字段时值对象不出现在 schema 中
+func (s *graphScope) fieldsOf(object *protoValueObject) []*graphField {
+	if fields, ok := s.objectFields[object.name]; ok {
+		return fields
+	}
+	// 先占位，嵌套引用自身的值对象按无法映射处理
+	s.objectFields[object.name] = nil
+	var fields []*graphField
+	for _, member := range object.fields {
+		// 与 OpenAPI 一致，json:"-" 的字段不对外暴露
+		if member.JSONTag == "-" {
+			continue
+		}
+		if f := s.field(object.agg, member); f != nil && !f.fallible {
+			fields = append(fields, f)
+		}
+	}
+	s.objectFields[object.name] = fields
+	return fields
+}
+
+// graphObjects 返回出现在 schema 中的值对象，按名称排序
+func (s *graphScope) graphObjects() []*protoValueObject {
+	var objects []*protoValueObject
+	for _, object := range s.sortedValueObjects() {
+		if len(s.fieldsOf(object)) > 0 {
+			objects = append(objects, object)
+		}
+	}
+	return objects
+}
+
+// graphElement 标量或对象类型的元素（字段本身或切片的元素）在 schema 和 Go 代码中的表示
+type graphElement struct {
+	scope   *graphScope
+	gqlType string // schema 中的输出类型，如 "Int"、"Time"、"Address"
+	input   string // schema 中的输入类型，如 "Int"、"AddressInput"
+	goType  string // 输出结构体中的 Go 类型，如 "int64"、"*types.Address"
+	inputGo string // 输入结构体中的 Go 类型，如 "int64"、"*types.AddressInput"
+	domain  string // 领域对象中的类型，如 "int32"、"model.Address"
+	object  bool   // 是否为值对象
+
+	to, from   string // 转换函数或类型，如 "toAddressType"、"int32"；为空表示类型相同
+	conversion bool   // to、from 为类型转换
+}
+
+// graphScalars 基础类型 → schema 中的标量类型和 Go 类型
+var graphScalars = map[string][2]string{
+	"string":  {"String", "string"},
+	"bool":    {"Boolean", "bool"},
+	"int":     {"Int", "int64"},
+	"int8":    {"Int", "int64"},
+	"int16":   {"Int", "int64"},
+	"int32":   {"Int", "int64"},
+	"rune":    {"Int", "int64"},
+	"int64":   {"Int", "int64"},
+	"uint8":   {"Int", "int64"},
+	"byte":    {"Int", "int64"},
+	"uint16":  {"Int", "int64"},
+	"uint32":  {"Int", "int64"},
+	"float32": {"Float", "float64"},
+	"float64": {"Float", "float64"},
+}
+
+// graphArgTypes gqlgen 为标量类型的参数生成的 Go 类型
+var graphArgTypes = map[string]string{
+	"ID":      "string",
+	"String":  "string",
+	"Boolean": "bool",
+	"Int":     "int",
+	"Float":   "float64",
+	"Time":    "time.Time",
+}
+
+// element 返回带包名的类型 domain（底层类型为 basicType）对应的元素，无法映射时返回 nil
+func (s *graphScope) element(agg *metadata.AggregateMetadata, domain, basicType string) *graphElement {
+	e := &graphElement{scope: s, domain: domain}
+
+	if domain == "time.Time" {
+		e.gqlType, e.input, e.goType, e.inputGo = "Time", "Time", "time.Time", "time.Time"
+		return e
+	}
+
+	if object := s.valueObjects[strings.TrimPrefix(domain, agg.PackageName+".")]; object != nil && object.domain == domain {
+		if len(s.fieldsOf(object)) == 0 {
+			return nil
+		}
+		e.gqlType, e.input, e.object = object.name, object.name+"Input", true
+		e.goType, e.inputGo = "*types."+object.name, "*types."+object.name+"Input"
+		e.to, e.from = "to"+object.name+"Type", "from"+object.name+"Input"
+		return e
+	}
+
+	scalar, ok := graphScalars[basicType]
+	// 其他包中的命名类型无法确定 import 路径
+	if !ok || strings.Contains(domain, ".") && !strings.HasPrefix(domain, agg.PackageName+".") {
+		return nil
+	}
+	e.gqlType, e.input, e.goType, e.inputGo = scalar[0], scalar[0], scalar[1], scalar[1]
+	if domain != e.goType {
+		e.to, e.from, e.conversion = e.goType, domain, true
+	}
+	return e
+}
+
+// toExpr 返回将领域值 value 转换为输出值的表达式
+func (e *graphElement) toExpr(value string) string {
+	if e.to == "" {
+		return value
+	}
+	return e.to + "(" + value + ")"
+}
+
+// fromExpr 返回将输入值 value 转换为领域值的表达式
+func (e *graphElement) fromExpr(value string) string {
+	if e.from == "" {
+		return value
+	}
+	return e.from + "(" + value + ")"
+}
+
+// funcs 返回切片元素的转换函数，类型相同时为空；pointer 为 true 时元素为指向值对象的指针，nil 对应 null
+func (e *graphElement) funcs(pointer bool) (toFunc, fromFunc string) {
+	switch {
+	case pointer:
+		toFunc = fmt.Sprintf("func(v *%s) %s { return convertOptional(v, %s) }", e.domain, e.goType, e.to)
+		fromFunc = fmt.Sprintf("func(in %s) *%s { return convertMessage(in, %s) }", e.inputGo, e.domain, e.from)
+	case e.conversion:
+		toFunc = fmt.Sprintf("func(v %s) %s { return %s }", e.domain, e.goType, e.toExpr("v"))
+		fromFunc = fmt.Sprintf("func(v %s) %s { return %s }", e.inputGo, e.domain, e.fromExpr("v"))
+	default:
+		toFunc, fromFunc = e.to, e.from
+	}
+	return toFunc, fromFunc
+}
+
+// graphField 类型中的字段
+type graphField struct {
+	field   *metadata.FieldMetadata
+	name    string // schema 中的字段名，如 "userID"
+	goName  string // 结构体中的字段名，如 "UserID"
+	gqlType string // 输出类型，如 "Int!"、"[Address!]"
+	input   string // 新增时的输入类型，如 "Int!"、"AddressInput!"
+	goType  string // 输出结构体中的 Go 类型，如 "int64"、"*types.Address"
+	inputGo string // 输入结构体中的 Go 类型，如 "int64"、"[]*types.AddressInput"
+
+	// to 返回将领域值 value 转换为输出值的表达式
+	to func(value string) string
+	// from 返回将输入值 value（类型为 inputGo）写入 target 的语句；fallible 为 true 时语句可能 return err，只能用于返回 error 的函数
+	from     func(target, value string) []string
+	fallible bool
+}
+
+// nullable 判断输入值在 Go 中是否可以为 nil
+func (f *graphField) nullable() bool {
+	return strings.HasPrefix(f.inputGo, "*") || strings.HasPrefix(f.inputGo, "[]")
+}
+
+// field 返回字段的映射，无法映射时返回 nil
+func (s *graphScope) field(agg *metadata.AggregateMetadata, field *metadata.FieldMetadata) *graphField {
+	f := &graphField{field: field, name: jsonName(field.Name), goName: field.Name}
+	assign := func(expr func(value string) string) func(target, value string) []string {
+		return func(target, value string) []string {
+			return []string{target + " = " + expr(value)}
+		}
+	}
+	domain := qualifyType(field.Type, agg.PackageName)
+
+	switch {
+	case field.IsMap || field.IsArray || field.IsSlice && field.Type == "byte" && !field.IsPointer,
+		strings.HasPrefix(field.Type, "sql.Null"), field.Type == "time.Duration", field.Type == "json.RawMessage":
+		return nil
+
+	case field.IsSlice:
+		elem := s.element(agg, domain, field.BasicType())
+		if elem == nil || field.IsPointer && !elem.object {
+			return nil
+		}
+		required := "!"
+		if field.IsPointer {
+			required = ""
+		}
+		f.gqlType, f.input = "["+elem.gqlType+required+"]", "["+elem.input+required+"]"
+		f.goType, f.inputGo = "[]"+elem.goType, "[]"+elem.inputGo
+		toFunc, fromFunc := elem.funcs(field.IsPointer)
+		f.to = func(value string) string {
+			if toFunc != "" {
+				return fmt.Sprintf("convertSlice(%s, %s)", value, toFunc)
+			}
+			return value
+		}
+		f.from = assign(func(value string) string {
+			if fromFunc != "" {
+				return fmt.Sprintf("convertSlice(%s, %s)", value, fromFunc)
+			}
+			return value
+		})
+		return f
+	}
+
+	elem := s.element(agg, domain, field.BasicType())
+	if elem == nil {
+		return s.textField(f, field)
+	}
+	f.gqlType, f.input, f.goType, f.inputGo = elem.gqlType+"!", elem.input+"!", elem.goType, elem.inputGo
+	switch {
+	case field.IsPointer && elem.object:
+		f.gqlType, f.input = elem.gqlType, elem.input
+		f.to = func(value string) string { return fmt.Sprintf("convertOptional(%s, %s)", value, elem.to) }
+		f.from = assign(func(value string) string { return fmt.Sprintf("convertMessage(%s, %s)", value, elem.from) })
+	case field.IsPointer:
+		f.gqlType, f.input, f.goType, f.inputGo = elem.gqlType, elem.input, "*"+elem.goType, "*"+elem.inputGo
+		toFunc, fromFunc := elem.funcs(false)
+		f.to = func(value string) string {
+			if toFunc != "" {
+				return fmt.Sprintf("convertPtr(%s, %s)", value, toFunc)
+			}
+			return value
+		}
+		f.from = assign(func(value string) string {
+			if fromFunc != "" {
+				return fmt.Sprintf("convertPtr(%s, %s)", value, fromFunc)
+			}
+			return value
+		})
+	default:
+		f.to = elem.toExpr
+		f.from = assign(elem.fromExpr)
+	}
+	return f
+}
+
+// textField 映射其他已知标量类型（如 uuid.UUID）的字段：通过 String、UnmarshalText 与 String 转换，空字符串为零值
+func (s *graphScope) textField(f *graphField, field *metadata.FieldMetadata) *graphField {
+	if field.ScalarType == nil || field.ScalarType.ImportPath == "" || field.IsPointer {
+		return nil
+	}
+	f.gqlType, f.input, f.goType, f.inputGo, f.fallible = "String!", "String!", "string", "string", true
+	f.to = func(value string) string { return value + ".String()" }
+	f.from = func(target, value string) []string {
+		s.use(field.ScalarType.ImportPath)
+		return []string{
+			fmt.Sprintf("if %s, err = parseText[%s](%q, %s); err != nil {", target, field.Type, f.name, value),
+			"\treturn err",
+			"}",
+		}
+	}
+	return f
+}
+
+// graphKey 主键在参数和输出中的表示
+type graphKey struct {
+	fields []*graphField // 复合主键的各字段，单列主键时为 id
+	agg    *metadata.AggregateMetadata
+}
+
+// keyOf 返回聚合根的主键：单列主键为 ID 类型的 id 字段，复合主键为各主键字段（须为非指针的标量）
+func (s *graphScope) keyOf(agg *metadata.AggregateMetadata) (*graphKey, error) {
+	if len(agg.PrimaryKey) == 0 {
+		return nil, fmt.Errorf("聚合根 %s 没有主键", agg.Name)
+	}
+	key := &graphKey{agg: agg}
+	if agg.IsCompositeKey() {
+		for _, field := range agg.PrimaryKey {
+			f := s.field(agg, field)
+			if f == nil || f.fallible || !strings.HasSuffix(f.gqlType, "!") || graphArgTypes[strings.TrimSuffix(f.gqlType, "!")] == "" {
+				return nil, fmt.Errorf("聚合根 %s 的主键字段 %s 的类型 %s 不能映射为 GraphQL 标量", agg.Name, field.Name, field.Type)
+			}
+			key.fields = append(key.fields, f)
+		}
+		return key, nil
+	}
+
+	field := agg.PrimaryKey[0]
+	domain := qualifiedKeyType(agg)
+	f := &graphField{field: field, name: "id", goName: "ID", gqlType: "ID!", input: "ID!", goType: "string", inputGo: "string"}
+	switch {
+	case isIntegerType(field.BasicType()) && field.BasicType() != "uint" && field.BasicType() != "uint64" && !field.IsPointer:
+		f.fallible = true
+		f.to = func(value string) string {
+			s.use("strconv")
+			if field.Type == "int64" {
+				return fmt.Sprintf("strconv.FormatInt(%s, 10)", value)
+			}
+			return fmt.Sprintf("strconv.FormatInt(int64(%s), 10)", value)
+		}
+		f.from = func(target, value string) []string {
+			return []string{
+				fmt.Sprintf("if %s, err = parseIntID[%s](%q, %s); err != nil {", target, domain, f.name, value),
+				"\treturn err",
+				"}",
+			}
+		}
+	case field.BasicType() == "string" && !field.IsPointer:
+		f.to = func(value string) string {
+			if field.Type != "string" {
+				return "string(" + value + ")"
+			}
+			return value
+		}
+		f.from = func(target, value string) []string {
+			if field.Type != "string" {
+				value = domain + "(" + value + ")"
+			}
+			return []string{target + " = " + value}
+		}
+	default:
+		text := s.textField(&graphField{name: "id"}, field)
+		if text == nil {
+			return nil, fmt.Errorf("聚合根 %s 的主键字段 %s 的类型 %s 不能映射为 GraphQL ID", agg.Name, field.Name, field.Type)
+		}
+		f.fallible, f.to, f.from = true, text.to, text.from
+	}
+	key.fields = []*graphField{f}
+	return key, nil
+}
+
+// params 返回主键参数在 schema 和 Go 解析器方法中的声明，如 "id: ID!"、"id string"
+func (k *graphKey) params() (schema, goParams []string) {
+	for _, f := range k.fields {
+		base := strings.TrimSuffix(f.gqlType, "!")
+		schema = append(schema, fmt.Sprintf("%s: %s", f.name, f.gqlType))
+		goParams = append(goParams, fmt.Sprintf("%s %s", graphParam(f.name), graphArgTypes[base]))
+	}
+	return schema, goParams
+}
+
+// statements 返回由参数（或 value 返回的表达式）构造主键变量 key 的语句，返回 error 时为 onError 中的 return 语句
+func (k *graphKey) statements(value func(param string) string, onError string) []string {
+	if !k.agg.IsCompositeKey() {
+		f := k.fields[0]
+		lines := f.from("key", value(graphParam(f.name)))
+		if !f.fallible {
+			return []string{strings.Replace(lines[0], " = ", " := ", 1)}
+		}
+		// 将 if key, err = parse...; err != nil { 改写为先声明再判断
+		expr := strings.TrimSuffix(strings.TrimPrefix(lines[0], "if key, err = "), "; err != nil {")
+		return []string{"key, err := " + expr, "if err != nil {", "\t" + onError, "}"}
+	}
+
+	values := make([]string, len(k.fields))
+	for i, f := range k.fields {
+		param := value(graphParam(f.name))
+		base := strings.TrimSuffix(f.gqlType, "!")
+		domain := qualifyType(f.field.Type, k.agg.PackageName)
+		if graphArgTypes[base] != domain {
+			param = domain + "(" + param + ")"
+		}
+		values[i] = fmt.Sprintf("%s: %s", f.field.Name, param)
+	}
+	return []string{fmt.Sprintf("key := %s{%s}", qualifiedKeyType(k.agg), strings.Join(values, ", "))}
+}
+
+// graphMessage 聚合根的类型字段：输出类型（{AggregateName}）和新增、更新输入中的字段，以及无法映射的字段
+type graphMessage struct {
+	outputs     []*graphField
+	inputs      []*graphField
+	readOnly    map[*graphField]bool // 只在新增时写入的字段
+	presence    map[*graphField]bool // 新增时可以为 null、此时取默认值的字段（声明了 +soliton:default）
+	unsupported []*metadata.FieldMetadata
+}
+
+// message 计算聚合根的类型字段，单列主键替换为 id
+func (s *graphScope) message(agg *metadata.AggregateMetadata, key *graphKey) *graphMessage {
+	m := &graphMessage{readOnly: make(map[*graphField]bool), presence: make(map[*graphField]bool)}
+	mapField := func(field *metadata.FieldMetadata) *graphField {
+		if !agg.IsCompositeKey() && agg.InPrimaryKey(field) {
+			return key.fields[0]
+		}
+		return s.field(agg, field)
+	}
+
+	for _, dto := range responseFields(agg) {
+		if f := mapField(dto.field); f != nil {
+			m.outputs = append(m.outputs, f)
+		} else {
+			m.unsupported = append(m.unsupported, dto.field)
+		}
+	}
+	for _, dto := range requestFields(agg) {
+		f := mapField(dto.field)
+		if f == nil {
+			if !slices.Contains(m.unsupported, dto.field) {
+				m.unsupported = append(m.unsupported, dto.field)
+			}
+			continue
+		}
+		m.inputs = append(m.inputs, f)
+		m.readOnly[f] = dto.readOnly
+		if _, ok := dto.field.DefaultLiteral(); ok && !f.field.IsSlice && !f.field.Annotations.IsValueObject {
+			m.presence[f] = true
+		}
+	}
+	return m
+}
+
+// createType 返回字段在 Create{AggregateName}Input 中的 schema 类型和 Go 类型
+func (m *graphMessage) createType(f *graphField) (string, string) {
+	if !m.presence[f] {
+		return f.input, f.inputGo
+	}
+	if f.nullable() {
+		return strings.TrimSuffix(f.input, "!"), f.inputGo
+	}
+	return strings.TrimSuffix(f.input, "!"), "*" + f.inputGo
+}
+
+// updateType 返回字段在 Update{AggregateName}Input 中的 schema 类型和 Go 类型，均可以为 null
+func (m *graphMessage) updateType(f *graphField) (string, string) {
+	if f.nullable() {
+		return strings.TrimSuffix(f.input, "!"), f.inputGo
+	}
+	return strings.TrimSuffix(f.input, "!"), "*" + f.inputGo
+}
+
+// mutable 返回更新时可以写入的字段
+func (m *graphMessage) mutable() []*graphField {
+	var fields []*graphField
+	for _, f := range m.inputs {
+		if !m.readOnly[f] {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// graphOperation Query 或 Mutation 中的字段
+type graphOperation struct {
+	op     string
+	parent string // "Query" 或 "Mutation"
+	name   string // 字段名，如 createOrder
+}
+
+// method 返回解析器的方法名
+func (o graphOperation) method() string {
+	return graphGoName(o.name)
+}
+
+// graphOperations 返回聚合根启用的字段，按 APIOps 的顺序排列；没有可以更新的字段时不生成 update
+func graphOperations(agg *metadata.AggregateMetadata, message *graphMessage) []graphOperation {
+	var operations []graphOperation
+	for _, op := range apiOperations(agg) {
+		switch op {
+		case metadata.APIOpCreate:
+			operations = append(operations, graphOperation{op, "Mutation", "create" + agg.Name})
+		case metadata.APIOpGet:
+			operations = append(operations, graphOperation{op, "Query", jsonName(agg.Name)})
+		case metadata.APIOpList:
+			operations = append(operations, graphOperation{op, "Query", jsonName(agg.PluralName())})
+		case metadata.APIOpUpdate:
+			if len(message.mutable()) > 0 {
+				operations = append(operations, graphOperation{op, "Mutation", "update" + agg.Name})
+			}
+		case metadata.APIOpDelete:
+			operations = append(operations, graphOperation{op, "Mutation", "delete" + agg.Name})
+		}
+	}
+	return operations
+}
+
+// graphRelation 关联字段：一对多关联实体或多对多关联的另一侧，返回 {Target}Connection
+type graphRelation struct {
+	source *metadata.AggregateMetadata
+	target *metadata.AggregateMetadata
+	name   string // schema 中的字段名，如 "items"、"roles"
+	loader string // 数据加载器名，如 "orderItems"
+
+	relation *metadata.RelationMetadata        // 一对多关系，多对多时为 nil
+	table    *metadata.ManyToManyTableMetadata // 多对多关联表
+	left     bool                              // source 为关联表的左侧
+}
+
+// method 返回关联字段解析器的方法名
+func (r *graphRelation) method() string {
+	return graphGoName(r.name)
+}
+
+// links 返回 Resolver 中多对多关联表的字段名，如 UserRoleLinks
+func (r *graphRelation) links() string {
+	return r.table.LeftAggregate + r.table.RightAggregate + "Links"
+}
+
+// relations 返回限界上下文内的关联字段：
+// 一对多要求关联实体同样对外暴露（见 loadableRelations）且切片元素为指针，多对多要求纯关联表两侧均对外暴露且为 int64 主键
+func (g *GraphQLGenerator) relations(members []*metadata.AggregateMetadata) []*graphRelation {
+	if g.registry == nil {
+		return nil
+	}
+	byName := make(map[string]*metadata.AggregateMetadata, len(members))
+	for _, agg := range members {
+		byName[agg.Name] = agg
+	}
+
+	var relations []*graphRelation
+	// 关联字段名与聚合根自身的字段或已有的关联字段冲突时跳过
+	add := func(r *graphRelation) {
+		for _, field := range r.source.MappedFields() {
+			if !field.Annotations.IsEntity && jsonName(field.Name) == r.name {
+				return
+			}
+		}
+		for _, other := range relations {
+			if other.source == r.source && (other.name == r.name || other.loader == r.loader) {
+				return
+			}
+		}
+		relations = append(relations, r)
+	}
+
+	for _, agg := range members {
+		for _, rel := range loadableRelations(g.registry, agg) {
+			target := byName[rel.TargetAggregate]
+			if target == nil || !rel.Field.IsSlice || !rel.Field.IsPointer {
+				continue
+			}
+			add(&graphRelation{source: agg, target: target, name: jsonName(rel.Field.Name),
+				loader: toLowerFirst(agg.Name) + rel.Field.Name, relation: rel})
+		}
+
+		for _, table := range g.registry.GetManyToManyTables() {
+			if table.GenerationType != "relation_only" || table.LeftAggregate == table.RightAggregate {
+				continue
+			}
+			left, right := byName[table.LeftAggregate], byName[table.RightAggregate]
+			if left == nil || right == nil || !int64Key(left) || !int64Key(right) {
+				continue
+			}
+			switch agg {
+			case left:
+				add(&graphRelation{source: left, target: right, name: jsonName(right.PluralName()),
+					loader: toLowerFirst(left.Name) + right.PluralName(), table: table, left: true})
+			case right:
+				add(&graphRelation{source: right, target: left, name: jsonName(left.PluralName()),
+					loader: toLowerFirst(right.Name) + left.PluralName(), table: table})
+			}
+		}
+	}
+	return relations
+}
+
+// int64Key 判断聚合根是否为 int64 单列主键（多对多关联表中的 ID 类型）
+func int64Key(agg *metadata.AggregateMetadata) bool {
+	return !agg.IsCompositeKey() && qualifiedKeyType(agg) == "int64"
+}
+
+// relationsOf 返回聚合根上的关联字段
+func relationsOf(relations []*graphRelation, agg *metadata.AggregateMetadata) []*graphRelation {
+	var result []*graphRelation
+	for _, r := range relations {
+		if r.source == agg {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// connectionTargets 返回作为关联字段目标、需要生成 {Target}Connection 的聚合根，按 members 的顺序排列
+func connectionTargets(members []*metadata.AggregateMetadata, relations []*graphRelation) []*metadata.AggregateMetadata {
+	var targets []*metadata.AggregateMetadata
+	for _, agg := range members {
+		if slices.ContainsFunc(relations, func(r *graphRelation) bool { return r.target == agg }) {
+			targets = append(targets, agg)
+		}
+	}
+	return targets
+}
+
+// generateSchema 生成限界上下文的 schema.graphql
+func (g *GraphQLGenerator) generateSchema(scope *graphScope, members []*metadata.AggregateMetadata, relations []*graphRelation,
+	configPath string) (*GraphQLSchemaData, error) {
+	query := &GraphQLType{Kind: "type", Name: "Query"}
+	mutation := &GraphQLType{Kind: "type", Name: "Mutation"}
+	var types []*GraphQLType
+
+	for _, agg := range members {
+		key, err := scope.keyOf(agg)
+		if err != nil {
+			return nil, err
+		}
+		message := scope.message(agg, key)
+		keyParams, _ := key.params()
+		keyArgs := strings.Join(keyParams, ", ")
+
+		for _, op := range graphOperations(agg, message) {
+			switch op.op {
+			case metadata.APIOpGet:
+				query.Fields = append(query.Fields, &GraphQLField{Description: fmt.Sprintf("按主键查询 %s，不存在时为 null", agg.Name),
+					Name: op.name, Args: keyArgs, Type: agg.Name})
+			case metadata.APIOpList:
+				query.Fields = append(query.Fields, &GraphQLField{
+					Description: fmt.Sprintf("分页查询 %s，page 从 1 开始，pageSize 为空时取 %d，最大 %d", agg.Name, framework.DefaultPageSize, framework.MaxPageSize),
+					Name:        op.name, Args: "page: Int, pageSize: Int", Type: agg.Name + "Page!"})
+			case metadata.APIOpCreate:
+				field := &GraphQLField{Description: fmt.Sprintf("新增 %s", agg.Name), Name: op.name, Type: agg.Name + "!"}
+				if len(message.inputs) > 0 {
+					field.Args = fmt.Sprintf("input: Create%sInput!", agg.Name)
+				}
+				mutation.Fields = append(mutation.Fields, field)
+			case metadata.APIOpUpdate:
+				mutation.Fields = append(mutation.Fields, &GraphQLField{Description: fmt.Sprintf("更新 %s，输入中为 null 的字段保持原值", agg.Name),
+					Name: op.name, Args: fmt.Sprintf("%s, input: Update%sInput!", keyArgs, agg.Name), Type: agg.Name + "!"})
+			case metadata.APIOpDelete:
+				mutation.Fields = append(mutation.Fields, &GraphQLField{Description: fmt.Sprintf("删除 %s", agg.Name),
+					Name: op.name, Args: keyArgs, Type: "Boolean!"})
+			}
+		}
+
+		// 输出类型
+		object := &GraphQLType{Description: fmt.Sprintf("%s 查询、新增和更新返回的对象", agg.Name), Kind: "type", Name: agg.Name}
+		if len(message.unsupported) > 0 {
+			object.Description += "。" + unsupportedComment(message.unsupported)
+		}
+		for _, f := range message.outputs {
+			object.Fields = append(object.Fields, &GraphQLField{Description: g.enumDescription(agg, f.field), Name: f.name, Type: f.gqlType})
+		}
+		for _, r := range relationsOf(relations, agg) {
+			object.Fields = append(object.Fields, &GraphQLField{
+				Description: fmt.Sprintf("%s 关联的 %s，按 first、after 分页，first 为空时取 %d，最大 %d",
+					agg.Name, r.target.Name, framework.DefaultPageSize, framework.MaxPageSize),
+				Name: r.name, Args: "first: Int, after: String", Type: r.target.Name + "Connection!"})
+		}
+		types = append(types, object)
+
+		ops := graphOperations(agg, message)
+		if slices.ContainsFunc(ops, func(o graphOperation) bool { return o.op == metadata.APIOpList }) {
+			types = append(types, &GraphQLType{Description: fmt.Sprintf("%s 的分页查询结果", agg.Name), Kind: "type", Name: agg.Name + "Page",
+				Fields: []*GraphQLField{
+					{Name: "items", Type: fmt.Sprintf("[%s!]!", agg.Name)},
+					{Description: "总数", Name: "total", Type: "Int!"},
+					{Name: "page", Type: "Int!"},
+					{Name: "pageSize", Type: "Int!"},
+				}})
+		}
+		if slices.ContainsFunc(ops, func(o graphOperation) bool { return o.op == metadata.APIOpCreate }) && len(message.inputs) > 0 {
+			input := &GraphQLType{Description: fmt.Sprintf("新增 %s 时写入的字段", agg.Name), Kind: "input", Name: fmt.Sprintf("Create%sInput", agg.Name)}
+			for _, f := range message.inputs {
+				notes := []string{g.enumDescription(agg, f.field)}
+				if message.presence[f] {
+					notes = append(notes, fmt.Sprintf("为 null 时取默认值 %s", f.field.Annotations.Default))
+				}
+				gqlType, _ := message.createType(f)
+				input.Fields = append(input.Fields, &GraphQLField{Description: graphDescription(notes...), Name: f.name, Type: gqlType})
+			}
+			types = append(types, input)
+		}
+		if slices.ContainsFunc(ops, func(o graphOperation) bool { return o.op == metadata.APIOpUpdate }) {
+			input := &GraphQLType{Description: fmt.Sprintf("更新 %s 时写入的字段，为 null 的字段保持原值", agg.Name), Kind: "input",
+				Name: fmt.Sprintf("Update%sInput", agg.Name)}
+			for _, f := range message.mutable() {
+				gqlType, _ := message.updateType(f)
+				input.Fields = append(input.Fields, &GraphQLField{Description: g.enumDescription(agg, f.field), Name: f.name, Type: gqlType})
+			}
+			types = append(types, input)
+		}
+	}
+	if len(query.Fields) == 0 {
+		return nil, fmt.Errorf("限界上下文中没有启用查询（get、list）的聚合根，GraphQL schema 必须包含 Query")
+	}
+
+	// 关联字段的分页类型
+	for _, target := range connectionTargets(members, relations) {
+		types = append(types,
+			&GraphQLType{Description: fmt.Sprintf("%s 的分页列表", target.Name), Kind: "type", Name: target.Name + "Connection",
+				Fields: []*GraphQLField{
+					{Name: "edges", Type: fmt.Sprintf("[%sEdge!]!", target.Name)},
+					{Name: "pageInfo", Type: "PageInfo!"},
+					{Description: "列表的总数", Name: "totalCount", Type: "Int!"},
+				}},
+			&GraphQLType{Kind: "type", Name: target.Name + "Edge",
+				Fields: []*GraphQLField{
+					{Description: "游标，作为 after 参数查询下一页", Name: "cursor", Type: "String!"},
+					{Name: "node", Type: target.Name + "!"},
+				}})
+	}
+	if len(relations) > 0 {
+		types = append(types, &GraphQLType{Description: "分页信息", Kind: "type", Name: "PageInfo",
+			Fields: []*GraphQLField{
+				{Name: "hasNextPage", Type: "Boolean!"},
+				{Name: "hasPreviousPage", Type: "Boolean!"},
+				{Name: "startCursor", Type: "String"},
+				{Name: "endCursor", Type: "String"},
+			}})
+	}
+
+	for _, object := range scope.graphObjects() {
+		output := &GraphQLType{Description: fmt.Sprintf("%s 值对象", object.name), Kind: "type", Name: object.name}
+		input := &GraphQLType{Description: fmt.Sprintf("%s 值对象的输入", object.name), Kind: "input", Name: object.name + "Input"}
+		var unsupported []*metadata.FieldMetadata
+		fields := scope.fieldsOf(object)
+		for _, member := range object.fields {
+			if member.JSONTag != "-" && !slices.ContainsFunc(fields, func(f *graphField) bool { return f.field == member }) {
+				unsupported = append(unsupported, member)
+			}
+		}
+		for _, f := range fields {
+			output.Fields = append(output.Fields, &GraphQLField{Name: f.name, Type: f.gqlType})
+			input.Fields = append(input.Fields, &GraphQLField{Name: f.name, Type: f.input})
+		}
+		if len(unsupported) > 0 {
+			output.Description += "。" + unsupportedComment(unsupported)
+		}
+		types = append(types, output, input)
+	}
+
+	if len(mutation.Fields) > 0 {
+		types = append([]*GraphQLType{mutation}, types...)
+	}
+	types = append([]*GraphQLType{query}, types...)
+
+	relPath, err := filepath.Rel(members[0].ModuleRoot, configPath)
+	if err != nil {
+		relPath = configPath
+	}
+	return &GraphQLSchemaData{Config: filepath.ToSlash(relPath), Time: usesTime(types), Types: types}, nil
+}
+
+// usesTime 判断类型中是否有字段或参数使用标量 Time
+func usesTime(types []*GraphQLType) bool {
+	for _, t := range types {
+		for _, f := range t.Fields {
+			if strings.Trim(f.Type, "[]!") == "Time" || strings.Contains(f.Args, ": Time") || strings.Contains(f.Args, "[Time") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// graphDescription 返回由 notes 中非空的说明组成的字段说明（一个字段只能有一条说明），都为空时没有说明
+func graphDescription(notes ...string) string {
+	notes = slices.DeleteFunc(notes, func(note string) bool { return note == "" })
+	return strings.Join(notes, "；")
+}
+
+// enumDescription 返回枚举字段取值的说明，如 "取值：PENDING、PAID"；不是枚举字段时为空
+func (g *GraphQLGenerator) enumDescription(agg *metadata.AggregateMetadata, field *metadata.FieldMetadata) string {
+	if g.registry == nil {
+		return ""
+	}
+	for _, enum := range g.registry.GetEnums() {
+		if enum.AggregateName == agg.Name && enum.FieldName == field.Name {
+			values := make([]string, len(enum.Values))
+			for i := range enum.Values {
+				values[i] = enumValueDescription(enum, i)
+			}
+			return "取值：" + strings.Join(values, "、")
+		}
+	}
+	return ""
+}
+
+// generateConfig 生成 gqlgen.yml：全部类型绑定到 types 包中的结构体，关联字段由解析器返回
+func (g *GraphQLGenerator) generateConfig(scope *graphScope, members []*metadata.AggregateMetadata, relations []*graphRelation) *GraphQLConfigData {
+	data := &GraphQLConfigData{TypesImport: scope.typesImport}
+	for _, name := range g.typeNames(scope, members, relations) {
+		model := &GraphQLModel{Name: name}
+		for _, agg := range members {
+			if agg.Name == name {
+				for _, r := range relationsOf(relations, agg) {
+					model.Resolvers = append(model.Resolvers, r.name)
+				}
+			}
+		}
+		data.Models = append(data.Models, model)
+	}
+	return data
+}
+
+// typeNames 返回 schema 中定义、绑定到 types 包的全部类型名
+func (g *GraphQLGenerator) typeNames(scope *graphScope, members []*metadata.AggregateMetadata, relations []*graphRelation) []string {
+	var names []string
+	for _, agg := range members {
+		key, err := scope.keyOf(agg)
+		if err != nil {
+			continue
+		}
+		message := scope.message(agg, key)
+		names = append(names, agg.Name)
+		for _, op := range graphOperations(agg, message) {
+			switch op.op {
+			case metadata.APIOpList:
+				names = append(names, agg.Name+"Page")
+			case metadata.APIOpCreate:
+				if len(message.inputs) > 0 {
+					names = append(names, "Create"+agg.Name+"Input")
+				}
+			case metadata.APIOpUpdate:
+				names = append(names, "Update"+agg.Name+"Input")
+			}
+		}
+	}
+	for _, target := range connectionTargets(members, relations) {
+		names = append(names, target.Name+"Connection", target.Name+"Edge")
+	}
+	if len(relations) > 0 {
+		names = append(names, "PageInfo")
+	}
+	for _, object := range scope.graphObjects() {
+		names = append(names, object.name, object.name+"Input")
+	}
+	return names
+}
+
+// addStruct 向 types 包添加结构体定义，fields 为字段名和 Go 类型
+func addStruct(file *GoFileData, comment, name string, fields [][2]string) {
+	decl := file.addType(name+" "+comment, name, "struct")
+	for _, field := range fields {
+		goType := strings.ReplaceAll(field[1], "types.", "")
+		if strings.Contains(goType, "time.") {
+			file.addImports("time")
+		}
+		decl.Fields = append(decl.Fields, &GoField{Name: field[0], Type: goType})
+	}
+}
+
+// generateTypes 生成 types 包：schema 中各类型对应的结构体，关联字段由解析器返回，不在结构体中
+func (g *GraphQLGenerator) generateTypes(scope *graphScope, members []*metadata.AggregateMetadata, relations []*graphRelation) *GoFileData {
+	file := &GoFileData{Package: "types"}
+	for _, agg := range members {
+		key, err := scope.keyOf(agg)
+		if err != nil {
+			continue
+		}
+		message := scope.message(agg, key)
+
+		var fields [][2]string
+		for _, f := range message.outputs {
+			fields = append(fields, [2]string{f.goName, f.goType})
+		}
+		addStruct(file, agg.Name+" 查询、新增和更新返回的对象", agg.Name, fields)
+
+		for _, op := range graphOperations(agg, message) {
+			switch op.op {
+			case metadata.APIOpList:
+				addStruct(file, agg.Name+" 的分页查询结果", agg.Name+"Page",
+					[][2]string{{"Items", "[]*" + agg.Name}, {"Total", "int64"}, {"Page", "int"}, {"PageSize", "int"}})
+			case metadata.APIOpCreate:
+				if len(message.inputs) == 0 {
+					continue
+				}
+				var fields [][2]string
+				for _, f := range message.inputs {
+					_, goType := message.createType(f)
+					fields = append(fields, [2]string{f.goName, goType})
+				}
+				addStruct(file, "新增 "+agg.Name+" 时写入的字段", "Create"+agg.Name+"Input", fields)
+			case metadata.APIOpUpdate:
+				var fields [][2]string
+				for _, f := range message.mutable() {
+					_, goType := message.updateType(f)
+					fields = append(fields, [2]string{f.goName, goType})
+				}
+				addStruct(file, "更新 "+agg.Name+" 时写入的字段，为 nil 的字段保持原值", "Update"+agg.Name+"Input", fields)
+			}
+		}
+	}
+
+	for _, target := range connectionTargets(members, relations) {
+		addStruct(file, target.Name+" 的分页列表", target.Name+"Connection",
+			[][2]string{{"Edges", "[]*" + target.Name + "Edge"}, {"PageInfo", "*PageInfo"}, {"TotalCount", "int"}})
+		addStruct(file, target.Name+" 分页列表中的一项", target.Name+"Edge",
+			[][2]string{{"Cursor", "string"}, {"Node", "*" + target.Name}})
+	}
+	if len(relations) > 0 {
+		addStruct(file, "分页信息", "PageInfo",
+			[][2]string{{"HasNextPage", "bool"}, {"HasPreviousPage", "bool"}, {"StartCursor", "*string"}, {"EndCursor", "*string"}})
+	}
+
+	for _, object := range scope.graphObjects() {
+		var outputs, inputs [][2]string
+		for _, f := range scope.fieldsOf(object) {
+			outputs = append(outputs, [2]string{f.goName, f.goType})
+			inputs = append(inputs, [2]string{f.goName, f.inputGo})
+		}
+		addStruct(file, object.name+" 值对象", object.name, outputs)
+		addStruct(file, object.name+" 值对象的输入", object.name+"Input", inputs)
+	}
+
+	return file
+}
+
+// generateRoot 生成 resolver.go：Resolver 及其依赖、根解析器
+func (g *GraphQLGenerator) generateRoot(scope *graphScope, members []*metadata.AggregateMetadata, relations []*graphRelation) *GoFileData {
+	file := &GoFileData{Package: "graph"}
+	file.addImports("soliton/pkg/framework", scope.generatedImport)
+
+	resolver := file.addType("Resolver GraphQL 解析器的根，字段为解析器依赖的领域服务、仓储和关联表：\n"+
+		"\n"+
+		"\tresolver := &graph.Resolver{...}\n"+
+		"\tsrv := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{Resolvers: resolver}))\n"+
+		"\thttp.Handle(\"/query\", resolver.Middleware(srv))", "Resolver", "struct")
+	for _, agg := range members {
+		file.addImports(agg.ImportPath)
+		resolver.Fields = append(resolver.Fields, &GoField{Name: agg.Name + "Service",
+			Type: fmt.Sprintf("framework.ServiceOf[*%s.%s, %s]", agg.PackageName, agg.Name, qualifiedKeyType(agg))})
+	}
+	var loaders []*metadata.AggregateMetadata
+	var tables []*graphRelation
+	for _, r := range relations {
+		if r.relation != nil && !slices.Contains(loaders, r.source) {
+			loaders = append(loaders, r.source)
+			resolver.Fields = append(resolver.Fields, &GoField{Name: r.source.Name + "Relations", Type: r.source.Name + "RelationLoader",
+				Comment: fmt.Sprintf("批量加载 %s 的关联实体，通常为 %s 的仓储", r.source.Name, r.source.Name)})
+		}
+		if r.table != nil && r.left {
+			tables = append(tables, r)
+			resolver.Fields = append(resolver.Fields, &GoField{Name: r.links(), Type: "LinkStore",
+				Comment: fmt.Sprintf("关联表 %s，通常为 framework.ManyToManyRepository", r.table.TableName)})
+		}
+	}
+
+	for _, agg := range loaders {
+		loader := file.addType(fmt.Sprintf("%sRelationLoader 批量加载 %s 的一对多关联实体，%s 的仓储实现了该接口", agg.Name, agg.Name, agg.Name),
+			agg.Name+"RelationLoader", "interface")
+		for _, r := range relationsOf(relations, agg) {
+			if r.relation != nil {
+				loader.Methods = append(loader.Methods, &GoFunc{
+					Name:    "Load" + r.relation.Field.Name,
+					Params:  fmt.Sprintf("ctx context.Context, entities ...*%s.%s", agg.PackageName, agg.Name),
+					Results: "error",
+				})
+			}
+		}
+	}
+	if len(tables) > 0 {
+		store := file.addType("LinkStore 多对多关联表，framework.ManyToManyRepository 实现了该接口", "LinkStore", "interface")
+		store.Methods = []*GoFunc{
+			{Name: "ListRightIDsByLeft", Params: "ctx context.Context, leftIDs []int64", Results: "(map[int64][]int64, error)"},
+			{Name: "ListLeftIDsByRight", Params: "ctx context.Context, rightIDs []int64", Results: "(map[int64][]int64, error)"},
+		}
+	}
+	if len(loaders) > 0 || len(tables) > 0 {
+		file.addImports("context")
+	}
+
+	// 根解析器
+	roots := []string{"Query"}
+	for _, agg := range members {
+		key, err := scope.keyOf(agg)
+		if err != nil {
+			continue
+		}
+		if slices.ContainsFunc(graphOperations(agg, scope.message(agg, key)), func(o graphOperation) bool { return o.parent == "Mutation" }) {
+			roots = append(roots, "Mutation")
+			break
+		}
+	}
+	for _, agg := range members {
+		if len(relationsOf(relations, agg)) > 0 {
+			roots = append(roots, agg.Name)
+		}
+	}
+	for _, root := range roots {
+		description := "查询字段的解析器"
+		switch {
+		case root == "Mutation":
+			description = "修改字段的解析器"
+		case root != "Query":
+			description = " " + root + " 关联字段的解析器"
+		}
+		file.addFunc(&GoFunc{
+			Doc:      fmt.Sprintf("%s 返回%s", root, description),
+			Receiver: "r *Resolver",
+			Name:     root,
+			Results:  fmt.Sprintf("generated.%sResolver", root),
+			Body:     fmt.Sprintf("\treturn &%sResolver{r}\n", toLowerFirst(root)),
+		})
+	}
+	for _, root := range roots {
+		file.addType("", toLowerFirst(root)+"Resolver", "struct{ *Resolver }")
+	}
+
+	return file
+}
+
+// generateResolver 生成聚合根的查询、修改字段解析器和转换函数
+func (g *GraphQLGenerator) generateResolver(scope *graphScope, agg *metadata.AggregateMetadata) (*GoFileData, error) {
+	key, err := scope.keyOf(agg)
+	if err != nil {
+		return nil, err
+	}
+	file := &GoFileData{
+		TemplateData: TemplateData{Aggregate: agg, Context: agg.Context()},
+		Package:      "graph",
+	}
+	message := scope.message(agg, key)
+	operations := graphOperations(agg, message)
+	serviceField := agg.Name + "Service"
+	entityType := fmt.Sprintf("*%s.%s", agg.PackageName, agg.Name)
+	_, keyParams := key.params()
+	params := strings.Join(append([]string{"ctx context.Context"}, keyParams...), ", ")
+	keyLines := func(onError string) []string {
+		return key.statements(func(param string) string { return param }, onError)
+	}
+	errorReturn := "\t\treturn nil, toGraphQLError(ctx, err)\n"
+
+	defaults, needTime := defaultAssignments(agg)
+	for _, op := range operations {
+		var body strings.Builder
+		method := &GoFunc{Receiver: "r *queryResolver", Name: op.method(), Params: params}
+		if op.parent == "Mutation" {
+			method.Receiver = "r *mutationResolver"
+		}
+		switch op.op {
+		case metadata.APIOpGet:
+			method.Doc = fmt.Sprintf("%s 按主键查询 %s，不存在时返回 null", op.method(), agg.Name)
+			method.Results = fmt.Sprintf("(*types.%s, error)", agg.Name)
+			writeStatements(&body, "\t", keyLines("return nil, toGraphQLError(ctx, err)"))
+			body.WriteString(fmt.Sprintf("\tentity, err := r.%s.GetByID(ctx, key)\n", serviceField))
+			body.WriteString("\tif isNotFound(err) {\n")
+			body.WriteString("\t\treturn nil, nil\n")
+			body.WriteString("\t}\n")
+			body.WriteString("\tif err != nil {\n")
+			body.WriteString(errorReturn)
+			body.WriteString("\t}\n")
+			body.WriteString(fmt.Sprintf("\treturn to%sType(entity), nil\n", agg.Name))
+		case metadata.APIOpList:
+			method.Doc = fmt.Sprintf("%s 分页查询 %s，page 从 1 开始，pageSize 最大为 framework.MaxPageSize", op.method(), agg.Name)
+			method.Params = "ctx context.Context, page *int, pageSize *int"
+			method.Results = fmt.Sprintf("(*types.%sPage, error)", agg.Name)
+			body.WriteString("\tpageNum, size, err := framework.NormalizePage(valueOf(page), valueOf(pageSize))\n")
+			body.WriteString("\tif err != nil {\n")
+			body.WriteString(errorReturn)
+			body.WriteString("\t}\n")
+			body.WriteString(fmt.Sprintf("\tentities, total, err := r.%s.GetPage(ctx, pageNum, size)\n", serviceField))
+			body.WriteString("\tif err != nil {\n")
+			body.WriteString(errorReturn)
+			body.WriteString("\t}\n\n")
+			body.WriteString(fmt.Sprintf("\titems := make([]*types.%s, len(entities))\n", agg.Name))
+			body.WriteString("\tfor i, entity := range entities {\n")
+			body.WriteString(fmt.Sprintf("\t\titems[i] = to%sType(entity)\n", agg.Name))
+			body.WriteString("\t}\n")
+			body.WriteString(fmt.Sprintf("\treturn &types.%sPage{Items: items, Total: total, Page: pageNum, PageSize: size}, nil\n", agg.Name))
+		case metadata.APIOpCreate:
+			method.Doc = fmt.Sprintf("%s 新增 %s", op.method(), agg.Name)
+			if len(message.presence) > 0 {
+				method.Doc += "，声明了 +soliton:default 的字段为 null 时取默认值"
+			}
+			method.Params = "ctx context.Context"
+			if len(message.inputs) > 0 {
+				method.Params += fmt.Sprintf(", input types.Create%sInput", agg.Name)
+			}
+			method.Results = fmt.Sprintf("(*types.%s, error)", agg.Name)
+			body.WriteString(fmt.Sprintf("\tentity := &%s.%s{}\n", agg.PackageName, agg.Name))
+			writeStatements(&body, "\t", defaults)
+			if len(message.inputs) > 0 {
+				body.WriteString(fmt.Sprintf("\tif err := applyCreate%sInput(entity, input); err != nil {\n", agg.Name))
+				body.WriteString(errorReturn)
+				body.WriteString("\t}\n")
+			}
+			body.WriteString(fmt.Sprintf("\tif err := r.%s.Add(ctx, entity); err != nil {\n", serviceField))
+			body.WriteString(errorReturn)
+			body.WriteString("\t}\n")
+			body.WriteString(fmt.Sprintf("\treturn to%sType(entity), nil\n", agg.Name))
+		case metadata.APIOpUpdate:
+			method.Doc = fmt.Sprintf("%s 更新 %s，输入中为 null 的字段保持原值；主键和 +soliton:immutable 字段不可修改", op.method(), agg.Name)
+			method.Params = fmt.Sprintf("%s, input types.Update%sInput", params, agg.Name)
+			method.Results = fmt.Sprintf("(*types.%s, error)", agg.Name)
+			writeStatements(&body, "\t", keyLines("return nil, toGraphQLError(ctx, err)"))
+			body.WriteString(fmt.Sprintf("\tentity, err := r.%s.GetByID(ctx, key)\n", serviceField))
+			body.WriteString("\tif err != nil {\n")
+			body.WriteString(errorReturn)
+			body.WriteString("\t}\n")
+			body.WriteString(fmt.Sprintf("\tif err := applyUpdate%sInput(entity, input); err != nil {\n", agg.Name))
+			body.WriteString(errorReturn)
+			body.WriteString("\t}\n")
+			body.WriteString(fmt.Sprintf("\tif err := r.%s.Update(ctx, entity); err != nil {\n", serviceField))
+			body.WriteString(errorReturn)
+			body.WriteString("\t}\n")
+			body.WriteString(fmt.Sprintf("\treturn to%sType(entity), nil\n", agg.Name))
+		case metadata.APIOpDelete:
+			method.Doc = fmt.Sprintf("%s 删除 %s", op.method(), agg.Name)
+			method.Results = "(bool, error)"
+			writeStatements(&body, "\t", keyLines("return false, toGraphQLError(ctx, err)"))
+			body.WriteString(fmt.Sprintf("\tif err := r.%s.Delete(ctx, key); err != nil {\n", serviceField))
+			body.WriteString("\t\treturn false, toGraphQLError(ctx, err)\n")
+			body.WriteString("\t}\n")
+			body.WriteString("\treturn true, nil\n")
+		}
+		method.Body = body.String()
+		file.addFunc(method)
+	}
+
+	// 转换
+	var body strings.Builder
+	writeObjectLiteral(&body, agg.Name, message.outputs, "entity")
+	file.addFunc(&GoFunc{
+		Doc:     fmt.Sprintf("to%sType 将实体转换为 GraphQL 对象", agg.Name),
+		Name:    fmt.Sprintf("to%sType", agg.Name),
+		Params:  "entity " + entityType,
+		Results: "*types." + agg.Name,
+		Body:    body.String(),
+	})
+
+	hasOp := func(op string) bool {
+		return slices.ContainsFunc(operations, func(o graphOperation) bool { return o.op == op })
+	}
+	if hasOp(metadata.APIOpCreate) && len(message.inputs) > 0 {
+		file.addFunc(applyFunc(fmt.Sprintf("applyCreate%sInput 将新增输入写入实体，为 null 的字段保持默认值", agg.Name),
+			"applyCreate"+agg.Name+"Input", entityType, "types.Create"+agg.Name+"Input", message.inputs,
+			func(f *graphField) bool { return message.presence[f] },
+			func(f *graphField) bool { _, goType := message.createType(f); return goType != f.inputGo }))
+	}
+	if hasOp(metadata.APIOpUpdate) {
+		file.addFunc(applyFunc(fmt.Sprintf("applyUpdate%sInput 将更新输入写入实体，为 null 的字段保持原值", agg.Name),
+			"applyUpdate"+agg.Name+"Input", entityType, "types.Update"+agg.Name+"Input", message.mutable(),
+			func(*graphField) bool { return true },
+			func(f *graphField) bool { _, goType := message.updateType(f); return goType != f.inputGo }))
+	}
+
+	// 导入
+	file.addImports(agg.ImportPath, scope.typesImport)
+	if len(operations) > 0 {
+		file.addImports("context")
+	}
+	if hasOp(metadata.APIOpList) {
+		file.addImports("soliton/pkg/framework")
+	}
+	if needTime && len(defaults) > 0 && hasOp(metadata.APIOpCreate) {
+		file.addImports("time")
+	}
+	for importPath := range scope.imports {
+		file.addImports(importPath)
+	}
+	return file, nil
+}
+
+// writeObjectLiteral 写出由 source（实体或值对象）构造 types.{name} 的 return 语句
+func writeObjectLiteral(sb *strings.Builder, name string, fields []*graphField, source string) {
+	sb.WriteString(fmt.Sprintf("\treturn &types.%s{\n", name))
+	for _, f := range fields {
+		sb.WriteString(fmt.Sprintf("\t\t%s: %s,\n", f.goName, f.to(source+"."+f.field.Name)))
+	}
+	sb.WriteString("\t}\n")
+}
+
+// applyFunc 返回将输入写入实体的函数：optional 的字段为 nil 时跳过，pointer 的字段在输入中比映射的类型多一层指针
+func applyFunc(doc, name, entityType, inputType string, fields []*graphField, optional, pointer func(f *graphField) bool) *GoFunc {
+	var sb strings.Builder
+	if slices.ContainsFunc(fields, func(f *graphField) bool { return f.fallible }) {
+		sb.WriteString("\tvar err error\n")
+	}
+	for _, f := range fields {
+		value := "input." + f.goName
+		if !optional(f) {
+			writeStatements(&sb, "\t", f.from("entity."+f.field.Name, value))
+			continue
+		}
+		if pointer(f) {
+			value = "*" + value
+		}
+		sb.WriteString(fmt.Sprintf("\tif input.%s != nil {\n", f.goName))
+		writeStatements(&sb, "\t\t", f.from("entity."+f.field.Name, value))
+		sb.WriteString("\t}\n")
+	}
+	sb.WriteString("\treturn nil\n")
+
+	return &GoFunc{
+		Doc:     doc,
+		Name:    name,
+		Params:  fmt.Sprintf("entity %s, input %s", entityType, inputType),
+		Results: "error",
+		Body:    sb.String(),
+	}
+}
+
+// generateRelations 生成 relations.go：数据加载器、Middleware 和关联字段的解析器
+func (g *GraphQLGenerator) generateRelations(scope *graphScope, relations []*graphRelation) *GoFileData {
+	file := &GoFileData{Package: "graph"}
+	file.addImports("context", "net/http")
+
+	loaders := file.addType("dataLoaders 一次请求内关联字段的数据加载器", "dataLoaders", "struct{}")
+	newLoaders := "\treturn &dataLoaders{}\n"
+	if len(relations) > 0 {
+		loaders.Type = "struct"
+		var sb strings.Builder
+		sb.WriteString("\treturn &dataLoaders{\n")
+		for _, r := range relations {
+			loaders.Fields = append(loaders.Fields, &GoField{Name: r.loader,
+				Type: fmt.Sprintf("*framework.Loader[%s, []*%s.%s]", qualifiedKeyType(r.source), r.target.PackageName, r.target.Name)})
+			sb.WriteString(fmt.Sprintf("\t\t%s: framework.NewLoader(r.load%s),\n", r.loader, toUpperFirst(r.loader)))
+		}
+		sb.WriteString("\t}\n")
+		newLoaders = sb.String()
+	}
+	file.addType("loadersKey 数据加载器在 context 中的键", "loadersKey", "struct{}")
+
+	file.addFunc(&GoFunc{
+		Doc:      "Middleware 为每个请求创建数据加载器，同一请求内对关联字段的加载合并为批量查询",
+		Receiver: "r *Resolver",
+		Name:     "Middleware",
+		Params:   "next http.Handler",
+		Results:  "http.Handler",
+		Body: "\treturn http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {\n" +
+			"\t\tctx := context.WithValue(req.Context(), loadersKey{}, r.newLoaders())\n" +
+			"\t\tnext.ServeHTTP(w, req.WithContext(ctx))\n" +
+			"\t})\n",
+	})
+	file.addFunc(&GoFunc{
+		Doc:      "loadersFrom 返回请求的数据加载器，未经过 Middleware 时创建新的加载器，此时每个字段单独查询",
+		Receiver: "r *Resolver",
+		Name:     "loadersFrom",
+		Params:   "ctx context.Context",
+		Results:  "*dataLoaders",
+		Body: "\tif loaders, ok := ctx.Value(loadersKey{}).(*dataLoaders); ok {\n" +
+			"\t\treturn loaders\n" +
+			"\t}\n" +
+			"\treturn r.newLoaders()\n",
+	})
+	file.addFunc(&GoFunc{
+		Doc:      "newLoaders 创建数据加载器",
+		Receiver: "r *Resolver",
+		Name:     "newLoaders",
+		Results:  "*dataLoaders",
+		Body:     newLoaders,
+	})
+
+	for _, r := range relations {
+		file.addImports("soliton/pkg/framework", r.source.ImportPath, r.target.ImportPath, scope.typesImport)
+		keyType := qualifiedKeyType(r.source)
+		target := fmt.Sprintf("%s.%s", r.target.PackageName, r.target.Name)
+		key, err := scope.keyOf(r.source)
+		if err != nil {
+			continue
+		}
+
+		// 关联字段的解析器
+		var body strings.Builder
+		writeStatements(&body, "\t", key.statements(func(string) string { return "obj.ID" }, "return nil, toGraphQLError(ctx, err)"))
+		body.WriteString(fmt.Sprintf("\tentities, err := r.loadersFrom(ctx).%s.Load(ctx, key)\n", r.loader))
+		body.WriteString("\tif err != nil {\n")
+		body.WriteString("\t\treturn nil, toGraphQLError(ctx, err)\n")
+		body.WriteString("\t}\n")
+		body.WriteString(fmt.Sprintf("\tconnection, err := to%sConnection(entities, first, after)\n", r.target.Name))
+		body.WriteString("\tif err != nil {\n")
+		body.WriteString("\t\treturn nil, toGraphQLError(ctx, err)\n")
+		body.WriteString("\t}\n")
+		body.WriteString("\treturn connection, nil\n")
+		file.addFunc(&GoFunc{
+			Doc:      fmt.Sprintf("%s 按 first、after 分页返回 %s 关联的 %s", r.method(), r.source.Name, r.target.Name),
+			Receiver: fmt.Sprintf("r *%sResolver", toLowerFirst(r.source.Name)),
+			Name:     r.method(),
+			Params:   fmt.Sprintf("ctx context.Context, obj *types.%s, first *int, after *string", r.source.Name),
+			Results:  fmt.Sprintf("(*types.%sConnection, error)", r.target.Name),
+			Body:     body.String(),
+		})
+
+		// 批量加载函数
+		load := &GoFunc{Receiver: "r *Resolver", Name: "load" + toUpperFirst(r.loader)}
+		body.Reset()
+		if r.relation != nil {
+			pk := r.source.PrimaryKey[0].Name
+			source := fmt.Sprintf("%s.%s", r.source.PackageName, r.source.Name)
+			load.Doc = fmt.Sprintf("%s 通过仓储的 Load%s 批量加载 %s 的 %s", load.Name, r.relation.Field.Name, r.source.Name, r.relation.Field.Name)
+			load.Params = fmt.Sprintf("ctx context.Context, ids []%s", keyType)
+			load.Results = fmt.Sprintf("(map[%s][]*%s, error)", keyType, target)
+			body.WriteString(fmt.Sprintf("\tentities := make([]*%s, len(ids))\n", source))
+			body.WriteString("\tfor i, id := range ids {\n")
+			body.WriteString(fmt.Sprintf("\t\tentities[i] = &%s{%s: id}\n", source, pk))
+			body.WriteString("\t}\n")
+			body.WriteString(fmt.Sprintf("\tif err := r.%sRelations.Load%s(ctx, entities...); err != nil {\n", r.source.Name, r.relation.Field.Name))
+			body.WriteString("\t\treturn nil, err\n")
+			body.WriteString("\t}\n\n")
+			body.WriteString(fmt.Sprintf("\tresult := make(map[%s][]*%s, len(entities))\n", keyType, target))
+			body.WriteString("\tfor _, entity := range entities {\n")
+			body.WriteString(fmt.Sprintf("\t\tresult[entity.%s] = entity.%s\n", pk, r.relation.Field.Name))
+			body.WriteString("\t}\n")
+			body.WriteString("\treturn result, nil\n")
+		} else {
+			list := "ListRightIDsByLeft"
+			if !r.left {
+				list = "ListLeftIDsByRight"
+			}
+			load.Doc = fmt.Sprintf("%s 通过关联表 %s 批量加载 %s 关联的 %s", load.Name, r.table.TableName, r.source.Name, r.target.Name)
+			load.Params = "ctx context.Context, ids []int64"
+			load.Results = fmt.Sprintf("(map[int64][]*%s, error)", target)
+			body.WriteString(fmt.Sprintf("\tlinks, err := r.%s.%s(ctx, ids)\n", r.links(), list))
+			body.WriteString("\tif err != nil {\n")
+			body.WriteString("\t\treturn nil, err\n")
+			body.WriteString("\t}\n")
+			body.WriteString("\tvar linkedIDs []int64\n")
+			body.WriteString("\tfor _, linked := range links {\n")
+			body.WriteString("\t\tlinkedIDs = append(linkedIDs, linked...)\n")
+			body.WriteString("\t}\n")
+			body.WriteString(fmt.Sprintf("\tentities, err := r.%sService.GetByIDs(ctx, linkedIDs)\n", r.target.Name))
+			body.WriteString("\tif err != nil {\n")
+			body.WriteString("\t\treturn nil, err\n")
+			body.WriteString("\t}\n")
+			body.WriteString("\treturn collectLinked(links, entities), nil\n")
+		}
+		load.Body = body.String()
+		file.addFunc(load)
+	}
+	for importPath := range scope.imports {
+		file.addImports(importPath)
+	}
+
+	// 关联字段的分页
+	var targets []*metadata.AggregateMetadata
+	for _, r := range relations {
+		if !slices.Contains(targets, r.target) {
+			targets = append(targets, r.target)
+		}
+	}
+	for _, target := range targets {
+		file.addFunc(&GoFunc{
+			Doc:     fmt.Sprintf("to%sConnection 按 first、after 截取 %s 列表", target.Name, target.Name),
+			Name:    fmt.Sprintf("to%sConnection", target.Name),
+			Params:  fmt.Sprintf("entities []*%s.%s, first *int, after *string", target.PackageName, target.Name),
+			Results: fmt.Sprintf("(*types.%sConnection, error)", target.Name),
+			Body: "\tstart, end, pageInfo, err := paginate(len(entities), first, after)\n" +
+				"\tif err != nil {\n" +
+				"\t\treturn nil, err\n" +
+				"\t}\n" +
+				fmt.Sprintf("\tedges := make([]*types.%sEdge, 0, end-start)\n", target.Name) +
+				"\tfor i := start; i < end; i++ {\n" +
+				fmt.Sprintf("\t\tedges = append(edges, &types.%sEdge{Cursor: encodeCursor(i), Node: to%sType(entities[i])})\n", target.Name, target.Name) +
+				"\t}\n" +
+				fmt.Sprintf("\treturn &types.%sConnection{Edges: edges, PageInfo: pageInfo, TotalCount: len(entities)}, nil\n", target.Name),
+		})
+	}
+
+	if len(relations) > 0 {
+		file.addImports("encoding/base64", "strconv", "strings")
+		file.addFunc(&GoFunc{
+			Doc: "paginate 计算 first、after 对应的列表范围 [start, end)：after 为上一页最后一项的游标，\n" +
+				"first 为空时取 framework.DefaultPageSize，最大为 framework.MaxPageSize",
+			Name:    "paginate",
+			Params:  "total int, first *int, after *string",
+			Results: "(int, int, *types.PageInfo, error)",
+			Body: `	start := 0
+	if after != nil {
+		offset, err := decodeCursor(*after)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		start = min(offset+1, total)
+	}
+	if first != nil && *first < 0 {
+		return 0, 0, nil, framework.NewBadRequestError("first", "first 不能为负数: %d", *first)
+	}
+	_, size, err := framework.NormalizePage(1, valueOf(first))
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	end := min(start+size, total)
+
+	pageInfo := &types.PageInfo{HasNextPage: end < total, HasPreviousPage: start > 0}
+	if end > start {
+		startCursor, endCursor := encodeCursor(start), encodeCursor(end-1)
+		pageInfo.StartCursor, pageInfo.EndCursor = &startCursor, &endCursor
+	}
+	return start, end, pageInfo, nil
+`,
+		})
+		file.addFunc(&GoFunc{
+			Doc:     "encodeCursor 返回列表中第 offset 项的游标",
+			Name:    "encodeCursor",
+			Params:  "offset int",
+			Results: "string",
+			Body:    "\treturn base64.StdEncoding.EncodeToString([]byte(\"cursor:\" + strconv.Itoa(offset)))\n",
+		})
+		file.addFunc(&GoFunc{
+			Doc:     "decodeCursor 解析 encodeCursor 返回的游标，无效时返回 ErrBadRequest",
+			Name:    "decodeCursor",
+			Params:  "cursor string",
+			Results: "(int, error)",
+			Body: `	data, err := base64.StdEncoding.DecodeString(cursor)
+	if err == nil {
+		if value, ok := strings.CutPrefix(string(data), "cursor:"); ok {
+			if offset, err := strconv.Atoi(value); err == nil && offset >= 0 {
+				return offset, nil
+			}
+		}
+	}
+	return 0, framework.NewBadRequestError("after", "after 不是有效的游标: %q", cursor)
+`,
+		})
+	}
+	if slices.ContainsFunc(relations, func(r *graphRelation) bool { return r.table != nil }) {
+		file.addFunc(&GoFunc{
+			Doc:     "collectLinked 按关联表中的 ID 列表取出关联实体，已删除或不存在的实体跳过",
+			Name:    "collectLinked[T any]",
+			Params:  "links map[int64][]int64, entities map[int64]T",
+			Results: "map[int64][]T",
+			Body: `	result := make(map[int64][]T, len(links))
+	for id, linked := range links {
+		for _, linkedID := range linked {
+			if entity, ok := entities[linkedID]; ok {
+				result[id] = append(result[id], entity)
+			}
+		}
+	}
+	return result
+`,
+		})
+	}
+
+	return file
+}
+
+// generateConvert 生成值对象的转换函数，限界上下文共用的转换辅助函数和错误转换在 graphql_convert 模板中
+func (g *GraphQLGenerator) generateConvert(scope *graphScope) *GoFileData {
+	file := &GoFileData{Package: "graph"}
+	file.addImports("context", "encoding", "errors", "log", "net/http", "soliton/pkg/framework", "strconv",
+		"github.com/99designs/gqlgen/graphql", "github.com/vektah/gqlparser/v2/gqlerror")
+
+	// 值对象
+	for _, object := range scope.graphObjects() {
+		fields := scope.fieldsOf(object)
+		file.addImports(object.agg.ImportPath, scope.typesImport)
+
+		var body strings.Builder
+		writeObjectLiteral(&body, object.name, fields, "v")
+		file.addFunc(&GoFunc{
+			Doc:     fmt.Sprintf("to%sType 将值对象 %s 转换为 GraphQL 对象", object.name, object.name),
+			Name:    fmt.Sprintf("to%sType", object.name),
+			Params:  "v " + object.domain,
+			Results: "*types." + object.name,
+			Body:    body.String(),
+		})
+
+		body.Reset()
+		body.WriteString(fmt.Sprintf("\tvar v %s\n", object.domain))
+		body.WriteString("\tif in == nil {\n")
+		body.WriteString("\t\treturn v\n")
+		body.WriteString("\t}\n")
+		for _, f := range fields {
+			writeStatements(&body, "\t", f.from("v."+f.field.Name, "in."+f.goName))
+		}
+		body.WriteString("\treturn v\n")
+		file.addFunc(&GoFunc{
+			Doc:     fmt.Sprintf("from%sInput 将输入对象转换为值对象 %s，in 为 nil 时返回零值", object.name, object.name),
+			Name:    fmt.Sprintf("from%sInput", object.name),
+			Params:  "in *types." + object.name + "Input",
+			Results: object.domain,
+			Body:    body.String(),
+		})
+	}
+	for importPath := range scope.imports {
+		file.addImports(importPath)
+	}
+
+	return file
+}
@@ -0,0 +1,1098 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"soliton/pkg/framework"
+	"soliton/pkg/metadata"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// GRPCGenerator gRPC 服务生成器
+//
+// 为通过 gRPC 暴露的聚合根生成 .proto 服务定义，以及在 protobuf 消息和领域对象之间转换、调用领域服务的服务端适配器。
+// 与 HTTPHandlerGenerator 共用暴露范围、操作和请求/响应字段的计算，按 +soliton:api 的 ops、exclude 生成启用的方法：
+//   - Create{AggregateName}：新增，声明了 +soliton:default 的字段在 {AggregateName}Input 中为 optional，未设置时取默认值
+//   - Get{AggregateName}：按主键查询
+//   - List{PluralName}：分页查询，page、page_size 为 0 时取默认值
+//   - Update{AggregateName}：更新，update_mask 列出要写入的字段，为空时写入全部字段；主键和 +soliton:immutable 字段不可修改
+//   - Delete{AggregateName}：删除，返回 google.protobuf.Empty
+//
+// 类型映射：基础类型映射为对应的标量类型（int、uint 为 64 位），time.Time、time.Duration 为 Timestamp、Duration，
+// 指针为 optional，切片、定长数组为 repeated（[]byte、[N]byte 为 bytes），结构体值对象为消息，sql.NullXxx 为 optional 标量，
+// uuid.UUID 等其他已知标量类型通过 String 和 UnmarshalText 与 string 转换。无法映射的字段不出现在消息中，列在消息的注释里。
+// 字段编号按声明顺序分配，调整字段顺序或增删字段会改变编号。领域服务返回的错误按 framework 中的错误类型转换为 gRPC 状态码。
+//
+// 生成文件（声明了 +soliton:context 的聚合根输出到 interfaces/{context}/rpc）：
+//   - interfaces/rpc/pb/{name}.proto：限界上下文内全部服务和消息，通过 protoc-gen-go、protoc-gen-go-grpc 生成 Go 代码
+//   - interfaces/rpc/{AggregateName}Server.go：服务端适配器
+//   - interfaces/rpc/convert.go、register.go：转换辅助函数和错误转换、注册全部服务的 RegisterServices
+type GRPCGenerator struct {
+	fileOutput
+	registry *metadata.AggregateMetadataRegistry
+}
+
+// NewGRPCGenerator 创建 gRPC 服务生成器
+func NewGRPCGenerator() *GRPCGenerator {
+	return &GRPCGenerator{}
+}
+
+// SetRegistry 设置聚合根注册表，用于在 .proto 中注明枚举字段的取值
+func (g *GRPCGenerator) SetRegistry(registry *metadata.AggregateMetadataRegistry) {
+	g.registry = registry
+}
+
+// Generate 为聚合根生成 gRPC 服务端适配器
+func (g *GRPCGenerator) Generate(agg *metadata.AggregateMetadata, outputDir string) error {
+	absOutputDir, _ := filepath.Abs(outputDir)
+	scope := newProtoScope([]*metadata.AggregateMetadata{agg}, absOutputDir)
+
+	file, err := g.generateServer(scope, agg)
+	if err != nil {
+		return err
+	}
+
+	filePath := filepath.Join(rpcDir(agg, absOutputDir), fmt.Sprintf("%sServer.go", agg.Name))
+	if err := g.writeTemplate(filePath, "grpc_server", file); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return nil
+}
+
+// GenerateService 为限界上下文 boundedContext 生成 .proto、convert.go 和 register.go，aggregates 为全部对外暴露的聚合根
+func (g *GRPCGenerator) GenerateService(aggregates []*metadata.AggregateMetadata, outputDir, boundedContext string) error {
+	var members []*metadata.AggregateMetadata
+	for _, agg := range aggregates {
+		if agg.Context() == boundedContext {
+			members = append(members, agg)
+		}
+	}
+	if len(members) == 0 {
+		return fmt.Errorf("限界上下文 %q 中没有对外暴露的聚合根", boundedContext)
+	}
+
+	absOutputDir, _ := filepath.Abs(outputDir)
+	dir := rpcDir(members[0], absOutputDir)
+
+	protoPath := filepath.Join(dir, "pb", protoFileName(members[0]))
+	proto, err := g.generateProto(newProtoScope(members, absOutputDir), members, protoPath)
+	if err != nil {
+		return err
+	}
+	proto.Aggregates, proto.Context = members, boundedContext
+	if err := g.writeTemplate(protoPath, "grpc_proto", proto); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	convert := g.generateConvert(newProtoScope(members, absOutputDir))
+	convert.Aggregates, convert.Context = members, boundedContext
+	if err := g.writeTemplate(filepath.Join(dir, "convert.go"), "grpc_convert", convert); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	register := g.generateRegister(members, absOutputDir)
+	register.Aggregates, register.Context = members, boundedContext
+	if err := g.writeTemplate(filepath.Join(dir, "register.go"), "grpc_register", register); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return nil
+}
+
+// rpcDir 返回聚合根的 gRPC 适配器所在目录，如 interfaces/ordering/rpc
+func rpcDir(agg *metadata.AggregateMetadata, outputDir string) string {
+	return filepath.Join(interfacesDir(agg, outputDir), "rpc")
+}
+
+// protoIdentPattern .proto 包名中不允许的字符
+var protoIdentPattern = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// protoFileName 返回限界上下文的 .proto 文件名：上下文名，未声明上下文时为模块名的最后一段，如 ordering.proto
+func protoFileName(agg *metadata.AggregateMetadata) string {
+	name := agg.Context()
+	if name == "" {
+		name = path.Base(agg.ModuleName)
+	}
+	return protoIdentPattern.ReplaceAllString(strings.ToLower(name), "_") + ".proto"
+}
+
+// protoPackage 返回 .proto 的包名：模块名的最后一段、限界上下文和版本，如 shop.ordering.v1
+func protoPackage(agg *metadata.AggregateMetadata) string {
+	parts := []string{path.Base(agg.ModuleName)}
+	if agg.Context() != "" {
+		parts = append(parts, agg.Context())
+	}
+	for i, part := range parts {
+		part = protoIdentPattern.ReplaceAllString(strings.ToLower(part), "_")
+		if part == "" || unicode.IsDigit(rune(part[0])) {
+			part = "x" + part
+		}
+		parts[i] = part
+	}
+	return strings.Join(append(parts, "v1"), ".")
+}
+
+// protoGoName 返回 protoc-gen-go 为 .proto 字段名生成的 Go 名称，如 user_id → UserId、address2_line → Address2Line
+func protoGoName(name string) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range name {
+		switch {
+		case r == '_':
+			upper = true
+		case unicode.IsDigit(r):
+			sb.WriteRune(r)
+			upper = true
+		case upper:
+			sb.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// protoValueObject .proto 中的值对象消息
+type protoValueObject struct {
+	agg    *metadata.AggregateMetadata // 值对象所在的聚合根，用于限定字段类型
+	name   string                      // 类型名，即消息名，如 Address
+	domain string                      // 领域模型中带包名的类型，如 model.Address
+	fields []*metadata.FieldMetadata
+}
+
+// protoScope 生成一个文件时的上下文：可以映射为消息的值对象，以及生成的代码用到的包
+type protoScope struct {
+	pbImport     string                       // protoc-gen-go 生成的包的 import 路径
+	valueObjects map[string]*protoValueObject // 键为类型名
+	imports      map[string]bool
+}
+
+// newProtoScope 创建 protoScope，收集 aggregates 字段中定义在领域模型包内的结构体值对象（含嵌套的值对象）
+func newProtoScope(aggregates []*metadata.AggregateMetadata, outputDir string) *protoScope {
+	agg := aggregates[0]
+	s := &protoScope{
+		pbImport:     calculateImportPath(agg.ModuleName, agg.ModuleRoot, filepath.Join(rpcDir(agg, outputDir), "pb")),
+		valueObjects: make(map[string]*protoValueObject),
+		imports:      make(map[string]bool),
+	}
+	for _, agg := range aggregates {
+		s.collect(agg, agg.MappedFields())
+	}
+	return s
+}
+
+// collect 收集字段中的值对象
+func (s *protoScope) collect(agg *metadata.AggregateMetadata, fields []*metadata.FieldMetadata) {
+	for _, field := range fields {
+		if !field.Annotations.IsValueObject {
+			continue
+		}
+		members := field.ValueFields
+		if field.Annotations.Strategy == metadata.ValueObjectFlatten {
+			members = field.Flattened
+		}
+		domain := qualifyType(elementType(field), agg.PackageName)
+		name, ok := strings.CutPrefix(domain, agg.PackageName+".")
+		if !ok || len(members) == 0 || s.valueObjects[name] != nil {
+			continue
+		}
+		s.valueObjects[name] = &protoValueObject{agg: agg, name: name, domain: domain, fields: members}
+		s.collect(agg, members)
+	}
+}
+
+// use 记录生成的代码用到的包
+func (s *protoScope) use(importPath string) {
+	if importPath != "" {
+		s.imports[importPath] = true
+	}
+}
+
+// sortedValueObjects 返回按名称排序的值对象
+func (s *protoScope) sortedValueObjects() []*protoValueObject {
+	objects := make([]*protoValueObject, 0, len(s.valueObjects))
+	for _, object := range s.valueObjects {
+		objects = append(objects, object)
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].name < objects[j].name })
+	return objects
+}
+
+// protoElement 标量或消息类型的元素（字段本身或切片、map 的元素）在 .proto 和 Go 代码中的表示
+type protoElement struct {
+	scope     *protoScope
+	protoType string // .proto 中的类型，如 "int64"、"google.protobuf.Timestamp"、"Address"
+	goType    string // protoc-gen-go 生成的 Go 类型，如 "int64"、"*timestamppb.Timestamp"、"*pb.Address"
+	domain    string // 领域对象中的类型，如 "int"、"model.Address"
+	message   bool   // 是否为消息类型
+
+	to, from             string // 转换函数或类型，如 "timestamppb.New"、"fromTimestamp"、"int64"；为空表示类型相同
+	toImport, fromImport string // 转换函数所在的包
+	conversion           bool   // to、from 为类型转换
+}
+
+// protoScalars 基础类型 → .proto 标量类型和 protoc-gen-go 生成的 Go 类型
+var protoScalars = map[string][2]string{
+	"string":  {"string", "string"},
+	"bool":    {"bool", "bool"},
+	"int":     {"int64", "int64"},
+	"int8":    {"int32", "int32"},
+	"int16":   {"int32", "int32"},
+	"int32":   {"int32", "int32"},
+	"rune":    {"int32", "int32"},
+	"int64":   {"int64", "int64"},
+	"uint":    {"uint64", "uint64"},
+	"uint8":   {"uint32", "uint32"},
+	"byte":    {"uint32", "uint32"},
+	"uint16":  {"uint32", "uint32"},
+	"uint32":  {"uint32", "uint32"},
+	"uint64":  {"uint64", "uint64"},
+	"float32": {"float", "float32"},
+	"float64": {"double", "float64"},
+}
+
+// protoMapKeys 可以作为 map 键的 Go 类型（与 protoc-gen-go 生成的键类型相同）
+var protoMapKeys = []string{"string", "bool", "int32", "int64", "uint32", "uint64"}
+
+// element 返回带包名的类型 domain（底层类型为 basicType）对应的元素，无法映射时返回 nil
+func (s *protoScope) element(agg *metadata.AggregateMetadata, domain, basicType string) *protoElement {
+	e := &protoElement{scope: s, domain: domain}
+
+	switch domain {
+	case "time.Time":
+		e.protoType, e.goType, e.message = "google.protobuf.Timestamp", "*timestamppb.Timestamp", true
+		e.to, e.toImport, e.from = "timestamppb.New", "google.golang.org/protobuf/types/known/timestamppb", "fromTimestamp"
+		return e
+	case "time.Duration":
+		e.protoType, e.goType, e.message = "google.protobuf.Duration", "*durationpb.Duration", true
+		e.to, e.toImport, e.from = "durationpb.New", "google.golang.org/protobuf/types/known/durationpb", "fromDuration"
+		return e
+	case "json.RawMessage":
+		e.protoType, e.goType, e.conversion = "bytes", "[]byte", true
+		e.to, e.from, e.fromImport = "[]byte", "json.RawMessage", "encoding/json"
+		return e
+	}
+
+	if object := s.valueObjects[strings.TrimPrefix(domain, agg.PackageName+".")]; object != nil && object.domain == domain {
+		e.protoType, e.goType, e.message = object.name, "*pb."+object.name, true
+		e.to, e.from = "to"+object.name+"Proto", "from"+object.name+"Proto"
+		return e
+	}
+
+	scalar, ok := protoScalars[basicType]
+	// 其他包中的命名类型无法确定 import 路径
+	if !ok || strings.Contains(domain, ".") && !strings.HasPrefix(domain, agg.PackageName+".") {
+		return nil
+	}
+	e.protoType, e.goType = scalar[0], scalar[1]
+	if domain != e.goType {
+		e.to, e.from, e.conversion = e.goType, domain, true
+	}
+	return e
+}
+
+// toExpr 返回将领域值 value 转换为 protobuf 值的表达式
+func (e *protoElement) toExpr(value string) string {
+	if e.to == "" {
+		return value
+	}
+	e.scope.use(e.toImport)
+	return e.to + "(" + value + ")"
+}
+
+// fromExpr 返回将 protobuf 值 value 转换为领域值的表达式
+func (e *protoElement) fromExpr(value string) string {
+	if e.from == "" {
+		return value
+	}
+	e.scope.use(e.fromImport)
+	return e.from + "(" + value + ")"
+}
+
+// toFunc 返回将领域值转换为 protobuf 值的函数，用于 convertSlice 等辅助函数；类型相同时为空
+func (e *protoElement) toFunc() string {
+	if e.conversion {
+		return fmt.Sprintf("func(v %s) %s { return %s }", e.domain, e.goType, e.toExpr("v"))
+	}
+	e.scope.use(e.toImport)
+	return e.to
+}
+
+// fromFunc 返回将 protobuf 值转换为领域值的函数；类型相同时为空
+func (e *protoElement) fromFunc() string {
+	if e.conversion {
+		return fmt.Sprintf("func(v %s) %s { return %s }", e.goType, e.domain, e.fromExpr("v"))
+	}
+	e.scope.use(e.fromImport)
+	return e.from
+}
+
+// protoField 消息中的字段
+type protoField struct {
+	field     *metadata.FieldMetadata
+	name      string // .proto 中的字段名，如 "user_id"
+	goName    string // protoc-gen-go 生成的字段名，如 "UserId"
+	label     string // "optional"、"repeated" 或空
+	protoType string // .proto 中的类型，如 "int64"、"map<string, Address>"
+	message   bool   // 是否为单个消息（有字段存在性）
+
+	// to 返回将领域值 value 转换为字段值的表达式
+	to func(value string) string
+	// from 返回将消息 msg 中的字段写入 target 的语句；fallible 为 true 时语句可能 return err，只能用于返回 error 的函数
+	from     func(target, msg string) []string
+	fallible bool
+}
+
+// getter 返回读取消息 msg 中字段值的表达式，如 input.GetUserId()
+func (f *protoField) getter(msg string) string {
+	return fmt.Sprintf("%s.Get%s()", msg, f.goName)
+}
+
+// declaration 返回 .proto 中编号为 number 的字段声明，doc 为字段的注释
+func (f *protoField) declaration(number int, doc string) *ProtoMessageField {
+	return &ProtoMessageField{Doc: doc, Label: f.label, Type: f.protoType, Name: f.name, Number: number}
+}
+
+// field 返回字段的映射，无法映射时返回 nil
+func (s *protoScope) field(agg *metadata.AggregateMetadata, field *metadata.FieldMetadata) *protoField {
+	name := toSnakeCase(field.Name)
+	f := &protoField{field: field, name: name, goName: protoGoName(name)}
+	assign := func(expr func(msg string) string) func(target, msg string) []string {
+		return func(target, msg string) []string {
+			return []string{target + " = " + expr(msg)}
+		}
+	}
+	domain := qualifyType(field.Type, agg.PackageName)
+
+	switch {
+	case (field.IsSlice || field.IsArray) && field.Type == "byte" && !field.IsPointer:
+		f.protoType = "bytes"
+		if field.IsSlice {
+			f.to = func(value string) string { return value }
+			f.from = assign(f.getter)
+		} else {
+			f.to = func(value string) string { return value + "[:]" }
+			f.from = func(target, msg string) []string {
+				return []string{fmt.Sprintf("clear(%s[:])", target), fmt.Sprintf("copy(%s[:], %s)", target, f.getter(msg))}
+			}
+		}
+		return f
+
+	case field.IsSlice || field.IsArray:
+		elem := s.element(agg, domain, field.BasicType())
+		if elem == nil || field.IsPointer && !elem.message {
+			return nil
+		}
+		f.label, f.protoType = "repeated", elem.protoType
+		toFunc, fromFunc := elementFuncs(elem, field.IsPointer)
+		f.to = func(value string) string {
+			if field.IsArray {
+				value += "[:]"
+			}
+			if to := toFunc(); to != "" {
+				return fmt.Sprintf("convertSlice(%s, %s)", value, to)
+			}
+			return value
+		}
+		f.from = func(target, msg string) []string {
+			value := f.getter(msg)
+			if from := fromFunc(); from != "" {
+				value = fmt.Sprintf("convertSlice(%s, %s)", value, from)
+			}
+			if field.IsArray {
+				return []string{fmt.Sprintf("clear(%s[:])", target), fmt.Sprintf("copy(%s[:], %s)", target, value)}
+			}
+			return []string{target + " = " + value}
+		}
+		return f
+
+	case field.IsMap:
+		valueType := strings.TrimPrefix(field.MapValueType, "*")
+		pointer := valueType != field.MapValueType
+		if !slices.Contains(protoMapKeys, field.MapKeyType) || strings.ContainsAny(valueType, "[]*") {
+			return nil
+		}
+		elem := s.element(agg, qualifyType(valueType, agg.PackageName), valueType)
+		if elem == nil || pointer && !elem.message {
+			return nil
+		}
+		f.protoType = fmt.Sprintf("map<%s, %s>", field.MapKeyType, elem.protoType)
+		toFunc, fromFunc := elementFuncs(elem, pointer)
+		f.to = func(value string) string {
+			if to := toFunc(); to != "" {
+				return fmt.Sprintf("convertMap(%s, %s)", value, to)
+			}
+			return value
+		}
+		f.from = assign(func(msg string) string {
+			if from := fromFunc(); from != "" {
+				return fmt.Sprintf("convertMap(%s, %s)", f.getter(msg), from)
+			}
+			return f.getter(msg)
+		})
+		return f
+
+	case strings.HasPrefix(field.Type, "sql.Null"):
+		return s.nullField(f, field)
+	}
+
+	elem := s.element(agg, domain, field.BasicType())
+	if elem == nil {
+		return s.textField(f, field)
+	}
+	f.protoType, f.message = elem.protoType, elem.message && !field.IsPointer
+	switch {
+	case field.IsPointer && elem.message:
+		f.message = true
+		f.to = func(value string) string { return fmt.Sprintf("convertOptional(%s, %s)", value, elem.toFunc()) }
+		f.from = assign(func(msg string) string { return fmt.Sprintf("convertMessage(%s, %s)", f.getter(msg), elem.fromFunc()) })
+	case field.IsPointer:
+		f.label = "optional"
+		f.to = func(value string) string {
+			if to := elem.toFunc(); to != "" {
+				return fmt.Sprintf("convertPtr(%s, %s)", value, to)
+			}
+			return value
+		}
+		f.from = assign(func(msg string) string {
+			if from := elem.fromFunc(); from != "" {
+				return fmt.Sprintf("convertPtr(%s.%s, %s)", msg, f.goName, from)
+			}
+			return msg + "." + f.goName
+		})
+	default:
+		f.to = elem.toExpr
+		f.from = assign(func(msg string) string { return elem.fromExpr(f.getter(msg)) })
+	}
+	return f
+}
+
+// elementFuncs 返回切片、map 元素的转换函数，pointer 为 true 时元素为指向消息类型的指针，nil 对应 nil 消息
+func elementFuncs(elem *protoElement, pointer bool) (toFunc, fromFunc func() string) {
+	if !pointer {
+		return elem.toFunc, elem.fromFunc
+	}
+	toFunc = func() string {
+		return fmt.Sprintf("func(v *%s) %s { return convertOptional(v, %s) }", elem.domain, elem.goType, elem.toFunc())
+	}
+	fromFunc = func() string {
+		return fmt.Sprintf("func(m %s) *%s { return convertMessage(m, %s) }", elem.goType, elem.domain, elem.fromFunc())
+	}
+	return toFunc, fromFunc
+}
+
+// nullValueFields sql.NullXxx 中保存值的字段及其类型
+var nullValueFields = map[string]string{
+	"sql.NullString":  "string",
+	"sql.NullInt64":   "int64",
+	"sql.NullInt32":   "int32",
+	"sql.NullFloat64": "float64",
+	"sql.NullBool":    "bool",
+	"sql.NullTime":    "time.Time",
+}
+
+// nullField 映射 sql.NullXxx 字段：NullTime 为可以不设置的 Timestamp，其他为 optional 标量
+func (s *protoScope) nullField(f *protoField, field *metadata.FieldMetadata) *protoField {
+	valueType, ok := nullValueFields[field.Type]
+	if !ok || field.IsPointer {
+		return nil
+	}
+	valueField := strings.TrimPrefix(field.Type, "sql.Null")
+
+	f.to = func(value string) string {
+		optional := fmt.Sprintf("optionalValue(%s.%s, %s.Valid)", value, valueField, value)
+		if valueType == "time.Time" {
+			s.use("google.golang.org/protobuf/types/known/timestamppb")
+			return fmt.Sprintf("convertOptional(%s, timestamppb.New)", optional)
+		}
+		return optional
+	}
+	f.from = func(target, msg string) []string {
+		s.use("database/sql")
+		value := f.getter(msg)
+		if valueType == "time.Time" {
+			value = "fromTimestamp(" + value + ")"
+		}
+		return []string{fmt.Sprintf("%s = %s{%s: %s, Valid: %s.%s != nil}", target, field.Type, valueField, value, msg, f.goName)}
+	}
+
+	if valueType == "time.Time" {
+		f.protoType, f.message = "google.protobuf.Timestamp", true
+	} else {
+		f.label, f.protoType = "optional", protoScalars[valueType][0]
+	}
+	return f
+}
+
+// textField 映射其他已知标量类型（如 uuid.UUID）的字段：通过 String、UnmarshalText 与 string 转换，空字符串为零值
+func (s *protoScope) textField(f *protoField, field *metadata.FieldMetadata) *protoField {
+	if field.ScalarType == nil || field.ScalarType.ImportPath == "" || field.IsPointer {
+		return nil
+	}
+	f.protoType, f.fallible = "string", true
+	f.to = func(value string) string { return value + ".String()" }
+	f.from = func(target, msg string) []string {
+		s.use(field.ScalarType.ImportPath)
+		return []string{
+			fmt.Sprintf("if %s, err = parseText[%s](%q, %s); err != nil {", target, field.Type, f.name, f.getter(msg)),
+			"\treturn err",
+			"}",
+		}
+	}
+	return f
+}
+
+// protoMessage 聚合根的消息字段：响应（{AggregateName}）和请求（{AggregateName}Input）中的字段，以及无法映射的字段
+type protoMessage struct {
+	responses   []*protoField
+	inputs      []*protoField
+	readOnly    map[*protoField]bool // 只在新增时写入的字段
+	presence    map[*protoField]bool // 未设置时保持原值的字段（声明了 +soliton:default）
+	unsupported []*metadata.FieldMetadata
+}
+
+// message 计算聚合根的消息字段
+func (s *protoScope) message(agg *metadata.AggregateMetadata) *protoMessage {
+	m := &protoMessage{readOnly: make(map[*protoField]bool), presence: make(map[*protoField]bool)}
+	mapped := make(map[*metadata.FieldMetadata]bool)
+	for _, dto := range responseFields(agg) {
+		if f := s.field(agg, dto.field); f != nil {
+			m.responses = append(m.responses, f)
+			mapped[dto.field] = true
+		} else {
+			m.unsupported = append(m.unsupported, dto.field)
+		}
+	}
+	for _, dto := range requestFields(agg) {
+		f := s.field(agg, dto.field)
+		if f == nil {
+			if !slices.Contains(m.unsupported, dto.field) {
+				m.unsupported = append(m.unsupported, dto.field)
+			}
+			continue
+		}
+		m.inputs = append(m.inputs, f)
+		m.readOnly[f] = dto.readOnly
+		if _, ok := dto.field.DefaultLiteral(); ok && !f.field.IsSlice && !f.field.IsMap && !f.field.IsArray &&
+			!f.field.Annotations.IsValueObject && f.label != "repeated" {
+			m.presence[f] = true
+			if f.label == "" && !f.message {
+				f.label = "optional"
+			}
+		}
+	}
+	return m
+}
+
+// unsupportedComment 返回列出无法映射的字段的注释
+func unsupportedComment(fields []*metadata.FieldMetadata) string {
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = fmt.Sprintf("%s（%s）", field.Name, field.GoType())
+	}
+	return "无法映射、未包含的字段：" + strings.Join(names, "、")
+}
+
+// protoKey 请求中的主键字段
+type protoKey struct {
+	field *protoField
+	key   *metadata.FieldMetadata // 复合主键的字段，单列主键时为 nil
+}
+
+// keys 返回 Get、Update、Delete 请求中的主键字段：单列主键为 id，复合主键为各主键字段
+func (s *protoScope) keys(agg *metadata.AggregateMetadata) ([]protoKey, error) {
+	var keys []protoKey
+	for _, field := range agg.PrimaryKey {
+		f := s.field(agg, field)
+		if f == nil || f.label != "" || f.message || f.fallible {
+			return nil, fmt.Errorf("聚合根 %s 的主键字段 %s 的类型 %s 不能映射为 protobuf 标量", agg.Name, field.Name, field.Type)
+		}
+		if !agg.IsCompositeKey() {
+			f.name, f.goName = "id", "Id"
+			return []protoKey{{field: f}}, nil
+		}
+		keys = append(keys, protoKey{field: f, key: field})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("聚合根 %s 没有主键", agg.Name)
+	}
+	return keys, nil
+}
+
+// keyExpr 返回由请求 req 中的主键字段构造主键的表达式
+func keyExpr(agg *metadata.AggregateMetadata, keys []protoKey, req string) string {
+	if !agg.IsCompositeKey() {
+		return strings.TrimPrefix(keys[0].field.from("", req)[0], " = ")
+	}
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		values[i] = fmt.Sprintf("%s: %s", key.key.Name, strings.TrimPrefix(key.field.from("", req)[0], " = "))
+	}
+	return fmt.Sprintf("%s{%s}", qualifiedKeyType(agg), strings.Join(values, ", "))
+}
+
+// protoRPC 服务中的方法
+type protoRPC struct {
+	op       string
+	name     string // 方法名，如 CreateOrder
+	request  string // 请求消息名
+	response string // 响应消息名
+}
+
+// protoRPCs 返回聚合根启用的方法，按 APIOps 的顺序排列
+func protoRPCs(agg *metadata.AggregateMetadata) []protoRPC {
+	var rpcs []protoRPC
+	for _, op := range apiOperations(agg) {
+		switch op {
+		case metadata.APIOpCreate:
+			rpcs = append(rpcs, protoRPC{op, "Create" + agg.Name, "Create" + agg.Name + "Request", agg.Name})
+		case metadata.APIOpGet:
+			rpcs = append(rpcs, protoRPC{op, "Get" + agg.Name, "Get" + agg.Name + "Request", agg.Name})
+		case metadata.APIOpList:
+			plural := agg.PluralName()
+			rpcs = append(rpcs, protoRPC{op, "List" + plural, "List" + plural + "Request", "List" + plural + "Response"})
+		case metadata.APIOpUpdate:
+			rpcs = append(rpcs, protoRPC{op, "Update" + agg.Name, "Update" + agg.Name + "Request", agg.Name})
+		case metadata.APIOpDelete:
+			rpcs = append(rpcs, protoRPC{op, "Delete" + agg.Name, "Delete" + agg.Name + "Request", "google.protobuf.Empty"})
+		}
+	}
+	return rpcs
+}
+
+// writable 判断是否启用了新增或更新，此时生成 {AggregateName}Input
+func writable(agg *metadata.AggregateMetadata) bool {
+	ops := apiOperations(agg)
+	return slices.Contains(ops, metadata.APIOpCreate) || slices.Contains(ops, metadata.APIOpUpdate)
+}
+
+// generateProto 生成限界上下文的 .proto 文件
+func (g *GRPCGenerator) generateProto(scope *protoScope, aggregates []*metadata.AggregateMetadata, protoPath string) (*ProtoData, error) {
+	data := &ProtoData{Package: protoPackage(aggregates[0]), GoPackage: scope.pbImport}
+	imports := make(map[string]bool)
+
+	for _, agg := range aggregates {
+		keys, err := scope.keys(agg)
+		if err != nil {
+			return nil, err
+		}
+		message := scope.message(agg)
+		rpcs := protoRPCs(agg)
+		payload := toSnakeCase(agg.Name)
+
+		service := &ProtoService{Doc: fmt.Sprintf("%sService %s 的增删改查", agg.Name, agg.Name), Name: agg.Name + "Service"}
+		for _, rpc := range rpcs {
+			service.Methods = append(service.Methods, &ProtoMethod{Name: rpc.name, Request: rpc.request, Response: rpc.response})
+		}
+		data.Services = append(data.Services, service)
+
+		service.Messages = append(service.Messages, g.message(agg, fmt.Sprintf("%s 查询、新增和更新 %s 返回的消息", agg.Name, agg.Name),
+			agg.Name, message.responses, message.unsupported, nil))
+		if writable(agg) {
+			service.Messages = append(service.Messages, g.message(agg, fmt.Sprintf("%sInput 新增、更新 %s 时写入的字段", agg.Name, agg.Name),
+				agg.Name+"Input", message.inputs, nil, func(f *protoField) []string {
+					var notes []string
+					if message.readOnly[f] {
+						notes = append(notes, "只在新增时写入，更新时忽略")
+					}
+					if message.presence[f] {
+						notes = append(notes, fmt.Sprintf("未设置时保持原值，新增时为默认值 %s", f.field.Annotations.Default))
+					}
+					return notes
+				}))
+		}
+
+		keyFields := make([]*ProtoMessageField, len(keys))
+		for i, key := range keys {
+			keyFields[i] = key.field.declaration(i+1, "")
+		}
+		for _, rpc := range rpcs {
+			request := &ProtoMessage{Name: rpc.request}
+			switch rpc.op {
+			case metadata.APIOpCreate:
+				request.Fields = []*ProtoMessageField{{Type: agg.Name + "Input", Name: payload, Number: 1}}
+			case metadata.APIOpGet, metadata.APIOpDelete:
+				request.Fields = keyFields
+				if rpc.op == metadata.APIOpDelete {
+					imports["google/protobuf/empty.proto"] = true
+				}
+			case metadata.APIOpList:
+				request.Fields = []*ProtoMessageField{
+					{Doc: "页码，从 1 开始，0 表示第 1 页", Type: "int32", Name: "page", Number: 1},
+					{Doc: fmt.Sprintf("每页数量，0 表示默认值 %d，最大 %d", framework.DefaultPageSize, framework.MaxPageSize), Type: "int32", Name: "page_size", Number: 2},
+				}
+			case metadata.APIOpUpdate:
+				imports["google/protobuf/field_mask.proto"] = true
+				request.Fields = append(slices.Clone(keyFields),
+					&ProtoMessageField{Type: agg.Name + "Input", Name: payload, Number: len(keys) + 1},
+					&ProtoMessageField{Doc: fmt.Sprintf("要写入的 %s 字段，为空时写入全部字段", payload), Type: "google.protobuf.FieldMask",
+						Name: "update_mask", Number: len(keys) + 2})
+			}
+			service.Messages = append(service.Messages, request)
+
+			if rpc.op == metadata.APIOpList {
+				service.Messages = append(service.Messages, &ProtoMessage{Name: rpc.response, Fields: []*ProtoMessageField{
+					{Label: "repeated", Type: agg.Name, Name: "items", Number: 1},
+					{Doc: "总数", Type: "int64", Name: "total", Number: 2},
+					{Type: "int32", Name: "page", Number: 3},
+					{Type: "int32", Name: "page_size", Number: 4},
+				}})
+			}
+		}
+	}
+
+	for _, object := range scope.sortedValueObjects() {
+		var fields []*protoField
+		var unsupported []*metadata.FieldMetadata
+		for _, member := range object.fields {
+			if f := scope.field(object.agg, member); f != nil && !f.fallible {
+				fields = append(fields, f)
+			} else {
+				unsupported = append(unsupported, member)
+			}
+		}
+		data.Messages = append(data.Messages, g.message(nil, object.name+" 值对象", object.name, fields, unsupported, nil))
+	}
+
+	// 字段中用到的 Duration、Timestamp
+	messages := slices.Clone(data.Messages)
+	for _, service := range data.Services {
+		messages = append(messages, service.Messages...)
+	}
+	for _, message := range messages {
+		for _, field := range message.Fields {
+			for _, wellKnown := range []string{"Duration", "Timestamp"} {
+				if strings.Contains(field.Type, "google.protobuf."+wellKnown) {
+					imports["google/protobuf/"+strings.ToLower(wellKnown)+".proto"] = true
+				}
+			}
+		}
+	}
+	for importPath := range imports {
+		data.Imports = append(data.Imports, importPath)
+	}
+	sort.Strings(data.Imports)
+
+	relPath, err := filepath.Rel(aggregates[0].ModuleRoot, protoPath)
+	if err != nil {
+		relPath = protoPath
+	}
+	data.File = filepath.ToSlash(relPath)
+
+	return data, nil
+}
+
+// message 返回消息，notes 返回字段的附加说明
+func (g *GRPCGenerator) message(agg *metadata.AggregateMetadata, doc, name string, fields []*protoField,
+	unsupported []*metadata.FieldMetadata, notes func(f *protoField) []string) *ProtoMessage {
+	message := &ProtoMessage{Doc: doc, Name: name}
+	if len(unsupported) > 0 {
+		message.Comment = unsupportedComment(unsupported)
+	}
+	for i, f := range fields {
+		var lines []string
+		if description := g.enumDescription(agg, f.field); description != "" {
+			lines = append(lines, "取值："+description)
+		}
+		if notes != nil {
+			lines = append(lines, notes(f)...)
+		}
+		message.Fields = append(message.Fields, f.declaration(i+1, strings.Join(lines, "\n")))
+	}
+	return message
+}
+
+// enumDescription 返回枚举字段的取值说明，如 "PENDING、PAID"、"1 ACTIVE：正常、2 BANNED：封禁"；不是枚举字段时为空
+func (g *GRPCGenerator) enumDescription(agg *metadata.AggregateMetadata, field *metadata.FieldMetadata) string {
+	if g.registry == nil || agg == nil {
+		return ""
+	}
+	for _, enum := range g.registry.GetEnums() {
+		if enum.AggregateName == agg.Name && enum.FieldName == field.Name {
+			values := make([]string, len(enum.Values))
+			for i := range enum.Values {
+				values[i] = enumValueDescription(enum, i)
+			}
+			return strings.Join(values, "、")
+		}
+	}
+	return ""
+}
+
+// generateServer 生成聚合根的服务端适配器
+func (g *GRPCGenerator) generateServer(scope *protoScope, agg *metadata.AggregateMetadata) (*GoFileData, error) {
+	keys, err := scope.keys(agg)
+	if err != nil {
+		return nil, err
+	}
+	message := scope.message(agg)
+	rpcs := protoRPCs(agg)
+	payload := protoGoName(toSnakeCase(agg.Name))
+
+	file := &GoFileData{
+		TemplateData: TemplateData{Aggregate: agg, Context: agg.Context()},
+		Package:      "rpc",
+	}
+	server := agg.Name + "Server"
+	entityType := fmt.Sprintf("*%s.%s", agg.PackageName, agg.Name)
+	serviceType := fmt.Sprintf("framework.ServiceOf[%s, %s]", entityType, qualifiedKeyType(agg))
+	mutableVar := toLowerFirst(agg.Name) + "MutableFields"
+	hasUpdate := slices.Contains(apiOperations(agg), metadata.APIOpUpdate)
+
+	if hasUpdate {
+		var names []string
+		for _, f := range message.inputs {
+			if !message.readOnly[f] {
+				names = append(names, fmt.Sprintf("%q", f.name))
+			}
+		}
+		file.addValues(declVar, fmt.Sprintf("%s %sInput 中可以更新的字段，用于校验 update_mask", mutableVar, agg.Name),
+			&GoField{Name: mutableVar, Value: fmt.Sprintf("[]string{%s}", strings.Join(names, ", "))})
+	}
+
+	file.addType(fmt.Sprintf("%s %s 的 gRPC 服务，实现 pb.%sServiceServer", server, agg.Name, agg.Name), server, "struct",
+		&GoField{Type: fmt.Sprintf("pb.Unimplemented%sServiceServer", agg.Name)},
+		&GoField{Name: "service", Type: serviceType})
+	file.addFunc(&GoFunc{
+		Doc:     fmt.Sprintf("New%s 创建 %s 的 gRPC 服务", server, agg.Name),
+		Name:    "New" + server,
+		Params:  "service " + serviceType,
+		Results: "*" + server,
+		Body:    fmt.Sprintf("\treturn &%s{service: service}\n", server),
+	})
+
+	key := keyExpr(agg, keys, "req")
+	defaults, needTime := defaultAssignments(agg)
+	for _, rpc := range rpcs {
+		var body strings.Builder
+		method := &GoFunc{
+			Receiver: "s *" + server,
+			Name:     rpc.name,
+			Params:   fmt.Sprintf("ctx context.Context, req *pb.%s", rpc.request),
+			Results:  fmt.Sprintf("(*pb.%s, error)", rpc.response),
+		}
+		switch rpc.op {
+		case metadata.APIOpCreate:
+			method.Doc = fmt.Sprintf("%s 新增 %s，声明了 +soliton:default 的字段未设置时取默认值", rpc.name, agg.Name)
+			body.WriteString(fmt.Sprintf("\tentity := &%s.%s{}\n", agg.PackageName, agg.Name))
+			writeStatements(&body, "\t", defaults)
+			body.WriteString(fmt.Sprintf("\tif err := apply%sInput(entity, req.Get%s(), nil, true); err != nil {\n", agg.Name, payload))
+			body.WriteString("\t\treturn nil, toStatus(ctx, err)\n")
+			body.WriteString("\t}\n")
+			body.WriteString("\tif err := s.service.Add(ctx, entity); err != nil {\n")
+			body.WriteString("\t\treturn nil, toStatus(ctx, err)\n")
+			body.WriteString("\t}\n")
+			body.WriteString(fmt.Sprintf("\treturn to%sProto(entity), nil\n", agg.Name))
+		case metadata.APIOpGet:
+			method.Doc = fmt.Sprintf("%s 按主键查询 %s", rpc.name, agg.Name)
+			body.WriteString(fmt.Sprintf("\tentity, err := s.service.GetByID(ctx, %s)\n", key))
+			body.WriteString("\tif err != nil {\n")
+			body.WriteString("\t\treturn nil, toStatus(ctx, err)\n")
+			body.WriteString("\t}\n")
+			body.WriteString(fmt.Sprintf("\treturn to%sProto(entity), nil\n", agg.Name))
+		case metadata.APIOpList:
+			method.Doc = fmt.Sprintf("%s 分页查询 %s，page 从 1 开始，page_size 最大为 framework.MaxPageSize", rpc.name, agg.Name)
+			body.WriteString("\tpage, pageSize, err := framework.NormalizePage(int(req.GetPage()), int(req.GetPageSize()))\n")
+			body.WriteString("\tif err != nil {\n")
+			body.WriteString("\t\treturn nil, toStatus(ctx, err)\n")
+			body.WriteString("\t}\n")
+			body.WriteString("\tentities, total, err := s.service.GetPage(ctx, page, pageSize)\n")
+			body.WriteString("\tif err != nil {\n")
+			body.WriteString("\t\treturn nil, toStatus(ctx, err)\n")
+			body.WriteString("\t}\n\n")
+			body.WriteString(fmt.Sprintf("\titems := make([]*pb.%s, len(entities))\n", agg.Name))
+			body.WriteString("\tfor i, entity := range entities {\n")
+			body.WriteString(fmt.Sprintf("\t\titems[i] = to%sProto(entity)\n", agg.Name))
+			body.WriteString("\t}\n")
+			body.WriteString(fmt.Sprintf("\treturn &pb.%s{Items: items, Total: total, Page: int32(page), PageSize: int32(pageSize)}, nil\n", rpc.response))
+		case metadata.APIOpUpdate:
+			method.Doc = fmt.Sprintf("%s 更新 %s：update_mask 为空时写入全部字段，否则只写入其中列出的字段；主键和 +soliton:immutable 字段不可修改", rpc.name, agg.Name)
+			body.WriteString(fmt.Sprintf("\tmask, err := newFieldMask(req.GetUpdateMask(), %s)\n", mutableVar))
+			body.WriteString("\tif err != nil {\n")
+			body.WriteString("\t\treturn nil, toStatus(ctx, err)\n")
+			body.WriteString("\t}\n")
+			body.WriteString(fmt.Sprintf("\tentity, err := s.service.GetByID(ctx, %s)\n", key))
+			body.WriteString("\tif err != nil {\n")
+			body.WriteString("\t\treturn nil, toStatus(ctx, err)\n")
+			body.WriteString("\t}\n")
+			body.WriteString(fmt.Sprintf("\tif err := apply%sInput(entity, req.Get%s(), mask, false); err != nil {\n", agg.Name, payload))
+			body.WriteString("\t\treturn nil, toStatus(ctx, err)\n")
+			body.WriteString("\t}\n")
+			body.WriteString("\tif err := s.service.Update(ctx, entity); err != nil {\n")
+			body.WriteString("\t\treturn nil, toStatus(ctx, err)\n")
+			body.WriteString("\t}\n")
+			body.WriteString(fmt.Sprintf("\treturn to%sProto(entity), nil\n", agg.Name))
+		case metadata.APIOpDelete:
+			scope.use("google.golang.org/protobuf/types/known/emptypb")
+			method.Doc = fmt.Sprintf("%s 删除 %s", rpc.name, agg.Name)
+			method.Results = "(*emptypb.Empty, error)"
+			body.WriteString(fmt.Sprintf("\tif err := s.service.Delete(ctx, %s); err != nil {\n", key))
+			body.WriteString("\t\treturn nil, toStatus(ctx, err)\n")
+			body.WriteString("\t}\n")
+			body.WriteString("\treturn &emptypb.Empty{}, nil\n")
+		}
+		method.Body = body.String()
+		file.addFunc(method)
+	}
+
+	// 转换
+	var body strings.Builder
+	writeMessageLiteral(&body, agg.Name, message.responses, "entity")
+	file.addFunc(&GoFunc{
+		Doc:     fmt.Sprintf("to%sProto 将实体转换为 protobuf 消息", agg.Name),
+		Name:    fmt.Sprintf("to%sProto", agg.Name),
+		Params:  "entity " + entityType,
+		Results: "*pb." + agg.Name,
+		Body:    body.String(),
+	})
+
+	if writable(agg) {
+		file.addFunc(g.generateApplyInput(agg, message, entityType))
+	}
+
+	// 导入
+	file.addImports("context", agg.ImportPath, "soliton/pkg/framework", scope.pbImport)
+	if needTime && len(defaults) > 0 && slices.Contains(apiOperations(agg), metadata.APIOpCreate) {
+		file.addImports("time")
+	}
+	for importPath := range scope.imports {
+		file.addImports(importPath)
+	}
+
+	return file, nil
+}
+
+// writeMessageLiteral 写出由 source（实体或值对象）构造消息 name 的 return 语句
+func writeMessageLiteral(sb *strings.Builder, name string, fields []*protoField, source string) {
+	sb.WriteString(fmt.Sprintf("\treturn &pb.%s{\n", name))
+	for _, f := range fields {
+		sb.WriteString(fmt.Sprintf("\t\t%s: %s,\n", f.goName, f.to(source+"."+f.field.Name)))
+	}
+	sb.WriteString("\t}\n")
+}
+
+// generateApplyInput 生成将 {AggregateName}Input 写入实体的 apply{AggregateName}Input
+func (g *GRPCGenerator) generateApplyInput(agg *metadata.AggregateMetadata, message *protoMessage, entityType string) *GoFunc {
+	var sb strings.Builder
+	if slices.ContainsFunc(message.inputs, func(f *protoField) bool { return f.fallible }) {
+		sb.WriteString("\tvar err error\n")
+	}
+
+	// 未设置时保持原值的字段需要同时判断字段是否存在
+	condition := func(f *protoField, conditions ...string) string {
+		if message.presence[f] {
+			conditions = append(conditions, fmt.Sprintf("input.%s != nil", f.goName))
+		}
+		return strings.Join(conditions, " && ")
+	}
+
+	var readOnly []*protoField
+	for _, f := range message.inputs {
+		if message.readOnly[f] {
+			readOnly = append(readOnly, f)
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\tif %s {\n", condition(f, fmt.Sprintf("mask.has(%q)", f.name))))
+		writeStatements(&sb, "\t\t", f.from("entity."+f.field.Name, "input"))
+		sb.WriteString("\t}\n")
+	}
+	if len(readOnly) > 0 {
+		sb.WriteString("\tif creating {\n")
+		for _, f := range readOnly {
+			if message.presence[f] {
+				sb.WriteString(fmt.Sprintf("\t\tif %s {\n", condition(f)))
+				writeStatements(&sb, "\t\t\t", f.from("entity."+f.field.Name, "input"))
+				sb.WriteString("\t\t}\n")
+			} else {
+				writeStatements(&sb, "\t\t", f.from("entity."+f.field.Name, "input"))
+			}
+		}
+		sb.WriteString("\t}\n")
+	}
+	sb.WriteString("\treturn nil\n")
+
+	return &GoFunc{
+		Doc: fmt.Sprintf("apply%sInput 将请求中的字段写入实体：mask 为 nil 时写入全部字段，否则只写入其中列出的字段；\n", agg.Name) +
+			"更新（creating 为 false）时忽略主键和 +soliton:immutable 字段，声明了 +soliton:default 的字段未设置时保持原值",
+		Name:    fmt.Sprintf("apply%sInput", agg.Name),
+		Params:  fmt.Sprintf("entity %s, input *pb.%sInput, mask fieldMask, creating bool", entityType, agg.Name),
+		Results: "error",
+		Body:    sb.String(),
+	}
+}
+
+// generateConvert 生成限界上下文共用的转换辅助函数（见模板 grpc_convert）、值对象的转换函数和错误转换
+func (g *GRPCGenerator) generateConvert(scope *protoScope) *GoFileData {
+	file := &GoFileData{Package: "rpc"}
+	file.addImports(
+		"context", "encoding", "errors", "log", "slices", "soliton/pkg/framework", "time",
+		"google.golang.org/grpc", "google.golang.org/grpc/codes", "google.golang.org/grpc/status",
+		"google.golang.org/protobuf/types/known/durationpb", "google.golang.org/protobuf/types/known/fieldmaskpb",
+		"google.golang.org/protobuf/types/known/timestamppb",
+	)
+
+	// 值对象
+	for _, object := range scope.sortedValueObjects() {
+		var fields []*protoField
+		for _, member := range object.fields {
+			if f := scope.field(object.agg, member); f != nil && !f.fallible {
+				fields = append(fields, f)
+			}
+		}
+		file.addImports(scope.pbImport, object.agg.ImportPath)
+
+		var body strings.Builder
+		writeMessageLiteral(&body, object.name, fields, "v")
+		file.addFunc(&GoFunc{
+			Doc:     fmt.Sprintf("to%sProto 将值对象 %s 转换为 protobuf 消息", object.name, object.name),
+			Name:    fmt.Sprintf("to%sProto", object.name),
+			Params:  "v " + object.domain,
+			Results: "*pb." + object.name,
+			Body:    body.String(),
+		})
+
+		body.Reset()
+		body.WriteString(fmt.Sprintf("\tvar v %s\n", object.domain))
+		for _, f := range fields {
+			writeStatements(&body, "\t", f.from("v."+f.field.Name, "m"))
+		}
+		body.WriteString("\treturn v\n")
+		file.addFunc(&GoFunc{
+			Doc:     fmt.Sprintf("from%sProto 将 protobuf 消息转换为值对象 %s，m 为 nil 时返回零值", object.name, object.name),
+			Name:    fmt.Sprintf("from%sProto", object.name),
+			Params:  "m *pb." + object.name,
+			Results: object.domain,
+			Body:    body.String(),
+		})
+	}
+
+	for importPath := range scope.imports {
+		file.addImports(importPath)
+	}
+	return file
+}
+
+// generateRegister 生成注册限界上下文内全部服务的 RegisterServices
+func (g *GRPCGenerator) generateRegister(aggregates []*metadata.AggregateMetadata, outputDir string) *GoFileData {
+	file := &GoFileData{Package: "rpc"}
+	file.addImports("google.golang.org/grpc", newProtoScope(aggregates[:1], outputDir).pbImport)
+
+	servers := file.addType("Servers 各聚合根的 gRPC 服务，为 nil 的服务不注册", "Servers", "struct")
+	var body strings.Builder
+	for _, agg := range aggregates {
+		servers.Fields = append(servers.Fields, &GoField{Name: agg.Name, Type: "*" + agg.Name + "Server"})
+		body.WriteString(fmt.Sprintf("\tif servers.%s != nil {\n", agg.Name))
+		body.WriteString(fmt.Sprintf("\t\tpb.Register%sServiceServer(registrar, servers.%s)\n", agg.Name, agg.Name))
+		body.WriteString("\t}\n")
+	}
+	file.addFunc(&GoFunc{
+		Doc:    "RegisterServices 注册全部 gRPC 服务，如 RegisterServices(grpcServer, rpc.Servers{...})",
+		Name:   "RegisterServices",
+		Params: "registrar grpc.ServiceRegistrar, servers Servers",
+		Body:   body.String(),
+	})
+	return file
+}
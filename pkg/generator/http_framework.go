@@ -0,0 +1,338 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 生成 REST 处理器使用的 Web 框架（-http）
+const (
+	HTTPFrameworkGin  = "gin"
+	HTTPFrameworkEcho = "echo"
+	HTTPFrameworkChi  = "chi"
+)
+
+// HTTPFrameworks 支持的 Web 框架
+var HTTPFrameworks = []string{HTTPFrameworkGin, HTTPFrameworkEcho, HTTPFrameworkChi}
+
+// httpRenderer 按 Web 框架渲染 REST 处理器中与框架有关的代码
+//
+// 路径参数统一写作 {id}，由渲染器转换为框架的写法；处理函数中的响应语句以 return 结束处理，
+// 错误响应由 responseHelpers 中的 respondError 写出。
+type httpRenderer interface {
+	// importPath 返回 Web 框架的 import 路径
+	importPath() string
+	// routerType 返回 RegisterRoutes 接收的路由类型，如 "gin.IRoutes"
+	routerType() string
+	// route 返回注册路由的语句，method 为 GET、POST 等，handler 为处理函数，如 "h.Create"
+	route(method, path, handler string) string
+	// handlerParams、handlerResults 返回处理函数的参数和结果，如 "c *gin.Context" 和 ""
+	handlerParams() string
+	handlerResults() string
+	// requestParam 返回读取路径参数的函数的参数声明，requestArg 为处理函数中对应的实参
+	requestParam() string
+	requestArg() string
+	// context 返回请求的 context.Context
+	context() string
+	// pathValue、queryValue 返回读取路径参数、查询参数的表达式
+	pathValue(name string) string
+	queryValue(name string) string
+	// bindJSON 返回将请求体解码到 target（指针表达式）的表达式，结果为 error
+	bindJSON(target string) string
+	// respond 返回以状态码 status 写出 JSON 响应 body 并结束处理的语句
+	respond(status, body string) []string
+	// noContent 返回写出 204 并结束处理的语句
+	noContent() []string
+	// fail 返回写出错误响应并结束处理的语句
+	fail(err string) []string
+	// responseHelpers 向 file 添加 respondError 等辅助函数及其需要导入的包（soliton/pkg/framework 除外）
+	responseHelpers(file *GoFileData)
+}
+
+// newHTTPRenderer 返回 Web 框架对应的渲染器，未知框架按 gin 渲染
+func newHTTPRenderer(framework string) httpRenderer {
+	switch framework {
+	case HTTPFrameworkEcho:
+		return echoHTTP{}
+	case HTTPFrameworkChi:
+		return chiHTTP{}
+	}
+	return ginHTTP{}
+}
+
+// respondErrorDoc 各框架 respondError 的注释
+const respondErrorDoc = "respondError 按 framework.HTTPError 写出统一的错误响应，500 错误记录日志"
+
+// pathParamPattern 路径参数 {name}
+var pathParamPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+// colonPath 将路径参数 {id} 转换为 :id（gin、echo 的写法）
+func colonPath(path string) string {
+	return pathParamPattern.ReplaceAllString(path, ":$1")
+}
+
+// ginHTTP github.com/gin-gonic/gin
+type ginHTTP struct{}
+
+func (ginHTTP) importPath() string {
+	return "github.com/gin-gonic/gin"
+}
+
+func (ginHTTP) routerType() string {
+	return "gin.IRoutes"
+}
+
+func (ginHTTP) route(method, path, handler string) string {
+	return fmt.Sprintf("router.%s(%q, %s)", method, colonPath(path), handler)
+}
+
+func (ginHTTP) handlerParams() string {
+	return "c *gin.Context"
+}
+
+func (ginHTTP) handlerResults() string {
+	return ""
+}
+
+func (ginHTTP) requestParam() string {
+	return "c *gin.Context"
+}
+
+func (ginHTTP) requestArg() string {
+	return "c"
+}
+
+func (ginHTTP) context() string {
+	return "c.Request.Context()"
+}
+
+func (ginHTTP) pathValue(name string) string {
+	return fmt.Sprintf("c.Param(%q)", name)
+}
+
+func (ginHTTP) queryValue(name string) string {
+	return fmt.Sprintf("c.Query(%q)", name)
+}
+
+func (ginHTTP) bindJSON(target string) string {
+	return fmt.Sprintf("c.ShouldBindJSON(%s)", target)
+}
+
+func (ginHTTP) respond(status, body string) []string {
+	return []string{fmt.Sprintf("c.JSON(%s, %s)", status, body)}
+}
+
+func (ginHTTP) noContent() []string {
+	return []string{"c.Status(http.StatusNoContent)"}
+}
+
+func (ginHTTP) fail(err string) []string {
+	return []string{fmt.Sprintf("respondError(c, %s)", err), "return"}
+}
+
+func (ginHTTP) responseHelpers(file *GoFileData) {
+	var sb strings.Builder
+	sb.WriteString("\tstatus, body := framework.HTTPError(err)\n")
+	sb.WriteString("\tif status == http.StatusInternalServerError {\n")
+	sb.WriteString("\t\tlog.Printf(\"%s %s 失败: %v\", c.Request.Method, c.Request.URL.Path, err)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tc.JSON(status, body)\n")
+
+	file.addImports("github.com/gin-gonic/gin", "log", "net/http")
+	file.addFunc(&GoFunc{
+		Doc:    respondErrorDoc,
+		Name:   "respondError",
+		Params: "c *gin.Context, err error",
+		Body:   sb.String(),
+	})
+}
+
+// echoHTTP github.com/labstack/echo/v4，处理函数返回 error
+type echoHTTP struct{}
+
+func (echoHTTP) importPath() string {
+	return "github.com/labstack/echo/v4"
+}
+
+func (echoHTTP) routerType() string {
+	return "Router"
+}
+
+func (echoHTTP) route(method, path, handler string) string {
+	return fmt.Sprintf("router.%s(%q, %s)", method, colonPath(path), handler)
+}
+
+func (echoHTTP) handlerParams() string {
+	return "c echo.Context"
+}
+
+func (echoHTTP) handlerResults() string {
+	return "error"
+}
+
+func (echoHTTP) requestParam() string {
+	return "c echo.Context"
+}
+
+func (echoHTTP) requestArg() string {
+	return "c"
+}
+
+func (echoHTTP) context() string {
+	return "c.Request().Context()"
+}
+
+func (echoHTTP) pathValue(name string) string {
+	return fmt.Sprintf("c.Param(%q)", name)
+}
+
+func (echoHTTP) queryValue(name string) string {
+	return fmt.Sprintf("c.QueryParam(%q)", name)
+}
+
+func (echoHTTP) bindJSON(target string) string {
+	return fmt.Sprintf("c.Bind(%s)", target)
+}
+
+func (echoHTTP) respond(status, body string) []string {
+	return []string{fmt.Sprintf("return c.JSON(%s, %s)", status, body)}
+}
+
+func (echoHTTP) noContent() []string {
+	return []string{"return c.NoContent(http.StatusNoContent)"}
+}
+
+func (echoHTTP) fail(err string) []string {
+	return []string{fmt.Sprintf("return respondError(c, %s)", err)}
+}
+
+func (echoHTTP) responseHelpers(file *GoFileData) {
+	router := file.addType("Router 可以注册路由的 *echo.Echo 或 *echo.Group", "Router", "interface")
+	for _, method := range []string{"GET", "POST", "PUT", "DELETE"} {
+		router.Methods = append(router.Methods, &GoFunc{
+			Name:    method,
+			Params:  "path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc",
+			Results: "*echo.Route",
+		})
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\tstatus, body := framework.HTTPError(err)\n")
+	sb.WriteString("\tif status == http.StatusInternalServerError {\n")
+	sb.WriteString("\t\tlog.Printf(\"%s %s 失败: %v\", c.Request().Method, c.Request().URL.Path, err)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn c.JSON(status, body)\n")
+
+	file.addImports("github.com/labstack/echo/v4", "log", "net/http")
+	file.addFunc(&GoFunc{
+		Doc:     respondErrorDoc,
+		Name:    "respondError",
+		Params:  "c echo.Context, err error",
+		Results: "error",
+		Body:    sb.String(),
+	})
+}
+
+// chiHTTP github.com/go-chi/chi/v5，处理函数为 net/http 的 http.HandlerFunc
+type chiHTTP struct{}
+
+func (chiHTTP) importPath() string {
+	return "github.com/go-chi/chi/v5"
+}
+
+func (chiHTTP) routerType() string {
+	return "chi.Router"
+}
+
+func (chiHTTP) route(method, path, handler string) string {
+	// chi 的方法名为 Get、Post 等
+	return fmt.Sprintf("router.%s(%q, %s)", method[:1]+strings.ToLower(method[1:]), path, handler)
+}
+
+func (chiHTTP) handlerParams() string {
+	return "w http.ResponseWriter, r *http.Request"
+}
+
+func (chiHTTP) handlerResults() string {
+	return ""
+}
+
+func (chiHTTP) requestParam() string {
+	return "r *http.Request"
+}
+
+func (chiHTTP) requestArg() string {
+	return "r"
+}
+
+func (chiHTTP) context() string {
+	return "r.Context()"
+}
+
+func (chiHTTP) pathValue(name string) string {
+	return fmt.Sprintf("chi.URLParam(r, %q)", name)
+}
+
+func (chiHTTP) queryValue(name string) string {
+	return fmt.Sprintf("r.URL.Query().Get(%q)", name)
+}
+
+func (chiHTTP) bindJSON(target string) string {
+	return fmt.Sprintf("decodeJSON(r, %s)", target)
+}
+
+func (chiHTTP) respond(status, body string) []string {
+	return []string{fmt.Sprintf("respondJSON(w, %s, %s)", status, body)}
+}
+
+func (chiHTTP) noContent() []string {
+	return []string{"w.WriteHeader(http.StatusNoContent)"}
+}
+
+func (chiHTTP) fail(err string) []string {
+	return []string{fmt.Sprintf("respondError(w, r, %s)", err), "return"}
+}
+
+func (chiHTTP) responseHelpers(file *GoFileData) {
+	file.addImports("encoding/json", "errors", "io", "log", "net/http")
+
+	var sb strings.Builder
+	sb.WriteString("\tif err := json.NewDecoder(r.Body).Decode(target); err != nil && !errors.Is(err, io.EOF) {\n")
+	sb.WriteString("\t\treturn err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn nil\n")
+	file.addFunc(&GoFunc{
+		Doc:     "decodeJSON 将请求体解码到 target，请求体为空时保持 target 不变",
+		Name:    "decodeJSON",
+		Params:  "r *http.Request, target any",
+		Results: "error",
+		Body:    sb.String(),
+	})
+
+	sb.Reset()
+	sb.WriteString("\tw.Header().Set(\"Content-Type\", \"application/json; charset=utf-8\")\n")
+	sb.WriteString("\tw.WriteHeader(status)\n")
+	sb.WriteString("\tif err := json.NewEncoder(w).Encode(body); err != nil {\n")
+	sb.WriteString("\t\tlog.Printf(\"写出响应失败: %v\", err)\n")
+	sb.WriteString("\t}\n")
+	file.addFunc(&GoFunc{
+		Doc:    "respondJSON 以状态码 status 写出 JSON 响应",
+		Name:   "respondJSON",
+		Params: "w http.ResponseWriter, status int, body any",
+		Body:   sb.String(),
+	})
+
+	sb.Reset()
+	sb.WriteString("\tstatus, body := framework.HTTPError(err)\n")
+	sb.WriteString("\tif status == http.StatusInternalServerError {\n")
+	sb.WriteString("\t\tlog.Printf(\"%s %s 失败: %v\", r.Method, r.URL.Path, err)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\trespondJSON(w, status, body)\n")
+	file.addFunc(&GoFunc{
+		Doc:    respondErrorDoc,
+		Name:   "respondError",
+		Params: "w http.ResponseWriter, r *http.Request, err error",
+		Body:   sb.String(),
+	})
+}
@@ -0,0 +1,474 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"soliton/pkg/metadata"
+	"strings"
+)
+
+// HTTPHandlerGenerator REST 处理器生成器
+//
+// 为通过 REST 暴露的聚合根生成调用领域服务的 CRUD 接口，按 +soliton:api 的 ops、exclude 生成启用的操作
+// （未声明 +soliton:api 的聚合根生成全部操作）：
+//   - POST   {path}       create：新增，返回 201 和新增的实体
+//   - GET    {path}/{id}  get：按主键查询
+//   - GET    {path}       list：分页查询，查询参数 page、pageSize，返回 framework.PageResponse
+//   - PUT    {path}/{id}  update：更新，请求体中未出现的字段保持原值，主键和 +soliton:immutable 字段不可修改
+//   - DELETE {path}/{id}  delete：删除，返回 204
+//
+// 请求体和响应为 DTOGenerator 生成的 Create{AggregateName}Request、Update{AggregateName}Request 和 {AggregateName}Response，
+// 不直接绑定或返回领域对象；
+// 请求体无法解析、校验失败、实体不存在等错误按 framework.HTTPError 映射为状态码和统一的错误响应。
+// 复合主键的每个字段对应一个路径参数，如 /order-lines/{orderID}/{lineNo}。
+//
+// 生成文件：interfaces/handler/{AggregateName}Handler.go，以及每个限界上下文一份的 response.go（错误响应）
+// 和 router.go（注册全部处理器的路由）；声明了 +soliton:context 的聚合根输出到 interfaces/{context}/handler。
+type HTTPHandlerGenerator struct {
+	fileOutput
+	renderer httpRenderer
+}
+
+// NewHTTPHandlerGenerator 创建 REST 处理器生成器，默认生成 gin 的处理器
+func NewHTTPHandlerGenerator() *HTTPHandlerGenerator {
+	return &HTTPHandlerGenerator{
+		renderer: newHTTPRenderer(HTTPFrameworkGin),
+	}
+}
+
+// SetFramework 设置 Web 框架，见 HTTPFrameworks
+func (g *HTTPHandlerGenerator) SetFramework(framework string) error {
+	if !slices.Contains(HTTPFrameworks, framework) {
+		return fmt.Errorf("不支持的 Web 框架 %s，可选 %s", framework, strings.Join(HTTPFrameworks, "、"))
+	}
+	g.renderer = newHTTPRenderer(framework)
+	return nil
+}
+
+// Generate 为聚合根生成 REST 处理器
+func (g *HTTPHandlerGenerator) Generate(agg *metadata.AggregateMetadata, outputDir string) error {
+	absOutputDir, _ := filepath.Abs(outputDir)
+	handlerDir := filepath.Join(interfacesDir(agg, absOutputDir), "handler")
+	dtoImport := calculateImportPath(agg.ModuleName, agg.ModuleRoot, dtoDir(agg, absOutputDir))
+
+	file, err := g.generateFile(agg, dtoImport)
+	if err != nil {
+		return err
+	}
+
+	filePath := filepath.Join(handlerDir, fmt.Sprintf("%sHandler.go", agg.Name))
+	if err := g.writeTemplate(filePath, "http_handler", file); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return nil
+}
+
+// GenerateRouter 为限界上下文 boundedContext 生成 response.go 和 router.go，aggregates 为全部对外暴露的聚合根
+func (g *HTTPHandlerGenerator) GenerateRouter(aggregates []*metadata.AggregateMetadata, outputDir, boundedContext string) error {
+	var members []*metadata.AggregateMetadata
+	for _, agg := range aggregates {
+		if agg.Context() == boundedContext {
+			members = append(members, agg)
+		}
+	}
+	if len(members) == 0 {
+		return fmt.Errorf("限界上下文 %q 中没有对外暴露的聚合根", boundedContext)
+	}
+
+	absOutputDir, _ := filepath.Abs(outputDir)
+	handlerDir := filepath.Join(interfacesDir(members[0], absOutputDir), "handler")
+	if err := g.writeTemplate(filepath.Join(handlerDir, "response.go"), "http_response", g.generateResponse(members, boundedContext)); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	if err := g.writeTemplate(filepath.Join(handlerDir, "router.go"), "http_router", g.generateRouter(members, boundedContext)); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return nil
+}
+
+// apiOperations 返回聚合根启用的 API 操作，未声明 +soliton:api 时为全部操作
+func apiOperations(agg *metadata.AggregateMetadata) []string {
+	if agg.API == nil {
+		return metadata.APIOps
+	}
+	return agg.API.Operations()
+}
+
+// generateResponse 生成写出错误响应的辅助函数
+func (g *HTTPHandlerGenerator) generateResponse(aggregates []*metadata.AggregateMetadata, boundedContext string) *GoFileData {
+	file := &GoFileData{
+		TemplateData: TemplateData{Aggregates: aggregates, Context: boundedContext},
+		Package:      "handler",
+	}
+	file.addImports("soliton/pkg/framework")
+	g.renderer.responseHelpers(file)
+	return file
+}
+
+// generateRouter 生成注册限界上下文内全部处理器路由的 RegisterRoutes
+func (g *HTTPHandlerGenerator) generateRouter(aggregates []*metadata.AggregateMetadata, boundedContext string) *GoFileData {
+	file := &GoFileData{
+		TemplateData: TemplateData{Aggregates: aggregates, Context: boundedContext},
+		Package:      "handler",
+	}
+	if g.renderer.routerType() != "Router" {
+		file.addImports(g.renderer.importPath())
+	}
+
+	handlers := file.addType("Handlers 各聚合根的 REST 处理器，为 nil 的处理器不注册路由", "Handlers", "struct")
+	var body strings.Builder
+	for _, agg := range aggregates {
+		handlers.Fields = append(handlers.Fields, &GoField{Name: agg.Name, Type: "*" + agg.Name + "Handler"})
+		body.WriteString(fmt.Sprintf("\tif handlers.%s != nil {\n", agg.Name))
+		body.WriteString(fmt.Sprintf("\t\thandlers.%s.RegisterRoutes(router)\n", agg.Name))
+		body.WriteString("\t}\n")
+	}
+
+	file.addFunc(&GoFunc{
+		Doc:    "RegisterRoutes 注册全部处理器的路由",
+		Name:   "RegisterRoutes",
+		Params: fmt.Sprintf("router %s, handlers Handlers", g.renderer.routerType()),
+		Body:   body.String(),
+	})
+	return file
+}
+
+// pathParam 路径中的主键参数
+type pathParam struct {
+	name  string                  // 参数名，如 "id"、"orderID"
+	field *metadata.FieldMetadata // 复合主键的字段，单列主键时为 nil
+}
+
+// keyParams 返回路径中的主键参数：单列主键为 id，复合主键为各主键字段
+func keyParams(agg *metadata.AggregateMetadata) []pathParam {
+	if !agg.IsCompositeKey() {
+		return []pathParam{{name: "id"}}
+	}
+	params := make([]pathParam, len(agg.PrimaryKey))
+	for i, field := range agg.PrimaryKey {
+		params[i] = pathParam{name: jsonName(field.Name), field: field}
+	}
+	return params
+}
+
+// generateFile 生成聚合根的处理器，dtoImport 为请求和响应所在 dto 包的 import 路径
+func (g *HTTPHandlerGenerator) generateFile(agg *metadata.AggregateMetadata, dtoImport string) (*GoFileData, error) {
+	r := g.renderer
+	ops := apiOperations(agg)
+
+	keyFunc, needStrconv, err := g.generateKeyFunc(agg)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &GoFileData{
+		TemplateData: TemplateData{Aggregate: agg, Context: agg.Context()},
+		Package:      "handler",
+	}
+
+	// 导入：新增时的默认值 now()
+	needKey := slices.ContainsFunc(ops, func(op string) bool {
+		return op == metadata.APIOpGet || op == metadata.APIOpUpdate || op == metadata.APIOpDelete
+	})
+	defaults, needTime := defaultAssignments(agg)
+	file.addImports("net/http", agg.ImportPath, dtoImport, "soliton/pkg/framework", r.importPath())
+	if needKey && needStrconv {
+		file.addImports("strconv")
+	}
+	if needTime && slices.Contains(ops, metadata.APIOpCreate) {
+		file.addImports("time")
+	}
+
+	// 处理器
+	handler := agg.Name + "Handler"
+	serviceType := fmt.Sprintf("framework.ServiceOf[*%s.%s, %s]", agg.PackageName, agg.Name, qualifiedKeyType(agg))
+	file.addType(fmt.Sprintf("%s %s 的 REST 处理器", handler, agg.Name), handler, "struct",
+		&GoField{Name: "service", Type: serviceType})
+	file.addFunc(&GoFunc{
+		Doc:     fmt.Sprintf("New%s 创建 %s 的 REST 处理器", handler, agg.Name),
+		Name:    "New" + handler,
+		Params:  "service " + serviceType,
+		Results: "*" + handler,
+		Body:    fmt.Sprintf("\treturn &%s{service: service}\n", handler),
+	})
+
+	// 路由
+	collection := agg.APIPath()
+	item := collection
+	for _, param := range keyParams(agg) {
+		item += "/{" + param.name + "}"
+	}
+	routes := map[string]struct{ method, path, handler string }{
+		metadata.APIOpCreate: {"POST", collection, "Create"},
+		metadata.APIOpGet:    {"GET", item, "Get"},
+		metadata.APIOpList:   {"GET", collection, "List"},
+		metadata.APIOpUpdate: {"PUT", item, "Update"},
+		metadata.APIOpDelete: {"DELETE", item, "Delete"},
+	}
+	doc := fmt.Sprintf("RegisterRoutes 注册 %s 的路由：", agg.Name)
+	var body strings.Builder
+	for _, op := range ops {
+		doc += fmt.Sprintf("\n  - %-6s %s", routes[op].method, routes[op].path)
+		body.WriteString(fmt.Sprintf("\t%s\n", r.route(routes[op].method, routes[op].path, "h."+routes[op].handler)))
+	}
+	file.addFunc(&GoFunc{
+		Doc:      doc,
+		Receiver: "h *" + handler,
+		Name:     "RegisterRoutes",
+		Params:   "router " + r.routerType(),
+		Body:     body.String(),
+	})
+
+	for _, op := range ops {
+		switch op {
+		case metadata.APIOpCreate:
+			file.addFunc(g.generateCreate(agg, defaults))
+		case metadata.APIOpGet:
+			file.addFunc(g.generateGet(agg))
+		case metadata.APIOpList:
+			file.addFunc(g.generateList(agg))
+		case metadata.APIOpUpdate:
+			file.addFunc(g.generateUpdate(agg))
+		case metadata.APIOpDelete:
+			file.addFunc(g.generateDelete(agg))
+		}
+	}
+
+	if needKey {
+		file.addFunc(keyFunc)
+	}
+
+	return file, nil
+}
+
+// handlerFunc 返回处理器的处理函数 name
+func (g *HTTPHandlerGenerator) handlerFunc(agg *metadata.AggregateMetadata, doc, name, body string) *GoFunc {
+	return &GoFunc{
+		Doc:      doc,
+		Receiver: fmt.Sprintf("h *%sHandler", agg.Name),
+		Name:     name,
+		Params:   g.renderer.handlerParams(),
+		Results:  g.renderer.handlerResults(),
+		Body:     body,
+	}
+}
+
+// defaultAssignments 返回新增时按 +soliton:default 初始化字段的语句（与生成的 New{AggregateName} 一致），以及是否用到 time 包
+// 指针字段的默认值先赋给局部变量再取地址
+func defaultAssignments(agg *metadata.AggregateMetadata) ([]string, bool) {
+	var lines []string
+	needTime := false
+	for _, field := range agg.MappedFields() {
+		if agg.InPrimaryKey(field) || field.Annotations.IsEntity || field.Annotations.IsValueObject ||
+			field.IsSlice || field.IsMap || field.IsArray {
+			continue
+		}
+		literal, ok := field.DefaultLiteral()
+		if !ok {
+			continue
+		}
+		if literal == "time.Now()" {
+			needTime = true
+		}
+
+		if field.IsPointer {
+			local := "default" + field.Name
+			lines = append(lines, fmt.Sprintf("%s := %s", local, typedLiteral(literal, qualifyType(field.Type, agg.PackageName))))
+			literal = "&" + local
+		}
+		lines = append(lines, fmt.Sprintf("entity.%s = %s", field.Name, literal))
+	}
+	return lines, needTime
+}
+
+// writeStatements 以缩进 indent 写出语句
+func writeStatements(sb *strings.Builder, indent string, statements []string) {
+	for _, statement := range statements {
+		sb.WriteString(indent + statement + "\n")
+	}
+}
+
+// generateKeyFunc 生成从路径参数解析主键的 key 方法，以及是否用到 strconv
+func (g *HTTPHandlerGenerator) generateKeyFunc(agg *metadata.AggregateMetadata) (*GoFunc, bool, error) {
+	var sb strings.Builder
+	r := g.renderer
+	keyType := qualifiedKeyType(agg)
+	needStrconv := false
+	keyFunc := &GoFunc{
+		Doc:      "key 解析路径中的主键",
+		Receiver: fmt.Sprintf("h *%sHandler", agg.Name),
+		Name:     "key",
+		Params:   r.requestParam(),
+		Results:  fmt.Sprintf("(%s, error)", keyType),
+	}
+
+	if !agg.IsCompositeKey() {
+		if keyType == "string" {
+			keyFunc.Body = fmt.Sprintf("\treturn %s, nil\n", r.pathValue("id"))
+			return keyFunc, false, nil
+		}
+		parse, _ := parsePathValue("int64", r.pathValue("id"))
+		sb.WriteString(fmt.Sprintf("\tid, err := %s\n", parse))
+		sb.WriteString("\tif err != nil {\n")
+		sb.WriteString(fmt.Sprintf("\t\treturn 0, framework.NewBadRequestError(\"id\", \"id 无效: %%q\", %s)\n", r.pathValue("id")))
+		sb.WriteString("\t}\n")
+		sb.WriteString("\treturn id, nil\n")
+		keyFunc.Body = sb.String()
+		return keyFunc, true, nil
+	}
+
+	sb.WriteString(fmt.Sprintf("\tvar key %s\n", keyType))
+	for _, param := range keyParams(agg) {
+		field := param.field
+		value := r.pathValue(param.name)
+		if field.BasicType() == "string" {
+			if field.Type == "string" {
+				sb.WriteString(fmt.Sprintf("\tkey.%s = %s\n", field.Name, value))
+			} else {
+				sb.WriteString(fmt.Sprintf("\tkey.%s = %s(%s)\n", field.Name, qualifyType(field.Type, agg.PackageName), value))
+			}
+			continue
+		}
+
+		parse, parsedType := parsePathValue(field.BasicType(), value)
+		if parse == "" {
+			return nil, false, fmt.Errorf("聚合根 %s 的主键字段 %s 的类型 %s 不能从路径参数解析", agg.Name, field.Name, field.Type)
+		}
+		needStrconv = true
+		local := param.name
+		if slices.Contains([]string{"key", "err", "h", "c", "r"}, local) {
+			local += "Value"
+		}
+		sb.WriteString(fmt.Sprintf("\t%s, err := %s\n", local, parse))
+		sb.WriteString("\tif err != nil {\n")
+		sb.WriteString(fmt.Sprintf("\t\treturn key, framework.NewBadRequestError(%q, \"%s 无效: %%q\", %s)\n", param.name, param.name, value))
+		sb.WriteString("\t}\n")
+		if field.Type == parsedType {
+			sb.WriteString(fmt.Sprintf("\tkey.%s = %s\n", field.Name, local))
+		} else {
+			sb.WriteString(fmt.Sprintf("\tkey.%s = %s(%s)\n", field.Name, qualifyType(field.Type, agg.PackageName), local))
+		}
+	}
+	sb.WriteString("\treturn key, nil\n")
+	keyFunc.Body = sb.String()
+
+	return keyFunc, needStrconv, nil
+}
+
+// parsePathValue 返回将路径参数 value 解析为整数类型 basicType 的表达式（结果为值和 error）及解析结果的类型，
+// 不支持的类型返回空
+func parsePathValue(basicType, value string) (string, string) {
+	bits := strings.TrimLeft(basicType, "uint")
+	if bits == "" {
+		bits = "0"
+	}
+	switch basicType {
+	case "int":
+		return fmt.Sprintf("strconv.Atoi(%s)", value), "int"
+	case "int8", "int16", "int32", "int64":
+		return fmt.Sprintf("strconv.ParseInt(%s, 10, %s)", value, bits), "int64"
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		return fmt.Sprintf("strconv.ParseUint(%s, 10, %s)", value, bits), "uint64"
+	}
+	return "", ""
+}
+
+// generateCreate 生成新增处理函数
+func (g *HTTPHandlerGenerator) generateCreate(agg *metadata.AggregateMetadata, defaults []string) *GoFunc {
+	var sb strings.Builder
+	r := g.renderer
+
+	sb.WriteString(fmt.Sprintf("\tentity := &%s.%s{}\n", agg.PackageName, agg.Name))
+	writeStatements(&sb, "\t", defaults)
+	sb.WriteString(fmt.Sprintf("\trequest := dto.NewCreate%sRequest(entity)\n", agg.Name))
+	sb.WriteString(fmt.Sprintf("\tif err := %s; err != nil {\n", r.bindJSON("&request")))
+	writeStatements(&sb, "\t\t", r.fail("framework.NewBadRequestError(\"\", \"请求体无效: %v\", err)"))
+	sb.WriteString("\t}\n")
+	sb.WriteString("\trequest.ApplyTo(entity)\n\n")
+	sb.WriteString(fmt.Sprintf("\tif err := h.service.Add(%s, entity); err != nil {\n", r.context()))
+	writeStatements(&sb, "\t\t", r.fail("err"))
+	sb.WriteString("\t}\n")
+	writeStatements(&sb, "\t", r.respond("http.StatusCreated", fmt.Sprintf("dto.New%sResponse(entity)", agg.Name)))
+
+	return g.handlerFunc(agg, fmt.Sprintf("Create 新增 %s，请求体中未出现的字段取 +soliton:default 声明的默认值", agg.Name), "Create", sb.String())
+}
+
+// generateGet 生成按主键查询的处理函数
+func (g *HTTPHandlerGenerator) generateGet(agg *metadata.AggregateMetadata) *GoFunc {
+	var sb strings.Builder
+	r := g.renderer
+
+	g.writeLoad(&sb, agg)
+	writeStatements(&sb, "\t", r.respond("http.StatusOK", fmt.Sprintf("dto.New%sResponse(entity)", agg.Name)))
+
+	return g.handlerFunc(agg, fmt.Sprintf("Get 按主键查询 %s", agg.Name), "Get", sb.String())
+}
+
+// generateList 生成分页查询的处理函数
+func (g *HTTPHandlerGenerator) generateList(agg *metadata.AggregateMetadata) *GoFunc {
+	var sb strings.Builder
+	r := g.renderer
+
+	sb.WriteString(fmt.Sprintf("\tpage, pageSize, err := framework.ParsePage(%s, %s)\n", r.queryValue("page"), r.queryValue("pageSize")))
+	sb.WriteString("\tif err != nil {\n")
+	writeStatements(&sb, "\t\t", r.fail("err"))
+	sb.WriteString("\t}\n")
+	sb.WriteString(fmt.Sprintf("\tentities, total, err := h.service.GetPage(%s, page, pageSize)\n", r.context()))
+	sb.WriteString("\tif err != nil {\n")
+	writeStatements(&sb, "\t\t", r.fail("err"))
+	sb.WriteString("\t}\n\n")
+	writeStatements(&sb, "\t", r.respond("http.StatusOK", fmt.Sprintf(
+		"framework.PageResponse[dto.%sResponse]{Items: dto.New%sResponses(entities), Total: total, Page: page, PageSize: pageSize}", agg.Name, agg.Name)))
+
+	return g.handlerFunc(agg, fmt.Sprintf("List 分页查询 %s，查询参数 page 从 1 开始，pageSize 默认为 framework.DefaultPageSize", agg.Name), "List", sb.String())
+}
+
+// generateUpdate 生成更新的处理函数
+func (g *HTTPHandlerGenerator) generateUpdate(agg *metadata.AggregateMetadata) *GoFunc {
+	var sb strings.Builder
+	r := g.renderer
+
+	g.writeLoad(&sb, agg)
+	sb.WriteString(fmt.Sprintf("\trequest := dto.NewUpdate%sRequest(entity)\n", agg.Name))
+	sb.WriteString(fmt.Sprintf("\tif err := %s; err != nil {\n", r.bindJSON("&request")))
+	writeStatements(&sb, "\t\t", r.fail("framework.NewBadRequestError(\"\", \"请求体无效: %v\", err)"))
+	sb.WriteString("\t}\n")
+	sb.WriteString("\trequest.ApplyTo(entity)\n\n")
+	sb.WriteString(fmt.Sprintf("\tif err := h.service.Update(%s, entity); err != nil {\n", r.context()))
+	writeStatements(&sb, "\t\t", r.fail("err"))
+	sb.WriteString("\t}\n")
+	writeStatements(&sb, "\t", r.respond("http.StatusOK", fmt.Sprintf("dto.New%sResponse(entity)", agg.Name)))
+
+	return g.handlerFunc(agg, fmt.Sprintf("Update 更新 %s，请求体中未出现的字段保持原值", agg.Name), "Update", sb.String())
+}
+
+// generateDelete 生成删除的处理函数
+func (g *HTTPHandlerGenerator) generateDelete(agg *metadata.AggregateMetadata) *GoFunc {
+	var sb strings.Builder
+	r := g.renderer
+
+	sb.WriteString(fmt.Sprintf("\tid, err := h.key(%s)\n", r.requestArg()))
+	sb.WriteString("\tif err != nil {\n")
+	writeStatements(&sb, "\t\t", r.fail("err"))
+	sb.WriteString("\t}\n")
+	sb.WriteString(fmt.Sprintf("\tif err := h.service.Delete(%s, id); err != nil {\n", r.context()))
+	writeStatements(&sb, "\t\t", r.fail("err"))
+	sb.WriteString("\t}\n")
+	writeStatements(&sb, "\t", r.noContent())
+
+	return g.handlerFunc(agg, fmt.Sprintf("Delete 删除 %s", agg.Name), "Delete", sb.String())
+}
+
+// writeLoad 写出解析路径中的主键并查询实体的语句，查询结果为 entity
+func (g *HTTPHandlerGenerator) writeLoad(sb *strings.Builder, agg *metadata.AggregateMetadata) {
+	r := g.renderer
+	sb.WriteString(fmt.Sprintf("\tid, err := h.key(%s)\n", r.requestArg()))
+	sb.WriteString("\tif err != nil {\n")
+	writeStatements(sb, "\t\t", r.fail("err"))
+	sb.WriteString("\t}\n")
+	sb.WriteString(fmt.Sprintf("\tentity, err := h.service.GetByID(%s, id)\n", r.context()))
+	sb.WriteString("\tif err != nil {\n")
+	writeStatements(sb, "\t\t", r.fail("err"))
+	sb.WriteString("\t}\n")
+}
@@ -0,0 +1,211 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"soliton/pkg/metadata"
+	"sort"
+	"strings"
+)
+
+// IncrementalStateFile 增量生成状态文件相对工程根目录的路径
+const IncrementalStateFile = ".soliton/state.json"
+
+// incrementalStateVersion 增量生成状态文件的格式版本，版本不同时视为没有记录
+const incrementalStateVersion = 1
+
+// incrementalState 增量生成状态文件的内容
+type incrementalState struct {
+	Version     int               `json:"version"`     // 格式版本，见 incrementalStateVersion
+	Fingerprint string            `json:"fingerprint"` // 生成时的全局摘要
+	Aggregates  map[string]string `json:"aggregates"`  // 聚合根名 -> 输入摘要
+}
+
+// Incremental 增量生成状态
+//
+// 为每个聚合根计算输入摘要，与上次成功生成时记录的摘要相同的聚合根无需重新生成逐聚合根的文件。
+// 聚合根的输入包括：聚合根元数据、所在模型文件（不含追加的 Entity 方法）、涉及它的关系和多对多关联表、
+// 关系另一端的聚合根、使用的枚举和发布的领域事件。
+// fingerprint 概括影响全部聚合根的输入（soliton 版本、生成参数、覆盖的模板），与记录不同时全部聚合根视为变化。
+type Incremental struct {
+	path        string
+	fingerprint string
+	registry    *metadata.AggregateMetadataRegistry
+	previous    map[string]string // 上次记录的摘要，全局摘要不同时为空
+	digests     map[string]string // 本次计算的摘要
+}
+
+// LoadIncremental 读取工程根目录 root 下的增量生成状态，状态文件不存在时所有聚合根视为变化
+func LoadIncremental(root, fingerprint string, registry *metadata.AggregateMetadataRegistry) (*Incremental, error) {
+	inc := &Incremental{
+		path:        filepath.Join(root, filepath.FromSlash(IncrementalStateFile)),
+		fingerprint: fingerprint,
+		registry:    registry,
+		previous:    make(map[string]string),
+		digests:     make(map[string]string),
+	}
+
+	data, err := os.ReadFile(inc.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return inc, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取增量生成状态失败: %w", err)
+	}
+	var state incrementalState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("解析增量生成状态 %s 失败: %w", inc.path, err)
+	}
+	if state.Version == incrementalStateVersion && state.Fingerprint == fingerprint {
+		for name, digest := range state.Aggregates {
+			inc.previous[name] = digest
+		}
+	}
+	return inc, nil
+}
+
+// Path 返回状态文件路径
+func (i *Incremental) Path() string {
+	return i.path
+}
+
+// Changed 判断聚合根的输入自上次成功生成以来是否变化，没有记录的聚合根视为变化
+func (i *Incremental) Changed(agg *metadata.AggregateMetadata) (bool, error) {
+	digest, err := i.digest(agg)
+	if err != nil {
+		return false, err
+	}
+	return i.previous[agg.Name] != digest, nil
+}
+
+// Save 写出状态：generated 中的聚合根记录本次的摘要，其他聚合根保留上次的记录，已删除的聚合根不再记录
+func (i *Incremental) Save(generated []*metadata.AggregateMetadata) error {
+	state := incrementalState{
+		Version:     incrementalStateVersion,
+		Fingerprint: i.fingerprint,
+		Aggregates:  make(map[string]string),
+	}
+	for _, agg := range i.registry.GetAll() {
+		if digest, ok := i.previous[agg.Name]; ok {
+			state.Aggregates[agg.Name] = digest
+		}
+	}
+	for _, agg := range generated {
+		digest, err := i.digest(agg)
+		if err != nil {
+			return err
+		}
+		state.Aggregates[agg.Name] = digest
+	}
+
+	data, err := json.MarshalIndent(&state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化增量生成状态失败: %w", err)
+	}
+	if err := (DiskWriter{}).WriteFile(i.path, append(data, '\n')); err != nil {
+		return fmt.Errorf("写入增量生成状态失败: %w", err)
+	}
+	return nil
+}
+
+// digest 计算聚合根的输入摘要，同一次运行中只计算一次
+func (i *Incremental) digest(agg *metadata.AggregateMetadata) (string, error) {
+	if digest, ok := i.digests[agg.Name]; ok {
+		return digest, nil
+	}
+
+	source, err := os.ReadFile(agg.FilePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("读取聚合根 %s 的模型文件失败: %w", agg.Name, err)
+	}
+
+	relations := append(i.registry.GetRelationsByAggregate(agg.Name), i.registry.GetRelationsByTarget(agg.Name)...)
+	relatedNames := make(map[string]bool)
+	for _, rel := range relations {
+		relatedNames[rel.SourceAggregate] = true
+		relatedNames[rel.TargetAggregate] = true
+	}
+	delete(relatedNames, agg.Name)
+
+	var tables []*metadata.ManyToManyTableMetadata
+	for _, table := range i.registry.GetManyToManyTables() {
+		if table.LeftAggregate == agg.Name || table.RightAggregate == agg.Name {
+			tables = append(tables, table)
+			relatedNames[table.LeftAggregate] = true
+			relatedNames[table.RightAggregate] = true
+		}
+	}
+	delete(relatedNames, agg.Name)
+
+	names := make([]string, 0, len(relatedNames))
+	for name := range relatedNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var related []*metadata.AggregateMetadata
+	for _, name := range names {
+		// 外部聚合根（-external）不在注册表中
+		if other := i.registry.Get(name); other != nil {
+			related = append(related, other)
+		}
+	}
+
+	fieldTypes := make(map[string]bool)
+	for _, field := range agg.Fields {
+		fieldTypes[baseTypeName(field.Type)] = true
+	}
+	var enums []*metadata.EnumMetadata
+	for _, enum := range i.registry.GetEnums() {
+		if enum.AggregateName == agg.Name || fieldTypes[enum.Name] {
+			enums = append(enums, enum)
+		}
+	}
+
+	input, err := json.Marshal(struct {
+		Aggregate        *metadata.AggregateMetadata         `json:"aggregate"`
+		Source           string                              `json:"source"`
+		Relations        []*metadata.RelationMetadata        `json:"relations"`
+		Related          []*metadata.AggregateMetadata       `json:"related"`
+		ManyToManyTables []*metadata.ManyToManyTableMetadata `json:"manyToManyTables"`
+		Enums            []*metadata.EnumMetadata            `json:"enums"`
+		Events           []*metadata.EventMetadata           `json:"events"`
+	}{
+		Aggregate:        agg,
+		Source:           stripGeneratedCode(string(source)),
+		Relations:        relations,
+		Related:          related,
+		ManyToManyTables: tables,
+		Enums:            enums,
+		Events:           i.registry.GetEventsByAggregate(agg.Name),
+	})
+	if err != nil {
+		return "", fmt.Errorf("序列化聚合根 %s 失败: %w", agg.Name, err)
+	}
+
+	sum := sha256.Sum256(input)
+	digest := hex.EncodeToString(sum[:])
+	i.digests[agg.Name] = digest
+	return digest, nil
+}
+
+// stripGeneratedCode 去掉模型文件中追加的 Entity 方法，生成器重写的部分不影响摘要
+func stripGeneratedCode(content string) string {
+	if idx := strings.Index(content, generatedMarker); idx != -1 {
+		content = content[:idx]
+	}
+	return strings.TrimRight(content, "\n")
+}
+
+// baseTypeName 返回去掉指针、切片和包名的类型名，如 []*model.OrderStatus -> OrderStatus
+func baseTypeName(typ string) string {
+	typ = strings.TrimLeft(typ, "*[]")
+	if idx := strings.LastIndex(typ, "."); idx != -1 {
+		typ = typ[idx+1:]
+	}
+	return typ
+}
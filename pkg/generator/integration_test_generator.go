@@ -0,0 +1,525 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"soliton/pkg/metadata"
+	"strings"
+)
+
+// integrationTestKey 集成测试中敏感字段使用的 AES-256 密钥
+const integrationTestKey = "soliton-integration-test-aes-key"
+
+// IntegrationTestGenerator 仓储集成测试生成器
+//
+// 为每个聚合根的仓储实现生成带 integration 构建标签的集成测试，在执行了生成的迁移的数据库中验证：
+//   - Add、FindByID、Exists 和硬删除 Delete
+//   - Update 写入修改的字段；有版本号（Version）时校验版本号与数据库一致，并用过期的版本号更新得到 ErrVersionConflict
+//   - FindPage 的分页结果和总数
+//   - 有软删除字段（DeletedAt）时的 Remove、FindByIDWithDeleted、FindAllDeleted 和 Restore
+//
+// 数据库按 -dialect 选择：mysql、postgres 由 testcontainers 启动容器，sqlite 使用临时文件。
+// 每个限界上下文的仓储包由 TestMain 创建一次数据库，用 golang-migrate 执行 {context}/sql/migrations 中的迁移；
+// 测试数据不构造被引用的聚合根，连接时关闭外键检查。唯一约束中的字段按序号取不同的值，只支持字符串和整数类型。
+//
+// 运行：go test -tags integration ./infrastructure/...
+//
+// 生成文件：infrastructure/repository/main_integration_test.go、infrastructure/repository/{AggregateName}RepositoryImpl_integration_test.go
+type IntegrationTestGenerator struct {
+	fileOutput
+	dialect  string
+	registry *metadata.AggregateMetadataRegistry
+}
+
+// NewIntegrationTestGenerator 创建仓储集成测试生成器，默认使用 MySQL
+func NewIntegrationTestGenerator() *IntegrationTestGenerator {
+	return &IntegrationTestGenerator{dialect: metadata.DialectMySQL}
+}
+
+// SetDialect 设置测试数据库的方言，与建表脚本和迁移的方言一致
+func (g *IntegrationTestGenerator) SetDialect(dialect string) {
+	g.dialect = dialect
+}
+
+// SetRegistry 设置聚合根注册表，用于读取一对一关系外键列上的唯一约束
+func (g *IntegrationTestGenerator) SetRegistry(registry *metadata.AggregateMetadataRegistry) {
+	g.registry = registry
+}
+
+// Generate 为聚合根生成仓储集成测试
+func (g *IntegrationTestGenerator) Generate(agg *metadata.AggregateMetadata, outputDir string) error {
+	absOutputDir, _ := filepath.Abs(outputDir)
+	filePath := filepath.Join(infrastructureDir(agg, absOutputDir), "repository", agg.Name+"RepositoryImpl_integration_test.go")
+
+	if err := g.writeTemplate(filePath, "integration_test", g.generateTests(agg, absOutputDir)); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return nil
+}
+
+// GenerateContext 为限界上下文的仓储包生成创建测试数据库的 TestMain
+func (g *IntegrationTestGenerator) GenerateContext(aggregates []*metadata.AggregateMetadata, outputDir, boundedContext string) error {
+	members := contextMembers(aggregates, boundedContext)
+	if len(members) == 0 {
+		return nil
+	}
+	absOutputDir, _ := filepath.Abs(outputDir)
+	repositoryDir := filepath.Join(infrastructureDir(members[0], absOutputDir), "repository")
+	migrationsDir, err := filepath.Rel(repositoryDir, filepath.Join(domainDir(members[0], absOutputDir), "sql", "migrations"))
+	if err != nil {
+		return fmt.Errorf("计算迁移目录失败: %w", err)
+	}
+
+	needPtr := slices.ContainsFunc(members, func(agg *metadata.AggregateMetadata) bool {
+		return slices.ContainsFunc(g.testData(agg), func(assignment [2]string) bool {
+			return strings.HasPrefix(assignment[1], "ptr(")
+		})
+	})
+	file := g.generateMain(filepath.ToSlash(migrationsDir), needPtr)
+	file.Aggregates, file.Context = members, boundedContext
+	if err := g.writeTemplate(filepath.Join(repositoryDir, "main_integration_test.go"), "integration_main", file); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return nil
+}
+
+// generateMain 生成 main_integration_test.go：启动数据库、执行迁移并打开 testDB
+func (g *IntegrationTestGenerator) generateMain(migrationsDir string, needPtr bool) *GoFileData {
+	file := &GoFileData{Package: "repository"}
+	file.addImports("errors", "fmt", "os", "path/filepath", "testing", "gorm.io/gorm")
+	file.addImport("migrate", "github.com/golang-migrate/migrate/v4")
+	file.addImport("_", "github.com/golang-migrate/migrate/v4/source/file")
+
+	file.addValues(declConst, "migrationsDir 限界上下文的迁移目录，相对于本包", &GoField{Name: "migrationsDir", Value: fmt.Sprintf("%q", migrationsDir)})
+	file.addValues(declVar, "testDB 集成测试使用的数据库连接，由 TestMain 在执行迁移后打开", &GoField{Name: "testDB", Type: "*gorm.DB"})
+	file.addFunc(&GoFunc{Name: "TestMain", Params: "m *testing.M", Body: "\tos.Exit(runIntegration(m))\n"})
+
+	var body strings.Builder
+	run := &GoFunc{Name: "runIntegration", Params: "m *testing.M", Results: "int"}
+	switch g.dialect {
+	case metadata.DialectPostgres:
+		file.addImports("context", "gorm.io/driver/postgres")
+		file.addImport("_", "github.com/golang-migrate/migrate/v4/database/postgres")
+		file.addImport("tcpostgres", "github.com/testcontainers/testcontainers-go/modules/postgres")
+		run.Doc = "runIntegration 启动 PostgreSQL 容器、执行迁移后运行测试，返回退出码"
+		body.WriteString("\tctx := context.Background()\n")
+		body.WriteString("\tcontainer, err := tcpostgres.Run(ctx, \"postgres:16-alpine\",\n")
+		body.WriteString("\t\ttcpostgres.WithDatabase(\"soliton\"),\n")
+		body.WriteString("\t\ttcpostgres.WithUsername(\"soliton\"),\n")
+		body.WriteString("\t\ttcpostgres.WithPassword(\"soliton\"),\n")
+		body.WriteString("\t\ttcpostgres.BasicWaitStrategies(),\n")
+		body.WriteString("\t)\n")
+		body.WriteString(fatalIf("启动 PostgreSQL 容器失败"))
+		body.WriteString("\tdefer container.Terminate(ctx)\n\n")
+		body.WriteString("\tdsn, err := container.ConnectionString(ctx, \"sslmode=disable\")\n")
+		body.WriteString(fatalIf("获取连接串失败"))
+		body.WriteString("\tif err := migrateUp(dsn); err != nil {\n")
+		body.WriteString("\t\tfmt.Fprintln(os.Stderr, err)\n")
+		body.WriteString("\t\treturn 1\n")
+		body.WriteString("\t}\n")
+		body.WriteString("\t// 测试数据不构造被引用的聚合根，以复制模式连接以跳过外键检查\n")
+		body.WriteString("\ttestDB, err = gorm.Open(postgres.Open(dsn + \"&session_replication_role=replica\"))\n")
+	case metadata.DialectSQLite:
+		file.addImports("gorm.io/driver/sqlite")
+		file.addImport("_", "github.com/golang-migrate/migrate/v4/database/sqlite3")
+		run.Doc = "runIntegration 在临时目录中创建 SQLite 数据库、执行迁移后运行测试，返回退出码"
+		body.WriteString("\tdir, err := os.MkdirTemp(\"\", \"soliton-integration\")\n")
+		body.WriteString(fatalIf("创建临时目录失败"))
+		body.WriteString("\tdefer os.RemoveAll(dir)\n\n")
+		body.WriteString("\tpath := filepath.Join(dir, \"test.db\")\n")
+		body.WriteString("\tif err := migrateUp(\"sqlite3://\" + filepath.ToSlash(path)); err != nil {\n")
+		body.WriteString("\t\tfmt.Fprintln(os.Stderr, err)\n")
+		body.WriteString("\t\treturn 1\n")
+		body.WriteString("\t}\n")
+		body.WriteString("\t// SQLite 默认不检查外键\n")
+		body.WriteString("\ttestDB, err = gorm.Open(sqlite.Open(path))\n")
+	default:
+		file.addImports("context", "gorm.io/driver/mysql")
+		file.addImport("_", "github.com/golang-migrate/migrate/v4/database/mysql")
+		file.addImport("tcmysql", "github.com/testcontainers/testcontainers-go/modules/mysql")
+		run.Doc = "runIntegration 启动 MySQL 容器、执行迁移后运行测试，返回退出码"
+		body.WriteString("\tctx := context.Background()\n")
+		body.WriteString("\tcontainer, err := tcmysql.Run(ctx, \"mysql:8.0\",\n")
+		body.WriteString("\t\ttcmysql.WithDatabase(\"soliton\"),\n")
+		body.WriteString("\t\ttcmysql.WithUsername(\"soliton\"),\n")
+		body.WriteString("\t\ttcmysql.WithPassword(\"soliton\"),\n")
+		body.WriteString("\t)\n")
+		body.WriteString(fatalIf("启动 MySQL 容器失败"))
+		body.WriteString("\tdefer container.Terminate(ctx)\n\n")
+		body.WriteString("\t// multiStatements：迁移文件包含多条语句；clientFoundRows：更新的值不变时仍计入匹配的行；\n")
+		body.WriteString("\t// foreign_key_checks=0：测试数据不构造被引用的聚合根\n")
+		body.WriteString("\tdsn, err := container.ConnectionString(ctx, \"multiStatements=true\", \"parseTime=true\", \"clientFoundRows=true\", \"foreign_key_checks=0\")\n")
+		body.WriteString(fatalIf("获取连接串失败"))
+		body.WriteString("\tif err := migrateUp(\"mysql://\" + dsn); err != nil {\n")
+		body.WriteString("\t\tfmt.Fprintln(os.Stderr, err)\n")
+		body.WriteString("\t\treturn 1\n")
+		body.WriteString("\t}\n")
+		body.WriteString("\ttestDB, err = gorm.Open(mysql.Open(dsn))\n")
+	}
+	body.WriteString(fatalIf("连接数据库失败"))
+	body.WriteString("\treturn m.Run()\n")
+	run.Body = body.String()
+	file.addFunc(run)
+
+	body.Reset()
+	body.WriteString("\tdir, err := filepath.Abs(migrationsDir)\n")
+	body.WriteString("\tif err != nil {\n")
+	body.WriteString("\t\treturn fmt.Errorf(\"解析迁移目录失败: %w\", err)\n")
+	body.WriteString("\t}\n")
+	body.WriteString("\tmigration, err := migrate.New(\"file://\"+filepath.ToSlash(dir), databaseURL)\n")
+	body.WriteString("\tif err != nil {\n")
+	body.WriteString("\t\treturn fmt.Errorf(\"创建迁移失败: %w\", err)\n")
+	body.WriteString("\t}\n")
+	body.WriteString("\tdefer migration.Close()\n\n")
+	body.WriteString("\tif err := migration.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {\n")
+	body.WriteString("\t\treturn fmt.Errorf(\"执行迁移失败: %w\", err)\n")
+	body.WriteString("\t}\n")
+	body.WriteString("\treturn nil\n")
+	file.addFunc(&GoFunc{
+		Doc:     "migrateUp 执行迁移目录中的全部迁移",
+		Name:    "migrateUp",
+		Params:  "databaseURL string",
+		Results: "error",
+		Body:    body.String(),
+	})
+
+	if needPtr {
+		file.addFunc(&GoFunc{
+			Doc:     "ptr 返回值 v 的指针，用于为指针字段赋值",
+			Name:    "ptr[T any]",
+			Params:  "v T",
+			Results: "*T",
+			Body:    "\treturn &v\n",
+		})
+	}
+	return file
+}
+
+// fatalIf 生成 runIntegration 中 err 不为 nil 时输出 message 并返回退出码 1 的语句
+func fatalIf(message string) string {
+	return fmt.Sprintf("\tif err != nil {\n\t\tfmt.Fprintf(os.Stderr, \"%s: %%v\\n\", err)\n\t\treturn 1\n\t}\n", message)
+}
+
+// generateTests 生成聚合根仓储的集成测试
+func (g *IntegrationTestGenerator) generateTests(agg *metadata.AggregateMetadata, absOutputDir string) *GoFileData {
+	infraDir := infrastructureDir(agg, absOutputDir)
+	file := &GoFileData{
+		TemplateData: TemplateData{Aggregate: agg, Context: agg.Context()},
+		Package:      "repository",
+	}
+	file.addImports("context", "errors", "testing", agg.ImportPath,
+		calculateImportPath(agg.ModuleName, agg.ModuleRoot, filepath.Join(infraDir, "do")), "soliton/pkg/framework", "gorm.io/gorm")
+	name := agg.Name
+	entityType := fmt.Sprintf("*%s.%s", agg.PackageName, name)
+
+	var body strings.Builder
+
+	// 准备仓储：清空表，有敏感字段时设置编解码器
+	body.WriteString("\tt.Helper()\n")
+	body.WriteString(fmt.Sprintf("\tif err := testDB.Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped().Delete(&do.%sDO{}).Error; err != nil {\n", name))
+	body.WriteString(fmt.Sprintf("\t\tt.Fatalf(\"清空 %s 失败: %%v\", err)\n", agg.Table()))
+	body.WriteString("\t}\n")
+	body.WriteString(fmt.Sprintf("\trepo := New%sRepository(testDB)\n", name))
+	if hasSensitiveFields(agg) {
+		body.WriteString(fmt.Sprintf("\tcodec, err := framework.NewAESCodec([]byte(%q))\n", integrationTestKey))
+		body.WriteString("\tif err != nil {\n")
+		body.WriteString("\t\tt.Fatalf(\"创建编解码器失败: %v\", err)\n")
+		body.WriteString("\t}\n")
+		body.WriteString("\trepo.SetEncryptionCodec(codec)\n")
+	}
+	body.WriteString("\treturn repo\n")
+	file.addFunc(&GoFunc{
+		Doc:     fmt.Sprintf("setup%sRepository 清空 %s 表并返回连接测试数据库的 %s 仓储", name, agg.Table(), name),
+		Name:    fmt.Sprintf("setup%sRepository", name),
+		Params:  "t *testing.T",
+		Results: fmt.Sprintf("*%sRepositoryImpl", name),
+		Body:    body.String(),
+	})
+
+	// 测试数据
+	body.Reset()
+	assignments := g.testData(agg)
+	body.WriteString(fmt.Sprintf("\te := &%s.%s{}\n", agg.PackageName, name))
+	for _, assignment := range assignments {
+		body.WriteString(fmt.Sprintf("\te.%s = %s\n", assignment[0], assignment[1]))
+		if strings.Contains(assignment[1], "fmt.Sprintf") {
+			file.addImports("fmt")
+		}
+		if strings.Contains(assignment[1], "time.Now()") {
+			file.addImports("time")
+		}
+	}
+	body.WriteString("\treturn e\n")
+	file.addFunc(&GoFunc{
+		Doc:     fmt.Sprintf("new%s 返回第 n 个测试用的 %s，主键和唯一约束中的字段按 n 取不同的值", name, name),
+		Name:    "new" + name,
+		Params:  "n int",
+		Results: entityType,
+		Body:    body.String(),
+	})
+
+	// 新增和查询
+	body.Reset()
+	body.WriteString(fmt.Sprintf("\tentity := new%s(1)\n", name))
+	body.WriteString(checkErr("repo.Add(ctx, entity)", "Add"))
+	body.WriteString("\tfound, err := repo.FindByID(ctx, entity.GetID())\n")
+	body.WriteString(fatalErr("FindByID"))
+	body.WriteString("\tif found.GetID() != entity.GetID() {\n")
+	body.WriteString("\t\tt.Errorf(\"FindByID 返回 %v，期望 %v\", found.GetID(), entity.GetID())\n")
+	body.WriteString("\t}\n")
+	body.WriteString("\texists, err := repo.Exists(ctx, entity.GetID())\n")
+	body.WriteString(fatalErr("Exists"))
+	body.WriteString("\tif !exists {\n")
+	body.WriteString("\t\tt.Error(\"Exists 返回 false，期望 true\")\n")
+	body.WriteString("\t}\n")
+	file.addFunc(testFunc(name, "AddAndFind", body.String()))
+
+	// 更新
+	update := g.updateField(agg)
+	version := ""
+	if agg.BaseEntity != nil && agg.BaseEntity.HasVersion && agg.BaseEntity.VersionField != nil {
+		version = agg.BaseEntity.VersionField.Name
+	}
+	body.Reset()
+	body.WriteString(fmt.Sprintf("\tentity := new%s(1)\n", name))
+	body.WriteString(checkErr("repo.Add(ctx, entity)", "Add"))
+	if update != nil {
+		body.WriteString(fmt.Sprintf("\tentity.%s = \"updated\"\n", update.Name))
+	}
+	body.WriteString(checkErr("repo.Update(ctx, entity)", "Update"))
+	if update == nil && version == "" {
+		body.WriteString("\tif _, err := repo.FindByID(ctx, entity.GetID()); err != nil {\n")
+		body.WriteString("\t\tt.Fatalf(\"FindByID 失败: %v\", err)\n")
+		body.WriteString("\t}\n")
+	} else {
+		body.WriteString("\tfound, err := repo.FindByID(ctx, entity.GetID())\n")
+		body.WriteString(fatalErr("FindByID"))
+	}
+	if update != nil {
+		body.WriteString(fmt.Sprintf("\tif found.%s != \"updated\" {\n", update.Name))
+		body.WriteString(fmt.Sprintf("\t\tt.Errorf(\"%s 为 %%q，期望 %%q\", found.%s, \"updated\")\n", update.Name, update.Name))
+		body.WriteString("\t}\n")
+	}
+	if version != "" {
+		body.WriteString(fmt.Sprintf("\tif found.%s != entity.%s {\n", version, version))
+		body.WriteString(fmt.Sprintf("\t\tt.Errorf(\"%s 为 %%d，期望 %%d\", found.%s, entity.%s)\n", version, version, version))
+		body.WriteString("\t}\n")
+	}
+	file.addFunc(testFunc(name, "Update", body.String()))
+
+	// 硬删除
+	body.Reset()
+	body.WriteString(fmt.Sprintf("\tentity := new%s(1)\n", name))
+	body.WriteString(checkErr("repo.Add(ctx, entity)", "Add"))
+	body.WriteString(checkErr("repo.Delete(ctx, entity.GetID())", "Delete"))
+	body.WriteString("\tif _, err := repo.FindByID(ctx, entity.GetID()); !errors.Is(err, framework.ErrRecordNotFound) {\n")
+	body.WriteString("\t\tt.Errorf(\"删除后 FindByID 返回 %v，期望 ErrRecordNotFound\", err)\n")
+	body.WriteString("\t}\n")
+	body.WriteString("\texists, err := repo.Exists(ctx, entity.GetID())\n")
+	body.WriteString(fatalErr("Exists"))
+	body.WriteString("\tif exists {\n")
+	body.WriteString("\t\tt.Error(\"删除后 Exists 返回 true，期望 false\")\n")
+	body.WriteString("\t}\n")
+	file.addFunc(testFunc(name, "Delete", body.String()))
+
+	// 分页
+	body.Reset()
+	body.WriteString("\tfor n := 1; n <= 3; n++ {\n")
+	body.WriteString(fmt.Sprintf("\t\tif err := repo.Add(ctx, new%s(n)); err != nil {\n", name))
+	body.WriteString("\t\t\tt.Fatalf(\"Add 失败: %v\", err)\n")
+	body.WriteString("\t\t}\n")
+	body.WriteString("\t}\n")
+	body.WriteString("\tentities, total, err := repo.FindPage(ctx, 1, 2)\n")
+	body.WriteString(fatalErr("FindPage"))
+	body.WriteString("\tif len(entities) != 2 || total != 3 {\n")
+	body.WriteString("\t\tt.Errorf(\"FindPage 返回 %d 条、总数 %d，期望 2 条、总数 3\", len(entities), total)\n")
+	body.WriteString("\t}\n")
+	file.addFunc(testFunc(name, "FindPage", body.String()))
+
+	// 乐观锁
+	if version != "" {
+		body.Reset()
+		body.WriteString(fmt.Sprintf("\tentity := new%s(1)\n", name))
+		body.WriteString(checkErr("repo.Add(ctx, entity)", "Add"))
+		body.WriteString("\tstale, err := repo.FindByID(ctx, entity.GetID())\n")
+		body.WriteString(fatalErr("FindByID"))
+		body.WriteString(checkErr("repo.Update(ctx, entity)", "Update"))
+		body.WriteString("\tif err := repo.Update(ctx, stale); !errors.Is(err, framework.ErrVersionConflict) {\n")
+		body.WriteString("\t\tt.Errorf(\"以过期的版本号更新返回 %v，期望 ErrVersionConflict\", err)\n")
+		body.WriteString("\t}\n")
+		file.addFunc(testFunc(name, "OptimisticLock", body.String()))
+	}
+
+	// 软删除和恢复
+	if softDeleteField(agg) != nil {
+		body.Reset()
+		body.WriteString(fmt.Sprintf("\tentity := new%s(1)\n", name))
+		body.WriteString(checkErr("repo.Add(ctx, entity)", "Add"))
+		body.WriteString(checkErr("repo.Remove(ctx, entity.GetID())", "Remove"))
+		body.WriteString("\tif _, err := repo.FindByID(ctx, entity.GetID()); !errors.Is(err, framework.ErrRecordNotFound) {\n")
+		body.WriteString("\t\tt.Errorf(\"软删除后 FindByID 返回 %v，期望 ErrRecordNotFound\", err)\n")
+		body.WriteString("\t}\n")
+		body.WriteString("\tif _, err := repo.FindByIDWithDeleted(ctx, entity.GetID()); err != nil {\n")
+		body.WriteString("\t\tt.Errorf(\"FindByIDWithDeleted 失败: %v\", err)\n")
+		body.WriteString("\t}\n")
+		body.WriteString("\tdeleted, err := repo.FindAllDeleted(ctx)\n")
+		body.WriteString(fatalErr("FindAllDeleted"))
+		body.WriteString("\tif len(deleted) != 1 {\n")
+		body.WriteString("\t\tt.Errorf(\"FindAllDeleted 返回 %d 条，期望 1 条\", len(deleted))\n")
+		body.WriteString("\t}\n\n")
+		body.WriteString(checkErr("repo.Restore(ctx, entity.GetID())", "Restore"))
+		body.WriteString("\tif _, err := repo.FindByID(ctx, entity.GetID()); err != nil {\n")
+		body.WriteString("\t\tt.Errorf(\"恢复后 FindByID 失败: %v\", err)\n")
+		body.WriteString("\t}\n")
+		file.addFunc(testFunc(name, "SoftDelete", body.String()))
+	}
+
+	return file
+}
+
+// testFunc 返回仓储测试函数，body 之前创建 ctx 和仓储
+func testFunc(aggregate, scenario, body string) *GoFunc {
+	return &GoFunc{
+		Name:   fmt.Sprintf("Test%sRepository_%s", aggregate, scenario),
+		Params: "t *testing.T",
+		Body:   fmt.Sprintf("\tctx := context.Background()\n\trepo := setup%sRepository(t)\n\n%s", aggregate, body),
+	}
+}
+
+// checkErr 生成调用 call、返回错误时以 method 失败结束测试的语句
+func checkErr(call, method string) string {
+	return fmt.Sprintf("\tif err := %s; err != nil {\n\t\tt.Fatalf(\"%s 失败: %%v\", err)\n\t}\n", call, method)
+}
+
+// fatalErr 生成 err 不为 nil 时以 method 失败结束测试的语句
+func fatalErr(method string) string {
+	return fmt.Sprintf("\tif err != nil {\n\t\tt.Fatalf(\"%s 失败: %%v\", err)\n\t}\n", method)
+}
+
+// testData 返回构造测试数据的赋值（字段路径和值表达式），依次为：
+//   - 手动指定的主键和复合主键的组成字段
+//   - 唯一约束中的字段，字符串取 "{字段名}-{n}"，整数取 n
+//   - 非指针的 time.Time 字段取当前时间（MySQL 不接受零值时间），软删除字段除外
+//
+// 其他类型的字段、指针值对象中的字段保持零值
+func (g *IntegrationTestGenerator) testData(agg *metadata.AggregateMetadata) [][2]string {
+	var assignments [][2]string
+	assign := func(path string) {
+		if slices.ContainsFunc(assignments, func(assignment [2]string) bool { return assignment[0] == path }) {
+			return
+		}
+		if value, ok := testValue(agg, path); ok {
+			assignments = append(assignments, [2]string{path, value})
+		}
+	}
+
+	if agg.IsCompositeKey() {
+		for _, field := range agg.PrimaryKey {
+			assign(field.Name)
+		}
+	} else if agg.IDStrategy == metadata.IDStrategyManual && agg.IDField != nil {
+		assign(agg.IDField.Name)
+	}
+	for _, path := range uniqueFields(g.registry, agg) {
+		assign(path)
+	}
+
+	deletedAt := softDeleteField(agg)
+	for _, field := range agg.MappedFields() {
+		paths := []fieldPath{{path: field.Name, field: field}}
+		if len(field.Flattened) > 0 {
+			paths = paths[:0]
+			for _, sub := range field.Flattened {
+				paths = append(paths, fieldPath{path: field.Name + "." + sub.Name, field: sub})
+			}
+		}
+		for _, p := range paths {
+			if p.field != deletedAt && p.field.GoType() == "time.Time" && assignable(agg, p.path) {
+				assignments = append(assignments, [2]string{p.path, "time.Now()"})
+			}
+		}
+	}
+	return assignments
+}
+
+// fieldPath 字段及其在领域对象中的路径，如 Address.City
+type fieldPath struct {
+	path  string
+	field *metadata.FieldMetadata
+}
+
+// assignable 判断能否直接为字段路径赋值，指针值对象中的字段不能赋值
+func assignable(agg *metadata.AggregateMetadata, path string) bool {
+	name, _, nested := strings.Cut(path, ".")
+	if !nested {
+		return true
+	}
+	parent := agg.FieldByPath(name)
+	return parent != nil && !parent.IsPointer
+}
+
+// testValue 返回字段路径第 n 个测试值的表达式，字符串为 "{字段名}-{n}"，整数为 n；其他类型返回 false
+func testValue(agg *metadata.AggregateMetadata, path string) (string, bool) {
+	field := agg.FieldByPath(path)
+	if field == nil || !assignable(agg, path) || field.IsSlice || field.IsMap || field.IsArray || strings.Contains(field.Type, ".") {
+		return "", false
+	}
+
+	typeName := qualifyType(field.Type, agg.PackageName)
+	var value string
+	switch basicType := field.BasicType(); {
+	case basicType == "string":
+		value = fmt.Sprintf("fmt.Sprintf(\"%s-%%d\", n)", field.Name)
+		if typeName != "string" {
+			value = fmt.Sprintf("%s(%s)", typeName, value)
+		}
+	case isIntegerType(basicType):
+		value = typedLiteral("n", typeName)
+	default:
+		return "", false
+	}
+
+	if field.IsPointer {
+		value = "ptr(" + value + ")"
+	}
+	return value, true
+}
+
+// uniqueFields 返回唯一约束（包括部分唯一索引）中的字段路径
+func uniqueFields(registry *metadata.AggregateMetadataRegistry, agg *metadata.AggregateMetadata) []string {
+	var paths []string
+	for _, index := range agg.Indexes {
+		if index.Unique {
+			paths = append(paths, index.Fields...)
+		}
+	}
+	for _, fields := range uniqueConstraints(registry, agg) {
+		paths = append(paths, fields...)
+	}
+	return paths
+}
+
+// updateField 返回 Update 测试修改的字段：第一个直接声明的非指针字符串字段，
+// 排除主键、唯一约束、外部引用、枚举、敏感、只读、多态类型和自定义列类型的字段；没有时返回 nil
+func (g *IntegrationTestGenerator) updateField(agg *metadata.AggregateMetadata) *metadata.FieldMetadata {
+	excluded := uniqueFields(g.registry, agg)
+	for _, field := range agg.MappedFields() {
+		if field.IsPolymorphic() {
+			excluded = append(excluded, field.PolymorphicTypeField())
+		}
+	}
+
+	for _, field := range agg.MappedFields() {
+		annotations := field.Annotations
+		switch {
+		case field.GoType() != "string", field.ColumnType != "",
+			agg.InPrimaryKey(field), field == agg.IDField, slices.Contains(excluded, field.Name),
+			annotations.IsRef, annotations.IsEntity, annotations.IsValueObject, annotations.IsImmutable,
+			annotations.Sensitive != "", len(annotations.EnumValues) > 0:
+			continue
+		}
+		return field
+	}
+	return nil
+}
@@ -0,0 +1,865 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"soliton/pkg/metadata"
+	"strings"
+)
+
+// MemoryRepositoryGenerator 内存仓储生成器
+//
+// 为每个聚合根生成基于 framework.MemoryRepositoryOf 的仓储接口实现，不连接数据库，供服务层测试使用：
+//   - 软删除字段（DeletedAt）、乐观锁版本号（Version）和唯一约束（+soliton:unique、无条件的 +soliton:uniqueIndex）
+//     由生成的 framework.MemoryModel 交给内存仓储处理，行为与 BaseRepositoryOf 一致；部分唯一索引的条件无法在内存中求值，不检查
+//   - 扩展方法（FindByXxx、ListByXxx、多态、层级和中间实体的关联方法）按字段值过滤已保存的实体；
+//     FindByFilter 在内存中求值查询条件并按 {AggregateName}Sort 排序，指针字段为 nil 时排在前面（见 framework.CompareNullable）
+//   - 一对多关联实体的 LoadXxx 从字段 {Target}Repository 指定的仓储读取关联实体，未设置时只清空关联字段；
+//     AddWithChildren、UpdateWithChildren 通过同一字段保存关联实体
+//   - 多对多纯关联表的关联管理方法读写字段 {Left}{Right}Links（framework.MemoryLinks），
+//     List{Others}Of{Owner} 从字段 {Other}Repository 指定的仓储读取另一侧的聚合根
+//
+// 生成文件：infrastructure/memory/{AggregateName}Repository.go
+type MemoryRepositoryGenerator struct {
+	fileOutput
+	registry *metadata.AggregateMetadataRegistry
+}
+
+// NewMemoryRepositoryGenerator 创建内存仓储生成器
+func NewMemoryRepositoryGenerator() *MemoryRepositoryGenerator {
+	return &MemoryRepositoryGenerator{}
+}
+
+// SetRegistry 设置聚合根注册表，用于生成与仓储接口一致的关联实体加载方法
+func (g *MemoryRepositoryGenerator) SetRegistry(registry *metadata.AggregateMetadataRegistry) {
+	g.registry = registry
+}
+
+// Generate 为聚合根生成内存仓储
+func (g *MemoryRepositoryGenerator) Generate(agg *metadata.AggregateMetadata, outputDir string) error {
+	absOutputDir, _ := filepath.Abs(outputDir)
+	filePath := filepath.Join(infrastructureDir(agg, absOutputDir), "memory", agg.Name+"Repository.go")
+
+	if err := g.writeTemplate(filePath, "memory_repository", g.generateFile(agg, absOutputDir)); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return nil
+}
+
+// generateFile 生成内存仓储文件的模板数据
+func (g *MemoryRepositoryGenerator) generateFile(agg *metadata.AggregateMetadata, absOutputDir string) *GoFileData {
+	imports := []string{
+		agg.ImportPath,
+		calculateImportPath(agg.ModuleName, agg.ModuleRoot, filepath.Join(domainDir(agg, absOutputDir), "repository")),
+		"soliton/pkg/framework",
+	}
+	name := agg.Name + "Repository"
+	entityType := fmt.Sprintf("*%s.%s", agg.PackageName, agg.Name)
+	relations := loadableRelations(g.registry, agg)
+	links := joinLinks(g.registry, agg)
+
+	file := &GoFileData{
+		TemplateData: TemplateData{Aggregate: agg, Context: agg.Context()},
+		Package:      "memory",
+	}
+
+	fields := []*GoField{{Type: fmt.Sprintf("*framework.MemoryRepositoryOf[%s, %s]", entityType, qualifiedKeyType(agg))}}
+	targets := relationTargets(agg, relations)
+	for _, target := range targets {
+		fields = append(fields, &GoField{
+			Doc:       fmt.Sprintf("%sRepository 提供一对多关联实体的加载，为 nil 时 Load 方法只清空关联字段", target),
+			Name:      target + "Repository",
+			Type:      fmt.Sprintf("repository.%sRepository", target),
+			Separated: true,
+		})
+	}
+	for _, link := range links {
+		fields = append(fields, &GoField{
+			Doc:       fmt.Sprintf("%s 关联表 %s 的内存实现，与 %sRepository 的同名字段共享同一实例时两侧的关联一致", memoryLinks(link), link.table.TableName, link.other.Name),
+			Name:      memoryLinks(link),
+			Type:      "*framework.MemoryLinks",
+			Separated: true,
+		})
+		if target := link.other.Name; link.listable() && !slices.Contains(targets, target) {
+			fields = append(fields, &GoField{
+				Doc:  fmt.Sprintf("%sRepository 提供关联的 %s，为 nil 时 List%sOf%s 返回空列表", target, target, link.other.PluralName(), agg.Name),
+				Name: target + "Repository",
+				Type: fmt.Sprintf("repository.%sRepository", target),
+			})
+			targets = append(targets, target)
+		}
+	}
+	file.addType(fmt.Sprintf("%s repository.%s 的内存实现，见 framework.MemoryRepositoryOf", name, name), name, "struct", fields...)
+	file.addValues(declVar, "", &GoField{Name: "_", Type: "repository." + name, Value: fmt.Sprintf("(*%s)(nil)", name)})
+
+	model, modelImports := g.generateModel(agg)
+	imports = append(imports, modelImports...)
+	var body strings.Builder
+	body.WriteString(fmt.Sprintf("\treturn &%s{\n", name))
+	body.WriteString(fmt.Sprintf("\t\tMemoryRepositoryOf: framework.NewMemoryRepositoryOf[%s, %s](%s),\n", entityType, qualifiedKeyType(agg), model))
+	for _, link := range links {
+		body.WriteString(fmt.Sprintf("\t\t%s: framework.NewMemoryLinks(),\n", memoryLinks(link)))
+	}
+	body.WriteString("\t}\n")
+	file.addFunc(&GoFunc{
+		Doc:     fmt.Sprintf("New%s 创建空的 %s 内存仓储", name, agg.Name),
+		Name:    "New" + name,
+		Results: "*" + name,
+		Body:    body.String(),
+	})
+
+	methods, methodImports := g.generateExtendMethods(agg, relations, links)
+	if len(methods) > 0 {
+		imports = append(imports, "context")
+		imports = append(imports, methodImports...)
+		for _, method := range methods {
+			file.addFunc(method)
+		}
+	}
+
+	file.addImports(imports...)
+	return file
+}
+
+// relationTargets 返回一对多关系中需要另一个仓储加载的关联实体（去重，按关系顺序），自引用的关联实体从仓储自身加载
+func relationTargets(agg *metadata.AggregateMetadata, relations []*metadata.RelationMetadata) []string {
+	var targets []string
+	for _, rel := range relations {
+		if rel.TargetAggregate != agg.Name && !slices.Contains(targets, rel.TargetAggregate) {
+			targets = append(targets, rel.TargetAggregate)
+		}
+	}
+	return targets
+}
+
+// generateModel 生成传给 framework.NewMemoryRepositoryOf 的 framework.MemoryModel 字面量及其需要的 import
+func (g *MemoryRepositoryGenerator) generateModel(agg *metadata.AggregateMetadata) (string, []string) {
+	entityType := fmt.Sprintf("*%s.%s", agg.PackageName, agg.Name)
+	var entries [][2]string // 字段名和值
+	var imports []string
+
+	if field := softDeleteField(agg); field != nil {
+		imports = append(imports, "time")
+		switch field.GoType() {
+		case "*time.Time":
+			entries = append(entries,
+				[2]string{"DeletedAt", funcLit(fmt.Sprintf("func(e %s) *time.Time", entityType), "return e."+field.Name, "")},
+				[2]string{"SetDeletedAt", funcLit(fmt.Sprintf("func(e %s, t *time.Time)", entityType), fmt.Sprintf("e.%s = t", field.Name), "")})
+		case "gorm.DeletedAt":
+			entries = append(entries,
+				[2]string{"DeletedAt", funcLit(fmt.Sprintf("func(e %s) *time.Time", entityType), fmt.Sprintf("return framework.DeletedAtOf(e.%s)", field.Name), "")},
+				[2]string{"SetDeletedAt", funcLit(fmt.Sprintf("func(e %s, t *time.Time)", entityType), fmt.Sprintf("e.%s = framework.SoftDeleteOf(t)", field.Name), "")})
+		default:
+			// time.Time 以零值表示未删除
+			entries = append(entries,
+				[2]string{"DeletedAt", fmt.Sprintf("func(e %s) *time.Time {\n\tif e.%s.IsZero() {\n\t\treturn nil\n\t}\n\treturn &e.%s\n}", entityType, field.Name, field.Name)},
+				[2]string{"SetDeletedAt", fmt.Sprintf("func(e %s, t *time.Time) {\n\te.%s = time.Time{}\n\tif t != nil {\n\t\te.%s = *t\n\t}\n}", entityType, field.Name, field.Name)})
+		}
+	}
+
+	if agg.BaseEntity != nil && agg.BaseEntity.HasVersion && agg.BaseEntity.VersionField != nil {
+		field := agg.BaseEntity.VersionField
+		version, assign := fmt.Sprintf("int64(e.%s)", field.Name), fmt.Sprintf("%s(v)", field.Type)
+		if field.Type == "int64" {
+			version, assign = "e."+field.Name, "v"
+		}
+		entries = append(entries,
+			[2]string{"Version", funcLit(fmt.Sprintf("func(e %s) int64", entityType), "return "+version, "")},
+			[2]string{"SetVersion", funcLit(fmt.Sprintf("func(e %s, v int64)", entityType), fmt.Sprintf("e.%s = %s", field.Name, assign), "")})
+	}
+
+	var uniques []string
+	for _, fields := range uniqueConstraints(g.registry, agg) {
+		values := make([]string, len(fields))
+		for i, path := range fields {
+			values[i] = "e." + path
+		}
+		key := funcLit(fmt.Sprintf("func(e %s) []any", entityType), fmt.Sprintf("return []any{%s}", strings.Join(values, ", ")), "\t")
+		uniques = append(uniques, fmt.Sprintf("{Field: %q, Key: %s},", strings.Join(fields, ","), key))
+	}
+	if len(uniques) > 0 {
+		entries = append(entries, [2]string{"Uniques", fmt.Sprintf("[]framework.MemoryUnique[%s]{\n\t%s\n}", entityType, strings.Join(uniques, "\n\t"))})
+	}
+
+	if len(entries) == 0 {
+		return fmt.Sprintf("framework.MemoryModel[%s]{}", entityType), imports
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("framework.MemoryModel[%s]{\n", entityType))
+	for i, entry := range entries {
+		// 与 gofmt 一致，连续的单行字段对齐值，多行的值不对齐
+		key := entry[0] + ":"
+		if !strings.Contains(entry[1], "\n") {
+			width := len(key)
+			for j := i - 1; j >= 0 && !strings.Contains(entries[j][1], "\n"); j-- {
+				width = max(width, len(entries[j][0])+1)
+			}
+			for j := i + 1; j < len(entries) && !strings.Contains(entries[j][1], "\n"); j++ {
+				width = max(width, len(entries[j][0])+1)
+			}
+			key += strings.Repeat(" ", width-len(key))
+		}
+		sb.WriteString("\t\t\t" + key + " " + strings.ReplaceAll(entry[1], "\n", "\n\t\t\t") + ",\n")
+	}
+	sb.WriteString("\t\t}")
+	return sb.String(), imports
+}
+
+// uniqueConstraints 返回聚合根无条件的唯一约束（各约束的字段路径），与建表脚本中的唯一索引一致：
+// 声明的唯一索引、中间实体关联两端的组合唯一索引和 registry 中一对一关系的外键列；不包括部分唯一索引
+func uniqueConstraints(registry *metadata.AggregateMetadataRegistry, agg *metadata.AggregateMetadata) [][]string {
+	var constraints [][]string
+	declared := func(fields ...string) bool {
+		return slices.ContainsFunc(constraints, func(constraint []string) bool {
+			return len(constraint) == len(fields) && !slices.ContainsFunc(fields, func(field string) bool {
+				return !slices.Contains(constraint, field)
+			})
+		})
+	}
+
+	for _, index := range agg.Indexes {
+		if index.Unique && index.Where == "" {
+			constraints = append(constraints, index.Fields)
+		}
+	}
+	if left, right := agg.AssociationEnds(); left != nil && !declared(left.Name, right.Name) {
+		constraints = append(constraints, []string{left.Name, right.Name})
+	}
+	if registry != nil {
+		fields := agg.MappedFields()
+		for _, rel := range registry.GetRelations() {
+			if rel.Type == metadata.RelationTypeOneToOne && slices.Contains(fields, rel.ForeignKey) && !declared(rel.ForeignKey.Name) {
+				constraints = append(constraints, []string{rel.ForeignKey.Name})
+			}
+		}
+	}
+	return constraints
+}
+
+// funcLit 返回只有一条语句 stmt 的函数字面量，与 gofmt 一致，超过一行的长度限制时拆成多行（indent 为所在行的缩进）
+func funcLit(header, stmt, indent string) string {
+	if len(header)+len(stmt) <= 100 {
+		return fmt.Sprintf("%s { %s }", header, stmt)
+	}
+	return fmt.Sprintf("%s {\n%s\t%s\n%s}", header, indent, stmt, indent)
+}
+
+// fieldEquals 返回实体 e 的字段与同类型的值 value 相等的条件，指针字段比较指向的值，为 nil 时不相等
+func fieldEquals(field *metadata.FieldMetadata, value string) string {
+	if field.IsPointer {
+		return fmt.Sprintf("e.%s != nil && *e.%s == %s", field.Name, field.Name, value)
+	}
+	return fmt.Sprintf("e.%s == %s", field.Name, value)
+}
+
+// generateExtendMethods 生成与仓储接口扩展方法对应的实现及其需要的 import（context 除外）
+func (g *MemoryRepositoryGenerator) generateExtendMethods(agg *metadata.AggregateMetadata, relations []*metadata.RelationMetadata, links []*joinLink) ([]*GoFunc, []string) {
+	var methods []*GoFunc
+	var imports []string
+	receiver := "r *" + agg.Name + "Repository"
+	entityType := fmt.Sprintf("*%s.%s", agg.PackageName, agg.Name)
+	generatedMethods := make(map[string]bool)
+
+	for _, field := range agg.MappedFields() {
+		if (agg.IDField != nil && field.Name == agg.IDField.Name) || field.Annotations.IsEntity {
+			continue
+		}
+		param := toLowerFirst(field.Name)
+
+		if findMethod := "FindBy" + field.Name; field.Annotations.IsUnique && !generatedMethods[findMethod] {
+			match := funcLit(fmt.Sprintf("func(e %s) bool", entityType), "return "+fieldEquals(field, param), "\t")
+			methods = append(methods, &GoFunc{
+				Doc:      fmt.Sprintf("%s 根据 %s 查询（唯一）", findMethod, field.Name),
+				Receiver: receiver,
+				Name:     findMethod,
+				Params:   fmt.Sprintf("ctx context.Context, %s %s", param, qualifyType(field.Type, agg.PackageName)),
+				Results:  fmt.Sprintf("(%s, error)", entityType),
+				Body:     fmt.Sprintf("\treturn r.FindFirstWhere(ctx, %s)\n", match),
+			})
+			generatedMethods[findMethod] = true
+		}
+
+		if listMethod := "ListBy" + field.Name; (field.Annotations.IsIndex || isSingleRef(field)) && !generatedMethods[listMethod] {
+			match := funcLit(fmt.Sprintf("func(e %s) bool", entityType), "return "+fieldEquals(field, param), "\t")
+			methods = append(methods, &GoFunc{
+				Doc:      fmt.Sprintf("%s 根据 %s 分页查询（%s），返回当前页和总数", listMethod, field.Name, finderKind(field)),
+				Receiver: receiver,
+				Name:     listMethod,
+				Params:   fmt.Sprintf("ctx context.Context, %s %s, page, pageSize int", param, qualifyType(field.Type, agg.PackageName)),
+				Results:  fmt.Sprintf("([]%s, int64, error)", entityType),
+				Body:     fmt.Sprintf("\treturn r.FindPageWhere(ctx, %s, page, pageSize)\n", match),
+			})
+			generatedMethods[listMethod] = true
+		}
+
+		if polymorphicMethod := "GetBy" + field.PolymorphicName(); field.IsPolymorphic() && !generatedMethods[polymorphicMethod] {
+			typeField := field.PolymorphicTypeField()
+			typeParam := toLowerFirst(typeField)
+			match := funcLit(fmt.Sprintf("func(e %s) bool", entityType),
+				fmt.Sprintf("return string(e.%s) == %s && %s", typeField, typeParam, fieldEquals(field, param)), "\t")
+			methods = append(methods, &GoFunc{
+				Doc:      fmt.Sprintf("%s 根据多态关联查询（%s + %s）", polymorphicMethod, typeField, field.Name),
+				Receiver: receiver,
+				Name:     polymorphicMethod,
+				Params:   fmt.Sprintf("ctx context.Context, %s string, %s %s", typeParam, param, qualifyType(field.Type, agg.PackageName)),
+				Results:  fmt.Sprintf("([]%s, error)", entityType),
+				Body:     fmt.Sprintf("\treturn r.FindWhere(ctx, %s)\n", match),
+			})
+			generatedMethods[polymorphicMethod] = true
+		}
+	}
+
+	if hierarchy := g.generateHierarchyMethods(agg); len(hierarchy) > 0 {
+		imports = append(imports, "slices")
+		methods = append(methods, hierarchy...)
+	}
+	if association := g.generateAssociationMethods(agg); len(association) > 0 {
+		imports = append(imports, "errors")
+		methods = append(methods, association...)
+	}
+	for _, link := range links {
+		methods = append(methods, g.generateJoinMethods(link)...)
+	}
+	for _, rel := range relations {
+		methods = append(methods, g.generateLoadMethod(agg, rel))
+	}
+	methods = append(methods, g.generateChildMethods(agg)...)
+	if filter, filterImports := g.generateFindByFilterMethod(agg); filter != nil {
+		imports = append(imports, filterImports...)
+		methods = append(methods, filter)
+	}
+
+	return methods, imports
+}
+
+// generateFindByFilterMethod 生成按 {Aggregate}Filter 分页查询的方法及其需要的 import（context 除外），没有 FindByFilter 方法时返回空
+// 与数据库实现一致，排序方式无效时返回 framework.ErrInvalidSort，最后按主键升序排列
+func (g *MemoryRepositoryGenerator) generateFindByFilterMethod(agg *metadata.AggregateMetadata) (*GoFunc, []string) {
+	method := filterMethod(agg, "repository.")
+	if method == nil {
+		return nil, nil
+	}
+
+	var sb strings.Builder
+	imports := []string{"fmt"}
+	entityType := fmt.Sprintf("*%s.%s", agg.PackageName, agg.Name)
+
+	// compare 返回按字段升序比较实体 a、b 的表达式
+	compare := func(field *metadata.FieldMetadata, a, b string) string {
+		valueType := qualifyType(field.Type, agg.PackageName)
+		isTime := field.BasicType() == "time.Time"
+		switch {
+		case field.IsPointer && isTime:
+			imports = append(imports, "time")
+			return fmt.Sprintf("framework.CompareNullable(%s.%s, %s.%s, %s.Compare)", a, field.Name, b, field.Name, valueType)
+		case field.IsPointer:
+			imports = append(imports, "cmp")
+			return fmt.Sprintf("framework.CompareNullable(%s.%s, %s.%s, cmp.Compare[%s])", a, field.Name, b, field.Name, valueType)
+		case isTime:
+			return fmt.Sprintf("%s.%s.Compare(%s.%s)", a, field.Name, b, field.Name)
+		default:
+			imports = append(imports, "cmp")
+			return fmt.Sprintf("cmp.Compare(%s.%s, %s.%s)", a, field.Name, b, field.Name)
+		}
+	}
+	// compareFunc 返回按字段比较实体的函数字面量，indent 为所在行的缩进
+	compareFunc := func(field *metadata.FieldMetadata, desc bool, indent string) string {
+		a, b := "a", "b"
+		if desc {
+			a, b = b, a
+		}
+		return funcLit(fmt.Sprintf("func(a, b %s) int", entityType), "return "+compare(field, a, b), indent)
+	}
+
+	sb.WriteString(fmt.Sprintf("\tcompares := make([]func(a, b %s) int, 0, len(filter.Sort)+1)\n", entityType))
+	sb.WriteString("\tfor _, sort := range filter.Sort {\n")
+	sb.WriteString("\t\tswitch sort {\n")
+	for _, option := range sortOptions(agg) {
+		sb.WriteString(fmt.Sprintf("\t\tcase repository.%s:\n", option.Const))
+		sb.WriteString(fmt.Sprintf("\t\t\tcompares = append(compares, %s)\n", compareFunc(option.Field, option.Desc, "\t\t\t")))
+	}
+	sb.WriteString("\t\tdefault:\n")
+	sb.WriteString("\t\t\treturn nil, 0, fmt.Errorf(\"%w: %q\", framework.ErrInvalidSort, sort)\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n")
+	for _, field := range agg.PrimaryKey {
+		if field.Ordered() {
+			sb.WriteString(fmt.Sprintf("\tcompares = append(compares, %s)\n", compareFunc(field, false, "\t")))
+		}
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString(fmt.Sprintf("\tmatch := func(e %s) bool {\n", entityType))
+	for _, condition := range filterConditions(agg) {
+		field, value := condition.Field, "filter."+condition.Name
+		// 指针字段比较值时解引用，时间字段的 Before 方法可直接通过指针调用
+		current := "e." + field.Name
+		if field.IsPointer && condition.Op != ">=" && condition.Op != "<" {
+			current = "*" + current
+		}
+		// set 为条件已设置，differs 为字段值不满足条件
+		set := value + " != nil"
+		var differs string
+		switch condition.Op {
+		case "IN":
+			imports = append(imports, "slices")
+			set = fmt.Sprintf("len(%s) > 0", value)
+			differs = fmt.Sprintf("!slices.Contains(%s, %s)", value, current)
+		case ">=":
+			differs = fmt.Sprintf("%s.Before(*%s)", current, value)
+		case "<":
+			differs = fmt.Sprintf("!%s.Before(*%s)", current, value)
+		default:
+			differs = fmt.Sprintf("%s != *%s", current, value)
+		}
+		mismatch := set + " && " + differs
+		// 指针字段为 nil 时与数据库中的 NULL 一致，不满足任何条件
+		if field.IsPointer {
+			mismatch = fmt.Sprintf("%s && (e.%s == nil || %s)", set, field.Name, differs)
+		}
+		sb.WriteString(fmt.Sprintf("\t\tif %s {\n", mismatch))
+		sb.WriteString("\t\t\treturn false\n")
+		sb.WriteString("\t\t}\n")
+	}
+	sb.WriteString("\t\treturn true\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn r.FindPageSorted(ctx, match, compares, page, pageSize)\n")
+
+	return method.goFunc("r *"+agg.Name+"Repository", sb.String()), imports
+}
+
+// generateChildMethods 生成随聚合根一并保存和加载一对多关联实体的方法，没有 childRelations 时返回空
+// 关联实体通过字段 {Target}Repository 指定的仓储保存，未设置时跳过；内存中聚合根与关联实体不是原子写入
+func (g *MemoryRepositoryGenerator) generateChildMethods(agg *metadata.AggregateMetadata) []*GoFunc {
+	relations := childRelations(g.registry, agg)
+	if len(relations) == 0 {
+		return nil
+	}
+
+	var result []*GoFunc
+	var sb strings.Builder
+	receiver := "r *" + agg.Name + "Repository"
+	keyType := qualifiedKeyType(agg)
+	methods := childMethods(agg, relations)
+
+	for i, op := range []string{"Add", "Update"} {
+		sb.Reset()
+		sb.WriteString(fmt.Sprintf("\tif err := r.%s(ctx, entity); err != nil {\n", op))
+		sb.WriteString("\t\treturn err\n")
+		sb.WriteString("\t}\n")
+		sb.WriteString("\treturn r.saveChildren(ctx, entity)\n")
+		result = append(result, methods[i].goFunc(receiver, sb.String()))
+	}
+
+	sb.Reset()
+	sb.WriteString("\tentity, err := r.FindByID(ctx, id)\n")
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn nil, err\n")
+	sb.WriteString("\t}\n")
+	for _, rel := range relations {
+		sb.WriteString(fmt.Sprintf("\tif err := r.Load%s(ctx, entity); err != nil {\n", rel.Field.Name))
+		sb.WriteString("\t\treturn nil, err\n")
+		sb.WriteString("\t}\n")
+	}
+	sb.WriteString("\treturn entity, nil\n")
+	result = append(result, methods[2].goFunc(receiver, sb.String()))
+
+	sb.Reset()
+	for i, rel := range relations {
+		child := g.registry.Get(rel.TargetAggregate)
+		variable := toLowerFirst(rel.Field.Name)
+		childType := fmt.Sprintf("*%s.%s", child.PackageName, child.Name)
+		source := fmt.Sprintf("r.%sRepository", child.Name)
+		foreignKey := rel.ForeignKey
+
+		// 已保存的关联实体中外键等于聚合根主键的条件
+		ownerID := "child." + foreignKey.Name
+		if foreignKey.IsPointer {
+			ownerID = "*" + ownerID
+		}
+		if foreignKey.Type != keyType {
+			ownerID = fmt.Sprintf("%s(%s)", keyType, ownerID)
+		}
+		owned := ownerID + " == entity.GetID()"
+		if foreignKey.IsPointer {
+			owned = fmt.Sprintf("child.%s != nil && %s", foreignKey.Name, owned)
+		}
+
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("\tif %s != nil {\n", source))
+		sb.WriteString(backfillChildren(agg, rel, child, variable, "\t\t"))
+		sb.WriteString(fmt.Sprintf("\t\tstored%s, err := %s.FindAll(ctx)\n", rel.Field.Name, source))
+		sb.WriteString("\t\tif err != nil {\n")
+		sb.WriteString("\t\t\treturn err\n")
+		sb.WriteString("\t\t}\n")
+		sb.WriteString(fmt.Sprintf("\t\tvar existing%s []%s\n", rel.Field.Name, childType))
+		sb.WriteString(fmt.Sprintf("\t\tfor _, child := range stored%s {\n", rel.Field.Name))
+		sb.WriteString(fmt.Sprintf("\t\t\tif %s {\n", owned))
+		sb.WriteString(fmt.Sprintf("\t\t\t\texisting%s = append(existing%s, child)\n", rel.Field.Name, rel.Field.Name))
+		sb.WriteString("\t\t\t}\n")
+		sb.WriteString("\t\t}\n")
+		sb.WriteString(fmt.Sprintf("\t\tif err := framework.SaveChildren[%s, %s](ctx, %s, %s, existing%s, %s); err != nil {\n",
+			childType, qualifiedKeyType(child), source, variable, rel.Field.Name, childCascade(rel)))
+		sb.WriteString("\t\t\treturn err\n")
+		sb.WriteString("\t\t}\n")
+		sb.WriteString("\t}\n")
+	}
+	sb.WriteString("\treturn nil\n")
+	result = append(result, &GoFunc{
+		Doc:      "saveChildren 通过关联实体的仓储保存关联实体，外键回填为聚合根的主键，见 framework.SaveChildren",
+		Receiver: receiver,
+		Name:     "saveChildren",
+		Params:   fmt.Sprintf("ctx context.Context, entity *%s.%s", agg.PackageName, agg.Name),
+		Results:  "error",
+		Body:     sb.String(),
+	})
+
+	return result
+}
+
+// memoryLinks 返回内存仓储中多对多关联表的字段名，两侧相同，如 RoleUserLinks
+func memoryLinks(link *joinLink) string {
+	return link.table.LeftAggregate + link.table.RightAggregate + "Links"
+}
+
+// generateJoinMethods 生成多对多纯关联表的关联管理方法，读写 framework.MemoryLinks
+func (g *MemoryRepositoryGenerator) generateJoinMethods(link *joinLink) []*GoFunc {
+	var result []*GoFunc
+	links := "r." + memoryLinks(link)
+	ownerParam, otherParam := link.ownerParam(), link.otherParam()
+	// MemoryLinks 按关联表的左右两侧存取，聚合根为右侧时交换参数
+	leftID, rightID, ids, replace := ownerParam, otherParam, "RightIDs", "ReplaceRights"
+	if !link.left {
+		leftID, rightID, ids, replace = otherParam, ownerParam, "LeftIDs", "ReplaceLefts"
+	}
+
+	for _, method := range link.methods() {
+		var sb strings.Builder
+		switch method.Name {
+		case "Add" + link.other.Name + "To" + link.owner.Name:
+			sb.WriteString(fmt.Sprintf("\t%s.Attach(%s, %s)\n", links, leftID, rightID))
+			sb.WriteString("\treturn nil\n")
+		case "Remove" + link.other.Name + "From" + link.owner.Name:
+			sb.WriteString(fmt.Sprintf("\t%s.Detach(%s, %s)\n", links, leftID, rightID))
+			sb.WriteString("\treturn nil\n")
+		case "Replace" + link.other.PluralName():
+			sb.WriteString(fmt.Sprintf("\t%s.%s(%s, %ss)\n", links, replace, ownerParam, otherParam))
+			sb.WriteString("\treturn nil\n")
+		default:
+			source := fmt.Sprintf("r.%sRepository", link.other.Name)
+			sb.WriteString(fmt.Sprintf("\tif %s == nil {\n", source))
+			sb.WriteString("\t\treturn nil, 0, nil\n")
+			sb.WriteString("\t}\n")
+			sb.WriteString(fmt.Sprintf("\tids := %s.%s(%s)\n", links, ids, ownerParam))
+			sb.WriteString(fmt.Sprintf("\tlinked, err := %s.FindByIDs(ctx, ids)\n", source))
+			sb.WriteString("\tif err != nil {\n")
+			sb.WriteString("\t\treturn nil, 0, err\n")
+			sb.WriteString("\t}\n")
+			sb.WriteString("\tresult, total := framework.PageLinked(ids, linked, page, pageSize)\n")
+			sb.WriteString("\treturn result, total, nil\n")
+		}
+		result = append(result, method.goFunc("r *"+link.owner.Name+"Repository", sb.String()))
+	}
+
+	return result
+}
+
+// generateHierarchyMethods 生成树形结构（邻接表）的层级查询方法，没有父节点字段时返回空
+// 与数据库实现一致，已访问的节点会被跳过，数据中存在环时不会死循环
+func (g *MemoryRepositoryGenerator) generateHierarchyMethods(agg *metadata.AggregateMetadata) []*GoFunc {
+	parent := agg.ParentField()
+	if parent == nil {
+		return nil
+	}
+
+	var methods []*GoFunc
+	var sb strings.Builder
+	receiver := "r *" + agg.Name + "Repository"
+	keyType := qualifiedKeyType(agg)
+	entityType := fmt.Sprintf("*%s.%s", agg.PackageName, agg.Name)
+
+	// 节点 node 有父节点的条件及父节点 ID 表达式
+	zero := "0"
+	if parent.Type == "string" {
+		zero = `""`
+	}
+	hasParent := func(node string) string { return fmt.Sprintf("%s.%s != %s", node, parent.Name, zero) }
+	isRoot := func(node string) string { return fmt.Sprintf("%s.%s == %s", node, parent.Name, zero) }
+	parentID := func(node string) string {
+		id := node + "." + parent.Name
+		if parent.IsPointer {
+			id = "*" + id
+		}
+		if parent.Type != keyType {
+			id = fmt.Sprintf("%s(%s)", keyType, id)
+		}
+		return id
+	}
+	if parent.IsPointer {
+		hasParent = func(node string) string { return fmt.Sprintf("%s.%s != nil", node, parent.Name) }
+		isRoot = func(node string) string { return fmt.Sprintf("%s.%s == nil", node, parent.Name) }
+	}
+
+	// GetRoots
+	methods = append(methods, &GoFunc{
+		Doc:      fmt.Sprintf("GetRoots 查询根节点（%s 为空）", parent.Name),
+		Receiver: receiver,
+		Name:     "GetRoots",
+		Params:   "ctx context.Context",
+		Results:  fmt.Sprintf("([]%s, error)", entityType),
+		Body:     fmt.Sprintf("\treturn r.FindWhere(ctx, %s)\n", funcLit(fmt.Sprintf("func(e %s) bool", entityType), "return "+isRoot("e"), "\t")),
+	})
+
+	// GetAncestors
+	sb.WriteString("\tnode, err := r.FindByID(ctx, id)\n")
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn nil, err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("\tvar result []%s\n", entityType))
+	sb.WriteString(fmt.Sprintf("\tvisited := map[%s]bool{id: true}\n", keyType))
+	sb.WriteString(fmt.Sprintf("\tfor %s {\n", hasParent("node")))
+	sb.WriteString(fmt.Sprintf("\t\tparentID := %s\n", parentID("node")))
+	sb.WriteString("\t\tif visited[parentID] {\n")
+	sb.WriteString("\t\t\tbreak\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tvisited[parentID] = true\n")
+	sb.WriteString("\n")
+	sb.WriteString("\t\tnode, err = r.FindByID(ctx, parentID)\n")
+	sb.WriteString("\t\tif err != nil {\n")
+	sb.WriteString("\t\t\treturn nil, err\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tresult = append(result, node)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString("\treturn result, nil\n")
+	methods = append(methods, &GoFunc{
+		Doc:      "GetAncestors 查询祖先节点，从父节点到根节点排列，不含自身",
+		Receiver: receiver,
+		Name:     "GetAncestors",
+		Params:   "ctx context.Context, id " + keyType,
+		Results:  fmt.Sprintf("([]%s, error)", entityType),
+		Body:     sb.String(),
+	})
+
+	// GetDescendants
+	sb.Reset()
+	sb.WriteString(fmt.Sprintf("\tvar result []%s\n", entityType))
+	sb.WriteString(fmt.Sprintf("\tvisited := map[%s]bool{id: true}\n", keyType))
+	sb.WriteString(fmt.Sprintf("\tparentIDs := []%s{id}\n", keyType))
+	sb.WriteString("\tfor len(parentIDs) > 0 {\n")
+	sb.WriteString(fmt.Sprintf("\t\tchildren, err := r.FindWhere(ctx, func(e %s) bool {\n", entityType))
+	sb.WriteString(fmt.Sprintf("\t\t\treturn %s && slices.Contains(parentIDs, %s)\n", hasParent("e"), parentID("e")))
+	sb.WriteString("\t\t})\n")
+	sb.WriteString("\t\tif err != nil {\n")
+	sb.WriteString("\t\t\treturn nil, err\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString("\t\tparentIDs = nil\n")
+	sb.WriteString("\t\tfor _, node := range children {\n")
+	sb.WriteString("\t\t\tif visited[node.GetID()] {\n")
+	sb.WriteString("\t\t\t\tcontinue\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t\tvisited[node.GetID()] = true\n")
+	sb.WriteString("\t\t\tresult = append(result, node)\n")
+	sb.WriteString("\t\t\tparentIDs = append(parentIDs, node.GetID())\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString("\treturn result, nil\n")
+	methods = append(methods, &GoFunc{
+		Doc:      "GetDescendants 查询全部后代节点，按层级排列，不含自身",
+		Receiver: receiver,
+		Name:     "GetDescendants",
+		Params:   "ctx context.Context, id " + keyType,
+		Results:  fmt.Sprintf("([]%s, error)", entityType),
+		Body:     sb.String(),
+	})
+
+	// GetTree
+	if children := agg.ChildrenField(); children != nil {
+		sb.Reset()
+		sb.WriteString("\troot, err := r.FindByID(ctx, id)\n")
+		sb.WriteString("\tif err != nil {\n")
+		sb.WriteString("\t\treturn nil, err\n")
+		sb.WriteString("\t}\n")
+		sb.WriteString("\tdescendants, err := r.GetDescendants(ctx, id)\n")
+		sb.WriteString("\tif err != nil {\n")
+		sb.WriteString("\t\treturn nil, err\n")
+		sb.WriteString("\t}\n")
+		sb.WriteString("\n")
+		sb.WriteString(fmt.Sprintf("\troot.%s = nil\n", children.Name))
+		sb.WriteString(fmt.Sprintf("\tnodes := map[%s]%s{id: root}\n", keyType, entityType))
+		sb.WriteString("\tfor _, node := range descendants {\n")
+		sb.WriteString(fmt.Sprintf("\t\tnode.%s = nil\n", children.Name))
+		sb.WriteString("\t\tnodes[node.GetID()] = node\n")
+		sb.WriteString("\t}\n")
+		sb.WriteString("\tfor _, node := range descendants {\n")
+		sb.WriteString(fmt.Sprintf("\t\tif parent, ok := nodes[%s]; ok {\n", parentID("node")))
+		sb.WriteString(fmt.Sprintf("\t\t\tparent.%s = append(parent.%s, node)\n", children.Name, children.Name))
+		sb.WriteString("\t\t}\n")
+		sb.WriteString("\t}\n")
+		sb.WriteString("\n")
+		sb.WriteString("\treturn root, nil\n")
+		methods = append(methods, &GoFunc{
+			Doc:      fmt.Sprintf("GetTree 查询以指定节点为根的子树，后代节点逐层填充到 %s", children.Name),
+			Receiver: receiver,
+			Name:     "GetTree",
+			Params:   "ctx context.Context, id " + keyType,
+			Results:  fmt.Sprintf("(%s, error)", entityType),
+			Body:     sb.String(),
+		})
+	}
+
+	return methods
+}
+
+// generateAssociationMethods 为中间实体（+soliton:manyToMany）生成关联管理方法，不是中间实体时返回空
+func (g *MemoryRepositoryGenerator) generateAssociationMethods(agg *metadata.AggregateMetadata) []*GoFunc {
+	left, right := agg.AssociationEnds()
+	if left == nil {
+		return nil
+	}
+
+	var methods []*GoFunc
+	var sb strings.Builder
+	receiver := "r *" + agg.Name + "Repository"
+	entityType := fmt.Sprintf("*%s.%s", agg.PackageName, agg.Name)
+	leftParam, rightParam := toLowerFirst(left.Name), toLowerFirst(right.Name)
+	params := fmt.Sprintf("ctx context.Context, %s %s, %s %s", leftParam, left.Type, rightParam, right.Type)
+
+	// GetLink
+	match := funcLit(fmt.Sprintf("func(e %s) bool", entityType),
+		fmt.Sprintf("return %s && %s", fieldEquals(left, leftParam), fieldEquals(right, rightParam)), "\t")
+	methods = append(methods, &GoFunc{
+		Doc: fmt.Sprintf("GetLink 查询 %s 与 %s 的关联（%s + %s），不存在时返回 framework.ErrRecordNotFound",
+			left.RefAggregate(), right.RefAggregate(), left.Name, right.Name),
+		Receiver: receiver,
+		Name:     "GetLink",
+		Params:   params,
+		Results:  fmt.Sprintf("(%s, error)", entityType),
+		Body:     fmt.Sprintf("\treturn r.FindFirstWhere(ctx, %s)\n", match),
+	})
+
+	// Link
+	linkLeft, linkRight := "link."+left.Name, "link."+right.Name
+	if left.IsPointer {
+		linkLeft = "*" + linkLeft
+	}
+	if right.IsPointer {
+		linkRight = "*" + linkRight
+	}
+	sb.WriteString(fmt.Sprintf("\texisting, err := r.GetLink(ctx, %s, %s)\n", linkLeft, linkRight))
+	sb.WriteString("\tif errors.Is(err, framework.ErrRecordNotFound) {\n")
+	sb.WriteString("\t\treturn r.Add(ctx, link)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString("\tlink.SetID(existing.GetID())\n")
+	if agg.BaseEntity != nil && agg.BaseEntity.HasVersion {
+		version := agg.BaseEntity.VersionField.Name
+		sb.WriteString(fmt.Sprintf("\tlink.%s = existing.%s\n", version, version))
+	}
+	sb.WriteString("\treturn r.Update(ctx, link)\n")
+	methods = append(methods, &GoFunc{
+		Doc:      fmt.Sprintf("Link 建立 %s 与 %s 的关联：不存在时新增，已存在时更新附加属性（沿用已有记录的主键）", left.RefAggregate(), right.RefAggregate()),
+		Receiver: receiver,
+		Name:     "Link",
+		Params:   "ctx context.Context, link " + entityType,
+		Results:  "error",
+		Body:     sb.String(),
+	})
+
+	// Unlink
+	sb.Reset()
+	sb.WriteString(fmt.Sprintf("\texisting, err := r.GetLink(ctx, %s, %s)\n", leftParam, rightParam))
+	sb.WriteString("\tif errors.Is(err, framework.ErrRecordNotFound) {\n")
+	sb.WriteString("\t\treturn nil\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString("\treturn r.Delete(ctx, existing.GetID())\n")
+	methods = append(methods, &GoFunc{
+		Doc:      fmt.Sprintf("Unlink 解除 %s 与 %s 的关联（硬删除关联记录），关联不存在时不报错", left.RefAggregate(), right.RefAggregate()),
+		Receiver: receiver,
+		Name:     "Unlink",
+		Params:   params,
+		Results:  "error",
+		Body:     sb.String(),
+	})
+
+	return methods
+}
+
+// generateLoadMethod 生成一对多关联实体的批量加载方法，从 {Target}Repository（自引用时为仓储自身）读取未删除的关联实体并按外键分组填充
+func (g *MemoryRepositoryGenerator) generateLoadMethod(agg *metadata.AggregateMetadata, rel *metadata.RelationMetadata) *GoFunc {
+	var sb strings.Builder
+	keyType := qualifiedKeyType(agg)
+	entityType := fmt.Sprintf("*%s.%s", agg.PackageName, agg.Name)
+	field, foreignKey := rel.Field, rel.ForeignKey
+
+	ownerID := "child." + foreignKey.Name
+	if foreignKey.IsPointer {
+		ownerID = "*" + ownerID
+	}
+	if foreignKey.Type != keyType {
+		ownerID = fmt.Sprintf("%s(%s)", keyType, ownerID)
+	}
+	element := "child"
+	if !field.IsPointer {
+		element = "*child"
+	}
+
+	doc := fmt.Sprintf("Load%s 批量加载关联实体 %s（%s.%s 引用 %s）", field.Name, field.Name, rel.TargetAggregate, foreignKey.Name, agg.Name)
+	if rel.TargetAggregate != agg.Name {
+		doc += fmt.Sprintf("，%sRepository 为 nil 时只清空 %s", rel.TargetAggregate, field.Name)
+	}
+	sb.WriteString(fmt.Sprintf("\towners := make(map[%s]%s, len(entities))\n", keyType, entityType))
+	sb.WriteString("\tfor _, entity := range entities {\n")
+	sb.WriteString(fmt.Sprintf("\t\tentity.%s = nil\n", field.Name))
+	sb.WriteString("\t\towners[entity.GetID()] = entity\n")
+	sb.WriteString("\t}\n")
+	source := "r"
+	if rel.TargetAggregate == agg.Name {
+		sb.WriteString("\tif len(entities) == 0 {\n")
+	} else {
+		source = fmt.Sprintf("r.%sRepository", rel.TargetAggregate)
+		sb.WriteString(fmt.Sprintf("\tif len(entities) == 0 || %s == nil {\n", source))
+	}
+	sb.WriteString("\t\treturn nil\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("\tchildren, err := %s.FindAll(ctx)\n", source))
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tfor _, child := range children {\n")
+	if foreignKey.IsPointer {
+		sb.WriteString(fmt.Sprintf("\t\tif child.%s == nil {\n", foreignKey.Name))
+		sb.WriteString("\t\t\tcontinue\n")
+		sb.WriteString("\t\t}\n")
+	}
+	sb.WriteString(fmt.Sprintf("\t\tif owner, ok := owners[%s]; ok {\n", ownerID))
+	sb.WriteString(fmt.Sprintf("\t\t\towner.%s = append(owner.%s, %s)\n", field.Name, field.Name, element))
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString("\treturn nil\n")
+
+	return &GoFunc{
+		Doc:      doc,
+		Receiver: "r *" + agg.Name + "Repository",
+		Name:     "Load" + field.Name,
+		Params:   "ctx context.Context, entities ..." + entityType,
+		Results:  "error",
+		Body:     sb.String(),
+	}
+}
@@ -0,0 +1,72 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMemoryFindByFilterPointerFields(t *testing.T) {
+	agg := parseTestModel(t, "Payment", `package model
+
+import "time"
+
+// Payment 支付单
+//
+// +soliton:aggregate
+type Payment struct {
+	ID int64 `+"`db:\"id\"`"+`
+	// +soliton:filter(sort)
+	PaidAt *time.Time `+"`db:\"paid_at\"`"+`
+	// +soliton:filter
+	Remark *string `+"`db:\"remark\"`"+`
+	// +soliton:filter
+	CreatedAt time.Time `+"`db:\"created_at\"`"+`
+}
+`)
+
+	code := renderTestTemplate(t, "memory_repository", NewMemoryRepositoryGenerator().generateFile(agg, t.TempDir()))
+	assertGoSource(t, code,
+		"if filter.PaidAtFrom != nil && (e.PaidAt == nil || e.PaidAt.Before(*filter.PaidAtFrom)) {",
+		"if filter.PaidAtTo != nil && (e.PaidAt == nil || !e.PaidAt.Before(*filter.PaidAtTo)) {",
+		"if filter.Remark != nil && (e.Remark == nil || *e.Remark != *filter.Remark) {",
+		"if filter.CreatedAtFrom != nil && e.CreatedAt.Before(*filter.CreatedAtFrom) {",
+		"if filter.CreatedAtTo != nil && !e.CreatedAt.Before(*filter.CreatedAtTo) {",
+		"framework.CompareNullable(a.PaidAt, b.PaidAt, time.Time.Compare)",
+	)
+	if strings.Contains(code, "*e.PaidAt.Before") {
+		t.Errorf("指针时间字段不应解引用后调用 Before:\n%s", code)
+	}
+}
+
+func TestRepositoryListBySkipsCollectionRef(t *testing.T) {
+	agg := parseTestModel(t, "User", `package model
+
+// Role 角色
+//
+// +soliton:aggregate
+type Role struct {
+	ID int64 `+"`db:\"id\"`"+`
+}
+
+// User 用户
+//
+// +soliton:aggregate
+type User struct {
+	ID int64 `+"`db:\"id\"`"+`
+	// +soliton:ref(Role)
+	PrimaryRoleID int64 `+"`db:\"primary_role_id\"`"+`
+	// +soliton:ref(Role)
+	RoleIDs []int64 `+"`db:\"role_ids\"`"+`
+}
+`)
+
+	memory := renderTestTemplate(t, "memory_repository", NewMemoryRepositoryGenerator().generateFile(agg, t.TempDir()))
+	assertGoSource(t, memory, "func (r *UserRepository) ListByPrimaryRoleID(")
+	repository := renderTestTemplate(t, "repository_interface", NewRepositoryInterfaceGenerator().generateFile(agg))
+	assertGoSource(t, repository, "ListByPrimaryRoleID(")
+	for name, code := range map[string]string{"memory_repository": memory, "repository_interface": repository} {
+		if strings.Contains(code, "ListByRoleIDs") {
+			t.Errorf("%s 不应为切片上的外部引用生成 ListBy 方法:\n%s", name, code)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"soliton/pkg/metadata"
+	"strings"
+)
+
+// migrationStep ALTER 迁移中的一步，up 与 down 互为逆操作
+type migrationStep struct {
+	up          string
+	down        string
+	review      string // 需要人工确认的原因，为空表示可以直接执行
+	destructive bool   // 是否可能丢失数据（删除表、删除列、修改列类型），这类语句生成时注释掉，确认后再启用
+}
+
+// schemaChanges 比较同一限界上下文变更前后的表，返回从 before 迁移到 after 的步骤
+//
+// 聚合根表按聚合根名对应，表名不同时重命名；纯多对多关联表按表名对应。列按字段路径对应，列名不同时改名，
+// 关联表的列按列名对应；索引和外键按名称对应，定义变化时先删除再重新创建。
+// 步骤依次为：创建新表、修改已有的表、添加新表的外键（外键在建表后添加的方言）、删除不再存在的表。
+func schemaChanges(renderer ddlRenderer, before, after []*metadata.TableMetadata) []migrationStep {
+	old := make(map[string]*metadata.TableMetadata)
+	for _, table := range before {
+		old[tableKey(table)] = table
+	}
+
+	var created, altered, foreignKeys, dropped []migrationStep
+	seen := make(map[string]bool)
+	for _, table := range after {
+		key := tableKey(table)
+		seen[key] = true
+		prev, ok := old[key]
+		if !ok {
+			created = append(created, migrationStep{up: renderer.createTable(table), down: renderer.dropTable(table)})
+			if statements := renderer.foreignKeys(table); statements != "" {
+				foreignKeys = append(foreignKeys, migrationStep{up: statements})
+			}
+			continue
+		}
+		altered = append(altered, alterTable(renderer, prev, table)...)
+	}
+	for _, table := range before {
+		if !seen[tableKey(table)] {
+			dropped = append(dropped, migrationStep{
+				up:          renderer.dropTable(table),
+				down:        renderer.createTable(table) + renderer.foreignKeys(table),
+				review:      fmt.Sprintf("删除表 %s", table.Name),
+				destructive: true,
+			})
+		}
+	}
+
+	return slices.Concat(created, altered, foreignKeys, dropped)
+}
+
+// tableKey 返回用于对应前后表的键：聚合根表为聚合根名，纯多对多关联表为表名
+func tableKey(table *metadata.TableMetadata) string {
+	if table.Aggregate != "" {
+		return "aggregate:" + table.Aggregate
+	}
+	return "table:" + table.Name
+}
+
+// columnKey 返回用于对应前后列的键：字段路径，关联表的列为列名
+func columnKey(column *metadata.ColumnMetadata) string {
+	if column.FieldPath != "" {
+		return column.FieldPath
+	}
+	return column.Name
+}
+
+// alterTable 返回将已有的表 before 修改为 after 的步骤
+//
+// 先重命名表，再删除不再存在或定义变化的外键和索引，然后增删改列，最后创建新的索引和外键，
+// 使删除列之前已去掉依赖它的索引，创建索引时列已存在。
+func alterTable(renderer ddlRenderer, before, after *metadata.TableMetadata) []migrationStep {
+	var steps []migrationStep
+	table := after.Name
+
+	if before.Name != after.Name {
+		steps = append(steps, migrationStep{up: renderer.renameTable(before.Name, after.Name), down: renderer.renameTable(after.Name, before.Name)})
+	}
+	if !slices.Equal(before.PrimaryKey, after.PrimaryKey) {
+		steps = append(steps, migrationStep{review: fmt.Sprintf("表 %s 的主键由 (%s) 改为 (%s)，需要手动迁移",
+			table, strings.Join(before.PrimaryKey, ", "), strings.Join(after.PrimaryKey, ", "))})
+	}
+
+	// 被删除的列（按列名），依赖它们的索引和外键与删除列一样需要确认
+	oldColumns := make(map[string]*metadata.ColumnMetadata)
+	for _, column := range before.Columns {
+		oldColumns[columnKey(column)] = column
+	}
+	newColumns := make(map[string]bool)
+	for _, column := range after.Columns {
+		newColumns[columnKey(column)] = true
+	}
+	removed := make(map[string]bool)
+	for key, column := range oldColumns {
+		if !newColumns[key] {
+			removed[column.Name] = true
+		}
+	}
+
+	// 删除外键和索引
+	newForeignKeys := make(map[string]*metadata.ForeignKeyMetadata)
+	for _, fk := range after.ForeignKeys {
+		newForeignKeys[fk.Name] = fk
+	}
+	for _, fk := range before.ForeignKeys {
+		if next, ok := newForeignKeys[fk.Name]; !ok || *next != *fk {
+			step := migrationStep{up: renderer.dropForeignKey(table, fk), down: renderer.addForeignKey(table, fk)}
+			if removed[fk.Column] {
+				step.review = fmt.Sprintf("删除列 %s.%s 上的外键 %s", table, fk.Column, fk.Name)
+				step.destructive = true
+			}
+			steps = append(steps, step)
+		}
+	}
+	newIndexes := make(map[string]*metadata.TableIndexMetadata)
+	for _, index := range after.Indexes {
+		newIndexes[index.Name] = index
+	}
+	for _, index := range before.Indexes {
+		if next, ok := newIndexes[index.Name]; !ok || !sameIndex(index, next) {
+			step := migrationStep{up: renderer.dropIndex(table, index), down: renderer.createIndex(table, index)}
+			// 依赖被删除列的索引随列一起确认，否则 down 会在不存在的列上重建索引
+			if column := slices.IndexFunc(index.Columns, func(name string) bool { return removed[name] }); column >= 0 {
+				step.review = fmt.Sprintf("删除列 %s.%s 上的索引 %s", table, index.Columns[column], index.Name)
+				step.destructive = true
+			}
+			steps = append(steps, step)
+		}
+	}
+
+	// 列
+	seen := make(map[string]bool)
+	for _, column := range after.Columns {
+		key := columnKey(column)
+		seen[key] = true
+		prev, ok := oldColumns[key]
+		switch {
+		case !ok:
+			step := migrationStep{up: renderer.addColumn(table, column), down: renderer.dropColumn(table, column)}
+			if !column.Nullable && column.Default == "" {
+				step.review = fmt.Sprintf("新增的列 %s.%s 不可空且没有默认值，表中已有数据时需要先设置默认值", table, column.Name)
+			}
+			steps = append(steps, step)
+		case columnChanged(prev, column):
+			step := migrationStep{up: renderer.alterColumn(table, prev, column), down: renderer.alterColumn(table, column, prev)}
+			switch {
+			case prev.Type != column.Type:
+				step.review = fmt.Sprintf("修改列 %s.%s 的类型 %s → %s，可能丢失数据", table, column.Name, prev.Type, column.Type)
+				step.destructive = true
+			case prev.Nullable && !column.Nullable:
+				step.review = fmt.Sprintf("列 %s.%s 改为不可空，需要先处理已有的 NULL 值", table, column.Name)
+			}
+			steps = append(steps, step)
+		}
+	}
+	for _, column := range before.Columns {
+		if !seen[columnKey(column)] {
+			steps = append(steps, migrationStep{
+				up:          renderer.dropColumn(table, column),
+				down:        renderer.addColumn(table, column),
+				review:      fmt.Sprintf("删除列 %s.%s", table, column.Name),
+				destructive: true,
+			})
+		}
+	}
+
+	// 创建索引和外键
+	oldIndexes := make(map[string]*metadata.TableIndexMetadata)
+	for _, index := range before.Indexes {
+		oldIndexes[index.Name] = index
+	}
+	for _, index := range after.Indexes {
+		if prev, ok := oldIndexes[index.Name]; !ok || !sameIndex(prev, index) {
+			step := migrationStep{up: renderer.createIndex(table, index), down: renderer.dropIndex(table, index)}
+			if index.Unique {
+				step.review = fmt.Sprintf("新增唯一索引 %s.%s，已有数据可能不满足", table, index.Name)
+			}
+			steps = append(steps, step)
+		}
+	}
+	oldForeignKeys := make(map[string]*metadata.ForeignKeyMetadata)
+	for _, fk := range before.ForeignKeys {
+		oldForeignKeys[fk.Name] = fk
+	}
+	for _, fk := range after.ForeignKeys {
+		if prev, ok := oldForeignKeys[fk.Name]; !ok || *prev != *fk {
+			steps = append(steps, migrationStep{up: renderer.addForeignKey(table, fk), down: renderer.dropForeignKey(table, fk)})
+		}
+	}
+
+	return steps
+}
+
+// columnChanged 判断列的定义是否变化（列名、类型、可空性、默认值、自增、注释）
+func columnChanged(before, after *metadata.ColumnMetadata) bool {
+	return before.Name != after.Name || before.Type != after.Type || before.Nullable != after.Nullable ||
+		before.Default != after.Default || before.AutoIncrement != after.AutoIncrement || before.Comment != after.Comment
+}
+
+// sameIndex 判断两个索引的定义是否相同
+func sameIndex(a, b *metadata.TableIndexMetadata) bool {
+	return a.Unique == b.Unique && a.Where == b.Where && slices.Equal(a.Columns, b.Columns)
+}
+
+// alterMigration 由迁移步骤组装 ALTER 迁移，down 为 true 时按相反顺序取各步骤的逆操作
+//
+// 需要人工确认的步骤前加 ⚠️ 说明；可能丢失数据的步骤整体注释掉，确认后去掉注释再执行。
+func alterMigration(renderer ddlRenderer, steps []migrationStep, down bool) *MigrationData {
+	data := newMigration(renderer)
+	data.Review = slices.ContainsFunc(steps, func(step migrationStep) bool { return step.review != "" })
+
+	for i := range steps {
+		step := steps[i]
+		statements := step.up
+		if down {
+			step = steps[len(steps)-1-i]
+			statements = step.down
+		}
+		if statements == "" && (down || step.review == "") {
+			continue
+		}
+
+		// down 中只为注释掉的语句保留说明
+		migrationStep := &MigrationStep{Statements: statements}
+		switch {
+		case step.review != "" && !down:
+			migrationStep.Comment = "⚠️ " + step.review
+		case step.review != "" && step.destructive:
+			migrationStep.Comment = "⚠️ 撤销：" + step.review
+		}
+		if step.destructive {
+			migrationStep.Statements = commentOut(statements)
+		}
+		data.Steps = append(data.Steps, migrationStep)
+	}
+
+	return data
+}
+
+// commentOut 将语句逐行注释掉，已是注释的行保持不变
+func commentOut(statements string) string {
+	lines := strings.Split(strings.TrimSuffix(statements, "\n"), "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "--") {
+			lines[i] = "-- " + line
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,252 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"soliton/pkg/metadata"
+	"strconv"
+	"strings"
+)
+
+// MigrationGenerator 迁移脚本生成器
+//
+// 按表结构元数据生成与 golang-migrate 兼容的版本化迁移，每个版本是一对 up/down 文件。
+// 与 sql/schema.sql 不同，up 迁移不包含 DROP TABLE，可以直接在已有数据库上执行：
+//   - 表结构定义、主键、唯一索引和普通索引
+//   - 外键约束
+//   - 多对多关联表（ManyToManyTableMetadata）
+//
+// down 迁移按相反的顺序删除 up 迁移创建的表（索引和外键随表删除）。
+//
+// 生成文件：sql/migrations/0001_create_tables.up.sql、sql/migrations/0001_create_tables.down.sql，
+// 声明了 +soliton:context 的聚合根与 SQLGenerator 一样按限界上下文拆分到 {context}/sql/migrations。
+//
+// 目录中已有迁移时不再生成建表迁移。设置了基线（SetBaseline，通常由上次 -json 导出的元数据计算，
+// 代表数据库当前的表结构）时，比较基线与当前的表结构，以下一个版本号生成 ALTER 迁移，如 0002_alter_tables.up.sql：
+// 新增、删除和重命名表、列，修改列定义，增删索引和外键；删除表或列、修改列类型等可能丢失数据的语句
+// 生成时注释掉并标记 ⚠️，需要人工确认后启用，见 Reviews。
+//
+// 启用 outbox（SetOutbox）时，为有聚合根嵌入 framework.EventRecorder 的限界上下文以下一个版本号生成
+// 创建 outbox 表（framework.OutboxMessage）的迁移，如 0002_create_outbox.up.sql；目录中已有该迁移时不再生成。
+type MigrationGenerator struct {
+	fileOutput
+	sql      *SQLGenerator
+	baseline *metadata.Schema
+	outbox   bool
+	written  []string
+	reviews  []string
+}
+
+// NewMigrationGenerator 创建迁移脚本生成器，表名、列和索引与 sqlGenerator 生成的建表脚本一致
+func NewMigrationGenerator(sqlGenerator *SQLGenerator) *MigrationGenerator {
+	return &MigrationGenerator{
+		sql: sqlGenerator,
+	}
+}
+
+// SetBaseline 设置数据库当前的表结构，已有迁移的限界上下文据此生成 ALTER 迁移；应与建表脚本使用同一方言计算
+func (g *MigrationGenerator) SetBaseline(schema *metadata.Schema) {
+	g.baseline = schema
+}
+
+// SetOutbox 设置是否生成创建 outbox 表的迁移
+func (g *MigrationGenerator) SetOutbox(enabled bool) {
+	g.outbox = enabled
+}
+
+// Generate 为每个限界上下文生成建表迁移，已有迁移且设置了基线时生成 ALTER 迁移
+func (g *MigrationGenerator) Generate(outputDir string) error {
+	g.written = nil
+	g.reviews = nil
+
+	for _, boundedContext := range g.sql.Contexts() {
+		migrationDir := filepath.Join(outputDir, boundedContext, "sql", "migrations")
+
+		versions, err := migrationVersions(migrationDir)
+		if err != nil {
+			return fmt.Errorf("读取迁移目录失败: %w", err)
+		}
+
+		version, err := g.generateTables(migrationDir, boundedContext, versions)
+		if err != nil {
+			return err
+		}
+		if err := g.generateOutbox(migrationDir, boundedContext, version+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// generateTables 生成限界上下文的建表或 ALTER 迁移，返回生成后的最新版本号（没有迁移时为 0）
+func (g *MigrationGenerator) generateTables(migrationDir, boundedContext string, versions []int) (int, error) {
+	tables := g.sql.tables(boundedContext)
+	if len(versions) == 0 {
+		if err := g.writeMigration(migrationDir, boundedContext, 1, "create_tables", g.generateUp(tables), g.generateDown(tables)); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	}
+
+	version := versions[len(versions)-1]
+	if g.baseline == nil {
+		return version, nil
+	}
+
+	var previous []*metadata.TableMetadata
+	for _, table := range g.baseline.Tables {
+		if table.Context == boundedContext {
+			previous = append(previous, table)
+		}
+	}
+	renderer := g.sql.renderer()
+	steps := schemaChanges(renderer, previous, tables)
+	if len(steps) == 0 {
+		return version, nil
+	}
+
+	version++
+	if err := g.writeMigration(migrationDir, boundedContext, version, "alter_tables", alterMigration(renderer, steps, false), alterMigration(renderer, steps, true)); err != nil {
+		return 0, err
+	}
+	for _, step := range steps {
+		if step.review != "" {
+			g.reviews = append(g.reviews, step.review)
+		}
+	}
+	return version, nil
+}
+
+// generateOutbox 启用 outbox 时，为有聚合根记录领域事件的限界上下文生成版本号为 version 的 outbox 建表迁移
+func (g *MigrationGenerator) generateOutbox(migrationDir, boundedContext string, version int) error {
+	if !g.outbox || hasMigration(migrationDir, "create_outbox") {
+		return nil
+	}
+	recordsEvents := false
+	for _, agg := range g.sql.registry.GetByContext(boundedContext) {
+		recordsEvents = recordsEvents || agg.RecordsEvents
+	}
+	if !recordsEvents {
+		return nil
+	}
+
+	dialect, err := metadata.ParseDialect(g.sql.Schema().Dialect)
+	if err != nil {
+		return err
+	}
+	tables := []*metadata.TableMetadata{metadata.OutboxTable(dialect, boundedContext)}
+	return g.writeMigration(migrationDir, boundedContext, version, "create_outbox", g.generateUp(tables), g.generateDown(tables))
+}
+
+// Reviews 返回最近一次 Generate 生成的 ALTER 迁移中需要人工确认的变更，如 "删除列 orders.remark"
+func (g *MigrationGenerator) Reviews() []string {
+	return g.reviews
+}
+
+// Written 返回最近一次 Generate 写出的迁移文件（up 在前），没有生成迁移的限界上下文不在其中
+func (g *MigrationGenerator) Written() []string {
+	return g.written
+}
+
+// writeMigration 写出限界上下文 boundedContext 版本号为 version 的一对迁移文件，如 0001_create_tables.up.sql、0001_create_tables.down.sql
+func (g *MigrationGenerator) writeMigration(dir, boundedContext string, version int, description string, up, down *MigrationData) error {
+	for _, file := range []struct {
+		direction string
+		data      *MigrationData
+	}{{"up", up}, {"down", down}} {
+		filePath := filepath.Join(dir, fmt.Sprintf("%04d_%s.%s.sql", version, description, file.direction))
+		file.data.Aggregates, file.data.Context = g.sql.registry.GetByContext(boundedContext), boundedContext
+		if err := g.writeTemplate(filePath, "migration_"+file.direction, file.data); err != nil {
+			return fmt.Errorf("写入文件失败: %w", err)
+		}
+		g.written = append(g.written, filePath)
+	}
+	return nil
+}
+
+// hasMigration 判断迁移目录中是否已有描述为 description 的迁移，如 0002_create_outbox.up.sql
+func hasMigration(dir, description string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if match := migrationFilePattern.FindStringSubmatch(entry.Name()); match != nil &&
+			strings.HasPrefix(entry.Name(), match[1]+"_"+description+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// migrationFilePattern golang-migrate 的迁移文件名：{version}_{description}.{up|down}.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_.*\.(up|down)\.sql$`)
+
+// migrationVersions 返回迁移目录中已有的迁移版本（升序），目录不存在时返回空
+// 不符合 golang-migrate 命名的 .sql 文件也视为已有迁移，版本记为 0
+func migrationVersions(dir string) ([]int, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []int
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		version := 0
+		if match := migrationFilePattern.FindStringSubmatch(entry.Name()); match != nil {
+			version, _ = strconv.Atoi(match[1])
+		}
+		if !slices.Contains(versions, version) {
+			versions = append(versions, version)
+		}
+	}
+	slices.Sort(versions)
+	return versions, nil
+}
+
+// newMigration 返回按 renderer 的方言渲染、尚未添加步骤的迁移
+func newMigration(renderer ddlRenderer) *MigrationData {
+	return &MigrationData{Database: renderer.database(), Begin: renderer.begin(), End: renderer.end()}
+}
+
+// generateUp 生成创建 tables 的 up 迁移
+func (g *MigrationGenerator) generateUp(tables []*metadata.TableMetadata) *MigrationData {
+	renderer := g.sql.renderer()
+	data := newMigration(renderer)
+
+	// 聚合根表按聚合根名排列，外键可能引用后面的表：MySQL、SQLite 建表期间关闭外键检查，PostgreSQL 在建表后添加外键
+	for _, table := range tables {
+		title := table.Name
+		if table.Aggregate == "" && table.Name != metadata.OutboxTableName {
+			title += " (多对多关联表)"
+		}
+		data.Steps = append(data.Steps, &MigrationStep{Comment: title, Statements: renderer.createTable(table)})
+	}
+	data.ForeignKeys = foreignKeys(renderer, tables)
+
+	return data
+}
+
+// generateDown 生成删除 tables 的 down 迁移，按创建的相反顺序删除
+func (g *MigrationGenerator) generateDown(tables []*metadata.TableMetadata) *MigrationData {
+	renderer := g.sql.renderer()
+	data := newMigration(renderer)
+
+	var sb strings.Builder
+	for i := len(tables) - 1; i >= 0; i-- {
+		sb.WriteString(renderer.dropTable(tables[i]))
+	}
+	data.Steps = []*MigrationStep{{Statements: sb.String()}}
+
+	return data
+}
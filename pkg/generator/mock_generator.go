@@ -0,0 +1,186 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"soliton/pkg/metadata"
+	"strings"
+)
+
+// MockGenerator 模拟实现生成器
+//
+// 为每个聚合根的仓储接口和领域服务生成基于 testify mock.Mock 的模拟实现，供不连接数据库的单元测试使用：
+//   - {AggregateName}Repository：实现 repository.{AggregateName}Repository，包括泛型 Repository[T] 的方法和扩展方法
+//   - {AggregateName}Service：实现 framework.ServiceOf[*T, K]
+//
+// 每个方法以全部参数调用 m.Called，按位置取返回值，未设置或为 nil 的返回值取零值；可变参数以切片传入。
+// New{AggregateName}Repository(t) 和 New{AggregateName}Service(t) 在测试结束时断言期望的调用都已发生。
+//
+// 生成文件：domain/mocks/{AggregateName}Repository.go、domain/mocks/{AggregateName}Service.go
+type MockGenerator struct {
+	fileOutput
+	registry *metadata.AggregateMetadataRegistry
+}
+
+// NewMockGenerator 创建模拟实现生成器
+func NewMockGenerator() *MockGenerator {
+	return &MockGenerator{}
+}
+
+// SetRegistry 设置聚合根注册表，用于生成与仓储接口一致的关联实体加载方法
+func (g *MockGenerator) SetRegistry(registry *metadata.AggregateMetadataRegistry) {
+	g.registry = registry
+}
+
+// Generate 为聚合根生成仓储和领域服务的模拟实现
+func (g *MockGenerator) Generate(agg *metadata.AggregateMetadata, outputDir string) error {
+	absOutputDir, _ := filepath.Abs(outputDir)
+	mocksDir := filepath.Join(domainDir(agg, absOutputDir), "mocks")
+
+	files := []struct {
+		path, template string
+		data           *GoFileData
+	}{
+		{filepath.Join(mocksDir, agg.Name+"Repository.go"), "mock_repository", g.generateRepository(agg, absOutputDir)},
+		{filepath.Join(mocksDir, agg.Name+"Service.go"), "mock_service", g.generateService(agg)},
+	}
+	for _, file := range files {
+		if err := g.writeTemplate(file.path, file.template, file.data); err != nil {
+			return fmt.Errorf("写入文件失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// generateRepository 生成仓储接口的模拟实现的模板数据
+func (g *MockGenerator) generateRepository(agg *metadata.AggregateMetadata, absOutputDir string) *GoFileData {
+	name := agg.Name + "Repository"
+	entityType := fmt.Sprintf("*%s.%s", agg.PackageName, agg.Name)
+	repositories := &RepositoryInterfaceGenerator{registry: g.registry}
+	methods := append(repositoryOfMethods(entityType, qualifiedKeyType(agg)), repositories.extendMethods(agg)...)
+	if method := filterMethod(agg, "repository."); method != nil {
+		methods = append(methods, *method)
+	}
+
+	file := mockFile(agg, name, fmt.Sprintf("repository.%s 的模拟实现", name), "repository."+name, methods)
+	file.addImports("context", "time", agg.ImportPath,
+		calculateImportPath(agg.ModuleName, agg.ModuleRoot, filepath.Join(domainDir(agg, absOutputDir), "repository")),
+		"github.com/stretchr/testify/mock")
+	return file
+}
+
+// generateService 生成领域服务的模拟实现的模板数据
+func (g *MockGenerator) generateService(agg *metadata.AggregateMetadata) *GoFileData {
+	name := agg.Name + "Service"
+	entityType := fmt.Sprintf("*%s.%s", agg.PackageName, agg.Name)
+
+	file := mockFile(agg, name, fmt.Sprintf("%s 领域服务 %s 的模拟实现", agg.Name, serviceOfType(agg)), serviceOfType(agg),
+		serviceOfMethods(entityType, qualifiedKeyType(agg)))
+	file.addImports("context", agg.ImportPath, "soliton/pkg/framework", "github.com/stretchr/testify/mock")
+	return file
+}
+
+// mockFile 返回模拟类型 name 的文件数据：类型及其构造函数、实现接口 iface 的检查和各方法
+func mockFile(agg *metadata.AggregateMetadata, name, comment, iface string, methods []interfaceMethod) *GoFileData {
+	file := &GoFileData{
+		TemplateData: TemplateData{Aggregate: agg, Context: agg.Context()},
+		Package:      "mocks",
+	}
+	file.addType(name+" "+comment, name, "struct", &GoField{Type: "mock.Mock"})
+
+	var body strings.Builder
+	body.WriteString(fmt.Sprintf("\tm := &%s{}\n", name))
+	body.WriteString("\tm.Test(t)\n")
+	body.WriteString("\tt.Cleanup(func() { m.AssertExpectations(t) })\n")
+	body.WriteString("\treturn m\n")
+	file.addFunc(&GoFunc{
+		Doc:     fmt.Sprintf("New%s 创建 %s，测试结束时断言期望的调用都已发生", name, name),
+		Name:    "New" + name,
+		Params:  "t interface {\n\tmock.TestingT\n\tCleanup(func())\n}",
+		Results: "*" + name,
+		Body:    body.String(),
+	})
+
+	file.addValues(declVar, "", &GoField{Name: "_", Type: iface, Value: fmt.Sprintf("(*%s)(nil)", name)})
+	for _, method := range methods {
+		file.addFunc(mockMethod(name, method))
+	}
+	return file
+}
+
+// mockMethod 生成模拟类型 name 上的方法 method
+func mockMethod(name string, method interfaceMethod) *GoFunc {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("\targs := m.Called(%s)\n", strings.Join(method.Args, ", ")))
+
+	var results []string
+	for i, result := range method.Results {
+		if result == "error" {
+			results = append(results, fmt.Sprintf("args.Error(%d)", i))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\tr%d, _ := args.Get(%d).(%s)\n", i, i, result))
+		results = append(results, fmt.Sprintf("r%d", i))
+	}
+	sb.WriteString(fmt.Sprintf("\treturn %s\n", strings.Join(results, ", ")))
+
+	return &GoFunc{
+		Doc:      fmt.Sprintf("%s 模拟 %s.%s", method.Name, name, method.Name),
+		Receiver: "m *" + name,
+		Name:     method.Name,
+		Params:   method.Params,
+		Results:  method.results(),
+		Body:     sb.String(),
+	}
+}
+
+// repositoryOfMethods 返回 framework.RepositoryOf[T, K] 的方法，与 framework/repository.go 保持一致
+func repositoryOfMethods(entityType, keyType string) []interfaceMethod {
+	id := fmt.Sprintf("ctx context.Context, id %s", keyType)
+	ids := fmt.Sprintf("ctx context.Context, ids []%s", keyType)
+	entity := fmt.Sprintf("ctx context.Context, entity %s", entityType)
+	page := "ctx context.Context, page, pageSize int"
+	return []interfaceMethod{
+		{Name: "Add", Params: entity, Args: []string{"ctx", "entity"}, Results: []string{"error"}},
+		{Name: "AddBatch", Params: fmt.Sprintf("ctx context.Context, entities []%s, batchSize int", entityType), Args: []string{"ctx", "entities", "batchSize"}, Results: []string{"error"}},
+		{Name: "Update", Params: entity, Args: []string{"ctx", "entity"}, Results: []string{"error"}},
+		{Name: "UpdateBatch", Params: fmt.Sprintf("ctx context.Context, entities []%s", entityType), Args: []string{"ctx", "entities"}, Results: []string{"error"}},
+		{Name: "Delete", Params: id, Args: []string{"ctx", "id"}, Results: []string{"error"}},
+		{Name: "DeleteBatch", Params: ids, Args: []string{"ctx", "ids"}, Results: []string{"error"}},
+		{Name: "DeleteByIDs", Params: ids, Args: []string{"ctx", "ids"}, Results: []string{"int64", "error"}},
+		{Name: "Remove", Params: id, Args: []string{"ctx", "id"}, Results: []string{"error"}},
+		{Name: "RemoveBatch", Params: ids, Args: []string{"ctx", "ids"}, Results: []string{"error"}},
+		{Name: "RemoveByIDs", Params: ids, Args: []string{"ctx", "ids"}, Results: []string{"int64", "error"}},
+		{Name: "FindByID", Params: id, Args: []string{"ctx", "id"}, Results: []string{entityType, "error"}},
+		{Name: "FindByIDs", Params: ids, Args: []string{"ctx", "ids"}, Results: []string{fmt.Sprintf("map[%s]%s", keyType, entityType), "error"}},
+		{Name: "FindByIDWithDeleted", Params: id, Args: []string{"ctx", "id"}, Results: []string{entityType, "error"}},
+		{Name: "Restore", Params: id, Args: []string{"ctx", "id"}, Results: []string{"error"}},
+		{Name: "FindAllDeleted", Params: "ctx context.Context", Args: []string{"ctx"}, Results: []string{"[]" + entityType, "error"}},
+		{Name: "FindPageDeleted", Params: page, Args: []string{"ctx", "page", "pageSize"}, Results: []string{"[]" + entityType, "int64", "error"}},
+		{Name: "PurgeDeleted", Params: "ctx context.Context, olderThan time.Duration", Args: []string{"ctx", "olderThan"}, Results: []string{"int64", "error"}},
+		{Name: "FindAll", Params: "ctx context.Context", Args: []string{"ctx"}, Results: []string{"[]" + entityType, "error"}},
+		{Name: "FindPage", Params: page, Args: []string{"ctx", "page", "pageSize"}, Results: []string{"[]" + entityType, "int64", "error"}},
+		{Name: "Exists", Params: id, Args: []string{"ctx", "id"}, Results: []string{"bool", "error"}},
+	}
+}
+
+// serviceOfMethods 返回 framework.ServiceOf[T, K] 的方法，与 framework/service.go 保持一致
+func serviceOfMethods(entityType, keyType string) []interfaceMethod {
+	id := fmt.Sprintf("ctx context.Context, id %s", keyType)
+	ids := fmt.Sprintf("ctx context.Context, ids []%s", keyType)
+	entity := fmt.Sprintf("ctx context.Context, entity %s", entityType)
+	return []interfaceMethod{
+		{Name: "Add", Params: entity, Args: []string{"ctx", "entity"}, Results: []string{"error"}},
+		{Name: "AddBatch", Params: fmt.Sprintf("ctx context.Context, entities []%s, batchSize int", entityType), Args: []string{"ctx", "entities", "batchSize"}, Results: []string{"error"}},
+		{Name: "Update", Params: entity, Args: []string{"ctx", "entity"}, Results: []string{"error"}},
+		{Name: "Delete", Params: id, Args: []string{"ctx", "id"}, Results: []string{"error"}},
+		{Name: "DeleteByIDs", Params: ids, Args: []string{"ctx", "ids"}, Results: []string{"int64", "error"}},
+		{Name: "Restore", Params: id, Args: []string{"ctx", "id"}, Results: []string{"error"}},
+		{Name: "GetByID", Params: id, Args: []string{"ctx", "id"}, Results: []string{entityType, "error"}},
+		{Name: "GetByIDs", Params: ids, Args: []string{"ctx", "ids"}, Results: []string{fmt.Sprintf("map[%s]%s", keyType, entityType), "error"}},
+		{Name: "GetAll", Params: "ctx context.Context", Args: []string{"ctx"}, Results: []string{"[]" + entityType, "error"}},
+		{Name: "GetAllDeleted", Params: "ctx context.Context", Args: []string{"ctx"}, Results: []string{"[]" + entityType, "error"}},
+		{Name: "GetPage", Params: "ctx context.Context, page, pageSize int", Args: []string{"ctx", "page", "pageSize"}, Results: []string{"[]" + entityType, "int64", "error"}},
+		{Name: "Exists", Params: id, Args: []string{"ctx", "id"}, Results: []string{"bool", "error"}},
+	}
+}
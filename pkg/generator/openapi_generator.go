@@ -0,0 +1,376 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"soliton/pkg/metadata"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OpenAPIGenerator OpenAPI 3 接口文档生成器
+//
+// 描述 HTTPHandlerGenerator 生成的 REST 接口，与处理器共用暴露范围、操作、路径参数和请求/响应字段的计算，
+// 二者始终一致：
+//   - paths：各聚合根启用的 CRUD 操作，分页查询的 page、pageSize 参数，以及各操作可能返回的错误状态码
+//   - components.schemas：请求体 Create{AggregateName}Request、Update{AggregateName}Request，响应 {AggregateName}Response、
+//     分页响应 {AggregateName}Page、枚举（+soliton:enum）、值对象和统一的错误响应 ErrorResponse
+//
+// 值对象的属性名取自 json 标签，未声明时与 encoding/json 一致使用字段名；找不到结构体定义的值对象描述为任意对象。
+//
+// 生成文件：interfaces/openapi.yaml，包含全部限界上下文的接口。
+type OpenAPIGenerator struct {
+	fileOutput
+	registry *metadata.AggregateMetadataRegistry
+
+	schemas      map[string][]string                  // 本次生成收集的枚举和值对象 Schema，键为 Schema 名
+	valueObjects map[string][]*metadata.FieldMetadata // 本次生成涉及的值对象的字段，键为类型名，如 Address
+}
+
+// NewOpenAPIGenerator 创建 OpenAPI 文档生成器
+func NewOpenAPIGenerator() *OpenAPIGenerator {
+	return &OpenAPIGenerator{}
+}
+
+// SetRegistry 设置聚合根注册表，用于读取字段对应的枚举
+// 未设置时枚举字段按底层类型描述
+func (g *OpenAPIGenerator) SetRegistry(registry *metadata.AggregateMetadataRegistry) {
+	g.registry = registry
+}
+
+// Generate 为对外暴露的聚合根 aggregates 生成 openapi.yaml
+func (g *OpenAPIGenerator) Generate(aggregates []*metadata.AggregateMetadata, outputDir string) error {
+	if len(aggregates) == 0 {
+		return fmt.Errorf("没有对外暴露的聚合根")
+	}
+
+	absOutputDir, _ := filepath.Abs(outputDir)
+	filePath := filepath.Join(filepath.Dir(absOutputDir), "interfaces", "openapi.yaml")
+
+	if err := g.writeTemplate(filePath, "openapi", g.generateDocument(aggregates)); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return nil
+}
+
+// 操作可能返回的错误响应
+var (
+	responseBadRequest = &APIResponse{"400", "BadRequest"}
+	responseNotFound   = &APIResponse{"404", "NotFound"}
+	responseConflict   = &APIResponse{"409", "Conflict"}
+	responseValidation = &APIResponse{"422", "ValidationFailed"}
+	responseInternal   = &APIResponse{"500", "InternalError"}
+)
+
+// generateDocument 生成完整的文档
+func (g *OpenAPIGenerator) generateDocument(aggregates []*metadata.AggregateMetadata) *OpenAPIData {
+	g.schemas = make(map[string][]string)
+	g.valueObjects = make(map[string][]*metadata.FieldMetadata)
+	for _, agg := range aggregates {
+		collectValueObjects(g.valueObjects, agg.MappedFields())
+	}
+
+	data := &OpenAPIData{
+		TemplateData: TemplateData{Aggregates: aggregates},
+		Title:        filepath.Base(aggregates[0].ModuleRoot) + " API",
+	}
+	for _, agg := range aggregates {
+		data.Resources = append(data.Resources, g.generateResource(agg))
+	}
+
+	// 枚举和值对象按名称排序
+	for name, fields := range g.valueObjects {
+		g.schemas[name] = g.valueObjectSchema(fields)
+	}
+	names := make([]string, 0, len(g.schemas))
+	for name := range g.schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data.Schemas = append(data.Schemas, &APISchema{Name: name, Schema: g.schemas[name]})
+	}
+
+	return data
+}
+
+// generateResource 生成聚合根的路径、操作和请求、响应 Schema
+func (g *OpenAPIGenerator) generateResource(agg *metadata.AggregateMetadata) *APIResource {
+	ops := apiOperations(agg)
+	resource := &APIResource{
+		Name:       agg.Name,
+		Collection: agg.APIPath(),
+		Item:       agg.APIPath(),
+		List:       slices.Contains(ops, metadata.APIOpList),
+	}
+	for _, param := range keyParams(agg) {
+		resource.Item += "/{" + param.name + "}"
+		resource.Keys = append(resource.Keys, &APIProperty{Name: param.name, Schema: g.keySchema(agg, param)})
+	}
+
+	operation := func(method, op, summary, request, status, response string, errors ...*APIResponse) *APIOperation {
+		return &APIOperation{
+			Method:   method,
+			Tag:      agg.Name,
+			ID:       op + agg.Name,
+			Summary:  summary + " " + agg.Name,
+			Paged:    op == metadata.APIOpList,
+			Request:  request,
+			Status:   status,
+			Response: response,
+			Errors:   errors,
+		}
+	}
+	if resource.List {
+		resource.CollectionOperations = append(resource.CollectionOperations, operation("get", "list", "分页查询", "", "200", agg.Name+"Page",
+			responseBadRequest, responseInternal))
+	}
+	if slices.Contains(ops, metadata.APIOpCreate) {
+		resource.CollectionOperations = append(resource.CollectionOperations, operation("post", "create", "新增", "Create"+agg.Name+"Request", "201", agg.Name+"Response",
+			responseBadRequest, responseConflict, responseValidation, responseInternal))
+		resource.Create = &APIObject{Description: "未出现的字段取默认值", Properties: g.properties(agg, requestFields(agg), false)}
+	}
+	if slices.Contains(ops, metadata.APIOpGet) {
+		resource.ItemOperations = append(resource.ItemOperations, operation("get", "get", "按主键查询", "", "200", agg.Name+"Response",
+			responseBadRequest, responseNotFound, responseInternal))
+	}
+	if slices.Contains(ops, metadata.APIOpUpdate) {
+		resource.ItemOperations = append(resource.ItemOperations, operation("put", "update", "更新", "Update"+agg.Name+"Request", "200", agg.Name+"Response",
+			responseBadRequest, responseNotFound, responseConflict, responseValidation, responseInternal))
+		resource.Update = &APIObject{Description: "未出现的字段保持原值，主键和不可变字段不可修改", Properties: g.properties(agg, updateFields(agg), false)}
+	}
+	if slices.Contains(ops, metadata.APIOpDelete) {
+		resource.ItemOperations = append(resource.ItemOperations, operation("delete", "delete", "删除", "", "204", "",
+			responseBadRequest, responseNotFound, responseConflict, responseInternal))
+	}
+	resource.Response = &APIObject{Properties: g.properties(agg, responseDTOFields(agg), true)}
+
+	return resource
+}
+
+// properties 返回请求或响应的属性，属性名与生成的结构体的 json 标签一致；response 为 true 时说明敏感字段已脱敏
+func (g *OpenAPIGenerator) properties(agg *metadata.AggregateMetadata, fields []*dtoField, response bool) []*APIProperty {
+	var properties []*APIProperty
+	for _, f := range fields {
+		schema := g.fieldSchema(agg, f.field)
+		if response && isSensitiveDTOField(f) {
+			schema = append(schema, "description: 敏感字段，返回脱敏后的值")
+		}
+		properties = append(properties, &APIProperty{Name: jsonName(f.field.Name), Schema: schema})
+	}
+	return properties
+}
+
+// keySchema 返回路径中主键参数的 Schema
+func (g *OpenAPIGenerator) keySchema(agg *metadata.AggregateMetadata, param pathParam) []string {
+	if param.field != nil {
+		return g.typeSchema(param.field.BasicType())
+	}
+	if agg.IDKeyType() == "string" {
+		return []string{"type: string"}
+	}
+	return []string{"type: integer", "format: int64"}
+}
+
+// fieldSchema 返回字段的 Schema：指针可为 null，切片和数组为 array，map 为 object
+func (g *OpenAPIGenerator) fieldSchema(agg *metadata.AggregateMetadata, field *metadata.FieldMetadata) []string {
+	switch {
+	case field.IsSlice && field.Type == "byte":
+		return []string{"type: string", "format: byte"}
+	case field.IsSlice, field.IsArray:
+		lines := []string{"type: array", "items:"}
+		lines = append(lines, indentYAML(g.elementSchema(agg, field, field.Type, field.IsPointer))...)
+		if size, err := strconv.Atoi(field.ArrayLen); err == nil {
+			lines = append(lines, fmt.Sprintf("minItems: %d", size), fmt.Sprintf("maxItems: %d", size))
+		}
+		return lines
+	case field.IsMap:
+		lines := []string{"type: object", "additionalProperties:"}
+		valueType := strings.TrimPrefix(field.MapValueType, "*")
+		return append(lines, indentYAML(g.elementSchema(agg, field, valueType, valueType != field.MapValueType))...)
+	}
+	return g.elementSchema(agg, field, field.BasicType(), field.IsPointer)
+}
+
+// elementSchema 返回字段（或其元素）类型 goType 的 Schema，nullable 为 true 时可为 null
+func (g *OpenAPIGenerator) elementSchema(agg *metadata.AggregateMetadata, field *metadata.FieldMetadata, goType string, nullable bool) []string {
+	schema := g.typeSchema(goType)
+	if enum := g.enumOf(agg, field); enum != nil && !field.IsMap {
+		g.collectEnum(enum)
+		schema = []string{fmt.Sprintf("$ref: '#/components/schemas/%s'", enum.Name)}
+	}
+	if !nullable {
+		return schema
+	}
+	// OpenAPI 3.0 中 $ref 的同级属性会被忽略，可为 null 的引用需要包一层 allOf
+	if strings.HasPrefix(schema[0], "$ref: ") {
+		return []string{"nullable: true", "allOf:", "  - " + schema[0]}
+	}
+	return append(schema, "nullable: true")
+}
+
+// typeSchema 返回 Go 类型的 Schema，与 encoding/json 的编码结果一致
+func (g *OpenAPIGenerator) typeSchema(goType string) []string {
+	switch goType {
+	case "string":
+		return []string{"type: string"}
+	case "bool":
+		return []string{"type: boolean"}
+	case "int", "uint":
+		return []string{"type: integer"}
+	case "int8", "int16", "int32", "uint8", "uint16", "byte", "rune":
+		return []string{"type: integer", "format: int32"}
+	case "int64", "uint32", "uint64":
+		return []string{"type: integer", "format: int64"}
+	case "float32":
+		return []string{"type: number", "format: float"}
+	case "float64":
+		return []string{"type: number", "format: double"}
+	case "time.Time":
+		return []string{"type: string", "format: date-time"}
+	case "time.Duration":
+		return []string{"type: integer", "format: int64", "description: 纳秒"}
+	case "uuid.UUID":
+		return []string{"type: string", "format: uuid"}
+	case "decimal.Decimal":
+		return []string{"type: string", "format: decimal"}
+	case "json.RawMessage":
+		return []string{"description: 任意 JSON"}
+	}
+
+	// sql.NullString 等编码为 {"String": ..., "Valid": ...}
+	if valueType, ok := strings.CutPrefix(goType, "sql.Null"); ok {
+		lines := []string{"type: object", "properties:", "  " + valueType + ":"}
+		lines = append(lines, indentYAML(indentYAML(g.typeSchema(nullValueType(valueType))))...)
+		return append(lines, "  Valid:", "    type: boolean")
+	}
+
+	name := goType[strings.LastIndex(goType, ".")+1:]
+	if _, ok := g.valueObjects[name]; ok {
+		return []string{fmt.Sprintf("$ref: '#/components/schemas/%s'", name)}
+	}
+	return []string{"description: " + yamlString(goType+" 的 JSON 编码")}
+}
+
+// nullValueType 返回 sql.NullXxx 中值的类型，如 String → string、Time → time.Time
+func nullValueType(name string) string {
+	if name == "Time" {
+		return "time.Time"
+	}
+	return strings.ToLower(name)
+}
+
+// enumOf 返回聚合根字段对应的枚举，不是枚举字段或 agg 为 nil（值对象的字段）时返回 nil
+func (g *OpenAPIGenerator) enumOf(agg *metadata.AggregateMetadata, field *metadata.FieldMetadata) *metadata.EnumMetadata {
+	if g.registry == nil || agg == nil {
+		return nil
+	}
+	for _, enum := range g.registry.GetEnums() {
+		if enum.AggregateName == agg.Name && enum.FieldName == field.Name {
+			return enum
+		}
+	}
+	return nil
+}
+
+// collectEnum 收集枚举的 Schema：字符串枚举的值为字符串，整数枚举的值为编码，值的名称和说明写在 description 中
+func (g *OpenAPIGenerator) collectEnum(enum *metadata.EnumMetadata) {
+	if _, ok := g.schemas[enum.Name]; ok {
+		return
+	}
+
+	schemaType, values := "string", make([]string, len(enum.Values))
+	for i, value := range enum.Values {
+		values[i] = yamlString(value)
+	}
+	if enum.IsInt() {
+		schemaType, values = "integer", enum.Values
+	}
+
+	lines := []string{"type: " + schemaType, fmt.Sprintf("enum: [%s]", strings.Join(values, ", "))}
+	var descriptions []string
+	for i, value := range enum.Values {
+		if description := enumValueDescription(enum, i); description != value {
+			descriptions = append(descriptions, description)
+		}
+	}
+	if len(descriptions) > 0 {
+		lines = append(lines, "description: "+yamlString(strings.Join(descriptions, "；")))
+	}
+	g.schemas[enum.Name] = lines
+}
+
+// enumValueDescription 返回枚举第 i 个值的说明：值、与值不同的常量名和标签，如 "1 ACTIVE：正常"、"PENDING"
+func enumValueDescription(enum *metadata.EnumMetadata, i int) string {
+	description := enum.Values[i]
+	if name := enum.ValueName(i); name != enum.Values[i] {
+		description += " " + name
+	}
+	if label := enum.ValueLabel(i); label != "" {
+		description += "：" + label
+	}
+	return description
+}
+
+// collectValueObjects 将字段中的值对象（含值对象内嵌套的值对象）的字段收集到 valueObjects，键为类型名，如 Address
+// 没有结构体定义的值对象不收集
+func collectValueObjects(valueObjects map[string][]*metadata.FieldMetadata, fields []*metadata.FieldMetadata) {
+	for _, field := range fields {
+		if !field.Annotations.IsValueObject {
+			continue
+		}
+		members := field.ValueFields
+		if field.Annotations.Strategy == metadata.ValueObjectFlatten {
+			members = field.Flattened
+		}
+		name := elementType(field)
+		name = name[strings.LastIndex(name, ".")+1:]
+		if _, ok := valueObjects[name]; ok || len(members) == 0 {
+			continue
+		}
+		valueObjects[name] = members
+		collectValueObjects(valueObjects, members)
+	}
+}
+
+// valueObjectSchema 返回值对象的 Schema
+// 属性名取自 json 标签，未声明时为字段名；json:"-" 的字段不参与编码
+func (g *OpenAPIGenerator) valueObjectSchema(fields []*metadata.FieldMetadata) []string {
+	lines := []string{"type: object", "properties:"}
+	for _, field := range fields {
+		property := field.Name
+		if field.JSONTag == "-" {
+			continue
+		}
+		if field.JSONTag != "" {
+			property = field.JSONTag
+		}
+		lines = append(lines, "  "+property+":")
+		lines = append(lines, indentYAML(indentYAML(g.fieldSchema(nil, field)))...)
+	}
+	return lines
+}
+
+// elementType 返回字段的元素类型（去掉指针、切片、数组、map），如 map[string]*Address → Address
+func elementType(field *metadata.FieldMetadata) string {
+	if field.IsMap {
+		return strings.TrimPrefix(field.MapValueType, "*")
+	}
+	return field.Type
+}
+
+// indentYAML 将 YAML 行缩进一级（两个空格）
+func indentYAML(lines []string) []string {
+	indented := make([]string, len(lines))
+	for i, line := range lines {
+		indented[i] = "  " + line
+	}
+	return indented
+}
+
+// yamlString 返回 YAML 双引号字符串
+func yamlString(value string) string {
+	return strconv.Quote(value)
+}
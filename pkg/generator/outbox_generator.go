@@ -0,0 +1,57 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// 支持的 outbox 消息队列
+const (
+	OutboxBrokerKafka = "kafka"
+	OutboxBrokerNATS  = "nats"
+)
+
+// OutboxBrokers 支持的 outbox 消息队列
+var OutboxBrokers = []string{OutboxBrokerKafka, OutboxBrokerNATS}
+
+// OutboxGenerator 事务性 outbox 中继生成器
+//
+// 生成将 outbox 表中的领域事件发布到消息队列的 Publisher（实现 framework.OutboxPublisher）
+// 和创建中继的 NewRelay，应用通过 relay.Run(ctx) 运行中继：
+//   - kafka：基于 github.com/segmentio/kafka-go，消息键为聚合根 ID，同一聚合根的事件写入同一分区
+//   - nats：基于 github.com/nats-io/nats.go/jetstream，以 outbox 消息 ID 作为 Nats-Msg-Id，由 stream 去重
+//
+// outbox 表的迁移由 MigrationGenerator 生成，仓储写入 outbox 的设置由 RepositoryImplGenerator 生成。
+//
+// 生成文件：infrastructure/outbox/relay.go
+type OutboxGenerator struct {
+	fileOutput
+	broker string
+}
+
+// NewOutboxGenerator 创建 outbox 中继生成器，默认使用 Kafka
+func NewOutboxGenerator() *OutboxGenerator {
+	return &OutboxGenerator{broker: OutboxBrokerKafka}
+}
+
+// SetBroker 设置消息队列，见 OutboxBrokers
+func (g *OutboxGenerator) SetBroker(broker string) error {
+	if !slices.Contains(OutboxBrokers, broker) {
+		return fmt.Errorf("不支持的 outbox 消息队列 %s，可选 %s", broker, strings.Join(OutboxBrokers, "、"))
+	}
+	g.broker = broker
+	return nil
+}
+
+// Generate 生成 outbox 中继，模板为 outbox_{broker}，如 outbox_kafka
+func (g *OutboxGenerator) Generate(outputDir string) error {
+	absOutputDir, _ := filepath.Abs(outputDir)
+	filePath := filepath.Join(filepath.Dir(absOutputDir), "infrastructure", "outbox", "relay.go")
+
+	if err := g.writeTemplate(filePath, "outbox_"+g.broker, &OutboxData{Broker: g.broker}); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package generator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"soliton/pkg/metadata"
+	"sort"
+	"sync"
+)
+
+// PluginProtocolVersion 外部进程插件的协议版本，见 PluginRequest
+const PluginProtocolVersion = 1
+
+// execPluginPrefix 外部进程插件可执行文件名的前缀，插件 audit 对应 PATH 中的 soliton-gen-audit
+const execPluginPrefix = "soliton-gen-"
+
+// Generator 代码生成插件
+//
+// 插件读取聚合根注册表，返回要写出的文件，由 soliton 统一写出（支持 -dry-run）。
+// 插件有两种形式：
+//   - 编译期插件：在包的 init 中调用 RegisterPlugin 注册，通过空导入编译进自定义的 soliton 命令
+//   - 外部进程插件：可执行文件，通过标准输入输出交换 JSON，见 ExecPlugin
+type Generator interface {
+	// Name 返回插件名，即 -plugin 使用的名称
+	Name() string
+	// Generate 为注册表中的聚合根生成文件
+	Generate(registry *metadata.AggregateMetadataRegistry, config *PluginConfig) ([]File, error)
+}
+
+// File 插件生成的文件
+type File struct {
+	Path    string `json:"path"`    // 相对工程根目录的路径，如 infrastructure/audit/order.go
+	Content string `json:"content"` // 文件内容
+}
+
+// PluginConfig 传给插件的生成配置
+type PluginConfig struct {
+	Root    string            `json:"root"`              // 工程根目录（domain、infrastructure 所在目录）的绝对路径
+	Dialect string            `json:"dialect"`           // 数据库方言，如 mysql
+	Options map[string]string `json:"options,omitempty"` // 插件参数（-plugin-opt {插件名}:{参数}={值}）
+}
+
+var (
+	pluginsMu sync.RWMutex
+	plugins   = make(map[string]Generator)
+)
+
+// RegisterPlugin 注册编译期插件，通常在插件包的 init 中调用
+// 插件名为空或已注册时 panic
+func RegisterPlugin(plugin Generator) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+
+	name := plugin.Name()
+	if name == "" {
+		panic("generator: 插件名不能为空")
+	}
+	if _, ok := plugins[name]; ok {
+		panic(fmt.Sprintf("generator: 插件 %s 重复注册", name))
+	}
+	plugins[name] = plugin
+}
+
+// RegisteredPlugins 返回已注册的编译期插件名（按名称排序）
+func RegisteredPlugins() []string {
+	pluginsMu.RLock()
+	defer pluginsMu.RUnlock()
+
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LookupPlugin 按名称查找插件：优先返回 RegisterPlugin 注册的插件，否则在 PATH 中查找可执行文件 soliton-gen-{name}
+func LookupPlugin(name string) (Generator, error) {
+	pluginsMu.RLock()
+	plugin, ok := plugins[name]
+	pluginsMu.RUnlock()
+	if ok {
+		return plugin, nil
+	}
+
+	path, err := exec.LookPath(execPluginPrefix + name)
+	if err != nil {
+		return nil, fmt.Errorf("插件 %s 未注册，PATH 中也没有 %s%s", name, execPluginPrefix, name)
+	}
+	return NewExecPlugin(name, path), nil
+}
+
+// PluginRequest 写入外部进程插件标准输入的 JSON
+type PluginRequest struct {
+	Version  int                                 `json:"version"`  // 协议版本，见 PluginProtocolVersion
+	Plugin   string                              `json:"plugin"`   // 插件名
+	Config   *PluginConfig                       `json:"config"`   // 生成配置
+	Metadata *metadata.AggregateMetadataRegistry `json:"metadata"` // 元数据，与 -json 导出的内容相同，可通过 metadata.LoadFromJSON 加载
+}
+
+// PluginResponse 外部进程插件写入标准输出的 JSON
+type PluginResponse struct {
+	Files []File `json:"files"`           // 要写出的文件
+	Error string `json:"error,omitempty"` // 生成失败的原因，不为空时忽略 Files
+}
+
+// ExecPlugin 外部进程插件
+//
+// 每次生成启动一次插件进程：向标准输入写入 PluginRequest，从标准输出读取 PluginResponse；
+// 插件的标准错误转发到 soliton 的标准错误，可用于输出日志。插件以非零状态退出时生成失败。
+type ExecPlugin struct {
+	name string
+	path string
+}
+
+// NewExecPlugin 创建以可执行文件 path 运行的插件 name
+func NewExecPlugin(name, path string) *ExecPlugin {
+	return &ExecPlugin{name: name, path: path}
+}
+
+// Name 返回插件名
+func (p *ExecPlugin) Name() string {
+	return p.name
+}
+
+// Generate 运行插件进程生成文件
+func (p *ExecPlugin) Generate(registry *metadata.AggregateMetadataRegistry, config *PluginConfig) ([]File, error) {
+	request, err := json.Marshal(&PluginRequest{
+		Version:  PluginProtocolVersion,
+		Plugin:   p.name,
+		Config:   config,
+		Metadata: registry,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("序列化插件请求失败: %w", err)
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.Command(p.path)
+	cmd.Stdin = bytes.NewReader(request)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("运行插件 %s 失败: %w", p.path, err)
+	}
+
+	var response PluginResponse
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return nil, fmt.Errorf("解析插件输出失败: %w", err)
+	}
+	if response.Error != "" {
+		return nil, fmt.Errorf("%s", response.Error)
+	}
+	return response.Files, nil
+}
+
+// PluginGenerator 运行插件并写出插件生成的文件
+//
+// 插件返回的路径相对工程根目录，不能是绝对路径，也不能通过 .. 指向工程根目录之外。
+type PluginGenerator struct {
+	fileOutput
+	registry *metadata.AggregateMetadataRegistry
+	dialect  string
+}
+
+// NewPluginGenerator 创建插件生成器
+func NewPluginGenerator(registry *metadata.AggregateMetadataRegistry) *PluginGenerator {
+	return &PluginGenerator{registry: registry}
+}
+
+// SetDialect 设置传给插件的数据库方言，与建表脚本和迁移的方言一致
+func (g *PluginGenerator) SetDialect(dialect string) {
+	g.dialect = dialect
+}
+
+// Generate 以参数 options 运行插件，返回写出的文件路径；工程根目录为 outputDir 的上级目录
+func (g *PluginGenerator) Generate(plugin Generator, options map[string]string, outputDir string) ([]string, error) {
+	absOutputDir, _ := filepath.Abs(outputDir)
+	config := &PluginConfig{Root: filepath.Dir(absOutputDir), Dialect: g.dialect, Options: options}
+
+	files, err := plugin.Generate(g.registry, config)
+	if err != nil {
+		return nil, fmt.Errorf("插件 %s 生成失败: %w", plugin.Name(), err)
+	}
+
+	written := make([]string, 0, len(files))
+	for _, file := range files {
+		if !filepath.IsLocal(filepath.FromSlash(file.Path)) {
+			return written, fmt.Errorf("插件 %s 生成的文件路径 %q 不在工程根目录中", plugin.Name(), file.Path)
+		}
+		filePath := filepath.Join(config.Root, filepath.FromSlash(file.Path))
+		if err := g.writeFile(filePath, file.Content); err != nil {
+			return written, fmt.Errorf("写入文件失败: %w", err)
+		}
+		written = append(written, filePath)
+	}
+	return written, nil
+}
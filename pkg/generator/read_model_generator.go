@@ -0,0 +1,530 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"soliton/pkg/metadata"
+	"strings"
+)
+
+// ReadModelGenerator 读模型（CQRS 的读侧）生成器
+//
+// 为声明了 +soliton:query 的聚合根生成：
+//   - domain/readmodel/{AggregateName}Queries.go：每个查询的参数 {Query}Query（条件字段，分页查询还有 Page、PageSize）、
+//     投影查询的结果 {Query}View、只读仓储接口 {AggregateName}ReadRepository 和查询处理器 {Query}Handler
+//   - infrastructure/repository/{AggregateName}ReadRepositoryImpl.go：只读仓储实现，投影查询只 SELECT 投影字段的列，
+//     不加载整个聚合根；没有 select 的查询返回聚合根（不含关联实体）
+//
+// 条件字段恰好覆盖主键或唯一索引（QueryMetadata.IsSingle）的查询返回一条记录，不存在时返回 framework.ErrRecordNotFound；
+// 其他查询分页返回，处理器按 framework.NormalizePage 补全分页参数后返回 framework.PageResponse。
+// 同一限界上下文的读模型生成到同一个包中，查询名在上下文内唯一（见 RelationAnalyzer.ValidateQueries）。
+//
+// 声明了 +soliton:context 的聚合根输出到对应上下文的子目录，如 domain/ordering/readmodel。
+type ReadModelGenerator struct {
+	fileOutput
+}
+
+// NewReadModelGenerator 创建读模型生成器
+func NewReadModelGenerator() *ReadModelGenerator {
+	return &ReadModelGenerator{}
+}
+
+// Generate 为聚合根生成读模型和只读仓储实现，没有声明查询时不写文件
+func (g *ReadModelGenerator) Generate(agg *metadata.AggregateMetadata, outputDir string) error {
+	queries := readQueries(agg)
+	if len(queries) == 0 {
+		return nil
+	}
+
+	absOutputDir, _ := filepath.Abs(outputDir)
+	readModelDir := readModelDir(agg, absOutputDir)
+	infraDir := infrastructureDir(agg, absOutputDir)
+	imports := &readRepoImports{
+		readModel: calculateImportPath(agg.ModuleName, agg.ModuleRoot, readModelDir),
+		convertor: calculateImportPath(agg.ModuleName, agg.ModuleRoot, filepath.Join(infraDir, "convertor")),
+		do:        calculateImportPath(agg.ModuleName, agg.ModuleRoot, filepath.Join(infraDir, "do")),
+	}
+
+	files := []struct {
+		path, template string
+		data           *GoFileData
+	}{
+		{filepath.Join(readModelDir, agg.Name+"Queries.go"), "read_model_queries", g.generateQueries(agg, queries)},
+		{filepath.Join(infraDir, "repository", agg.Name+"ReadRepositoryImpl.go"), "read_model_repository", g.generateRepository(agg, queries, imports)},
+	}
+	for _, file := range files {
+		if err := g.writeTemplate(file.path, file.template, file.data); err != nil {
+			return fmt.Errorf("写入文件失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// readModelDir 返回聚合根读模型所在的目录，如 domain/ordering/readmodel
+func readModelDir(agg *metadata.AggregateMetadata, outputDir string) string {
+	return filepath.Join(domainDir(agg, outputDir), "readmodel")
+}
+
+// readRepoImports 只读仓储实现的 import 路径
+type readRepoImports struct {
+	readModel string
+	convertor string
+	do        string
+}
+
+// readQuery 生成读模型的一个查询
+type readQuery struct {
+	*metadata.QueryMetadata
+	by     []*readField // 条件字段
+	view   []*readField // 投影字段，不是投影查询时为空
+	single bool         // 至多返回一条记录
+}
+
+// readField 查询条件或投影中的字段
+type readField struct {
+	name   string                  // 查询结构体或视图中的字段名，如 Status、AddressCity
+	field  *metadata.FieldMetadata // 字段，展开的值对象中的字段为其子字段
+	parent *metadata.FieldMetadata // 展开的值对象中的字段所属的值对象字段，顶层字段为 nil
+	goType string                  // 带包名的类型，条件字段为指针字段的元素类型
+}
+
+// readQueries 返回聚合根上声明的查询，引用了不存在字段的查询已由校验报告，这里跳过
+func readQueries(agg *metadata.AggregateMetadata) []*readQuery {
+	var queries []*readQuery
+	for _, query := range agg.Queries {
+		q := &readQuery{QueryMetadata: query, single: query.IsSingle(agg)}
+		valid := true
+		for _, path := range query.By {
+			field := readFieldOf(agg, path, false)
+			valid = valid && field != nil
+			q.by = append(q.by, field)
+		}
+		for _, path := range query.Select {
+			field := readFieldOf(agg, path, true)
+			valid = valid && field != nil
+			q.view = append(q.view, field)
+		}
+		for _, order := range query.Orders() {
+			valid = valid && agg.FieldByPath(order.Field) != nil
+		}
+		if valid {
+			queries = append(queries, q)
+		}
+	}
+	return queries
+}
+
+// readFieldOf 按字段路径返回查询中的字段，projected 表示投影字段（保留指针类型），字段不存在时返回 nil
+func readFieldOf(agg *metadata.AggregateMetadata, path string, projected bool) *readField {
+	field := agg.FieldByPath(path)
+	if field == nil {
+		return nil
+	}
+
+	f := &readField{name: strings.ReplaceAll(path, ".", ""), field: field}
+	if name, _, nested := strings.Cut(path, "."); nested {
+		f.parent = agg.FieldByPath(name)
+	}
+	goType := field.Type
+	if projected {
+		goType = field.GoType()
+	}
+	f.goType = qualifyType(goType, agg.PackageName)
+	return f
+}
+
+// columns 返回字段对应的列，展开的值对象为其各字段的列
+func (f *readField) columns() []string {
+	if f.field.Annotations.IsValueObject && f.field.Annotations.Strategy == metadata.ValueObjectFlatten {
+		columns := make([]string, len(f.field.Flattened))
+		for i, sub := range f.field.Flattened {
+			columns[i] = sub.Column()
+		}
+		return columns
+	}
+	return []string{f.field.Column()}
+}
+
+// accessor 返回从聚合根 entity 读取字段的表达式，如 entity.Address.City
+func (f *readField) accessor() string {
+	if f.parent != nil {
+		return fmt.Sprintf("entity.%s.%s", f.parent.Name, f.field.Name)
+	}
+	return "entity." + f.field.Name
+}
+
+// viewName 返回投影查询的结果类型名
+func (q *readQuery) viewName() string {
+	return q.Name + "View"
+}
+
+// resultType 返回查询的单条结果类型，pkg 为读模型包的引用前缀（如 "readmodel."，在读模型包内为空）
+func (q *readQuery) resultType(agg *metadata.AggregateMetadata, pkg string) string {
+	if q.IsProjection() {
+		return "*" + pkg + q.viewName()
+	}
+	return fmt.Sprintf("*%s.%s", agg.PackageName, agg.Name)
+}
+
+// results 返回只读仓储方法的返回值
+func (q *readQuery) results(agg *metadata.AggregateMetadata, pkg string) string {
+	if q.single {
+		return fmt.Sprintf("(%s, error)", q.resultType(agg, pkg))
+	}
+	return fmt.Sprintf("([]%s, int64, error)", q.resultType(agg, pkg))
+}
+
+// description 返回查询的说明，未声明 comment 时按条件和排序生成，如 "按 Status、CreatedAt 查询 Order"
+func (q *readQuery) description(agg *metadata.AggregateMetadata) string {
+	if q.Comment != "" {
+		return q.Comment
+	}
+	description := "查询 " + agg.Name
+	if len(q.By) > 0 {
+		description = fmt.Sprintf("按 %s 查询 %s", strings.Join(q.By, "、"), agg.Name)
+	}
+	if len(q.OrderBy) > 0 {
+		description += fmt.Sprintf("，按 %s 排序", strings.Join(q.OrderBy, "、"))
+	}
+	return description
+}
+
+// readTypeImports 返回字段类型用到的 import 路径：领域模型、time 和已知标量类型（如 uuid.UUID）
+func readTypeImports(agg *metadata.AggregateMetadata, fields []*readField) []string {
+	var imports []string
+	for _, f := range fields {
+		parts := strings.FieldsFunc(f.goType, func(r rune) bool { return strings.ContainsRune("*[](){}, ", r) })
+		for _, part := range parts {
+			switch {
+			case strings.HasPrefix(part, agg.PackageName+"."):
+				imports = append(imports, agg.ImportPath)
+			case strings.HasPrefix(part, "time."):
+				imports = append(imports, "time")
+			}
+		}
+		if f.field.ScalarType != nil && f.field.ScalarType.ImportPath != "" {
+			imports = append(imports, f.field.ScalarType.ImportPath)
+		}
+	}
+	return imports
+}
+
+// generateQueries 生成读模型包中的查询结构体、视图、只读仓储接口和查询处理器
+func (g *ReadModelGenerator) generateQueries(agg *metadata.AggregateMetadata, queries []*readQuery) *GoFileData {
+	file := &GoFileData{
+		TemplateData: TemplateData{Aggregate: agg, Context: agg.Context()},
+		Package:      "readmodel",
+	}
+	file.addImports("context")
+
+	for _, q := range queries {
+		file.addImports(readTypeImports(agg, append(slices.Clone(q.by), q.view...))...)
+		if !q.IsProjection() {
+			file.addImports(agg.ImportPath)
+		}
+		if !q.single {
+			file.addImports("soliton/pkg/framework")
+		}
+
+		// 查询参数
+		fields := readStructFields(q.by)
+		doc := fmt.Sprintf("%sQuery %s", q.Name, q.description(agg))
+		if !q.single {
+			fields = append(fields,
+				&GoField{Name: "Page", Type: "int", Tag: fmt.Sprintf(`json:"%s"`, jsonName("Page"))},
+				&GoField{Name: "PageSize", Type: "int", Tag: fmt.Sprintf(`json:"%s"`, jsonName("PageSize"))})
+			doc += "\nPage 从 1 开始，Page、PageSize 为 0 时由处理器分别按 1 和 framework.DefaultPageSize 处理"
+		}
+		file.addType(doc, q.Name+"Query", "struct", fields...)
+
+		// 投影结果
+		if q.IsProjection() {
+			file.addType(fmt.Sprintf("%s %s 的投影结果", q.viewName(), q.Name), q.viewName(), "struct", readStructFields(q.view)...)
+		}
+	}
+
+	// 只读仓储接口
+	repository := file.addType(fmt.Sprintf("%sReadRepository %s 只读仓储，执行 +soliton:query 声明的查询", agg.Name, agg.Name), agg.Name+"ReadRepository", "interface")
+	for _, q := range queries {
+		doc := fmt.Sprintf("%s %s，q.Page 从 1 开始，返回当前页和总数", q.Name, q.description(agg))
+		if q.single {
+			doc = fmt.Sprintf("%s %s，不存在时返回 framework.ErrRecordNotFound", q.Name, q.description(agg))
+		}
+		repository.Methods = append(repository.Methods, &GoFunc{
+			Doc:     doc,
+			Name:    q.Name,
+			Params:  fmt.Sprintf("ctx context.Context, q %sQuery", q.Name),
+			Results: q.results(agg, ""),
+		})
+	}
+
+	// 查询处理器
+	for _, q := range queries {
+		g.generateHandler(file, agg, q)
+	}
+
+	return file
+}
+
+// readStructFields 返回查询结构体或视图的字段，字段的 JSON 名称见 jsonName
+func readStructFields(fields []*readField) []*GoField {
+	result := make([]*GoField, len(fields))
+	for i, f := range fields {
+		result[i] = &GoField{Name: f.name, Type: f.goType, Tag: fmt.Sprintf(`json:"%s"`, jsonName(f.name))}
+	}
+	return result
+}
+
+// generateHandler 向 file 添加查询处理器
+func (g *ReadModelGenerator) generateHandler(file *GoFileData, agg *metadata.AggregateMetadata, q *readQuery) {
+	handler := q.Name + "Handler"
+
+	file.addType(fmt.Sprintf("%s %s 查询处理器", handler, q.Name), handler, "struct",
+		&GoField{Name: "repo", Type: agg.Name + "ReadRepository"})
+	file.addFunc(&GoFunc{
+		Doc:     fmt.Sprintf("New%s 创建 %s 查询处理器", handler, q.Name),
+		Name:    "New" + handler,
+		Params:  fmt.Sprintf("repo %sReadRepository", agg.Name),
+		Results: "*" + handler,
+		Body:    fmt.Sprintf("\treturn &%s{repo: repo}\n", handler),
+	})
+
+	handle := &GoFunc{
+		Receiver: "h *" + handler,
+		Name:     "Handle",
+		Params:   fmt.Sprintf("ctx context.Context, q %sQuery", q.Name),
+	}
+	if q.single {
+		handle.Doc = "Handle 执行查询，不存在时返回 framework.ErrRecordNotFound"
+		handle.Results = q.results(agg, "")
+		handle.Body = fmt.Sprintf("\treturn h.repo.%s(ctx, q)\n", q.Name)
+		file.addFunc(handle)
+		return
+	}
+
+	var body strings.Builder
+	page := fmt.Sprintf("framework.PageResponse[%s]", q.resultType(agg, ""))
+	body.WriteString("\tpage, pageSize, err := framework.NormalizePage(q.Page, q.PageSize)\n")
+	body.WriteString("\tif err != nil {\n")
+	body.WriteString("\t\treturn nil, err\n")
+	body.WriteString("\t}\n")
+	body.WriteString("\tq.Page, q.PageSize = page, pageSize\n")
+	body.WriteString("\n")
+	body.WriteString(fmt.Sprintf("\titems, total, err := h.repo.%s(ctx, q)\n", q.Name))
+	body.WriteString("\tif err != nil {\n")
+	body.WriteString("\t\treturn nil, err\n")
+	body.WriteString("\t}\n")
+	body.WriteString(fmt.Sprintf("\treturn &%s{Items: items, Total: total, Page: page, PageSize: pageSize}, nil\n", page))
+
+	handle.Doc = "Handle 执行分页查询，分页参数按 framework.NormalizePage 补全，为负数时返回 framework.ErrBadRequest"
+	handle.Results = fmt.Sprintf("(*%s, error)", page)
+	handle.Body = body.String()
+	file.addFunc(handle)
+}
+
+// generateRepository 生成只读仓储实现
+func (g *ReadModelGenerator) generateRepository(agg *metadata.AggregateMetadata, queries []*readQuery, imports *readRepoImports) *GoFileData {
+	receiver := strings.ToLower(string(agg.Name[0]))
+	impl := agg.Name + "ReadRepositoryImpl"
+	keyType := qualifiedKeyType(agg)
+	baseType := fmt.Sprintf("framework.BaseRepositoryOf[*%s.%s, do.%sDO, %s]", agg.PackageName, agg.Name, agg.Name, keyType)
+
+	file := &GoFileData{
+		TemplateData: TemplateData{Aggregate: agg, Context: agg.Context()},
+		Package:      "repository",
+	}
+	file.addImports("context", agg.ImportPath, imports.readModel, imports.convertor, imports.do, "soliton/pkg/framework", "gorm.io/gorm")
+	if slices.ContainsFunc(queries, func(q *readQuery) bool { return q.single }) {
+		file.addImports("errors")
+	}
+
+	file.addType(fmt.Sprintf("%s %s 只读仓储实现\n投影查询只读取投影字段的列，经转换器（解密敏感字段、反序列化值对象）后复制到视图", impl, agg.Name), impl, "struct",
+		&GoField{Name: "base", Type: "*" + baseType})
+	file.addFunc(&GoFunc{
+		Doc:     fmt.Sprintf("New%sReadRepository 创建 %s 只读仓储实例", agg.Name, agg.Name),
+		Name:    "New" + agg.Name + "ReadRepository",
+		Params:  "db *gorm.DB",
+		Results: "*" + impl,
+		Body: fmt.Sprintf("\treturn &%s{\n\t\tbase: framework.NewBaseRepositoryOf[*%s.%s, do.%sDO, %s](db, convertor.%sToData, convertor.%sToDomain),\n\t}\n",
+			impl, agg.PackageName, agg.Name, agg.Name, keyType, agg.Name, agg.Name),
+	})
+
+	if hasSensitiveFields(agg) {
+		file.addFunc(&GoFunc{
+			Doc:      fmt.Sprintf("SetEncryptionCodec 设置敏感字段的编解码器，与 %s 仓储使用同一个", agg.Name),
+			Receiver: fmt.Sprintf("%s *%s", receiver, impl),
+			Name:     "SetEncryptionCodec",
+			Params:   "codec framework.EncryptionCodec",
+			Body:     fmt.Sprintf("\t%s.base.SetEncryptionCodec(codec)\n", receiver),
+		})
+	}
+
+	for _, q := range queries {
+		if q.single {
+			file.addFunc(g.generateFindMethod(agg, q, receiver))
+		} else {
+			file.addFunc(g.generateListMethod(agg, q, receiver))
+		}
+		if q.IsProjection() {
+			file.addFunc(g.generateViewConversion(agg, q))
+		}
+	}
+
+	file.addValues(declVar, "确保实现了接口", &GoField{Name: "_", Type: fmt.Sprintf("readmodel.%sReadRepository", agg.Name), Value: fmt.Sprintf("(*%s)(nil)", impl)})
+
+	return file
+}
+
+// readWhere 返回查询条件的 Where 调用，如 .Where("status = ? AND user_id = ?", q.Status, q.UserID)，没有条件时为空
+func readWhere(q *readQuery) string {
+	if len(q.by) == 0 {
+		return ""
+	}
+	conds := make([]string, len(q.by))
+	args := make([]string, len(q.by))
+	for i, f := range q.by {
+		conds[i] = f.field.Column() + " = ?"
+		args[i] = "q." + f.name
+	}
+	return fmt.Sprintf(".Where(%q, %s)", strings.Join(conds, " AND "), strings.Join(args, ", "))
+}
+
+// readOrder 返回排序的 Order 调用，如 .Order("created_at DESC, id")，没有排序时为空
+func readOrder(agg *metadata.AggregateMetadata, q *readQuery) string {
+	orders := q.Orders()
+	if len(orders) == 0 {
+		return ""
+	}
+	items := make([]string, len(orders))
+	for i, order := range orders {
+		items[i] = agg.FieldByPath(order.Field).Column()
+		if order.Desc {
+			items[i] += " DESC"
+		}
+	}
+	return fmt.Sprintf(".Order(%q)", strings.Join(items, ", "))
+}
+
+// readSelect 返回投影查询只读取投影列的 Select 调用，不是投影查询时为空
+func readSelect(q *readQuery) string {
+	if !q.IsProjection() {
+		return ""
+	}
+	var columns []string
+	for _, f := range q.view {
+		for _, column := range f.columns() {
+			if column = fmt.Sprintf("%q", column); !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+	return fmt.Sprintf(".Select([]string{%s})", strings.Join(columns, ", "))
+}
+
+// readResult 返回由转换后的聚合根 entity 得到查询结果的表达式
+func readResult(q *readQuery) string {
+	if q.IsProjection() {
+		return fmt.Sprintf("new%s(entity)", q.viewName())
+	}
+	return "entity"
+}
+
+// generateFindMethod 生成返回一条记录的查询方法
+func (g *ReadModelGenerator) generateFindMethod(agg *metadata.AggregateMetadata, q *readQuery, receiver string) *GoFunc {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("\tvar dataObj do.%sDO\n", agg.Name))
+	sb.WriteString(fmt.Sprintf("\terr := %s.base.DB().WithContext(ctx)%s%s%s.First(&dataObj).Error\n", receiver, readSelect(q), readWhere(q), readOrder(agg, q)))
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\tif errors.Is(err, gorm.ErrRecordNotFound) {\n")
+	sb.WriteString("\t\t\treturn nil, framework.ErrRecordNotFound\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\treturn nil, err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	if !q.IsProjection() {
+		sb.WriteString(fmt.Sprintf("\treturn %s.base.ToDomain(&dataObj)\n", receiver))
+	} else {
+		sb.WriteString(fmt.Sprintf("\tentity, err := %s.base.ToDomain(&dataObj)\n", receiver))
+		sb.WriteString("\tif err != nil {\n")
+		sb.WriteString("\t\treturn nil, err\n")
+		sb.WriteString("\t}\n")
+		sb.WriteString(fmt.Sprintf("\treturn %s, nil\n", readResult(q)))
+	}
+
+	return readMethod(agg, q, receiver, fmt.Sprintf("%s %s，不存在时返回 framework.ErrRecordNotFound", q.Name, q.description(agg)), sb.String())
+}
+
+// generateListMethod 生成分页查询方法，与 FindPage 一致，page 从 1 开始，先按条件统计总数再取当前页
+func (g *ReadModelGenerator) generateListMethod(agg *metadata.AggregateMetadata, q *readQuery, receiver string) *GoFunc {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("\tdb := %s.base.DB().WithContext(ctx).Model(&do.%sDO{})%s.Session(&gorm.Session{})\n", receiver, agg.Name, readWhere(q)))
+	sb.WriteString("\n")
+	sb.WriteString("\t// 查询总数\n")
+	sb.WriteString("\tvar total int64\n")
+	sb.WriteString("\tif err := db.Count(&total).Error; err != nil {\n")
+	sb.WriteString("\t\treturn nil, 0, err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString("\t// 分页查询\n")
+	sb.WriteString(fmt.Sprintf("\tvar dataObjs []do.%sDO\n", agg.Name))
+	sb.WriteString(fmt.Sprintf("\tif err := db%s%s.Offset((q.Page - 1) * q.PageSize).Limit(q.PageSize).Find(&dataObjs).Error; err != nil {\n", readSelect(q), readOrder(agg, q)))
+	sb.WriteString("\t\treturn nil, 0, err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("\tresult := make([]%s, len(dataObjs))\n", q.resultType(agg, "readmodel.")))
+	sb.WriteString("\tfor i := range dataObjs {\n")
+	sb.WriteString(fmt.Sprintf("\t\tentity, err := %s.base.ToDomain(&dataObjs[i])\n", receiver))
+	sb.WriteString("\t\tif err != nil {\n")
+	sb.WriteString("\t\t\treturn nil, 0, err\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString(fmt.Sprintf("\t\tresult[i] = %s\n", readResult(q)))
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString("\treturn result, total, nil\n")
+
+	return readMethod(agg, q, receiver, fmt.Sprintf("%s %s，q.Page 从 1 开始，返回当前页和总数", q.Name, q.description(agg)), sb.String())
+}
+
+// readMethod 返回只读仓储实现中执行查询 q 的方法
+func readMethod(agg *metadata.AggregateMetadata, q *readQuery, receiver, doc, body string) *GoFunc {
+	return &GoFunc{
+		Doc:      doc,
+		Receiver: fmt.Sprintf("%s *%sReadRepositoryImpl", receiver, agg.Name),
+		Name:     q.Name,
+		Params:   fmt.Sprintf("ctx context.Context, q readmodel.%sQuery", q.Name),
+		Results:  q.results(agg, "readmodel."),
+		Body:     body,
+	}
+}
+
+// generateViewConversion 生成由聚合根构造投影结果的函数，指针值对象中的字段在值对象为 nil 时保持零值
+func (g *ReadModelGenerator) generateViewConversion(agg *metadata.AggregateMetadata, q *readQuery) *GoFunc {
+	var sb strings.Builder
+	view := "readmodel." + q.viewName()
+
+	var nested []*readField
+	sb.WriteString(fmt.Sprintf("\tview := &%s{\n", view))
+	for _, f := range q.view {
+		if f.parent != nil && f.parent.IsPointer {
+			nested = append(nested, f)
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\t\t%s: %s,\n", f.name, f.accessor()))
+	}
+	sb.WriteString("\t}\n")
+	for _, f := range nested {
+		sb.WriteString(fmt.Sprintf("\tif entity.%s != nil {\n", f.parent.Name))
+		sb.WriteString(fmt.Sprintf("\t\tview.%s = %s\n", f.name, f.accessor()))
+		sb.WriteString("\t}\n")
+	}
+	sb.WriteString("\treturn view\n")
+
+	return &GoFunc{
+		Doc:     fmt.Sprintf("new%s 将只读取了投影列的 %s 转换为 %s", q.viewName(), agg.Name, q.viewName()),
+		Name:    "new" + q.viewName(),
+		Params:  fmt.Sprintf("entity *%s.%s", agg.PackageName, agg.Name),
+		Results: "*" + view,
+		Body:    sb.String(),
+	}
+}
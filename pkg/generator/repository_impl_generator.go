@@ -2,7 +2,6 @@ package generator
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"soliton/pkg/metadata"
 	"strings"
@@ -13,43 +12,53 @@ import (
 // 生成嵌入 BaseRepository[T, D] 的具体仓储实现，并实现扩展方法。
 //
 // 生成文件：infrastructure/persistence/{AggregateName}RepositoryImpl.go
-type RepositoryImplGenerator struct{}
+type RepositoryImplGenerator struct {
+	fileOutput
+	registry *metadata.AggregateMetadataRegistry
+	outbox   bool
+}
 
 // NewRepositoryImplGenerator 创建仓储实现生成器
 func NewRepositoryImplGenerator() *RepositoryImplGenerator {
 	return &RepositoryImplGenerator{}
 }
 
+// SetRegistry 设置聚合根注册表，用于读取关联实体的级联规则（+soliton:cascade）和一对多关系的外键列
+// 未设置时不生成级联规则和关联实体加载方法
+func (g *RepositoryImplGenerator) SetRegistry(registry *metadata.AggregateMetadataRegistry) {
+	g.registry = registry
+}
+
+// SetOutbox 设置是否使用事务性 outbox，启用时嵌入 framework.EventRecorder 的聚合根的仓储
+// 在构造函数中调用 SetOutbox(true)，领域事件与聚合根在同一事务中写入 outbox 表
+func (g *RepositoryImplGenerator) SetOutbox(enabled bool) {
+	g.outbox = enabled
+}
+
 // Generate 为聚合根生成仓储实现
 func (g *RepositoryImplGenerator) Generate(agg *metadata.AggregateMetadata, outputDir string) error {
 	// 获取绝对路径
 	absOutputDir, _ := filepath.Abs(outputDir)
-	parentDir := filepath.Dir(absOutputDir)
+	infraDir := infrastructureDir(agg, absOutputDir)
 
-	// 创建输出目录（infrastructure 与 domain 平级）
-	implDir := filepath.Join(parentDir, "infrastructure", "repository")
-	if err := os.MkdirAll(implDir, 0755); err != nil {
-		return fmt.Errorf("创建输出目录失败: %w", err)
-	}
+	// 输出目录（infrastructure 与 domain 平级）
+	implDir := filepath.Join(infraDir, "repository")
 
 	// 计算各个依赖的 import 路径
 	imports := &repoImplImports{
 		model:      agg.ImportPath,
-		repository: calculateImportPath(agg.ModuleName, agg.ModuleRoot, filepath.Join(absOutputDir, "repository")),
-		convertor:  calculateImportPath(agg.ModuleName, agg.ModuleRoot, filepath.Join(parentDir, "infrastructure", "convertor")),
-		do:         calculateImportPath(agg.ModuleName, agg.ModuleRoot, filepath.Join(parentDir, "infrastructure", "do")),
-		query:      calculateImportPath(agg.ModuleName, agg.ModuleRoot, filepath.Join(parentDir, "infrastructure", "query")),
+		repository: calculateImportPath(agg.ModuleName, agg.ModuleRoot, filepath.Join(domainDir(agg, absOutputDir), "repository")),
+		convertor:  calculateImportPath(agg.ModuleName, agg.ModuleRoot, filepath.Join(infraDir, "convertor")),
+		do:         calculateImportPath(agg.ModuleName, agg.ModuleRoot, filepath.Join(infraDir, "do")),
+		query:      calculateImportPath(agg.ModuleName, agg.ModuleRoot, filepath.Join(infraDir, "query")),
 	}
 
 	// 生成文件路径
 	fileName := fmt.Sprintf("%sRepositoryImpl.go", agg.Name)
 	filePath := filepath.Join(implDir, fileName)
 
-	// 生成代码
-	code := g.generateCode(agg, imports)
-
 	// 写入文件
-	if err := os.WriteFile(filePath, []byte(code), 0644); err != nil {
+	if err := g.writeTemplate(filePath, "repository_impl", g.generateFile(agg, imports)); err != nil {
 		return fmt.Errorf("写入文件失败: %w", err)
 	}
 
@@ -65,128 +74,628 @@ type repoImplImports struct {
 	query      string
 }
 
-// generateCode 生成仓储实现代码
-func (g *RepositoryImplGenerator) generateCode(agg *metadata.AggregateMetadata, imports *repoImplImports) string {
-	var sb strings.Builder
+// generateFile 生成仓储实现文件的模板数据
+func (g *RepositoryImplGenerator) generateFile(agg *metadata.AggregateMetadata, imports *repoImplImports) *GoFileData {
+	file := &GoFileData{
+		TemplateData: TemplateData{Aggregate: agg, Context: agg.Context()},
+		Package:      "repository",
+	}
 
-	// 检查是否需要 errors 包（有 unique 字段时需要）
-	needErrors := false
-	for _, field := range agg.Fields {
+	// 导入（使用动态计算的 import 路径）
+	paths := []string{"context", imports.model, imports.repository, imports.convertor, imports.do, imports.query, "soliton/pkg/framework", "gorm.io/gorm"}
+
+	// 有 unique 字段或为中间实体时需要 errors 包
+	left, _ := agg.AssociationEnds()
+	needErrors := left != nil
+	for _, field := range agg.MappedFields() {
 		if field.Annotations.IsUnique {
 			needErrors = true
 			break
 		}
 	}
-
-	// 文件头
-	sb.WriteString("// Code generated by soliton. DO NOT EDIT.\n\n")
-	sb.WriteString("package repository\n\n")
-
-	// 导入（使用动态计算的 import 路径）
-	sb.WriteString("import (\n")
-	sb.WriteString("\t\"context\"\n")
 	if needErrors {
-		sb.WriteString("\t\"errors\"\n")
+		paths = append(paths, "errors")
+	}
+	// FindByFilter 需要 fmt 报告无效的排序方式、strings 拼接排序子句
+	if filterMethod(agg, "") != nil {
+		paths = append(paths, "fmt", "strings")
 	}
-	sb.WriteString(fmt.Sprintf("\t\"%s\"\n", imports.model))
-	sb.WriteString(fmt.Sprintf("\t\"%s\"\n", imports.repository))
-	sb.WriteString(fmt.Sprintf("\t\"%s\"\n", imports.convertor))
-	sb.WriteString(fmt.Sprintf("\t\"%s\"\n", imports.do))
-	sb.WriteString(fmt.Sprintf("\t\"%s\"\n", imports.query))
-	sb.WriteString("\t\"soliton/pkg/framework\"\n")
-	sb.WriteString("\t\"gorm.io/gorm\"\n")
-	sb.WriteString(")\n\n")
+	file.addImports(paths...)
 
 	// 结构体定义
-	sb.WriteString(fmt.Sprintf("// %sRepositoryImpl %s 仓储实现\n", agg.Name, agg.Name))
-	sb.WriteString(fmt.Sprintf("type %sRepositoryImpl struct {\n", agg.Name))
-	sb.WriteString(fmt.Sprintf("\tframework.BaseRepository[*%s.%s, do.%sDO]\n", agg.PackageName, agg.Name, agg.Name))
-	sb.WriteString("}\n\n")
+	file.addType(fmt.Sprintf("%sRepositoryImpl %s 仓储实现", agg.Name, agg.Name), agg.Name+"RepositoryImpl", "struct",
+		&GoField{Type: "framework." + baseRepositoryType(agg)})
 
 	// 构造函数
-	sb.WriteString(g.generateConstructor(agg))
-	sb.WriteString("\n")
+	file.addFunc(g.generateConstructor(agg))
 
-	// 生成扩展方法实现
-	sb.WriteString(g.generateExtendMethodsImpl(agg))
+	// 扩展方法实现
+	for _, method := range g.generateExtendMethodsImpl(agg) {
+		file.addFunc(method)
+	}
 
 	// 接口实现检查
-	sb.WriteString(fmt.Sprintf("// 确保实现了接口\n"))
-	sb.WriteString(fmt.Sprintf("var _ repository.%sRepository = (*%sRepositoryImpl)(nil)\n",
-		agg.Name, agg.Name))
+	file.addValues(declVar, "确保实现了接口", &GoField{
+		Name:  "_",
+		Type:  fmt.Sprintf("repository.%sRepository", agg.Name),
+		Value: fmt.Sprintf("(*%sRepositoryImpl)(nil)", agg.Name),
+	})
 
-	return sb.String()
+	return file
 }
 
 // generateConstructor 生成构造函数
-func (g *RepositoryImplGenerator) generateConstructor(agg *metadata.AggregateMetadata) string {
+func (g *RepositoryImplGenerator) generateConstructor(agg *metadata.AggregateMetadata) *GoFunc {
 	var sb strings.Builder
 
-	sb.WriteString(fmt.Sprintf("// New%sRepository 创建 %s 仓储实例\n", agg.Name, agg.Name))
-	sb.WriteString(fmt.Sprintf("func New%sRepository(db *gorm.DB) *%sRepositoryImpl {\n",
-		agg.Name, agg.Name))
-	sb.WriteString(fmt.Sprintf("\treturn &%sRepositoryImpl{\n", agg.Name))
-	sb.WriteString(fmt.Sprintf("\t\tBaseRepository: *framework.NewBaseRepository[*%s.%s, do.%sDO](\n",
-		agg.PackageName, agg.Name, agg.Name))
+	idGenerator := idGeneratorExpr(agg)
+	var cascades []*metadata.RelationMetadata
+	if g.registry != nil {
+		cascades = g.registry.GetCascadeRelations(agg.Name)
+	}
+	outbox := g.outbox && agg.RecordsEvents
+	configured := idGenerator != "" || len(cascades) > 0 || outbox
+	if !configured {
+		sb.WriteString(fmt.Sprintf("\treturn &%sRepositoryImpl{\n", agg.Name))
+	} else {
+		sb.WriteString(fmt.Sprintf("\trepo := &%sRepositoryImpl{\n", agg.Name))
+	}
+	sb.WriteString(fmt.Sprintf("\t\t%s: *framework.New%s(\n", baseRepositoryField(agg), baseRepositoryType(agg)))
 	sb.WriteString("\t\t\tdb,\n")
 	sb.WriteString(fmt.Sprintf("\t\t\tconvertor.%sToData,\n", agg.Name))
 	sb.WriteString(fmt.Sprintf("\t\t\tconvertor.%sToDomain,\n", agg.Name))
 	sb.WriteString("\t\t),\n")
 	sb.WriteString("\t}\n")
-	sb.WriteString("}\n")
+	if idGenerator != "" {
+		sb.WriteString(fmt.Sprintf("\t// 主键策略：%s\n", agg.IDStrategy))
+		sb.WriteString(fmt.Sprintf("\trepo.SetIDGenerator(%s)\n", idGenerator))
+	}
+	if len(cascades) > 0 {
+		sb.WriteString("\t// 关联实体级联规则：+soliton:cascade\n")
+		for _, rel := range cascades {
+			sb.WriteString(fmt.Sprintf("\trepo.RegisterCascade(%q, framework.%s, &do.%sDO{}, %q)\n",
+				rel.Field.Name, cascadeActionConst(rel.Cascade), rel.TargetAggregate, rel.ForeignKey.Column()))
+		}
+	}
+	if outbox {
+		sb.WriteString("\t// 领域事件与聚合根在同一事务中写入 outbox 表\n")
+		sb.WriteString("\trepo.SetOutbox(true)\n")
+	}
+	if configured {
+		sb.WriteString("\treturn repo\n")
+	}
 
-	return sb.String()
+	return &GoFunc{
+		Doc:     fmt.Sprintf("New%sRepository 创建 %s 仓储实例", agg.Name, agg.Name),
+		Name:    fmt.Sprintf("New%sRepository", agg.Name),
+		Params:  "db *gorm.DB",
+		Results: fmt.Sprintf("*%sRepositoryImpl", agg.Name),
+		Body:    sb.String(),
+	}
+}
+
+// cascadeActionConst 返回级联行为对应的 framework 常量名
+func cascadeActionConst(action string) string {
+	switch action {
+	case metadata.CascadeNullify:
+		return "CascadeNullify"
+	case metadata.CascadeRestrict:
+		return "CascadeRestrict"
+	default:
+		return "CascadeDelete"
+	}
+}
+
+// idGeneratorExpr 返回主键策略对应的生成器表达式，auto 策略由数据库自增，返回空字符串
+func idGeneratorExpr(agg *metadata.AggregateMetadata) string {
+	switch agg.IDStrategy {
+	case metadata.IDStrategyUUID:
+		return "framework.UUIDGenerator()"
+	case metadata.IDStrategySnowflake:
+		return "framework.DefaultSnowflakeGenerator()"
+	case metadata.IDStrategyManual:
+		return fmt.Sprintf("framework.ManualIDGenerator[%s]()", qualifiedKeyType(agg))
+	default:
+		return ""
+	}
 }
 
 // generateExtendMethodsImpl 生成扩展方法实现
-func (g *RepositoryImplGenerator) generateExtendMethodsImpl(agg *metadata.AggregateMetadata) string {
-	var sb strings.Builder
+func (g *RepositoryImplGenerator) generateExtendMethodsImpl(agg *metadata.AggregateMetadata) []*GoFunc {
+	var methods []*GoFunc
 	// 记录已生成的方法名，避免重复
 	generatedMethods := make(map[string]bool)
 
-	for _, field := range agg.Fields {
+	for _, field := range agg.MappedFields() {
 		// 跳过 ID 字段和关联实体字段
 		if (agg.IDField != nil && field.Name == agg.IDField.Name) || field.Annotations.IsEntity {
 			continue
 		}
 
-		methodName := fmt.Sprintf("GetBy%s", field.Name)
-
 		// unique 字段实现
-		if field.Annotations.IsUnique {
-			if !generatedMethods[methodName] {
-				sb.WriteString(g.generateGetByUniqueMethod(agg, field))
-				sb.WriteString("\n")
-				generatedMethods[methodName] = true
-			}
+		if findMethod := "FindBy" + field.Name; field.Annotations.IsUnique && !generatedMethods[findMethod] {
+			methods = append(methods, g.generateFindByUniqueMethod(agg, field))
+			generatedMethods[findMethod] = true
 		}
 
 		// index 或 ref 字段实现（避免重复生成）
-		if field.Annotations.IsIndex || field.Annotations.IsRef {
-			if !generatedMethods[methodName] {
-				sb.WriteString(g.generateGetByIndexMethod(agg, field))
-				sb.WriteString("\n")
-				generatedMethods[methodName] = true
-			}
+		if listMethod := "ListBy" + field.Name; (field.Annotations.IsIndex || isSingleRef(field)) && !generatedMethods[listMethod] {
+			methods = append(methods, g.generateListByIndexMethod(agg, field))
+			generatedMethods[listMethod] = true
+		}
+
+		// 多态关联字段实现
+		if polymorphicMethod := "GetBy" + field.PolymorphicName(); field.IsPolymorphic() && !generatedMethods[polymorphicMethod] {
+			methods = append(methods, g.generateGetByPolymorphicMethod(agg, field))
+			generatedMethods[polymorphicMethod] = true
 		}
 	}
 
+	methods = append(methods, g.generateHierarchyMethodsImpl(agg)...)
+	methods = append(methods, g.generateAssociationMethodsImpl(agg)...)
+	for _, link := range joinLinks(g.registry, agg) {
+		methods = append(methods, g.generateJoinMethodsImpl(link)...)
+	}
+
+	for _, rel := range loadableRelations(g.registry, agg) {
+		methods = append(methods, g.generateLoadMethodImpl(agg, rel))
+	}
+	methods = append(methods, g.generateChildMethodsImpl(agg)...)
+	if filter := g.generateFindByFilterMethod(agg); filter != nil {
+		methods = append(methods, filter)
+	}
+
+	return methods
+}
+
+// generateFindByFilterMethod 生成按 {Aggregate}Filter 分页查询的方法，没有 FindByFilter 方法时返回空
+//
+// 条件值以参数绑定传入；排序只接受 {Aggregate}Sort 的取值并映射为查询字段的列名，调用方的输入不会拼接到 SQL 中。
+// 最后按主键升序排列，保证分页结果稳定。
+func (g *RepositoryImplGenerator) generateFindByFilterMethod(agg *metadata.AggregateMetadata) *GoFunc {
+	method := filterMethod(agg, "repository.")
+	if method == nil {
+		return nil
+	}
+
+	var sb strings.Builder
+	receiver := strings.ToLower(string(agg.Name[0]))
+	base := receiver + "." + baseRepositoryField(agg)
+	column := func(field *metadata.FieldMetadata) string {
+		return fmt.Sprintf("query.%s.%s.Column()", agg.Name, field.Name)
+	}
+
+	sb.WriteString("\t// 排序：只接受 Sort 的取值，最后按主键升序\n")
+	sb.WriteString("\torders := make([]string, 0, len(filter.Sort)+1)\n")
+	sb.WriteString("\tfor _, sort := range filter.Sort {\n")
+	sb.WriteString("\t\tswitch sort {\n")
+	for _, option := range sortOptions(agg) {
+		order := column(option.Field)
+		if option.Desc {
+			order += ` + " DESC"`
+		}
+		sb.WriteString(fmt.Sprintf("\t\tcase repository.%s:\n", option.Const))
+		sb.WriteString(fmt.Sprintf("\t\t\torders = append(orders, %s)\n", order))
+	}
+	sb.WriteString("\t\tdefault:\n")
+	sb.WriteString("\t\t\treturn nil, 0, fmt.Errorf(\"%w: %q\", framework.ErrInvalidSort, sort)\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n")
+	for _, field := range agg.PrimaryKey {
+		sb.WriteString(fmt.Sprintf("\torders = append(orders, %s)\n", column(field)))
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString(fmt.Sprintf("\tdb := %s.DB().WithContext(ctx).Model(&do.%sDO{})\n", base, agg.Name))
+	for _, condition := range filterConditions(agg) {
+		value := "filter." + condition.Name
+		if condition.Op == "IN" {
+			sb.WriteString(fmt.Sprintf("\tif len(%s) > 0 {\n", value))
+			sb.WriteString(fmt.Sprintf("\t\tdb = db.Where(%s+\" IN (?)\", %s)\n", column(condition.Field), value))
+		} else {
+			sb.WriteString(fmt.Sprintf("\tif %s != nil {\n", value))
+			sb.WriteString(fmt.Sprintf("\t\tdb = db.Where(%s+\" %s ?\", *%s)\n", column(condition.Field), condition.Op, value))
+		}
+		sb.WriteString("\t}\n")
+	}
+	sb.WriteString("\tdb = db.Session(&gorm.Session{})\n")
+	sb.WriteString("\n")
+	sb.WriteString("\t// 查询总数\n")
+	sb.WriteString("\tvar total int64\n")
+	sb.WriteString("\tif err := db.Count(&total).Error; err != nil {\n")
+	sb.WriteString("\t\treturn nil, 0, err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString("\t// 分页查询\n")
+	sb.WriteString(fmt.Sprintf("\tvar dataObjs []do.%sDO\n", agg.Name))
+	sb.WriteString("\tif err := db.Order(strings.Join(orders, \", \")).Offset((page - 1) * pageSize).Limit(pageSize).Find(&dataObjs).Error; err != nil {\n")
+	sb.WriteString("\t\treturn nil, 0, err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("\tresult := make([]*%s.%s, len(dataObjs))\n", agg.PackageName, agg.Name))
+	sb.WriteString("\tfor idx := range dataObjs {\n")
+	sb.WriteString(fmt.Sprintf("\t\tentity, err := %s.ToDomain(&dataObjs[idx])\n", base))
+	sb.WriteString("\t\tif err != nil {\n")
+	sb.WriteString("\t\t\treturn nil, 0, err\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tresult[idx] = entity\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString("\treturn result, total, nil\n")
+
+	return method.goFunc(fmt.Sprintf("%s *%sRepositoryImpl", receiver, agg.Name), sb.String())
+}
+
+// loadableRelations 返回可生成批量加载方法的一对多关系
+//
+// 要求已确定关联实体中的外键字段（见 RelationMetadata.ForeignKey），聚合根使用单列主键，
+// 关联实体与聚合根位于同一限界上下文（共用 do、convertor、query 包），且关联实体没有敏感字段
+// （加载时直接使用转换器，不经过其仓储解码）。registry 为 nil 时返回空。
+func loadableRelations(registry *metadata.AggregateMetadataRegistry, agg *metadata.AggregateMetadata) []*metadata.RelationMetadata {
+	if registry == nil || agg.IsCompositeKey() {
+		return nil
+	}
+
+	var relations []*metadata.RelationMetadata
+	for _, rel := range registry.GetRelationsByAggregate(agg.Name, metadata.RelationTypeOneToMany) {
+		if rel.ForeignKey == nil {
+			continue
+		}
+		target := registry.Get(rel.TargetAggregate)
+		if target == nil || target.Context() != agg.Context() || hasSensitiveFields(target) {
+			continue
+		}
+		relations = append(relations, rel)
+	}
+	return relations
+}
+
+// childRelations 返回随聚合根一并保存的一对多关联实体，即 loadableRelations 中关联实体不是聚合根自身的关系
+// （树形结构的子节点是同一聚合根，由各自的 Add、Update 保存）
+func childRelations(registry *metadata.AggregateMetadataRegistry, agg *metadata.AggregateMetadata) []*metadata.RelationMetadata {
+	var relations []*metadata.RelationMetadata
+	for _, rel := range loadableRelations(registry, agg) {
+		if rel.TargetAggregate != agg.Name {
+			relations = append(relations, rel)
+		}
+	}
+	return relations
+}
+
+// backfillChildren 生成将关联实体的外键回填为 entity 的主键、并收集到切片变量 variable 的语句，每行以 indent 缩进
+func backfillChildren(agg *metadata.AggregateMetadata, rel *metadata.RelationMetadata, child *metadata.AggregateMetadata, variable, indent string) string {
+	var sb strings.Builder
+	field, foreignKey := rel.Field, rel.ForeignKey
+
+	ownerID := "entity.GetID()"
+	if foreignKey.Type != qualifiedKeyType(agg) {
+		ownerID = fmt.Sprintf("%s(%s)", foreignKey.Type, ownerID)
+	}
+	element := fmt.Sprintf("entity.%s[i]", field.Name)
+	if !field.IsPointer {
+		element = "&" + element
+	}
+
+	sb.WriteString(fmt.Sprintf("%s%s := make([]*%s.%s, len(entity.%s))\n", indent, variable, child.PackageName, child.Name, field.Name))
+	sb.WriteString(fmt.Sprintf("%sfor i := range entity.%s {\n", indent, field.Name))
+	sb.WriteString(fmt.Sprintf("%s\tchild := %s\n", indent, element))
+	if foreignKey.IsPointer {
+		sb.WriteString(fmt.Sprintf("%s\townerID := %s\n", indent, ownerID))
+		sb.WriteString(fmt.Sprintf("%s\tchild.%s = &ownerID\n", indent, foreignKey.Name))
+	} else {
+		sb.WriteString(fmt.Sprintf("%s\tchild.%s = %s\n", indent, foreignKey.Name, ownerID))
+	}
+	sb.WriteString(fmt.Sprintf("%s\t%s[i] = child\n", indent, variable))
+	sb.WriteString(fmt.Sprintf("%s}\n", indent))
+
 	return sb.String()
 }
 
-// generateGetByUniqueMethod 生成唯一字段查询方法
-func (g *RepositoryImplGenerator) generateGetByUniqueMethod(agg *metadata.AggregateMetadata, field *metadata.FieldMetadata) string {
+// generateChildMethodsImpl 生成随聚合根一并保存和加载一对多关联实体的方法实现，没有 childRelations 时返回空
+//
+// 聚合根和关联实体在同一事务中写入：关联实体使用以事务创建的 BaseRepository（沿用其主键策略，不执行其仓储上注册的钩子），
+// 由 framework.SaveChildren 新增或更新，并按级联规则删除已移除的关联实体。
+func (g *RepositoryImplGenerator) generateChildMethodsImpl(agg *metadata.AggregateMetadata) []*GoFunc {
+	relations := childRelations(g.registry, agg)
+	if len(relations) == 0 {
+		return nil
+	}
+
+	var funcs []*GoFunc
+	receiver := strings.ToLower(string(agg.Name[0]))
+	implReceiver := fmt.Sprintf("%s *%sRepositoryImpl", receiver, agg.Name)
+	entityType := fmt.Sprintf("*%s.%s", agg.PackageName, agg.Name)
+	methods := childMethods(agg, relations)
+
+	// AddWithChildren、UpdateWithChildren
+	for i, op := range []string{"Add", "Update"} {
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("\treturn %s.Transaction(ctx, func(tx *framework.%s) error {\n", receiver, baseRepositoryType(agg)))
+		sb.WriteString(fmt.Sprintf("\t\tif err := tx.%s(ctx, entity); err != nil {\n", op))
+		sb.WriteString("\t\t\treturn err\n")
+		sb.WriteString("\t\t}\n")
+		sb.WriteString(fmt.Sprintf("\t\treturn %s.saveChildren(ctx, tx.DB(), entity)\n", receiver))
+		sb.WriteString("\t})\n")
+		funcs = append(funcs, methods[i].goFunc(implReceiver, sb.String()))
+	}
+
+	// FindByIDWithChildren
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("\tentity, err := %s.FindByID(ctx, id)\n", receiver))
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn nil, err\n")
+	sb.WriteString("\t}\n")
+	for _, rel := range relations {
+		sb.WriteString(fmt.Sprintf("\tif err := %s.Load%s(ctx, entity); err != nil {\n", receiver, rel.Field.Name))
+		sb.WriteString("\t\treturn nil, err\n")
+		sb.WriteString("\t}\n")
+	}
+	sb.WriteString("\treturn entity, nil\n")
+	funcs = append(funcs, methods[2].goFunc(implReceiver, sb.String()))
+
+	// saveChildren
+	sb.Reset()
+	for i, rel := range relations {
+		child := g.registry.Get(rel.TargetAggregate)
+		variable := toLowerFirst(rel.Field.Name)
+		childType := fmt.Sprintf("*%s.%s", child.PackageName, child.Name)
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("\t// %s\n", rel.Field.Name))
+		sb.WriteString(backfillChildren(agg, rel, child, variable, "\t"))
+		sb.WriteString(fmt.Sprintf("\tvar stored%s []do.%sDO\n", rel.Field.Name, child.Name))
+		sb.WriteString(fmt.Sprintf("\tif err := db.Where(query.%s.%s.Column()+\" = ?\", entity.GetID()).Find(&stored%s).Error; err != nil {\n",
+			child.Name, rel.ForeignKey.Name, rel.Field.Name))
+		sb.WriteString("\t\treturn err\n")
+		sb.WriteString("\t}\n")
+		sb.WriteString(fmt.Sprintf("\texisting%s := make([]%s, len(stored%s))\n", rel.Field.Name, childType, rel.Field.Name))
+		sb.WriteString(fmt.Sprintf("\tfor i := range stored%s {\n", rel.Field.Name))
+		sb.WriteString(fmt.Sprintf("\t\tstored, err := convertor.%sToDomain(&stored%s[i])\n", child.Name, rel.Field.Name))
+		sb.WriteString("\t\tif err != nil {\n")
+		sb.WriteString("\t\t\treturn err\n")
+		sb.WriteString("\t\t}\n")
+		sb.WriteString(fmt.Sprintf("\t\texisting%s[i] = stored\n", rel.Field.Name))
+		sb.WriteString("\t}\n")
+		sb.WriteString(fmt.Sprintf("\t%sRepo := framework.New%s(db, convertor.%sToData, convertor.%sToDomain)\n",
+			variable, baseRepositoryType(child), child.Name, child.Name))
+		if idGenerator := idGeneratorExpr(child); idGenerator != "" {
+			sb.WriteString(fmt.Sprintf("\t%sRepo.SetIDGenerator(%s)\n", variable, idGenerator))
+		}
+		sb.WriteString(fmt.Sprintf("\tif err := framework.SaveChildren[%s, %s](ctx, %sRepo, %s, existing%s, %s); err != nil {\n",
+			childType, qualifiedKeyType(child), variable, variable, rel.Field.Name, childCascade(rel)))
+		sb.WriteString("\t\treturn err\n")
+		sb.WriteString("\t}\n")
+	}
+	sb.WriteString("\treturn nil\n")
+	funcs = append(funcs, &GoFunc{
+		Doc:      "saveChildren 在 db（聚合根的事务）中保存关联实体，外键回填为聚合根的主键，见 framework.SaveChildren",
+		Receiver: implReceiver,
+		Name:     "saveChildren",
+		Params:   fmt.Sprintf("ctx context.Context, db *gorm.DB, entity %s", entityType),
+		Results:  "error",
+		Body:     sb.String(),
+	})
+
+	return funcs
+}
+
+// childCascade 返回传给 framework.SaveChildren 的级联行为（+soliton:cascade），未声明时为空
+func childCascade(rel *metadata.RelationMetadata) string {
+	if rel.Cascade == "" {
+		return `""`
+	}
+	return "framework." + cascadeActionConst(rel.Cascade)
+}
+
+// joinLink 聚合根在多对多纯关联表（relation_only）中的一侧，生成关联管理方法
+type joinLink struct {
+	table       *metadata.ManyToManyTableMetadata
+	owner       *metadata.AggregateMetadata // 生成方法的聚合根
+	other       *metadata.AggregateMetadata // 关联的另一侧聚合根
+	column      string                      // 关联表中引用 owner 的列
+	otherColumn string                      // 关联表中引用 other 的列
+	left        bool                        // owner 为关联表的左侧
+}
+
+// joinLinks 返回聚合根可生成关联管理方法的多对多纯关联表
+//
+// 要求两侧为不同的 int64 主键聚合根（与 framework.ManyToManyRepository 一致），且位于同一限界上下文的同一模型包
+// （共用 do、convertor、query 包）。registry 为 nil 时返回空。
+func joinLinks(registry *metadata.AggregateMetadataRegistry, agg *metadata.AggregateMetadata) []*joinLink {
+	if registry == nil || !int64Key(agg) {
+		return nil
+	}
+
+	var links []*joinLink
+	for _, table := range registry.GetManyToManyTables() {
+		if table.GenerationType != "relation_only" || table.LeftAggregate == table.RightAggregate {
+			continue
+		}
+		link := &joinLink{table: table, owner: agg}
+		switch agg.Name {
+		case table.LeftAggregate:
+			link.other = registry.Get(table.RightAggregate)
+			link.column, link.otherColumn, link.left = table.LeftColumn, table.RightColumn, true
+		case table.RightAggregate:
+			link.other = registry.Get(table.LeftAggregate)
+			link.column, link.otherColumn = table.RightColumn, table.LeftColumn
+		default:
+			continue
+		}
+		if other := link.other; other == nil || !int64Key(other) || other.Context() != agg.Context() || other.ImportPath != agg.ImportPath {
+			continue
+		}
+		links = append(links, link)
+	}
+	return links
+}
+
+// listable 判断是否生成 List{Others}Of{Owner}：与 loadableRelations 相同，另一侧有敏感字段时不生成（查询时直接使用转换器）
+func (l *joinLink) listable() bool {
+	return !hasSensitiveFields(l.other)
+}
+
+// ownerParam 返回本侧 ID 参数名，如 userID
+func (l *joinLink) ownerParam() string {
+	return toLowerFirst(l.owner.Name) + "ID"
+}
+
+// otherParam 返回另一侧 ID 参数名，如 roleID
+func (l *joinLink) otherParam() string {
+	return toLowerFirst(l.other.Name) + "ID"
+}
+
+// methods 返回关联管理方法，如 AddRoleToUser、RemoveRoleFromUser、ListRolesOfUser 和 ReplaceRoles
+func (l *joinLink) methods() []interfaceMethod {
+	owner, other := l.owner.Name, l.other.Name
+	entityType := fmt.Sprintf("*%s.%s", l.other.PackageName, other)
+	pair := fmt.Sprintf("ctx context.Context, %s, %s int64", l.ownerParam(), l.otherParam())
+	pairArgs := []string{"ctx", l.ownerParam(), l.otherParam()}
+
+	methods := []interfaceMethod{
+		{
+			Comment: fmt.Sprintf("为 %s 关联 %s（关联表 %s），关联已存在时不报错", owner, other, l.table.TableName),
+			Name:    "Add" + other + "To" + owner,
+			Params:  pair,
+			Args:    pairArgs,
+			Results: []string{"error"},
+		},
+		{
+			Comment: fmt.Sprintf("解除 %s 与 %s 的关联，关联不存在时不报错", owner, other),
+			Name:    "Remove" + other + "From" + owner,
+			Params:  pair,
+			Args:    pairArgs,
+			Results: []string{"error"},
+		},
+	}
+	if l.listable() {
+		methods = append(methods, interfaceMethod{
+			Comment: fmt.Sprintf("分页查询 %s 关联的 %s（按主键升序，跳过已删除的记录），返回当前页和总数", owner, other),
+			Name:    "List" + l.other.PluralName() + "Of" + owner,
+			Params:  fmt.Sprintf("ctx context.Context, %s int64, page, pageSize int", l.ownerParam()),
+			Args:    []string{"ctx", l.ownerParam(), "page", "pageSize"},
+			Results: []string{"[]" + entityType, "int64", "error"},
+		})
+	}
+	methods = append(methods, interfaceMethod{
+		Comment: fmt.Sprintf("在一个事务中将 %s 关联的 %s 替换为 %ss，只增删有变化的关联", owner, other, l.otherParam()),
+		Name:    "Replace" + l.other.PluralName(),
+		Params:  fmt.Sprintf("ctx context.Context, %s int64, %ss []int64", l.ownerParam(), l.otherParam()),
+		Args:    []string{"ctx", l.ownerParam(), l.otherParam() + "s"},
+		Results: []string{"error"},
+	})
+	return methods
+}
+
+// hasSensitiveFields 判断聚合根是否有敏感字段（+soliton:sensitive）
+func hasSensitiveFields(agg *metadata.AggregateMetadata) bool {
+	for _, field := range agg.MappedFields() {
+		if field.Annotations.Sensitive != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// generateLoadMethodImpl 生成一对多关联实体的批量加载方法
+//
+// 按外键列以 IN 查询一次取出全部关联实体（跳过已软删除的记录），再按外键分组填充到各聚合根的切片字段，
+// 避免逐个查询（N+1）；调用前字段中已有的关联实体会被清空。
+func (g *RepositoryImplGenerator) generateLoadMethodImpl(agg *metadata.AggregateMetadata, rel *metadata.RelationMetadata) *GoFunc {
 	var sb strings.Builder
 
 	receiver := strings.ToLower(string(agg.Name[0]))
+	base := receiver + "." + baseRepositoryField(agg)
+	keyType := qualifiedKeyType(agg)
+	entityType := fmt.Sprintf("%s.%s", agg.PackageName, agg.Name)
+	field, foreignKey := rel.Field, rel.ForeignKey
 
-	sb.WriteString(fmt.Sprintf("// GetBy%s 根据 %s 查询（唯一）\n", field.Name, field.Name))
-	sb.WriteString(fmt.Sprintf("func (%s *%sRepositoryImpl) GetBy%s(ctx context.Context, %s %s) (*%s.%s, error) {\n",
-		receiver, agg.Name, field.Name, toLowerFirst(field.Name), field.Type, agg.PackageName, agg.Name))
+	// 关联实体外键字段转换为聚合根主键的表达式
+	ownerID := "child." + foreignKey.Name
+	if foreignKey.IsPointer {
+		ownerID = "*" + ownerID
+	}
+	if foreignKey.Type != keyType {
+		ownerID = fmt.Sprintf("%s(%s)", keyType, ownerID)
+	}
+	element := "child"
+	if !field.IsPointer {
+		element = "*child"
+	}
+
+	sb.WriteString("\tif len(entities) == 0 {\n")
+	sb.WriteString("\t\treturn nil\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("\towners := make(map[%s]*%s, len(entities))\n", keyType, entityType))
+	sb.WriteString(fmt.Sprintf("\tids := make([]%s, 0, len(entities))\n", keyType))
+	sb.WriteString("\tfor _, entity := range entities {\n")
+	sb.WriteString(fmt.Sprintf("\t\tentity.%s = nil\n", field.Name))
+	sb.WriteString("\t\towners[entity.GetID()] = entity\n")
+	sb.WriteString("\t\tids = append(ids, entity.GetID())\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	// 关联实体支持软删除时跳过已删除的记录
+	conds := fmt.Sprintf("Where(query.%s.%s.Column()+\" IN ?\", ids)", rel.TargetAggregate, foreignKey.Name)
+	if target := g.registry.Get(rel.TargetAggregate); target.BaseEntity != nil && target.BaseEntity.HasDeletedAt {
+		conds += fmt.Sprintf(".Where(query.%s.%s.Column() + \" IS NULL\")", rel.TargetAggregate, target.BaseEntity.DeletedAtField.Name)
+	}
+	sb.WriteString(fmt.Sprintf("\tvar dataObjs []do.%sDO\n", rel.TargetAggregate))
+	sb.WriteString(fmt.Sprintf("\tif err := %s.DB().WithContext(ctx).%s.Find(&dataObjs).Error; err != nil {\n", base, conds))
+	sb.WriteString("\t\treturn err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString("\tfor i := range dataObjs {\n")
+	sb.WriteString(fmt.Sprintf("\t\tchild, err := convertor.%sToDomain(&dataObjs[i])\n", rel.TargetAggregate))
+	sb.WriteString("\t\tif err != nil {\n")
+	sb.WriteString("\t\t\treturn err\n")
+	sb.WriteString("\t\t}\n")
+	if foreignKey.IsPointer {
+		sb.WriteString(fmt.Sprintf("\t\tif child.%s == nil {\n", foreignKey.Name))
+		sb.WriteString("\t\t\tcontinue\n")
+		sb.WriteString("\t\t}\n")
+	}
+	sb.WriteString(fmt.Sprintf("\t\tif owner, ok := owners[%s]; ok {\n", ownerID))
+	sb.WriteString(fmt.Sprintf("\t\t\towner.%s = append(owner.%s, %s)\n", field.Name, field.Name, element))
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString("\treturn nil\n")
+
+	return &GoFunc{
+		Doc:      fmt.Sprintf("Load%s 批量加载关联实体 %s（%s.%s 引用 %s），按外键分组填充，避免逐个查询", field.Name, field.Name, rel.TargetAggregate, foreignKey.Name, agg.Name),
+		Receiver: fmt.Sprintf("%s *%sRepositoryImpl", receiver, agg.Name),
+		Name:     "Load" + field.Name,
+		Params:   fmt.Sprintf("ctx context.Context, entities ...*%s", entityType),
+		Results:  "error",
+		Body:     sb.String(),
+	}
+}
+
+// generateAssociationMethodsImpl 生成中间实体（+soliton:manyToMany）的关联管理方法实现，不是中间实体时返回空
+//
+// Link 按两端查找已有关联，存在时沿用其主键（和版本号）更新附加属性；Unlink 硬删除关联记录，
+// 避免软删除的记录占用两端的组合唯一索引。
+func (g *RepositoryImplGenerator) generateAssociationMethodsImpl(agg *metadata.AggregateMetadata) []*GoFunc {
+	left, right := agg.AssociationEnds()
+	if left == nil {
+		return nil
+	}
+
+	var sb strings.Builder
+	receiver := strings.ToLower(string(agg.Name[0]))
+	implReceiver := fmt.Sprintf("%s *%sRepositoryImpl", receiver, agg.Name)
+	base := receiver + "." + baseRepositoryField(agg)
+	entityType := fmt.Sprintf("%s.%s", agg.PackageName, agg.Name)
+	leftParam, rightParam := toLowerFirst(left.Name), toLowerFirst(right.Name)
+	params := fmt.Sprintf("%s %s, %s %s", leftParam, left.Type, rightParam, right.Type)
+
+	// GetLink
 	sb.WriteString(fmt.Sprintf("\tvar dataObj do.%sDO\n", agg.Name))
-	sb.WriteString(fmt.Sprintf("\tcond := query.%s.%s.Eq(%s)\n", agg.Name, field.Name, toLowerFirst(field.Name)))
-	sb.WriteString(fmt.Sprintf("\tsql, args := cond.Build()\n"))
-	sb.WriteString(fmt.Sprintf("\terr := %s.BaseRepository.DB().WithContext(ctx).Where(sql, args...).First(&dataObj).Error\n", receiver))
+	sb.WriteString(fmt.Sprintf("\tleftSQL, leftArgs := query.%s.%s.Eq(%s).Build()\n", agg.Name, left.Name, leftParam))
+	sb.WriteString(fmt.Sprintf("\trightSQL, rightArgs := query.%s.%s.Eq(%s).Build()\n", agg.Name, right.Name, rightParam))
+	sb.WriteString(fmt.Sprintf("\terr := %s.DB().WithContext(ctx).Where(leftSQL, leftArgs...).Where(rightSQL, rightArgs...).First(&dataObj).Error\n", base))
 	sb.WriteString("\n")
 	sb.WriteString("\tif err != nil {\n")
 	sb.WriteString("\t\tif errors.Is(err, gorm.ErrRecordNotFound) {\n")
@@ -195,43 +704,437 @@ func (g *RepositoryImplGenerator) generateGetByUniqueMethod(agg *metadata.Aggreg
 	sb.WriteString("\t\treturn nil, err\n")
 	sb.WriteString("\t}\n")
 	sb.WriteString("\n")
-	sb.WriteString(fmt.Sprintf("\treturn convertor.%sToDomain(&dataObj), nil\n", agg.Name))
-	sb.WriteString("}\n")
+	sb.WriteString(fmt.Sprintf("\treturn %s.ToDomain(&dataObj)\n", base))
+	getLink := &GoFunc{
+		Doc:      fmt.Sprintf("GetLink 查询 %s 与 %s 的关联（%s + %s），不存在时返回 framework.ErrRecordNotFound", left.RefAggregate(), right.RefAggregate(), left.Name, right.Name),
+		Receiver: implReceiver,
+		Name:     "GetLink",
+		Params:   "ctx context.Context, " + params,
+		Results:  fmt.Sprintf("(*%s, error)", entityType),
+		Body:     sb.String(),
+	}
 
-	return sb.String()
+	// Link
+	sb.Reset()
+	sb.WriteString(fmt.Sprintf("\texisting, err := %s.GetLink(ctx, link.%s, link.%s)\n", receiver, left.Name, right.Name))
+	sb.WriteString("\tif errors.Is(err, framework.ErrRecordNotFound) {\n")
+	sb.WriteString(fmt.Sprintf("\t\treturn %s.Add(ctx, link)\n", base))
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString("\tlink.SetID(existing.GetID())\n")
+	if agg.BaseEntity != nil && agg.BaseEntity.HasVersion {
+		version := agg.BaseEntity.VersionField.Name
+		sb.WriteString(fmt.Sprintf("\tlink.%s = existing.%s\n", version, version))
+	}
+	sb.WriteString(fmt.Sprintf("\treturn %s.Update(ctx, link)\n", base))
+	link := &GoFunc{
+		Doc:      fmt.Sprintf("Link 建立 %s 与 %s 的关联：不存在时新增，已存在时更新附加属性（沿用已有记录的主键）", left.RefAggregate(), right.RefAggregate()),
+		Receiver: implReceiver,
+		Name:     "Link",
+		Params:   "ctx context.Context, link *" + entityType,
+		Results:  "error",
+		Body:     sb.String(),
+	}
+
+	// Unlink
+	sb.Reset()
+	sb.WriteString(fmt.Sprintf("\texisting, err := %s.GetLink(ctx, %s, %s)\n", receiver, leftParam, rightParam))
+	sb.WriteString("\tif errors.Is(err, framework.ErrRecordNotFound) {\n")
+	sb.WriteString("\t\treturn nil\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("\treturn %s.Delete(ctx, existing.GetID())\n", base))
+	unlink := &GoFunc{
+		Doc:      fmt.Sprintf("Unlink 解除 %s 与 %s 的关联（硬删除关联记录），关联不存在时不报错", left.RefAggregate(), right.RefAggregate()),
+		Receiver: implReceiver,
+		Name:     "Unlink",
+		Params:   "ctx context.Context, " + params,
+		Results:  "error",
+		Body:     sb.String(),
+	}
+
+	return []*GoFunc{getLink, link, unlink}
 }
 
-// generateGetByIndexMethod 生成索引字段查询方法
-func (g *RepositoryImplGenerator) generateGetByIndexMethod(agg *metadata.AggregateMetadata, field *metadata.FieldMetadata) string {
+// generateJoinMethodsImpl 生成多对多纯关联表的关联管理方法实现
+//
+// 以聚合根为左侧创建 framework.ManyToManyRepository：建立和解除关联都是幂等的，替换在一个事务中只增删有变化的关联。
+// 分页查询以关联表的子查询过滤另一侧的记录。
+func (g *RepositoryImplGenerator) generateJoinMethodsImpl(link *joinLink) []*GoFunc {
+	owner, other := link.owner, link.other
+	receiver := strings.ToLower(string(owner.Name[0]))
+	implReceiver := fmt.Sprintf("%s *%sRepositoryImpl", receiver, owner.Name)
+	base := receiver + "." + baseRepositoryField(owner)
+	links := toLowerFirst(other.Name) + "Links"
+	ownerParam, otherParam := link.ownerParam(), link.otherParam()
+
+	pair := fmt.Sprintf("*%s.%s, *%s.%s", owner.PackageName, owner.Name, other.PackageName, other.Name)
+	funcs := []*GoFunc{{
+		Doc:      fmt.Sprintf("%s 返回关联表 %s 的仓储（以 %s 为左侧）", links, link.table.TableName, owner.Name),
+		Receiver: implReceiver,
+		Name:     links,
+		Results:  fmt.Sprintf("*framework.ManyToManyRepository[%s]", pair),
+		Body: fmt.Sprintf("\treturn framework.NewManyToManyRepository[%s](%s.DB(), %q, %q, %q)\n",
+			pair, base, link.table.TableName, link.column, link.otherColumn),
+	}}
+
+	for _, method := range link.methods() {
+		var body string
+		switch method.Name {
+		case "Add" + other.Name + "To" + owner.Name:
+			body = fmt.Sprintf("\treturn %s.%s().Attach(ctx, %s, %s)\n", receiver, links, ownerParam, otherParam)
+		case "Remove" + other.Name + "From" + owner.Name:
+			body = fmt.Sprintf("\treturn %s.%s().Detach(ctx, %s, %s)\n", receiver, links, ownerParam, otherParam)
+		case "Replace" + other.PluralName():
+			body = fmt.Sprintf("\treturn %s.%s().ReplaceRights(ctx, %s, %ss)\n", receiver, links, ownerParam, otherParam)
+		default:
+			body = g.listLinkedBody(link, base)
+		}
+		funcs = append(funcs, method.goFunc(implReceiver, body))
+	}
+
+	return funcs
+}
+
+// listLinkedBody 生成 List{Others}Of{Owner} 的方法体
+func (g *RepositoryImplGenerator) listLinkedBody(link *joinLink, base string) string {
 	var sb strings.Builder
 
+	other := link.other
+	idColumn := fmt.Sprintf("query.%s.%s.Column()", other.Name, other.IDField.Name)
+	// 另一侧支持软删除时跳过已删除的记录
+	conds := fmt.Sprintf("Where(%s+\" IN (?)\", linked)", idColumn)
+	if other.BaseEntity != nil && other.BaseEntity.HasDeletedAt {
+		conds += fmt.Sprintf(".Where(query.%s.%s.Column() + \" IS NULL\")", other.Name, other.BaseEntity.DeletedAtField.Name)
+	}
+
+	sb.WriteString(fmt.Sprintf("\tlinked := %s.DB().WithContext(ctx).Table(%q).Select(%q).Where(%q, %s)\n",
+		base, link.table.TableName, link.otherColumn, link.column+" = ?", link.ownerParam()))
+	sb.WriteString(fmt.Sprintf("\tdb := %s.DB().WithContext(ctx).Model(&do.%sDO{}).%s.Session(&gorm.Session{})\n", base, other.Name, conds))
+	sb.WriteString("\n")
+	sb.WriteString("\t// 查询总数\n")
+	sb.WriteString("\tvar total int64\n")
+	sb.WriteString("\tif err := db.Count(&total).Error; err != nil {\n")
+	sb.WriteString("\t\treturn nil, 0, err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString("\t// 分页查询\n")
+	sb.WriteString(fmt.Sprintf("\tvar dataObjs []do.%sDO\n", other.Name))
+	sb.WriteString(fmt.Sprintf("\tif err := db.Order(%s).Offset((page - 1) * pageSize).Limit(pageSize).Find(&dataObjs).Error; err != nil {\n", idColumn))
+	sb.WriteString("\t\treturn nil, 0, err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("\tresult := make([]*%s.%s, len(dataObjs))\n", other.PackageName, other.Name))
+	sb.WriteString("\tfor i := range dataObjs {\n")
+	sb.WriteString(fmt.Sprintf("\t\tentity, err := convertor.%sToDomain(&dataObjs[i])\n", other.Name))
+	sb.WriteString("\t\tif err != nil {\n")
+	sb.WriteString("\t\t\treturn nil, 0, err\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tresult[i] = entity\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString("\treturn result, total, nil\n")
+
+	return sb.String()
+}
+
+// generateHierarchyMethodsImpl 生成树形结构（邻接表）的层级查询方法实现，没有父节点字段时返回空
+//
+// 后代按层级逐层以 IN 查询，祖先沿父节点字段逐个向上查询；已访问的节点会被跳过，数据中存在环时不会死循环。
+func (g *RepositoryImplGenerator) generateHierarchyMethodsImpl(agg *metadata.AggregateMetadata) []*GoFunc {
+	parent := agg.ParentField()
+	if parent == nil {
+		return nil
+	}
+
+	var sb strings.Builder
+	var funcs []*GoFunc
 	receiver := strings.ToLower(string(agg.Name[0]))
+	implReceiver := fmt.Sprintf("%s *%sRepositoryImpl", receiver, agg.Name)
+	base := receiver + "." + baseRepositoryField(agg)
+	keyType := qualifiedKeyType(agg)
+	entityType := fmt.Sprintf("%s.%s", agg.PackageName, agg.Name)
+	column := fmt.Sprintf("query.%s.%s.Column()", agg.Name, parent.Name)
+
+	// 父节点为空的条件及父节点 ID 表达式（node 为当前节点变量）
+	zero := "0"
+	if parent.Type == "string" {
+		zero = `""`
+	}
+	rootCond := fmt.Sprintf(`Where(%s+" = ?", %s)`, column, zero)
+	hasParent := fmt.Sprintf("node.%s != %s", parent.Name, zero)
+	parentID := "node." + parent.Name
+	if parent.IsPointer {
+		rootCond = fmt.Sprintf(`Where(%s + " IS NULL")`, column)
+		hasParent = fmt.Sprintf("node.%s != nil", parent.Name)
+		parentID = "*node." + parent.Name
+	}
+	if parent.Type != keyType {
+		parentID = fmt.Sprintf("%s(%s)", keyType, parentID)
+	}
 
-	sb.WriteString(fmt.Sprintf("// GetBy%s 根据 %s 查询（索引）\n", field.Name, field.Name))
-	sb.WriteString(fmt.Sprintf("func (%s *%sRepositoryImpl) GetBy%s(ctx context.Context, %s %s) ([]*%s.%s, error) {\n",
-		receiver, agg.Name, field.Name, toLowerFirst(field.Name), field.Type, agg.PackageName, agg.Name))
+	// GetRoots
 	sb.WriteString(fmt.Sprintf("\tvar dataObjs []do.%sDO\n", agg.Name))
-	sb.WriteString(fmt.Sprintf("\tcond := query.%s.%s.Eq(%s)\n", agg.Name, field.Name, toLowerFirst(field.Name)))
+	sb.WriteString(fmt.Sprintf("\tif err := %s.DB().WithContext(ctx).%s.Find(&dataObjs).Error; err != nil {\n", base, rootCond))
+	sb.WriteString("\t\treturn nil, err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("\tresult := make([]*%s, len(dataObjs))\n", entityType))
+	sb.WriteString("\tfor i := range dataObjs {\n")
+	sb.WriteString(fmt.Sprintf("\t\tentity, err := %s.ToDomain(&dataObjs[i])\n", base))
+	sb.WriteString("\t\tif err != nil {\n")
+	sb.WriteString("\t\t\treturn nil, err\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tresult[i] = entity\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString("\treturn result, nil\n")
+	funcs = append(funcs, &GoFunc{
+		Doc:      fmt.Sprintf("GetRoots 查询根节点（%s 为空）", parent.Name),
+		Receiver: implReceiver,
+		Name:     "GetRoots",
+		Params:   "ctx context.Context",
+		Results:  fmt.Sprintf("([]*%s, error)", entityType),
+		Body:     sb.String(),
+	})
+
+	// GetAncestors
+	sb.Reset()
+	sb.WriteString(fmt.Sprintf("\tnode, err := %s.FindByID(ctx, id)\n", base))
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn nil, err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("\tvar result []*%s\n", entityType))
+	sb.WriteString(fmt.Sprintf("\tvisited := map[%s]bool{id: true}\n", keyType))
+	sb.WriteString(fmt.Sprintf("\tfor %s {\n", hasParent))
+	sb.WriteString(fmt.Sprintf("\t\tparentID := %s\n", parentID))
+	sb.WriteString("\t\tif visited[parentID] {\n")
+	sb.WriteString("\t\t\tbreak\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tvisited[parentID] = true\n")
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("\t\tnode, err = %s.FindByID(ctx, parentID)\n", base))
+	sb.WriteString("\t\tif err != nil {\n")
+	sb.WriteString("\t\t\treturn nil, err\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tresult = append(result, node)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString("\treturn result, nil\n")
+	funcs = append(funcs, &GoFunc{
+		Doc:      "GetAncestors 查询祖先节点，从父节点到根节点排列，不含自身",
+		Receiver: implReceiver,
+		Name:     "GetAncestors",
+		Params:   "ctx context.Context, id " + keyType,
+		Results:  fmt.Sprintf("([]*%s, error)", entityType),
+		Body:     sb.String(),
+	})
+
+	// GetDescendants
+	sb.Reset()
+	sb.WriteString(fmt.Sprintf("\tvar result []*%s\n", entityType))
+	sb.WriteString(fmt.Sprintf("\tvisited := map[%s]bool{id: true}\n", keyType))
+	sb.WriteString(fmt.Sprintf("\tparentIDs := []%s{id}\n", keyType))
+	sb.WriteString("\tfor len(parentIDs) > 0 {\n")
+	sb.WriteString(fmt.Sprintf("\t\tvar dataObjs []do.%sDO\n", agg.Name))
+	sb.WriteString(fmt.Sprintf("\t\tif err := %s.DB().WithContext(ctx).Where(%s+\" IN ?\", parentIDs).Find(&dataObjs).Error; err != nil {\n", base, column))
+	sb.WriteString("\t\t\treturn nil, err\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString("\t\tparentIDs = parentIDs[:0]\n")
+	sb.WriteString("\t\tfor i := range dataObjs {\n")
+	sb.WriteString(fmt.Sprintf("\t\t\tnode, err := %s.ToDomain(&dataObjs[i])\n", base))
+	sb.WriteString("\t\t\tif err != nil {\n")
+	sb.WriteString("\t\t\t\treturn nil, err\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t\tif visited[node.GetID()] {\n")
+	sb.WriteString("\t\t\t\tcontinue\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t\tvisited[node.GetID()] = true\n")
+	sb.WriteString("\t\t\tresult = append(result, node)\n")
+	sb.WriteString("\t\t\tparentIDs = append(parentIDs, node.GetID())\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString("\treturn result, nil\n")
+	funcs = append(funcs, &GoFunc{
+		Doc:      "GetDescendants 查询全部后代节点，按层级排列，不含自身",
+		Receiver: implReceiver,
+		Name:     "GetDescendants",
+		Params:   "ctx context.Context, id " + keyType,
+		Results:  fmt.Sprintf("([]*%s, error)", entityType),
+		Body:     sb.String(),
+	})
+
+	// GetTree
+	if children := agg.ChildrenField(); children != nil {
+		sb.Reset()
+		sb.WriteString(fmt.Sprintf("\troot, err := %s.FindByID(ctx, id)\n", base))
+		sb.WriteString("\tif err != nil {\n")
+		sb.WriteString("\t\treturn nil, err\n")
+		sb.WriteString("\t}\n")
+		sb.WriteString(fmt.Sprintf("\tdescendants, err := %s.GetDescendants(ctx, id)\n", receiver))
+		sb.WriteString("\tif err != nil {\n")
+		sb.WriteString("\t\treturn nil, err\n")
+		sb.WriteString("\t}\n")
+		sb.WriteString("\n")
+		sb.WriteString(fmt.Sprintf("\tnodes := map[%s]*%s{id: root}\n", keyType, entityType))
+		sb.WriteString("\tfor _, node := range descendants {\n")
+		sb.WriteString("\t\tnodes[node.GetID()] = node\n")
+		sb.WriteString("\t}\n")
+		sb.WriteString("\tfor _, node := range descendants {\n")
+		sb.WriteString(fmt.Sprintf("\t\tif parent, ok := nodes[%s]; ok {\n", parentID))
+		sb.WriteString(fmt.Sprintf("\t\t\tparent.%s = append(parent.%s, node)\n", children.Name, children.Name))
+		sb.WriteString("\t\t}\n")
+		sb.WriteString("\t}\n")
+		sb.WriteString("\n")
+		sb.WriteString("\treturn root, nil\n")
+		funcs = append(funcs, &GoFunc{
+			Doc:      fmt.Sprintf("GetTree 查询以指定节点为根的子树，后代节点逐层填充到 %s", children.Name),
+			Receiver: implReceiver,
+			Name:     "GetTree",
+			Params:   "ctx context.Context, id " + keyType,
+			Results:  fmt.Sprintf("(*%s, error)", entityType),
+			Body:     sb.String(),
+		})
+	}
+
+	return funcs
+}
+
+// generateFindByUniqueMethod 生成唯一字段查询方法
+func (g *RepositoryImplGenerator) generateFindByUniqueMethod(agg *metadata.AggregateMetadata, field *metadata.FieldMetadata) *GoFunc {
+	var sb strings.Builder
+
+	receiver := strings.ToLower(string(agg.Name[0]))
+
+	sb.WriteString(fmt.Sprintf("\tvar dataObj do.%sDO\n", agg.Name))
+	sb.WriteString(fmt.Sprintf("\tcond := query.%s.%s.Eq(%s)\n", agg.Name, field.Name, toDataValue(field, toLowerFirst(field.Name))))
 	sb.WriteString(fmt.Sprintf("\tsql, args := cond.Build()\n"))
-	sb.WriteString(fmt.Sprintf("\terr := %s.BaseRepository.DB().WithContext(ctx).Where(sql, args...).Find(&dataObjs).Error\n", receiver))
+	sb.WriteString(fmt.Sprintf("\terr := %s.%s.DB().WithContext(ctx).Where(sql, args...).First(&dataObj).Error\n", receiver, baseRepositoryField(agg)))
+	sb.WriteString("\n")
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\tif errors.Is(err, gorm.ErrRecordNotFound) {\n")
+	sb.WriteString("\t\t\treturn nil, framework.ErrRecordNotFound\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\treturn nil, err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("\treturn %s.%s.ToDomain(&dataObj)\n", receiver, baseRepositoryField(agg)))
+
+	return &GoFunc{
+		Doc:      fmt.Sprintf("FindBy%s 根据 %s 查询（唯一）", field.Name, field.Name),
+		Receiver: fmt.Sprintf("%s *%sRepositoryImpl", receiver, agg.Name),
+		Name:     "FindBy" + field.Name,
+		Params:   fmt.Sprintf("ctx context.Context, %s %s", toLowerFirst(field.Name), qualifyType(field.Type, agg.PackageName)),
+		Results:  fmt.Sprintf("(*%s.%s, error)", agg.PackageName, agg.Name),
+		Body:     sb.String(),
+	}
+}
+
+// generateListByIndexMethod 生成索引和外键字段的分页查询方法
+// 与 FindPage 一致，page 从 1 开始，先按条件统计总数再取当前页
+func (g *RepositoryImplGenerator) generateListByIndexMethod(agg *metadata.AggregateMetadata, field *metadata.FieldMetadata) *GoFunc {
+	var sb strings.Builder
+
+	receiver := strings.ToLower(string(agg.Name[0]))
+	base := receiver + "." + baseRepositoryField(agg)
+
+	sb.WriteString(fmt.Sprintf("\tvar dataObjs []do.%sDO\n", agg.Name))
+	sb.WriteString("\tvar total int64\n")
+	sb.WriteString(fmt.Sprintf("\tcond := query.%s.%s.Eq(%s)\n", agg.Name, field.Name, toDataValue(field, toLowerFirst(field.Name))))
+	sb.WriteString("\tsql, args := cond.Build()\n")
+	sb.WriteString("\n")
+	sb.WriteString("\t// 查询总数\n")
+	sb.WriteString(fmt.Sprintf("\tif err := %s.DB().WithContext(ctx).Model(&do.%sDO{}).Where(sql, args...).Count(&total).Error; err != nil {\n", base, agg.Name))
+	sb.WriteString("\t\treturn nil, 0, err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString("\t// 分页查询\n")
+	sb.WriteString(fmt.Sprintf("\terr := %s.DB().WithContext(ctx).Where(sql, args...).Offset((page - 1) * pageSize).Limit(pageSize).Find(&dataObjs).Error\n", base))
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn nil, 0, err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("\tresult := make([]*%s.%s, len(dataObjs))\n", agg.PackageName, agg.Name))
+	sb.WriteString("\tfor i := range dataObjs {\n")
+	sb.WriteString(fmt.Sprintf("\t\tentity, err := %s.ToDomain(&dataObjs[i])\n", base))
+	sb.WriteString("\t\tif err != nil {\n")
+	sb.WriteString("\t\t\treturn nil, 0, err\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tresult[i] = entity\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\n")
+	sb.WriteString("\treturn result, total, nil\n")
+
+	return &GoFunc{
+		Doc:      fmt.Sprintf("ListBy%s 根据 %s 分页查询（%s），返回当前页和总数", field.Name, field.Name, finderKind(field)),
+		Receiver: fmt.Sprintf("%s *%sRepositoryImpl", receiver, agg.Name),
+		Name:     "ListBy" + field.Name,
+		Params:   fmt.Sprintf("ctx context.Context, %s %s, page, pageSize int", toLowerFirst(field.Name), qualifyType(field.Type, agg.PackageName)),
+		Results:  fmt.Sprintf("([]*%s.%s, int64, error)", agg.PackageName, agg.Name),
+		Body:     sb.String(),
+	}
+}
+
+// generateGetByPolymorphicMethod 生成多态关联查询方法，按类型字段和 ID 字段同时过滤
+func (g *RepositoryImplGenerator) generateGetByPolymorphicMethod(agg *metadata.AggregateMetadata, field *metadata.FieldMetadata) *GoFunc {
+	var sb strings.Builder
+
+	receiver := strings.ToLower(string(agg.Name[0]))
+	methodName := "GetBy" + field.PolymorphicName()
+	typeField := field.PolymorphicTypeField()
+
+	sb.WriteString(fmt.Sprintf("\tvar dataObjs []do.%sDO\n", agg.Name))
+	sb.WriteString(fmt.Sprintf("\ttypeSQL, typeArgs := query.%s.%s.Eq(%s).Build()\n", agg.Name, typeField, toLowerFirst(typeField)))
+	sb.WriteString(fmt.Sprintf("\tidSQL, idArgs := query.%s.%s.Eq(%s).Build()\n", agg.Name, field.Name, toDataValue(field, toLowerFirst(field.Name))))
+	sb.WriteString(fmt.Sprintf("\terr := %s.%s.DB().WithContext(ctx).Where(typeSQL, typeArgs...).Where(idSQL, idArgs...).Find(&dataObjs).Error\n", receiver, baseRepositoryField(agg)))
 	sb.WriteString("\n")
 	sb.WriteString("\tif err != nil {\n")
 	sb.WriteString("\t\treturn nil, err\n")
 	sb.WriteString("\t}\n")
 	sb.WriteString("\n")
 	sb.WriteString(fmt.Sprintf("\tresult := make([]*%s.%s, len(dataObjs))\n", agg.PackageName, agg.Name))
-	sb.WriteString("\tfor i, dataObj := range dataObjs {\n")
-	sb.WriteString(fmt.Sprintf("\t\tresult[i] = convertor.%sToDomain(&dataObj)\n", agg.Name))
+	sb.WriteString("\tfor i := range dataObjs {\n")
+	sb.WriteString(fmt.Sprintf("\t\tentity, err := %s.%s.ToDomain(&dataObjs[i])\n", receiver, baseRepositoryField(agg)))
+	sb.WriteString("\t\tif err != nil {\n")
+	sb.WriteString("\t\t\treturn nil, err\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tresult[i] = entity\n")
 	sb.WriteString("\t}\n")
 	sb.WriteString("\n")
 	sb.WriteString("\treturn result, nil\n")
-	sb.WriteString("}\n")
 
-	return sb.String()
+	return &GoFunc{
+		Doc:      fmt.Sprintf("%s 根据多态关联查询（%s + %s）", methodName, typeField, field.Name),
+		Receiver: fmt.Sprintf("%s *%sRepositoryImpl", receiver, agg.Name),
+		Name:     methodName,
+		Params:   fmt.Sprintf("ctx context.Context, %s string, %s %s", toLowerFirst(typeField), toLowerFirst(field.Name), qualifyType(field.Type, agg.PackageName)),
+		Results:  fmt.Sprintf("([]*%s.%s, error)", agg.PackageName, agg.Name),
+		Body:     sb.String(),
+	}
 }
 
-// generateGetByRefMethod 生成外键字段查询方法
-func (g *RepositoryImplGenerator) generateGetByRefMethod(agg *metadata.AggregateMetadata, field *metadata.FieldMetadata) string {
-	// ref 字段和 index 字段的实现逻辑相同
-	return g.generateGetByIndexMethod(agg, field)
+// baseRepositoryType 返回仓储实现嵌入的框架基类类型
+// int64 主键使用 BaseRepository[T, D]，其他主键类型使用 BaseRepositoryOf[T, D, K]
+func baseRepositoryType(agg *metadata.AggregateMetadata) string {
+	if keyType := qualifiedKeyType(agg); keyType != "int64" {
+		return fmt.Sprintf("BaseRepositoryOf[*%s.%s, do.%sDO, %s]", agg.PackageName, agg.Name, agg.Name, keyType)
+	}
+	return fmt.Sprintf("BaseRepository[*%s.%s, do.%sDO]", agg.PackageName, agg.Name, agg.Name)
+}
+
+// baseRepositoryField 返回嵌入的框架基类字段名
+func baseRepositoryField(agg *metadata.AggregateMetadata) string {
+	if agg.IDKeyType() != "int64" {
+		return "BaseRepositoryOf"
+	}
+	return "BaseRepository"
 }
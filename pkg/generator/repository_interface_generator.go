@@ -2,9 +2,10 @@ package generator
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
+	"slices"
 	"soliton/pkg/metadata"
+	"strconv"
 	"strings"
 )
 
@@ -13,124 +14,481 @@ import (
 // 生成继承泛型 Repository[T] 的具体仓储接口，并添加扩展方法。
 //
 // 扩展方法生成规则（根据字段注解）：
-//   - +soliton:unique → GetByXxx(ctx, xxx) (*T, error)  返回单个对象
-//   - +soliton:index  → GetByXxx(ctx, xxx) ([]*T, error) 返回列表
-//   - +soliton:ref    → GetByXxx(ctx, xxx) ([]*T, error) 返回列表
+//   - +soliton:unique → FindByXxx(ctx, xxx) (*T, error)  返回单个对象，不存在时返回 framework.ErrRecordNotFound
+//   - +soliton:index  → ListByXxx(ctx, xxx, page, pageSize) ([]*T, int64, error) 分页返回列表和总数
+//   - +soliton:ref    → ListByXxx(ctx, xxx, page, pageSize) ([]*T, int64, error) 分页返回列表和总数
+//   - +soliton:polymorphic → GetByXxx(ctx, xxxType, xxxID) ([]*T, error)，如 AttachableID 生成 GetByAttachable
+//   - 自引用的 +soliton:ref（树形结构的父节点字段）→ GetRoots、GetAncestors、GetDescendants，
+//     同时声明了 Children []*T +soliton:entity 时还生成 GetTree
+//   - 中间实体（+soliton:manyToMany）→ GetLink、Link、Unlink，按关联两端的外部引用字段管理关联及其附加属性
+//   - 多对多纯关联表的两侧（如 User、Role）→ AddRoleToUser、RemoveRoleFromUser、ListRolesOfUser、ReplaceRoles，
+//     直接读写关联表，见 joinLinks
+//   - 一对多关联实体（+soliton:entity 切片）→ LoadXxx(ctx, entities...)，按外键列批量加载，见 loadableRelations；
+//     关联实体不是聚合根自身时还生成 AddWithChildren、UpdateWithChildren 和 FindByIDWithChildren，见 childRelations
+//   - 查询条件字段（见 AggregateMetadata.FilterFields）和排序字段（见 AggregateMetadata.SortFields）→
+//     FindByFilter(ctx, filter, page, pageSize)，同时在接口文件中生成条件结构体 {AggregateName}Filter 和排序方式 {AggregateName}Sort
 //
 // 生成文件：domain/repository/{AggregateName}Repository.go
-type RepositoryInterfaceGenerator struct{}
+type RepositoryInterfaceGenerator struct {
+	fileOutput
+	registry *metadata.AggregateMetadataRegistry
+}
 
 // NewRepositoryInterfaceGenerator 创建仓储接口生成器
 func NewRepositoryInterfaceGenerator() *RepositoryInterfaceGenerator {
 	return &RepositoryInterfaceGenerator{}
 }
 
+// SetRegistry 设置聚合根注册表，用于读取一对多关系的外键列生成关联实体加载方法
+// 未设置时不生成加载方法
+func (g *RepositoryInterfaceGenerator) SetRegistry(registry *metadata.AggregateMetadataRegistry) {
+	g.registry = registry
+}
+
 // Generate 为聚合根生成仓储接口
 func (g *RepositoryInterfaceGenerator) Generate(agg *metadata.AggregateMetadata, outputDir string) error {
-	// 创建输出目录
-	repoDir := filepath.Join(outputDir, "repository")
-	if err := os.MkdirAll(repoDir, 0755); err != nil {
-		return fmt.Errorf("创建输出目录失败: %w", err)
-	}
+	// 输出目录
+	repoDir := filepath.Join(domainDir(agg, outputDir), "repository")
 
 	// 生成文件路径
 	fileName := fmt.Sprintf("%sRepository.go", agg.Name)
 	filePath := filepath.Join(repoDir, fileName)
 
-	// 生成代码
-	code := g.generateCode(agg)
-
 	// 写入文件
-	if err := os.WriteFile(filePath, []byte(code), 0644); err != nil {
+	if err := g.writeTemplate(filePath, "repository_interface", g.generateFile(agg)); err != nil {
 		return fmt.Errorf("写入文件失败: %w", err)
 	}
 
 	return nil
 }
 
-// generateCode 生成仓储接口代码
-func (g *RepositoryInterfaceGenerator) generateCode(agg *metadata.AggregateMetadata) string {
-	var sb strings.Builder
-
-	// 文件头
-	sb.WriteString("// Code generated by soliton. DO NOT EDIT.\n\n")
-	sb.WriteString("package repository\n\n")
+// generateFile 生成仓储接口文件的模板数据
+func (g *RepositoryInterfaceGenerator) generateFile(agg *metadata.AggregateMetadata) *GoFileData {
+	file := &GoFileData{
+		TemplateData: TemplateData{Aggregate: agg, Context: agg.Context()},
+		Package:      "repository",
+	}
 
 	// 导入（使用实际的领域模型 import 路径）
-	sb.WriteString("import (\n")
-	sb.WriteString("\t\"context\"\n")
-	sb.WriteString(fmt.Sprintf("\t\"%s\"\n", agg.ImportPath))
-	sb.WriteString("\t\"soliton/pkg/framework\"\n")
-	sb.WriteString(")\n\n")
-
-	// 接口定义
-	sb.WriteString(fmt.Sprintf("// %sRepository %s 仓储接口\n", agg.Name, agg.Name))
-	sb.WriteString(fmt.Sprintf("type %sRepository interface {\n", agg.Name))
+	file.addImports("context", agg.ImportPath, "soliton/pkg/framework")
+	file.addImports(filterImports(agg)...)
 
 	// 继承泛型接口（使用指针类型，因为 Entity 接口方法定义在指针接收器上）
-	sb.WriteString(fmt.Sprintf("\tframework.Repository[*%s.%s]\n", agg.PackageName, agg.Name))
+	// 非 int64 主键（如 UUID）使用 RepositoryOf 显式指定主键类型
+	base := fmt.Sprintf("framework.Repository[*%s.%s]", agg.PackageName, agg.Name)
+	if keyType := qualifiedKeyType(agg); keyType != "int64" {
+		base = fmt.Sprintf("framework.RepositoryOf[*%s.%s, %s]", agg.PackageName, agg.Name, keyType)
+	}
+	repository := file.addType(fmt.Sprintf("%sRepository %s 仓储接口", agg.Name, agg.Name), agg.Name+"Repository", "interface", &GoField{Type: base})
 
-	// 生成扩展方法
-	extendMethods := g.generateExtendMethods(agg)
-	if extendMethods != "" {
-		sb.WriteString("\n")
-		sb.WriteString(extendMethods)
+	// 扩展方法
+	methods := g.extendMethods(agg)
+	if method := filterMethod(agg, ""); method != nil {
+		methods = append(methods, *method)
 	}
+	for _, method := range methods {
+		repository.Methods = append(repository.Methods, method.goFunc("", ""))
+	}
+
+	// 查询条件和排序方式
+	file.Decls = append(file.Decls, filterTypes(agg)...)
 
-	sb.WriteString("}\n")
+	return file
+}
 
-	return sb.String()
+// interfaceMethod 接口方法的签名，用于生成接口声明和模拟实现
+type interfaceMethod struct {
+	Comment string   // 方法注释（方法名之后的部分）
+	Name    string   // 方法名
+	Params  string   // 参数列表，如 "ctx context.Context, orderNo string"
+	Args    []string // 参数名，如 ["ctx", "orderNo"]
+	Results []string // 返回值类型
 }
 
-// generateExtendMethods 生成扩展方法
-func (g *RepositoryInterfaceGenerator) generateExtendMethods(agg *metadata.AggregateMetadata) string {
-	var sb strings.Builder
+// results 返回方法的返回值，多个返回值带括号，如 (*model.Order, error)
+func (m interfaceMethod) results() string {
+	if len(m.Results) > 1 {
+		return "(" + strings.Join(m.Results, ", ") + ")"
+	}
+	return strings.Join(m.Results, ", ")
+}
+
+// signature 返回方法签名，如 FindByOrderNo(ctx context.Context, orderNo string) (*model.Order, error)
+func (m interfaceMethod) signature() string {
+	return fmt.Sprintf("%s(%s) %s", m.Name, m.Params, m.results())
+}
+
+// goFunc 返回方法的声明，注释为方法名和 Comment；receiver 为实现方法的接收者，声明接口方法时 receiver、body 为空
+func (m interfaceMethod) goFunc(receiver, body string) *GoFunc {
+	return &GoFunc{
+		Doc:      m.Name + " " + m.Comment,
+		Receiver: receiver,
+		Name:     m.Name,
+		Params:   m.Params,
+		Results:  m.results(),
+		Body:     body,
+	}
+}
+
+// extendMethods 返回仓储接口在泛型 Repository[T] 之外的扩展方法
+func (g *RepositoryInterfaceGenerator) extendMethods(agg *metadata.AggregateMetadata) []interfaceMethod {
+	var methods []interfaceMethod
 	// 记录已生成的方法名，避免重复
 	generatedMethods := make(map[string]bool)
+	entityType := fmt.Sprintf("*%s.%s", agg.PackageName, agg.Name)
 
-	for _, field := range agg.Fields {
+	for _, field := range agg.MappedFields() {
 		// 跳过 ID 字段和关联实体字段
 		if (agg.IDField != nil && field.Name == agg.IDField.Name) || field.Annotations.IsEntity {
 			continue
 		}
+		param := toLowerFirst(field.Name)
 
-		methodName := fmt.Sprintf("GetBy%s", field.Name)
-
-		// unique 字段生成 GetByXxx 方法（返回单个对象）
-		if field.Annotations.IsUnique {
-			if !generatedMethods[methodName] {
-				sb.WriteString(fmt.Sprintf("\t// GetBy%s 根据 %s 查询（唯一）\n",
-					field.Name, field.Name))
-				sb.WriteString(fmt.Sprintf("\tGetBy%s(ctx context.Context, %s %s) (*%s.%s, error)\n",
-					field.Name, toLowerFirst(field.Name), field.Type, agg.PackageName, agg.Name))
-				sb.WriteString("\n")
-				generatedMethods[methodName] = true
-			}
+		// unique 字段生成 FindByXxx 方法（返回单个对象）
+		if findMethod := "FindBy" + field.Name; field.Annotations.IsUnique && !generatedMethods[findMethod] {
+			methods = append(methods, interfaceMethod{
+				Comment: fmt.Sprintf("根据 %s 查询（唯一）", field.Name),
+				Name:    findMethod,
+				Params:  fmt.Sprintf("ctx context.Context, %s %s", param, qualifyType(field.Type, agg.PackageName)),
+				Args:    []string{"ctx", param},
+				Results: []string{entityType, "error"},
+			})
+			generatedMethods[findMethod] = true
 		}
 
-		// index 和 ref 字段生成 GetByXxx 方法（返回列表）
+		// index 和 ref 字段生成 ListByXxx 方法（分页返回列表）
 		// 如果同时有 index 和 ref 注解，只生成一个方法
-		if field.Annotations.IsIndex || field.Annotations.IsRef {
-			if !generatedMethods[methodName] {
-				// 构建注释说明
-				var comments []string
-				if field.Annotations.IsIndex {
-					comments = append(comments, "索引")
-				}
-				if field.Annotations.IsRef {
-					comments = append(comments, "外键")
-				}
-				comment := strings.Join(comments, "/")
-
-				sb.WriteString(fmt.Sprintf("\t// GetBy%s 根据 %s 查询（%s）\n",
-					field.Name, field.Name, comment))
-				sb.WriteString(fmt.Sprintf("\tGetBy%s(ctx context.Context, %s %s) ([]*%s.%s, error)\n",
-					field.Name, toLowerFirst(field.Name), field.Type, agg.PackageName, agg.Name))
-				sb.WriteString("\n")
-				generatedMethods[methodName] = true
-			}
+		if listMethod := "ListBy" + field.Name; (field.Annotations.IsIndex || isSingleRef(field)) && !generatedMethods[listMethod] {
+			methods = append(methods, interfaceMethod{
+				Comment: fmt.Sprintf("根据 %s 分页查询（%s），返回当前页和总数", field.Name, finderKind(field)),
+				Name:    listMethod,
+				Params:  fmt.Sprintf("ctx context.Context, %s %s, page, pageSize int", param, qualifyType(field.Type, agg.PackageName)),
+				Args:    []string{"ctx", param, "page", "pageSize"},
+				Results: []string{"[]" + entityType, "int64", "error"},
+			})
+			generatedMethods[listMethod] = true
+		}
+
+		// 多态关联按类型和 ID 查询
+		if polymorphicMethod := "GetBy" + field.PolymorphicName(); field.IsPolymorphic() && !generatedMethods[polymorphicMethod] {
+			typeField := field.PolymorphicTypeField()
+			methods = append(methods, interfaceMethod{
+				Comment: fmt.Sprintf("根据多态关联查询（%s + %s）", typeField, field.Name),
+				Name:    polymorphicMethod,
+				Params:  fmt.Sprintf("ctx context.Context, %s string, %s %s", toLowerFirst(typeField), param, qualifyType(field.Type, agg.PackageName)),
+				Args:    []string{"ctx", toLowerFirst(typeField), param},
+				Results: []string{"[]" + entityType, "error"},
+			})
+			generatedMethods[polymorphicMethod] = true
+		}
+	}
+
+	methods = append(methods, g.hierarchyMethods(agg)...)
+	methods = append(methods, g.associationMethods(agg)...)
+	for _, link := range joinLinks(g.registry, agg) {
+		methods = append(methods, link.methods()...)
+	}
+
+	for _, rel := range loadableRelations(g.registry, agg) {
+		methods = append(methods, interfaceMethod{
+			Comment: fmt.Sprintf("批量加载关联实体 %s（%s.%s 引用 %s）", rel.Field.Name, rel.TargetAggregate, rel.ForeignKey.Name, agg.Name),
+			Name:    "Load" + rel.Field.Name,
+			Params:  fmt.Sprintf("ctx context.Context, entities ...%s", entityType),
+			Args:    []string{"ctx", "entities"},
+			Results: []string{"error"},
+		})
+	}
+	methods = append(methods, childMethods(agg, childRelations(g.registry, agg))...)
+
+	return methods
+}
+
+// childMethods 返回随聚合根一并保存和加载一对多关联实体的方法，relations 为空时返回空
+func childMethods(agg *metadata.AggregateMetadata, relations []*metadata.RelationMetadata) []interfaceMethod {
+	if len(relations) == 0 {
+		return nil
+	}
+
+	entityType := fmt.Sprintf("*%s.%s", agg.PackageName, agg.Name)
+	fields := make([]string, len(relations))
+	for i, rel := range relations {
+		fields[i] = rel.Field.Name
+	}
+	children := strings.Join(fields, "、")
+
+	return []interfaceMethod{
+		{
+			Comment: fmt.Sprintf("在一个事务中添加 %s 及其关联实体（%s），关联实体的外键回填为 %s 的主键", agg.Name, children, agg.Name),
+			Name:    "AddWithChildren",
+			Params:  fmt.Sprintf("ctx context.Context, entity %s", entityType),
+			Args:    []string{"ctx", "entity"},
+			Results: []string{"error"},
+		},
+		{
+			Comment: fmt.Sprintf("在一个事务中更新 %s 并保存其关联实体（%s），已移除的关联实体在 +soliton:cascade(delete) 时删除", agg.Name, children),
+			Name:    "UpdateWithChildren",
+			Params:  fmt.Sprintf("ctx context.Context, entity %s", entityType),
+			Args:    []string{"ctx", "entity"},
+			Results: []string{"error"},
+		},
+		{
+			Comment: fmt.Sprintf("根据 ID 查询 %s 并加载关联实体（%s），不存在时返回 framework.ErrRecordNotFound", agg.Name, children),
+			Name:    "FindByIDWithChildren",
+			Params:  fmt.Sprintf("ctx context.Context, id %s", qualifiedKeyType(agg)),
+			Args:    []string{"ctx", "id"},
+			Results: []string{entityType, "error"},
+		},
+	}
+}
+
+// finderKind 返回 ListByXxx 方法注释中的字段类别，如 "索引/外键"
+func finderKind(field *metadata.FieldMetadata) string {
+	var kinds []string
+	if field.Annotations.IsIndex {
+		kinds = append(kinds, "索引")
+	}
+	if isSingleRef(field) {
+		kinds = append(kinds, "外键")
+	}
+	return strings.Join(kinds, "/")
+}
+
+// associationMethods 返回中间实体（+soliton:manyToMany）的关联管理方法，不是中间实体时返回空
+func (g *RepositoryInterfaceGenerator) associationMethods(agg *metadata.AggregateMetadata) []interfaceMethod {
+	left, right := agg.AssociationEnds()
+	if left == nil {
+		return nil
+	}
+
+	entityType := fmt.Sprintf("%s.%s", agg.PackageName, agg.Name)
+	params := fmt.Sprintf("ctx context.Context, %s %s, %s %s", toLowerFirst(left.Name), left.Type, toLowerFirst(right.Name), right.Type)
+	args := []string{"ctx", toLowerFirst(left.Name), toLowerFirst(right.Name)}
+
+	return []interfaceMethod{
+		{
+			Comment: fmt.Sprintf("查询 %s 与 %s 的关联（%s + %s），不存在时返回 framework.ErrRecordNotFound",
+				left.RefAggregate(), right.RefAggregate(), left.Name, right.Name),
+			Name:    "GetLink",
+			Params:  params,
+			Args:    args,
+			Results: []string{"*" + entityType, "error"},
+		},
+		{
+			Comment: fmt.Sprintf("建立 %s 与 %s 的关联：不存在时新增，已存在时更新附加属性", left.RefAggregate(), right.RefAggregate()),
+			Name:    "Link",
+			Params:  fmt.Sprintf("ctx context.Context, link *%s", entityType),
+			Args:    []string{"ctx", "link"},
+			Results: []string{"error"},
+		},
+		{
+			Comment: fmt.Sprintf("解除 %s 与 %s 的关联，关联不存在时不报错", left.RefAggregate(), right.RefAggregate()),
+			Name:    "Unlink",
+			Params:  params,
+			Args:    args,
+			Results: []string{"error"},
+		},
+	}
+}
+
+// hierarchyMethods 返回树形结构（邻接表）的聚合根的层级查询方法，没有父节点字段时返回空
+func (g *RepositoryInterfaceGenerator) hierarchyMethods(agg *metadata.AggregateMetadata) []interfaceMethod {
+	parent := agg.ParentField()
+	if parent == nil {
+		return nil
+	}
+
+	keyType := qualifiedKeyType(agg)
+	entityType := fmt.Sprintf("*%s.%s", agg.PackageName, agg.Name)
+
+	methods := []interfaceMethod{
+		{
+			Comment: fmt.Sprintf("查询根节点（%s 为空）", parent.Name),
+			Name:    "GetRoots",
+			Params:  "ctx context.Context",
+			Args:    []string{"ctx"},
+			Results: []string{"[]" + entityType, "error"},
+		},
+		{
+			Comment: "查询祖先节点，从父节点到根节点排列，不含自身",
+			Name:    "GetAncestors",
+			Params:  fmt.Sprintf("ctx context.Context, id %s", keyType),
+			Args:    []string{"ctx", "id"},
+			Results: []string{"[]" + entityType, "error"},
+		},
+		{
+			Comment: "查询全部后代节点，按层级排列，不含自身",
+			Name:    "GetDescendants",
+			Params:  fmt.Sprintf("ctx context.Context, id %s", keyType),
+			Args:    []string{"ctx", "id"},
+			Results: []string{"[]" + entityType, "error"},
+		},
+	}
+	if children := agg.ChildrenField(); children != nil {
+		methods = append(methods, interfaceMethod{
+			Comment: fmt.Sprintf("查询以指定节点为根的子树，后代节点逐层填充到 %s", children.Name),
+			Name:    "GetTree",
+			Params:  fmt.Sprintf("ctx context.Context, id %s", keyType),
+			Args:    []string{"ctx", "id"},
+			Results: []string{entityType, "error"},
+		})
+	}
+
+	return methods
+}
+
+// filterCondition {Aggregate}Filter 中的一个查询条件
+type filterCondition struct {
+	Name    string                  // 条件字段名，如 Status、UserIDIn、CreatedAtFrom
+	Type    string                  // 条件字段类型，如 *string、[]int64、*time.Time
+	Op      string                  // SQL 比较运算符：=、IN、>=、<
+	Comment string                  // 条件说明
+	Field   *metadata.FieldMetadata // 比较的字段
+}
+
+// filterConditions 返回 {Aggregate}Filter 的查询条件，按 AggregateMetadata.FilterFields 的顺序排列：
+//   - time.Time 字段 → {Field}From（不早于，含）和 {Field}To（早于，不含）两个时间范围条件
+//   - 其他字段 → {Field} 等于；外部引用和枚举字段还有 {Field}In，为其中之一
+//
+// 条件字段名与排序字段 Sort 或其他条件重名时跳过该条件
+func filterConditions(agg *metadata.AggregateMetadata) []filterCondition {
+	var conditions []filterCondition
+	used := map[string]bool{"Sort": true}
+	add := func(condition filterCondition) {
+		if !used[condition.Name] {
+			used[condition.Name] = true
+			conditions = append(conditions, condition)
+		}
+	}
+
+	for _, field := range agg.FilterFields() {
+		valueType := qualifyType(field.Type, agg.PackageName)
+		if field.BasicType() == "time.Time" {
+			add(filterCondition{Name: field.Name + "From", Type: "*" + valueType, Op: ">=", Comment: field.Name + " 不早于该时间（含）", Field: field})
+			add(filterCondition{Name: field.Name + "To", Type: "*" + valueType, Op: "<", Comment: field.Name + " 早于该时间（不含）", Field: field})
+			continue
+		}
+		add(filterCondition{Name: field.Name, Type: "*" + valueType, Op: "=", Comment: field.Name + " 等于该值", Field: field})
+		if field.Annotations.IsRef || field.IsEnum() {
+			add(filterCondition{Name: field.Name + "In", Type: "[]" + valueType, Op: "IN", Comment: field.Name + " 为其中之一", Field: field})
+		}
+	}
+	return conditions
+}
+
+// sortOption {Aggregate}Sort 的一个取值
+type sortOption struct {
+	Const string                  // 常量名，如 OrderSortByCreatedAtDesc
+	Value string                  // 取值：字段的 JSON 名，降序时带前缀 -，如 -createdAt
+	Desc  bool                    // 是否降序
+	Field *metadata.FieldMetadata // 排序字段
+}
+
+// sortOptions 返回 {Aggregate}Sort 的取值，AggregateMetadata.SortFields 中的每个字段各有升序和降序两个取值
+func sortOptions(agg *metadata.AggregateMetadata) []sortOption {
+	var options []sortOption
+	for _, field := range agg.SortFields() {
+		name := fmt.Sprintf("%sSortBy%s", agg.Name, field.Name)
+		options = append(options,
+			sortOption{Const: name, Value: jsonName(field.Name), Field: field},
+			sortOption{Const: name + "Desc", Value: "-" + jsonName(field.Name), Desc: true, Field: field})
+	}
+	return options
+}
+
+// filterMethod 返回按 {Aggregate}Filter 分页查询的 FindByFilter 方法，没有查询条件和排序字段时返回 nil
+// pkg 为 {Aggregate}Filter 的包名限定，在 repository 包内为空，在其他包中为 "repository."
+func filterMethod(agg *metadata.AggregateMetadata, pkg string) *interfaceMethod {
+	if len(filterConditions(agg)) == 0 && len(sortOptions(agg)) == 0 {
+		return nil
+	}
+	return &interfaceMethod{
+		Comment: fmt.Sprintf("按 %sFilter 中的条件分页查询，返回当前页和总数；排序方式无效时返回 framework.ErrInvalidSort", agg.Name),
+		Name:    "FindByFilter",
+		Params:  fmt.Sprintf("ctx context.Context, filter %s%sFilter, page, pageSize int", pkg, agg.Name),
+		Args:    []string{"ctx", "filter", "page", "pageSize"},
+		Results: []string{fmt.Sprintf("[]*%s.%s", agg.PackageName, agg.Name), "int64", "error"},
+	}
+}
+
+// filterImports 返回 {Aggregate}Filter 的条件字段类型需要的 import（领域模型包除外）
+func filterImports(agg *metadata.AggregateMetadata) []string {
+	var imports []string
+	for _, condition := range filterConditions(agg) {
+		importPath := ""
+		switch field := condition.Field; {
+		case field.ScalarType != nil:
+			importPath = field.ScalarType.ImportPath
+		case strings.HasPrefix(field.Type, "time."):
+			importPath = "time"
+		}
+		if importPath != "" && !slices.Contains(imports, importPath) {
+			imports = append(imports, importPath)
+		}
+	}
+	slices.Sort(imports)
+	return imports
+}
+
+// filterTypes 返回查询条件结构体 {Aggregate}Filter 和排序方式 {Aggregate}Sort 的声明，没有 FindByFilter 方法时返回空
+func filterTypes(agg *metadata.AggregateMetadata) []*GoDecl {
+	if filterMethod(agg, "") == nil {
+		return nil
+	}
+
+	filterType, sortType := agg.Name+"Filter", agg.Name+"Sort"
+	options := sortOptions(agg)
+
+	var fields []*GoField
+	for _, condition := range filterConditions(agg) {
+		fields = append(fields, &GoField{Name: condition.Name, Type: condition.Type, Comment: condition.Comment})
+	}
+	fields = append(fields, &GoField{Name: "Sort", Type: "[]" + sortType, Comment: "排序方式，依次排序，最后按主键升序"})
+	decls := []*GoDecl{{
+		Kind:   declType,
+		Doc:    fmt.Sprintf("%s %s 的查询条件，见 %sRepository.FindByFilter\n为 nil 的条件（切片为空）不参与过滤，其余条件需同时满足", filterType, agg.Name, agg.Name),
+		Name:   filterType,
+		Type:   "struct",
+		Fields: fields,
+	}}
+
+	sortDoc := fmt.Sprintf("%s %s 的排序方式，取值为字段的 JSON 名，前缀 - 表示降序", sortType, agg.Name)
+	if len(options) > 0 {
+		sortDoc += fmt.Sprintf("，可直接用作请求参数（如 sort=%s）", options[len(options)-1].Value)
+	}
+	decls = append(decls, &GoDecl{Kind: declType, Doc: sortDoc, Name: sortType, Type: "string"})
+	if len(options) == 0 {
+		return append(decls, &GoDecl{Kind: declFunc, Func: &GoFunc{
+			Doc:      fmt.Sprintf("Valid 判断是否为 %s 的取值，%s 没有可排序的字段", sortType, agg.Name),
+			Receiver: "s " + sortType,
+			Name:     "Valid",
+			Results:  "bool",
+			Body:     "\treturn false\n",
+		}})
+	}
+
+	values := make([]*GoField, len(options))
+	consts := make([]string, len(options))
+	for i, option := range options {
+		direction := "升序"
+		if option.Desc {
+			direction = "降序"
 		}
+		values[i] = &GoField{Name: option.Const, Type: sortType, Value: strconv.Quote(option.Value), Comment: fmt.Sprintf("按 %s %s", option.Field.Name, direction)}
+		consts[i] = option.Const
 	}
+	decls = append(decls, &GoDecl{Kind: declConst, Doc: sortType + " 的取值", Fields: values})
 
-	return sb.String()
+	var body strings.Builder
+	body.WriteString("\tswitch s {\n")
+	body.WriteString(fmt.Sprintf("\tcase %s:\n", strings.Join(consts, ", ")))
+	body.WriteString("\t\treturn true\n")
+	body.WriteString("\t}\n")
+	body.WriteString("\treturn false\n")
+	return append(decls, &GoDecl{Kind: declFunc, Func: &GoFunc{
+		Doc:      fmt.Sprintf("Valid 判断是否为 %s 的取值", sortType),
+		Receiver: "s " + sortType,
+		Name:     "Valid",
+		Results:  "bool",
+		Body:     body.String(),
+	}})
 }
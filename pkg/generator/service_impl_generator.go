@@ -2,54 +2,68 @@ package generator
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"soliton/pkg/metadata"
+	"strconv"
 	"strings"
 )
 
 // ServiceImplGenerator 领域服务实现生成器
 //
 // 生成嵌入 BaseService[T] 的具体服务实现，包含标记驱动的校验逻辑：
-//   - required：非空校验
-//   - unique：唯一性校验
-//   - ref：外键存在性校验
+//   - required：非空校验，空值的判断见 presenceCheck
+//   - unique：通过仓储的 FindByXxx 做唯一性校验，更新时排除自身
+//   - ref：通过引用聚合根的仓储做外键存在性校验
+//   - polymorphic：多态关联的类型和目标存在性校验，并生成按类型加载目标聚合根的 LoadXxx 方法
 //   - enum：枚举值校验
+//   - validate/length/pattern/email：数值范围、长度和格式校验
+//
+// 校验失败返回 framework.FieldError（违反唯一性时 errors.Is(err, framework.ErrEntityAlreadyExists)，
+// 其余为 framework.ErrValidationFailed），调用方和 REST 接口据此区分校验失败与仓储错误。
+//
+// 聚合根嵌入 framework.EventRecorder 时，Add、AddBatch、Update 在仓储写入成功后
+// 通过 SetEventBus 设置的事件总线发布聚合根记录的领域事件。
 //
 // 生成文件：domain/service/impl/{AggregateName}ServiceImpl.go
-type ServiceImplGenerator struct{}
+type ServiceImplGenerator struct {
+	fileOutput
+	registry *metadata.AggregateMetadataRegistry
+}
 
 // NewServiceImplGenerator 创建领域服务实现生成器
 func NewServiceImplGenerator() *ServiceImplGenerator {
 	return &ServiceImplGenerator{}
 }
 
+// SetRegistry 设置聚合根注册表，用于定位跨限界上下文引用的聚合根仓储
+// 未设置时所有引用的仓储都视为与当前聚合根位于同一上下文
+func (g *ServiceImplGenerator) SetRegistry(registry *metadata.AggregateMetadataRegistry) {
+	g.registry = registry
+}
+
 // Generate 为聚合根生成领域服务实现
 func (g *ServiceImplGenerator) Generate(agg *metadata.AggregateMetadata, outputDir string) error {
 	// 获取绝对路径
 	absOutputDir, _ := filepath.Abs(outputDir)
 
-	// 创建输出目录：service/impl
-	implDir := filepath.Join(absOutputDir, "service", "impl")
-	if err := os.MkdirAll(implDir, 0755); err != nil {
-		return fmt.Errorf("创建输出目录失败: %w", err)
-	}
+	// 输出目录：service/impl
+	implDir := filepath.Join(domainDir(agg, absOutputDir), "service", "impl")
 
 	// 计算各个依赖的 import 路径
 	imports := &serviceImplImports{
 		model:      agg.ImportPath,
-		repository: calculateImportPath(agg.ModuleName, agg.ModuleRoot, filepath.Join(absOutputDir, "repository")),
+		repository: calculateImportPath(agg.ModuleName, agg.ModuleRoot, filepath.Join(domainDir(agg, absOutputDir), "repository")),
 	}
 
+	// 收集外键字段信息
+	refs := g.collectRefFields(agg, absOutputDir)
+
 	// 生成文件路径
 	fileName := fmt.Sprintf("%sServiceImpl.go", agg.Name)
 	filePath := filepath.Join(implDir, fileName)
 
-	// 生成代码
-	code := g.generateCode(agg, imports)
-
 	// 写入文件
-	if err := os.WriteFile(filePath, []byte(code), 0644); err != nil {
+	if err := g.writeTemplate(filePath, "service_impl", g.generateFile(agg, imports, refs)); err != nil {
 		return fmt.Errorf("写入文件失败: %w", err)
 	}
 
@@ -67,137 +81,152 @@ type refFieldInfo struct {
 	FieldName     string // 字段名，如 UserID
 	RefAggregate  string // 引用的聚合根名，如 User
 	RepoFieldName string // 仓储字段名，如 userRepo
+	RepoPackage   string // 仓储接口所在包的引用名，引用其他限界上下文时为别名，如 identityrepository
+	RepoImport    string // 引用其他限界上下文时仓储接口包的 import 路径，同一上下文时为空
 }
 
-// generateCode 生成领域服务实现代码
-func (g *ServiceImplGenerator) generateCode(agg *metadata.AggregateMetadata, imports *serviceImplImports) string {
-	var sb strings.Builder
-
-	// 收集外键字段信息
-	refs := g.collectRefFields(agg)
-
-	// 检查需要哪些包
-	needErrors := false // 有 required/unique 字段时需要
-	needFmt := false    // 有 unique 或 enum 或 ref 字段时需要
-	for _, field := range agg.Fields {
-		if field.Annotations.IsRequired || field.Annotations.IsUnique {
-			needErrors = true
-		}
-		if field.Annotations.IsUnique || len(field.Annotations.EnumValues) > 0 || field.Annotations.IsRef {
-			needFmt = true
-		}
+// generateFile 生成领域服务实现文件的模板数据
+func (g *ServiceImplGenerator) generateFile(agg *metadata.AggregateMetadata, imports *serviceImplImports, refs []*refFieldInfo) *GoFileData {
+	file := &GoFileData{
+		TemplateData: TemplateData{Aggregate: agg, Context: agg.Context()},
+		Package:      "impl",
 	}
 
-	// 文件头
-	sb.WriteString("// Code generated by soliton. DO NOT EDIT.\n\n")
-	sb.WriteString("package impl\n\n")
-
 	// 导入（使用动态计算的 import 路径）
-	sb.WriteString("import (\n")
-	sb.WriteString("\t\"context\"\n")
-	if needErrors {
-		sb.WriteString("\t\"errors\"\n")
+	// 校验失败以 framework.FieldError 返回，errors、fmt 只用于包装查询仓储时的错误
+	file.addImports("context", imports.model, imports.repository, "soliton/pkg/framework")
+	for _, field := range agg.MappedFields() {
+		if field.Annotations.IsUnique {
+			file.addImports("errors")
+		}
+		if field.Annotations.IsUnique || field.IsPolymorphic() {
+			file.addImports("fmt")
+		}
+		if rules := field.Annotations.Validation; rules != nil && !field.IsSlice {
+			if rules.Pattern != "" || rules.IsEmail {
+				file.addImports("regexp")
+			}
+			if rules.MinLength != nil || rules.MaxLength != nil {
+				file.addImports("unicode/utf8")
+			}
+		}
 	}
-	if needFmt {
-		sb.WriteString("\t\"fmt\"\n")
+	for _, ref := range refs {
+		file.addImports("fmt")
+		if ref.RepoImport != "" {
+			file.addImport(ref.RepoPackage, ref.RepoImport)
+		}
 	}
-	sb.WriteString(fmt.Sprintf("\t\"%s\"\n", imports.model))
-	sb.WriteString(fmt.Sprintf("\t\"%s\"\n", imports.repository))
-	sb.WriteString("\t\"soliton/pkg/framework\"\n")
-	sb.WriteString(")\n\n")
 
 	// 结构体定义
-	sb.WriteString(fmt.Sprintf("// %sServiceImpl %s 领域服务实现\n", agg.Name, agg.Name))
-	sb.WriteString(fmt.Sprintf("type %sServiceImpl struct {\n", agg.Name))
-	sb.WriteString(fmt.Sprintf("\tframework.BaseService[*%s.%s]\n", agg.PackageName, agg.Name))
-	sb.WriteString(fmt.Sprintf("\trepository repository.%sRepository\n", agg.Name))
+	fields := []*GoField{
+		{Type: "framework." + baseServiceType(agg)},
+		{Name: "repository", Type: fmt.Sprintf("repository.%sRepository", agg.Name)},
+	}
 	// 添加外键仓储依赖
 	for _, ref := range refs {
-		sb.WriteString(fmt.Sprintf("\t%s repository.%sRepository\n", ref.RepoFieldName, ref.RefAggregate))
+		fields = append(fields, &GoField{Name: ref.RepoFieldName, Type: fmt.Sprintf("%s.%sRepository", ref.RepoPackage, ref.RefAggregate)})
 	}
-	sb.WriteString("}\n\n")
+	if agg.RecordsEvents {
+		fields = append(fields, &GoField{Name: "eventBus", Type: "framework.EventBus"})
+	}
+	file.addType(fmt.Sprintf("%sServiceImpl %s 领域服务实现", agg.Name, agg.Name), agg.Name+"ServiceImpl", "struct", fields...)
 
 	// 构造函数
-	sb.WriteString(g.generateConstructorWithRefs(agg, refs))
-	sb.WriteString("\n")
+	file.addFunc(g.generateConstructorWithRefs(agg, refs))
 
-	// 重写 Add 方法（含校验）
-	sb.WriteString(g.generateAddMethodWithRef(agg, refs))
-	sb.WriteString("\n")
+	// 领域事件总线
+	if agg.RecordsEvents {
+		file.addFunc(g.generateSetEventBus(agg))
+	}
 
-	// 重写 Update 方法（含校验）
-	sb.WriteString(g.generateUpdateMethodWithRef(agg, refs))
-	sb.WriteString("\n")
+	// 重写 Add、AddBatch（逐个校验后批量保存）、Update 方法（含校验）
+	file.addFunc(g.generateAddMethodWithRef(agg, refs))
+	file.addFunc(g.generateAddBatchMethod(agg))
+	file.addFunc(g.generateUpdateMethodWithRef(agg, refs))
 
-	// 生成校验方法
-	sb.WriteString(g.generateValidationMethods(agg))
+	// 校验方法
+	for _, method := range g.generateValidationMethods(agg) {
+		file.addFunc(method)
+	}
 
-	// 生成外键校验方法
-	sb.WriteString(g.generateRefValidation(agg, refs))
+	// 字段规则校验方法及其使用的正则
+	patterns, rules := g.generateRuleValidation(agg)
+	if patterns != nil {
+		file.Decls = append(file.Decls, patterns)
+	}
+	file.addFunc(rules)
 
-	return sb.String()
-}
+	// 外键校验方法
+	file.addFunc(g.generateRefValidation(agg, refs))
 
-// generateConstructor 生成构造函数（无外键依赖）
-func (g *ServiceImplGenerator) generateConstructor(agg *metadata.AggregateMetadata) string {
-	var sb strings.Builder
+	// 多态关联加载方法
+	for _, loader := range g.generatePolymorphicLoaders(agg, refs) {
+		file.addFunc(loader)
+	}
 
-	sb.WriteString(fmt.Sprintf("// New%sService 创建 %s 领域服务实例\n", agg.Name, agg.Name))
-	sb.WriteString(fmt.Sprintf("func New%sService(repo repository.%sRepository) *%sServiceImpl {\n",
-		agg.Name, agg.Name, agg.Name))
-	sb.WriteString(fmt.Sprintf("\treturn &%sServiceImpl{\n", agg.Name))
-	sb.WriteString(fmt.Sprintf("\t\tBaseService: *framework.NewBaseService[*%s.%s](repo),\n", agg.PackageName, agg.Name))
-	sb.WriteString("\t\trepository:  repo,\n")
-	sb.WriteString("\t}\n")
-	sb.WriteString("}\n")
+	return file
+}
 
-	return sb.String()
+// serviceMethod 返回 {Aggregate}ServiceImpl 的方法
+func serviceMethod(agg *metadata.AggregateMetadata, doc, name, params, results, body string) *GoFunc {
+	return &GoFunc{
+		Doc:      doc,
+		Receiver: fmt.Sprintf("%s *%sServiceImpl", strings.ToLower(string(agg.Name[0])), agg.Name),
+		Name:     name,
+		Params:   params,
+		Results:  results,
+		Body:     body,
+	}
+}
+
+// entityParam 返回以聚合根为类型的 entity 参数，如 entity *model.Order
+func entityParam(agg *metadata.AggregateMetadata) string {
+	return fmt.Sprintf("entity *%s.%s", agg.PackageName, agg.Name)
 }
 
 // generateConstructorWithRefs 生成构造函数（带外键仓储依赖）
-func (g *ServiceImplGenerator) generateConstructorWithRefs(agg *metadata.AggregateMetadata, refs []*refFieldInfo) string {
+func (g *ServiceImplGenerator) generateConstructorWithRefs(agg *metadata.AggregateMetadata, refs []*refFieldInfo) *GoFunc {
 	var sb strings.Builder
 
-	sb.WriteString(fmt.Sprintf("// New%sService 创建 %s 领域服务实例\n", agg.Name, agg.Name))
-
-	// 构造函数参数
-	params := []string{fmt.Sprintf("repo repository.%sRepository", agg.Name)}
+	// 构造函数参数，每个参数一行
+	var params strings.Builder
+	params.WriteString(fmt.Sprintf("\n\trepo repository.%sRepository,\n", agg.Name))
 	for _, ref := range refs {
-		params = append(params, fmt.Sprintf("%s repository.%sRepository", ref.RepoFieldName, ref.RefAggregate))
-	}
-
-	sb.WriteString(fmt.Sprintf("func New%sService(\n", agg.Name))
-	for i, param := range params {
-		if i < len(params)-1 {
-			sb.WriteString(fmt.Sprintf("\t%s,\n", param))
-		} else {
-			sb.WriteString(fmt.Sprintf("\t%s,\n", param))
-		}
+		params.WriteString(fmt.Sprintf("\t%s %s.%sRepository,\n", ref.RepoFieldName, ref.RepoPackage, ref.RefAggregate))
 	}
-	sb.WriteString(fmt.Sprintf(") *%sServiceImpl {\n", agg.Name))
 
 	sb.WriteString(fmt.Sprintf("\treturn &%sServiceImpl{\n", agg.Name))
-	sb.WriteString(fmt.Sprintf("\t\tBaseService: *framework.NewBaseService[*%s.%s](repo),\n", agg.PackageName, agg.Name))
+	sb.WriteString(fmt.Sprintf("\t\t%s: *framework.New%s(repo),\n", baseServiceField(agg), baseServiceType(agg)))
 	sb.WriteString("\t\trepository:  repo,\n")
 	for _, ref := range refs {
 		sb.WriteString(fmt.Sprintf("\t\t%s: %s,\n", ref.RepoFieldName, ref.RepoFieldName))
 	}
 	sb.WriteString("\t}\n")
-	sb.WriteString("}\n")
 
-	return sb.String()
+	return &GoFunc{
+		Doc:     fmt.Sprintf("New%sService 创建 %s 领域服务实例", agg.Name, agg.Name),
+		Name:    fmt.Sprintf("New%sService", agg.Name),
+		Params:  params.String(),
+		Results: fmt.Sprintf("*%sServiceImpl", agg.Name),
+		Body:    sb.String(),
+	}
+}
+
+// generateSetEventBus 生成设置领域事件总线的 SetEventBus 方法（聚合根嵌入 framework.EventRecorder 时）
+func (g *ServiceImplGenerator) generateSetEventBus(agg *metadata.AggregateMetadata) *GoFunc {
+	receiver := strings.ToLower(string(agg.Name[0]))
+
+	return serviceMethod(agg, "SetEventBus 设置领域事件总线，Add、AddBatch、Update 成功后通过它发布聚合根记录的领域事件\n未设置时记录的事件被丢弃",
+		"SetEventBus", "bus framework.EventBus", "", fmt.Sprintf("\t%s.eventBus = bus\n", receiver))
 }
 
 // generateAddMethodWithRef 生成 Add 方法（含外键校验）
-func (g *ServiceImplGenerator) generateAddMethodWithRef(agg *metadata.AggregateMetadata, refs []*refFieldInfo) string {
+func (g *ServiceImplGenerator) generateAddMethodWithRef(agg *metadata.AggregateMetadata, refs []*refFieldInfo) *GoFunc {
 	var sb strings.Builder
 
 	receiver := strings.ToLower(string(agg.Name[0]))
 
-	sb.WriteString(fmt.Sprintf("// Add 添加实体（含校验）\n"))
-	sb.WriteString(fmt.Sprintf("func (%s *%sServiceImpl) Add(ctx context.Context, entity *%s.%s) error {\n",
-		receiver, agg.Name, agg.PackageName, agg.Name))
-
 	// 生成校验逻辑
 	sb.WriteString("\t// 必填字段校验\n")
 	sb.WriteString(fmt.Sprintf("\tif err := %s.validateRequired(entity); err != nil {\n", receiver))
@@ -214,75 +243,89 @@ func (g *ServiceImplGenerator) generateAddMethodWithRef(agg *metadata.AggregateM
 	sb.WriteString("\t\treturn err\n")
 	sb.WriteString("\t}\n\n")
 
+	sb.WriteString("\t// 字段规则校验（范围、长度、格式）\n")
+	sb.WriteString(fmt.Sprintf("\tif err := %s.validateRules(entity); err != nil {\n", receiver))
+	sb.WriteString("\t\treturn err\n")
+	sb.WriteString("\t}\n\n")
+
 	// 外键存在性校验
 	sb.WriteString("\t// 外键存在性校验\n")
 	sb.WriteString(fmt.Sprintf("\tif err := %s.validateRef(ctx, entity); err != nil {\n", receiver))
 	sb.WriteString("\t\treturn err\n")
 	sb.WriteString("\t}\n\n")
 
-	sb.WriteString("\t// 调用仓储层保存\n")
-	sb.WriteString(fmt.Sprintf("\treturn %s.repository.Add(ctx, entity)\n", receiver))
-	sb.WriteString("}\n")
+	if agg.RecordsEvents {
+		sb.WriteString("\t// 调用仓储层保存，成功后发布聚合根记录的领域事件\n")
+		sb.WriteString(fmt.Sprintf("\tif err := %s.repository.Add(ctx, entity); err != nil {\n", receiver))
+		sb.WriteString("\t\treturn err\n")
+		sb.WriteString("\t}\n")
+		sb.WriteString(fmt.Sprintf("\treturn framework.PublishEvents(ctx, %s.eventBus, entity, entity.GetID())\n", receiver))
+	} else {
+		sb.WriteString("\t// 调用仓储层保存\n")
+		sb.WriteString(fmt.Sprintf("\treturn %s.repository.Add(ctx, entity)\n", receiver))
+	}
 
-	return sb.String()
+	return serviceMethod(agg, "Add 添加实体（含校验）", "Add", "ctx context.Context, "+entityParam(agg), "error", sb.String())
 }
 
-// generateUpdateMethodWithRef 生成 Update 方法（含外键校验）
-func (g *ServiceImplGenerator) generateUpdateMethodWithRef(agg *metadata.AggregateMetadata, refs []*refFieldInfo) string {
+// generateAddBatchMethod 生成 AddBatch 方法
+// 对每个实体执行与 Add 相同的校验，全部通过后再调用仓储批量保存
+func (g *ServiceImplGenerator) generateAddBatchMethod(agg *metadata.AggregateMetadata) *GoFunc {
 	var sb strings.Builder
 
 	receiver := strings.ToLower(string(agg.Name[0]))
 
-	sb.WriteString(fmt.Sprintf("// Update 更新实体（含校验）\n"))
-	sb.WriteString(fmt.Sprintf("func (%s *%sServiceImpl) Update(ctx context.Context, entity *%s.%s) error {\n",
-		receiver, agg.Name, agg.PackageName, agg.Name))
-
-	sb.WriteString("\t// 必填字段校验\n")
-	sb.WriteString(fmt.Sprintf("\tif err := %s.validateRequired(entity); err != nil {\n", receiver))
-	sb.WriteString("\t\treturn err\n")
+	sb.WriteString("\tfor _, entity := range entities {\n")
+	sb.WriteString(fmt.Sprintf("\t\tif err := %s.validateRequired(entity); err != nil {\n", receiver))
+	sb.WriteString("\t\t\treturn err\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString(fmt.Sprintf("\t\tif err := %s.validateUnique(ctx, entity); err != nil {\n", receiver))
+	sb.WriteString("\t\t\treturn err\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString(fmt.Sprintf("\t\tif err := %s.validateEnum(entity); err != nil {\n", receiver))
+	sb.WriteString("\t\t\treturn err\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString(fmt.Sprintf("\t\tif err := %s.validateRules(entity); err != nil {\n", receiver))
+	sb.WriteString("\t\t\treturn err\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString(fmt.Sprintf("\t\tif err := %s.validateRef(ctx, entity); err != nil {\n", receiver))
+	sb.WriteString("\t\t\treturn err\n")
+	sb.WriteString("\t\t}\n")
 	sb.WriteString("\t}\n\n")
 
-	sb.WriteString("\t// 唯一性校验（排除自己）\n")
-	sb.WriteString(fmt.Sprintf("\tif err := %s.validateUniqueExcludeSelf(ctx, entity); err != nil {\n", receiver))
-	sb.WriteString("\t\treturn err\n")
-	sb.WriteString("\t}\n\n")
-
-	sb.WriteString("\t// 枚举值校验\n")
-	sb.WriteString(fmt.Sprintf("\tif err := %s.validateEnum(entity); err != nil {\n", receiver))
-	sb.WriteString("\t\treturn err\n")
-	sb.WriteString("\t}\n\n")
-
-	// 外键存在性校验
-	sb.WriteString("\t// 外键存在性校验\n")
-	sb.WriteString(fmt.Sprintf("\tif err := %s.validateRef(ctx, entity); err != nil {\n", receiver))
-	sb.WriteString("\t\treturn err\n")
-	sb.WriteString("\t}\n\n")
-
-	sb.WriteString("\t// 调用仓储层更新\n")
-	sb.WriteString(fmt.Sprintf("\treturn %s.repository.Update(ctx, entity)\n", receiver))
-	sb.WriteString("}\n")
+	if agg.RecordsEvents {
+		sb.WriteString("\t// 调用仓储层批量保存（单事务），成功后逐个发布聚合根记录的领域事件\n")
+		sb.WriteString(fmt.Sprintf("\tif err := %s.repository.AddBatch(ctx, entities, batchSize); err != nil {\n", receiver))
+		sb.WriteString("\t\treturn err\n")
+		sb.WriteString("\t}\n")
+		sb.WriteString("\tfor _, entity := range entities {\n")
+		sb.WriteString(fmt.Sprintf("\t\tif err := framework.PublishEvents(ctx, %s.eventBus, entity, entity.GetID()); err != nil {\n", receiver))
+		sb.WriteString("\t\t\treturn err\n")
+		sb.WriteString("\t\t}\n")
+		sb.WriteString("\t}\n")
+		sb.WriteString("\treturn nil\n")
+	} else {
+		sb.WriteString("\t// 调用仓储层批量保存（单事务）\n")
+		sb.WriteString(fmt.Sprintf("\treturn %s.repository.AddBatch(ctx, entities, batchSize)\n", receiver))
+	}
 
-	return sb.String()
+	return serviceMethod(agg, "AddBatch 批量添加实体（含校验）", "AddBatch",
+		fmt.Sprintf("ctx context.Context, entities []*%s.%s, batchSize int", agg.PackageName, agg.Name), "error", sb.String())
 }
 
-// generateAddMethod 生成 Add 方法（含校验）
-func (g *ServiceImplGenerator) generateAddMethod(agg *metadata.AggregateMetadata) string {
+// generateUpdateMethodWithRef 生成 Update 方法（含外键校验）
+func (g *ServiceImplGenerator) generateUpdateMethodWithRef(agg *metadata.AggregateMetadata, refs []*refFieldInfo) *GoFunc {
 	var sb strings.Builder
 
 	receiver := strings.ToLower(string(agg.Name[0]))
 
-	sb.WriteString(fmt.Sprintf("// Add 添加实体（含校验）\n"))
-	sb.WriteString(fmt.Sprintf("func (%s *%sServiceImpl) Add(ctx context.Context, entity *%s.%s) error {\n",
-		receiver, agg.Name, agg.PackageName, agg.Name))
-
-	// 生成校验逻辑
 	sb.WriteString("\t// 必填字段校验\n")
 	sb.WriteString(fmt.Sprintf("\tif err := %s.validateRequired(entity); err != nil {\n", receiver))
 	sb.WriteString("\t\treturn err\n")
 	sb.WriteString("\t}\n\n")
 
-	sb.WriteString("\t// 唯一性校验\n")
-	sb.WriteString(fmt.Sprintf("\tif err := %s.validateUnique(ctx, entity); err != nil {\n", receiver))
+	sb.WriteString("\t// 唯一性校验（排除自己）\n")
+	sb.WriteString(fmt.Sprintf("\tif err := %s.validateUniqueExcludeSelf(ctx, entity); err != nil {\n", receiver))
 	sb.WriteString("\t\treturn err\n")
 	sb.WriteString("\t}\n\n")
 
@@ -291,71 +334,53 @@ func (g *ServiceImplGenerator) generateAddMethod(agg *metadata.AggregateMetadata
 	sb.WriteString("\t\treturn err\n")
 	sb.WriteString("\t}\n\n")
 
-	sb.WriteString("\t// 调用仓储层保存\n")
-	sb.WriteString(fmt.Sprintf("\treturn %s.repository.Add(ctx, entity)\n", receiver))
-	sb.WriteString("}\n")
-
-	return sb.String()
-}
-
-// generateUpdateMethod 生成 Update 方法（含校验）
-func (g *ServiceImplGenerator) generateUpdateMethod(agg *metadata.AggregateMetadata) string {
-	var sb strings.Builder
-
-	receiver := strings.ToLower(string(agg.Name[0]))
-
-	sb.WriteString(fmt.Sprintf("// Update 更新实体（含校验）\n"))
-	sb.WriteString(fmt.Sprintf("func (%s *%sServiceImpl) Update(ctx context.Context, entity *%s.%s) error {\n",
-		receiver, agg.Name, agg.PackageName, agg.Name))
-
-	sb.WriteString("\t// 必填字段校验\n")
-	sb.WriteString(fmt.Sprintf("\tif err := %s.validateRequired(entity); err != nil {\n", receiver))
-	sb.WriteString("\t\treturn err\n")
-	sb.WriteString("\t}\n\n")
-
-	sb.WriteString("\t// 唯一性校验（排除自己）\n")
-	sb.WriteString(fmt.Sprintf("\tif err := %s.validateUniqueExcludeSelf(ctx, entity); err != nil {\n", receiver))
+	sb.WriteString("\t// 字段规则校验（范围、长度、格式）\n")
+	sb.WriteString(fmt.Sprintf("\tif err := %s.validateRules(entity); err != nil {\n", receiver))
 	sb.WriteString("\t\treturn err\n")
 	sb.WriteString("\t}\n\n")
 
-	sb.WriteString("\t// 枚举值校验\n")
-	sb.WriteString(fmt.Sprintf("\tif err := %s.validateEnum(entity); err != nil {\n", receiver))
+	// 外键存在性校验
+	sb.WriteString("\t// 外键存在性校验\n")
+	sb.WriteString(fmt.Sprintf("\tif err := %s.validateRef(ctx, entity); err != nil {\n", receiver))
 	sb.WriteString("\t\treturn err\n")
 	sb.WriteString("\t}\n\n")
 
-	sb.WriteString("\t// 调用仓储层更新\n")
-	sb.WriteString(fmt.Sprintf("\treturn %s.repository.Update(ctx, entity)\n", receiver))
-	sb.WriteString("}\n")
+	if agg.RecordsEvents {
+		sb.WriteString("\t// 调用仓储层更新，成功后发布聚合根记录的领域事件\n")
+		sb.WriteString(fmt.Sprintf("\tif err := %s.repository.Update(ctx, entity); err != nil {\n", receiver))
+		sb.WriteString("\t\treturn err\n")
+		sb.WriteString("\t}\n")
+		sb.WriteString(fmt.Sprintf("\treturn framework.PublishEvents(ctx, %s.eventBus, entity, entity.GetID())\n", receiver))
+	} else {
+		sb.WriteString("\t// 调用仓储层更新\n")
+		sb.WriteString(fmt.Sprintf("\treturn %s.repository.Update(ctx, entity)\n", receiver))
+	}
 
-	return sb.String()
+	return serviceMethod(agg, "Update 更新实体（含校验）", "Update", "ctx context.Context, "+entityParam(agg), "error", sb.String())
 }
 
 // generateValidationMethods 生成校验方法
-func (g *ServiceImplGenerator) generateValidationMethods(agg *metadata.AggregateMetadata) string {
+func (g *ServiceImplGenerator) generateValidationMethods(agg *metadata.AggregateMetadata) []*GoFunc {
 	var sb strings.Builder
-
-	receiver := strings.ToLower(string(agg.Name[0]))
+	var methods []*GoFunc
 
 	// 1. 必填字段校验
-	sb.WriteString(fmt.Sprintf("// validateRequired 必填字段校验\n"))
-	sb.WriteString(fmt.Sprintf("func (%s *%sServiceImpl) validateRequired(entity *%s.%s) error {\n",
-		receiver, agg.Name, agg.PackageName, agg.Name))
 
 	hasRequired := false
-	for _, field := range agg.Fields {
-		if field.Annotations.IsRequired {
-			hasRequired = true
-			// 根据类型生成不同的校验逻辑
-			if field.Type == "string" {
-				sb.WriteString(fmt.Sprintf("\tif entity.%s == \"\" {\n", field.Name))
-				sb.WriteString(fmt.Sprintf("\t\treturn errors.New(\"%s 不能为空\")\n", field.Name))
-				sb.WriteString("\t}\n")
-			} else if !field.IsPointer {
-				sb.WriteString(fmt.Sprintf("\tif entity.%s == 0 {\n", field.Name))
-				sb.WriteString(fmt.Sprintf("\t\treturn errors.New(\"%s 不能为空\")\n", field.Name))
-				sb.WriteString("\t}\n")
-			}
+	for _, field := range agg.MappedFields() {
+		if !field.Annotations.IsRequired {
+			continue
 		}
+		hasRequired = true
+		// 根据类型生成不同的校验逻辑
+		empty, _, ok := presenceCheck(field, "entity."+field.Name)
+		if !ok {
+			sb.WriteString(fmt.Sprintf("\t// %s 的类型 %s 没有可判断的空值，跳过必填校验\n", field.Name, field.GoType()))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("\tif %s {\n", empty))
+		sb.WriteString(fmt.Sprintf("\t\treturn framework.NewValidationError(%q, \"%s 不能为空\")\n", field.Name, field.Name))
+		sb.WriteString("\t}\n")
 	}
 
 	if !hasRequired {
@@ -363,191 +388,497 @@ func (g *ServiceImplGenerator) generateValidationMethods(agg *metadata.Aggregate
 	}
 
 	sb.WriteString("\treturn nil\n")
-	sb.WriteString("}\n\n")
+	methods = append(methods, serviceMethod(agg, "validateRequired 必填字段校验", "validateRequired", entityParam(agg), "error", sb.String()))
 
 	// 2. 唯一性校验
-	sb.WriteString(fmt.Sprintf("// validateUnique 唯一性校验\n"))
-	sb.WriteString(fmt.Sprintf("func (%s *%sServiceImpl) validateUnique(ctx context.Context, entity *%s.%s) error {\n",
-		receiver, agg.Name, agg.PackageName, agg.Name))
+	methods = append(methods, serviceMethod(agg, "validateUnique 唯一性校验", "validateUnique",
+		"ctx context.Context, "+entityParam(agg), "error", g.generateUniqueChecks(agg, false)+"\treturn nil\n"))
 
-	hasUnique := false
-	firstUnique := true
-	for _, field := range agg.Fields {
-		if field.Annotations.IsUnique {
-			hasUnique = true
-			sb.WriteString(fmt.Sprintf("\t// %s 唯一性校验\n", field.Name))
-			// 第一个用 :=，后续用 =
-			if firstUnique {
-				sb.WriteString(fmt.Sprintf("\texisting, err := %s.repository.GetBy%s(ctx, entity.%s)\n",
-					receiver, field.Name, field.Name))
-				firstUnique = false
-			} else {
-				sb.WriteString(fmt.Sprintf("\texisting, err = %s.repository.GetBy%s(ctx, entity.%s)\n",
-					receiver, field.Name, field.Name))
+	// 3. 唯一性校验（排除自己）
+	methods = append(methods, serviceMethod(agg, "validateUniqueExcludeSelf 唯一性校验（排除自己）", "validateUniqueExcludeSelf",
+		"ctx context.Context, "+entityParam(agg), "error", g.generateUniqueChecks(agg, true)+"\treturn nil\n"))
+
+	// 4. 枚举值校验
+	sb.Reset()
+
+	hasEnum := false
+	for _, field := range agg.MappedFields() {
+		if len(field.Annotations.EnumValues) > 0 {
+			hasEnum = true
+			sb.WriteString(fmt.Sprintf("\t// %s 枚举校验\n", field.Name))
+			// 指针字段为 nil 时表示未设置，跳过校验
+			indent, field := "\t", field
+			value := "entity." + field.Name
+			if field.IsPointer {
+				sb.WriteString(fmt.Sprintf("\tif entity.%s != nil {\n", field.Name))
+				indent, value = "\t\t", "*"+value
 			}
-			sb.WriteString("\tif err != nil {\n")
-			sb.WriteString("\t\tif !errors.Is(err, framework.ErrRecordNotFound) {\n")
-			sb.WriteString(fmt.Sprintf("\t\t\treturn fmt.Errorf(\"校验 %s 唯一性失败: %%w\", err)\n", field.Name))
-			sb.WriteString("\t\t}\n")
-			sb.WriteString("\t} else if existing != nil {\n")
-			sb.WriteString(fmt.Sprintf("\t\treturn fmt.Errorf(\"%s 已存在: %%v\", entity.%s)\n",
-				field.Name, field.Name))
-			sb.WriteString("\t}\n\n")
+			// 整数枚举按编码校验，统一转换为 int64；命名字符串类型（如 const 块定义的 OrderStatus）需要转换为 string
+			keyType, key, verb := "string", value, "%s"
+			if field.Annotations.IsIntEnum() {
+				keyType, key, verb = "int64", "int64("+value+")", "%d"
+			} else if field.Type != "string" {
+				key = "string(" + value + ")"
+			}
+			sb.WriteString(fmt.Sprintf("%svalid%s := map[%s]bool{\n", indent, field.Name, keyType))
+			for i, code := range field.Annotations.EnumValues {
+				item := fmt.Sprintf("%q", code)
+				if field.Annotations.IsIntEnum() {
+					item = code
+				}
+				// 注释写出整数枚举的名称和枚举值的说明
+				var notes []string
+				if field.Annotations.IsIntEnum() {
+					notes = append(notes, field.Annotations.EnumNames[i])
+				}
+				if label := field.Annotations.EnumLabel(i); label != "" {
+					notes = append(notes, label)
+				}
+				if len(notes) > 0 {
+					sb.WriteString(fmt.Sprintf("%s\t%s: true, // %s\n", indent, item, strings.Join(notes, " ")))
+				} else {
+					sb.WriteString(fmt.Sprintf("%s\t%s: true,\n", indent, item))
+				}
+			}
+			sb.WriteString(fmt.Sprintf("%s}\n", indent))
+			sb.WriteString(fmt.Sprintf("%sif !valid%s[%s] {\n", indent, field.Name, key))
+			sb.WriteString(fmt.Sprintf("%s\treturn framework.NewValidationError(%q, \"%s 值无效: %s\", %s)\n",
+				indent, field.Name, field.Name, verb, value))
+			sb.WriteString(fmt.Sprintf("%s}\n", indent))
+			if field.IsPointer {
+				sb.WriteString("\t}\n")
+			}
+			sb.WriteString("\n")
 		}
 	}
 
-	if !hasUnique {
-		sb.WriteString("\t// 无唯一字段\n")
+	if !hasEnum {
+		sb.WriteString("\t// 无枚举字段\n")
 	}
 
 	sb.WriteString("\treturn nil\n")
-	sb.WriteString("}\n\n")
+	methods = append(methods, serviceMethod(agg, "validateEnum 枚举值校验", "validateEnum", entityParam(agg), "error", sb.String()))
 
-	// 3. 唯一性校验（排除自己）
-	sb.WriteString(fmt.Sprintf("// validateUniqueExcludeSelf 唯一性校验（排除自己）\n"))
-	sb.WriteString(fmt.Sprintf("func (%s *%sServiceImpl) validateUniqueExcludeSelf(ctx context.Context, entity *%s.%s) error {\n",
-		receiver, agg.Name, agg.PackageName, agg.Name))
-
-	if hasUnique {
-		firstUnique = true
-		for _, field := range agg.Fields {
-			if field.Annotations.IsUnique {
-				sb.WriteString(fmt.Sprintf("\t// %s 唯一性校验\n", field.Name))
-				// 第一个用 :=，后续用 =
-				if firstUnique {
-					sb.WriteString(fmt.Sprintf("\texisting, err := %s.repository.GetBy%s(ctx, entity.%s)\n",
-						receiver, field.Name, field.Name))
-					firstUnique = false
-				} else {
-					sb.WriteString(fmt.Sprintf("\texisting, err = %s.repository.GetBy%s(ctx, entity.%s)\n",
-						receiver, field.Name, field.Name))
-				}
-				sb.WriteString("\tif err != nil {\n")
-				sb.WriteString("\t\tif !errors.Is(err, framework.ErrRecordNotFound) {\n")
-				sb.WriteString(fmt.Sprintf("\t\t\treturn fmt.Errorf(\"校验 %s 唯一性失败: %%w\", err)\n", field.Name))
-				sb.WriteString("\t\t}\n")
-				sb.WriteString("\t} else if existing != nil && existing.GetID() != entity.GetID() {\n")
-				sb.WriteString(fmt.Sprintf("\t\treturn fmt.Errorf(\"%s 已存在: %%v\", entity.%s)\n",
-					field.Name, field.Name))
-				sb.WriteString("\t}\n\n")
-			}
+	return methods
+}
+
+// generateUniqueChecks 生成各唯一字段的校验，通过 FindByXxx 查询是否已有相同取值的记录
+// excludeSelf 为 true 时（更新）查到的记录是实体自身不算冲突；字段未设置（空值、nil）时跳过，不与其他未设置的记录冲突
+func (g *ServiceImplGenerator) generateUniqueChecks(agg *metadata.AggregateMetadata, excludeSelf bool) string {
+	var sb strings.Builder
+
+	receiver := strings.ToLower(string(agg.Name[0]))
+	conflict := "existing != nil"
+	if excludeSelf {
+		conflict = "existing != nil && existing.GetID() != entity.GetID()"
+	}
+
+	hasUnique := false
+	for _, field := range agg.MappedFields() {
+		if !field.Annotations.IsUnique {
+			continue
 		}
-	} else {
+		hasUnique = true
+
+		indent := "\t"
+		value := "entity." + field.Name
+		sb.WriteString(fmt.Sprintf("\t// %s 唯一性校验\n", field.Name))
+		if _, present, ok := presenceCheck(field, value); ok {
+			sb.WriteString(fmt.Sprintf("\tif %s {\n", present))
+			indent = "\t\t"
+		}
+		// FindByXxx 的参数为字段的值类型
+		if field.IsPointer {
+			value = "*" + value
+		}
+		sb.WriteString(fmt.Sprintf("%sif existing, err := %s.repository.FindBy%s(ctx, %s); err != nil {\n", indent, receiver, field.Name, value))
+		sb.WriteString(fmt.Sprintf("%s\tif !errors.Is(err, framework.ErrRecordNotFound) {\n", indent))
+		sb.WriteString(fmt.Sprintf("%s\t\treturn fmt.Errorf(\"校验 %s 唯一性失败: %%w\", err)\n", indent, field.Name))
+		sb.WriteString(fmt.Sprintf("%s\t}\n", indent))
+		sb.WriteString(fmt.Sprintf("%s} else if %s {\n", indent, conflict))
+		sb.WriteString(fmt.Sprintf("%s\treturn framework.NewDuplicateError(%q, \"%s 已存在: %%v\", %s)\n", indent, field.Name, field.Name, value))
+		sb.WriteString(fmt.Sprintf("%s}\n", indent))
+		if indent != "\t" {
+			sb.WriteString("\t}\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	if !hasUnique {
 		sb.WriteString("\t// 无唯一字段\n")
 	}
 
-	sb.WriteString("\treturn nil\n")
-	sb.WriteString("}\n\n")
+	return sb.String()
+}
 
-	// 4. 枚举值校验
-	sb.WriteString(fmt.Sprintf("// validateEnum 枚举值校验\n"))
-	sb.WriteString(fmt.Sprintf("func (%s *%sServiceImpl) validateEnum(entity *%s.%s) error {\n",
-		receiver, agg.Name, agg.PackageName, agg.Name))
+// presenceCheck 返回判断字段是否为空的条件表达式（为空、非空），value 为字段的取值，如 entity.Email
+// 指针为 nil、切片和 map 为空、字符串为 ""、数值为 0、time.Time 为零值时视为空；
+// 无法判断空值的类型（bool、定长数组、值对象、未解析底层类型的命名类型等）返回 false
+func presenceCheck(field *metadata.FieldMetadata, value string) (empty, present string, ok bool) {
+	basicType := field.BasicType()
+	switch {
+	case field.IsSlice || field.IsMap:
+		return fmt.Sprintf("len(%s) == 0", value), fmt.Sprintf("len(%s) != 0", value), true
+	case field.IsArray || field.Annotations.IsValueObject || field.Annotations.IsEntity:
+		return "", "", false
+	case field.IsPointer:
+		return value + " == nil", value + " != nil", true
+	case basicType == "time.Time":
+		return value + ".IsZero()", "!" + value + ".IsZero()", true
+	case basicType == "string" || len(field.Annotations.EnumValues) > 0 && !field.Annotations.IsIntEnum():
+		return value + ` == ""`, value + ` != ""`, true
+	case isIntegerType(basicType), basicType == "float32", basicType == "float64", basicType == "time.Duration", field.Annotations.IsIntEnum():
+		return value + " == 0", value + " != 0", true
+	}
+	return "", "", false
+}
 
-	hasEnum := false
-	for _, field := range agg.Fields {
-		if len(field.Annotations.EnumValues) > 0 {
-			hasEnum = true
-			sb.WriteString(fmt.Sprintf("\t// %s 枚举校验\n", field.Name))
-			sb.WriteString(fmt.Sprintf("\tvalid%s := map[string]bool{\n", field.Name))
-			for _, value := range field.Annotations.EnumValues {
-				sb.WriteString(fmt.Sprintf("\t\t\"%s\": true,\n", value))
+// emailRegexp 生成代码中使用的邮箱格式正则
+const emailRegexp = `^[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}$`
+
+// generateRuleValidation 生成字段规则校验方法（+soliton:validate/length/pattern/email）及其使用的正则变量，没有格式规则时变量为 nil
+// 正则在包级变量中预编译；字符串规则只校验非空值，空值是否允许由 required 决定；指针字段为 nil 时跳过
+func (g *ServiceImplGenerator) generateRuleValidation(agg *metadata.AggregateMetadata) (*GoDecl, *GoFunc) {
+	var sb strings.Builder
+
+	prefix := toLowerFirst(agg.Name)
+
+	// 预编译正则
+	var patternVars []*GoField
+	for _, field := range agg.MappedFields() {
+		rules := field.Annotations.Validation
+		if rules == nil || field.IsSlice {
+			continue
+		}
+		if rules.Pattern != "" {
+			patternVars = append(patternVars, &GoField{Name: prefix + field.Name + "Pattern", Value: fmt.Sprintf("regexp.MustCompile(%q)", rules.Pattern)})
+		}
+		if rules.IsEmail {
+			patternVars = append(patternVars, &GoField{Name: prefix + field.Name + "Format", Value: fmt.Sprintf("regexp.MustCompile(%q)", emailRegexp)})
+		}
+	}
+	var patterns *GoDecl
+	if len(patternVars) > 0 {
+		patterns = &GoDecl{Kind: declVar, Doc: agg.Name + " 字段格式校验使用的正则", Fields: patternVars}
+	}
+
+	hasRules := false
+	for _, field := range agg.MappedFields() {
+		rules := field.Annotations.Validation
+		if rules == nil || field.IsSlice {
+			continue
+		}
+		hasRules = true
+
+		indent := "\t"
+		value := "entity." + field.Name
+		sb.WriteString(fmt.Sprintf("\t// %s 规则校验\n", field.Name))
+		if field.IsPointer {
+			sb.WriteString(fmt.Sprintf("\tif entity.%s != nil {\n", field.Name))
+			indent = "\t\t"
+			value = "*entity." + field.Name
+		}
+
+		// 数值范围
+		if rules.Min != nil {
+			bound := strconv.FormatFloat(*rules.Min, 'f', -1, 64)
+			sb.WriteString(fmt.Sprintf("%sif %s < %s {\n", indent, value, bound))
+			sb.WriteString(fmt.Sprintf("%s\treturn framework.NewValidationError(%q, \"%s 不能小于 %s: %%v\", %s)\n", indent, field.Name, field.Name, bound, value))
+			sb.WriteString(fmt.Sprintf("%s}\n", indent))
+		}
+		if rules.Max != nil {
+			bound := strconv.FormatFloat(*rules.Max, 'f', -1, 64)
+			sb.WriteString(fmt.Sprintf("%sif %s > %s {\n", indent, value, bound))
+			sb.WriteString(fmt.Sprintf("%s\treturn framework.NewValidationError(%q, \"%s 不能大于 %s: %%v\", %s)\n", indent, field.Name, field.Name, bound, value))
+			sb.WriteString(fmt.Sprintf("%s}\n", indent))
+		}
+
+		// 字符串规则：空值跳过
+		if rules.MinLength != nil || rules.MaxLength != nil || rules.Pattern != "" || rules.IsEmail {
+			text := value
+			if field.Type != "string" {
+				// 基于 string 的命名类型
+				text = fmt.Sprintf("string(%s)", value)
+			}
+
+			sb.WriteString(fmt.Sprintf("%sif %s != \"\" {\n", indent, value))
+			if rules.MinLength != nil {
+				sb.WriteString(fmt.Sprintf("%s\tif utf8.RuneCountInString(%s) < %d {\n", indent, text, *rules.MinLength))
+				sb.WriteString(fmt.Sprintf("%s\t\treturn framework.NewValidationError(%q, \"%s 长度不能小于 %d: %%q\", %s)\n", indent, field.Name, field.Name, *rules.MinLength, value))
+				sb.WriteString(fmt.Sprintf("%s\t}\n", indent))
+			}
+			if rules.MaxLength != nil {
+				sb.WriteString(fmt.Sprintf("%s\tif utf8.RuneCountInString(%s) > %d {\n", indent, text, *rules.MaxLength))
+				sb.WriteString(fmt.Sprintf("%s\t\treturn framework.NewValidationError(%q, \"%s 长度不能大于 %d: %%q\", %s)\n", indent, field.Name, field.Name, *rules.MaxLength, value))
+				sb.WriteString(fmt.Sprintf("%s\t}\n", indent))
+			}
+			if rules.Pattern != "" {
+				sb.WriteString(fmt.Sprintf("%s\tif !%s%sPattern.MatchString(%s) {\n", indent, prefix, field.Name, text))
+				sb.WriteString(fmt.Sprintf("%s\t\treturn framework.NewValidationError(%q, \"%s 格式不正确: %%q\", %s)\n", indent, field.Name, field.Name, value))
+				sb.WriteString(fmt.Sprintf("%s\t}\n", indent))
+			}
+			if rules.IsEmail {
+				sb.WriteString(fmt.Sprintf("%s\tif !%s%sFormat.MatchString(%s) {\n", indent, prefix, field.Name, text))
+				sb.WriteString(fmt.Sprintf("%s\t\treturn framework.NewValidationError(%q, \"%s 不是有效的邮箱地址: %%q\", %s)\n", indent, field.Name, field.Name, value))
+				sb.WriteString(fmt.Sprintf("%s\t}\n", indent))
 			}
+			sb.WriteString(fmt.Sprintf("%s}\n", indent))
+		}
+
+		if field.IsPointer {
 			sb.WriteString("\t}\n")
-			sb.WriteString(fmt.Sprintf("\tif !valid%s[entity.%s] {\n", field.Name, field.Name))
-			sb.WriteString(fmt.Sprintf("\t\treturn fmt.Errorf(\"%s 值无效: %%s\", entity.%s)\n",
-				field.Name, field.Name))
-			sb.WriteString("\t}\n\n")
 		}
+		sb.WriteString("\n")
 	}
 
-	if !hasEnum {
-		sb.WriteString("\t// 无枚举字段\n")
+	if !hasRules {
+		sb.WriteString("\t// 无字段规则\n")
 	}
 
 	sb.WriteString("\treturn nil\n")
-	sb.WriteString("}\n\n")
 
-	return sb.String()
+	return patterns, serviceMethod(agg, "validateRules 字段规则校验（范围、长度、格式）", "validateRules", entityParam(agg), "error", sb.String())
 }
 
 // collectRefFields 收集所有外键字段信息
-func (g *ServiceImplGenerator) collectRefFields(agg *metadata.AggregateMetadata) []*refFieldInfo {
+// 引用的聚合根属于其他限界上下文时，通过别名导入该上下文的仓储接口包；
+// 引用其他服务中的聚合根（见 isExternalRef）时本模型不生成其仓储，不注入仓储依赖也不校验存在性
+func (g *ServiceImplGenerator) collectRefFields(agg *metadata.AggregateMetadata, absOutputDir string) []*refFieldInfo {
 	var refs []*refFieldInfo
 	seen := make(map[string]bool) // 避免重复
 
-	for _, field := range agg.Fields {
-		if field.Annotations.IsRef {
-			// 从字段名推断聚合根名称
-			// 例如：UserID -> User, OrderID -> Order
-			refAggregate := g.extractRefAggregateName(field.Name)
-			if refAggregate != "" && !seen[refAggregate] {
-				seen[refAggregate] = true
-				refs = append(refs, &refFieldInfo{
-					FieldName:     field.Name,
-					RefAggregate:  refAggregate,
-					RepoFieldName: toLowerFirst(refAggregate) + "Repo",
-				})
+	addRef := func(fieldName, refAggregate string) {
+		if refAggregate == "" || seen[refAggregate] {
+			return
+		}
+		seen[refAggregate] = true
+		ref := &refFieldInfo{
+			FieldName:     fieldName,
+			RefAggregate:  refAggregate,
+			RepoFieldName: toLowerFirst(refAggregate) + "Repo",
+			RepoPackage:   "repository",
+		}
+		if g.registry != nil {
+			if target := g.registry.Get(refAggregate); target != nil && target.Context() != agg.Context() {
+				ref.RepoPackage = strings.ToLower(target.Context()) + "repository"
+				if target.Context() == "" {
+					ref.RepoPackage = "domainrepository"
+				}
+				ref.RepoImport = calculateImportPath(agg.ModuleName, agg.ModuleRoot, filepath.Join(domainDir(target, absOutputDir), "repository"))
 			}
 		}
+		refs = append(refs, ref)
+	}
+
+	for _, field := range agg.MappedFields() {
+		if isSingleRef(field) && !g.isExternalRef(agg, field) {
+			// 引用的聚合根：+soliton:ref(User) 声明的目标，未声明时从字段名推断（UserID -> User）
+			addRef(field.Name, field.RefAggregate())
+		}
+		// 多态关联的每个目标聚合根都需要仓储，用于校验和加载
+		for _, target := range field.Annotations.Polymorphic {
+			addRef(field.Name, target)
+		}
 	}
 
 	return refs
 }
 
-// extractRefAggregateName 从字段名提取引用的聚合根名称
-// UserID -> User, OrderID -> Order, CreatorID -> Creator
-func (g *ServiceImplGenerator) extractRefAggregateName(fieldName string) string {
-	// 如果以 ID 结尾，去掉 ID
-	if strings.HasSuffix(fieldName, "ID") && len(fieldName) > 2 {
-		return fieldName[:len(fieldName)-2]
+// isSingleRef 判断字段是否为保存单个目标 ID 的外部引用
+// 切片、map、数组上的 +soliton:ref 由 ValidateAnnotationConflicts 报告，不生成仓储依赖、存在性校验和 ListBy 查询方法
+func isSingleRef(field *metadata.FieldMetadata) bool {
+	return field.Annotations.IsRef && !field.IsSlice && !field.IsMap && !field.IsArray
+}
+
+// isExternalRef 判断外部引用的目标是否为其他服务中的聚合根（+soliton:external 或 -external 指定）
+func (g *ServiceImplGenerator) isExternalRef(agg *metadata.AggregateMetadata, field *metadata.FieldMetadata) bool {
+	if field.Annotations.IsExternal {
+		return true
+	}
+	if g.registry == nil {
+		return false
+	}
+	for _, relation := range g.registry.GetRelationsByAggregate(agg.Name, metadata.RelationTypeRef) {
+		if relation.Field != nil && relation.Field.Name == field.Name {
+			return relation.External
+		}
+	}
+	return false
+}
+
+// findRef 返回引用指定聚合根的外键信息，没有时返回 nil
+func findRef(refs []*refFieldInfo, refAggregate string) *refFieldInfo {
+	for _, ref := range refs {
+		if ref.RefAggregate == refAggregate {
+			return ref
+		}
+	}
+	return nil
+}
+
+// polymorphicKeyArg 返回以多态关联 ID 作为目标仓储主键参数的表达式，字段类型与目标主键类型不同时做类型转换
+// 可空的 ID 字段解引用，调用方需先确认不为 nil
+func (g *ServiceImplGenerator) polymorphicKeyArg(field *metadata.FieldMetadata, target string) string {
+	keyType := "int64"
+	if g.registry != nil {
+		if agg := g.registry.Get(target); agg != nil {
+			keyType = agg.IDKeyType()
+		}
+	}
+	value := "entity." + field.Name
+	if field.IsPointer {
+		value = "*" + value
 	}
-	// 如果以 Id 结尾，去掉 Id
-	if strings.HasSuffix(fieldName, "Id") && len(fieldName) > 2 {
-		return fieldName[:len(fieldName)-2]
+	if field.Type == keyType {
+		return value
 	}
-	return ""
+	return fmt.Sprintf("%s(%s)", keyType, value)
+}
+
+// generatePolymorphicLoaders 为每个多态关联字段生成 Load{名称} 方法，按类型字段从对应仓储加载目标聚合根
+func (g *ServiceImplGenerator) generatePolymorphicLoaders(agg *metadata.AggregateMetadata, refs []*refFieldInfo) []*GoFunc {
+	var loaders []*GoFunc
+
+	receiver := strings.ToLower(string(agg.Name[0]))
+
+	for _, field := range agg.MappedFields() {
+		if !field.IsPolymorphic() {
+			continue
+		}
+		typeField := field.PolymorphicTypeField()
+
+		var sb strings.Builder
+		// 可空的 ID 字段以 nil 表示未关联
+		if field.IsPointer {
+			sb.WriteString(fmt.Sprintf("\tif entity.%s == nil {\n", field.Name))
+			sb.WriteString("\t\treturn nil, nil\n")
+			sb.WriteString("\t}\n")
+		}
+		sb.WriteString(fmt.Sprintf("\tswitch entity.%s {\n", typeField))
+		sb.WriteString("\tcase \"\":\n")
+		sb.WriteString("\t\treturn nil, nil\n")
+		for _, target := range field.Annotations.Polymorphic {
+			ref := findRef(refs, target)
+			sb.WriteString(fmt.Sprintf("\tcase %q:\n", target))
+			sb.WriteString(fmt.Sprintf("\t\ttarget, err := %s.%s.FindByID(ctx, %s)\n", receiver, ref.RepoFieldName, g.polymorphicKeyArg(field, target)))
+			sb.WriteString("\t\tif err != nil {\n")
+			sb.WriteString("\t\t\treturn nil, err\n")
+			sb.WriteString("\t\t}\n")
+			sb.WriteString("\t\treturn target, nil\n")
+		}
+		sb.WriteString("\tdefault:\n")
+		sb.WriteString(fmt.Sprintf("\t\treturn nil, fmt.Errorf(\"%s 无效: %%s\", entity.%s)\n", typeField, typeField))
+		sb.WriteString("\t}\n")
+		loaders = append(loaders, serviceMethod(agg,
+			fmt.Sprintf("Load%s 按 %s 加载多态关联的聚合根（%s 之一），未关联时返回 nil", field.PolymorphicName(), typeField, strings.Join(field.Annotations.Polymorphic, "、")),
+			"Load"+field.PolymorphicName(), "ctx context.Context, "+entityParam(agg), "(any, error)", sb.String()))
+	}
+
+	return loaders
 }
 
 // generateRefValidation 生成外键存在性校验方法
-func (g *ServiceImplGenerator) generateRefValidation(agg *metadata.AggregateMetadata, refs []*refFieldInfo) string {
+func (g *ServiceImplGenerator) generateRefValidation(agg *metadata.AggregateMetadata, refs []*refFieldInfo) *GoFunc {
 	var sb strings.Builder
 
 	receiver := strings.ToLower(string(agg.Name[0]))
-
-	sb.WriteString(fmt.Sprintf("// validateRef 外键存在性校验\n"))
-	sb.WriteString(fmt.Sprintf("func (%s *%sServiceImpl) validateRef(ctx context.Context, entity *%s.%s) error {\n",
-		receiver, agg.Name, agg.PackageName, agg.Name))
+	method := func(body string) *GoFunc {
+		return serviceMethod(agg, "validateRef 外键存在性校验", "validateRef", "ctx context.Context, "+entityParam(agg), "error", body)
+	}
 
 	if len(refs) == 0 {
-		sb.WriteString("\t// 无外键字段\n")
-		sb.WriteString("\treturn nil\n")
-		sb.WriteString("}\n\n")
-		return sb.String()
+		return method("\t// 无外键字段\n\treturn nil\n")
 	}
 
 	for _, ref := range refs {
 		// 获取该外键字段的所有字段（可能有多个字段引用同一个聚合根）
-		for _, field := range agg.Fields {
-			if field.Annotations.IsRef && g.extractRefAggregateName(field.Name) == ref.RefAggregate {
+		for _, field := range agg.MappedFields() {
+			if isSingleRef(field) && !g.isExternalRef(agg, field) && field.RefAggregate() == ref.RefAggregate {
 				sb.WriteString(fmt.Sprintf("\t// %s 外键存在性校验\n", field.Name))
-				sb.WriteString(fmt.Sprintf("\tif entity.%s != 0 {\n", field.Name))
-				sb.WriteString(fmt.Sprintf("\t\texists, err := %s.%s.Exists(ctx, entity.%s)\n",
-					receiver, ref.RepoFieldName, field.Name))
+				// string 外键（如 UUID）以空字符串表示未设置
+				zeroValue, verb := "0", "%%d"
+				if field.Type == "string" {
+					zeroValue, verb = `""`, "%%s"
+				}
+				// 可空外键（如树形结构根节点的 ParentID）以 nil 表示未设置
+				value := "entity." + field.Name
+				if field.IsPointer {
+					zeroValue = "nil"
+					value = "*" + value
+				}
+				sb.WriteString(fmt.Sprintf("\tif entity.%s != %s {\n", field.Name, zeroValue))
+				sb.WriteString(fmt.Sprintf("\t\texists, err := %s.%s.Exists(ctx, %s)\n",
+					receiver, ref.RepoFieldName, value))
 				sb.WriteString("\t\tif err != nil {\n")
 				sb.WriteString(fmt.Sprintf("\t\t\treturn fmt.Errorf(\"校验 %s 失败: %%w\", err)\n", field.Name))
 				sb.WriteString("\t\t}\n")
 				sb.WriteString("\t\tif !exists {\n")
-				sb.WriteString(fmt.Sprintf("\t\t\treturn fmt.Errorf(\"%s 不存在: %%d\", entity.%s)\n",
-					ref.RefAggregate, field.Name))
+				sb.WriteString(fmt.Sprintf("\t\t\treturn framework.NewValidationError(%q, \"%s 不存在: "+verb+"\", %s)\n",
+					field.Name, ref.RefAggregate, value))
 				sb.WriteString("\t\t}\n")
 				sb.WriteString("\t}\n\n")
 			}
 		}
 	}
 
+	// 多态关联：类型必须是声明的聚合根之一，且目标存在
+	for _, field := range agg.MappedFields() {
+		if !field.IsPolymorphic() {
+			continue
+		}
+		typeField := field.PolymorphicTypeField()
+		zeroValue, verb := "0", "%%d"
+		if field.Type == "string" {
+			zeroValue, verb = `""`, "%%s"
+		}
+		// 可空的 ID 字段以 nil 表示未关联
+		value := "entity." + field.Name
+		if field.IsPointer {
+			zeroValue = "nil"
+			value = "*" + value
+		}
+
+		sb.WriteString(fmt.Sprintf("\t// %s 多态关联校验\n", field.PolymorphicName()))
+		sb.WriteString(fmt.Sprintf("\tif entity.%s != %s {\n", field.Name, zeroValue))
+		sb.WriteString("\t\tvar exists bool\n")
+		sb.WriteString("\t\tvar err error\n")
+		sb.WriteString(fmt.Sprintf("\t\tswitch entity.%s {\n", typeField))
+		for _, target := range field.Annotations.Polymorphic {
+			ref := findRef(refs, target)
+			sb.WriteString(fmt.Sprintf("\t\tcase %q:\n", target))
+			sb.WriteString(fmt.Sprintf("\t\t\texists, err = %s.%s.Exists(ctx, %s)\n", receiver, ref.RepoFieldName, g.polymorphicKeyArg(field, target)))
+		}
+		sb.WriteString("\t\tdefault:\n")
+		sb.WriteString(fmt.Sprintf("\t\t\treturn framework.NewValidationError(%q, \"%s 无效: %%s，可选值：%s\", entity.%s)\n",
+			typeField, typeField, strings.Join(field.Annotations.Polymorphic, "、"), typeField))
+		sb.WriteString("\t\t}\n")
+		sb.WriteString("\t\tif err != nil {\n")
+		sb.WriteString(fmt.Sprintf("\t\t\treturn fmt.Errorf(\"校验 %s 失败: %%w\", err)\n", field.Name))
+		sb.WriteString("\t\t}\n")
+		sb.WriteString("\t\tif !exists {\n")
+		sb.WriteString(fmt.Sprintf("\t\t\treturn framework.NewValidationError(%q, \"%%s 不存在: "+verb+"\", entity.%s, %s)\n", field.Name, typeField, value))
+		sb.WriteString("\t\t}\n")
+		sb.WriteString("\t}\n\n")
+	}
+
 	sb.WriteString("\treturn nil\n")
-	sb.WriteString("}\n\n")
 
-	return sb.String()
+	return method(sb.String())
+}
+
+// baseServiceType 返回服务实现嵌入的框架基类类型
+// int64 主键使用 BaseService[T]，其他主键类型使用 BaseServiceOf[T, K]
+func baseServiceType(agg *metadata.AggregateMetadata) string {
+	if keyType := qualifiedKeyType(agg); keyType != "int64" {
+		return fmt.Sprintf("BaseServiceOf[*%s.%s, %s]", agg.PackageName, agg.Name, keyType)
+	}
+	return fmt.Sprintf("BaseService[*%s.%s]", agg.PackageName, agg.Name)
+}
+
+// baseServiceField 返回嵌入的框架基类字段名
+func baseServiceField(agg *metadata.AggregateMetadata) string {
+	if agg.IDKeyType() != "int64" {
+		return "BaseServiceOf"
+	}
+	return "BaseService"
 }
@@ -0,0 +1,117 @@
+package generator
+
+import (
+	"soliton/pkg/metadata"
+	"strings"
+	"testing"
+)
+
+func TestServiceImplRefValidationSkipsCollectionRef(t *testing.T) {
+	agg := parseTestModel(t, "Order", `package model
+
+// User 用户
+//
+// +soliton:aggregate
+type User struct {
+	ID int64 `+"`db:\"id\"`"+`
+}
+
+// Order 订单
+//
+// +soliton:aggregate
+type Order struct {
+	ID int64 `+"`db:\"id\"`"+`
+	// +soliton:ref(User)
+	BuyerID int64 `+"`db:\"buyer_id\"`"+`
+	// +soliton:ref(User)
+	WatcherIDs []int64 `+"`db:\"watcher_ids\"`"+`
+}
+`)
+
+	g := NewServiceImplGenerator()
+	dir := t.TempDir()
+	imports := &serviceImplImports{model: agg.ImportPath, repository: "sample/domain/repository"}
+	code := renderTestTemplate(t, "service_impl", g.generateFile(agg, imports, g.collectRefFields(agg, dir)))
+	assertGoSource(t, code,
+		"if entity.BuyerID != 0 {",
+		"exists, err := o.userRepo.Exists(ctx, entity.BuyerID)",
+	)
+	if strings.Contains(code, "WatcherIDs") {
+		t.Errorf("切片上的外部引用不应生成存在性校验:\n%s", code)
+	}
+}
+
+func TestServiceImplSkipsExternalRef(t *testing.T) {
+	registry := parseTestRegistry(t, `package model
+
+// Order 订单
+//
+// +soliton:aggregate
+type Order struct {
+	ID int64 `+"`db:\"id\"`"+`
+	// +soliton:ref(Customer)
+	CustomerID int64 `+"`db:\"customer_id\"`"+`
+	// +soliton:ref(Payment)
+	// +soliton:external
+	PaymentID *int64 `+"`db:\"payment_id\"`"+`
+}
+`, "Customer")
+	agg := registry.Get("Order")
+
+	g := NewServiceImplGenerator()
+	g.SetRegistry(registry)
+	dir := t.TempDir()
+	refs := g.collectRefFields(agg, dir)
+	if len(refs) != 0 {
+		t.Fatalf("引用其他服务中的聚合根不应生成仓储依赖: %+v", refs)
+	}
+	imports := &serviceImplImports{model: agg.ImportPath, repository: "sample/domain/repository"}
+	code := renderTestTemplate(t, "service_impl", g.generateFile(agg, imports, refs))
+	assertGoSource(t, code, "func NewOrderService( repo repository.OrderRepository, ) *OrderServiceImpl {")
+	for _, unwanted := range []string{"CustomerRepository", "PaymentRepository", `"fmt"`} {
+		if strings.Contains(code, unwanted) {
+			t.Errorf("领域服务不应包含 %s:\n%s", unwanted, code)
+		}
+	}
+
+	di := NewDIGenerator()
+	di.SetRegistry(registry)
+	providers := renderTestTemplate(t, "di_providers", di.generateServices([]*metadata.AggregateMetadata{agg}, dir))
+	assertGoSource(t, providers, "func ProvideOrderService(repo repository.OrderRepository)")
+	if strings.Contains(providers, "CustomerRepository") {
+		t.Errorf("依赖注入不应提供其他服务中的聚合根仓储:\n%s", providers)
+	}
+}
+
+func TestServiceImplPolymorphicPointerID(t *testing.T) {
+	agg := parseTestModel(t, "Attachment", `package model
+
+// Invoice 发票
+//
+// +soliton:aggregate
+type Invoice struct {
+	ID int64 `+"`db:\"id\"`"+`
+}
+
+// Attachment 附件
+//
+// +soliton:aggregate
+type Attachment struct {
+	ID int64 `+"`db:\"id\"`"+`
+	// +soliton:polymorphic(types=Invoice)
+	AttachableID   *int64 `+"`db:\"attachable_id\"`"+`
+	AttachableType string `+"`db:\"attachable_type\"`"+`
+}
+`)
+
+	g := NewServiceImplGenerator()
+	imports := &serviceImplImports{model: agg.ImportPath, repository: "sample/domain/repository"}
+	code := renderTestTemplate(t, "service_impl", g.generateFile(agg, imports, g.collectRefFields(agg, t.TempDir())))
+	assertGoSource(t, code,
+		"if entity.AttachableID != nil {",
+		"exists, err = a.invoiceRepo.Exists(ctx, *entity.AttachableID)",
+		`return framework.NewValidationError("AttachableID", "%s 不存在: %d", entity.AttachableType, *entity.AttachableID)`,
+		"if entity.AttachableID == nil { return nil, nil }",
+		"target, err := a.invoiceRepo.FindByID(ctx, *entity.AttachableID)",
+	)
+}
@@ -2,18 +2,18 @@ package generator
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"soliton/pkg/metadata"
-	"strings"
 )
 
 // ServiceInterfaceGenerator 领域服务接口生成器
 //
-// 生成继承泛型 Service[T] 的具体领域服务接口。
+// 生成继承泛型 Service[T] 的具体领域服务接口，模板为 service_interface。
 //
 // 生成文件：domain/service/{AggregateName}Service.go
-type ServiceInterfaceGenerator struct{}
+type ServiceInterfaceGenerator struct {
+	fileOutput
+}
 
 // NewServiceInterfaceGenerator 创建领域服务接口生成器
 func NewServiceInterfaceGenerator() *ServiceInterfaceGenerator {
@@ -22,54 +22,28 @@ func NewServiceInterfaceGenerator() *ServiceInterfaceGenerator {
 
 // Generate 为聚合根生成领域服务接口
 func (g *ServiceInterfaceGenerator) Generate(agg *metadata.AggregateMetadata, outputDir string) error {
-	// 创建输出目录
-	serviceDir := filepath.Join(outputDir, "service")
-	if err := os.MkdirAll(serviceDir, 0755); err != nil {
-		return fmt.Errorf("创建输出目录失败: %w", err)
-	}
+	// 输出目录
+	serviceDir := filepath.Join(domainDir(agg, outputDir), "service")
 
 	// 生成文件路径
 	fileName := fmt.Sprintf("%sService.go", agg.Name)
 	filePath := filepath.Join(serviceDir, fileName)
 
-	// 生成代码
-	code := g.generateCode(agg)
+	// 多态关联字段生成 LoadXxx 方法
+	data := &ServiceInterfaceData{
+		TemplateData: TemplateData{Aggregate: agg, Context: agg.Context()},
+		KeyType:      qualifiedKeyType(agg),
+	}
+	for _, field := range agg.MappedFields() {
+		if field.IsPolymorphic() {
+			data.Polymorphic = append(data.Polymorphic, field)
+		}
+	}
 
 	// 写入文件
-	if err := os.WriteFile(filePath, []byte(code), 0644); err != nil {
+	if err := g.writeTemplate(filePath, "service_interface", data); err != nil {
 		return fmt.Errorf("写入文件失败: %w", err)
 	}
 
 	return nil
 }
-
-// generateCode 生成领域服务接口代码
-func (g *ServiceInterfaceGenerator) generateCode(agg *metadata.AggregateMetadata) string {
-	var sb strings.Builder
-
-	// 文件头
-	sb.WriteString("// Code generated by soliton. DO NOT EDIT.\n\n")
-	sb.WriteString("package service\n\n")
-
-	// 导入
-	sb.WriteString("import (\n")
-	sb.WriteString(fmt.Sprintf("\t\"%s\"\n", agg.ImportPath))
-	sb.WriteString("\t\"soliton/pkg/framework\"\n")
-	sb.WriteString(")\n\n")
-
-	// 接口定义
-	sb.WriteString(fmt.Sprintf("// %sService %s 领域服务接口\n", agg.Name, agg.Name))
-	sb.WriteString(fmt.Sprintf("type %sService interface {\n", agg.Name))
-
-	// 继承泛型接口（使用指针类型，因为 Entity 接口方法定义在指针接收器上）
-	sb.WriteString(fmt.Sprintf("\tframework.Service[*%s.%s]\n", agg.PackageName, agg.Name))
-
-	// 可以在这里添加扩展业务方法的注释提示
-	sb.WriteString("\n")
-	sb.WriteString("\t// 在此处添加扩展业务方法\n")
-	sb.WriteString(fmt.Sprintf("\t// 例如：PlaceOrder(ctx context.Context, order *%s.Order) error\n", agg.PackageName))
-
-	sb.WriteString("}\n")
-
-	return sb.String()
-}
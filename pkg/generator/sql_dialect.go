@@ -0,0 +1,500 @@
+package generator
+
+import (
+	"fmt"
+	"soliton/pkg/metadata"
+	"strings"
+)
+
+// ddlRenderer 按数据库方言渲染建表脚本和迁移中的 DDL
+type ddlRenderer interface {
+	// database 返回脚本头部注明的数据库，如 "MySQL 5.7+"
+	database() string
+	// begin、end 返回脚本开头和结尾的会话设置，没有时为空
+	begin() string
+	end() string
+	// dropTable 返回删除表的语句
+	dropTable(table *metadata.TableMetadata) string
+	// createTable 返回建表语句，包括表上的索引和注释
+	createTable(table *metadata.TableMetadata) string
+	// foreignKeys 返回所有表创建之后添加外键约束的语句，外键定义在建表语句中时为空
+	foreignKeys(table *metadata.TableMetadata) string
+
+	// 以下用于修改已有表的迁移（见 MigrationGenerator），table 为表名；方言不支持的修改返回以 -- 开头的说明
+
+	// renameTable 返回重命名表的语句
+	renameTable(from, to string) string
+	// addColumn、dropColumn 返回添加、删除列的语句
+	addColumn(table string, column *metadata.ColumnMetadata) string
+	dropColumn(table string, column *metadata.ColumnMetadata) string
+	// alterColumn 返回将列 before 修改为 after 的语句（包括改名），没有需要修改的内容时为空
+	alterColumn(table string, before, after *metadata.ColumnMetadata) string
+	// createIndex、dropIndex 返回创建、删除索引的语句
+	createIndex(table string, index *metadata.TableIndexMetadata) string
+	dropIndex(table string, index *metadata.TableIndexMetadata) string
+	// addForeignKey、dropForeignKey 返回添加、删除外键约束的语句
+	addForeignKey(table string, fk *metadata.ForeignKeyMetadata) string
+	dropForeignKey(table string, fk *metadata.ForeignKeyMetadata) string
+}
+
+// newDDLRenderer 返回方言名称（见 metadata.Schema.Dialect）对应的渲染器，未知方言按 MySQL 渲染
+func newDDLRenderer(dialect string) ddlRenderer {
+	switch dialect {
+	case metadata.DialectPostgres:
+		return postgresDDL{}
+	case metadata.DialectSQLite:
+		return sqliteDDL{}
+	}
+	return mysqlDDL{}
+}
+
+// mysqlDDL MySQL 5.7+ 的 DDL，外键随建表语句定义，建表期间关闭外键检查
+type mysqlDDL struct{}
+
+func (mysqlDDL) database() string {
+	return "MySQL 5.7+\n-- Charset: utf8mb4"
+}
+
+func (mysqlDDL) begin() string {
+	return "SET NAMES utf8mb4;\nSET FOREIGN_KEY_CHECKS = 0;\n\n"
+}
+
+func (mysqlDDL) end() string {
+	return "SET FOREIGN_KEY_CHECKS = 1;\n"
+}
+
+func (mysqlDDL) dropTable(table *metadata.TableMetadata) string {
+	return fmt.Sprintf("DROP TABLE IF EXISTS `%s`;\n", table.Name)
+}
+
+func (d mysqlDDL) createTable(table *metadata.TableMetadata) string {
+	var sb strings.Builder
+
+	// MySQL 不支持部分索引，条件只作为说明保留
+	for _, index := range table.Indexes {
+		if index.Where != "" {
+			sb.WriteString(fmt.Sprintf("-- 索引 %s 的条件 WHERE %s 未生效：MySQL 不支持部分索引\n", index.Name, index.Where))
+		}
+	}
+	sb.WriteString(fmt.Sprintf("CREATE TABLE `%s` (\n", table.Name))
+
+	// 列定义
+	var lines []string
+	for _, column := range table.Columns {
+		lines = append(lines, d.column(column))
+	}
+
+	// 主键定义
+	if len(table.PrimaryKey) > 0 {
+		lines = append(lines, fmt.Sprintf("  PRIMARY KEY (%s)", quoteColumns(table.PrimaryKey)))
+	}
+
+	// 索引（唯一索引在前）
+	for _, index := range table.Indexes {
+		if index.Unique {
+			lines = append(lines, fmt.Sprintf("  UNIQUE KEY `%s` (%s)", index.Name, quoteColumns(index.Columns)))
+		} else {
+			lines = append(lines, fmt.Sprintf("  KEY `%s` (%s)", index.Name, quoteColumns(index.Columns)))
+		}
+	}
+
+	// 外键约束：其他聚合根将本表声明为级联关联实体
+	for _, fk := range table.ForeignKeys {
+		lines = append(lines, fmt.Sprintf("  CONSTRAINT `%s` FOREIGN KEY (`%s`) REFERENCES `%s` (`%s`) ON DELETE %s",
+			fk.Name, fk.Column, fk.RefTable, fk.RefColumn, fk.OnDelete))
+	}
+
+	sb.WriteString(strings.Join(lines, ",\n"))
+	sb.WriteString("\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci")
+	sb.WriteString(fmt.Sprintf(" COMMENT='%s';\n", table.Comment))
+
+	return sb.String()
+}
+
+func (mysqlDDL) foreignKeys(*metadata.TableMetadata) string {
+	return ""
+}
+
+// column 生成列定义
+func (mysqlDDL) column(column *metadata.ColumnMetadata) string {
+	parts := []string{fmt.Sprintf("  `%s`", column.Name), column.Type}
+	if !column.Nullable {
+		parts = append(parts, "NOT NULL")
+	}
+	if column.Default != "" {
+		parts = append(parts, "DEFAULT "+column.Default)
+	}
+	if column.AutoIncrement {
+		parts = append(parts, "AUTO_INCREMENT")
+	}
+	parts = append(parts, fmt.Sprintf("COMMENT '%s'", column.Comment))
+	return strings.Join(parts, " ")
+}
+
+func (mysqlDDL) renameTable(from, to string) string {
+	return fmt.Sprintf("RENAME TABLE `%s` TO `%s`;\n", from, to)
+}
+
+func (d mysqlDDL) addColumn(table string, column *metadata.ColumnMetadata) string {
+	return fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN %s;\n", table, strings.TrimSpace(d.column(column)))
+}
+
+func (mysqlDDL) dropColumn(table string, column *metadata.ColumnMetadata) string {
+	return fmt.Sprintf("ALTER TABLE `%s` DROP COLUMN `%s`;\n", table, column.Name)
+}
+
+// alterColumn MySQL 5.7 没有 RENAME COLUMN，改名时使用 CHANGE COLUMN 同时修改定义
+func (d mysqlDDL) alterColumn(table string, before, after *metadata.ColumnMetadata) string {
+	if before.Name != after.Name {
+		return fmt.Sprintf("ALTER TABLE `%s` CHANGE COLUMN `%s` %s;\n", table, before.Name, strings.TrimSpace(d.column(after)))
+	}
+	return fmt.Sprintf("ALTER TABLE `%s` MODIFY COLUMN %s;\n", table, strings.TrimSpace(d.column(after)))
+}
+
+func (mysqlDDL) createIndex(table string, index *metadata.TableIndexMetadata) string {
+	kind := "INDEX"
+	if index.Unique {
+		kind = "UNIQUE INDEX"
+	}
+	statement := fmt.Sprintf("CREATE %s `%s` ON `%s` (%s);\n", kind, index.Name, table, quoteColumns(index.Columns))
+	if index.Where != "" {
+		statement = fmt.Sprintf("-- 索引 %s 的条件 WHERE %s 未生效：MySQL 不支持部分索引\n", index.Name, index.Where) + statement
+	}
+	return statement
+}
+
+func (mysqlDDL) dropIndex(table string, index *metadata.TableIndexMetadata) string {
+	return fmt.Sprintf("DROP INDEX `%s` ON `%s`;\n", index.Name, table)
+}
+
+func (mysqlDDL) addForeignKey(table string, fk *metadata.ForeignKeyMetadata) string {
+	return fmt.Sprintf("ALTER TABLE `%s` ADD CONSTRAINT `%s` FOREIGN KEY (`%s`) REFERENCES `%s` (`%s`) ON DELETE %s;\n",
+		table, fk.Name, fk.Column, fk.RefTable, fk.RefColumn, fk.OnDelete)
+}
+
+func (mysqlDDL) dropForeignKey(table string, fk *metadata.ForeignKeyMetadata) string {
+	return fmt.Sprintf("ALTER TABLE `%s` DROP FOREIGN KEY `%s`;\n", table, fk.Name)
+}
+
+// quoteColumns 返回以反引号括起、逗号分隔的列名，如 `tenant_id`, `email`
+func quoteColumns(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = "`" + column + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// postgresDDL PostgreSQL 12+ 的 DDL
+//
+// 标识符使用双引号；自增主键为 GENERATED BY DEFAULT AS IDENTITY；索引和注释在建表后单独创建，
+// 部分索引保留 WHERE 条件；外键在所有表创建之后通过 ALTER TABLE 添加，不受建表顺序影响。
+type postgresDDL struct{}
+
+func (postgresDDL) database() string {
+	return "PostgreSQL 12+"
+}
+
+func (postgresDDL) begin() string {
+	return ""
+}
+
+func (postgresDDL) end() string {
+	return ""
+}
+
+func (postgresDDL) dropTable(table *metadata.TableMetadata) string {
+	return fmt.Sprintf("DROP TABLE IF EXISTS %s CASCADE;\n", quoteIdent(table.Name))
+}
+
+func (d postgresDDL) createTable(table *metadata.TableMetadata) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("CREATE TABLE %s (\n", quoteIdent(table.Name)))
+
+	// 列定义
+	var lines []string
+	for _, column := range table.Columns {
+		lines = append(lines, d.column(column))
+	}
+
+	// 主键定义
+	if len(table.PrimaryKey) > 0 {
+		lines = append(lines, fmt.Sprintf("  PRIMARY KEY (%s)", quoteIdents(table.PrimaryKey)))
+	}
+
+	sb.WriteString(strings.Join(lines, ",\n"))
+	sb.WriteString("\n);\n")
+
+	sb.WriteString(createIndexes(table))
+
+	// 表和列注释
+	sb.WriteString(fmt.Sprintf("COMMENT ON TABLE %s IS %s;\n", quoteIdent(table.Name), quoteString(table.Comment)))
+	for _, column := range table.Columns {
+		sb.WriteString(fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s;\n", quoteIdent(table.Name), quoteIdent(column.Name), quoteString(column.Comment)))
+	}
+
+	return sb.String()
+}
+
+func (d postgresDDL) foreignKeys(table *metadata.TableMetadata) string {
+	var sb strings.Builder
+	for _, fk := range table.ForeignKeys {
+		sb.WriteString(d.addForeignKey(table.Name, fk))
+	}
+	return sb.String()
+}
+
+func (postgresDDL) renameTable(from, to string) string {
+	return fmt.Sprintf("ALTER TABLE %s RENAME TO %s;\n", quoteIdent(from), quoteIdent(to))
+}
+
+func (d postgresDDL) addColumn(table string, column *metadata.ColumnMetadata) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;\n", quoteIdent(table), strings.TrimSpace(d.column(column))) +
+		fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s;\n", quoteIdent(table), quoteIdent(column.Name), quoteString(column.Comment))
+}
+
+func (postgresDDL) dropColumn(table string, column *metadata.ColumnMetadata) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;\n", quoteIdent(table), quoteIdent(column.Name))
+}
+
+// alterColumn 依次改名、修改类型（USING 显式转换已有数据）、可空性、默认值和注释
+func (postgresDDL) alterColumn(table string, before, after *metadata.ColumnMetadata) string {
+	var sb strings.Builder
+	name := quoteIdent(after.Name)
+	prefix := "ALTER TABLE " + quoteIdent(table)
+
+	if before.Name != after.Name {
+		sb.WriteString(fmt.Sprintf("%s RENAME COLUMN %s TO %s;\n", prefix, quoteIdent(before.Name), name))
+	}
+	if before.Type != after.Type {
+		sb.WriteString(fmt.Sprintf("%s ALTER COLUMN %s TYPE %s USING %s::%s;\n", prefix, name, after.Type, name, after.Type))
+	}
+	if before.Nullable != after.Nullable {
+		action := "SET NOT NULL"
+		if after.Nullable {
+			action = "DROP NOT NULL"
+		}
+		sb.WriteString(fmt.Sprintf("%s ALTER COLUMN %s %s;\n", prefix, name, action))
+	}
+	if before.Default != after.Default {
+		action := "DROP DEFAULT"
+		if after.Default != "" {
+			action = "SET DEFAULT " + after.Default
+		}
+		sb.WriteString(fmt.Sprintf("%s ALTER COLUMN %s %s;\n", prefix, name, action))
+	}
+	if before.Comment != after.Comment {
+		sb.WriteString(fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s;\n", quoteIdent(table), name, quoteString(after.Comment)))
+	}
+	return sb.String()
+}
+
+func (postgresDDL) createIndex(table string, index *metadata.TableIndexMetadata) string {
+	return createIndex(table, index)
+}
+
+// dropIndex PostgreSQL 的索引名在模式内唯一，不需要表名
+func (postgresDDL) dropIndex(_ string, index *metadata.TableIndexMetadata) string {
+	return fmt.Sprintf("DROP INDEX IF EXISTS %s;\n", quoteIdent(index.Name))
+}
+
+func (postgresDDL) addForeignKey(table string, fk *metadata.ForeignKeyMetadata) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s) ON DELETE %s;\n",
+		quoteIdent(table), quoteIdent(fk.Name), quoteIdent(fk.Column), quoteIdent(fk.RefTable), quoteIdent(fk.RefColumn), fk.OnDelete)
+}
+
+func (postgresDDL) dropForeignKey(table string, fk *metadata.ForeignKeyMetadata) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;\n", quoteIdent(table), quoteIdent(fk.Name))
+}
+
+// column 生成列定义
+func (postgresDDL) column(column *metadata.ColumnMetadata) string {
+	parts := []string{"  " + quoteIdent(column.Name), column.Type}
+	if column.AutoIncrement {
+		parts = append(parts, "GENERATED BY DEFAULT AS IDENTITY")
+	}
+	if !column.Nullable {
+		parts = append(parts, "NOT NULL")
+	}
+	if column.Default != "" {
+		parts = append(parts, "DEFAULT "+column.Default)
+	}
+	return strings.Join(parts, " ")
+}
+
+// createIndexes 返回在建表后逐个创建索引的语句（唯一索引在前），部分索引带 WHERE 条件
+func createIndexes(table *metadata.TableMetadata) string {
+	var sb strings.Builder
+	for _, index := range table.Indexes {
+		sb.WriteString(createIndex(table.Name, index))
+	}
+	return sb.String()
+}
+
+// createIndex 返回在表 table 上创建索引的语句（PostgreSQL、SQLite），部分索引带 WHERE 条件
+func createIndex(table string, index *metadata.TableIndexMetadata) string {
+	kind := "INDEX"
+	if index.Unique {
+		kind = "UNIQUE INDEX"
+	}
+	statement := fmt.Sprintf("CREATE %s %s ON %s (%s)", kind, quoteIdent(index.Name), quoteIdent(table), quoteIdents(index.Columns))
+	if index.Where != "" {
+		statement += " WHERE " + index.Where
+	}
+	return statement + ";\n"
+}
+
+// sqliteDDL SQLite 3 的 DDL
+//
+// 标识符使用双引号；单列自增主键写作 INTEGER PRIMARY KEY AUTOINCREMENT；SQLite 不支持 ALTER TABLE 添加约束，
+// 外键在建表语句中定义（引用的表可以稍后创建），并在建表期间关闭外键检查；不支持注释，列说明以 SQL 注释保留。
+type sqliteDDL struct{}
+
+func (sqliteDDL) database() string {
+	return "SQLite 3"
+}
+
+func (sqliteDDL) begin() string {
+	return "PRAGMA foreign_keys = OFF;\n\n"
+}
+
+func (sqliteDDL) end() string {
+	return "PRAGMA foreign_keys = ON;\n"
+}
+
+func (sqliteDDL) dropTable(table *metadata.TableMetadata) string {
+	return fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", quoteIdent(table.Name))
+}
+
+func (d sqliteDDL) createTable(table *metadata.TableMetadata) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("-- %s\n", table.Comment))
+	sb.WriteString(fmt.Sprintf("CREATE TABLE %s (\n", quoteIdent(table.Name)))
+
+	// 列定义，自增主键在列上声明
+	var lines, comments []string
+	inlineKey := false
+	for _, column := range table.Columns {
+		line := "  " + quoteIdent(column.Name) + " " + column.Type
+		if column.AutoIncrement && len(table.PrimaryKey) == 1 {
+			line = "  " + quoteIdent(column.Name) + " INTEGER PRIMARY KEY AUTOINCREMENT"
+			inlineKey = true
+		} else {
+			if !column.Nullable {
+				line += " NOT NULL"
+			}
+			if column.Default != "" {
+				line += " DEFAULT " + column.Default
+			}
+		}
+		lines = append(lines, line)
+		comments = append(comments, column.Comment)
+	}
+
+	// 主键定义
+	if len(table.PrimaryKey) > 0 && !inlineKey {
+		lines = append(lines, fmt.Sprintf("  PRIMARY KEY (%s)", quoteIdents(table.PrimaryKey)))
+		comments = append(comments, "")
+	}
+
+	// 外键约束
+	for _, fk := range table.ForeignKeys {
+		lines = append(lines, fmt.Sprintf("  CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s) ON DELETE %s",
+			quoteIdent(fk.Name), quoteIdent(fk.Column), quoteIdent(fk.RefTable), quoteIdent(fk.RefColumn), fk.OnDelete))
+		comments = append(comments, "")
+	}
+
+	for i, line := range lines {
+		sb.WriteString(line)
+		if i < len(lines)-1 {
+			sb.WriteString(",")
+		}
+		if comments[i] != "" {
+			sb.WriteString(" -- " + comments[i])
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(");\n")
+
+	sb.WriteString(createIndexes(table))
+
+	return sb.String()
+}
+
+func (sqliteDDL) foreignKeys(*metadata.TableMetadata) string {
+	return ""
+}
+
+func (sqliteDDL) renameTable(from, to string) string {
+	return fmt.Sprintf("ALTER TABLE %s RENAME TO %s;\n", quoteIdent(from), quoteIdent(to))
+}
+
+// addColumn SQLite 添加的列不能是主键或唯一列，NOT NULL 的列必须有非 NULL 的默认值
+func (sqliteDDL) addColumn(table string, column *metadata.ColumnMetadata) string {
+	definition := quoteIdent(column.Name) + " " + column.Type
+	if !column.Nullable {
+		definition += " NOT NULL"
+	}
+	if column.Default != "" {
+		definition += " DEFAULT " + column.Default
+	}
+	statement := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", quoteIdent(table), definition)
+	if column.Comment != "" {
+		statement += " -- " + column.Comment
+	}
+	return statement + "\n"
+}
+
+// dropColumn 需要 SQLite 3.35+
+func (sqliteDDL) dropColumn(table string, column *metadata.ColumnMetadata) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;\n", quoteIdent(table), quoteIdent(column.Name))
+}
+
+// alterColumn SQLite 只支持列改名，修改类型、可空性或默认值需要重建表；列说明不保存在数据库中，无需修改
+func (sqliteDDL) alterColumn(table string, before, after *metadata.ColumnMetadata) string {
+	var sb strings.Builder
+	if before.Name != after.Name {
+		sb.WriteString(fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;\n", quoteIdent(table), quoteIdent(before.Name), quoteIdent(after.Name)))
+	}
+	if before.Type != after.Type || before.Nullable != after.Nullable || before.Default != after.Default {
+		sb.WriteString(fmt.Sprintf("-- SQLite 不支持修改列 %s.%s 的类型、可空性或默认值，需要重建表\n", table, after.Name))
+	}
+	return sb.String()
+}
+
+func (sqliteDDL) createIndex(table string, index *metadata.TableIndexMetadata) string {
+	return createIndex(table, index)
+}
+
+func (sqliteDDL) dropIndex(_ string, index *metadata.TableIndexMetadata) string {
+	return fmt.Sprintf("DROP INDEX IF EXISTS %s;\n", quoteIdent(index.Name))
+}
+
+func (sqliteDDL) addForeignKey(table string, fk *metadata.ForeignKeyMetadata) string {
+	return fmt.Sprintf("-- SQLite 不支持在已有的表 %s 上添加外键约束 %s，需要重建表\n", table, fk.Name)
+}
+
+func (sqliteDDL) dropForeignKey(table string, fk *metadata.ForeignKeyMetadata) string {
+	return fmt.Sprintf("-- SQLite 不支持删除表 %s 上的外键约束 %s，需要重建表\n", table, fk.Name)
+}
+
+// quoteIdent 返回双引号括起的标识符，如 "order_items"
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quoteIdents 返回双引号括起、逗号分隔的标识符，如 "tenant_id", "email"
+func quoteIdents(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdent(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// quoteString 返回单引号括起的字符串字面量，单引号转义为两个单引号
+func quoteString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
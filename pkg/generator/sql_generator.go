@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"soliton/pkg/metadata"
 	"strings"
 	"time"
@@ -11,15 +12,20 @@ import (
 
 // SQLGenerator SQL DDL 生成器
 //
-// 生成 MySQL 建表脚本，包含：
+// 按表结构元数据的方言（MySQL、PostgreSQL、SQLite，见 metadata.ParseDialect）生成建表脚本，包含：
 //   - 表结构定义
 //   - 主键、唯一索引、普通索引
-//   - 外键约束（可选）
+//   - 外键约束（关联实体声明了 +soliton:cascade 时，按级联行为生成 ON DELETE 子句）
 //   - 多对多关联表
 //
 // 生成文件：sql/schema.sql
+//
+// 声明了 +soliton:context 的聚合根按限界上下文拆分脚本，生成到 {context}/sql/schema.sql，
+// 多对多关联表跟随左侧聚合根所在的上下文。
 type SQLGenerator struct {
+	fileOutput
 	registry *metadata.AggregateMetadataRegistry
+	schema   *metadata.Schema
 }
 
 // NewSQLGenerator 创建 SQL 生成器
@@ -29,303 +35,127 @@ func NewSQLGenerator(registry *metadata.AggregateMetadataRegistry) *SQLGenerator
 	}
 }
 
-// Generate 生成 SQL 建表脚本
-func (g *SQLGenerator) Generate(outputDir string) error {
-	// 创建输出目录
-	sqlDir := filepath.Join(outputDir, "sql")
-	if err := os.MkdirAll(sqlDir, 0755); err != nil {
-		return fmt.Errorf("创建输出目录失败: %w", err)
-	}
-
-	// 生成文件路径
-	filePath := filepath.Join(sqlDir, "schema.sql")
-
-	// 生成 SQL 代码
-	sql := g.generateSQL()
-
-	// 写入文件
-	if err := os.WriteFile(filePath, []byte(sql), 0644); err != nil {
-		return fmt.Errorf("写入文件失败: %w", err)
-	}
-
-	return nil
+// SetSchema 设置预先计算的表结构元数据，脚本按其方言生成；未设置时按注册表以 MySQL 方言计算
+func (g *SQLGenerator) SetSchema(schema *metadata.Schema) {
+	g.schema = schema
 }
 
-// generateSQL 生成完整的 SQL 脚本
-func (g *SQLGenerator) generateSQL() string {
-	var sb strings.Builder
-
-	// 文件头
-	sb.WriteString("-- Code generated by soliton. DO NOT EDIT.\n")
-	sb.WriteString(fmt.Sprintf("-- Generated at: %s\n\n", time.Now().Format("2006-01-02 15:04:05")))
-	sb.WriteString("-- Database: MySQL 5.7+\n")
-	sb.WriteString("-- Charset: utf8mb4\n\n")
-
-	// 设置字符集
-	sb.WriteString("SET NAMES utf8mb4;\n")
-	sb.WriteString("SET FOREIGN_KEY_CHECKS = 0;\n\n")
-
-	// 生成聚合根表
-	for _, agg := range g.registry.GetAll() {
-		sb.WriteString(g.generateTable(agg))
-		sb.WriteString("\n")
+// Schema 返回生成脚本使用的表结构元数据
+func (g *SQLGenerator) Schema() *metadata.Schema {
+	if g.schema == nil {
+		g.schema = metadata.NewSchema(g.registry, metadata.MySQLDialect{})
 	}
-
-	// 生成多对多关联表
-	for _, table := range g.registry.GetManyToManyTables() {
-		sb.WriteString(g.generateManyToManyTable(table))
-		sb.WriteString("\n")
-	}
-
-	sb.WriteString("SET FOREIGN_KEY_CHECKS = 1;\n")
-
-	return sb.String()
+	return g.schema
 }
 
-// generateTable 生成单个表的 DDL
-func (g *SQLGenerator) generateTable(agg *metadata.AggregateMetadata) string {
-	var sb strings.Builder
-
-	tableName := g.getTableName(agg.Name)
-
-	sb.WriteString(fmt.Sprintf("-- ----------------------------\n"))
-	sb.WriteString(fmt.Sprintf("-- Table structure for %s\n", tableName))
-	sb.WriteString(fmt.Sprintf("-- ----------------------------\n"))
-	sb.WriteString(fmt.Sprintf("DROP TABLE IF EXISTS `%s`;\n", tableName))
-	sb.WriteString(fmt.Sprintf("CREATE TABLE `%s` (\n", tableName))
-
-	// 生成列定义
-	columns := []string{}
-
-	// ID 字段（主键）
-	if agg.IDField != nil {
-		columns = append(columns, g.generateColumn(agg.IDField, true))
-	}
-
-	// 普通字段
-	for _, field := range agg.Fields {
-		// 跳过 ID 字段（已经处理）
-		if agg.IDField != nil && field.Name == agg.IDField.Name {
-			continue
-		}
-
-		// 跳过关联实体字段（不存储在数据库）
-		if field.Annotations.IsEntity {
-			continue
+// Generate 生成 SQL 建表脚本
+func (g *SQLGenerator) Generate(outputDir string) error {
+	for _, boundedContext := range g.Contexts() {
+		// 输出目录
+		sqlDir := filepath.Join(outputDir, boundedContext, "sql")
+
+		// 生成文件路径
+		filePath := filepath.Join(sqlDir, "schema.sql")
+
+		// 写入文件
+		data := g.generateSchema(boundedContext)
+		data.Aggregates, data.Context = g.registry.GetByContext(boundedContext), boundedContext
+		if err := g.keepGeneratedAt(filePath, data); err != nil {
+			return err
 		}
-
-		columns = append(columns, g.generateColumn(field, false))
-	}
-
-	// 主键定义
-	if agg.IDField != nil {
-		columns = append(columns, fmt.Sprintf("  PRIMARY KEY (`%s`)", g.getColumnName(agg.IDField.Name)))
-	}
-
-	// 唯一索引
-	for _, field := range agg.Fields {
-		if field.Annotations.IsUnique {
-			indexName := fmt.Sprintf("uk_%s_%s", tableName, g.getColumnName(field.Name))
-			columns = append(columns, fmt.Sprintf("  UNIQUE KEY `%s` (`%s`)", indexName, g.getColumnName(field.Name)))
+		if err := g.writeTemplate(filePath, "sql_schema", data); err != nil {
+			return fmt.Errorf("写入文件失败: %w", err)
 		}
 	}
 
-	// 普通索引
-	for _, field := range agg.Fields {
-		if field.Annotations.IsIndex || field.Annotations.IsRef {
-			indexName := fmt.Sprintf("idx_%s_%s", tableName, g.getColumnName(field.Name))
-			columns = append(columns, fmt.Sprintf("  KEY `%s` (`%s`)", indexName, g.getColumnName(field.Name)))
-		}
-	}
-
-	// DeletedAt 索引（用于软删除查询优化）
-	if agg.BaseEntity.HasDeletedAt {
-		indexName := fmt.Sprintf("idx_%s_deleted_at", tableName)
-		columns = append(columns, fmt.Sprintf("  KEY `%s` (`deleted_at`)", indexName))
-	}
-
-	sb.WriteString(strings.Join(columns, ",\n"))
-	sb.WriteString("\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci")
-	sb.WriteString(fmt.Sprintf(" COMMENT='%s 表';\n", agg.Name))
-
-	return sb.String()
+	return nil
 }
 
-// generateColumn 生成列定义
-func (g *SQLGenerator) generateColumn(field *metadata.FieldMetadata, isPrimaryKey bool) string {
-	columnName := g.getColumnName(field.Name)
-
-	// 值对象特殊处理
-	var sqlType string
-	if field.Annotations.IsValueObject {
-		if field.Annotations.Strategy == "json" {
-			sqlType = "TEXT"
-		} else {
-			// 展开策略暂不支持，使用 TEXT
-			sqlType = "TEXT"
-		}
-	} else {
-		sqlType = g.mapGoTypeToSQL(field.Type, field.IsPointer)
-	}
-
-	var parts []string
-	parts = append(parts, fmt.Sprintf("  `%s`", columnName))
-	parts = append(parts, sqlType)
+// generatedAtPattern 匹配脚本文件头中的生成时间
+var generatedAtPattern = regexp.MustCompile(`(?m)^-- Generated at: (.*)$`)
 
-	// NOT NULL 约束
-	if !field.IsPointer && !isPrimaryKey && !field.Annotations.IsValueObject {
-		if field.Annotations.IsRequired || field.Type == "int64" || field.Type == "int" || field.Type == "float64" {
-			parts = append(parts, "NOT NULL")
+// keepGeneratedAt 表结构没有变化时沿用已有脚本的生成时间，避免只有生成时间不同而重写文件
+func (g *SQLGenerator) keepGeneratedAt(filePath string, data *SchemaData) error {
+	previous, ok := g.pendingContent(filePath)
+	if !ok {
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil
 		}
+		previous = content
 	}
-
-	// 默认值
-	if field.IsPointer {
-		parts = append(parts, "DEFAULT NULL")
-	} else if field.Annotations.IsValueObject {
-		// 值对象默认为 NULL
-		parts = append(parts, "DEFAULT NULL")
-	} else if field.Type == "string" && !isPrimaryKey {
-		parts = append(parts, "DEFAULT ''")
-	} else if (field.Type == "int64" || field.Type == "int" || field.Type == "float64") && !isPrimaryKey {
-		parts = append(parts, "DEFAULT 0")
+	generatedAt := generatedAtPattern.FindSubmatch(previous)
+	if generatedAt == nil {
+		return nil
 	}
-
-	// 自增（仅主键）
-	if isPrimaryKey && (field.Type == "int64" || field.Type == "int") {
-		parts = append(parts, "AUTO_INCREMENT")
+	candidate := *data
+	candidate.GeneratedAt = string(generatedAt[1])
+	candidate.setPath(filePath)
+	content, err := g.render("sql_schema", &candidate)
+	if err != nil {
+		return err
 	}
-
-	// 注释
-	comment := field.Name
-	if field.Annotations.IsUnique {
-		comment += " (唯一)"
+	if strings.Contains(string(previous), content) {
+		data.GeneratedAt = candidate.GeneratedAt
 	}
-	if field.Annotations.IsRequired {
-		comment += " (必填)"
-	}
-	if field.Annotations.IsRef {
-		comment += " (外键)"
-	}
-	if field.Annotations.IsValueObject {
-		comment += " (值对象-JSON)"
-	}
-	parts = append(parts, fmt.Sprintf("COMMENT '%s'", comment))
-
-	return strings.Join(parts, " ")
+	return nil
 }
 
-// generateManyToManyTable 生成多对多关联表
-func (g *SQLGenerator) generateManyToManyTable(table *metadata.ManyToManyTableMetadata) string {
-	var sb strings.Builder
-
-	sb.WriteString(fmt.Sprintf("-- ----------------------------\n"))
-	sb.WriteString(fmt.Sprintf("-- Table structure for %s (多对多关联表)\n", table.TableName))
-	sb.WriteString(fmt.Sprintf("-- ----------------------------\n"))
-	sb.WriteString(fmt.Sprintf("DROP TABLE IF EXISTS `%s`;\n", table.TableName))
-	sb.WriteString(fmt.Sprintf("CREATE TABLE `%s` (\n", table.TableName))
-
-	columns := []string{
-		"  `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT '主键'",
-		fmt.Sprintf("  `%s` BIGINT NOT NULL COMMENT '%s ID'", table.LeftColumn, table.LeftAggregate),
-		fmt.Sprintf("  `%s` BIGINT NOT NULL COMMENT '%s ID'", table.RightColumn, table.RightAggregate),
-		"  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间'",
-		"  PRIMARY KEY (`id`)",
-		fmt.Sprintf("  UNIQUE KEY `uk_%s_%s_%s` (`%s`, `%s`)", table.TableName, table.LeftColumn, table.RightColumn, table.LeftColumn, table.RightColumn),
-		fmt.Sprintf("  KEY `idx_%s_%s` (`%s`)", table.TableName, table.LeftColumn, table.LeftColumn),
-		fmt.Sprintf("  KEY `idx_%s_%s` (`%s`)", table.TableName, table.RightColumn, table.RightColumn),
+// Contexts 返回需要生成脚本的限界上下文，空字符串表示未声明上下文的聚合根
+// 没有任何聚合根声明上下文时只有空上下文，即只生成 sql/schema.sql
+func (g *SQLGenerator) Contexts() []string {
+	contexts := g.registry.Contexts()
+	if len(contexts) == 0 || len(g.registry.GetByContext("")) > 0 {
+		contexts = append([]string{""}, contexts...)
 	}
-
-	sb.WriteString(strings.Join(columns, ",\n"))
-	sb.WriteString("\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci")
-	sb.WriteString(fmt.Sprintf(" COMMENT='%s 和 %s 关联表';\n", table.LeftAggregate, table.RightAggregate))
-
-	return sb.String()
+	return contexts
 }
 
-// mapGoTypeToSQL Go 类型映射到 MySQL 类型
-func (g *SQLGenerator) mapGoTypeToSQL(goType string, isPointer bool) string {
-	// 去除指针标记
-	baseType := strings.TrimPrefix(goType, "*")
-
-	switch baseType {
-	case "int64":
-		return "BIGINT"
-	case "int", "int32":
-		return "INT"
-	case "int16":
-		return "SMALLINT"
-	case "int8":
-		return "TINYINT"
-	case "uint64":
-		return "BIGINT UNSIGNED"
-	case "uint", "uint32":
-		return "INT UNSIGNED"
-	case "uint16":
-		return "SMALLINT UNSIGNED"
-	case "uint8":
-		return "TINYINT UNSIGNED"
-	case "float64":
-		return "DOUBLE"
-	case "float32":
-		return "FLOAT"
-	case "bool":
-		return "TINYINT(1)"
-	case "string":
-		return "VARCHAR(255)"
-	case "time.Time":
-		return "DATETIME"
-	case "[]byte":
-		return "BLOB"
-	default:
-		// 默认使用 TEXT 存储复杂类型（JSON）
-		return "TEXT"
-	}
+// renderer 返回表结构方言对应的 DDL 渲染器
+func (g *SQLGenerator) renderer() ddlRenderer {
+	return newDDLRenderer(g.Schema().Dialect)
 }
 
-// getTableName 获取表名（驼峰转下划线，复数形式）
-func (g *SQLGenerator) getTableName(aggregateName string) string {
-	// 驼峰转下划线
-	tableName := g.camelToSnake(aggregateName)
+// generateSchema 生成指定限界上下文的建表脚本
+func (g *SQLGenerator) generateSchema(boundedContext string) *SchemaData {
+	renderer := g.renderer()
+	data := &SchemaData{
+		GeneratedAt: time.Now().Format("2006-01-02 15:04:05"),
+		Database:    renderer.database(),
+		Begin:       renderer.begin(),
+		End:         renderer.end(),
+	}
 
-	// 简单的复数规则
-	if strings.HasSuffix(tableName, "s") {
-		return tableName + "es"
-	} else if strings.HasSuffix(tableName, "y") {
-		return tableName[:len(tableName)-1] + "ies"
-	} else {
-		return tableName + "s"
+	// 聚合根表在前，其后是多对多关联表（中间实体的表已随聚合根生成）
+	tables := g.tables(boundedContext)
+	for _, table := range tables {
+		data.Tables = append(data.Tables, &SchemaTable{
+			TableMetadata: table,
+			Drop:          renderer.dropTable(table),
+			Create:        renderer.createTable(table),
+		})
 	}
-}
+	data.ForeignKeys = foreignKeys(renderer, tables)
 
-// getColumnName 获取列名（驼峰转下划线）
-func (g *SQLGenerator) getColumnName(fieldName string) string {
-	return g.camelToSnake(fieldName)
+	return data
 }
 
-// camelToSnake 驼峰转下划线
-// 示例：UserID -> user_id, OrderNo -> order_no, CreatedAt -> created_at
-func (g *SQLGenerator) camelToSnake(s string) string {
-	var result []rune
-	runes := []rune(s)
-
-	for i := 0; i < len(runes); i++ {
-		r := runes[i]
-
-		// 如果是大写字母
-		if r >= 'A' && r <= 'Z' {
-			// 不是第一个字符，且前一个不是大写，需要加下划线
-			if i > 0 && runes[i-1] >= 'a' && runes[i-1] <= 'z' {
-				result = append(result, '_')
-			}
-			// 如果后面还有字符，且后面是小写，也需要加下划线
-			// 例如：UserID -> user_id（在 I 前加下划线）
-			if i > 0 && i < len(runes)-1 && runes[i-1] >= 'A' && runes[i-1] <= 'Z' && runes[i+1] >= 'a' && runes[i+1] <= 'z' {
-				result = append(result, '_')
-			}
+// tables 返回属于指定限界上下文的表
+func (g *SQLGenerator) tables(boundedContext string) []*metadata.TableMetadata {
+	var tables []*metadata.TableMetadata
+	for _, table := range g.Schema().Tables {
+		if table.Context == boundedContext {
+			tables = append(tables, table)
 		}
-		result = append(result, r)
 	}
+	return tables
+}
 
-	return strings.ToLower(string(result))
+// foreignKeys 返回所有表创建之后添加的外键约束，外键随建表语句定义的方言返回空
+func foreignKeys(renderer ddlRenderer, tables []*metadata.TableMetadata) string {
+	var sb strings.Builder
+	for _, table := range tables {
+		sb.WriteString(renderer.foreignKeys(table))
+	}
+	return sb.String()
 }
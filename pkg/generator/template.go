@@ -0,0 +1,743 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"soliton/pkg/metadata"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+)
+
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// TemplateData 传给模板的数据
+//
+// 各模板的数据类型内嵌 TemplateData，见 templateDataTypes；Go 文件的模板数据为 GoFileData。
+type TemplateData struct {
+	Path       string                        // 生成文件的路径
+	Aggregate  *metadata.AggregateMetadata   // 文件所属的聚合根，按限界上下文或全局生成的文件为 nil
+	Aggregates []*metadata.AggregateMetadata // 按限界上下文或全局生成的文件涉及的聚合根
+	Context    string                        // 限界上下文，未分组或全局生成的文件为空
+}
+
+// setPath 设置生成文件的路径
+func (d *TemplateData) setPath(path string) {
+	d.Path = path
+}
+
+// templateData 模板数据，由 writeTemplate 设置生成文件的路径
+type templateData interface {
+	setPath(path string)
+}
+
+// GoFileData Go 文件模板的数据
+//
+// 生成器给出包名、导入和按顺序排列的顶层声明：结构体字段、接口方法、常量和函数签名都是结构化的数据，
+// 只有函数体由生成器生成。模板输出文件头和包名，导入和声明由 go.tmpl 中的子模板 go_imports、go_decls 输出，
+// 覆盖模板可以增删、改写其中的声明，或在前后追加内容。
+type GoFileData struct {
+	TemplateData
+	Package string      // 包名
+	Imports []*GoImport // 导入，按路径排序
+	Decls   []*GoDecl   // 顶层声明
+}
+
+// GoImport 一个导入
+type GoImport struct {
+	Name string // 包的别名，不需要别名时为空
+	Path string // import 路径
+}
+
+// 顶层声明的种类，见 GoDecl.Kind
+const (
+	declType  = "type"
+	declFunc  = "func"
+	declConst = "const"
+	declVar   = "var"
+)
+
+// GoDecl Go 文件中的一个顶层声明
+//
+//   - type：类型 Name，Type 为 struct、interface 时成员分别为 Fields、Methods（Fields 中为嵌入的接口），否则为 Type 给出的类型
+//   - func：函数或方法 Func
+//   - const、var：Fields 中的常量、变量（Value 为值），多于一个时以括号分组
+type GoDecl struct {
+	Kind    string     // 声明的种类：type、func、const、var
+	Doc     string     // 声明的注释（不含 //），多行以换行分隔；函数的注释见 GoFunc.Doc
+	Name    string     // 类型名
+	Type    string     // 类型定义，如 struct、interface、string
+	Fields  []*GoField // 结构体字段、嵌入的接口、常量或变量
+	Methods []*GoFunc  // 接口方法
+	Func    *GoFunc    // 函数或方法
+}
+
+// GoField 结构体字段、嵌入的接口或一个常量、变量
+type GoField struct {
+	Doc       string // 字段前的注释（不含 //），多行以换行分隔
+	Name      string // 名称，嵌入字段为空
+	Type      string // 类型，常量、变量省略类型时为空
+	Value     string // 常量、变量的值
+	Tag       string // 结构体标签（不含反引号）
+	Comment   string // 行尾注释
+	Separated bool   // 与前一个字段之间空一行
+}
+
+// GoFunc 函数、方法或接口方法
+type GoFunc struct {
+	Doc      string // 注释（不含 //），多行以换行分隔
+	Receiver string // 方法接收者，如 r *OrderRepositoryImpl，函数为空
+	Name     string // 名称
+	Params   string // 参数列表，如 ctx context.Context, id int64
+	Results  string // 返回值，如 error、(*model.Order, error)
+	Body     string // 函数体，每行以一个制表符开头、以换行结尾；接口方法为空
+}
+
+// addType 追加类型声明
+func (f *GoFileData) addType(doc, name, typ string, fields ...*GoField) *GoDecl {
+	decl := &GoDecl{Kind: declType, Doc: doc, Name: name, Type: typ, Fields: fields}
+	f.Decls = append(f.Decls, decl)
+	return decl
+}
+
+// addImports 按路径顺序插入不需要别名的导入，已导入的路径跳过
+func (f *GoFileData) addImports(paths ...string) {
+	for _, path := range paths {
+		f.addImport("", path)
+	}
+}
+
+// addImport 按路径顺序插入以 name 为别名的导入（name 为空时不使用别名），已导入的路径跳过
+func (f *GoFileData) addImport(name, path string) {
+	i, found := slices.BinarySearchFunc(f.Imports, path, func(spec *GoImport, path string) int {
+		return strings.Compare(spec.Path, path)
+	})
+	if !found {
+		f.Imports = slices.Insert(f.Imports, i, &GoImport{Name: name, Path: path})
+	}
+}
+
+// addFunc 追加函数或方法
+func (f *GoFileData) addFunc(fn *GoFunc) {
+	f.Decls = append(f.Decls, &GoDecl{Kind: declFunc, Func: fn})
+}
+
+// addValues 追加常量（kind 为 const）或变量（kind 为 var）
+func (f *GoFileData) addValues(kind, doc string, values ...*GoField) {
+	f.Decls = append(f.Decls, &GoDecl{Kind: kind, Doc: doc, Fields: values})
+}
+
+// SchemaData 建表脚本模板 sql_schema 的数据，语句由生成器按方言渲染
+type SchemaData struct {
+	TemplateData
+	GeneratedAt string         // 生成时间，表结构没有变化时沿用已有脚本中的时间
+	Database    string         // 脚本头部注明的数据库，如 "MySQL 5.7+"
+	Begin       string         // 脚本开头的会话设置，没有时为空
+	End         string         // 脚本结尾的会话设置，没有时为空
+	Tables      []*SchemaTable // 聚合根表在前，其后是多对多关联表
+	ForeignKeys string         // 所有表创建之后添加外键约束的语句，外键随建表语句定义的方言为空
+}
+
+// SchemaTable 建表脚本中的一个表
+type SchemaTable struct {
+	*metadata.TableMetadata
+	Drop   string // 删除表的语句
+	Create string // 建表语句，包括表上的索引和注释
+}
+
+// MigrationData 迁移模板 migration_up、migration_down 的数据，语句由生成器按方言渲染
+type MigrationData struct {
+	TemplateData
+	Database    string           // 脚本头部注明的数据库，如 "MySQL 5.7+"
+	Review      bool             // 是否有需要人工确认的变更（标记 ⚠️ 的步骤）
+	Begin       string           // 脚本开头的会话设置，没有时为空
+	End         string           // 脚本结尾的会话设置，没有时为空
+	Steps       []*MigrationStep // 按执行顺序排列的步骤
+	ForeignKeys string           // 建表迁移中所有表创建之后添加外键约束的语句，没有时为空
+}
+
+// MigrationStep 迁移中的一个步骤
+type MigrationStep struct {
+	Comment    string // 步骤前的说明（不含 --），如表名、"⚠️ 删除列 orders.remark"，没有时为空
+	Statements string // 语句，可能丢失数据的语句已注释掉
+}
+
+// ERDData ER 图模板 erd 的数据，实体和连线按名称排序
+type ERDData struct {
+	TemplateData
+	Format   string       // 导出格式，见 ERDFormatMermaid、ERDFormatDOT
+	Entities []*ERDEntity // 聚合根在前，其后是纯关联表
+	Edges    []*ERDEdge   // 实体之间的连线
+}
+
+// ERDEntity ER 图中的实体（聚合根或纯关联表）
+type ERDEntity struct {
+	Name       string          // 实体名，纯关联表为表名
+	Table      string          // 表名
+	Attributes []*ERDAttribute // 属性，对应表中的列
+}
+
+// ERDAttribute 实体的属性，对应表中的一列
+type ERDAttribute struct {
+	Type   string   // 属性类型，见 erdType
+	Column string   // 列名
+	Keys   []string // PK、FK、UK
+}
+
+// ERDEdge 实体之间的连线，From 一端为关系的源
+type ERDEdge struct {
+	From, To         string         // 两端的实体名
+	FromCard, ToCard erdCardinality // 两端的基数：one、zeroOrOne、zeroOrMore
+	Label            string         // 连线的标签，通常为关联字段名
+}
+
+// OpenAPIData OpenAPI 文档模板 openapi 的数据
+type OpenAPIData struct {
+	TemplateData
+	Title     string         // 文档标题，如 "shop API"
+	Resources []*APIResource // 各聚合根的接口，顺序与 Aggregates 一致
+	Schemas   []*APISchema   // 枚举和值对象的 Schema，按名称排序
+}
+
+// APIResource 一个聚合根的 REST 接口
+type APIResource struct {
+	Name                 string          // 聚合根名，也是操作的 tag
+	Collection           string          // 集合路径，如 /orders
+	Item                 string          // 单个资源的路径，如 /orders/{id}
+	Keys                 []*APIProperty  // 单个资源路径中的主键参数
+	CollectionOperations []*APIOperation // 集合路径上的操作：list、create
+	ItemOperations       []*APIOperation // 单个资源路径上的操作：get、update、delete
+	Create               *APIObject      // 新增请求体 Create{Name}Request，未启用新增时为 nil
+	Update               *APIObject      // 更新请求体 Update{Name}Request，未启用更新时为 nil
+	Response             *APIObject      // 响应 {Name}Response
+	List                 bool            // 是否启用分页查询，启用时有分页响应 {Name}Page
+}
+
+// APIOperation 一个操作
+type APIOperation struct {
+	Method   string         // HTTP 方法，小写
+	Tag      string         // 操作的 tag，即聚合根名
+	ID       string         // operationId，如 createOrder
+	Summary  string         // 摘要，如 "新增 Order"
+	Paged    bool           // 是否有分页参数 page、pageSize
+	Request  string         // 请求体的 Schema，没有请求体时为空
+	Status   string         // 成功的状态码
+	Response string         // 成功响应的 Schema，没有响应体时为空
+	Errors   []*APIResponse // 可能返回的错误响应
+}
+
+// APIResponse 错误响应，与 framework.HTTPError 的映射一致
+type APIResponse struct {
+	Status string // 状态码，如 404
+	Name   string // components.responses 中的名称，如 NotFound
+}
+
+// APIObject 请求体或响应的 Schema
+type APIObject struct {
+	Description string         // 说明，没有时为空
+	Properties  []*APIProperty // 属性，名称与生成的结构体的 json 标签一致
+}
+
+// APIProperty 属性或路径参数
+type APIProperty struct {
+	Name   string   // 属性名或参数名
+	Schema []string // Schema，每个元素为一行 YAML，不含属性本身的缩进
+}
+
+// APISchema components.schemas 中的枚举或值对象
+type APISchema struct {
+	Name   string   // Schema 名，如 OrderStatus、Address
+	Schema []string // Schema，每个元素为一行 YAML，不含 Schema 本身的缩进
+}
+
+// GraphQLSchemaData GraphQL schema 模板 graphql_schema 的数据
+type GraphQLSchemaData struct {
+	TemplateData
+	Config string         // gqlgen.yml 相对模块根目录的路径，用于文件头中的生成命令
+	Time   bool           // 是否有字段使用标量 Time
+	Types  []*GraphQLType // Query、Mutation（有修改字段时）在前，其后是对象、输入和分页类型
+}
+
+// GraphQLType schema 中的类型定义
+type GraphQLType struct {
+	Description string          // 说明，没有时为空
+	Kind        string          // type 或 input
+	Name        string          // 类型名
+	Fields      []*GraphQLField // 字段
+}
+
+// GraphQLField 类型中的字段
+type GraphQLField struct {
+	Description string // 说明，没有时为空
+	Name        string // 字段名
+	Args        string // 参数，如 "id: ID!"，没有参数时为空
+	Type        string // 字段类型，如 "Order!"、"[OrderItem!]!"
+}
+
+// GraphQLConfigData gqlgen 配置模板 graphql_config 的数据
+type GraphQLConfigData struct {
+	TemplateData
+	TypesImport string          // 类型所在 types 包的 import 路径
+	Models      []*GraphQLModel // schema 中绑定到 types 包的类型
+}
+
+// GraphQLModel 绑定到 types 包中结构体的类型
+type GraphQLModel struct {
+	Name      string   // 类型名，也是结构体名
+	Resolvers []string // 由解析器返回的关联字段
+}
+
+// ProtoData .proto 模板 grpc_proto 的数据
+type ProtoData struct {
+	TemplateData
+	File      string          // .proto 相对模块根目录的路径，用于文件头中的生成命令
+	Package   string          // 包名，如 shop.ordering.v1
+	GoPackage string          // protoc-gen-go 生成的包的 import 路径
+	Imports   []string        // 依赖的 .proto，如 google/protobuf/empty.proto，按路径排序
+	Services  []*ProtoService // 各聚合根的服务，顺序与 Aggregates 一致
+	Messages  []*ProtoMessage // 值对象的消息，按名称排序
+}
+
+// ProtoService 聚合根的服务及其请求、响应消息
+type ProtoService struct {
+	Doc      string          // 服务的注释（不含 //）
+	Name     string          // 服务名，如 OrderService
+	Methods  []*ProtoMethod  // 方法
+	Messages []*ProtoMessage // 聚合根的消息和方法的请求、响应消息
+}
+
+// ProtoMethod 服务中的方法
+type ProtoMethod struct {
+	Name     string // 方法名，如 CreateOrder
+	Request  string // 请求消息
+	Response string // 响应消息
+}
+
+// ProtoMessage 消息
+type ProtoMessage struct {
+	Doc     string               // 消息的注释（不含 //），没有时为空
+	Name    string               // 消息名
+	Comment string               // 消息体开头的说明（不含 //），如无法映射的字段，没有时为空
+	Fields  []*ProtoMessageField // 字段
+}
+
+// ProtoMessageField 消息中的字段
+type ProtoMessageField struct {
+	Doc    string // 字段的注释（不含 //），多行以换行分隔，没有时为空
+	Label  string // optional、repeated 或空
+	Type   string // 字段类型，如 int64、map<string, Address>
+	Name   string // 字段名，如 user_id
+	Number int    // 字段编号
+}
+
+// EnumData 枚举模板 enum 的数据
+type EnumData struct {
+	TemplateData
+	Enum     *metadata.EnumMetadata // 生成的枚举
+	BaseType string                 // 底层类型，如 string、int
+	Int      bool                   // 是否为整数枚举：JSON 编码为名称，解析时同时接受名称和编码
+	Values   []*EnumValue           // 枚举值，按声明顺序排列
+}
+
+// EnumValue 枚举值
+type EnumValue struct {
+	Constant string // 常量名，如 OrderStatusPending
+	Name     string // 名称，如 PENDING；整数枚举未声明名称时为编码
+	Value    string // 值，整数枚举为编码，如 1
+	Literal  string // 常量的 Go 字面量，如 "PENDING"、1
+	Label    string // 说明，未声明时为空
+}
+
+// ServiceInterfaceData 领域服务接口模板 service_interface 的数据
+type ServiceInterfaceData struct {
+	TemplateData
+	KeyType     string                    // 在领域模型包之外使用的主键类型，如 int64、model.OrderLineKey
+	Polymorphic []*metadata.FieldMetadata // 多态关联字段，各生成一个 Load 方法
+}
+
+// EventData 领域事件模板 event 的数据
+type EventData struct {
+	TemplateData
+	KeyType string                    // 聚合根主键类型（领域模型包内的写法）
+	Events  []*metadata.EventMetadata // 在聚合根所在包中生成的事件
+	Structs []*metadata.EventMetadata // Events 中在聚合根上列出、需要生成结构体的事件
+}
+
+// OutboxData outbox 中继模板 outbox_kafka、outbox_nats 的数据
+type OutboxData struct {
+	TemplateData
+	Broker string // 消息队列，见 OutboxBrokers
+}
+
+// templateDataTypes 各模板的数据类型，未列出的模板为 *TemplateData，用于校验模板引用的字段
+var templateDataTypes = map[string]any{
+	"columns":               (*GoFileData)(nil),
+	"do":                    (*GoFileData)(nil),
+	"convertor":             (*GoFileData)(nil),
+	"repository_interface":  (*GoFileData)(nil),
+	"memory_repository":     (*GoFileData)(nil),
+	"repository_impl":       (*GoFileData)(nil),
+	"service_impl":          (*GoFileData)(nil),
+	"entity":                (*GoFileData)(nil),
+	"dto":                   (*GoFileData)(nil),
+	"fixture":               (*GoFileData)(nil),
+	"mock_repository":       (*GoFileData)(nil),
+	"mock_service":          (*GoFileData)(nil),
+	"graphql_convert":       (*GoFileData)(nil),
+	"graphql_relations":     (*GoFileData)(nil),
+	"graphql_resolver":      (*GoFileData)(nil),
+	"graphql_root":          (*GoFileData)(nil),
+	"graphql_types":         (*GoFileData)(nil),
+	"grpc_convert":          (*GoFileData)(nil),
+	"grpc_register":         (*GoFileData)(nil),
+	"grpc_server":           (*GoFileData)(nil),
+	"integration_test":      (*GoFileData)(nil),
+	"integration_main":      (*GoFileData)(nil),
+	"di_container":          (*GoFileData)(nil),
+	"di_providers":          (*GoFileData)(nil),
+	"http_router":           (*GoFileData)(nil),
+	"http_response":         (*GoFileData)(nil),
+	"http_handler":          (*GoFileData)(nil),
+	"read_model_repository": (*GoFileData)(nil),
+	"read_model_queries":    (*GoFileData)(nil),
+	"query_fields":          (*GoFileData)(nil),
+	"enum":                  (*EnumData)(nil),
+	"grpc_proto":            (*ProtoData)(nil),
+	"graphql_schema":        (*GraphQLSchemaData)(nil),
+	"graphql_config":        (*GraphQLConfigData)(nil),
+	"openapi":               (*OpenAPIData)(nil),
+	"erd":                   (*ERDData)(nil),
+	"sql_schema":            (*SchemaData)(nil),
+	"migration_up":          (*MigrationData)(nil),
+	"migration_down":        (*MigrationData)(nil),
+	"service_interface":     (*ServiceInterfaceData)(nil),
+	"event":                 (*EventData)(nil),
+	"outbox_kafka":          (*OutboxData)(nil),
+	"outbox_nats":           (*OutboxData)(nil),
+}
+
+// TemplateFuncs 模板中可用的函数
+//
+//   - lowerFirst、upperFirst：首字母小写、大写，如 Order → order
+//   - snake：蛇形命名，如 UserID → user_id
+//   - camel：小驼峰命名（开头的缩写整体小写），如 UserID → userID、URLPath → urlPath
+//   - receiver：方法接收者名，聚合根名首字母小写，如 Order → o
+//   - qualify：为领域模型包内的类型加上包名，如 qualify "[]*OrderItem" "model" → []*model.OrderItem
+//   - quote：Go 字符串字面量
+//   - join：以分隔符连接字符串切片
+//   - comment：将文本格式化为每行以 // 开头的注释，第一个参数为每行的缩进，如 comment "\t" .Doc
+//   - dotEscape：转义 Graphviz record 标签中的特殊字符，如 a|b → a\|b
+//   - contains、hasPrefix、hasSuffix、trimPrefix、trimSuffix、replace、lower、upper：同 strings 包中的同名函数
+var TemplateFuncs = template.FuncMap{
+	"lowerFirst": toLowerFirst,
+	"upperFirst": toUpperFirst,
+	"snake":      toSnakeCase,
+	"camel":      jsonName,
+	"receiver":   func(name string) string { return strings.ToLower(name[:min(len(name), 1)]) },
+	"qualify":    qualifyType,
+	"quote":      strconv.Quote,
+	"join":       func(sep string, elems []string) string { return strings.Join(elems, sep) },
+	"comment":    formatComment,
+	"dotEscape":  dotEscape,
+	"contains":   strings.Contains,
+	"hasPrefix":  strings.HasPrefix,
+	"hasSuffix":  strings.HasSuffix,
+	"trimPrefix": strings.TrimPrefix,
+	"trimSuffix": strings.TrimSuffix,
+	"replace":    strings.ReplaceAll,
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+}
+
+// formatComment 将 text 格式化为行注释，每行以 indent 开头、以换行结尾；以制表符开头的行（注释中的代码示例）不加空格，text 为空时返回空
+func formatComment(indent, text string) string {
+	if text == "" {
+		return ""
+	}
+	var sb strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		sb.WriteString(indent + "//")
+		if line != "" && !strings.HasPrefix(line, "\t") {
+			sb.WriteString(" ")
+		}
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
+}
+
+// Templates 生成器模板集合
+//
+// 每个生成文件由一个模板渲染，模板名为 templates 目录中的文件名（不含 .tmpl），如 repository_impl。
+// 模板执行时引用不存在的 map 键报错，而不是输出 <no value>。
+type Templates struct {
+	set       *template.Template
+	overrides []string // 被覆盖的模板名
+	digest    string   // 覆盖模板的模板名和内容摘要，见 Digest
+}
+
+// defaultTemplates 内置模板，供未设置模板的生成器使用
+var defaultTemplates = sync.OnceValue(func() *Templates {
+	templates, err := LoadTemplates("")
+	if err != nil {
+		panic(fmt.Sprintf("加载内置模板失败: %v", err))
+	}
+	return templates
+})
+
+// LoadTemplates 加载内置模板，dir 不为空时以目录中同名的 .tmpl 文件覆盖内置模板
+// 覆盖文件中可以用 {{define}} 定义供自身使用的子模板；文件名不对应任何内置模板时返回错误
+func LoadTemplates(dir string) (*Templates, error) {
+	set := template.New("").Funcs(TemplateFuncs).Option("missingkey=error")
+	templates := &Templates{set: set}
+	builtin := templates.Names()
+	for _, name := range builtin {
+		content, err := builtinTemplates.ReadFile("templates/" + name + ".tmpl")
+		if err != nil {
+			return nil, fmt.Errorf("读取内置模板失败: %w", err)
+		}
+		if _, err := set.New(name).Parse(string(content)); err != nil {
+			return nil, fmt.Errorf("解析内置模板 %s 失败: %w", name, err)
+		}
+	}
+	if dir == "" {
+		return templates, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return nil, fmt.Errorf("读取模板目录失败: %w", err)
+	}
+	if len(files) == 0 {
+		if _, err := os.Stat(dir); err != nil {
+			return nil, fmt.Errorf("读取模板目录失败: %w", err)
+		}
+	}
+	digest := sha256.New()
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".tmpl")
+		if !slices.Contains(builtin, name) {
+			return nil, fmt.Errorf("模板 %s 不对应任何内置模板，可覆盖的模板: %s", file, strings.Join(builtin, "、"))
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("读取模板失败: %w", err)
+		}
+		if _, err := set.New(name).Parse(string(content)); err != nil {
+			return nil, fmt.Errorf("解析模板 %s 失败: %w", file, err)
+		}
+		templates.overrides = append(templates.overrides, name)
+		fmt.Fprintf(digest, "%s\x00%d\x00%s", name, len(content), content)
+	}
+	templates.digest = hex.EncodeToString(digest.Sum(nil))
+	return templates, nil
+}
+
+// Names 返回可覆盖的模板名（按名称排序）
+func (t *Templates) Names() []string {
+	entries, _ := fs.ReadDir(builtinTemplates, "templates")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".tmpl"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Overrides 返回被模板目录覆盖的模板名
+func (t *Templates) Overrides() []string {
+	return t.overrides
+}
+
+// Digest 返回覆盖模板的摘要，没有覆盖模板时为空；内置模板随 soliton 一同编译，不计入摘要
+func (t *Templates) Digest() string {
+	return t.digest
+}
+
+// Execute 以 data 执行模板 name
+func (t *Templates) Execute(name string, data any) (string, error) {
+	tmpl := t.set.Lookup(name)
+	if tmpl == nil {
+		return "", fmt.Errorf("模板 %s 不存在", name)
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("执行模板失败: %w", err)
+	}
+	return sb.String(), nil
+}
+
+// Validate 校验模板，返回发现的全部问题：
+//   - 引用了模板数据中不存在的字段或方法，如 {{.Aggregate.Nmae}}
+//   - 调用了不存在的模板，如 {{template "header" .}}
+//
+// 字段按模板的数据类型（见 TemplateData）逐级检查，range、with 中的点无法确定类型时不检查；
+// {{define}} 定义的子模板的数据由调用方决定，只检查其中调用的模板。
+func (t *Templates) Validate() []error {
+	var errs []error
+	names := t.Names()
+	for _, tmpl := range t.set.Templates() {
+		if tmpl.Tree == nil || tmpl.Tree.Root == nil {
+			continue
+		}
+		var root reflect.Type
+		if slices.Contains(names, tmpl.Name()) {
+			root = reflect.TypeOf(&TemplateData{})
+			if data, ok := templateDataTypes[tmpl.Name()]; ok {
+				root = reflect.TypeOf(data)
+			}
+		}
+		checker := &templateChecker{set: t.set, tree: tmpl.Tree, root: root}
+		checker.walk(tmpl.Tree.Root, root)
+		errs = append(errs, checker.errs...)
+	}
+	return errs
+}
+
+// templateChecker 按模板数据类型检查模板中的字段引用
+type templateChecker struct {
+	set  *template.Template
+	tree *parse.Tree
+	root reflect.Type // 模板数据的类型，未知时为 nil
+	errs []error
+}
+
+// walk 检查节点，dot 为节点中 . 的类型，未知时为 nil
+func (c *templateChecker) walk(node parse.Node, dot reflect.Type) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			c.walk(child, dot)
+		}
+	case *parse.ActionNode:
+		c.pipe(n.Pipe, dot)
+	case *parse.IfNode:
+		c.pipe(n.Pipe, dot)
+		c.walk(n.List, dot)
+		c.walk(n.ElseList, dot)
+	case *parse.WithNode:
+		c.walk(n.List, c.pipe(n.Pipe, dot))
+		c.walk(n.ElseList, dot)
+	case *parse.RangeNode:
+		var elem reflect.Type
+		if typ := indirect(c.pipe(n.Pipe, dot)); typ != nil {
+			switch typ.Kind() {
+			case reflect.Slice, reflect.Array, reflect.Map:
+				elem = typ.Elem()
+			}
+		}
+		c.walk(n.List, elem)
+		c.walk(n.ElseList, dot)
+	case *parse.TemplateNode:
+		if c.set.Lookup(n.Name) == nil {
+			c.errorf(n, "调用了不存在的模板 %q", n.Name)
+		}
+		c.pipe(n.Pipe, dot)
+	}
+}
+
+// pipe 检查管道，管道只有一个操作数时返回其类型，否则返回 nil
+func (c *templateChecker) pipe(pipe *parse.PipeNode, dot reflect.Type) reflect.Type {
+	if pipe == nil {
+		return nil
+	}
+	var result reflect.Type
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			typ := c.operand(arg, dot)
+			if len(pipe.Cmds) == 1 && len(cmd.Args) == 1 {
+				result = typ
+			}
+		}
+	}
+	return result
+}
+
+// operand 检查操作数，返回其类型，未知时返回 nil
+func (c *templateChecker) operand(node parse.Node, dot reflect.Type) reflect.Type {
+	switch n := node.(type) {
+	case *parse.DotNode:
+		return dot
+	case *parse.FieldNode:
+		return c.fields(n, dot, n.Ident)
+	case *parse.ChainNode:
+		return c.fields(n, c.operand(n.Node, dot), n.Field)
+	case *parse.VariableNode:
+		if n.Ident[0] == "$" {
+			return c.fields(n, c.root, n.Ident[1:])
+		}
+	case *parse.PipeNode:
+		return c.pipe(n, dot)
+	}
+	return nil
+}
+
+// fields 依次解析 typ 的字段或方法 names，不存在时记录错误
+func (c *templateChecker) fields(node parse.Node, typ reflect.Type, names []string) reflect.Type {
+	for _, name := range names {
+		if typ == nil {
+			return nil
+		}
+		next, ok := memberType(typ, name)
+		if !ok {
+			c.errorf(node, "引用了不存在的字段或方法 %s（%s）", name, typ)
+			return nil
+		}
+		typ = next
+	}
+	return typ
+}
+
+// errorf 记录带模板位置的错误
+func (c *templateChecker) errorf(node parse.Node, format string, args ...any) {
+	location, _ := c.tree.ErrorContext(node)
+	c.errs = append(c.errs, fmt.Errorf("%s: %s", location, fmt.Sprintf(format, args...)))
+}
+
+// memberType 返回类型的导出字段或方法 name 的类型，map 返回值类型；接口类型无法确定时返回 nil, true
+func memberType(typ reflect.Type, name string) (reflect.Type, bool) {
+	if method, ok := typ.MethodByName(name); ok {
+		if method.Type.NumOut() == 0 {
+			return nil, true
+		}
+		return method.Type.Out(0), true
+	}
+	if typ.Kind() != reflect.Pointer && typ.Kind() != reflect.Interface {
+		if method, ok := reflect.PointerTo(typ).MethodByName(name); ok && method.Type.NumOut() > 0 {
+			return method.Type.Out(0), true
+		}
+	}
+
+	switch typ = indirect(typ); typ.Kind() {
+	case reflect.Struct:
+		if field, ok := typ.FieldByName(name); ok && field.IsExported() {
+			return field.Type, true
+		}
+	case reflect.Map:
+		return typ.Elem(), true
+	case reflect.Interface:
+		return nil, true
+	}
+	return nil, false
+}
+
+// indirect 返回指针指向的类型
+func indirect(typ reflect.Type) reflect.Type {
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ
+}
@@ -0,0 +1,214 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext unified diff 中变化前后保留的上下文行数
+const diffContext = 3
+
+// maxDiffEdits 逐行比较的最大编辑距离，超过时整段按删除后新增输出，避免差异很大的长文件耗尽内存
+const maxDiffEdits = 2000
+
+// diffLine 差异中的一行，kind 为 ' '（相同）、'-'（删除）或 '+'（新增）
+type diffLine struct {
+	kind byte
+	text string
+}
+
+// UnifiedDiff 返回从 oldContent 到 newContent 的 unified diff（与 diff -u、git diff 格式相同），内容相同时返回空字符串
+// oldName、newName 为文件头中的文件名，新增的文件 oldName 通常为 /dev/null
+func UnifiedDiff(oldName, newName, oldContent, newContent string) string {
+	if oldContent == newContent {
+		return ""
+	}
+
+	lines := diffLines(splitLines(oldContent), splitLines(newContent))
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", oldName, newName))
+
+	// 相邻变化之间相同的行不超过两倍上下文时合并为一个片段
+	oldLine, newLine := 0, 0
+	for start := 0; start < len(lines); {
+		if lines[start].kind == ' ' {
+			oldLine++
+			newLine++
+			start++
+			continue
+		}
+
+		first := max(start-diffContext, 0)
+		end := start
+		for end < len(lines) {
+			if lines[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(lines) && lines[next].kind == ' ' {
+				next++
+			}
+			if next == len(lines) || next-end > 2*diffContext {
+				break
+			}
+			end = next
+		}
+		last := min(end+diffContext, len(lines))
+
+		oldStart, newStart := oldLine-(start-first), newLine-(start-first)
+		oldCount, newCount := 0, 0
+		for _, line := range lines[first:last] {
+			if line.kind != '+' {
+				oldCount++
+			}
+			if line.kind != '-' {
+				newCount++
+			}
+		}
+		sb.WriteString(fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount)))
+		for _, line := range lines[first:last] {
+			sb.WriteByte(line.kind)
+			sb.WriteString(line.text)
+			if !strings.HasSuffix(line.text, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		for _, line := range lines[start:last] {
+			if line.kind != '+' {
+				oldLine++
+			}
+			if line.kind != '-' {
+				newLine++
+			}
+		}
+		start = last
+	}
+	return sb.String()
+}
+
+// hunkRange 返回片段头中的行范围：从 1 开始的起始行和行数，行数为 1 时省略，为 0 时起始行为前一行
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+}
+
+// splitLines 按行拆分，每行保留结尾的换行符
+func splitLines(content string) []string {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines 逐行比较 a、b，返回最短的编辑序列（Myers 差分算法）
+func diffLines(a, b []string) []diffLine {
+	// 相同的开头和结尾不参与比较
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var lines []diffLine
+	for _, text := range a[:prefix] {
+		lines = append(lines, diffLine{' ', text})
+	}
+	lines = append(lines, myersDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{' ', text})
+	}
+	return lines
+}
+
+// myersDiff Myers 差分算法：记录每一步各对角线到达的最远位置，再从终点回溯出编辑序列
+func myersDiff(a, b []string) []diffLine {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxDiffEdits)
+	offset := limit + 1
+	v := make([]int, 2*offset+1)
+	// trace[d] 为第 d 步开始时对角线 -d-1 到 d+1 的最远位置
+	var trace [][]int
+	found := false
+	for d := 0; d <= limit && !found; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	if !found {
+		lines := make([]diffLine, 0, n+m)
+		for _, text := range a {
+			lines = append(lines, diffLine{'-', text})
+		}
+		for _, text := range b {
+			lines = append(lines, diffLine{'+', text})
+		}
+		return lines
+	}
+
+	var reversed []diffLine
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[d+k] < v[d+k+2]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[d+1+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			reversed = append(reversed, diffLine{' ', a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			reversed = append(reversed, diffLine{'+', b[y-1]})
+			y--
+		} else {
+			reversed = append(reversed, diffLine{'-', a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		reversed = append(reversed, diffLine{' ', a[x-1]})
+		x--
+		y--
+	}
+
+	lines := make([]diffLine, len(reversed))
+	for i, line := range reversed {
+		lines[len(reversed)-1-i] = line
+	}
+	return lines
+}
@@ -1,7 +1,13 @@
 package generator
 
 import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/types"
 	"path/filepath"
+	"soliton/pkg/metadata"
+	"strings"
 	"unicode"
 )
 
@@ -15,6 +21,16 @@ func toLowerFirst(s string) string {
 	return string(runes)
 }
 
+// toUpperFirst 将字符串首字母转为大写
+func toUpperFirst(s string) string {
+	if len(s) == 0 {
+		return s
+	}
+	runes := []rune(s)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
 // calculateImportPath 根据模块信息计算目录的完整 import 路径
 // moduleName: Go 模块名，如 "mymodule"
 // moduleRoot: 模块根目录绝对路径
@@ -41,3 +57,129 @@ func calculateImportPath(moduleName, moduleRoot, targetDir string) string {
 	// 拼接完整的 import 路径
 	return moduleName + "/" + relPath
 }
+
+// domainDir 返回聚合根生成代码所在的 domain 目录
+// 声明了 +soliton:context 的聚合根输出到以上下文命名的子目录，如 domain/ordering
+func domainDir(agg *metadata.AggregateMetadata, outputDir string) string {
+	return filepath.Join(outputDir, agg.Context())
+}
+
+// infrastructureDir 返回聚合根生成代码所在的 infrastructure 目录（与 domain 平级）
+// 与 domainDir 一致，按限界上下文划分子目录，如 infrastructure/ordering
+func infrastructureDir(agg *metadata.AggregateMetadata, outputDir string) string {
+	return filepath.Join(filepath.Dir(outputDir), "infrastructure", agg.Context())
+}
+
+// interfacesDir 返回聚合根对外接口代码（REST 处理器等）所在的 interfaces 目录（与 domain 平级）
+// 与 domainDir 一致，按限界上下文划分子目录，如 interfaces/ordering
+func interfacesDir(agg *metadata.AggregateMetadata, outputDir string) string {
+	return filepath.Join(filepath.Dir(outputDir), "interfaces", agg.Context())
+}
+
+// typedLiteral 返回类型为 goType 的默认值表达式，用于赋给局部变量后取地址：
+// 除 string、bool、int 和 time.Now() 外转换为 goType，如 5 → int64(5)、5 → float64(5)
+func typedLiteral(literal, goType string) string {
+	switch goType {
+	case "string", "bool", "int", "time.Time":
+		return literal
+	}
+	return fmt.Sprintf("%s(%s)", goType, literal)
+}
+
+// toSnakeCase 转换为蛇形命名，缩写的复数形式不拆分
+// 示例：UserID -> user_id, OrderNo -> order_no, URLPath -> url_path, UserIDs -> user_ids
+func toSnakeCase(s string) string {
+	var result []rune
+	runes := []rune(s)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])) {
+				result = append(result, '_')
+			}
+			if i > 0 && i < len(runes)-1 && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1]) && !pluralSuffix(runes[i+1:]) {
+				result = append(result, '_')
+			}
+		}
+		result = append(result, r)
+	}
+
+	return strings.ToLower(string(result))
+}
+
+// pluralSuffix 判断 rest 是否为缩写复数形式的 s，即 s 后为结尾或下一个单词
+func pluralSuffix(rest []rune) bool {
+	return rest[0] == 's' && (len(rest) == 1 || unicode.IsUpper(rest[1]))
+}
+
+// jsonName 返回字段在 JSON 中的名称：首字母小写，开头的缩写整体小写，如 ID → id、UserID → userID、URLPath → urlPath
+func jsonName(name string) string {
+	runes := []rune(name)
+	upper := 0
+	for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+		upper++
+	}
+	// 缩写后紧跟小写字母时，最后一个大写字母属于下一个单词
+	if upper > 1 && upper < len(runes) && unicode.IsLower(runes[upper]) {
+		upper--
+	}
+	for i := 0; i < upper; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// isIntegerType 判断是否为整数类型
+func isIntegerType(goType string) bool {
+	switch goType {
+	case "int", "int8", "int16", "int32", "int64",
+		"uint", "uint8", "uint16", "uint32", "uint64":
+		return true
+	}
+	return false
+}
+
+// qualifyType 为类型表达式中领域模型包内的类型加上包名限定
+// 如 pkgName 为 "model" 时："Address" → "model.Address"，"map[string]*Label" → "map[string]*model.Label"；
+// 内置类型和已限定的类型（如 time.Time）保持不变
+func qualifyType(typeExpr, pkgName string) string {
+	expr, err := parser.ParseExpr(typeExpr)
+	if err != nil {
+		return typeExpr
+	}
+
+	ast.Inspect(expr, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.SelectorExpr:
+			// 已限定的类型
+			return false
+		case *ast.Ident:
+			if types.Universe.Lookup(n.Name) == nil {
+				n.Name = pkgName + "." + n.Name
+			}
+		}
+		return true
+	})
+
+	return types.ExprString(expr)
+}
+
+// qualifiedKeyType 返回在领域模型包之外使用的主键类型
+// 复合主键结构体定义在领域模型包中，需要带包名，如 "model.OrderLineKey"；int64、string 保持不变
+func qualifiedKeyType(agg *metadata.AggregateMetadata) string {
+	return qualifyType(agg.IDKeyType(), agg.PackageName)
+}
+
+// softDeleteField 返回数据对象中以 gorm.DeletedAt 保存的软删除字段
+// 领域对象的 DeletedAt 为 time.Time、*time.Time 或 gorm.DeletedAt 时返回该字段，其他类型按普通列处理，返回 nil
+func softDeleteField(agg *metadata.AggregateMetadata) *metadata.FieldMetadata {
+	if agg.BaseEntity == nil || !agg.BaseEntity.HasDeletedAt {
+		return nil
+	}
+	switch field := agg.BaseEntity.DeletedAtField; field.GoType() {
+	case "time.Time", "*time.Time", "gorm.DeletedAt":
+		return field
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// extFileSuffix 手写扩展文件的后缀，如 OrderRepositoryImpl_ext.go，生成器不会写出此类文件
+const extFileSuffix = "_ext.go"
+
+// FileWriter 生成文件写入器
+//
+// 所有生成器都通过 FileWriter 写出文件，便于替换写入行为：
+//   - DiskWriter：写入磁盘（默认）
+//   - DryRunWriter：只记录将要写入的文件，不修改磁盘
+type FileWriter interface {
+	WriteFile(path string, content []byte) error
+}
+
+// DiskWriter 写入磁盘的文件写入器，自动创建父目录
+// 文件已存在且内容相同时不重写，保留修改时间，避免触发无谓的重新编译
+type DiskWriter struct{}
+
+// WriteFile 写入文件
+func (DiskWriter) WriteFile(path string, content []byte) error {
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, content) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建输出目录失败: %w", err)
+	}
+	return os.WriteFile(path, content, 0644)
+}
+
+// DryRunWriter 预览模式的文件写入器
+// 只记录将要写入的文件路径和内容，不修改磁盘
+type DryRunWriter struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+// NewDryRunWriter 创建预览模式写入器
+func NewDryRunWriter() *DryRunWriter {
+	return &DryRunWriter{
+		files: make(map[string][]byte),
+	}
+}
+
+// WriteFile 记录文件，不写入磁盘
+func (w *DryRunWriter) WriteFile(path string, content []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.files[path] = content
+	return nil
+}
+
+// Files 返回将要写入的文件路径（按路径排序）
+func (w *DryRunWriter) Files() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	paths := make([]string, 0, len(w.files))
+	for path := range w.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Content 返回记录的文件内容
+func (w *DryRunWriter) Content(path string) ([]byte, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	content, ok := w.files[path]
+	return content, ok
+}
+
+// fileOutput 生成器的文件输出能力，嵌入到各生成器中
+// 零值以内置模板渲染、写入磁盘
+type fileOutput struct {
+	writer    FileWriter
+	templates *Templates
+	imports   map[string]string // 格式化 Go 代码时补充导入使用的包，包名 -> import 路径
+}
+
+// SetWriter 设置文件写入器，传入 nil 恢复为写入磁盘
+func (o *fileOutput) SetWriter(writer FileWriter) {
+	o.writer = writer
+}
+
+// SetTemplates 设置渲染生成文件的模板，传入 nil 恢复为内置模板
+func (o *fileOutput) SetTemplates(templates *Templates) {
+	o.templates = templates
+}
+
+// SetImports 设置格式化生成的 Go 代码时，为引用了但未导入的包补充导入所使用的包（包名 -> import 路径，见 PackageImports）
+func (o *fileOutput) SetImports(imports map[string]string) {
+	o.imports = imports
+}
+
+// render 以模板 name 渲染 data
+func (o *fileOutput) render(name string, data any) (string, error) {
+	templates := o.templates
+	if templates == nil {
+		templates = defaultTemplates()
+	}
+	content, err := templates.Execute(name, data)
+	if err != nil {
+		return "", fmt.Errorf("渲染模板 %s 失败: %w", name, err)
+	}
+	return content, nil
+}
+
+// writeTemplate 以模板 name 渲染 data 并写出到 path，data.Path 设置为 path
+func (o *fileOutput) writeTemplate(path, name string, data templateData) error {
+	data.setPath(path)
+	content, err := o.render(name, data)
+	if err != nil {
+		return err
+	}
+	return o.writeFile(path, content)
+}
+
+// pendingContent 返回写入器中已记录、尚未写入磁盘的文件内容（仅预览模式）
+// 生成器需要读取本次运行中先写出的文件时（如由模型定义文件生成的领域模型），应优先使用该内容
+func (o *fileOutput) pendingContent(path string) ([]byte, bool) {
+	if reader, ok := o.writer.(interface {
+		Content(path string) ([]byte, bool)
+	}); ok {
+		return reader.Content(path)
+	}
+	return nil, false
+}
+
+// writeFile 通过写入器写出文件，保留已有文件中的自定义代码区域（见 preserveCustomRegions）
+// Go 文件写出前整理导入并按 gofmt 格式化（见 formatGoSource）；手写的扩展文件 *_ext.go 不会被写出
+func (o *fileOutput) writeFile(path string, content string) error {
+	if strings.HasSuffix(path, extFileSuffix) {
+		return fmt.Errorf("%s 为手写的扩展文件，不能由生成器写出", path)
+	}
+
+	previous, ok := o.pendingContent(path)
+	if !ok {
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("读取已有文件失败: %w", err)
+		}
+		previous = data
+	}
+	content, err := preserveCustomRegions(string(previous), content)
+	if err != nil {
+		return fmt.Errorf("保留 %s 中的自定义代码失败: %w", path, err)
+	}
+	if strings.HasSuffix(path, ".go") {
+		if content, err = formatGoSource(path, content, o.imports); err != nil {
+			return err
+		}
+	}
+
+	writer := o.writer
+	if writer == nil {
+		writer = DiskWriter{}
+	}
+	return writer.WriteFile(path, []byte(content))
+}
@@ -0,0 +1,563 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"soliton/pkg/parser"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Table 数据库表结构
+type Table struct {
+	Name        string
+	Comment     string
+	Columns     []*Column
+	PrimaryKey  []string      // 主键列，按顺序
+	Indexes     []*Index      // 主键以外的索引
+	ForeignKeys []*ForeignKey // 外键约束
+}
+
+// Column 数据库列
+type Column struct {
+	Name          string
+	DataType      string  // 小写的基础类型，如 varchar、bigint、timestamp with time zone
+	ColumnType    string  // 完整类型，如 varchar(64)、decimal(10,2)、enum('A','B')、tinyint(1)
+	Nullable      bool    // 是否允许 NULL
+	Default       *string // 列默认值表达式，无默认值时为 nil
+	AutoIncrement bool    // 自增或 identity 列
+	Unsigned      bool    // 无符号整数（MySQL）
+	Comment       string
+}
+
+// Index 数据库索引
+type Index struct {
+	Name    string
+	Unique  bool
+	Columns []string
+}
+
+// ForeignKey 外键约束
+type ForeignKey struct {
+	Name              string
+	Columns           []string
+	ReferencedTable   string
+	ReferencedColumns []string
+}
+
+// Inspector 数据库结构读取器，MySQL 与 PostgreSQL 各有一个实现
+type Inspector interface {
+	// Inspect 读取所有表的列、主键、索引和外键，按表名排序
+	Inspect(ctx context.Context) ([]*Table, error)
+}
+
+// foreignKey 返回以 column 为唯一列的外键
+func (t *Table) foreignKey(column string) *ForeignKey {
+	for _, fk := range t.ForeignKeys {
+		if len(fk.Columns) == 1 && fk.Columns[0] == column {
+			return fk
+		}
+	}
+	return nil
+}
+
+// Importer 将数据库表结构转换为模型定义
+//
+// 转换规则：
+//   - 表 → 聚合根，表名取单数并转为驼峰（order_items → OrderItem），与默认表名不一致时声明 table
+//   - 单列主键 → ID 字段，自增列使用 auto 策略，其他整数主键使用 manual 策略
+//   - 单列唯一索引 → unique，组合唯一索引 → uniqueIndexes，单列普通索引 → index
+//   - 单列外键 → ref(Target.Field)
+//   - 只有两个外键列（以及 id、created_at）的关联表不生成聚合根，改为两端聚合根互相声明 ref（多对多）
+//   - 其他恰好有两个外键的表（带业务属性的中间表）→ manyToMany 聚合根
+//   - MySQL enum 列 → enum 注解，列默认值 → default 注解
+//
+// 无法表达的结构（无主键、组合主键、组合外键、组合普通索引、未识别的列类型）会记录为警告。
+type Importer struct {
+	packageName string
+	tables      map[string]bool // 只导入的表，为空表示全部
+	warnings    []string
+}
+
+// NewImporter 创建导入器
+func NewImporter() *Importer {
+	return &Importer{}
+}
+
+// SetPackage 设置生成的模型包名
+func (i *Importer) SetPackage(name string) {
+	i.packageName = name
+}
+
+// SetTables 只导入指定的表，传入空切片表示全部
+func (i *Importer) SetTables(names []string) {
+	i.tables = nil
+	if len(names) > 0 {
+		i.tables = make(map[string]bool, len(names))
+		for _, name := range names {
+			i.tables[name] = true
+		}
+	}
+}
+
+// Warnings 返回最近一次 BuildSchema 中无法转换的结构
+func (i *Importer) Warnings() []string {
+	return i.warnings
+}
+
+// BuildSchema 将表结构转换为模型定义
+func (i *Importer) BuildSchema(tables []*Table) (*parser.Schema, error) {
+	i.warnings = nil
+
+	var selected []*Table
+	for _, table := range tables {
+		if i.tables == nil || i.tables[table.Name] {
+			selected = append(selected, table)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("没有可导入的表")
+	}
+
+	// 先确定聚合根名，外键需要按被引用的表查找
+	aggregateNames := make(map[string]string)
+	joinTables := make(map[string]bool)
+	for _, table := range selected {
+		if i.isJoinTable(table) {
+			joinTables[table.Name] = true
+			continue
+		}
+		if len(table.PrimaryKey) == 0 {
+			i.warn("表 %s 没有主键，已跳过", table.Name)
+			continue
+		}
+		if len(table.PrimaryKey) > 1 {
+			i.warn("表 %s 使用组合主键 (%s)，已跳过", table.Name, strings.Join(table.PrimaryKey, ", "))
+			continue
+		}
+		aggregateNames[table.Name] = aggregateName(table.Name)
+	}
+
+	schema := &parser.Schema{Package: i.packageName}
+	aggregates := make(map[string]*parser.SchemaAggregate)
+	for _, table := range selected {
+		name, ok := aggregateNames[table.Name]
+		if !ok {
+			continue
+		}
+		agg := i.buildAggregate(table, name, aggregateNames)
+		aggregates[table.Name] = agg
+		schema.Aggregates = append(schema.Aggregates, agg)
+	}
+
+	// 纯关联表：两端聚合根互相声明 ref，由生成器重新生成关联表
+	for _, table := range selected {
+		if !joinTables[table.Name] {
+			continue
+		}
+		left, right := aggregates[table.ForeignKeys[0].ReferencedTable], aggregates[table.ForeignKeys[1].ReferencedTable]
+		if left == nil || right == nil {
+			i.warn("关联表 %s 引用的表未导入，已跳过", table.Name)
+			continue
+		}
+		left.Refs = appendUnique(left.Refs, right.Name)
+		right.Refs = appendUnique(right.Refs, left.Name)
+
+		// 表名或列名与生成器的默认命名不同时声明关联表配置，沿用已有的关联表
+		join := &parser.SchemaJoin{
+			With:  right.Name,
+			Table: table.Name,
+			Left:  table.ForeignKeys[0].Columns[0],
+			Right: table.ForeignKeys[1].Columns[0],
+		}
+		if join.Table != defaultJoinTable(left, right) ||
+			join.Left != toSnakeCase(left.Name)+"_id" || join.Right != toSnakeCase(right.Name)+"_id" {
+			left.JoinTables = append(left.JoinTables, join)
+		}
+	}
+
+	sort.Slice(schema.Aggregates, func(a, b int) bool {
+		return schema.Aggregates[a].Name < schema.Aggregates[b].Name
+	})
+	return schema, nil
+}
+
+// buildAggregate 转换单个表
+func (i *Importer) buildAggregate(table *Table, name string, aggregateNames map[string]string) *parser.SchemaAggregate {
+	agg := &parser.SchemaAggregate{
+		Name:       name,
+		Comment:    table.Comment,
+		ManyToMany: isAssociationTable(table),
+	}
+	if pluralize(toSnakeCase(name)) != table.Name {
+		agg.Table = table.Name
+	}
+
+	// 单列索引标记在字段上，组合唯一索引声明在聚合根上
+	unique := make(map[string]bool)
+	indexed := make(map[string]bool)
+	for _, index := range table.Indexes {
+		switch {
+		case len(index.Columns) == 1 && index.Unique:
+			unique[index.Columns[0]] = true
+		case len(index.Columns) == 1:
+			indexed[index.Columns[0]] = true
+		case index.Unique:
+			fields := make([]string, len(index.Columns))
+			for n, column := range index.Columns {
+				fields[n] = fieldName(column)
+			}
+			agg.UniqueIndexes = append(agg.UniqueIndexes, &parser.SchemaIndex{Name: index.Name, Fields: fields})
+		default:
+			i.warn("表 %s 的组合索引 %s (%s) 无法用注解表达，已跳过", table.Name, index.Name, strings.Join(index.Columns, ", "))
+		}
+	}
+	for _, fk := range table.ForeignKeys {
+		if len(fk.Columns) > 1 {
+			i.warn("表 %s 的组合外键 %s 无法用注解表达，已跳过", table.Name, fk.Name)
+		}
+	}
+
+	for _, column := range table.Columns {
+		isPrimaryKey := len(table.PrimaryKey) == 1 && table.PrimaryKey[0] == column.Name
+		field := i.buildField(table, column, isPrimaryKey)
+		field.Unique = unique[column.Name] && !isPrimaryKey
+		field.Index = indexed[column.Name]
+
+		if fk := table.foreignKey(column.Name); fk != nil {
+			if target, ok := aggregateNames[fk.ReferencedTable]; ok {
+				field.Ref = target + "." + fieldName(fk.ReferencedColumns[0])
+			} else {
+				i.warn("表 %s 的列 %s 引用的表 %s 未导入，未生成 ref", table.Name, column.Name, fk.ReferencedTable)
+			}
+		}
+		agg.Fields = append(agg.Fields, field)
+	}
+	return agg
+}
+
+// buildField 转换单个列
+func (i *Importer) buildField(table *Table, column *Column, isPrimaryKey bool) *parser.SchemaField {
+	field := &parser.SchemaField{
+		Name:    fieldName(column.Name),
+		Comment: column.Comment,
+	}
+	if toSnakeCase(field.Name) != column.Name {
+		field.Column = column.Name
+	}
+
+	goType, columnType, known := mapColumnType(column)
+	if !known {
+		i.warn("表 %s 的列 %s 类型 %s 未识别，按 string 处理", table.Name, column.Name, column.ColumnType)
+	}
+	field.ColumnType = columnType
+	if values := enumValues(column); len(values) > 0 {
+		field.Enum = values
+	}
+
+	if isPrimaryKey {
+		field.Type = goType
+		field.ID = field.Name != "ID"
+		field.Strategy = idStrategy(column, goType)
+		return field
+	}
+
+	if column.Nullable && goType != "[]byte" {
+		field.Type = "*" + goType
+	} else {
+		field.Type = goType
+	}
+	if column.Default != nil {
+		field.Default = defaultValue(*column.Default, goType)
+	}
+	// NOT NULL 且没有默认值的字符串列视为必填
+	field.Required = goType == "string" && !column.Nullable && column.Default == nil && len(field.Enum) == 0
+	return field
+}
+
+// defaultJoinTable 返回生成器为两个聚合根默认生成的关联表名：按聚合根名字母序以 _ 连接，
+// 聚合根声明了表名时使用其表名
+func defaultJoinTable(a, b *parser.SchemaAggregate) string {
+	if a.Name > b.Name {
+		a, b = b, a
+	}
+	part := func(agg *parser.SchemaAggregate) string {
+		if agg.Table != "" {
+			return agg.Table
+		}
+		return toSnakeCase(agg.Name)
+	}
+	return part(a) + "_" + part(b)
+}
+
+// isJoinTable 判断是否为纯关联表：多对多中间表除两个外键列外只有 id、created_at 这类生成器自动维护的列
+func (i *Importer) isJoinTable(table *Table) bool {
+	if !isAssociationTable(table) {
+		return false
+	}
+	fkColumns := map[string]bool{
+		table.ForeignKeys[0].Columns[0]: true,
+		table.ForeignKeys[1].Columns[0]: true,
+	}
+	for _, column := range table.Columns {
+		if !fkColumns[column.Name] && column.Name != "id" && column.Name != "created_at" {
+			return false
+		}
+	}
+	return true
+}
+
+// isAssociationTable 判断是否为带业务属性的多对多中间表：恰好两个指向不同表的单列外键
+func isAssociationTable(table *Table) bool {
+	return len(table.ForeignKeys) == 2 &&
+		len(table.ForeignKeys[0].Columns) == 1 && len(table.ForeignKeys[1].Columns) == 1 &&
+		table.ForeignKeys[0].ReferencedTable != table.ForeignKeys[1].ReferencedTable
+}
+
+// warn 记录警告
+func (i *Importer) warn(format string, args ...interface{}) {
+	i.warnings = append(i.warnings, fmt.Sprintf(format, args...))
+}
+
+// lengthPattern 匹配 varchar(64)、decimal(10,2) 中的长度和精度
+var lengthPattern = regexp.MustCompile(`\((\d+)(?:\s*,\s*(\d+))?\)`)
+
+// mapColumnType 列类型映射到 Go 类型
+// 返回 Go 类型、需要保留的列类型（生成的 DDL 默认类型与原列不一致时），以及类型是否被识别
+//
+// 保留的列类型只使用 MySQL 与 PostgreSQL 通用的写法（VARCHAR、CHAR、DECIMAL、TEXT），
+// 生成器输出的 DDL 是 MySQL 方言，数据库特有的类型交给 Go 类型的默认映射。
+func mapColumnType(column *Column) (goType string, columnType string, known bool) {
+	dataType := strings.ToLower(column.DataType)
+	fullType := strings.ToLower(column.ColumnType)
+	length := lengthPattern.FindStringSubmatch(fullType)
+
+	switch dataType {
+	case "tinyint":
+		if strings.HasPrefix(fullType, "tinyint(1)") {
+			return "bool", "", true
+		}
+		return unsigned("int8", column), "", true
+	case "bool", "boolean":
+		return "bool", "", true
+	case "smallint", "int2", "smallserial":
+		return unsigned("int16", column), "", true
+	case "mediumint", "int", "integer", "int4", "serial":
+		return unsigned("int", column), "", true
+	case "bigint", "int8", "bigserial":
+		return unsigned("int64", column), "", true
+	case "float", "real", "float4":
+		return "float32", "", true
+	case "double", "double precision", "float8":
+		return "float64", "", true
+	case "decimal", "numeric":
+		if length != nil && length[2] != "" {
+			return "float64", fmt.Sprintf("DECIMAL(%s,%s)", length[1], length[2]), true
+		}
+		if length != nil {
+			return "float64", fmt.Sprintf("DECIMAL(%s)", length[1]), true
+		}
+		return "float64", "", true
+	case "varchar", "character varying":
+		if length != nil && length[1] != "255" {
+			return "string", fmt.Sprintf("VARCHAR(%s)", length[1]), true
+		}
+		return "string", "", true
+	case "char", "character", "bpchar":
+		if length != nil {
+			return "string", fmt.Sprintf("CHAR(%s)", length[1]), true
+		}
+		return "string", "", true
+	case "text", "tinytext", "mediumtext", "longtext", "citext":
+		return "string", "TEXT", true
+	case "enum", "set", "uuid", "json", "jsonb", "time", "interval", "inet", "cidr", "xml":
+		return "string", "", true
+	case "date", "datetime", "timestamp", "timestamp without time zone", "timestamp with time zone", "timestamptz":
+		return "time.Time", "", true
+	case "blob", "tinyblob", "mediumblob", "longblob", "binary", "varbinary", "bytea":
+		return "[]byte", "", true
+	}
+	return "string", "", false
+}
+
+// unsigned 无符号整数列使用对应的无符号类型
+func unsigned(goType string, column *Column) string {
+	if column.Unsigned {
+		return "u" + goType
+	}
+	return goType
+}
+
+// enumValuePattern 匹配 enum('A','B') 中的值
+var enumValuePattern = regexp.MustCompile(`'((?:[^']|'')*)'`)
+
+// enumValues 返回 MySQL enum 列的取值
+func enumValues(column *Column) []string {
+	if strings.ToLower(column.DataType) != "enum" {
+		return nil
+	}
+	var values []string
+	for _, match := range enumValuePattern.FindAllStringSubmatch(column.ColumnType, -1) {
+		values = append(values, strings.ReplaceAll(match[1], "''", "'"))
+	}
+	return values
+}
+
+// idStrategy 主键生成策略：自增列使用 auto（int64 主键的默认策略，不必声明），
+// 其他整数主键由调用方设置；CHAR(36) / uuid 字符串主键使用 uuid（string 主键的默认策略）
+func idStrategy(column *Column, goType string) string {
+	dataType := strings.ToLower(column.DataType)
+	switch {
+	case goType == "string" && (dataType == "uuid" || strings.EqualFold(column.ColumnType, "char(36)")):
+		return ""
+	case goType == "string":
+		return "manual"
+	case column.AutoIncrement && goType == "int64":
+		return ""
+	case column.AutoIncrement:
+		return "auto"
+	}
+	return "manual"
+}
+
+// defaultValue 将列默认值表达式转换为 +soliton:default 的值，无法表达时返回空
+//
+// 去掉 PostgreSQL 的类型转换（'PENDING'::character varying）和字符串引号，
+// CURRENT_TIMESTAMP、now() 统一为 now()，序列、函数调用等表达式忽略。
+func defaultValue(expr string, goType string) string {
+	value := strings.TrimSpace(expr)
+	if idx := strings.Index(value, "::"); idx > 0 {
+		value = value[:idx]
+	}
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		value = value[1 : len(value)-1]
+	}
+
+	upper := strings.ToUpper(value)
+	switch {
+	case upper == "NULL" || value == "":
+		return ""
+	case goType == "time.Time":
+		if strings.HasPrefix(upper, "CURRENT_TIMESTAMP") || strings.HasPrefix(upper, "NOW(") || upper == "NOW" {
+			return "now()"
+		}
+		return ""
+	case goType == "bool":
+		switch upper {
+		case "1", "B'1'", "TRUE", "'1'":
+			return "true"
+		case "0", "B'0'", "FALSE", "'0'":
+			return "false"
+		}
+		return ""
+	}
+
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	}
+	if goType == "string" {
+		// MySQL 8 的 information_schema 不带引号返回字符串默认值
+		if strings.Contains(value, "(") {
+			return ""
+		}
+		return value
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	return ""
+}
+
+// initialisms 字段名中整体大写的缩写
+var initialisms = map[string]bool{
+	"ID": true, "URL": true, "URI": true, "IP": true, "UUID": true, "API": true,
+	"JSON": true, "HTTP": true, "SQL": true, "SKU": true,
+}
+
+// fieldName 列名转为字段名，如 user_id → UserID、avatar_url → AvatarURL
+func fieldName(column string) string {
+	var sb strings.Builder
+	for _, word := range strings.FieldsFunc(column, func(r rune) bool { return r == '_' || r == '-' || r == ' ' }) {
+		if upper := strings.ToUpper(word); initialisms[upper] {
+			sb.WriteString(upper)
+			continue
+		}
+		sb.WriteString(strings.ToUpper(word[:1]))
+		sb.WriteString(word[1:])
+	}
+	name := sb.String()
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "F" + name
+	}
+	return name
+}
+
+// aggregateName 表名转为聚合根名：取最后一个单词的单数形式后转为驼峰，如 order_items → OrderItem
+func aggregateName(table string) string {
+	words := strings.Split(table, "_")
+	words[len(words)-1] = singularize(words[len(words)-1])
+	return fieldName(strings.Join(words, "_"))
+}
+
+// singularize 简单的英文单数规则，与生成器的复数规则（pluralize）互逆
+func singularize(word string) string {
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 3:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "ses") && len(word) > 3,
+		strings.HasSuffix(word, "xes"), strings.HasSuffix(word, "zes"),
+		strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && len(word) > 1:
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// pluralize 与 metadata 中的表名复数规则保持一致
+func pluralize(name string) string {
+	switch {
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "z"),
+		strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiou", rune(name[len(name)-2])):
+		return name[:len(name)-1] + "ies"
+	default:
+		return name + "s"
+	}
+}
+
+// toSnakeCase 与 metadata 中的列名规则保持一致，如 UserID → user_id
+func toSnakeCase(s string) string {
+	var result []rune
+	runes := []rune(s)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 && runes[i-1] >= 'a' && runes[i-1] <= 'z' {
+				result = append(result, '_')
+			}
+			if i > 0 && i < len(runes)-1 &&
+				runes[i-1] >= 'A' && runes[i-1] <= 'Z' &&
+				runes[i+1] >= 'a' && runes[i+1] <= 'z' {
+				result = append(result, '_')
+			}
+		}
+		result = append(result, r)
+	}
+
+	return strings.ToLower(string(result))
+}
+
+// appendUnique 追加不重复的元素
+func appendUnique(items []string, item string) []string {
+	for _, existing := range items {
+		if existing == item {
+			return items
+		}
+	}
+	return append(items, item)
+}
@@ -0,0 +1,118 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// MySQLInspector 通过 information_schema 读取 MySQL 表结构
+type MySQLInspector struct {
+	db     *sql.DB
+	schema string
+}
+
+// NewMySQLInspector 创建 MySQL 表结构读取器，db 需使用已注册的 mysql 驱动打开
+func NewMySQLInspector(db *sql.DB) *MySQLInspector {
+	return &MySQLInspector{db: db}
+}
+
+// SetSchema 设置读取的数据库名，默认为连接当前使用的数据库（DATABASE()）
+func (i *MySQLInspector) SetSchema(schema string) {
+	i.schema = schema
+}
+
+// Inspect 读取所有表的列、主键、索引和外键
+func (i *MySQLInspector) Inspect(ctx context.Context) ([]*Table, error) {
+	schema := i.schema
+	if schema == "" {
+		var current sql.NullString
+		if err := i.db.QueryRowContext(ctx, "SELECT DATABASE()").Scan(&current); err != nil {
+			return nil, fmt.Errorf("查询当前数据库失败: %w", err)
+		}
+		if !current.Valid || current.String == "" {
+			return nil, fmt.Errorf("连接未选择数据库，请在 DSN 中指定数据库名")
+		}
+		schema = current.String
+	}
+
+	tables := newTableSet()
+
+	err := queryRows(ctx, i.db, `
+		SELECT TABLE_NAME, TABLE_COMMENT
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE'
+		ORDER BY TABLE_NAME`, []any{schema}, func(rows *sql.Rows) error {
+		var name, comment string
+		if err := rows.Scan(&name, &comment); err != nil {
+			return err
+		}
+		tables.add(name, comment)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取表失败: %w", err)
+	}
+
+	err = queryRows(ctx, i.db, `
+		SELECT TABLE_NAME, COLUMN_NAME, DATA_TYPE, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT, EXTRA, COLUMN_COMMENT
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ?
+		ORDER BY TABLE_NAME, ORDINAL_POSITION`, []any{schema}, func(rows *sql.Rows) error {
+		var tableName, nullable, extra string
+		var defaultValue sql.NullString
+		column := &Column{}
+		if err := rows.Scan(&tableName, &column.Name, &column.DataType, &column.ColumnType, &nullable,
+			&defaultValue, &extra, &column.Comment); err != nil {
+			return err
+		}
+		column.DataType = strings.ToLower(column.DataType)
+		column.Nullable = nullable == "YES"
+		column.AutoIncrement = strings.Contains(strings.ToLower(extra), "auto_increment")
+		column.Unsigned = strings.Contains(strings.ToLower(column.ColumnType), "unsigned")
+		if defaultValue.Valid {
+			column.Default = &defaultValue.String
+		}
+		tables.addColumn(tableName, column)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取列失败: %w", err)
+	}
+
+	err = queryRows(ctx, i.db, `
+		SELECT TABLE_NAME, INDEX_NAME, NON_UNIQUE, COLUMN_NAME
+		FROM information_schema.STATISTICS
+		WHERE TABLE_SCHEMA = ?
+		ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX`, []any{schema}, func(rows *sql.Rows) error {
+		var tableName, indexName, columnName string
+		var nonUnique int
+		if err := rows.Scan(&tableName, &indexName, &nonUnique, &columnName); err != nil {
+			return err
+		}
+		tables.addIndexColumn(tableName, indexName, indexName == "PRIMARY", nonUnique == 0, columnName)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取索引失败: %w", err)
+	}
+
+	err = queryRows(ctx, i.db, `
+		SELECT TABLE_NAME, CONSTRAINT_NAME, COLUMN_NAME, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME
+		FROM information_schema.KEY_COLUMN_USAGE
+		WHERE TABLE_SCHEMA = ? AND REFERENCED_TABLE_NAME IS NOT NULL
+		ORDER BY TABLE_NAME, CONSTRAINT_NAME, ORDINAL_POSITION`, []any{schema}, func(rows *sql.Rows) error {
+		var tableName, constraintName, columnName, referencedTable, referencedColumn string
+		if err := rows.Scan(&tableName, &constraintName, &columnName, &referencedTable, &referencedColumn); err != nil {
+			return err
+		}
+		tables.addForeignKeyColumn(tableName, constraintName, columnName, referencedTable, referencedColumn)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取外键失败: %w", err)
+	}
+
+	return tables.list(), nil
+}
@@ -0,0 +1,32 @@
+package importer
+
+import "testing"
+
+func TestSingularizeInvertsPluralize(t *testing.T) {
+	words := []string{"order", "item", "status", "category", "day", "key", "box", "tax", "match", "wish", "quiz"}
+	for _, word := range words {
+		plural := pluralize(word)
+		if got := singularize(plural); got != word {
+			t.Errorf("singularize(%q) = %q, want %q", plural, got, word)
+		}
+	}
+}
+
+func TestAggregateName(t *testing.T) {
+	tests := []struct {
+		table string
+		want  string
+	}{
+		{"order_items", "OrderItem"},
+		{"holidays", "Holiday"},
+		{"api_keys", "APIKey"},
+		{"mail_boxes", "MailBox"},
+		{"matches", "Match"},
+		{"categories", "Category"},
+	}
+	for _, tt := range tests {
+		if got := aggregateName(tt.table); got != tt.want {
+			t.Errorf("aggregateName(%q) = %q, want %q", tt.table, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// PostgresInspector 通过 pg_catalog 和 information_schema 读取 PostgreSQL 表结构
+type PostgresInspector struct {
+	db     *sql.DB
+	schema string
+}
+
+// NewPostgresInspector 创建 PostgreSQL 表结构读取器，db 需使用已注册的 postgres 驱动打开
+func NewPostgresInspector(db *sql.DB) *PostgresInspector {
+	return &PostgresInspector{db: db}
+}
+
+// SetSchema 设置读取的模式（schema），默认为 public
+func (i *PostgresInspector) SetSchema(schema string) {
+	i.schema = schema
+}
+
+// Inspect 读取所有表的列、主键、索引和外键
+func (i *PostgresInspector) Inspect(ctx context.Context) ([]*Table, error) {
+	schema := i.schema
+	if schema == "" {
+		schema = "public"
+	}
+
+	tables := newTableSet()
+
+	err := queryRows(ctx, i.db, `
+		SELECT c.relname, COALESCE(obj_description(c.oid, 'pg_class'), '')
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relkind IN ('r', 'p')
+		ORDER BY c.relname`, []any{schema}, func(rows *sql.Rows) error {
+		var name, comment string
+		if err := rows.Scan(&name, &comment); err != nil {
+			return err
+		}
+		tables.add(name, comment)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取表失败: %w", err)
+	}
+
+	err = queryRows(ctx, i.db, `
+		SELECT c.table_name, c.column_name, c.data_type, c.udt_name,
+			c.character_maximum_length, c.numeric_precision, c.numeric_scale,
+			c.is_nullable, c.column_default, c.is_identity,
+			COALESCE(col_description(format('%I.%I', c.table_schema, c.table_name)::regclass, c.ordinal_position), '')
+		FROM information_schema.columns c
+		WHERE c.table_schema = $1
+		ORDER BY c.table_name, c.ordinal_position`, []any{schema}, func(rows *sql.Rows) error {
+		var tableName, dataType, udtName, nullable, identity string
+		var length, precision, scale sql.NullInt64
+		var defaultValue sql.NullString
+		column := &Column{}
+		if err := rows.Scan(&tableName, &column.Name, &dataType, &udtName, &length, &precision, &scale,
+			&nullable, &defaultValue, &identity, &column.Comment); err != nil {
+			return err
+		}
+
+		// USER-DEFINED、ARRAY 等类型使用 udt_name，便于类型映射
+		column.DataType = strings.ToLower(dataType)
+		if column.DataType == "user-defined" || column.DataType == "array" {
+			column.DataType = udtName
+		}
+		column.ColumnType = udtName
+		switch {
+		case length.Valid:
+			column.ColumnType = fmt.Sprintf("%s(%d)", udtName, length.Int64)
+		case column.DataType == "numeric" && precision.Valid && scale.Valid:
+			column.ColumnType = fmt.Sprintf("numeric(%d,%d)", precision.Int64, scale.Int64)
+		}
+
+		column.Nullable = nullable == "YES"
+		column.AutoIncrement = identity == "YES" ||
+			defaultValue.Valid && strings.HasPrefix(defaultValue.String, "nextval(")
+		if defaultValue.Valid && !column.AutoIncrement {
+			column.Default = &defaultValue.String
+		}
+		tables.addColumn(tableName, column)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取列失败: %w", err)
+	}
+
+	err = queryRows(ctx, i.db, `
+		SELECT t.relname, ic.relname, ix.indisprimary, ix.indisunique, a.attname
+		FROM pg_index ix
+		JOIN pg_class t ON t.oid = ix.indrelid
+		JOIN pg_class ic ON ic.oid = ix.indexrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord) ON true
+		JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+		WHERE n.nspname = $1
+		ORDER BY t.relname, ic.relname, k.ord`, []any{schema}, func(rows *sql.Rows) error {
+		var tableName, indexName, columnName string
+		var primary, unique bool
+		if err := rows.Scan(&tableName, &indexName, &primary, &unique, &columnName); err != nil {
+			return err
+		}
+		tables.addIndexColumn(tableName, indexName, primary, unique, columnName)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取索引失败: %w", err)
+	}
+
+	err = queryRows(ctx, i.db, `
+		SELECT t.relname, con.conname, a.attname, rt.relname, ra.attname
+		FROM pg_constraint con
+		JOIN pg_class t ON t.oid = con.conrelid
+		JOIN pg_class rt ON rt.oid = con.confrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		JOIN LATERAL unnest(con.conkey, con.confkey) WITH ORDINALITY AS k(attnum, refattnum, ord) ON true
+		JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+		JOIN pg_attribute ra ON ra.attrelid = con.confrelid AND ra.attnum = k.refattnum
+		WHERE con.contype = 'f' AND n.nspname = $1
+		ORDER BY t.relname, con.conname, k.ord`, []any{schema}, func(rows *sql.Rows) error {
+		var tableName, constraintName, columnName, referencedTable, referencedColumn string
+		if err := rows.Scan(&tableName, &constraintName, &columnName, &referencedTable, &referencedColumn); err != nil {
+			return err
+		}
+		tables.addForeignKeyColumn(tableName, constraintName, columnName, referencedTable, referencedColumn)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取外键失败: %w", err)
+	}
+
+	return tables.list(), nil
+}
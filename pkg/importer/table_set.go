@@ -0,0 +1,95 @@
+package importer
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+)
+
+// tableSet 按查询结果逐行组装表结构
+// 各查询结果按表名、索引/约束名和列序排序，同一索引或外键的列连续出现
+type tableSet struct {
+	tables map[string]*Table
+}
+
+// newTableSet 创建表结构集合
+func newTableSet() *tableSet {
+	return &tableSet{tables: make(map[string]*Table)}
+}
+
+// add 登记表，列、索引和外键只追加到已登记的表（视图等其他对象被忽略）
+func (s *tableSet) add(name, comment string) {
+	s.tables[name] = &Table{Name: name, Comment: comment}
+}
+
+// addColumn 追加列
+func (s *tableSet) addColumn(tableName string, column *Column) {
+	if table, ok := s.tables[tableName]; ok {
+		table.Columns = append(table.Columns, column)
+	}
+}
+
+// addIndexColumn 追加索引列，主键记录到 Table.PrimaryKey
+func (s *tableSet) addIndexColumn(tableName, indexName string, primary, unique bool, columnName string) {
+	table, ok := s.tables[tableName]
+	if !ok {
+		return
+	}
+	if primary {
+		table.PrimaryKey = append(table.PrimaryKey, columnName)
+		return
+	}
+	if n := len(table.Indexes); n > 0 && table.Indexes[n-1].Name == indexName {
+		table.Indexes[n-1].Columns = append(table.Indexes[n-1].Columns, columnName)
+		return
+	}
+	table.Indexes = append(table.Indexes, &Index{Name: indexName, Unique: unique, Columns: []string{columnName}})
+}
+
+// addForeignKeyColumn 追加外键列
+func (s *tableSet) addForeignKeyColumn(tableName, constraintName, columnName, referencedTable, referencedColumn string) {
+	table, ok := s.tables[tableName]
+	if !ok {
+		return
+	}
+	if n := len(table.ForeignKeys); n > 0 && table.ForeignKeys[n-1].Name == constraintName {
+		fk := table.ForeignKeys[n-1]
+		fk.Columns = append(fk.Columns, columnName)
+		fk.ReferencedColumns = append(fk.ReferencedColumns, referencedColumn)
+		return
+	}
+	table.ForeignKeys = append(table.ForeignKeys, &ForeignKey{
+		Name:              constraintName,
+		Columns:           []string{columnName},
+		ReferencedTable:   referencedTable,
+		ReferencedColumns: []string{referencedColumn},
+	})
+}
+
+// list 返回按表名排序的表
+func (s *tableSet) list() []*Table {
+	tables := make([]*Table, 0, len(s.tables))
+	for _, table := range s.tables {
+		tables = append(tables, table)
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		return tables[i].Name < tables[j].Name
+	})
+	return tables
+}
+
+// queryRows 执行查询并逐行回调
+func queryRows(ctx context.Context, db *sql.DB, query string, args []any, scan func(rows *sql.Rows) error) error {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,105 @@
+package metadata
+
+import (
+	"go/token"
+	"strings"
+)
+
+// AnnotationNode 一个 +soliton 注解的语法树节点
+//
+// 所有注解共用同一套参数语法：+soliton:name 或 +soliton:name(参数列表)，
+// 参数以逗号分隔，形如 key=value 或不带 key 的值，如
+// +soliton:unique(name=uk_email, where=deleted_at IS NULL)。
+// 括号和双引号内的逗号不作为分隔符，不带 key 的参数并入前一个参数的值（如 fields=TenantID,Email）。
+type AnnotationNode struct {
+	Name string           `json:"name"`           // 注解名，如 unique
+	Raw  string           `json:"raw,omitempty"`  // 括号内的原始文本，pattern、default 等按原文取值
+	Args []*AnnotationArg `json:"args,omitempty"` // 解析后的参数，按声明顺序排列
+	Pos  token.Position   `json:"-"`              // 注解（+soliton:）在源码中的位置，从模型定义文件或 JSON 加载时无效
+}
+
+// AnnotationArg 注解参数
+type AnnotationArg struct {
+	Key   string `json:"key,omitempty"` // 参数名，不带 key 的参数为空
+	Value string `json:"value"`         // 去掉两侧空白和双引号的参数值
+}
+
+// Arg 返回参数 key 的值，未声明时为空
+func (n *AnnotationNode) Arg(key string) string {
+	for _, arg := range n.Args {
+		if arg.Key == key {
+			return arg.Value
+		}
+	}
+	return ""
+}
+
+// HasArg 判断是否声明了参数 key
+func (n *AnnotationNode) HasArg(key string) bool {
+	for _, arg := range n.Args {
+		if arg.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Positional 返回不带 key 的参数值，如 +soliton:enum(A,B) 为 [A B]
+func (n *AnnotationNode) Positional() []string {
+	var values []string
+	for _, arg := range n.Args {
+		if arg.Key == "" {
+			values = append(values, arg.Value)
+		}
+	}
+	return values
+}
+
+// Option 返回简写形式的参数：只有一个不带 key 的参数时返回它，否则返回参数 key 的值
+// 如 +soliton:id(uuid) 与 +soliton:id(strategy=uuid) 的 Option("strategy") 都为 uuid
+func (n *AnnotationNode) Option(key string) string {
+	if len(n.Args) == 1 && n.Args[0].Key == "" {
+		return n.Args[0].Value
+	}
+	return n.Arg(key)
+}
+
+// List 将参数 key 的值按逗号拆分，去掉空项，如 fields=TenantID,Email 为 [TenantID Email]
+func (n *AnnotationNode) List(key string) []string {
+	var values []string
+	for _, value := range strings.Split(n.Arg(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// AnnotationList 按声明顺序排列的注解节点
+type AnnotationList []*AnnotationNode
+
+// Get 返回第一个名为 name 的注解，不存在时为 nil
+func (l AnnotationList) Get(name string) *AnnotationNode {
+	for _, node := range l {
+		if node.Name == name {
+			return node
+		}
+	}
+	return nil
+}
+
+// All 返回所有名为 name 的注解，如聚合根上的多个 +soliton:uniqueIndex
+func (l AnnotationList) All(name string) []*AnnotationNode {
+	var nodes []*AnnotationNode
+	for _, node := range l {
+		if node.Name == name {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+// Has 判断是否声明了注解 name
+func (l AnnotationList) Has(name string) bool {
+	return l.Get(name) != nil
+}
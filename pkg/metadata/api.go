@@ -0,0 +1,86 @@
+package metadata
+
+import (
+	"slices"
+	"strings"
+)
+
+// API 协议（+soliton:api(rest, grpc)）
+const (
+	APIProtocolREST    = "rest"
+	APIProtocolGRPC    = "grpc"
+	APIProtocolGraphQL = "graphql"
+)
+
+// API 操作（+soliton:api(ops=create,get,list)）
+const (
+	APIOpCreate = "create" // 新增
+	APIOpGet    = "get"    // 按主键查询
+	APIOpList   = "list"   // 分页列表
+	APIOpUpdate = "update" // 更新
+	APIOpDelete = "delete" // 删除
+)
+
+// APIProtocols 支持的 API 协议
+var APIProtocols = []string{APIProtocolREST, APIProtocolGRPC, APIProtocolGraphQL}
+
+// APIOps 支持的 API 操作，按生成顺序排列
+var APIOps = []string{APIOpCreate, APIOpGet, APIOpList, APIOpUpdate, APIOpDelete}
+
+// APIMetadata 聚合根对外暴露的 API
+//
+// 由聚合根上的 +soliton:api 声明，HTTP、gRPC、GraphQL 生成器据此决定暴露哪些聚合根、使用的路径以及生成哪些操作：
+//
+//	// +soliton:api(rest, grpc, path=/orders, ops=create,get,list)
+//	// +soliton:api(exclude=delete)
+//
+// 不带协议时只暴露 REST 接口；ops 列出启用的操作，exclude 列出禁用的操作，都未声明时启用全部操作。
+type APIMetadata struct {
+	Protocols []string `json:"protocols"`         // 暴露的协议，见 APIProtocolREST 等常量
+	Path      string   `json:"path,omitempty"`    // REST 资源路径，如 "/orders"，未声明时见 AggregateMetadata.APIPath
+	Ops       []string `json:"ops,omitempty"`     // 启用的操作，为空表示全部
+	Exclude   []string `json:"exclude,omitempty"` // 禁用的操作
+}
+
+// Exposes 判断是否通过协议 protocol 暴露
+func (a *APIMetadata) Exposes(protocol string) bool {
+	return slices.Contains(a.Protocols, protocol)
+}
+
+// Enabled 判断操作 op 是否启用
+func (a *APIMetadata) Enabled(op string) bool {
+	if slices.Contains(a.Exclude, op) {
+		return false
+	}
+	return len(a.Ops) == 0 || slices.Contains(a.Ops, op)
+}
+
+// Operations 返回启用的操作，按 APIOps 的顺序排列
+func (a *APIMetadata) Operations() []string {
+	var ops []string
+	for _, op := range APIOps {
+		if a.Enabled(op) {
+			ops = append(ops, op)
+		}
+	}
+	return ops
+}
+
+// Exposes 判断聚合根是否通过协议 protocol 对外暴露，未声明 +soliton:api 时为 false
+func (a *AggregateMetadata) Exposes(protocol string) bool {
+	return a.API != nil && a.API.Exposes(protocol)
+}
+
+// APIPath 返回 REST 资源路径：优先使用 +soliton:api(path=...)，否则为聚合根名的复数短横线形式，如 OrderItem → /order-items
+// 默认路径不受表名前缀和 +soliton:table 影响
+func (a *AggregateMetadata) APIPath() string {
+	if a.API != nil && a.API.Path != "" {
+		return a.API.Path
+	}
+	return "/" + strings.ReplaceAll(pluralize(toSnakeCase(a.Name)), "_", "-")
+}
+
+// PluralName 返回聚合根名的复数形式，用于列表操作的命名，如 Order → Orders、Category → Categories
+func (a *AggregateMetadata) PluralName() string {
+	return pluralize(a.Name)
+}
@@ -0,0 +1,46 @@
+package metadata
+
+// BehaviorMetadata 领域行为元数据
+//
+// 对应聚合根上声明的导出方法，如 func (o *Order) Pay(amount float64) error。
+// 标记 +soliton:command 的方法为命令，应用服务和 API 生成器据此对外暴露为操作。
+type BehaviorMetadata struct {
+	Name            string           `json:"name"`                  // 方法名，如 "Pay"
+	Comment         string           `json:"comment,omitempty"`     // 方法注释（去掉方法名前缀和注解行）
+	Params          []*ParamMetadata `json:"params,omitempty"`      // 参数列表
+	Results         []*ParamMetadata `json:"results,omitempty"`     // 返回值列表
+	PointerReceiver bool             `json:"pointerReceiver"`       // 接收者是否为指针，如 (o *Order)
+	IsCommand       bool             `json:"isCommand"`             // +soliton:command
+	CommandName     string           `json:"commandName,omitempty"` // +soliton:command(name=PlaceOrder) 对外暴露的操作名，未声明时见 Command()
+	Nodes           AnnotationList   `json:"nodes,omitempty"`       // 方法注释中声明的全部注解
+}
+
+// ParamMetadata 方法参数或返回值
+type ParamMetadata struct {
+	Name string `json:"name,omitempty"` // 参数名，未命名的返回值为空
+	Type string `json:"type"`           // 源码中的类型表达式，如 "context.Context"、"*Address"、"...string"
+}
+
+// Command 返回命令对外暴露的操作名，未声明 name 时为方法名
+func (b *BehaviorMetadata) Command() string {
+	if b.CommandName != "" {
+		return b.CommandName
+	}
+	return b.Name
+}
+
+// ReturnsError 判断方法的最后一个返回值是否为 error
+func (b *BehaviorMetadata) ReturnsError() bool {
+	return len(b.Results) > 0 && b.Results[len(b.Results)-1].Type == "error"
+}
+
+// Commands 返回聚合根上标记 +soliton:command 的领域行为
+func (a *AggregateMetadata) Commands() []*BehaviorMetadata {
+	var commands []*BehaviorMetadata
+	for _, behavior := range a.Behaviors {
+		if behavior.IsCommand {
+			commands = append(commands, behavior)
+		}
+	}
+	return commands
+}
@@ -0,0 +1,299 @@
+package metadata
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect 数据库方言，决定列的 SQL 类型和默认值的写法
+//
+// 表结构元数据（见 NewSchema）按方言计算一次，SQL 脚本、DO 标签、ER 图、元数据差异等都使用同一份结果。
+type Dialect interface {
+	// Name 返回方言名称，如 "mysql"
+	Name() string
+	// ColumnType 返回字段对应列的 SQL 类型，如 string → VARCHAR(255)
+	ColumnType(field *FieldMetadata) string
+	// DefaultValue 返回字段默认值（+soliton:default）在 DEFAULT 子句中的写法，未声明或无效时返回 false
+	DefaultValue(field *FieldMetadata) (string, bool)
+	// PartialIndexes 是否支持部分索引（CREATE INDEX ... WHERE）
+	// 支持时软删除聚合根的唯一索引只约束未删除的记录，见 NewSchema
+	PartialIndexes() bool
+}
+
+// 支持的方言名称
+const (
+	DialectMySQL    = "mysql"
+	DialectPostgres = "postgres"
+	DialectSQLite   = "sqlite"
+)
+
+// ParseDialect 按名称返回方言：mysql、postgres（也可写作 postgresql、pg）或 sqlite（也可写作 sqlite3）
+func ParseDialect(name string) (Dialect, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", DialectMySQL:
+		return MySQLDialect{}, nil
+	case DialectPostgres, "postgresql", "pg":
+		return PostgresDialect{}, nil
+	case DialectSQLite, "sqlite3":
+		return SQLiteDialect{}, nil
+	}
+	return nil, fmt.Errorf("不支持的数据库方言: %s（可选 %s、%s、%s）", name, DialectMySQL, DialectPostgres, DialectSQLite)
+}
+
+// MySQLDialect MySQL 5.7+ 方言
+type MySQLDialect struct{}
+
+// Name 实现 Dialect
+func (MySQLDialect) Name() string {
+	return DialectMySQL
+}
+
+// ColumnType 实现 Dialect
+//
+// 优先级：+soliton:column(type=...) > 值对象（JSON，TEXT）> AES 加密字段（密文为 base64 编码，VARCHAR(1024)）
+// > 已知标量类型（如 uuid.UUID → CHAR(36)）> Go 类型映射，无法映射的复杂类型使用 TEXT。
+func (d MySQLDialect) ColumnType(field *FieldMetadata) string {
+	switch {
+	case field.ColumnType != "":
+		return field.ColumnType
+	case field.Annotations.IsValueObject:
+		return "TEXT"
+	case field.Annotations.Sensitive == SensitiveAES:
+		return "VARCHAR(1024)"
+	case field.ScalarType != nil && field.ScalarType.SQLType != "":
+		return field.ScalarType.SQLType
+	}
+
+	switch strings.TrimPrefix(field.GoType(), "*") {
+	case "int64":
+		return "BIGINT"
+	case "int", "int32":
+		return "INT"
+	case "int16":
+		return "SMALLINT"
+	case "int8":
+		return "TINYINT"
+	case "uint64":
+		return "BIGINT UNSIGNED"
+	case "uint", "uint32":
+		return "INT UNSIGNED"
+	case "uint16":
+		return "SMALLINT UNSIGNED"
+	case "uint8":
+		return "TINYINT UNSIGNED"
+	case "float64":
+		return "DOUBLE"
+	case "float32":
+		return "FLOAT"
+	case "bool":
+		return "TINYINT(1)"
+	case "string":
+		return "VARCHAR(255)"
+	case "time.Time":
+		return "DATETIME"
+	case "[]byte":
+		return "BLOB"
+	default:
+		return "TEXT"
+	}
+}
+
+// DefaultValue 实现 Dialect
+// now() 映射为 CURRENT_TIMESTAMP，布尔值映射为 1/0，字符串加单引号并转义
+func (MySQLDialect) DefaultValue(field *FieldMetadata) (string, bool) {
+	literal, ok := field.DefaultLiteral()
+	if !ok {
+		return "", false
+	}
+
+	switch {
+	case field.Annotations.Default == DefaultNow:
+		return "CURRENT_TIMESTAMP", true
+	case literal == "true":
+		return "1", true
+	case literal == "false":
+		return "0", true
+	case strings.HasPrefix(literal, `"`):
+		value := strings.ReplaceAll(field.Annotations.Default, `\`, `\\`)
+		return "'" + strings.ReplaceAll(value, "'", "''") + "'", true
+	}
+	return literal, true
+}
+
+// PartialIndexes 实现 Dialect，MySQL 不支持部分索引
+func (MySQLDialect) PartialIndexes() bool {
+	return false
+}
+
+// PostgresDialect PostgreSQL 12+ 方言
+type PostgresDialect struct{}
+
+// Name 实现 Dialect
+func (PostgresDialect) Name() string {
+	return DialectPostgres
+}
+
+// ColumnType 实现 Dialect
+//
+// 优先级与 MySQLDialect 相同；JSON 值对象使用 JSONB，time.Time 使用 TIMESTAMPTZ，
+// 已知标量类型的 MySQL 列类型换成对应的 PostgreSQL 类型（如 uuid.UUID → UUID）。
+// PostgreSQL 没有无符号整数，uint32、uint64 使用更宽的类型。自增主键的 IDENTITY 由建表脚本生成。
+func (d PostgresDialect) ColumnType(field *FieldMetadata) string {
+	switch {
+	case field.ColumnType != "":
+		return field.ColumnType
+	case field.Annotations.IsValueObject:
+		return "JSONB"
+	case field.Annotations.Sensitive == SensitiveAES:
+		return "VARCHAR(1024)"
+	case field.ScalarType != nil && field.ScalarType.Name == "uuid.UUID":
+		return "UUID"
+	case field.ScalarType != nil && field.ScalarType.SQLType != "":
+		return postgresType(field.ScalarType.SQLType)
+	}
+
+	switch strings.TrimPrefix(field.GoType(), "*") {
+	case "int64", "uint32":
+		return "BIGINT"
+	case "int", "int32", "uint16":
+		return "INTEGER"
+	case "int16", "int8", "uint8":
+		return "SMALLINT"
+	case "uint64", "uint":
+		return "NUMERIC(20)"
+	case "float64":
+		return "DOUBLE PRECISION"
+	case "float32":
+		return "REAL"
+	case "bool":
+		return "BOOLEAN"
+	case "string":
+		return "VARCHAR(255)"
+	case "time.Time":
+		return "TIMESTAMPTZ"
+	case "[]byte":
+		return "BYTEA"
+	default:
+		return "TEXT"
+	}
+}
+
+// postgresType 将已知标量类型登记的 MySQL 列类型换成 PostgreSQL 类型，其他类型原样返回
+func postgresType(sqlType string) string {
+	switch strings.ToUpper(sqlType) {
+	case "DATETIME":
+		return "TIMESTAMPTZ"
+	case "DOUBLE":
+		return "DOUBLE PRECISION"
+	case "INT":
+		return "INTEGER"
+	case "TINYINT(1)":
+		return "BOOLEAN"
+	case "JSON":
+		return "JSONB"
+	case "BLOB":
+		return "BYTEA"
+	}
+	return sqlType
+}
+
+// DefaultValue 实现 Dialect
+// now() 映射为 CURRENT_TIMESTAMP，布尔值为 TRUE/FALSE，字符串加单引号并转义单引号（反斜杠不是转义符）
+func (PostgresDialect) DefaultValue(field *FieldMetadata) (string, bool) {
+	literal, ok := field.DefaultLiteral()
+	if !ok {
+		return "", false
+	}
+
+	switch {
+	case field.Annotations.Default == DefaultNow:
+		return "CURRENT_TIMESTAMP", true
+	case literal == "true":
+		return "TRUE", true
+	case literal == "false":
+		return "FALSE", true
+	case strings.HasPrefix(literal, `"`):
+		return "'" + strings.ReplaceAll(field.Annotations.Default, "'", "''") + "'", true
+	}
+	return literal, true
+}
+
+// PartialIndexes 实现 Dialect
+func (PostgresDialect) PartialIndexes() bool {
+	return true
+}
+
+// SQLiteDialect SQLite 3 方言，用于本地开发和集成测试
+type SQLiteDialect struct{}
+
+// Name 实现 Dialect
+func (SQLiteDialect) Name() string {
+	return DialectSQLite
+}
+
+// ColumnType 实现 Dialect
+//
+// 优先级与 MySQLDialect 相同，类型名按 SQLite 的类型亲和性选取：整数为 INTEGER（自增主键要求），
+// 浮点数为 REAL，字符串、JSON 值对象和 uuid.UUID 为 TEXT，time.Time 为 DATETIME（与 GORM 的 SQLite 驱动一致）。
+func (d SQLiteDialect) ColumnType(field *FieldMetadata) string {
+	switch {
+	case field.ColumnType != "":
+		return field.ColumnType
+	case field.Annotations.IsValueObject, field.Annotations.Sensitive == SensitiveAES:
+		return "TEXT"
+	case field.ScalarType != nil && field.ScalarType.Name == "uuid.UUID":
+		return "TEXT"
+	case field.ScalarType != nil && strings.EqualFold(field.ScalarType.SQLType, "JSON"):
+		// JSON 不含 TEXT 等关键字，会得到 NUMERIC 亲和性
+		return "TEXT"
+	case field.ScalarType != nil && field.ScalarType.SQLType != "":
+		return field.ScalarType.SQLType
+	}
+
+	switch strings.TrimPrefix(field.GoType(), "*") {
+	case "int", "int8", "int16", "int32", "int64",
+		"uint", "uint8", "uint16", "uint32", "uint64":
+		return "INTEGER"
+	case "float32", "float64":
+		return "REAL"
+	case "bool":
+		return "BOOLEAN"
+	case "time.Time":
+		return "DATETIME"
+	case "[]byte":
+		return "BLOB"
+	default:
+		return "TEXT"
+	}
+}
+
+// DefaultValue 实现 Dialect
+// now() 映射为 CURRENT_TIMESTAMP，布尔值映射为 1/0，字符串加单引号并转义单引号
+func (SQLiteDialect) DefaultValue(field *FieldMetadata) (string, bool) {
+	literal, ok := field.DefaultLiteral()
+	if !ok {
+		return "", false
+	}
+
+	switch {
+	case field.Annotations.Default == DefaultNow:
+		return "CURRENT_TIMESTAMP", true
+	case literal == "true":
+		return "1", true
+	case literal == "false":
+		return "0", true
+	case strings.HasPrefix(literal, `"`):
+		return "'" + strings.ReplaceAll(field.Annotations.Default, "'", "''") + "'", true
+	}
+	return literal, true
+}
+
+// PartialIndexes 实现 Dialect
+func (SQLiteDialect) PartialIndexes() bool {
+	return true
+}
+
+// DefaultDialect 默认方言：MySQL
+func DefaultDialect() Dialect {
+	return MySQLDialect{}
+}
@@ -0,0 +1,133 @@
+package metadata
+
+import (
+	"go/token"
+	"slices"
+	"strings"
+)
+
+// EventMetadata 领域事件元数据
+//
+// 事件有两种声明方式：
+//   - 在聚合根上列出事件名，如 // +soliton:event(OrderPlaced, OrderCancelled)，事件不带字段
+//   - 专用结构体，如在 type OrderPlaced struct 上标记 // +soliton:event(aggregate=Order)，结构体字段即事件携带的数据
+//
+// 两种方式声明了同一聚合根的同名事件时以结构体为准。事件发布代码和消息主题据此生成。
+type EventMetadata struct {
+	Name          string           `json:"name"`                  // 事件名，如 "OrderPlaced"
+	AggregateName string           `json:"aggregateName"`         // 发布事件的聚合根，如 "Order"
+	Context       string           `json:"context,omitempty"`     // 聚合根所属的限界上下文
+	TopicName     string           `json:"topicName,omitempty"`   // +soliton:event(aggregate=Order, topic=order.placed) 自定义主题，未声明时见 Topic()
+	Comment       string           `json:"comment,omitempty"`     // 结构体注释（去掉注解行）
+	Fields        []*FieldMetadata `json:"fields,omitempty"`      // 专用结构体的字段，按声明顺序排列
+	PackageName   string           `json:"packageName,omitempty"` // 专用结构体所在的包名
+	ImportPath    string           `json:"importPath,omitempty"`  // 专用结构体所在包的 import 路径，在聚合根上声明的事件为空
+	Pos           token.Position   `json:"-"`                     // 事件注解（+soliton:event）在源码中的位置
+}
+
+// IsDeclared 判断事件是否由专用结构体定义（代码中已有类型定义，无需生成）
+func (e *EventMetadata) IsDeclared() bool {
+	return e.ImportPath != ""
+}
+
+// Topic 返回事件的消息主题
+// 未声明 topic 时为 {上下文.}{聚合根}.{事件}，事件名去掉聚合根前缀，如 ordering 上下文中 Order 的 OrderPlaced → ordering.order.placed
+func (e *EventMetadata) Topic() string {
+	if e.TopicName != "" {
+		return e.TopicName
+	}
+
+	event := e.Name
+	if trimmed := strings.TrimPrefix(event, e.AggregateName); trimmed != "" && trimmed != event {
+		event = trimmed
+	}
+	topic := toSnakeCase(event)
+	if e.AggregateName != "" {
+		topic = toSnakeCase(e.AggregateName) + "." + topic
+	}
+	if e.Context != "" {
+		topic = e.Context + "." + topic
+	}
+	return topic
+}
+
+// SetDeclaredEvents 设置由专用结构体定义的事件，CollectEvents 时一并收集
+func (r *AggregateMetadataRegistry) SetDeclaredEvents(events []*EventMetadata) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.declaredEvents = slices.Clone(events)
+}
+
+// GetEvents 获取所有领域事件（按聚合根名、事件名排序）
+func (r *AggregateMetadataRegistry) GetEvents() []*EventMetadata {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.events)
+}
+
+// GetEventsByAggregate 获取聚合根发布的领域事件（按事件名排序）
+func (r *AggregateMetadataRegistry) GetEventsByAggregate(aggregateName string) []*EventMetadata {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var events []*EventMetadata
+	for _, event := range r.events {
+		if event.AggregateName == aggregateName {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// CollectEvents 从聚合根的 +soliton:event 注解和专用结构体中收集领域事件
+// 事件的限界上下文取自所属聚合根；聚合根上列出的事件与同一聚合根的同名结构体事件合并
+func (r *AggregateMetadataRegistry) CollectEvents() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// 重建事件列表，避免重复收集
+	r.events = make([]*EventMetadata, 0, len(r.declaredEvents))
+	seen := make(map[[2]string]bool)
+	for _, event := range r.declaredEvents {
+		if agg := r.aggregates[event.AggregateName]; agg != nil {
+			event.Context = agg.Context()
+		}
+		seen[[2]string{event.AggregateName, event.Name}] = true
+		r.events = append(r.events, event)
+	}
+
+	for _, agg := range r.all() {
+		for _, name := range agg.Annotations.Events {
+			key := [2]string{agg.Name, name}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			r.events = append(r.events, &EventMetadata{
+				Name:          name,
+				AggregateName: agg.Name,
+				Context:       agg.Context(),
+				Pos:           eventPos(agg, name),
+			})
+		}
+	}
+
+	slices.SortStableFunc(r.events, func(a, b *EventMetadata) int {
+		if c := strings.Compare(a.AggregateName, b.AggregateName); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+}
+
+// eventPos 返回聚合根上声明事件 name 的 +soliton:event 注解位置，找不到时为聚合根的位置
+func eventPos(agg *AggregateMetadata, name string) token.Position {
+	for _, node := range agg.Annotations.Nodes.All("event") {
+		for _, arg := range node.Args {
+			if arg.Key == "" && arg.Value == name && node.Pos.IsValid() {
+				return node.Pos
+			}
+		}
+	}
+	return agg.Pos
+}
@@ -0,0 +1,71 @@
+package metadata
+
+// FilterFields 返回可作为 {Aggregate}Filter 查询条件的字段，按声明顺序排列
+//
+// 包括声明了 +soliton:filter 的字段，以及唯一、索引（含组合索引中的字段）、外部引用和枚举字段。
+// 单列主键（由 FindByID、FindByIDs 查询）、软删除字段（查询时自动排除已删除的记录）和不能按值比较的字段（见 Filterable）除外。
+func (a *AggregateMetadata) FilterFields() []*FieldMetadata {
+	var fields []*FieldMetadata
+	for _, field := range a.MappedFields() {
+		if !field.Filterable() || field == a.IDField || a.isSoftDeleteField(field) {
+			continue
+		}
+		annotations := field.Annotations
+		if annotations.IsFilter || annotations.IsRef || field.IsEnum() || len(a.IndexesOn(field.Name)) > 0 {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// SortFields 返回 {Aggregate}Sort 可排序的字段：主键字段在前（按主键顺序），
+// 其后按声明顺序为唯一、索引和声明了 +soliton:filter(sort) 的字段；只包括值可比较大小的字段（见 Ordered）
+func (a *AggregateMetadata) SortFields() []*FieldMetadata {
+	var fields []*FieldMetadata
+	for _, field := range a.PrimaryKey {
+		if field.Ordered() {
+			fields = append(fields, field)
+		}
+	}
+	for _, field := range a.MappedFields() {
+		if a.InPrimaryKey(field) || !field.Filterable() || !field.Ordered() || a.isSoftDeleteField(field) {
+			continue
+		}
+		if field.Annotations.IsSortable || len(a.IndexesOn(field.Name)) > 0 {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// isSoftDeleteField 判断字段是否为基础实体的软删除字段
+func (a *AggregateMetadata) isSoftDeleteField(field *FieldMetadata) bool {
+	return a.BaseEntity != nil && a.BaseEntity.DeletedAtField == field
+}
+
+// Filterable 判断字段能否作为查询条件：映射为单列、可按值比较的字段，
+// 不是关联实体、值对象、切片、map、定长数组或敏感字段（保存的是密文或脱敏值）
+func (f *FieldMetadata) Filterable() bool {
+	annotations := f.Annotations
+	return !annotations.IsIgnored && !annotations.IsEntity && !annotations.IsValueObject &&
+		!f.IsSlice && !f.IsMap && !f.IsArray && annotations.Sensitive == ""
+}
+
+// IsEnum 判断字段是否为枚举（+soliton:enum 或 const 块定义的枚举类型）
+func (f *FieldMetadata) IsEnum() bool {
+	return len(f.Annotations.EnumValues) > 0 || f.Annotations.EnumType != ""
+}
+
+// Ordered 判断字段的值能否比较大小：整数、浮点数、字符串、time.Time、time.Duration 和枚举，
+// 基于这些类型的命名类型需要启用类型解析（见 BasicType）
+func (f *FieldMetadata) Ordered() bool {
+	if f.IsEnum() {
+		return true
+	}
+	switch basicType := f.BasicType(); basicType {
+	case "string", "float32", "float64", "time.Time", "time.Duration":
+		return true
+	default:
+		return isIntegerType(basicType)
+	}
+}
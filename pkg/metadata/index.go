@@ -0,0 +1,133 @@
+package metadata
+
+import (
+	"go/token"
+	"strings"
+)
+
+// IndexMetadata 索引元数据
+//
+// 聚合根上声明的全部索引由 CollectIndexes 汇总到 AggregateMetadata.Indexes，DDL、DO 标签和元数据差异据此生成：
+//   - 字段上的 +soliton:unique、+soliton:unique(name=uk_user_email)
+//   - 字段上的 +soliton:index、+soliton:index(name=idx_user_status, where=deleted_at IS NULL)
+//   - 聚合根上的组合唯一索引 +soliton:uniqueIndex(name=uk_user_email, fields=TenantID,Email)
+//   - 聚合根上的组合索引 +soliton:index(fields=Status,CreatedAt, where=deleted_at IS NULL)
+type IndexMetadata struct {
+	Name    string         `json:"name"`              // 索引名，未指定时唯一索引为 uk_{表名}_{列名...}，普通索引为 idx_{表名}_{列名...}
+	Fields  []string       `json:"fields"`            // 按顺序组成索引的字段名（Go 字段名），展开的值对象中的字段为 Address.City 形式
+	Columns []string       `json:"columns,omitempty"` // 对应的列名，不含不存在的字段
+	Unique  bool           `json:"unique"`            // 是否唯一索引
+	Where   string         `json:"where,omitempty"`   // 部分索引的条件（SQL 表达式），如 deleted_at IS NULL
+	Pos     token.Position `json:"-"`                 // 索引注解在源码中的位置
+}
+
+// CollectIndexes 汇总聚合根上声明的索引，写入 Indexes
+//
+// 依次为字段上的 +soliton:unique、+soliton:index（按字段声明顺序，展开的值对象以其各字段代替），
+// 以及 declared 中聚合根级别的组合索引（按声明顺序）。字段名解析为列名，未命名的索引按表名和列名命名，
+// 因此应在字段和表名确定后调用。由关系、软删除和中间实体产生的索引不是注解声明的，由 NewSchema 补充。
+func (a *AggregateMetadata) CollectIndexes(declared []*IndexMetadata) {
+	var indexes []*IndexMetadata
+	for _, field := range a.MappedFields() {
+		switch {
+		case field.Annotations.IsEntity:
+			continue
+		case field.Annotations.IsValueObject && field.Annotations.Strategy == ValueObjectFlatten:
+			for _, sub := range field.Flattened {
+				indexes = append(indexes, a.fieldIndexes(field.Name+"."+sub.Name, sub)...)
+			}
+		default:
+			indexes = append(indexes, a.fieldIndexes(field.Name, field)...)
+		}
+	}
+
+	for _, index := range declared {
+		index.Columns = nil
+		parts := []string{"idx", a.Table()}
+		if index.Unique {
+			parts[0] = "uk"
+		}
+		for _, path := range index.Fields {
+			column := path
+			if field := a.FieldByPath(path); field != nil {
+				column = field.Column()
+				index.Columns = append(index.Columns, column)
+			}
+			parts = append(parts, column)
+		}
+		if index.Name == "" {
+			index.Name = strings.Join(parts, "_")
+		}
+		indexes = append(indexes, index)
+	}
+
+	a.Indexes = indexes
+}
+
+// fieldIndexes 返回字段上 +soliton:unique、+soliton:index 声明的单列索引，path 为字段路径
+func (a *AggregateMetadata) fieldIndexes(path string, field *FieldMetadata) []*IndexMetadata {
+	var indexes []*IndexMetadata
+	for _, name := range []string{"unique", "index"} {
+		unique := name == "unique"
+		if unique && !field.Annotations.IsUnique || !unique && !field.Annotations.IsIndex {
+			continue
+		}
+
+		index := &IndexMetadata{
+			Fields:  []string{path},
+			Columns: []string{field.Column()},
+			Unique:  unique,
+			Pos:     field.AnnotationPos(name),
+		}
+		if node := field.Annotations.Nodes.Get(name); node != nil {
+			index.Name = node.Option("name")
+			index.Where = node.Arg("where")
+		}
+		if unique && field.Annotations.UniqueName != "" {
+			index.Name = field.Annotations.UniqueName
+		}
+		if index.Name == "" {
+			prefix := "idx_"
+			if unique {
+				prefix = "uk_"
+			}
+			index.Name = prefix + a.Table() + "_" + field.Column()
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes
+}
+
+// FieldByPath 按字段路径查找字段，如 Amount、Address.City（展开的值对象中的字段），不存在时返回 nil
+func (a *AggregateMetadata) FieldByPath(path string) *FieldMetadata {
+	name, sub, nested := strings.Cut(path, ".")
+	for _, field := range a.Fields {
+		if field.Name != name {
+			continue
+		}
+		if !nested {
+			return field
+		}
+		for _, flattened := range field.Flattened {
+			if flattened.Name == sub {
+				return flattened
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// IndexesOn 返回包含字段路径 path 的索引
+func (a *AggregateMetadata) IndexesOn(path string) []*IndexMetadata {
+	var indexes []*IndexMetadata
+	for _, index := range a.Indexes {
+		for _, field := range index.Fields {
+			if field == path {
+				indexes = append(indexes, index)
+				break
+			}
+		}
+	}
+	return indexes
+}
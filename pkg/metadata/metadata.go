@@ -2,70 +2,461 @@ package metadata
 
 import (
 	"go/ast"
+	"go/token"
+	"math"
+	"slices"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
 )
 
 // AggregateMetadata 聚合根元数据
 type AggregateMetadata struct {
-	Name        string                // 聚合根名称，如 "Order"
-	PackageName string                // 包名
-	ImportPath  string                // 完整的 import 路径，如 "mymodule/domain/model"
-	ModuleName  string                // Go 模块名，如 "mymodule"
-	ModuleRoot  string                // 模块根目录绝对路径
-	FilePath    string                // 文件路径
-	Struct      *ast.StructType       // AST 结构体类型
-	Fields      []*FieldMetadata      // 字段元数据列表
-	Annotations *AggregateAnnotations // 聚合根级别注解
-	IDField     *FieldMetadata        // ID 字段（自动识别）
-	BaseEntity  *BaseEntityMetadata   // 基础实体元数据
+	Name          string                `json:"name"`                    // 聚合根名称，如 "Order"
+	PackageName   string                `json:"packageName"`             // 包名
+	ImportPath    string                `json:"importPath"`              // 完整的 import 路径，如 "mymodule/domain/model"
+	ModuleName    string                `json:"moduleName"`              // Go 模块名，如 "mymodule"
+	ModuleRoot    string                `json:"moduleRoot"`              // 模块根目录绝对路径
+	FilePath      string                `json:"filePath"`                // 文件路径
+	Pos           token.Position        `json:"-"`                       // 类型声明位置（类型名），用于在校验错误中指出源码位置
+	Struct        *ast.StructType       `json:"-"`                       // AST 结构体类型
+	Fields        []*FieldMetadata      `json:"fields"`                  // 字段元数据列表
+	Annotations   *AggregateAnnotations `json:"annotations"`             // 聚合根级别注解
+	IDField       *FieldMetadata        `json:"-"`                       // ID 字段（自动识别），复合主键时为 nil
+	PrimaryKey    []*FieldMetadata      `json:"-"`                       // 组成主键的字段，单列主键时为 [IDField]，复合主键（+soliton:pk）按声明顺序排列
+	BaseEntity    *BaseEntityMetadata   `json:"baseEntity,omitempty"`    // 基础实体元数据
+	TableName     string                `json:"tableName,omitempty"`     // 自定义表名（+soliton:table(name=...)），为空时按命名策略命名，见 Table()
+	Naming        NamingStrategy        `json:"-"`                       // 表命名策略，为空时使用 DefaultNamingStrategy
+	Indexes       []*IndexMetadata      `json:"indexes,omitempty"`       // 字段和聚合根上声明的全部索引，见 CollectIndexes
+	IDStrategy    string                `json:"idStrategy,omitempty"`    // 生效的主键生成策略，见 IDStrategyAuto 等常量
+	Behaviors     []*BehaviorMetadata   `json:"behaviors,omitempty"`     // 领域行为（聚合根上导出的接收者方法），按声明顺序排列
+	API           *APIMetadata          `json:"api,omitempty"`           // +soliton:api 对外暴露的 API，未声明时为 nil
+	Queries       []*QueryMetadata      `json:"queries,omitempty"`       // +soliton:query 声明的查询，按声明顺序排列
+	RecordsEvents bool                  `json:"recordsEvents,omitempty"` // 是否嵌入了 framework.EventRecorder，嵌入时生成事件记录方法并在领域服务中发布事件
+}
+
+// 主键生成策略（+soliton:id(strategy=...)）
+const (
+	IDStrategyAuto      = "auto"      // 数据库自增，int64 主键的默认策略
+	IDStrategyUUID      = "uuid"      // 应用生成 UUID，string 主键的默认策略
+	IDStrategySnowflake = "snowflake" // 应用生成雪花 ID
+	IDStrategyManual    = "manual"    // 由调用方设置
+)
+
+// 敏感字段策略（+soliton:sensitive(strategy=...)），与 framework.SensitiveStrategy 对应
+const (
+	SensitiveAES  = "aes"  // 写入前加密，读取后解密，未声明策略时的默认值
+	SensitiveMask = "mask" // 写入前脱敏，只保存脱敏后的值
+)
+
+// 值对象存储策略（+soliton:valueObject(strategy=...)）
+const (
+	ValueObjectJSON    = "json"    // 序列化为 JSON 存入一列，map 和定长数组的默认策略
+	ValueObjectFlatten = "flatten" // 将结构体字段展开为带前缀的多列，如 Address.City → address_city
+)
+
+// 关联实体的级联行为（+soliton:cascade(...)），与 framework.CascadeAction 对应
+const (
+	CascadeDelete   = "delete"   // 删除聚合根时一并删除关联实体（ON DELETE CASCADE）
+	CascadeNullify  = "nullify"  // 删除聚合根时将关联实体的外键置空（ON DELETE SET NULL）
+	CascadeRestrict = "restrict" // 存在关联实体时禁止删除聚合根（ON DELETE RESTRICT）
+)
+
+// DefaultNow 表示当前时间的默认值（+soliton:default(now())），仅适用于 time.Time 字段
+const DefaultNow = "now()"
+
+// MappedFields 返回参与列映射、校验和关系分析的字段
+//
+// 排除 +soliton:ignore 标记的字段（瞬态或计算字段），这些字段仍保留在 Fields 中，
+// 随元数据导出用于文档。关联实体字段（+soliton:entity）仍包含在内，由各生成器自行处理。
+func (a *AggregateMetadata) MappedFields() []*FieldMetadata {
+	fields := make([]*FieldMetadata, 0, len(a.Fields))
+	for _, field := range a.Fields {
+		if !field.Annotations.IsIgnored {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// AnnotationPos 返回聚合根上第一个注解 name 的源码位置，未声明该注解或位置未知时返回类型声明位置
+func (a *AggregateMetadata) AnnotationPos(name string) token.Position {
+	if a.Annotations != nil {
+		if node := a.Annotations.Nodes.Get(name); node != nil && node.Pos.IsValid() {
+			return node.Pos
+		}
+	}
+	return a.Pos
+}
+
+// Context 返回聚合根所属的限界上下文（+soliton:context），未声明时为空
+func (a *AggregateMetadata) Context() string {
+	if a.Annotations == nil {
+		return ""
+	}
+	return a.Annotations.Context
+}
+
+// IsCompositeKey 判断聚合根是否使用复合主键（+soliton:pk 标记了多个字段）
+func (a *AggregateMetadata) IsCompositeKey() bool {
+	return len(a.PrimaryKey) > 1
+}
+
+// InPrimaryKey 判断字段是否为主键（或复合主键的组成字段）
+func (a *AggregateMetadata) InPrimaryKey(field *FieldMetadata) bool {
+	for _, key := range a.PrimaryKey {
+		if key.Name == field.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// ParentField 返回指向聚合根自身的外部引用字段（如 Category 的 ParentID +soliton:ref(Category)），
+// 即邻接表中的父节点字段；没有或存在多个这样的字段时返回 nil
+func (a *AggregateMetadata) ParentField() *FieldMetadata {
+	var parent *FieldMetadata
+	for _, field := range a.MappedFields() {
+		if !field.Annotations.IsRef || field.RefAggregate() != a.Name {
+			continue
+		}
+		if parent != nil {
+			return nil
+		}
+		parent = field
+	}
+	return parent
+}
+
+// ChildrenField 返回元素为聚合根自身指针的关联实体字段（如 Children []*Category +soliton:entity），没有时返回 nil
+func (a *AggregateMetadata) ChildrenField() *FieldMetadata {
+	for _, field := range a.MappedFields() {
+		if field.Annotations.IsEntity && field.IsSlice && field.IsPointer && field.Type == a.Name {
+			return field
+		}
+	}
+	return nil
+}
+
+// AssociationEnds 返回中间实体（+soliton:manyToMany）关联两端的外部引用字段，
+// 即按声明顺序的前两个 +soliton:ref 字段（如 UserRole 的 UserID、RoleID），其余字段都作为关联的附加属性；
+// 不是中间实体或外部引用字段不足两个时返回 nil
+func (a *AggregateMetadata) AssociationEnds() (left, right *FieldMetadata) {
+	if !a.Annotations.IsManyToMany {
+		return nil, nil
+	}
+	var ends []*FieldMetadata
+	for _, field := range a.MappedFields() {
+		if field.Annotations.IsRef && !field.IsPolymorphic() {
+			ends = append(ends, field)
+		}
+	}
+	if len(ends) < 2 {
+		return nil, nil
+	}
+	return ends[0], ends[1]
+}
+
+// IDKeyType 返回主键在框架泛型（EntityOf[K]、RepositoryOf[T, K]）中使用的类型
+//
+// 复合主键返回生成的主键结构体名，如 "OrderLineKey"（定义在聚合根所在包，其他包中使用时需要带包名）；
+// string 类型的 ID 字段（如 UUID）返回 "string"；
+// 其他情况（整数类型或未识别到 ID 字段）返回 "int64"。
+func (a *AggregateMetadata) IDKeyType() string {
+	if a.IsCompositeKey() {
+		return a.Name + "Key"
+	}
+	if a.IDField != nil && a.IDField.Type == "string" && !a.IDField.IsPointer {
+		return "string"
+	}
+	return "int64"
 }
 
 // FieldMetadata 字段元数据
 type FieldMetadata struct {
-	Name        string            // 字段名称，如 "OrderNo"
-	Type        string            // 字段类型，如 "string", "int64"
-	DBTag       string            // db 标签值，如 "order_no"
-	IsPointer   bool              // 是否指针类型
-	IsSlice     bool              // 是否切片类型
-	Annotations *FieldAnnotations // 字段级别注解
-	RawType     ast.Expr          // 原始类型表达式
+	Name        string            `json:"name"`                 // 字段名称，如 "OrderNo"
+	Type        string            `json:"type"`                 // 字段类型，如 "string", "int64"
+	DBTag       string            `json:"dbTag"`                // db 标签值，如 "order_no"
+	JSONTag     string            `json:"jsonTag,omitempty"`    // json 标签中的名称，如 "city"，"-" 表示不参与 JSON 编码；未声明时为空
+	IsPointer   bool              `json:"isPointer"`            // 是否指针类型
+	IsSlice     bool              `json:"isSlice"`              // 是否切片类型
+	IsMap       bool              `json:"isMap"`                // 是否 map 类型，此时 Type 为完整类型表达式，如 "map[string]string"
+	IsArray     bool              `json:"isArray"`              // 是否定长数组类型，此时 Type 为元素类型，长度见 ArrayLen
+	Annotations *FieldAnnotations `json:"annotations"`          // 字段级别注解
+	RawType     ast.Expr          `json:"-"`                    // 原始类型表达式
+	Pos         token.Position    `json:"-"`                    // 字段声明位置，用于在校验错误中指出源码位置
+	EmbeddedIn  string            `json:"embeddedIn,omitempty"` // 提升字段所在的嵌入路径，如 "BaseEntity"；直接声明的字段为空
+	ColumnName  string            `json:"columnName,omitempty"` // 自定义列名（+soliton:column(name=...)），为空时见 Column()
+	ColumnType  string            `json:"columnType,omitempty"` // 自定义列类型（+soliton:column(type=...)），如 "varchar(64)"
+	IDStrategy  string            `json:"idStrategy,omitempty"` // +soliton:id(strategy=...) 声明的主键策略，未声明时为空
+
+	MapKeyType   string `json:"mapKeyType,omitempty"`   // map 键类型，如 "string"
+	MapValueType string `json:"mapValueType,omitempty"` // map 值类型，如 "*Label"
+	ArrayLen     string `json:"arrayLen,omitempty"`     // 定长数组的长度表达式，如 "32"
+
+	Flattened   []*FieldMetadata `json:"flattened,omitempty"`   // 展开策略值对象（+soliton:valueObject(strategy=flatten)）的字段，列名已带前缀，如 address_city
+	ValueFields []*FieldMetadata `json:"valueFields,omitempty"` // JSON 策略的结构体值对象（含 map、切片、数组的元素）的字段，用于生成接口文档；找不到结构体定义时为空
+
+	ScalarType *ScalarType `json:"scalarType,omitempty"` // 已知的外部标量类型（如 uuid.UUID），由 RelationAnalyzer 标记，按普通列处理
+
+	// 以下字段仅在启用类型解析（go/packages）时填充
+	QualifiedType  string `json:"qualifiedType,omitempty"`  // 完整限定类型（去除指针和切片），如 "mymodule/domain/model.OrderStatus"
+	UnderlyingType string `json:"underlyingType,omitempty"` // 命名类型的底层基础类型，如 type OrderStatus string 为 "string"
+}
+
+// GoType 返回字段声明的完整 Go 类型（不带包限定），如 "*time.Time"、"[]*OrderItem"、"[32]byte"、"map[string]string"
+func (f *FieldMetadata) GoType() string {
+	elem := f.Type
+	if f.IsPointer {
+		elem = "*" + elem
+	}
+
+	switch {
+	case f.IsSlice:
+		return "[]" + elem
+	case f.IsArray:
+		return "[" + f.ArrayLen + "]" + elem
+	}
+	return elem
+}
+
+// FlattenedName 返回展开的值对象字段在数据对象中的字段名，如 Address.City → AddressCity
+func (f *FieldMetadata) FlattenedName(sub *FieldMetadata) string {
+	return f.Name + sub.Name
+}
+
+// RefAggregate 返回外部引用字段（+soliton:ref）指向的聚合根名
+// 优先使用注解声明的目标，如 +soliton:ref(User)；未声明时按字段名推断，如 UserID → User、OwnerId → Owner
+func (f *FieldMetadata) RefAggregate() string {
+	if f.Annotations.RefTarget != "" {
+		return f.Annotations.RefTarget
+	}
+	if len(f.Name) > 2 && (strings.HasSuffix(f.Name, "ID") || strings.HasSuffix(f.Name, "Id")) {
+		return f.Name[:len(f.Name)-2]
+	}
+	return ""
+}
+
+// IsPolymorphic 判断字段是否为多态关联的 ID 字段（+soliton:polymorphic）
+func (f *FieldMetadata) IsPolymorphic() bool {
+	return len(f.Annotations.Polymorphic) > 0
+}
+
+// PolymorphicName 返回多态关联的名称，即去掉 ID 后缀的字段名，如 AttachableID 为 "Attachable"
+func (f *FieldMetadata) PolymorphicName() string {
+	if len(f.Name) > 2 && (strings.HasSuffix(f.Name, "ID") || strings.HasSuffix(f.Name, "Id")) {
+		return f.Name[:len(f.Name)-2]
+	}
+	return f.Name
+}
+
+// PolymorphicTypeField 返回保存多态关联目标类型（聚合根名）的字段名
+// 通过 typeField 声明，未声明时为 {PolymorphicName}Type，如 AttachableID 对应 AttachableType
+func (f *FieldMetadata) PolymorphicTypeField() string {
+	if f.Annotations.PolymorphicBy != "" {
+		return f.Annotations.PolymorphicBy
+	}
+	return f.PolymorphicName() + "Type"
+}
+
+// AnnotationPos 返回字段上第一个注解 name 的源码位置，未声明该注解或位置未知时返回字段的声明位置
+func (f *FieldMetadata) AnnotationPos(name string) token.Position {
+	if f.Annotations != nil {
+		if node := f.Annotations.Nodes.Get(name); node != nil && node.Pos.IsValid() {
+			return node.Pos
+		}
+	}
+	return f.Pos
+}
+
+// BasicType 返回用于判断基础类型的类型名
+// 启用类型解析且字段为基于基础类型的命名类型时返回底层类型，否则返回 Type
+func (f *FieldMetadata) BasicType() string {
+	if f.UnderlyingType != "" {
+		return f.UnderlyingType
+	}
+	return f.Type
+}
+
+// DefaultLiteral 返回字段默认值（+soliton:default）对应的 Go 表达式，如 "PENDING" → `"PENDING"`，now() → `time.Now()`
+// 未声明默认值或默认值与字段类型不符时返回 false（由分析器报告）；
+// 未解析底层类型的命名类型（如 OrderStatus）按默认值的字面形式生成无类型常量
+func (f *FieldMetadata) DefaultLiteral() (string, bool) {
+	value := f.Annotations.Default
+	basicType := f.BasicType()
+
+	switch {
+	case value == "":
+		return "", false
+	case value == DefaultNow:
+		return "time.Now()", basicType == "time.Time"
+	case basicType == "string":
+		return strconv.Quote(value), true
+	case basicType == "bool":
+		return value, value == "true" || value == "false"
+	case isIntegerType(basicType) || basicType == "time.Duration":
+		_, err := strconv.ParseInt(value, 10, 64)
+		return value, err == nil
+	case basicType == "float32" || basicType == "float64":
+		return value, isFiniteNumber(value)
+	case f.UnderlyingType == "" && !strings.Contains(basicType, "."):
+		if isFiniteNumber(value) || value == "true" || value == "false" {
+			return value, true
+		}
+		return strconv.Quote(value), true
+	}
+	return "", false
+}
+
+// isIntegerType 判断是否为整数类型
+func isIntegerType(goType string) bool {
+	switch goType {
+	case "int", "int8", "int16", "int32", "int64",
+		"uint", "uint8", "uint16", "uint32", "uint64":
+		return true
+	}
+	return false
+}
+
+// isFiniteNumber 判断字符串是否为有限的数字字面量
+func isFiniteNumber(value string) bool {
+	number, err := strconv.ParseFloat(value, 64)
+	return err == nil && !math.IsInf(number, 0) && !math.IsNaN(number)
+}
+
+// JoinTableMetadata 多对多关联表的自定义配置
+//
+// 由聚合根级别注解声明，如 +soliton:manyToMany(table=user_roles, left=uid, right=rid)，
+// 用于对接已有的关联表。left 为引用声明方聚合根的列，right 为引用对端聚合根的列，未声明的项使用默认命名。
+type JoinTableMetadata struct {
+	With  string `json:"with,omitempty"`  // 对端聚合根，聚合根只声明了一个 +soliton:ref 时可省略
+	Table string `json:"table,omitempty"` // 关联表名，未声明时为 {左}_{右}
+	Left  string `json:"left,omitempty"`  // 引用声明方聚合根的列，未声明时为 {聚合根}_id
+	Right string `json:"right,omitempty"` // 引用对端聚合根的列，未声明时为 {聚合根}_id
 }
 
 // AggregateAnnotations 聚合根级别注解
 type AggregateAnnotations struct {
-	IsAggregate  bool     // +soliton:aggregate
-	BaseEntity   string   // +soliton:baseEntity(BaseEntity)
-	IsManyToMany bool     // +soliton:manyToMany
-	Refs         []string // +soliton:ref(OtherAggregate) 可能有多个
+	IsAggregate  bool     `json:"isAggregate"`          // +soliton:aggregate
+	BaseEntity   string   `json:"baseEntity,omitempty"` // +soliton:baseEntity(BaseEntity)
+	IsManyToMany bool     `json:"isManyToMany"`         // +soliton:manyToMany（不带参数）作为中间实体的聚合根
+	IsEntity     bool     `json:"isEntity,omitempty"`   // +soliton:entity 聚合内的关联实体，只应通过其他聚合根的 +soliton:entity 字段访问
+	Refs         []string `json:"refs,omitempty"`       // +soliton:ref(OtherAggregate) 可能有多个
+	Context      string   `json:"context,omitempty"`    // +soliton:context(ordering) 所属限界上下文，为空表示不分组
+	Events       []string `json:"events,omitempty"`     // +soliton:event(OrderPlaced, OrderCancelled) 聚合根发布的领域事件，见 EventMetadata
+
+	JoinTables []*JoinTableMetadata `json:"joinTables,omitempty"` // +soliton:manyToMany(table=user_roles, left=uid, right=rid) 自定义的多对多关联表
+
+	Nodes AnnotationList `json:"nodes,omitempty"` // 聚合根上声明的全部注解，供生成器读取未映射为字段的参数
 }
 
 // FieldAnnotations 字段级别注解
 type FieldAnnotations struct {
-	IsUnique      bool     // +soliton:unique
-	IsRef         bool     // +soliton:ref
-	IsRequired    bool     // +soliton:required
-	IsEntity      bool     // +soliton:entity
-	IsValueObject bool     // +soliton:valueObject
-	IsIndex       bool     // +soliton:index
-	EnumValues    []string // +soliton:enum(value1,value2,...)
-	Strategy      string   // +soliton:valueObject(strategy=json)
+	IsUnique      bool     `json:"isUnique"`                // +soliton:unique
+	UniqueName    string   `json:"uniqueName,omitempty"`    // +soliton:unique(name=uk_user_email) 唯一约束名，未声明时为 uk_{表名}_{列名}
+	IsRef         bool     `json:"isRef"`                   // +soliton:ref
+	IsRequired    bool     `json:"isRequired"`              // +soliton:required
+	IsEntity      bool     `json:"isEntity"`                // +soliton:entity
+	IsValueObject bool     `json:"isValueObject"`           // +soliton:valueObject
+	IsIndex       bool     `json:"isIndex"`                 // +soliton:index
+	IsID          bool     `json:"isId"`                    // +soliton:id 显式标记主键字段
+	IsPK          bool     `json:"isPk"`                    // +soliton:pk 复合主键的组成字段，只标记一个字段时等同于 +soliton:id
+	IsIgnored     bool     `json:"isIgnored"`               // +soliton:ignore 瞬态或计算字段，不参与列映射、校验和关系分析
+	IsImmutable   bool     `json:"isImmutable"`             // +soliton:immutable 只在创建时写入，更新时不覆盖
+	Sensitive     string   `json:"sensitive,omitempty"`     // +soliton:sensitive(strategy=aes) 敏感字段策略，见 SensitiveAES，未声明时为空
+	EnumValues    []string `json:"enumValues,omitempty"`    // +soliton:enum(value1,value2,...)，整数枚举为编码，如 +soliton:enum(1=ACTIVE,2=BANNED) 的 ["1", "2"]
+	EnumNames     []string `json:"enumNames,omitempty"`     // 整数枚举与 EnumValues 一一对应的名称，如 ["ACTIVE", "BANNED"]；const 块定义的整数枚举为常量名，字符串枚举为空
+	EnumLabels    []string `json:"enumLabels,omitempty"`    // +soliton:enum(ACTIVE:正常,BANNED:封禁) 与 EnumValues 一一对应的说明，未声明说明的值为空字符串
+	EnumType      string   `json:"enumType,omitempty"`      // 枚举值来自 const 块时为对应的类型名，如 "OrderStatus"
+	Strategy      string   `json:"strategy,omitempty"`      // +soliton:valueObject(strategy=json)，见 ValueObjectJSON、ValueObjectFlatten
+	Default       string   `json:"default,omitempty"`       // +soliton:default(PENDING)、+soliton:default(now())，见 DefaultNow
+	RefTarget     string   `json:"refTarget,omitempty"`     // +soliton:ref(User)、+soliton:ref(User.ID) 引用的聚合根，见 FieldMetadata.RefAggregate
+	RefField      string   `json:"refField,omitempty"`      // +soliton:ref(User.ID) 引用的字段，未声明时为目标聚合根的主键
+	Polymorphic   []string `json:"polymorphic,omitempty"`   // +soliton:polymorphic(types=Invoice,Receipt) 多态关联可指向的聚合根
+	PolymorphicBy string   `json:"polymorphicBy,omitempty"` // +soliton:polymorphic(typeField=Kind) 保存目标类型的字段，见 FieldMetadata.PolymorphicTypeField
+	Cascade       string   `json:"cascade,omitempty"`       // +soliton:cascade(delete) 关联实体的级联行为，见 CascadeDelete，未声明时为空
+	ForeignKey    string   `json:"foreignKey,omitempty"`    // +soliton:fk(column=order_no) 关联实体表中引用聚合根的外键列，未声明时自动推断
+	IsOwner       bool     `json:"isOwner,omitempty"`       // +soliton:owner 一对一关联实体的外键列位于聚合根表中，如 Order.Shipment 对应 orders.shipment_id
+	IsExternal    bool     `json:"isExternal,omitempty"`    // +soliton:external 外部引用的目标是其他服务中的聚合根，不要求在本模型中定义
+	IsFilter      bool     `json:"isFilter,omitempty"`      // +soliton:filter 可作为 {Aggregate}Filter 的查询条件，见 AggregateMetadata.FilterFields
+	IsSortable    bool     `json:"isSortable,omitempty"`    // +soliton:filter(sort) 同时可作为 {Aggregate}Sort 的排序字段，见 AggregateMetadata.SortFields
+
+	Validation *ValidationRules `json:"validation,omitempty"` // 校验规则注解，未声明任何规则时为 nil
+	Nodes      AnnotationList   `json:"nodes,omitempty"`      // 字段上声明的全部注解（标签和注释），供生成器读取未映射为字段的参数
+}
+
+// IsIntEnum 判断枚举值是否为整数编码（+soliton:enum(1=ACTIVE,...) 或 const 块定义的整数枚举）
+func (a *FieldAnnotations) IsIntEnum() bool {
+	return len(a.EnumNames) > 0
+}
+
+// EnumLabel 返回第 i 个枚举值的说明，未声明时为空
+func (a *FieldAnnotations) EnumLabel(i int) string {
+	if i < len(a.EnumLabels) {
+		return a.EnumLabels[i]
+	}
+	return ""
+}
+
+// SplitEnumItems 拆分 +soliton:enum 的枚举项，如 ["1=ACTIVE:正常", "2=BANNED"]
+// 冒号后为说明，等号前为编码；未声明编码的项以名称作为值。
+// 返回枚举值（整数枚举为编码）、与之一一对应的名称（只有声明了编码时返回）和说明（只有声明了说明时返回）
+func SplitEnumItems(items []string) (values, names, labels []string) {
+	coded, labeled := false, false
+	for _, item := range items {
+		entry, label, hasLabel := strings.Cut(item, ":")
+		code, name, hasCode := strings.Cut(entry, "=")
+		if !hasCode {
+			code, name = entry, entry
+		}
+		values = append(values, strings.TrimSpace(code))
+		names = append(names, strings.TrimSpace(name))
+		labels = append(labels, strings.TrimSpace(label))
+		coded = coded || hasCode
+		labeled = labeled || hasLabel
+	}
+
+	if !coded {
+		names = nil
+	}
+	if !labeled {
+		labels = nil
+	}
+	return values, names, labels
+}
+
+// ValidationRules 字段校验规则
+//
+// 由字段级别注解声明，生成的领域服务在 Add/Update 时据此校验：
+//   - +soliton:validate(min=1,max=100)：数值范围（闭区间）
+//   - +soliton:length(2,64)、+soliton:length(max=64)：字符串长度（按字符计）
+//   - +soliton:pattern(^[A-Z]{2}\d{6}$)：正则表达式
+//   - +soliton:email：邮箱格式
+type ValidationRules struct {
+	Min       *float64 `json:"min,omitempty"`       // 最小值
+	Max       *float64 `json:"max,omitempty"`       // 最大值
+	MinLength *int     `json:"minLength,omitempty"` // 最小长度
+	MaxLength *int     `json:"maxLength,omitempty"` // 最大长度
+	Pattern   string   `json:"pattern,omitempty"`   // 正则表达式
+	IsEmail   bool     `json:"isEmail,omitempty"`   // 邮箱格式
 }
 
 // BaseEntityMetadata 基础实体元数据（通过字段识别）
 type BaseEntityMetadata struct {
-	HasDeletedAt bool // 是否有 DeletedAt 字段（软删除）
-	HasVersion   bool // 是否有 Version 字段（乐观锁）
-	HasCreatedAt bool // 是否有 CreatedAt 字段（创建时间）
-	HasUpdatedAt bool // 是否有 UpdatedAt 字段（更新时间）
-	HasCreatedBy bool // 是否有 CreatedBy 字段（创建人）
-	HasUpdatedBy bool // 是否有 UpdatedBy 字段（更新人）
+	HasDeletedAt bool `json:"hasDeletedAt"` // 是否有 DeletedAt 字段（软删除）
+	HasVersion   bool `json:"hasVersion"`   // 是否有 Version 字段（乐观锁）
+	HasCreatedAt bool `json:"hasCreatedAt"` // 是否有 CreatedAt 字段（创建时间）
+	HasUpdatedAt bool `json:"hasUpdatedAt"` // 是否有 UpdatedAt 字段（更新时间）
+	HasCreatedBy bool `json:"hasCreatedBy"` // 是否有 CreatedBy 字段（创建人）
+	HasUpdatedBy bool `json:"hasUpdatedBy"` // 是否有 UpdatedBy 字段（更新人）
 
-	DeletedAtField *FieldMetadata // DeletedAt 字段元数据
-	VersionField   *FieldMetadata // Version 字段元数据
-	CreatedAtField *FieldMetadata // CreatedAt 字段元数据
-	UpdatedAtField *FieldMetadata // UpdatedAt 字段元数据
-	CreatedByField *FieldMetadata // CreatedBy 字段元数据
-	UpdatedByField *FieldMetadata // UpdatedBy 字段元数据
+	DeletedAtField *FieldMetadata `json:"-"` // DeletedAt 字段元数据
+	VersionField   *FieldMetadata `json:"-"` // Version 字段元数据
+	CreatedAtField *FieldMetadata `json:"-"` // CreatedAt 字段元数据
+	UpdatedAtField *FieldMetadata `json:"-"` // UpdatedAt 字段元数据
+	CreatedByField *FieldMetadata `json:"-"` // CreatedBy 字段元数据
+	UpdatedByField *FieldMetadata `json:"-"` // UpdatedBy 字段元数据
 }
 
 // RelationType 关系类型枚举
@@ -74,75 +465,165 @@ type BaseEntityMetadata struct {
 //   - 一对多：切片类型 + +soliton:entity（如：Items []*OrderItem）
 //   - 多对多：双向 +soliton:ref 注解（聚合根级别）
 //   - 外部引用：基础类型 + +soliton:ref（如：UserID int64）
+//   - 多态关联：基础类型 + +soliton:polymorphic（如：AttachableID int64，目标类型保存在 AttachableType 中）
 type RelationType int
 
 const (
-	RelationTypeOneToOne   RelationType = iota // 一对一：单个对象 + entity注解
-	RelationTypeOneToMany                      // 一对多：切片 + entity注解
-	RelationTypeManyToMany                     // 多对多：双向ref注解
-	RelationTypeRef                            // 外部引用：基础类型 + ref注解
+	RelationTypeOneToOne    RelationType = iota // 一对一：单个对象 + entity注解
+	RelationTypeOneToMany                       // 一对多：切片 + entity注解
+	RelationTypeManyToMany                      // 多对多：双向ref注解
+	RelationTypeRef                             // 外部引用：基础类型 + ref注解
+	RelationTypePolymorphic                     // 多态关联：ID 字段 + 类型字段，polymorphic注解列出可指向的聚合根
 )
 
 // RelationMetadata 关系元数据
 type RelationMetadata struct {
-	SourceAggregate string         // 源聚合根
-	TargetAggregate string         // 目标聚合根
-	Type            RelationType   // 关系类型
-	Field           *FieldMetadata // 关联字段
-	IsOwner         bool           // 是否为关系的拥有方（用于多对多）
+	SourceAggregate  string            `json:"sourceAggregate"`            // 源聚合根
+	TargetAggregate  string            `json:"targetAggregate"`            // 目标聚合根
+	Type             RelationType      `json:"type"`                       // 关系类型
+	TargetField      string            `json:"targetField,omitempty"`      // 外部引用指向的目标字段（+soliton:ref(User.ID)），为空表示目标主键
+	SelfReference    bool              `json:"selfReference,omitempty"`    // 是否为自引用（源与目标是同一聚合根，如树形结构的 ParentID、Children）
+	Cascade          string            `json:"cascade,omitempty"`          // 关联实体的级联行为（+soliton:cascade），见 CascadeDelete
+	ForeignKey       *FieldMetadata    `json:"-"`                          // 一对多、一对一关系的外键字段，如 OrderItem.OrderID；源聚合根持有外键时为源中的字段，见 ForeignKeyOnSource
+	ForeignKeyColumn string            `json:"foreignKeyColumn,omitempty"` // 一对多、一对一关系的外键列，如 "order_id"，见 +soliton:fk
+	Field            *FieldMetadata    `json:"-"`                          // 关联字段
+	IsOwner          bool              `json:"isOwner"`                    // 是否为关系的拥有方：多对多中为声明方，双向关系中为持有外键的外部引用一方，一对一中为声明了 +soliton:owner 的源聚合根
+	Through          string            `json:"through,omitempty"`          // 多对多通过中间实体（+soliton:manyToMany）关联时的中间实体聚合根，为空表示纯关联表
+	Inverse          *RelationMetadata `json:"-"`                          // 双向关系的另一侧，如 Order.Items 与 OrderItem.OrderID 互为反向，见 IsBackReference
+	InverseField     string            `json:"inverseField,omitempty"`     // 双向关系另一侧的关联字段名，如 "OrderID"
+	External         bool              `json:"external,omitempty"`         // 外部引用的目标是其他服务中的聚合根（+soliton:external、-external），不在本模型中定义
+}
+
+// IsBackReference 判断关系是否为双向关系中外部引用的一侧（持有外键的拥有方）
+//
+// 一对多 Order.Items 与外键 OrderItem.OrderID +soliton:ref(Order) 描述的是同一个关系，
+// 外键、索引和加载方法只按一对多一侧生成，遍历关系的生成器应跳过这一侧。
+func (r *RelationMetadata) IsBackReference() bool {
+	return r.Inverse != nil && r.Type == RelationTypeRef
+}
+
+// ForeignKeyOnSource 判断外键列是否位于源聚合根表中
+//
+// 一对一关系的外键默认与一对多一样位于关联实体表中（如 shipments.order_id）；
+// 关联字段声明 +soliton:owner 时由聚合根持有外键（如 orders.shipment_id），ForeignKey 为聚合根中的字段。
+func (r *RelationMetadata) ForeignKeyOnSource() bool {
+	return r.Type == RelationTypeOneToOne && r.IsOwner
 }
 
 // ManyToManyTableMetadata 多对多关联表元数据
 type ManyToManyTableMetadata struct {
-	TableName      string // 关联表名，如 "user_role"
-	LeftAggregate  string // 左侧聚合根，如 "User"
-	RightAggregate string // 右侧聚合根，如 "Role"
-	LeftColumn     string // 左侧外键列名，如 "user_id"
-	RightColumn    string // 右侧外键列名，如 "role_id"
-	LeftIDField    string // 左侧ID字段名
-	RightIDField   string // 右侧ID字段名
-	GenerationType string // 生成类型："relation_only"（纯关联）或 "aggregate"（作为聚合根）
+	TableName      string `json:"tableName"`             // 关联表名，如 "user_role"
+	LeftAggregate  string `json:"leftAggregate"`         // 左侧聚合根，如 "User"
+	RightAggregate string `json:"rightAggregate"`        // 右侧聚合根，如 "Role"
+	LeftColumn     string `json:"leftColumn"`            // 左侧外键列名，如 "user_id"
+	RightColumn    string `json:"rightColumn"`           // 右侧外键列名，如 "role_id"
+	LeftIDField    string `json:"leftIdField"`           // 左侧ID字段名
+	RightIDField   string `json:"rightIdField"`          // 右侧ID字段名
+	GenerationType string `json:"generationType"`        // 生成类型："relation_only"（纯关联）或 "aggregate"（作为聚合根）
+	Association    string `json:"association,omitempty"` // 作为聚合根时的中间实体，如 "UserRole"，表结构由该聚合根生成
 }
 
 // EnumMetadata 枚举元数据
 type EnumMetadata struct {
-	Name          string   // 枚举名称，如 "UserStatus"
-	FieldName     string   // 原字段名，如 "Status"
-	AggregateName string   // 所属聚合根，如 "User"
-	Values        []string // 枚举值列表，如 ["ACTIVE", "INACTIVE", "BANNED"]
-	GoType        string   // Go 类型，通常是 string
+	Name          string   `json:"name"`                 // 枚举名称，如 "UserStatus"
+	FieldName     string   `json:"fieldName"`            // 原字段名，如 "Status"
+	AggregateName string   `json:"aggregateName"`        // 所属聚合根，如 "User"
+	Values        []string `json:"values"`               // 枚举值列表，如 ["ACTIVE", "INACTIVE", "BANNED"]
+	GoType        string   `json:"goType"`               // Go 类型，通常是 string
+	ImportPath    string   `json:"importPath,omitempty"` // 来自 const 块时为定义类型的包路径
+	Constants     []string `json:"constants,omitempty"`  // 来自 const 块时为与 Values 一一对应的常量名
+	Names         []string `json:"names,omitempty"`      // 整数枚举与 Values（编码）一一对应的名称，如 ["ACTIVE", "BANNED"]
+	Labels        []string `json:"labels,omitempty"`     // 与 Values 一一对应的说明，如 ["正常", "封禁"]，未声明说明时为空
+}
+
+// IsDeclared 判断枚举是否来自 const 块（代码中已有类型定义，无需生成）
+func (e *EnumMetadata) IsDeclared() bool {
+	return e.ImportPath != ""
+}
+
+// IsInt 判断是否为整数枚举，整数枚举的 Values 为编码，如 "1"
+func (e *EnumMetadata) IsInt() bool {
+	return len(e.Names) > 0 || (e.IsDeclared() && e.GoType != "string")
+}
+
+// ValueName 返回第 i 个值的名称：整数枚举为 Names 中的名称，字符串枚举为值本身
+func (e *EnumMetadata) ValueName(i int) string {
+	if i < len(e.Names) {
+		return e.Names[i]
+	}
+	return e.Values[i]
+}
+
+// ValueLabel 返回第 i 个值的说明，未声明时为空
+func (e *EnumMetadata) ValueLabel(i int) string {
+	if i < len(e.Labels) {
+		return e.Labels[i]
+	}
+	return ""
 }
 
 // AggregateMetadataRegistry 全局聚合根元数据注册表
+//
+// 注册表返回的列表顺序只由模型内容决定，与解析、分析的先后和 map 遍历顺序无关，
+// 从源码分析得到的注册表与 LoadFromJSON 加载的注册表顺序一致，生成结果在多次运行间保持稳定：
+//   - 聚合根：按名称排序
+//   - 关系：按源聚合根名、关联字段的声明顺序、目标聚合根名排序（见 compareRelations）
+//   - 多对多关联表：按表名排序
+//   - 枚举：按名称排序
+//   - 领域事件：按聚合根名、事件名排序
+//
+// 注册表的方法可以被多个协程并发调用，返回的列表都是副本，之后的注册和添加不会影响已取得的列表。
+// 锁只保护注册表自身的索引：关系分析会修改聚合根和关系元数据（如 Inverse、IsOwner、ScalarType），
+// 需要并发读取元数据内容的生成流程应在 AnalyzeRelations 完成后开始，或使用 Snapshot 取得的快照。
 type AggregateMetadataRegistry struct {
-	aggregates       map[string]*AggregateMetadata // 聚合根名 -> 元数据
-	relations        []*RelationMetadata           // 所有关系
-	manyToManyTables []*ManyToManyTableMetadata    // 多对多关联表
-	enums            []*EnumMetadata               // 所有枚举
+	mu                sync.RWMutex
+	aggregates        map[string]*AggregateMetadata  // 聚合根名 -> 元数据
+	relations         []*RelationMetadata            // 所有关系，按 compareRelations 排序
+	relationsBySource map[string][]*RelationMetadata // 源聚合根 -> 关系，顺序同 relations
+	relationsByTarget map[string][]*RelationMetadata // 目标聚合根 -> 关系，顺序同 relations
+	manyToManyTables  []*ManyToManyTableMetadata     // 多对多关联表，按表名排序
+	enums             []*EnumMetadata                // 所有枚举，按名称排序
+	declaredEnums     []*EnumMetadata                // 由 const 块定义的枚举
+	events            []*EventMetadata               // 所有领域事件，按聚合根名、事件名排序
+	declaredEvents    []*EventMetadata               // 由专用结构体定义的事件
 }
 
 // NewAggregateMetadataRegistry 创建注册表
 func NewAggregateMetadataRegistry() *AggregateMetadataRegistry {
 	return &AggregateMetadataRegistry{
-		aggregates:       make(map[string]*AggregateMetadata),
-		relations:        make([]*RelationMetadata, 0),
-		manyToManyTables: make([]*ManyToManyTableMetadata, 0),
-		enums:            make([]*EnumMetadata, 0),
+		aggregates:        make(map[string]*AggregateMetadata),
+		relations:         make([]*RelationMetadata, 0),
+		relationsBySource: make(map[string][]*RelationMetadata),
+		relationsByTarget: make(map[string][]*RelationMetadata),
+		manyToManyTables:  make([]*ManyToManyTableMetadata, 0),
+		enums:             make([]*EnumMetadata, 0),
+		events:            make([]*EventMetadata, 0),
 	}
 }
 
 // Register 注册聚合根
 func (r *AggregateMetadataRegistry) Register(agg *AggregateMetadata) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.aggregates[agg.Name] = agg
 }
 
 // Get 获取聚合根元数据
 func (r *AggregateMetadataRegistry) Get(name string) *AggregateMetadata {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	return r.aggregates[name]
 }
 
-// GetAll 获取所有聚合根
+// GetAll 获取所有聚合根（按名称排序）
 func (r *AggregateMetadataRegistry) GetAll() []*AggregateMetadata {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.all()
+}
+
+// all 返回按名称排序的聚合根，调用方需持有锁
+func (r *AggregateMetadataRegistry) all() []*AggregateMetadata {
 	result := make([]*AggregateMetadata, 0, len(r.aggregates))
 	names := make([]string, 0, len(r.aggregates))
 	for name := range r.aggregates {
@@ -155,76 +636,290 @@ func (r *AggregateMetadataRegistry) GetAll() []*AggregateMetadata {
 	return result
 }
 
-// AddRelation 添加关系
+// GetByContext 获取属于指定限界上下文的聚合根（按名称排序）
+// name 为空时返回未声明 +soliton:context 的聚合根
+func (r *AggregateMetadataRegistry) GetByContext(name string) []*AggregateMetadata {
+	return r.Select(func(agg *AggregateMetadata) bool {
+		return agg.Context() == name
+	})
+}
+
+// Contexts 获取所有已声明的限界上下文名称（按名称排序，不含空上下文）
+func (r *AggregateMetadataRegistry) Contexts() []string {
+	seen := make(map[string]bool)
+	contexts := make([]string, 0)
+	for _, agg := range r.GetAll() {
+		if name := agg.Context(); name != "" && !seen[name] {
+			seen[name] = true
+			contexts = append(contexts, name)
+		}
+	}
+	sort.Strings(contexts)
+	return contexts
+}
+
+// Select 获取满足条件的聚合根（按名称排序）
+func (r *AggregateMetadataRegistry) Select(match func(agg *AggregateMetadata) bool) []*AggregateMetadata {
+	result := make([]*AggregateMetadata, 0)
+	for _, agg := range r.GetAll() {
+		if match(agg) {
+			result = append(result, agg)
+		}
+	}
+	return result
+}
+
+// GetByPackage 获取定义在指定包中的聚合根（按名称排序），importPath 为完整的 import 路径，如 "mymodule/domain/model"
+func (r *AggregateMetadataRegistry) GetByPackage(importPath string) []*AggregateMetadata {
+	return r.Select(func(agg *AggregateMetadata) bool {
+		return agg.ImportPath == importPath
+	})
+}
+
+// GetWithAnnotation 获取在聚合根上声明了注解 name 的聚合根（按名称排序），如 GetWithAnnotation("manyToMany")
+// 只检查聚合根级别的注解，字段上的注解见 GetWithFieldAnnotation；带参数和不带参数的写法都算声明
+func (r *AggregateMetadataRegistry) GetWithAnnotation(name string) []*AggregateMetadata {
+	return r.Select(func(agg *AggregateMetadata) bool {
+		return agg.Annotations != nil && agg.Annotations.Nodes.Has(name)
+	})
+}
+
+// GetWithFieldAnnotation 获取至少有一个映射字段声明了注解 name 的聚合根（按名称排序），如 GetWithFieldAnnotation("sensitive")
+func (r *AggregateMetadataRegistry) GetWithFieldAnnotation(name string) []*AggregateMetadata {
+	return r.Select(func(agg *AggregateMetadata) bool {
+		return slices.ContainsFunc(agg.MappedFields(), func(field *FieldMetadata) bool {
+			return field.Annotations.Nodes.Has(name)
+		})
+	})
+}
+
+// GetAggregatesWithSoftDelete 获取支持软删除（带 DeletedAt 字段）的聚合根（按名称排序）
+func (r *AggregateMetadataRegistry) GetAggregatesWithSoftDelete() []*AggregateMetadata {
+	return r.Select(func(agg *AggregateMetadata) bool {
+		return agg.BaseEntity != nil && agg.BaseEntity.HasDeletedAt
+	})
+}
+
+// GetExposed 获取通过协议 protocol 对外暴露 API 的聚合根（按名称排序），见 APIProtocolREST 等常量
+func (r *AggregateMetadataRegistry) GetExposed(protocol string) []*AggregateMetadata {
+	return r.Select(func(agg *AggregateMetadata) bool {
+		return agg.Exposes(protocol)
+	})
+}
+
+// AddRelation 添加关系，按 compareRelations 插入到对应位置，排序相同的关系保持添加顺序
+// 源聚合根应已注册，否则无法取得关联字段的声明顺序
 func (r *AggregateMetadataRegistry) AddRelation(rel *RelationMetadata) {
-	r.relations = append(r.relations, rel)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.relations = r.insertRelation(r.relations, rel)
+	r.relationsBySource[rel.SourceAggregate] = r.insertRelation(r.relationsBySource[rel.SourceAggregate], rel)
+	r.relationsByTarget[rel.TargetAggregate] = r.insertRelation(r.relationsByTarget[rel.TargetAggregate], rel)
+}
+
+// insertRelation 将关系插入到已排序的 relations 中，位于所有不排在它之后的关系之后
+func (r *AggregateMetadataRegistry) insertRelation(relations []*RelationMetadata, rel *RelationMetadata) []*RelationMetadata {
+	i := len(relations)
+	for i > 0 && r.compareRelations(relations[i-1], rel) > 0 {
+		i--
+	}
+	return slices.Insert(relations, i, rel)
 }
 
-// GetRelations 获取所有关系
+// compareRelations 比较两条关系在注册表中的先后
+//
+// 依次比较源聚合根名、关联字段在源聚合根中的声明位置、目标聚合根名和关系类型。
+// 没有关联字段的关系（聚合根级别或中间实体声明的多对多关系）排在该聚合根的字段关系之后。
+func (r *AggregateMetadataRegistry) compareRelations(a, b *RelationMetadata) int {
+	if c := strings.Compare(a.SourceAggregate, b.SourceAggregate); c != 0 {
+		return c
+	}
+	if c := r.fieldPosition(a) - r.fieldPosition(b); c != 0 {
+		return c
+	}
+	if c := strings.Compare(a.TargetAggregate, b.TargetAggregate); c != 0 {
+		return c
+	}
+	return int(a.Type) - int(b.Type)
+}
+
+// fieldPosition 返回关系的关联字段在源聚合根字段中的下标，没有关联字段或找不到时返回字段数
+func (r *AggregateMetadataRegistry) fieldPosition(rel *RelationMetadata) int {
+	source := r.aggregates[rel.SourceAggregate]
+	if source == nil {
+		return 0
+	}
+	if i := slices.Index(source.Fields, rel.Field); rel.Field != nil && i >= 0 {
+		return i
+	}
+	return len(source.Fields)
+}
+
+// GetRelations 获取所有关系（按 compareRelations 排序）
 func (r *AggregateMetadataRegistry) GetRelations() []*RelationMetadata {
-	return r.relations
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.relations)
+}
+
+// GetRelationsByAggregate 获取指定聚合根作为源的所有关系（即该聚合根字段声明的关系）
+// types 非空时只返回这些类型的关系
+func (r *AggregateMetadataRegistry) GetRelationsByAggregate(aggregateName string, types ...RelationType) []*RelationMetadata {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return filterRelations(r.relationsBySource[aggregateName], types)
+}
+
+// GetRelationsByTarget 获取指向指定聚合根的所有关系，如 Order.Items、Invoice.Lines 等指向各自关联实体的关系
+// types 非空时只返回这些类型的关系
+func (r *AggregateMetadataRegistry) GetRelationsByTarget(aggregateName string, types ...RelationType) []*RelationMetadata {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return filterRelations(r.relationsByTarget[aggregateName], types)
 }
 
-// GetRelationsByAggregate 获取指定聚合根的所有关系
-func (r *AggregateMetadataRegistry) GetRelationsByAggregate(aggregateName string) []*RelationMetadata {
+// GetRelationsBetween 获取两个聚合根之间任意方向的关系，a 作为源的关系在前
+// 两者相同时返回自引用关系；types 非空时只返回这些类型的关系
+func (r *AggregateMetadataRegistry) GetRelationsBetween(a, b string, types ...RelationType) []*RelationMetadata {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	result := make([]*RelationMetadata, 0)
-	for _, rel := range r.relations {
-		if rel.SourceAggregate == aggregateName {
+	for _, rel := range r.relationsBySource[a] {
+		if rel.TargetAggregate == b {
+			result = append(result, rel)
+		}
+	}
+	if a != b {
+		for _, rel := range r.relationsBySource[b] {
+			if rel.TargetAggregate == a {
+				result = append(result, rel)
+			}
+		}
+	}
+	return filterRelations(result, types)
+}
+
+// GetRelationsByType 获取指定类型的所有关系
+func (r *AggregateMetadataRegistry) GetRelationsByType(types ...RelationType) []*RelationMetadata {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return filterRelations(r.relations, types)
+}
+
+// filterRelations 返回类型在 types 中的关系，types 为空时返回全部（总是返回新切片）
+func filterRelations(relations []*RelationMetadata, types []RelationType) []*RelationMetadata {
+	result := make([]*RelationMetadata, 0, len(relations))
+	for _, rel := range relations {
+		if len(types) == 0 || slices.Contains(types, rel.Type) {
 			result = append(result, rel)
 		}
 	}
 	return result
 }
 
-// AddManyToManyTable 添加多对多关联表
+// GetCascadeRelations 获取指定聚合根声明了级联行为（+soliton:cascade）的关联实体关系
+func (r *AggregateMetadataRegistry) GetCascadeRelations(aggregateName string) []*RelationMetadata {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []*RelationMetadata
+	for _, rel := range r.relationsBySource[aggregateName] {
+		if rel.Cascade != "" && rel.ForeignKey != nil {
+			result = append(result, rel)
+		}
+	}
+	return result
+}
+
+// AddManyToManyTable 添加多对多关联表，按表名插入到对应位置
 func (r *AggregateMetadataRegistry) AddManyToManyTable(table *ManyToManyTableMetadata) {
-	r.manyToManyTables = append(r.manyToManyTables, table)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i, _ := slices.BinarySearchFunc(r.manyToManyTables, table.TableName, func(t *ManyToManyTableMetadata, name string) int {
+		if t.TableName <= name {
+			return -1
+		}
+		return 1
+	})
+	r.manyToManyTables = slices.Insert(r.manyToManyTables, i, table)
 }
 
-// GetManyToManyTables 获取所有多对多关联表
+// GetManyToManyTables 获取所有多对多关联表（按表名排序）
 func (r *AggregateMetadataRegistry) GetManyToManyTables() []*ManyToManyTableMetadata {
-	return r.manyToManyTables
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.manyToManyTables)
 }
 
 // Exists 检查聚合根是否存在
 func (r *AggregateMetadataRegistry) Exists(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	_, ok := r.aggregates[name]
 	return ok
 }
 
-// AddEnum 添加枚举
+// AddEnum 添加枚举，按名称插入到对应位置
 func (r *AggregateMetadataRegistry) AddEnum(enum *EnumMetadata) {
-	r.enums = append(r.enums, enum)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i, _ := slices.BinarySearchFunc(r.enums, enum.Name, func(e *EnumMetadata, name string) int {
+		if e.Name <= name {
+			return -1
+		}
+		return 1
+	})
+	r.enums = slices.Insert(r.enums, i, enum)
 }
 
-// GetEnums 获取所有枚举
+// SetDeclaredEnums 设置由 const 块定义的枚举，CollectEnums 时一并收集
+func (r *AggregateMetadataRegistry) SetDeclaredEnums(enums []*EnumMetadata) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.declaredEnums = slices.Clone(enums)
+}
+
+// GetEnums 获取所有枚举（按名称排序）
 func (r *AggregateMetadataRegistry) GetEnums() []*EnumMetadata {
-	return r.enums
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.enums)
 }
 
 // CollectEnums 从所有聚合根中收集枚举
+// 由 const 块定义的枚举一并收集，同名的注解枚举以 const 块定义的为准
 func (r *AggregateMetadataRegistry) CollectEnums() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	// 重建枚举列表，避免重复收集
-	r.enums = r.enums[:0]
+	r.enums = slices.Clone(r.declaredEnums)
 	seen := make(map[string]bool)
+	for _, enum := range r.declaredEnums {
+		seen[enum.Name] = true
+	}
 
-	for _, agg := range r.GetAll() {
-		for _, field := range agg.Fields {
-			if len(field.Annotations.EnumValues) > 0 {
+	for _, agg := range r.all() {
+		for _, field := range agg.MappedFields() {
+			// 枚举值来自 const 块的字段已由对应的类型表示
+			if len(field.Annotations.EnumValues) > 0 && field.Annotations.EnumType == "" {
 				enumName := agg.Name + field.Name // 如 UserStatus
-				enumKey := agg.Name + "." + field.Name
-				if seen[enumKey] {
+				if seen[enumName] {
 					continue
 				}
-				seen[enumKey] = true
+				seen[enumName] = true
 				r.enums = append(r.enums, &EnumMetadata{
 					Name:          enumName,
 					FieldName:     field.Name,
 					AggregateName: agg.Name,
 					Values:        field.Annotations.EnumValues,
 					GoType:        field.Type,
+					Names:         field.Annotations.EnumNames,
+					Labels:        field.Annotations.EnumLabels,
 				})
 			}
 		}
 	}
+	slices.SortStableFunc(r.enums, func(a, b *EnumMetadata) int {
+		return strings.Compare(a.Name, b.Name)
+	})
 }
@@ -0,0 +1,153 @@
+package metadata
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NamingStrategy 表命名策略，决定聚合根表和多对多关联表的默认表名
+//
+// 只作用于默认表名：+soliton:table(name=...) 与 +soliton:manyToMany(table=...) 声明的表名原样使用。
+type NamingStrategy interface {
+	// TableName 返回聚合根的默认表名，如 OrderItem -> order_items
+	TableName(aggregateName string) string
+	// JoinTableName 返回多对多关联表的默认表名，left、right 为按字母序排列的两端名称
+	// （聚合根名，或其自定义表名），如 Role、User -> role_user
+	JoinTableName(left, right string) string
+}
+
+// SnakeNamingStrategy 蛇形命名策略
+//
+// 聚合根表名为聚合根名的蛇形形式，Plural 为 true 时取复数；关联表名为两端名称的蛇形形式以 _ 连接。
+// 设置 Prefix 时所有默认表名都加上前缀，如 t_order_items、t_role_user。
+type SnakeNamingStrategy struct {
+	Plural bool   // 聚合根表名是否取复数
+	Prefix string // 表名前缀，如 "t_"
+}
+
+// TableName 实现 NamingStrategy
+func (s *SnakeNamingStrategy) TableName(aggregateName string) string {
+	name := toSnakeCase(aggregateName)
+	if s.Plural {
+		name = pluralize(name)
+	}
+	return s.Prefix + name
+}
+
+// JoinTableName 实现 NamingStrategy
+// 两端使用自定义表名时去掉其中的前缀，避免出现 t_t_role_user
+func (s *SnakeNamingStrategy) JoinTableName(left, right string) string {
+	part := func(name string) string {
+		return toSnakeCase(strings.TrimPrefix(name, s.Prefix))
+	}
+	return s.Prefix + part(left) + "_" + part(right)
+}
+
+// DefaultNamingStrategy 默认命名策略：聚合根表名取复数（order_items），关联表名取单数（role_user），无前缀
+func DefaultNamingStrategy() NamingStrategy {
+	return &SnakeNamingStrategy{Plural: true}
+}
+
+// fixedTableNaming 固定聚合根表名的命名策略
+//
+// 从 JSON 加载元数据（见 LoadFromJSON）时，按导出时的命名策略得到的表名无法还原为原策略，
+// 以导出的表名作为该聚合根的默认表名；关联表名已记录在关联表元数据中，按默认策略拼接。
+type fixedTableNaming struct {
+	table string
+}
+
+// TableName 实现 NamingStrategy
+func (n fixedTableNaming) TableName(string) string {
+	return n.table
+}
+
+// JoinTableName 实现 NamingStrategy
+func (n fixedTableNaming) JoinTableName(left, right string) string {
+	return DefaultNamingStrategy().JoinTableName(left, right)
+}
+
+// 命名策略名称，见 ParseNamingStrategy
+const (
+	NamingSnake       = "snake"        // 蛇形单数，如 order_item
+	NamingSnakePlural = "snake_plural" // 蛇形复数，如 order_items（默认）
+)
+
+// ParseNamingStrategy 按名称和表名前缀创建命名策略（如 CLI 的 -naming、-table-prefix 参数）
+// name 为空时使用默认的 snake_plural
+func ParseNamingStrategy(name, prefix string) (NamingStrategy, error) {
+	switch name {
+	case "", NamingSnakePlural:
+		return &SnakeNamingStrategy{Plural: true, Prefix: prefix}, nil
+	case NamingSnake:
+		return &SnakeNamingStrategy{Prefix: prefix}, nil
+	default:
+		return nil, fmt.Errorf("未知的命名策略 %q，可选值: %s, %s", name, NamingSnake, NamingSnakePlural)
+	}
+}
+
+// Table 返回聚合根对应的表名
+//
+// 设置了 +soliton:table(name=...) 时使用自定义表名，
+// 否则按命名策略（Naming，未设置时为 DefaultNamingStrategy）生成，如 OrderItem -> order_items、Category -> categories。
+func (a *AggregateMetadata) Table() string {
+	if a.TableName != "" {
+		return a.TableName
+	}
+	naming := a.Naming
+	if naming == nil {
+		naming = DefaultNamingStrategy()
+	}
+	return naming.TableName(a.Name)
+}
+
+// Column 返回字段对应的列名
+//
+// 优先级：+soliton:column(name=...) > db 标签 > 字段名转蛇形（如 OrderNo -> order_no）。
+func (f *FieldMetadata) Column() string {
+	if f.ColumnName != "" {
+		return f.ColumnName
+	}
+	if f.DBTag != "" {
+		return f.DBTag
+	}
+	return toSnakeCase(f.Name)
+}
+
+// pluralize 简单的英文复数规则
+// 以 s、x、z、ch、sh 结尾时加 es（box -> boxes、match -> matches），辅音加 y 结尾时改为 ies（category -> categories），
+// 元音加 y 结尾时直接加 s（day -> days、key -> keys）
+func pluralize(name string) string {
+	switch {
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "z"),
+		strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiou", rune(name[len(name)-2])):
+		return name[:len(name)-1] + "ies"
+	default:
+		return name + "s"
+	}
+}
+
+// toSnakeCase 转换为蛇形命名
+// 示例：UserID -> user_id, OrderNo -> order_no, CreatedAt -> created_at
+func toSnakeCase(s string) string {
+	var result []rune
+	runes := []rune(s)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 && runes[i-1] >= 'a' && runes[i-1] <= 'z' {
+				result = append(result, '_')
+			}
+			if i > 0 && i < len(runes)-1 &&
+				runes[i-1] >= 'A' && runes[i-1] <= 'Z' &&
+				runes[i+1] >= 'a' && runes[i+1] <= 'z' {
+				result = append(result, '_')
+			}
+		}
+		result = append(result, r)
+	}
+
+	return strings.ToLower(string(result))
+}
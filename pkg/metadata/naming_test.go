@@ -0,0 +1,54 @@
+package metadata
+
+import "testing"
+
+func TestPluralize(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"order", "orders"},
+		{"order_item", "order_items"},
+		{"status", "statuses"},
+		{"category", "categories"},
+		{"company", "companies"},
+		{"day", "days"},
+		{"key", "keys"},
+		{"survey", "surveys"},
+		{"toy", "toys"},
+		{"guy", "guys"},
+		{"box", "boxes"},
+		{"tax", "taxes"},
+		{"match", "matches"},
+		{"branch", "branches"},
+		{"wish", "wishes"},
+		{"quiz", "quizes"},
+		{"y", "ys"},
+	}
+	for _, tt := range tests {
+		if got := pluralize(tt.name); got != tt.want {
+			t.Errorf("pluralize(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSnakeNamingStrategyTableName(t *testing.T) {
+	tests := []struct {
+		strategy  *SnakeNamingStrategy
+		aggregate string
+		want      string
+	}{
+		{&SnakeNamingStrategy{Plural: true}, "OrderItem", "order_items"},
+		{&SnakeNamingStrategy{Plural: true}, "Holiday", "holidays"},
+		{&SnakeNamingStrategy{Plural: true}, "ApiKey", "api_keys"},
+		{&SnakeNamingStrategy{Plural: true}, "MailBox", "mail_boxes"},
+		{&SnakeNamingStrategy{Plural: true}, "Match", "matches"},
+		{&SnakeNamingStrategy{Plural: true, Prefix: "t_"}, "Category", "t_categories"},
+		{&SnakeNamingStrategy{}, "Match", "match"},
+	}
+	for _, tt := range tests {
+		if got := tt.strategy.TableName(tt.aggregate); got != tt.want {
+			t.Errorf("TableName(%q) = %q, want %q", tt.aggregate, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package metadata
+
+import (
+	"go/token"
+	"slices"
+	"strings"
+)
+
+// QueryMetadata 聚合根上声明的查询（CQRS 的读侧）
+//
+// 由聚合根上的 +soliton:query 声明，可以声明多个，仓储查询方法和读模型据此生成，而不只是从索引推断：
+//
+//	// +soliton:query(name=ListActiveOrders, by=Status,CreatedAt)
+//	// +soliton:query(name=FindByOrderNo, by=OrderNo, select=ID,OrderNo,Status)
+//	// +soliton:query(name=ListRecent, orderBy=CreatedAt desc,ID)
+//
+// by 列出查询条件字段，按顺序作为方法参数，以等值条件组成 WHERE；select 列出投影字段，为空时查询整个聚合根；
+// orderBy 列出排序字段，字段名后可跟 asc 或 desc。字段名为 Go 字段名，展开的值对象中的字段写作 Address.City。
+type QueryMetadata struct {
+	Name    string         `json:"name"`              // 查询名，如 "ListActiveOrders"
+	By      []string       `json:"by,omitempty"`      // 查询条件字段，按顺序排列
+	Select  []string       `json:"select,omitempty"`  // 投影字段，为空表示整个聚合根
+	OrderBy []string       `json:"orderBy,omitempty"` // 排序，如 ["CreatedAt desc", "ID"]
+	Comment string         `json:"comment,omitempty"` // 查询说明（+soliton:query(..., comment=...)）
+	Pos     token.Position `json:"-"`                 // 查询注解在源码中的位置
+}
+
+// 排序方向
+const (
+	QueryOrderAsc  = "asc"
+	QueryOrderDesc = "desc"
+)
+
+// QueryOrder 查询的一个排序项
+type QueryOrder struct {
+	Field string // 排序字段
+	Desc  bool   // 是否降序
+}
+
+// Orders 返回解析后的排序项，未写方向时为升序；方向无效的排序项按原文作为字段名，由校验报告
+func (q *QueryMetadata) Orders() []QueryOrder {
+	orders := make([]QueryOrder, 0, len(q.OrderBy))
+	for _, item := range q.OrderBy {
+		field, direction, _ := strings.Cut(strings.TrimSpace(item), " ")
+		switch strings.ToLower(strings.TrimSpace(direction)) {
+		case "", QueryOrderAsc:
+			orders = append(orders, QueryOrder{Field: field})
+		case QueryOrderDesc:
+			orders = append(orders, QueryOrder{Field: field, Desc: true})
+		default:
+			orders = append(orders, QueryOrder{Field: strings.TrimSpace(item)})
+		}
+	}
+	return orders
+}
+
+// IsProjection 判断查询是否只读取部分字段
+func (q *QueryMetadata) IsProjection() bool {
+	return len(q.Select) > 0
+}
+
+// IsSingle 判断查询是否至多返回一条记录：条件字段恰好覆盖聚合根的主键或某个唯一索引
+func (q *QueryMetadata) IsSingle(agg *AggregateMetadata) bool {
+	if len(q.By) == 0 {
+		return false
+	}
+
+	covers := func(fields []string) bool {
+		if len(fields) != len(q.By) {
+			return false
+		}
+		for _, field := range fields {
+			if !slices.Contains(q.By, field) {
+				return false
+			}
+		}
+		return true
+	}
+
+	var primaryKey []string
+	for _, field := range agg.PrimaryKey {
+		primaryKey = append(primaryKey, field.Name)
+	}
+	if covers(primaryKey) {
+		return true
+	}
+	for _, index := range agg.Indexes {
+		if index.Unique && index.Where == "" && covers(index.Fields) {
+			return true
+		}
+	}
+	return false
+}
+
+// Query 返回聚合根上名为 name 的查询，未声明时返回 nil
+func (a *AggregateMetadata) Query(name string) *QueryMetadata {
+	for _, query := range a.Queries {
+		if query.Name == name {
+			return query
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package metadata
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ScalarType 可直接映射为单个数据库列的外部类型
+//
+// 这类类型虽然是结构体或其他包的命名类型，但实现了 sql.Scanner/driver.Valuer
+// （或由 GORM 内置支持），应当作为普通列处理，而不是识别为关联关系。
+type ScalarType struct {
+	Name       string `json:"name"`                 // 代码中的写法，如 "uuid.UUID"
+	ImportPath string `json:"importPath,omitempty"` // 包路径，如 "github.com/google/uuid"，生成 DO 时用于导入
+	SQLType    string `json:"sqlType,omitempty"`    // 列类型，如 "CHAR(36)"；为空时按 Go 类型推断
+}
+
+// QualifiedName 返回完整限定名，格式与 FieldMetadata.QualifiedType 一致，如 "github.com/google/uuid.UUID"
+// 未设置包路径时返回 Name
+func (t *ScalarType) QualifiedName() string {
+	if t.ImportPath == "" {
+		return t.Name
+	}
+	return t.ImportPath + "." + t.Name[strings.LastIndex(t.Name, ".")+1:]
+}
+
+// ScalarTypeRegistry 已知标量类型注册表
+//
+// 预置常用的外部类型，可通过 Register 追加或覆盖（如 CLI 的 -scalar 参数）。
+type ScalarTypeRegistry struct {
+	byName      map[string]*ScalarType // 代码写法 -> 类型
+	byQualified map[string]*ScalarType // 完整限定名 -> 类型
+}
+
+// NewScalarTypeRegistry 创建包含预置类型的标量类型注册表
+func NewScalarTypeRegistry() *ScalarTypeRegistry {
+	r := &ScalarTypeRegistry{
+		byName:      make(map[string]*ScalarType),
+		byQualified: make(map[string]*ScalarType),
+	}
+
+	for _, t := range []*ScalarType{
+		{Name: "time.Time", ImportPath: "time", SQLType: "DATETIME"},
+		{Name: "time.Duration", ImportPath: "time", SQLType: "BIGINT"},
+		{Name: "uuid.UUID", ImportPath: "github.com/google/uuid", SQLType: "CHAR(36)"},
+		{Name: "decimal.Decimal", ImportPath: "github.com/shopspring/decimal", SQLType: "DECIMAL(20,6)"},
+		{Name: "sql.NullString", ImportPath: "database/sql", SQLType: "VARCHAR(255)"},
+		{Name: "sql.NullInt64", ImportPath: "database/sql", SQLType: "BIGINT"},
+		{Name: "sql.NullInt32", ImportPath: "database/sql", SQLType: "INT"},
+		{Name: "sql.NullFloat64", ImportPath: "database/sql", SQLType: "DOUBLE"},
+		{Name: "sql.NullBool", ImportPath: "database/sql", SQLType: "TINYINT(1)"},
+		{Name: "sql.NullTime", ImportPath: "database/sql", SQLType: "DATETIME"},
+		{Name: "json.RawMessage", ImportPath: "encoding/json", SQLType: "JSON"},
+	} {
+		r.Register(t)
+	}
+
+	return r
+}
+
+// Register 注册标量类型，同名类型会被覆盖
+func (r *ScalarTypeRegistry) Register(t *ScalarType) {
+	r.byName[t.Name] = t
+	r.byQualified[t.QualifiedName()] = t
+}
+
+// Lookup 按代码写法查找标量类型，如 "uuid.UUID"、"*uuid.UUID"
+func (r *ScalarTypeRegistry) Lookup(typeName string) *ScalarType {
+	return r.byName[strings.TrimPrefix(typeName, "*")]
+}
+
+// LookupField 查找字段对应的标量类型
+//
+// 启用类型解析时优先按完整限定类型匹配（不受导入别名影响），否则按代码写法匹配。
+// 切片、map 和定长数组字段不视为标量。
+func (r *ScalarTypeRegistry) LookupField(field *FieldMetadata) *ScalarType {
+	if field.IsSlice || field.IsMap || field.IsArray {
+		return nil
+	}
+	if t, ok := r.byQualified[field.QualifiedType]; ok {
+		return t
+	}
+	return r.Lookup(field.Type)
+}
+
+// ParseScalarType 解析标量类型声明
+//
+// 格式：包路径.类型名[=列类型]，包路径最后一段需与包名一致，如
+//   - "github.com/shopspring/decimal.Decimal=DECIMAL(20,4)"
+//   - "net/netip.Addr=VARCHAR(45)"
+func ParseScalarType(spec string) (*ScalarType, error) {
+	qualified, sqlType, _ := strings.Cut(strings.TrimSpace(spec), "=")
+	qualified = strings.TrimSpace(qualified)
+
+	dot := strings.LastIndex(qualified, ".")
+	slash := strings.LastIndex(qualified, "/")
+	if dot <= slash+1 || dot == len(qualified)-1 {
+		return nil, fmt.Errorf("无效的标量类型 %q，格式应为 包路径.类型名[=列类型]", spec)
+	}
+
+	return &ScalarType{
+		Name:       qualified[slash+1:],
+		ImportPath: qualified[:dot],
+		SQLType:    strings.TrimSpace(sqlType),
+	}, nil
+}
@@ -0,0 +1,305 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// RegistrySnapshot 注册表快照
+//
+// 注册表内容的可序列化视图，用于导出 JSON 供外部工具消费。
+// 所有列表按名称排序，相同输入总是得到相同的输出。
+type RegistrySnapshot struct {
+	Version          int                        `json:"version"`          // 格式版本，见 MetadataVersion
+	Aggregates       []*AggregateMetadata       `json:"aggregates"`       // 聚合根（按名称排序）
+	Relations        []*RelationMetadata        `json:"relations"`        // 关系（按源、目标、字段排序）
+	ManyToManyTables []*ManyToManyTableMetadata `json:"manyToManyTables"` // 多对多关联表（按表名排序）
+	Enums            []*EnumMetadata            `json:"enums"`            // 枚举（按名称排序）
+	Events           []*EventMetadata           `json:"events,omitempty"` // 领域事件（按聚合根名、事件名排序）
+}
+
+// Snapshot 生成注册表快照
+// 快照中的列表在同一时刻取得，之后注册表的变化不会反映到快照中
+func (r *AggregateMetadataRegistry) Snapshot() *RegistrySnapshot {
+	r.mu.RLock()
+	aggregates := r.all()
+	relations := slices.Clone(r.relations)
+	tables := slices.Clone(r.manyToManyTables)
+	enums := slices.Clone(r.enums)
+	events := slices.Clone(r.events)
+	r.mu.RUnlock()
+
+	sort.SliceStable(relations, func(i, j int) bool {
+		a, b := relations[i], relations[j]
+		if a.SourceAggregate != b.SourceAggregate {
+			return a.SourceAggregate < b.SourceAggregate
+		}
+		if a.TargetAggregate != b.TargetAggregate {
+			return a.TargetAggregate < b.TargetAggregate
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.fieldName() < b.fieldName()
+	})
+
+	return &RegistrySnapshot{
+		Version:          MetadataVersion,
+		Aggregates:       aggregates,
+		Relations:        relations,
+		ManyToManyTables: tables,
+		Enums:            enums,
+		Events:           events,
+	}
+}
+
+// String 返回关系类型名称
+func (t RelationType) String() string {
+	switch t {
+	case RelationTypeOneToOne:
+		return "one_to_one"
+	case RelationTypeOneToMany:
+		return "one_to_many"
+	case RelationTypeManyToMany:
+		return "many_to_many"
+	case RelationTypeRef:
+		return "ref"
+	case RelationTypePolymorphic:
+		return "polymorphic"
+	default:
+		return fmt.Sprintf("RelationType(%d)", int(t))
+	}
+}
+
+// MarshalText 序列化为关系类型名称
+func (t RelationType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText 从关系类型名称反序列化
+func (t *RelationType) UnmarshalText(text []byte) error {
+	for _, candidate := range []RelationType{RelationTypeOneToOne, RelationTypeOneToMany, RelationTypeManyToMany, RelationTypeRef, RelationTypePolymorphic} {
+		if candidate.String() == string(text) {
+			*t = candidate
+			return nil
+		}
+	}
+	return fmt.Errorf("未知的关系类型 %q", text)
+}
+
+// MarshalJSON 序列化聚合根元数据
+// IDField 和 PrimaryKey 指向 Fields 中的元素，只输出字段名，避免重复；table 为生效的表名（见 Table）
+func (a *AggregateMetadata) MarshalJSON() ([]byte, error) {
+	type alias AggregateMetadata
+	idField := ""
+	if a.IDField != nil {
+		idField = a.IDField.Name
+	}
+	var primaryKey []string
+	for _, field := range a.PrimaryKey {
+		primaryKey = append(primaryKey, field.Name)
+	}
+
+	return json.Marshal(&struct {
+		*alias
+		IDField    string   `json:"idField,omitempty"`
+		PrimaryKey []string `json:"primaryKey,omitempty"`
+		Table      string   `json:"table"`
+	}{
+		alias:      (*alias)(a),
+		IDField:    idField,
+		PrimaryKey: primaryKey,
+		Table:      a.Table(),
+	})
+}
+
+// UnmarshalJSON 反序列化聚合根元数据
+// 按字段名还原 IDField、PrimaryKey 和 BaseEntity 中的字段指针；AST 结构体和字段的源码位置不随 JSON 保存，加载后为空
+func (a *AggregateMetadata) UnmarshalJSON(data []byte) error {
+	type alias AggregateMetadata
+	aux := &struct {
+		*alias
+		IDField    string   `json:"idField"`
+		PrimaryKey []string `json:"primaryKey"`
+		Table      string   `json:"table"`
+	}{alias: (*alias)(a)}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+
+	if aux.IDField != "" {
+		if a.IDField = a.field(aux.IDField); a.IDField == nil {
+			return fmt.Errorf("聚合根 %s 的主键字段 %s 不存在", a.Name, aux.IDField)
+		}
+	}
+	a.PrimaryKey = nil
+	for _, name := range aux.PrimaryKey {
+		field := a.field(name)
+		if field == nil {
+			return fmt.Errorf("聚合根 %s 的主键字段 %s 不存在", a.Name, name)
+		}
+		a.PrimaryKey = append(a.PrimaryKey, field)
+	}
+
+	if base := a.BaseEntity; base != nil {
+		base.DeletedAtField = a.field("DeletedAt")
+		base.VersionField = a.field("Version")
+		base.CreatedAtField = a.field("CreatedAt")
+		base.UpdatedAtField = a.field("UpdatedAt")
+		base.CreatedByField = a.field("CreatedBy")
+		base.UpdatedByField = a.field("UpdatedBy")
+	}
+
+	// 非默认命名策略得到的表名按原样保留
+	if a.TableName == "" && aux.Table != "" && aux.Table != DefaultNamingStrategy().TableName(a.Name) {
+		a.Naming = fixedTableNaming{table: aux.Table}
+	}
+	return nil
+}
+
+// field 按名称查找聚合根的字段，不存在时返回 nil
+func (a *AggregateMetadata) field(name string) *FieldMetadata {
+	for _, field := range a.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// MarshalJSON 序列化关系元数据
+// Field、ForeignKey 指向聚合根的字段，只输出字段名，避免重复
+func (r *RelationMetadata) MarshalJSON() ([]byte, error) {
+	type alias RelationMetadata
+	foreignKey := ""
+	if r.ForeignKey != nil {
+		foreignKey = r.ForeignKey.Name
+	}
+	return json.Marshal(&struct {
+		*alias
+		Field      string `json:"field,omitempty"`
+		ForeignKey string `json:"foreignKey,omitempty"`
+	}{
+		alias:      (*alias)(r),
+		Field:      r.fieldName(),
+		ForeignKey: foreignKey,
+	})
+}
+
+// MarshalJSON 序列化注册表，内容与 Snapshot 相同，可通过 LoadFromJSON 重新加载
+func (r *AggregateMetadataRegistry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Snapshot())
+}
+
+// LoadFromJSON 从 MarshalJSON（或 CLI 的 -json）导出的 JSON 重建注册表
+//
+// 聚合根、关系、多对多关联表、枚举和领域事件按导出时的内容还原，关系中的 Field、ForeignKey 按字段名、
+// Inverse 按 inverseField 重新指向加载后的字段和关系，生成器和校验可以直接使用，无需重新解析源码。
+// AST 节点和源码位置不随 JSON 保存：加载后 AggregateMetadata.Struct、FieldMetadata.RawType 为 nil，
+// 聚合根、字段和注解节点的 Pos 无效，校验错误不带源码位置。
+// 旧版本 soliton 导出的 JSON 先按 MetadataVersion 迁移到当前格式，版本高于当前支持的版本时返回错误。
+func LoadFromJSON(data []byte) (*AggregateMetadataRegistry, error) {
+	var doc struct {
+		Aggregates []*AggregateMetadata `json:"aggregates"`
+		Relations  []*struct {
+			RelationMetadata
+			Field      string `json:"field"`
+			ForeignKey string `json:"foreignKey"`
+		} `json:"relations"`
+		ManyToManyTables []*ManyToManyTableMetadata `json:"manyToManyTables"`
+		Enums            []*EnumMetadata            `json:"enums"`
+		Events           []*EventMetadata           `json:"events"`
+	}
+	version, err := metadataVersion(data)
+	if err != nil {
+		return nil, fmt.Errorf("解析元数据 JSON 失败: %w", err)
+	}
+	if data, err = migrateMetadata(data, version); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析元数据 JSON 失败: %w", err)
+	}
+
+	upgradeAggregates(doc.Aggregates, version)
+
+	r := NewAggregateMetadataRegistry()
+	for _, agg := range doc.Aggregates {
+		r.Register(agg)
+	}
+
+	for _, item := range doc.Relations {
+		rel := &item.RelationMetadata
+		if item.Field != "" {
+			source := r.Get(rel.SourceAggregate)
+			if source == nil {
+				return nil, fmt.Errorf("关系 %s → %s 的源聚合根不存在", rel.SourceAggregate, rel.TargetAggregate)
+			}
+			if rel.Field = source.field(item.Field); rel.Field == nil {
+				return nil, fmt.Errorf("关系 %s → %s 的字段 %s.%s 不存在", rel.SourceAggregate, rel.TargetAggregate, source.Name, item.Field)
+			}
+		}
+		if item.ForeignKey != "" {
+			holder := r.Get(rel.TargetAggregate)
+			if rel.ForeignKeyOnSource() {
+				holder = r.Get(rel.SourceAggregate)
+			}
+			if holder != nil {
+				rel.ForeignKey = holder.field(item.ForeignKey)
+			}
+			if rel.ForeignKey == nil {
+				return nil, fmt.Errorf("关系 %s → %s 的外键字段 %s 不存在", rel.SourceAggregate, rel.TargetAggregate, item.ForeignKey)
+			}
+		}
+		r.AddRelation(rel)
+	}
+
+	// 双向关系：按另一侧的字段名关联
+	for _, rel := range r.relations {
+		if rel.InverseField == "" || rel.Inverse != nil {
+			continue
+		}
+		for _, other := range r.GetRelationsBetween(rel.TargetAggregate, rel.SourceAggregate) {
+			if other != rel && other.SourceAggregate == rel.TargetAggregate && other.fieldName() == rel.InverseField && other.InverseField == rel.fieldName() {
+				rel.Inverse, other.Inverse = other, rel
+				break
+			}
+		}
+	}
+
+	for _, table := range doc.ManyToManyTables {
+		r.AddManyToManyTable(table)
+	}
+
+	// 来自 const 块的枚举在 CollectEnums 重建枚举列表时保留
+	var declared []*EnumMetadata
+	for _, enum := range doc.Enums {
+		if enum.IsDeclared() {
+			declared = append(declared, enum)
+		}
+		r.AddEnum(enum)
+	}
+	r.SetDeclaredEnums(declared)
+
+	// 在聚合根上声明的事件由 CollectEvents 按聚合根注解重建，只保留结构体定义的事件
+	var declaredEvents []*EventMetadata
+	for _, event := range doc.Events {
+		if event.IsDeclared() {
+			declaredEvents = append(declaredEvents, event)
+		}
+	}
+	r.SetDeclaredEvents(declaredEvents)
+	r.CollectEvents()
+
+	return r, nil
+}
+
+// fieldName 获取关联字段名，没有关联字段时返回空字符串
+func (r *RelationMetadata) fieldName() string {
+	if r.Field == nil {
+		return ""
+	}
+	return r.Field.Name
+}
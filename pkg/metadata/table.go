@@ -0,0 +1,457 @@
+package metadata
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Schema 表结构元数据
+//
+// 由注册表按数据库方言计算一次（见 NewSchema），包含每张表的表名、列名、SQL 类型、可空性、默认值、索引和外键，
+// SQL 脚本、DO 的 GORM 标签、ER 图和元数据差异都从这里读取，不再各自推导。
+type Schema struct {
+	Dialect string           `json:"dialect"` // 方言名称，如 "mysql"
+	Tables  []*TableMetadata `json:"tables"`  // 聚合根表按聚合根名排序，其后是纯多对多关联表（按表名排序）
+
+	byAggregate map[string]*TableMetadata
+}
+
+// TableMetadata 一张表的结构
+type TableMetadata struct {
+	Name        string                `json:"name"`                  // 表名
+	Aggregate   string                `json:"aggregate,omitempty"`   // 对应的聚合根，纯多对多关联表为空
+	Context     string                `json:"context,omitempty"`     // 所属限界上下文，关联表跟随左侧聚合根
+	Comment     string                `json:"comment"`               // 表注释，如 "Order 表"
+	Columns     []*ColumnMetadata     `json:"columns"`               // 主键列在前，其余按字段声明顺序
+	PrimaryKey  []string              `json:"primaryKey"`            // 主键列名，复合主键按声明顺序
+	Indexes     []*TableIndexMetadata `json:"indexes,omitempty"`     // 唯一索引在前，普通索引在后
+	ForeignKeys []*ForeignKeyMetadata `json:"foreignKeys,omitempty"` // 外键约束
+}
+
+// ColumnMetadata 表中的一列
+type ColumnMetadata struct {
+	Name          string         `json:"name"`                    // 列名
+	FieldPath     string         `json:"fieldPath,omitempty"`     // 对应的字段路径，如 Amount、Address.City；关联表的列为空
+	Field         *FieldMetadata `json:"-"`                       // 对应的字段，展开的值对象为其中的字段；关联表的列为 nil
+	Type          string         `json:"type"`                    // SQL 类型，如 VARCHAR(255)
+	Nullable      bool           `json:"nullable"`                // 是否可空
+	Default       string         `json:"default,omitempty"`       // DEFAULT 子句的值，如 ''、0、NULL、CURRENT_TIMESTAMP，为空表示不设置
+	AutoIncrement bool           `json:"autoIncrement,omitempty"` // 是否自增（auto 策略的主键）
+	PrimaryKey    bool           `json:"primaryKey,omitempty"`    // 是否为主键列
+	ForeignKey    bool           `json:"foreignKey,omitempty"`    // 是否引用其他表：外部引用、多态关联 ID、一对多（一对一）关系的外键列
+	Comment       string         `json:"comment"`                 // 列注释
+}
+
+// TableIndexMetadata 表上的索引（不含主键）
+type TableIndexMetadata struct {
+	Name    string   `json:"name"`            // 索引名，如 uk_users_email、idx_orders_user_id
+	Columns []string `json:"columns"`         // 按顺序组成索引的列名
+	Unique  bool     `json:"unique"`          // 是否唯一索引
+	Where   string   `json:"where,omitempty"` // 部分索引的条件，如 deleted_at IS NULL
+}
+
+// ForeignKeyMetadata 外键约束，由级联关系（+soliton:cascade）产生
+type ForeignKeyMetadata struct {
+	Name      string `json:"name"`      // 约束名，如 fk_order_items_order_id
+	Column    string `json:"column"`    // 本表的外键列
+	RefTable  string `json:"refTable"`  // 引用的表
+	RefColumn string `json:"refColumn"` // 引用的列（聚合根主键）
+	OnDelete  string `json:"onDelete"`  // 删除行为：CASCADE、SET NULL、RESTRICT
+}
+
+// Table 返回聚合根对应的表，未注册时返回 nil
+func (s *Schema) Table(aggregateName string) *TableMetadata {
+	return s.byAggregate[aggregateName]
+}
+
+// ColumnOf 返回字段对应的列，字段未映射为列时返回 nil
+func (t *TableMetadata) ColumnOf(field *FieldMetadata) *ColumnMetadata {
+	for _, column := range t.Columns {
+		if column.Field == field {
+			return column
+		}
+	}
+	return nil
+}
+
+// IndexesOf 返回包含指定列的索引
+func (t *TableMetadata) IndexesOf(column string) []*TableIndexMetadata {
+	var result []*TableIndexMetadata
+	for _, index := range t.Indexes {
+		if slices.Contains(index.Columns, column) {
+			result = append(result, index)
+		}
+	}
+	return result
+}
+
+// IsUnique 判断列上是否有单列唯一索引
+func (t *TableMetadata) IsUnique(column string) bool {
+	for _, index := range t.Indexes {
+		if index.Unique && len(index.Columns) == 1 && index.Columns[0] == column {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSchema 按注册表和数据库方言计算表结构元数据，dialect 为 nil 时使用 DefaultDialect
+//
+// 聚合根表的列、索引和外键规则：
+//   - 主键列排在最前，auto 策略的主键自增；关联实体字段不映射为列，展开的值对象每个字段对应一列
+//   - 非指针的必填字段和 int、int64、float64 字段不可空；指针字段和值对象默认为 NULL，
+//     其余字符串默认为空字符串、数字默认为 0，声明了 +soliton:default 时使用声明的默认值
+//   - 唯一索引：+soliton:unique、组合唯一索引、中间实体两端的组合唯一索引、一对一关系的外键列；
+//     软删除的聚合根在支持部分索引的方言（PostgreSQL）中只约束未删除的记录（WHERE deleted_at IS NULL）
+//   - 普通索引：+soliton:index、外部引用、一对多关系的外键列、软删除列
+//   - 外键约束：其他聚合根将本表声明为级联关联实体时，按级联行为生成 ON DELETE 子句
+//
+// 纯多对多关联表（中间实体的表随聚合根生成）包含自增主键、两端的 ID 列和创建时间。
+func NewSchema(registry *AggregateMetadataRegistry, dialect Dialect) *Schema {
+	if dialect == nil {
+		dialect = DefaultDialect()
+	}
+	b := &schemaBuilder{
+		registry:      registry,
+		dialect:       dialect,
+		foreignKeys:   make(map[*FieldMetadata]bool),
+		oneToOneKeys:  make(map[*FieldMetadata]bool),
+		oneToManyKeys: make(map[*FieldMetadata]bool),
+	}
+	for _, rel := range registry.GetRelations() {
+		if rel.ForeignKey == nil {
+			continue
+		}
+		b.foreignKeys[rel.ForeignKey] = true
+		switch rel.Type {
+		case RelationTypeOneToOne:
+			b.oneToOneKeys[rel.ForeignKey] = true
+		case RelationTypeOneToMany:
+			b.oneToManyKeys[rel.ForeignKey] = true
+		}
+	}
+
+	schema := &Schema{
+		Dialect:     dialect.Name(),
+		byAggregate: make(map[string]*TableMetadata),
+	}
+	for _, agg := range registry.GetAll() {
+		table := b.aggregateTable(agg)
+		schema.Tables = append(schema.Tables, table)
+		schema.byAggregate[agg.Name] = table
+	}
+	for _, joinTable := range registry.GetManyToManyTables() {
+		if joinTable.Association != "" {
+			continue
+		}
+		schema.Tables = append(schema.Tables, b.joinTable(joinTable))
+	}
+	return schema
+}
+
+// schemaBuilder 计算表结构元数据时使用的关系索引
+type schemaBuilder struct {
+	registry      *AggregateMetadataRegistry
+	dialect       Dialect
+	foreignKeys   map[*FieldMetadata]bool // 任意关系的外键字段
+	oneToOneKeys  map[*FieldMetadata]bool // 一对一关系的外键字段（见 RelationMetadata.ForeignKeyOnSource）
+	oneToManyKeys map[*FieldMetadata]bool // 一对多关系中关联实体引用聚合根的外键字段
+}
+
+// aggregateTable 计算聚合根对应的表
+func (b *schemaBuilder) aggregateTable(agg *AggregateMetadata) *TableMetadata {
+	tableName := agg.Table()
+	table := &TableMetadata{
+		Name:      tableName,
+		Aggregate: agg.Name,
+		Context:   agg.Context(),
+		Comment:   agg.Name + " 表",
+	}
+
+	// 主键列（复合主键按声明顺序排在最前）
+	for _, field := range agg.PrimaryKey {
+		column := b.column(field, field.Name, false)
+		column.PrimaryKey = true
+		column.Nullable = false
+		column.AutoIncrement = agg.IDStrategy == IDStrategyAuto
+		column.Default = ""
+		table.Columns = append(table.Columns, column)
+		table.PrimaryKey = append(table.PrimaryKey, field.Column())
+	}
+
+	for _, field := range agg.MappedFields() {
+		if agg.InPrimaryKey(field) || field.Annotations.IsEntity {
+			continue
+		}
+		// 展开的值对象每个字段对应一列；指针值对象为 nil 时各列均为空，按可空列处理
+		if field.Annotations.IsValueObject && field.Annotations.Strategy == ValueObjectFlatten {
+			for _, sub := range field.Flattened {
+				table.Columns = append(table.Columns, b.column(sub, field.Name+"."+sub.Name, field.IsPointer))
+			}
+			continue
+		}
+		table.Columns = append(table.Columns, b.column(field, field.Name, false))
+	}
+
+	fields := indexedFields(agg)
+
+	// 注解声明的唯一索引：字段上的 +soliton:unique 在前，组合唯一索引在后（见 AggregateMetadata.CollectIndexes）
+	for _, index := range agg.Indexes {
+		if index.Unique {
+			table.addDeclaredIndex(index)
+		}
+	}
+
+	// 中间实体（+soliton:manyToMany）：关联两端的组合唯一索引，已声明相同的组合唯一索引时不重复生成
+	if left, right := agg.AssociationEnds(); left != nil && !hasUniqueIndex(agg, left.Name, right.Name) {
+		table.addIndex(fmt.Sprintf("uk_%s_%s_%s", tableName, left.Column(), right.Column()), true, left.Column(), right.Column())
+	}
+
+	// 一对一关系的外键列：每一行至多对应一个关联对象，已声明唯一约束时不重复生成
+	for _, field := range fields {
+		if b.oneToOneKeys[field] && !field.Annotations.IsUnique {
+			table.addIndex(fmt.Sprintf("uk_%s_%s", tableName, field.Column()), true, field.Column())
+		}
+	}
+
+	// 普通索引：按字段顺序生成字段上的 +soliton:index 和引用其他聚合根的外键列（包括一对多关系的外键列）的索引，
+	// 一对一的外键列已有唯一索引；聚合根上声明的组合索引在后
+	fieldIndexes := make(map[string]*IndexMetadata)
+	for _, index := range agg.Indexes {
+		if !index.Unique && len(index.Columns) == 1 && fieldIndexes[index.Columns[0]] == nil {
+			fieldIndexes[index.Columns[0]] = index
+		}
+	}
+	added := make(map[*IndexMetadata]bool)
+	for _, field := range fields {
+		index := fieldIndexes[field.Column()]
+		if b.oneToOneKeys[field] {
+			added[index] = true
+			continue
+		}
+		if index != nil {
+			table.addDeclaredIndex(index)
+			added[index] = true
+			continue
+		}
+		if field.Annotations.IsRef || b.oneToManyKeys[field] && !field.Annotations.IsUnique {
+			table.addIndex(fmt.Sprintf("idx_%s_%s", tableName, field.Column()), false, field.Column())
+		}
+	}
+	for _, index := range agg.Indexes {
+		if !index.Unique && !added[index] {
+			table.addDeclaredIndex(index)
+		}
+	}
+
+	// 软删除列索引
+	if agg.BaseEntity.HasDeletedAt {
+		column := agg.BaseEntity.DeletedAtField.Column()
+		// 支持部分索引的方言只对未删除的记录做唯一约束，已删除的记录不占用唯一值
+		if b.dialect.PartialIndexes() {
+			for _, index := range table.Indexes {
+				if index.Unique && index.Where == "" {
+					index.Where = column + " IS NULL"
+				}
+			}
+		}
+		table.addIndex(fmt.Sprintf("idx_%s_%s", tableName, column), false, column)
+	}
+
+	// 外键约束：其他聚合根将本表声明为级联关联实体
+	for _, rel := range b.registry.GetRelationsByTarget(agg.Name) {
+		if rel.Cascade == "" || rel.ForeignKey == nil {
+			continue
+		}
+		source := b.registry.Get(rel.SourceAggregate)
+		if source == nil || source.IDField == nil {
+			continue
+		}
+
+		onDelete := "CASCADE"
+		switch rel.Cascade {
+		case CascadeNullify:
+			onDelete = "SET NULL"
+		case CascadeRestrict:
+			onDelete = "RESTRICT"
+		}
+
+		column := rel.ForeignKey.Column()
+		table.ForeignKeys = append(table.ForeignKeys, &ForeignKeyMetadata{
+			Name:      fmt.Sprintf("fk_%s_%s", tableName, column),
+			Column:    column,
+			RefTable:  source.Table(),
+			RefColumn: source.IDField.Column(),
+			OnDelete:  onDelete,
+		})
+	}
+
+	return table
+}
+
+// column 计算字段对应的列，path 为列注释中的字段路径，optional 表示所在的值对象可以为 nil
+func (b *schemaBuilder) column(field *FieldMetadata, path string, optional bool) *ColumnMetadata {
+	annotations := field.Annotations
+	nullable := field.IsPointer || optional || annotations.IsValueObject ||
+		!annotations.IsRequired && field.Type != "int64" && field.Type != "int" && field.Type != "float64"
+
+	column := &ColumnMetadata{
+		Name:       field.Column(),
+		FieldPath:  path,
+		Field:      field,
+		Type:       b.dialect.ColumnType(field),
+		Nullable:   nullable,
+		ForeignKey: annotations.IsRef || field.IsPolymorphic() || b.foreignKeys[field],
+	}
+
+	// 默认值
+	if value, ok := b.dialect.DefaultValue(field); ok && !annotations.IsValueObject {
+		// 显式声明的默认值（+soliton:default）
+		column.Default = value
+	} else if field.IsPointer || optional || annotations.IsValueObject {
+		column.Default = "NULL"
+	} else if field.Type == "string" {
+		column.Default = "''"
+	} else if field.Type == "int64" || field.Type == "int" || field.Type == "float64" {
+		column.Default = "0"
+	}
+
+	// 注释
+	column.Comment = path
+	if annotations.IsUnique {
+		column.Comment += " (唯一)"
+	}
+	if annotations.IsRequired {
+		column.Comment += " (必填)"
+	}
+	if annotations.IsRef {
+		column.Comment += " (外键"
+		if target := field.RefAggregate(); target != "" {
+			column.Comment += ": " + target
+			if annotations.RefField != "" {
+				column.Comment += "." + annotations.RefField
+			}
+		}
+		column.Comment += ")"
+	}
+	if annotations.IsValueObject {
+		column.Comment += " (值对象-JSON)"
+	}
+	if annotations.Sensitive != "" {
+		column.Comment += fmt.Sprintf(" (敏感: %s)", annotations.Sensitive)
+	}
+
+	return column
+}
+
+// joinTable 计算纯多对多关联表
+func (b *schemaBuilder) joinTable(joinTable *ManyToManyTableMetadata) *TableMetadata {
+	context := ""
+	if left := b.registry.Get(joinTable.LeftAggregate); left != nil {
+		context = left.Context()
+	}
+
+	idType := b.dialect.ColumnType(&FieldMetadata{Type: "int64", Annotations: &FieldAnnotations{}})
+	createdAt := &FieldMetadata{Type: "time.Time", Annotations: &FieldAnnotations{Default: DefaultNow}}
+	createdAtDefault, _ := b.dialect.DefaultValue(createdAt)
+
+	name, left, right := joinTable.TableName, joinTable.LeftColumn, joinTable.RightColumn
+	table := &TableMetadata{
+		Name:    name,
+		Context: context,
+		Comment: fmt.Sprintf("%s 和 %s 关联表", joinTable.LeftAggregate, joinTable.RightAggregate),
+		Columns: []*ColumnMetadata{
+			{Name: "id", Type: idType, AutoIncrement: true, PrimaryKey: true, Comment: "主键"},
+			{Name: left, Type: idType, ForeignKey: true, Comment: joinTable.LeftAggregate + " ID"},
+			{Name: right, Type: idType, ForeignKey: true, Comment: joinTable.RightAggregate + " ID"},
+			{Name: "created_at", Type: b.dialect.ColumnType(createdAt), Default: createdAtDefault, Comment: "创建时间"},
+		},
+		PrimaryKey: []string{"id"},
+	}
+	table.addIndex(fmt.Sprintf("uk_%s_%s_%s", name, left, right), true, left, right)
+	table.addIndex(fmt.Sprintf("idx_%s_%s", name, left), false, left)
+	table.addIndex(fmt.Sprintf("idx_%s_%s", name, right), false, right)
+	return table
+}
+
+// OutboxTableName 事务性 outbox 表名，与 framework.OutboxTableName 一致
+const OutboxTableName = "outbox_messages"
+
+// OutboxTable 返回方言 dialect 下事务性 outbox 表的结构，列与 framework.OutboxMessage 对应
+func OutboxTable(dialect Dialect, context string) *TableMetadata {
+	columnType := func(goType string) string {
+		return dialect.ColumnType(&FieldMetadata{Type: goType, Annotations: &FieldAnnotations{}})
+	}
+	createdAt := &FieldMetadata{Type: "time.Time", Annotations: &FieldAnnotations{Default: DefaultNow}}
+	createdAtDefault, _ := dialect.DefaultValue(createdAt)
+	payload := dialect.ColumnType(&FieldMetadata{Type: "string", Annotations: &FieldAnnotations{IsValueObject: true}})
+
+	table := &TableMetadata{
+		Name:    OutboxTableName,
+		Context: context,
+		Comment: "领域事件 outbox 表",
+		Columns: []*ColumnMetadata{
+			{Name: "id", Type: columnType("int64"), AutoIncrement: true, PrimaryKey: true, Comment: "主键，即消息 ID"},
+			{Name: "topic", Type: columnType("string"), Comment: "消息主题"},
+			{Name: "event_name", Type: columnType("string"), Comment: "事件名"},
+			{Name: "aggregate_id", Type: columnType("string"), Comment: "聚合根 ID"},
+			{Name: "payload", Type: payload, Comment: "事件的 JSON 编码"},
+			{Name: "attempts", Type: columnType("int"), Default: "0", Comment: "发布失败的次数"},
+			{Name: "last_error", Type: "TEXT", Nullable: true, Comment: "最近一次发布失败的原因"},
+			{Name: "created_at", Type: columnType("time.Time"), Default: createdAtDefault, Comment: "创建时间"},
+			{Name: "published_at", Type: columnType("time.Time"), Nullable: true, Comment: "发布成功的时间，为空表示待发布"},
+		},
+		PrimaryKey: []string{"id"},
+	}
+	table.addIndex(fmt.Sprintf("idx_%s_published_at", OutboxTableName), false, "published_at", "id")
+	return table
+}
+
+// addIndex 添加索引
+func (t *TableMetadata) addIndex(name string, unique bool, columns ...string) {
+	t.Indexes = append(t.Indexes, &TableIndexMetadata{Name: name, Columns: columns, Unique: unique})
+}
+
+// addDeclaredIndex 添加注解声明的索引，引用的字段都不存在时跳过（由 RelationAnalyzer.ValidateIndexes 报告）
+func (t *TableMetadata) addDeclaredIndex(index *IndexMetadata) {
+	if len(index.Columns) == 0 {
+		return
+	}
+	t.Indexes = append(t.Indexes, &TableIndexMetadata{Name: index.Name, Columns: index.Columns, Unique: index.Unique, Where: index.Where})
+}
+
+// indexedFields 返回聚合根映射为列的字段，展开的值对象以其各字段代替，用于计算单列索引
+func indexedFields(agg *AggregateMetadata) []*FieldMetadata {
+	var fields []*FieldMetadata
+	for _, field := range agg.MappedFields() {
+		switch {
+		case field.Annotations.IsEntity:
+			continue
+		case field.Annotations.IsValueObject && field.Annotations.Strategy == ValueObjectFlatten:
+			fields = append(fields, field.Flattened...)
+		default:
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// hasUniqueIndex 判断聚合根是否已声明由指定字段（不计顺序）组成的组合唯一索引
+func hasUniqueIndex(agg *AggregateMetadata, fields ...string) bool {
+	for _, index := range agg.Indexes {
+		if len(index.Fields) != len(fields) {
+			continue
+		}
+		matched := true
+		for _, field := range fields {
+			if !slices.Contains(index.Fields, field) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MetadataVersion 导出的元数据 JSON 的格式版本，写在顶层的 version 中
+//
+// 格式发生不兼容的变化时递增，并在 metadataMigrations 中登记从上一版本升级的迁移，
+// 使缓存的元数据和 CI 中的基线（-metadata、-diff）在升级 soliton 后仍可加载：
+//   - 1：未标记版本的早期导出
+//   - 2：标记 version；+soliton:enum 的编码和说明拆分到 enumNames、enumLabels（枚举的 names、labels）中
+//   - 3：聚合根的 indexes 汇总字段上的 +soliton:unique、+soliton:index 和组合索引，并记录列名 columns
+const MetadataVersion = 3
+
+// metadataMigrations 元数据格式迁移，第 i 项将版本 i+1 的文档升级到版本 i+2
+// 为 nil 时文档结构不变，需要完整元数据的迁移在解码后由 upgradeAggregates 完成
+var metadataMigrations = []func(doc map[string]any) error{
+	migrateEnumItems, // 1 → 2
+	nil,              // 2 → 3，见 upgradeAggregates
+}
+
+// metadataVersion 返回元数据 JSON 的格式版本，未标记版本时为 1
+func metadataVersion(data []byte) (int, error) {
+	var header struct {
+		Version *int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return 0, err
+	}
+	if header.Version == nil {
+		return 1, nil
+	}
+	return *header.Version, nil
+}
+
+// migrateMetadata 将版本为 version 的元数据 JSON 升级到 MetadataVersion
+// 版本高于当前支持的版本时报错，提示升级 soliton；已是当前版本时原样返回
+func migrateMetadata(data []byte, version int) ([]byte, error) {
+	switch {
+	case version < 1:
+		return nil, fmt.Errorf("元数据格式版本 %d 无效", version)
+	case version > MetadataVersion:
+		return nil, fmt.Errorf("元数据格式版本 %d 高于当前支持的版本 %d，请升级 soliton 后再加载", version, MetadataVersion)
+	case version == MetadataVersion:
+		return data, nil
+	}
+
+	// 保留数字的原始写法，避免经过 float64 后精度或格式发生变化
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var doc map[string]any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	for ; version < MetadataVersion; version++ {
+		migrate := metadataMigrations[version-1]
+		if migrate == nil {
+			continue
+		}
+		if err := migrate(doc); err != nil {
+			return nil, fmt.Errorf("元数据从版本 %d 升级到 %d 失败: %w", version, version+1, err)
+		}
+	}
+	doc["version"] = MetadataVersion
+	return json.Marshal(doc)
+}
+
+// upgradeAggregates 对按当前格式解码的聚合根完成需要完整元数据的迁移，version 为迁移前的版本
+func upgradeAggregates(aggregates []*AggregateMetadata, version int) {
+	// 2 → 3：早期的 indexes 只有组合唯一索引，按字段注解重新汇总，并解析列名
+	if version < 3 {
+		for _, agg := range aggregates {
+			agg.CollectIndexes(agg.Indexes)
+		}
+	}
+}
+
+// migrateEnumItems 版本 1 → 2：早期版本把 +soliton:enum(1=ACTIVE:正常) 的枚举项原样保存为枚举值，
+// 拆分为编码、名称和说明，与当前的注解解析结果一致
+func migrateEnumItems(doc map[string]any) error {
+	var walk func(node any)
+	walk = func(node any) {
+		switch node := node.(type) {
+		case []any:
+			for _, item := range node {
+				walk(item)
+			}
+		case map[string]any:
+			if annotations, ok := node["annotations"].(map[string]any); ok {
+				splitEnumKeys(annotations, "enumValues", "enumNames", "enumLabels")
+			}
+			for _, value := range node {
+				walk(value)
+			}
+		}
+	}
+	walk(doc["aggregates"])
+
+	if enums, ok := doc["enums"].([]any); ok {
+		for _, enum := range enums {
+			if enum, ok := enum.(map[string]any); ok {
+				splitEnumKeys(enum, "values", "names", "labels")
+			}
+		}
+	}
+	return nil
+}
+
+// splitEnumKeys 拆分 object[valuesKey] 中带编码或说明的枚举项，写回值、名称和说明
+func splitEnumKeys(object map[string]any, valuesKey, namesKey, labelsKey string) {
+	list, ok := object[valuesKey].([]any)
+	if !ok {
+		return
+	}
+
+	items := make([]string, 0, len(list))
+	for _, item := range list {
+		text, ok := item.(string)
+		if !ok {
+			return
+		}
+		items = append(items, text)
+	}
+	if !strings.ContainsAny(strings.Join(items, ""), "=:") {
+		return
+	}
+
+	values, names, labels := SplitEnumItems(items)
+	object[valuesKey] = values
+	if names != nil {
+		object[namesKey] = names
+	}
+	if labels != nil {
+		object[labelsKey] = labels
+	}
+}
@@ -0,0 +1,234 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// Diagnostic 注解诊断信息，定位到文件、行和列
+type Diagnostic struct {
+	Pos     token.Position // 注解所在位置
+	Message string         // 问题描述
+	Unknown string         // 未知注解的名称，其他问题为空；严格模式下未知注解视为错误
+}
+
+// String 返回 file:line:column: message 格式的诊断信息
+func (d *Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s", d.Pos, d.Message)
+}
+
+// annotationArgs 注解对参数的要求
+type annotationArgs int
+
+const (
+	argsNone     annotationArgs = iota // 不接受参数，如 +soliton:unique
+	argsOptional                       // 参数可选，如 +soliton:ref、+soliton:ref(User)
+	argsRequired                       // 必须带参数，如 +soliton:enum(A,B)
+	argsRaw                            // 必须带参数，参数按原文取值而不拆分，如 +soliton:pattern(^[a-z]{2,8}$)
+)
+
+// knownAnnotations 支持的注解及其参数要求，新增注解时需同步登记
+var knownAnnotations = map[string]annotationArgs{
+	// 聚合根级别
+	"aggregate":   argsNone,
+	"baseEntity":  argsRequired,
+	"manyToMany":  argsOptional,
+	"table":       argsRequired,
+	"uniqueIndex": argsRequired,
+	"context":     argsRequired,
+	"event":       argsRequired,
+	"api":         argsOptional,
+	"query":       argsRequired,
+	// 字段级别
+	"ref":         argsOptional,
+	"unique":      argsOptional,
+	"required":    argsNone,
+	"entity":      argsNone,
+	"valueObject": argsOptional,
+	"index":       argsOptional,
+	"enum":        argsRequired,
+	"column":      argsRequired,
+	"id":          argsOptional,
+	"pk":          argsNone,
+	"validate":    argsRequired,
+	"length":      argsRequired,
+	"pattern":     argsRaw,
+	"email":       argsNone,
+	"ignore":      argsNone,
+	"immutable":   argsNone,
+	"sensitive":   argsOptional,
+	"default":     argsRaw,
+	"polymorphic": argsRequired,
+	"cascade":     argsRequired,
+	"fk":          argsRequired,
+	"owner":       argsNone,
+	"external":    argsNone,
+	"filter":      argsOptional,
+	// 方法级别
+	"command": argsOptional,
+}
+
+// annotationIssue 注解文本中的一个问题，offset 为相对文本起始的字节偏移
+type annotationIssue struct {
+	offset  int
+	message string
+	unknown string // 未知注解的名称
+}
+
+// checkAnnotations 检查文本中所有 +soliton: 注解的语法
+// 报告未知的注解名（附带拼写建议）、括号或引号不匹配、参数缺少值以及参数缺失或多余
+func (p *AnnotationParser) checkAnnotations(text string) []annotationIssue {
+	tokens, issues := scanAnnotations(text)
+	for _, tok := range tokens {
+		name := tok.node.Name
+		args, known := knownAnnotations[name]
+		if !known {
+			message := fmt.Sprintf("未知注解 +soliton:%s", name)
+			if suggestion := suggestAnnotation(name); suggestion != "" {
+				message += fmt.Sprintf("，是否为 +soliton:%s？", suggestion)
+			}
+			issues = append(issues, annotationIssue{offset: tok.offset, message: message, unknown: name})
+			continue
+		}
+
+		switch {
+		case tok.hasParens && args == argsNone:
+			issues = append(issues, annotationIssue{offset: tok.offset, message: fmt.Sprintf("注解 +soliton:%s 不接受参数", name)})
+		case tok.hasParens && (args == argsRequired || args == argsRaw) && tok.node.Raw == "":
+			issues = append(issues, annotationIssue{offset: tok.offset, message: fmt.Sprintf("注解 +soliton:%s 缺少参数", name)})
+		case !tok.hasParens && (args == argsRequired || args == argsRaw):
+			issues = append(issues, annotationIssue{offset: tok.offset, message: fmt.Sprintf("注解 +soliton:%s 缺少参数，格式为 +soliton:%s(...)", name, name)})
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].offset < issues[j].offset
+	})
+	return issues
+}
+
+// suggestAnnotation 返回与 name 最接近的已知注解名（忽略大小写，编辑距离不超过 2），没有时返回空
+func suggestAnnotation(name string) string {
+	best, bestDistance := "", 3
+	for known := range knownAnnotations {
+		distance := editDistance(strings.ToLower(name), strings.ToLower(known))
+		if distance < bestDistance || (distance == bestDistance && known < best) {
+			best, bestDistance = known, distance
+		}
+	}
+	return best
+}
+
+// editDistance 计算两个字符串的编辑距离
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// diagnoseFile 检查文件中注释和结构体标签里的注解语法，结果追加到 p.diagnostics
+//
+// 与 ExtractCommentAnnotations 一致，注释中只检查以 +soliton: 开头的行。
+func (p *ASTParser) diagnoseFile(file *ast.File) {
+	report := func(base token.Pos, issues []annotationIssue) {
+		for _, issue := range issues {
+			// 允许列表中的注解由其他工具处理，不视为未知注解
+			if issue.unknown != "" && p.allowedAnnotations[issue.unknown] {
+				continue
+			}
+			p.diagnostics = append(p.diagnostics, &Diagnostic{
+				Pos:     p.fset.Position(base + token.Pos(issue.offset)),
+				Message: issue.message,
+				Unknown: issue.unknown,
+			})
+		}
+	}
+
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			lineStart := 0
+			for _, line := range strings.SplitAfter(comment.Text, "\n") {
+				trimmed := strings.TrimLeft(line, " \t/*")
+				if strings.HasPrefix(trimmed, "+soliton:") {
+					offset := lineStart + len(line) - len(trimmed)
+					report(comment.Slash+token.Pos(offset), p.annotationParser.checkAnnotations(strings.TrimRight(trimmed, "\r\n")))
+				}
+				lineStart += len(line)
+			}
+		}
+	}
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		if field, ok := node.(*ast.Field); ok && field.Tag != nil {
+			report(field.Tag.ValuePos, p.annotationParser.checkAnnotations(field.Tag.Value))
+		}
+		return true
+	})
+}
+
+// Diagnostics 返回解析过程中发现的注解语法问题（按文件和位置排序）
+//
+// 有问题的注解在解析时会被忽略，调用方应提示用户，或在校验模式下以非零状态退出。
+func (p *ASTParser) Diagnostics() []*Diagnostic {
+	diagnostics := append([]*Diagnostic(nil), p.diagnostics...)
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i].Pos, diagnostics[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Offset < b.Offset
+	})
+	return diagnostics
+}
+
+// SetStrict 设置严格模式
+//
+// 默认情况下未知注解（多为拼写错误）只作为诊断信息提示，解析时被忽略，可能导致索引或校验规则悄悄缺失；
+// 严格模式下解析发现未知注解时返回错误。其他工具使用的注解可通过 SetAllowedAnnotations 放行。
+func (p *ASTParser) SetStrict(enabled bool) {
+	p.strict = enabled
+}
+
+// SetAllowedAnnotations 设置允许的自定义注解名（不含 +soliton: 前缀），这些注解不报告为未知注解
+func (p *ASTParser) SetAllowedAnnotations(names []string) {
+	p.allowedAnnotations = make(map[string]bool, len(names))
+	for _, name := range names {
+		p.allowedAnnotations[strings.TrimPrefix(name, annotationPrefix)] = true
+	}
+}
+
+// checkStrict 严格模式下存在未知注解时返回错误，错误中列出每个未知注解的位置
+func (p *ASTParser) checkStrict() error {
+	if !p.strict {
+		return nil
+	}
+
+	var unknown []string
+	for _, diagnostic := range p.Diagnostics() {
+		if diagnostic.Unknown != "" {
+			unknown = append(unknown, diagnostic.String())
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	return fmt.Errorf("严格模式下发现 %d 个未知注解（其他工具使用的注解可加入允许列表）:\n  - %s",
+		len(unknown), strings.Join(unknown, "\n  - "))
+}
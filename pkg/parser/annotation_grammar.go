@@ -0,0 +1,196 @@
+package parser
+
+import (
+	"soliton/pkg/metadata"
+	"strings"
+)
+
+// annotationPrefix 注解前缀
+const annotationPrefix = "+soliton:"
+
+// annotationToken 扫描得到的一个注解
+type annotationToken struct {
+	node      *metadata.AnnotationNode
+	offset    int  // 注解（+soliton:）相对文本起始的字节偏移
+	hasParens bool // 是否带括号，+soliton:id() 带括号但参数为空
+}
+
+// scanAnnotations 扫描文本中的全部 +soliton 注解
+//
+// 语法：+soliton:name 或 +soliton:name(参数列表)。括号按配对截取，括号内的文本原样保存在 Raw 中，
+// 再按 parseAnnotationArgs 拆分为参数（按原文取值的注解如 pattern 不拆分）。缺少名称或括号不匹配的注解不产生节点，只报告问题。
+func scanAnnotations(text string) ([]*annotationToken, []annotationIssue) {
+	var tokens []*annotationToken
+	var issues []annotationIssue
+
+	for offset := 0; ; {
+		idx := strings.Index(text[offset:], annotationPrefix)
+		if idx < 0 {
+			break
+		}
+		start := offset + idx
+		nameStart := start + len(annotationPrefix)
+
+		nameEnd := nameStart
+		for nameEnd < len(text) && isIdentByte(text[nameEnd]) {
+			nameEnd++
+		}
+		name := text[nameStart:nameEnd]
+		offset = nameEnd
+
+		if name == "" {
+			issues = append(issues, annotationIssue{offset: start, message: "注解缺少名称"})
+			continue
+		}
+
+		tok := &annotationToken{node: &metadata.AnnotationNode{Name: name}, offset: start}
+		if nameEnd < len(text) && text[nameEnd] == '(' {
+			inner, ok := extractBalanced(text[nameEnd+1:])
+			if !ok {
+				issues = append(issues, annotationIssue{offset: start, message: "注解 +soliton:" + name + " 的括号不匹配"})
+				continue
+			}
+			offset = nameEnd + 1 + len(inner) + 1
+
+			tok.hasParens = true
+			tok.node.Raw = strings.TrimSpace(inner)
+			if knownAnnotations[name] != argsRaw {
+				args, problems := parseAnnotationArgs(inner)
+				for _, problem := range problems {
+					issues = append(issues, annotationIssue{offset: start, message: "注解 +soliton:" + name + " " + problem})
+				}
+				tok.node.Args = args
+			}
+		}
+		tokens = append(tokens, tok)
+	}
+
+	return tokens, issues
+}
+
+// parseAnnotationArgs 将括号内的文本拆分为参数，返回参数和语法问题
+//
+// 参数以逗号分隔，括号、方括号、花括号和双引号内的逗号不作为分隔符；
+// 形如 key=value 的参数记录 key，其余为不带 key 的参数。
+// 不带 key 的参数紧跟在带 key 的参数之后时并入其值，如 fields=TenantID,Email。
+func parseAnnotationArgs(text string) ([]*metadata.AnnotationArg, []string) {
+	var parts []string
+	var problems []string
+	depth, start, inQuote := 0, 0, false
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case inQuote:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inQuote = false
+			}
+		case c == '"':
+			inQuote = true
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case c == ')' || c == ']' || c == '}':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, text[start:i])
+			start = i + 1
+		}
+	}
+	parts = append(parts, text[start:])
+	if inQuote {
+		problems = append(problems, "的参数引号不匹配")
+	}
+
+	var args []*metadata.AnnotationArg
+	var lastKeyed *metadata.AnnotationArg
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		key, value, ok := cutAnnotationKey(part)
+		switch {
+		case ok:
+			if value == "" {
+				problems = append(problems, "的参数 "+key+" 缺少值")
+			}
+			lastKeyed = &metadata.AnnotationArg{Key: key, Value: unquoteAnnotationValue(value)}
+			args = append(args, lastKeyed)
+		case lastKeyed != nil:
+			lastKeyed.Value += "," + unquoteAnnotationValue(part)
+		default:
+			args = append(args, &metadata.AnnotationArg{Value: unquoteAnnotationValue(part)})
+		}
+	}
+	return args, problems
+}
+
+// cutAnnotationKey 拆分 key=value 形式的参数，key 必须是标识符；== 不视为赋值
+func cutAnnotationKey(part string) (key, value string, ok bool) {
+	i := strings.IndexByte(part, '=')
+	if i <= 0 || i+1 < len(part) && part[i+1] == '=' {
+		return "", "", false
+	}
+	key = strings.TrimSpace(part[:i])
+	if !isAnnotationIdent(key) {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(part[i+1:]), true
+}
+
+// unquoteAnnotationValue 去掉参数值两侧的双引号，引号内的转义字符保持原样（正则表达式依赖反斜杠）
+func unquoteAnnotationValue(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// extractBalanced 截取左括号之后、与之配对的右括号之前的文本
+// 输入为左括号之后的文本；转义字符和正则字符类 [...] 中的括号不参与配对，注解不能跨行
+func extractBalanced(text string) (string, bool) {
+	depth := 1
+	inClass := false
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '\n':
+			return "", false
+		case c == '\\':
+			i++
+		case inClass:
+			if c == ']' {
+				inClass = false
+			}
+		case c == '[':
+			inClass = true
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return text[:i], true
+			}
+		}
+	}
+	return "", false
+}
+
+// isAnnotationIdent 判断是否为注解名或参数名这样的标识符（Go 关键字如 type 也可以作为参数名）
+func isAnnotationIdent(s string) bool {
+	if s == "" || '0' <= s[0] && s[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isIdentByte(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isIdentByte 判断字节是否可以出现在注解名中
+func isIdentByte(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
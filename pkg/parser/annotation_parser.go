@@ -2,75 +2,110 @@ package parser
 
 import (
 	"regexp"
+	"slices"
+	"soliton/pkg/metadata"
+	"strconv"
 	"strings"
 )
 
 // AnnotationParser 注解解析器
+//
+// 注解统一由 scanAnnotations 解析为 metadata.AnnotationNode，各 ParseXxx 方法从节点中读取对应的参数。
 type AnnotationParser struct {
-	aggregatePattern   *regexp.Regexp
-	baseEntityPattern  *regexp.Regexp
-	manyToManyPattern  *regexp.Regexp
-	refPattern         *regexp.Regexp
-	uniquePattern      *regexp.Regexp
-	requiredPattern    *regexp.Regexp
-	entityPattern      *regexp.Regexp
-	valueObjectPattern *regexp.Regexp
-	indexPattern       *regexp.Regexp
-	enumPattern        *regexp.Regexp
-	dbTagPattern       *regexp.Regexp
+	dbTagPattern   *regexp.Regexp
+	jsonTagPattern *regexp.Regexp
 }
 
 // NewAnnotationParser 创建注解解析器
 func NewAnnotationParser() *AnnotationParser {
 	return &AnnotationParser{
-		aggregatePattern:   regexp.MustCompile(`\+soliton:aggregate`),
-		baseEntityPattern:  regexp.MustCompile(`\+soliton:baseEntity\((\w+)\)`),
-		manyToManyPattern:  regexp.MustCompile(`\+soliton:manyToMany`),
-		refPattern:         regexp.MustCompile(`\+soliton:ref(?:\((\w+)\))?`),
-		uniquePattern:      regexp.MustCompile(`\+soliton:unique`),
-		requiredPattern:    regexp.MustCompile(`\+soliton:required`),
-		entityPattern:      regexp.MustCompile(`\+soliton:entity`),
-		valueObjectPattern: regexp.MustCompile(`\+soliton:valueObject(?:\(strategy=(\w+)\))?`),
-		indexPattern:       regexp.MustCompile(`\+soliton:index`),
-		enumPattern:        regexp.MustCompile(`\+soliton:enum\((.*?)\)`),
-		dbTagPattern:       regexp.MustCompile(`db:"([^"]+)"`),
+		dbTagPattern:   regexp.MustCompile(`db:"([^"]+)"`),
+		jsonTagPattern: regexp.MustCompile(`(?:^|\s)json:"([^",]*)`),
 	}
 }
 
-// ParseAggregateAnnotations 解析聚合根级别注解
-// 输入：注释文本列表（可能包含多行注释）
-// 返回：是否为聚合根、基础实体名称、是否为多对多、引用列表
-func (p *AnnotationParser) ParseAggregateAnnotations(comments []string) (isAggregate bool, baseEntity string, isManyToMany bool, refs []string) {
-	for _, comment := range comments {
-		// 去除注释前缀 //
-		text := strings.TrimSpace(strings.TrimPrefix(comment, "//"))
+// ParseAnnotations 解析文本中的全部注解
+// 输入：字段标签或注释文本，如 `db:"email" +soliton:unique(name=uk_email)`
+// 返回：按声明顺序排列的注解节点，语法有误的注解被跳过（由诊断报告）
+func (p *AnnotationParser) ParseAnnotations(text string) metadata.AnnotationList {
+	tokens, _ := scanAnnotations(text)
+	nodes := make(metadata.AnnotationList, 0, len(tokens))
+	for _, tok := range tokens {
+		nodes = append(nodes, tok.node)
+	}
+	return nodes
+}
+
+// ParseCommentAnnotations 逐行解析聚合根注释中的注解
+// 输入：注释文本列表，行注释（// +soliton:table(name=orders_v2)）和块注释（/* ... */）均可，
+// 一行中可以写多个注解，如 "// +soliton:aggregate +soliton:context(ordering)"
+func (p *AnnotationParser) ParseCommentAnnotations(comments []string) metadata.AnnotationList {
+	var nodes metadata.AnnotationList
+	for _, line := range normalizeComments(comments) {
+		nodes = append(nodes, p.ParseAnnotations(line)...)
+	}
+	return nodes
+}
 
-		// 检查是否为聚合根
-		if p.aggregatePattern.MatchString(text) {
-			isAggregate = true
+// normalizeComments 将注释规范化为逐行文本
+//
+// 去掉行注释的 //、块注释的 /* 和 */ 以及块注释中每行开头的 *，块注释按换行拆分为多行，
+// 结果去掉首尾空白并跳过空行。已经规范化的文本原样返回，因此可以重复调用。
+func normalizeComments(comments []string) []string {
+	var lines []string
+	for _, comment := range comments {
+		text := strings.TrimSpace(comment)
+		switch {
+		case strings.HasPrefix(text, "//"):
+			text = strings.TrimPrefix(text, "//")
+		case strings.HasPrefix(text, "/*"):
+			text = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
 		}
 
-		// 检查基础实体
-		if matches := p.baseEntityPattern.FindStringSubmatch(text); len(matches) > 1 {
-			baseEntity = matches[1]
+		for _, line := range strings.Split(text, "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "*") && !strings.HasPrefix(line, "*/") {
+				line = strings.TrimSpace(strings.TrimPrefix(line, "*"))
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
 		}
+	}
+	return lines
+}
+
+// ParseAggregateAnnotations 解析聚合根级别注解
+// 输入：注释文本列表（可能包含多行注释）
+// 返回：是否为聚合根、基础实体名称、是否为多对多、引用列表
+func (p *AnnotationParser) ParseAggregateAnnotations(comments []string) (isAggregate bool, baseEntity string, isManyToMany bool, refs []string) {
+	nodes := p.ParseCommentAnnotations(comments)
 
-		// 检查是否为多对多
-		if p.manyToManyPattern.MatchString(text) {
+	isAggregate = nodes.Has("aggregate")
+	// 带参数的 +soliton:manyToMany 是关联表配置（见 ParseJoinTableAnnotations），不表示中间实体
+	for _, node := range nodes.All("manyToMany") {
+		if len(node.Args) == 0 {
 			isManyToMany = true
 		}
-
-		// 检查引用
-		if matches := p.refPattern.FindStringSubmatch(text); len(matches) > 0 {
-			if len(matches) > 1 && matches[1] != "" {
-				refs = append(refs, matches[1])
-			}
+	}
+	if node := nodes.Get("baseEntity"); node != nil {
+		baseEntity = node.Option("name")
+	}
+	for _, node := range nodes.All("ref") {
+		if target := node.Option("name"); target != "" {
+			refs = append(refs, target)
 		}
 	}
 
 	return
 }
 
+// ParseEntityMarker 解析聚合根上的 +soliton:entity 标记
+// 标记的结构体是聚合内的关联实体，应被其他聚合根的 +soliton:entity 字段包含，见 RelationAnalyzer.ValidateReachability
+func (p *AnnotationParser) ParseEntityMarker(comments []string) bool {
+	return p.ParseCommentAnnotations(comments).Has("entity")
+}
+
 // ParseFieldAnnotations 解析字段级别注解
 // 输入：字段标签（如 `db:"id" +soliton:unique`）
 // 返回：是否唯一、是否引用、是否必填、是否实体、是否值对象、是否索引、枚举值、策略
@@ -84,53 +119,429 @@ func (p *AnnotationParser) ParseFieldAnnotations(tag string) (
 	enumValues []string,
 	strategy string,
 ) {
-	// 检查唯一
-	if p.uniquePattern.MatchString(tag) {
-		isUnique = true
+	nodes := p.ParseAnnotations(tag)
+
+	isUnique = nodes.Has("unique")
+	isRef = nodes.Has("ref")
+	isRequired = nodes.Has("required")
+	isEntity = nodes.Has("entity")
+	isIndex = nodes.Has("index")
+
+	// 值对象
+	if node := nodes.Get("valueObject"); node != nil {
+		isValueObject = true
+		strategy = node.Option("strategy")
 	}
 
-	// 检查引用
-	if p.refPattern.MatchString(tag) {
-		isRef = true
+	// 枚举：编码和说明见 ParseEnumAnnotation
+	if node := nodes.Get("enum"); node != nil {
+		enumValues, _, _ = parseEnumItems(node)
 	}
 
-	// 检查必填
-	if p.requiredPattern.MatchString(tag) {
-		isRequired = true
+	return
+}
+
+// ParseEnumAnnotation 解析枚举注解的编码和说明
+// 输入：字段注解文本，如 `+soliton:enum(1=ACTIVE,2=BANNED)`、`+soliton:enum(ACTIVE:正常,BANNED:封禁)`，两种写法可以组合为 1=ACTIVE:正常
+// 返回：枚举值（整数枚举为编码）、与枚举值一一对应的名称（只有声明了编码时返回）和说明（只有声明了说明时返回）
+func (p *AnnotationParser) ParseEnumAnnotation(text string) (values, names, labels []string) {
+	if node := p.ParseAnnotations(text).Get("enum"); node != nil {
+		return parseEnumItems(node)
 	}
+	return nil, nil, nil
+}
 
-	// 检查实体
-	if p.entityPattern.MatchString(tag) {
-		isEntity = true
+// parseEnumItems 拆分枚举注解的参数
+// 参数按逗号拆分为枚举项，整体加引号的写法 enum("A,B") 同样拆分，枚举项的写法见 metadata.SplitEnumItems；
+// 未声明编码的项以名称作为值，由分析器报告与整数编码混用的问题
+func parseEnumItems(node *metadata.AnnotationNode) (values, names, labels []string) {
+	var items []string
+	for _, arg := range node.Args {
+		for _, item := range strings.Split(arg.Value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
 	}
+	return metadata.SplitEnumItems(items)
+}
 
-	// 检查值对象
-	if matches := p.valueObjectPattern.FindStringSubmatch(tag); len(matches) > 0 {
-		isValueObject = true
-		if len(matches) > 1 && matches[1] != "" {
-			strategy = matches[1]
+// ParseUniqueAnnotation 解析唯一约束的名称
+// 输入：字段注解文本，如 `+soliton:unique(name=uk_user_email)`
+// 返回：唯一约束名，未声明时为空（生成时使用 uk_{表名}_{列名}）
+func (p *AnnotationParser) ParseUniqueAnnotation(text string) string {
+	if node := p.ParseAnnotations(text).Get("unique"); node != nil {
+		return node.Option("name")
+	}
+	return ""
+}
+
+// ExtractCommentAnnotations 从字段注释中提取注解文本
+// 输入：字段上方或行尾的注释行，如 "// +soliton:unique +soliton:required"
+// 返回：注解文本（每行一条），可与标签拼接后交给 ParseFieldAnnotations 解析
+//
+// 只识别去除注释符后以 +soliton: 开头的行，普通说明文字中提到的注解不会生效；
+// 块注释按行处理，一行中可以写多个注解。
+func (p *AnnotationParser) ExtractCommentAnnotations(comments []string) string {
+	var annotations []string
+	for _, line := range normalizeComments(comments) {
+		if strings.HasPrefix(line, annotationPrefix) {
+			annotations = append(annotations, line)
 		}
 	}
+	return strings.Join(annotations, "\n")
+}
 
-	// 检查索引
-	if p.indexPattern.MatchString(tag) {
-		isIndex = true
+// ParseTableAnnotation 解析自定义表名注解
+// 输入：聚合根注释文本列表，如 "// +soliton:table(name=orders_v2)"
+// 返回：表名，未设置时为空
+func (p *AnnotationParser) ParseTableAnnotation(comments []string) string {
+	if node := p.ParseCommentAnnotations(comments).Get("table"); node != nil {
+		return node.Option("name")
 	}
+	return ""
+}
 
-	// 检查枚举
-	if matches := p.enumPattern.FindStringSubmatch(tag); len(matches) > 1 {
-		enumStr := matches[1]
-		// 去除引号并分割
-		enumStr = strings.Trim(enumStr, `"`)
-		for _, value := range strings.Split(enumStr, ",") {
-			value = strings.TrimSpace(value)
-			if value != "" {
-				enumValues = append(enumValues, value)
-			}
+// ParseContextAnnotation 解析限界上下文注解
+// 输入：聚合根注释文本列表，如 "// +soliton:context(ordering)"
+// 返回：上下文名称，未设置时为空
+func (p *AnnotationParser) ParseContextAnnotation(comments []string) string {
+	if node := p.ParseCommentAnnotations(comments).Get("context"); node != nil {
+		return node.Option("name")
+	}
+	return ""
+}
+
+// ParseAPIAnnotation 解析聚合根上的 API 暴露注解
+// 输入：聚合根注释文本列表，如 "// +soliton:api(rest, path=/orders, ops=create,get,list)"、"// +soliton:api(exclude=delete)"
+// 返回：API 元数据，未声明时为 nil；不带协议时只暴露 REST 接口。协议和操作名是否有效由 RelationAnalyzer.ValidateAPIs 校验
+func (p *AnnotationParser) ParseAPIAnnotation(comments []string) *metadata.APIMetadata {
+	node := p.ParseCommentAnnotations(comments).Get("api")
+	if node == nil {
+		return nil
+	}
+
+	api := &metadata.APIMetadata{
+		Protocols: node.Positional(),
+		Path:      node.Arg("path"),
+		Ops:       node.List("ops"),
+		Exclude:   node.List("exclude"),
+	}
+	if len(api.Protocols) == 0 {
+		api.Protocols = []string{metadata.APIProtocolREST}
+	}
+	return api
+}
+
+// ParseQueryAnnotations 解析聚合根上声明的查询
+// 输入：聚合根注释文本列表，如 "// +soliton:query(name=ListActiveOrders, by=Status,CreatedAt)"，可以声明多次；
+// 只有查询名时可简写为 +soliton:query(ListAll)
+// 返回：查询元数据列表，按声明顺序排列
+func (p *AnnotationParser) ParseQueryAnnotations(comments []string) []*metadata.QueryMetadata {
+	var queries []*metadata.QueryMetadata
+	for _, node := range p.ParseCommentAnnotations(comments).All("query") {
+		queries = append(queries, &metadata.QueryMetadata{
+			Name:    node.Option("name"),
+			By:      node.List("by"),
+			Select:  node.List("select"),
+			OrderBy: node.List("orderBy"),
+			Comment: node.Arg("comment"),
+		})
+	}
+	return queries
+}
+
+// ParseEventAnnotations 解析聚合根上声明的领域事件
+// 输入：聚合根注释文本列表，如 "// +soliton:event(OrderPlaced, OrderCancelled)"，可以声明多次
+// 返回：事件名列表，按声明顺序排列；带 key 的参数（专用结构体的写法）被忽略
+func (p *AnnotationParser) ParseEventAnnotations(comments []string) []string {
+	var events []string
+	for _, node := range p.ParseCommentAnnotations(comments).All("event") {
+		events = append(events, node.Positional()...)
+	}
+	return events
+}
+
+// ParseEventStructAnnotation 解析专用事件结构体上的事件注解
+// 输入：结构体注释文本列表，如 "// +soliton:event(aggregate=Order, topic=order.placed)"
+// 返回：是否为事件结构体、发布事件的聚合根、自定义主题（未声明时为空）
+func (p *AnnotationParser) ParseEventStructAnnotation(comments []string) (isEvent bool, aggregate string, topic string) {
+	if node := p.ParseCommentAnnotations(comments).Get("event"); node != nil {
+		return true, node.Arg("aggregate"), node.Arg("topic")
+	}
+	return false, "", ""
+}
+
+// ParseCommandAnnotation 解析方法上的命令注解
+// 输入：方法注释文本列表，如 "// +soliton:command"、"// +soliton:command(name=PlaceOrder)"
+// 返回：是否为命令、对外暴露的操作名（未声明时为空）
+func (p *AnnotationParser) ParseCommandAnnotation(comments []string) (isCommand bool, name string) {
+	if node := p.ParseCommentAnnotations(comments).Get("command"); node != nil {
+		return true, node.Option("name")
+	}
+	return false, ""
+}
+
+// ParseIndexAnnotations 解析聚合根级别的组合索引注解
+// 输入：聚合根注释文本列表，如 "// +soliton:uniqueIndex(name=uk_user_email, fields=TenantID,Email)"、
+// "// +soliton:index(fields=Status,CreatedAt, where=deleted_at IS NULL)"
+// 返回：按声明顺序排列的索引元数据列表，未指定名称的索引 Name 为空，由 AggregateMetadata.CollectIndexes 补全
+func (p *AnnotationParser) ParseIndexAnnotations(comments []string) []*metadata.IndexMetadata {
+	var indexes []*metadata.IndexMetadata
+	for _, node := range p.ParseCommentAnnotations(comments) {
+		if node.Name != "uniqueIndex" && node.Name != "index" {
+			continue
 		}
+		indexes = append(indexes, &metadata.IndexMetadata{
+			Name:   node.Arg("name"),
+			Fields: node.List("fields"),
+			Unique: node.Name == "uniqueIndex",
+			Where:  node.Arg("where"),
+		})
 	}
+	return indexes
+}
 
-	return
+// ParseJoinTableAnnotations 解析多对多关联表配置注解
+// 输入：聚合根注释文本列表，如 "// +soliton:manyToMany(table=user_roles, left=uid, right=rid)"、
+// "// +soliton:manyToMany(with=Role, table=user_roles)"
+// 返回：关联表配置列表，不带参数的 +soliton:manyToMany（中间实体）不包含在内
+func (p *AnnotationParser) ParseJoinTableAnnotations(comments []string) []*metadata.JoinTableMetadata {
+	var tables []*metadata.JoinTableMetadata
+	for _, node := range p.ParseCommentAnnotations(comments).All("manyToMany") {
+		if len(node.Args) == 0 {
+			continue
+		}
+		tables = append(tables, &metadata.JoinTableMetadata{
+			With:  node.Arg("with"),
+			Table: node.Arg("table"),
+			Left:  node.Arg("left"),
+			Right: node.Arg("right"),
+		})
+	}
+	return tables
+}
+
+// ParseIDAnnotation 解析主键注解
+// 输入：字段注解文本，如 `+soliton:id(strategy=snowflake)`、`+soliton:id(uuid)`、`+soliton:id`
+// 返回：是否标记为主键、声明的策略（未声明时为空）
+func (p *AnnotationParser) ParseIDAnnotation(text string) (isID bool, strategy string) {
+	node := p.ParseAnnotations(text).Get("id")
+	if node == nil {
+		return false, ""
+	}
+	return true, strings.ToLower(node.Option("strategy"))
+}
+
+// ParsePKAnnotation 解析复合主键注解
+// 输入：字段注解文本，如 `+soliton:pk`
+// 返回：字段是否为主键的组成部分
+func (p *AnnotationParser) ParsePKAnnotation(text string) bool {
+	return p.ParseAnnotations(text).Has("pk")
+}
+
+// ParseIgnoreAnnotation 解析忽略注解
+// 输入：字段注解文本，如 `+soliton:ignore`
+// 返回：字段是否被忽略（不映射为列，不参与校验和关系分析）
+func (p *AnnotationParser) ParseIgnoreAnnotation(text string) bool {
+	return p.ParseAnnotations(text).Has("ignore")
+}
+
+// ParseImmutableAnnotation 解析不可变注解
+// 输入：字段注解文本，如 `+soliton:immutable`
+// 返回：字段是否只在创建时写入（更新时不覆盖）
+func (p *AnnotationParser) ParseImmutableAnnotation(text string) bool {
+	return p.ParseAnnotations(text).Has("immutable")
+}
+
+// ParseFilterAnnotation 解析查询条件注解
+// 输入：字段注解文本，如 `+soliton:filter`、`+soliton:filter(sort)`
+// 返回：字段是否可作为 {Aggregate}Filter 的查询条件，以及是否同时可作为排序字段
+func (p *AnnotationParser) ParseFilterAnnotation(text string) (filter bool, sortable bool) {
+	node := p.ParseAnnotations(text).Get("filter")
+	if node == nil {
+		return false, false
+	}
+	return true, slices.Contains(node.Positional(), "sort")
+}
+
+// ParseSensitiveAnnotation 解析敏感字段注解
+// 输入：字段注解文本，如 `+soliton:sensitive(strategy=mask)`、`+soliton:sensitive(aes)`、`+soliton:sensitive`
+// 返回：小写的策略名，未声明策略时为 metadata.SensitiveAES；未标记时为空
+func (p *AnnotationParser) ParseSensitiveAnnotation(text string) string {
+	node := p.ParseAnnotations(text).Get("sensitive")
+	if node == nil {
+		return ""
+	}
+	if strategy := node.Option("strategy"); strategy != "" {
+		return strings.ToLower(strategy)
+	}
+	return metadata.SensitiveAES
+}
+
+// ParseDefaultAnnotation 解析默认值注解
+// 输入：字段注解文本，如 `+soliton:default(PENDING)`、`+soliton:default("in progress")`、`+soliton:default(now())`
+// 返回：去掉引号的默认值，now() 统一为 metadata.DefaultNow；未声明时为空
+func (p *AnnotationParser) ParseDefaultAnnotation(text string) string {
+	node := p.ParseAnnotations(text).Get("default")
+	if node == nil {
+		return ""
+	}
+
+	value := node.Raw
+	if strings.EqualFold(value, metadata.DefaultNow) {
+		return metadata.DefaultNow
+	}
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	return value
+}
+
+// ParseRefAnnotation 解析字段级别外部引用注解的目标
+// 输入：字段注解文本，如 `+soliton:ref(User)`、`+soliton:ref(User.ID)`
+// 返回：引用的聚合根名、字段名，未声明或不是合法标识符时为空
+func (p *AnnotationParser) ParseRefAnnotation(text string) (target string, field string) {
+	node := p.ParseAnnotations(text).Get("ref")
+	if node == nil {
+		return "", ""
+	}
+	target, field, _ = strings.Cut(node.Option("name"), ".")
+	target, field = strings.TrimSpace(target), strings.TrimSpace(field)
+	if !isAnnotationIdent(target) || field != "" && !isAnnotationIdent(field) {
+		return "", ""
+	}
+	return target, field
+}
+
+// ParsePolymorphicAnnotation 解析多态关联注解
+// 输入：字段注解文本，如 `+soliton:polymorphic(types=Invoice,Receipt)`、`+soliton:polymorphic(types=Invoice,Receipt, typeField=Kind)`
+// 返回：可关联的聚合根名列表、类型字段名（未声明时为空）；未标记时均为空
+func (p *AnnotationParser) ParsePolymorphicAnnotation(text string) (types []string, typeField string) {
+	node := p.ParseAnnotations(text).Get("polymorphic")
+	if node == nil {
+		return nil, ""
+	}
+	for _, name := range strings.Split(node.Option("types"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			types = append(types, name)
+		}
+	}
+	return types, node.Arg("typeField")
+}
+
+// ParseCascadeAnnotation 解析关联实体的级联行为注解
+// 输入：字段注解文本，如 `+soliton:cascade(delete)`、`+soliton:cascade(action=nullify)`
+// 返回：小写的级联行为（delete、nullify、restrict），未标记时为空
+func (p *AnnotationParser) ParseCascadeAnnotation(text string) string {
+	node := p.ParseAnnotations(text).Get("cascade")
+	if node == nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(node.Option("action")))
+}
+
+// ParseForeignKeyAnnotation 解析关联实体的外键列注解
+// 输入：字段注解文本，如 `+soliton:fk(column=order_no)`、`+soliton:fk(order_no)`
+// 返回：关联实体表中引用聚合根的外键列名，未声明时为空
+func (p *AnnotationParser) ParseForeignKeyAnnotation(text string) string {
+	node := p.ParseAnnotations(text).Get("fk")
+	if node == nil {
+		return ""
+	}
+	return strings.TrimSpace(node.Option("column"))
+}
+
+// ParseOwnerAnnotation 解析一对一关联实体的外键拥有方注解
+// 输入：字段注解文本，如 `+soliton:owner`
+// 返回：外键列是否位于聚合根（源）表中，未标记时外键列位于关联实体表中
+func (p *AnnotationParser) ParseOwnerAnnotation(text string) bool {
+	return p.ParseAnnotations(text).Has("owner")
+}
+
+// ParseExternalAnnotation 解析外部聚合根注解
+// 输入：字段注解文本，如 `+soliton:ref(Customer) +soliton:external`
+// 返回：外部引用的目标是否为其他服务（限界上下文）中、不在本模型内定义的聚合根
+func (p *AnnotationParser) ParseExternalAnnotation(text string) bool {
+	return p.ParseAnnotations(text).Has("external")
+}
+
+// ParseColumnAnnotation 解析自定义列注解
+// 输入：字段注解文本，如 `+soliton:column(name=order_number, type=varchar(64))`
+// 返回：列名、列类型，未设置的项为空
+func (p *AnnotationParser) ParseColumnAnnotation(text string) (name string, sqlType string) {
+	node := p.ParseAnnotations(text).Get("column")
+	if node == nil {
+		return "", ""
+	}
+	return node.Option("name"), node.Arg("type")
+}
+
+// ParseValidationAnnotations 解析校验规则注解
+// 输入：字段注解文本，如 `+soliton:validate(min=1,max=100) +soliton:length(2,64) +soliton:email`
+// 返回：校验规则，未声明任何规则时为 nil
+//
+// length 支持 length(2,64)、length(64)（只限制最大长度）以及 length(min=2,max=64) 写法。
+// 数值无法解析的参数会被忽略。
+func (p *AnnotationParser) ParseValidationAnnotations(text string) *metadata.ValidationRules {
+	nodes := p.ParseAnnotations(text)
+	rules := &metadata.ValidationRules{}
+	found := false
+
+	// 数值范围
+	if node := nodes.Get("validate"); node != nil {
+		if value, err := strconv.ParseFloat(node.Arg("min"), 64); err == nil {
+			rules.Min = &value
+			found = true
+		}
+		if value, err := strconv.ParseFloat(node.Arg("max"), 64); err == nil {
+			rules.Max = &value
+			found = true
+		}
+	}
+
+	// 长度
+	if node := nodes.Get("length"); node != nil {
+		minText, maxText := node.Arg("min"), node.Arg("max")
+		switch positional := node.Positional(); len(positional) {
+		case 1:
+			maxText = positional[0]
+		case 2:
+			minText, maxText = positional[0], positional[1]
+		}
+
+		if value, err := strconv.Atoi(strings.TrimSpace(minText)); err == nil {
+			rules.MinLength = &value
+			found = true
+		}
+		if value, err := strconv.Atoi(strings.TrimSpace(maxText)); err == nil {
+			rules.MaxLength = &value
+			found = true
+		}
+	}
+
+	// 正则表达式：按原文取值，表达式中的逗号和括号不拆分
+	if node := nodes.Get("pattern"); node != nil {
+		expr := node.Raw
+		if len(expr) >= 2 && strings.HasPrefix(expr, `"`) && strings.HasSuffix(expr, `"`) {
+			expr = expr[1 : len(expr)-1]
+		}
+		if expr != "" {
+			rules.Pattern = expr
+			found = true
+		}
+	}
+
+	// 邮箱
+	if nodes.Has("email") {
+		rules.IsEmail = true
+		found = true
+	}
+
+	if !found {
+		return nil
+	}
+	return rules
 }
 
 // ParseDBTag 解析 db 标签
@@ -144,6 +555,17 @@ func (p *AnnotationParser) ParseDBTag(tag string) string {
 	return ""
 }
 
+// ParseJSONTag 解析 json 标签中的名称，不含 omitempty 等选项
+// 输入：完整标签字符串，如 `db:"city" json:"city,omitempty"`
+// 返回：json 标签中的名称，未声明时为空
+func (p *AnnotationParser) ParseJSONTag(tag string) string {
+	matches := p.jsonTagPattern.FindStringSubmatch(tag)
+	if len(matches) > 1 {
+		return matches[1]
+	}
+	return ""
+}
+
 // IsBaseEntityField 判断字段名是否为 BaseEntity 的标准字段
 // 根据设计文档，BaseEntity 可能包含：DeletedAt、Version、CreatedAt、UpdatedAt、CreatedBy、UpdatedBy
 func (p *AnnotationParser) IsBaseEntityField(fieldName string) (isBaseField bool, fieldType string) {
@@ -1,22 +1,35 @@
 package parser
 
 import (
-	"bufio"
 	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"slices"
 	"soliton/pkg/metadata"
+	"sort"
 	"strings"
+
+	"golang.org/x/mod/modfile"
 )
 
 // ASTParser AST 解析器
 type ASTParser struct {
-	annotationParser *AnnotationParser
-	fset             *token.FileSet
+	annotationParser   *AnnotationParser
+	fset               *token.FileSet
+	resolveTypes       bool                     // 是否通过 go/packages 解析字段类型
+	packages           map[string]*packageScope // 已解析的包（import 路径 -> 包信息），用于展开嵌入字段
+	includePatterns    []string                 // 包含的文件模式，为空表示全部
+	excludePatterns    []string                 // 排除的目录或文件模式
+	diagnostics        []*Diagnostic            // 注解语法问题，见 Diagnostics
+	strict             bool                     // 严格模式：存在未知注解时解析失败，见 SetStrict
+	allowedAnnotations map[string]bool          // 不报告为未知注解的自定义注解名
+	overlay            map[string][]byte        // 尚未写入磁盘的源文件（绝对路径 -> 内容），见 ParseSchema
+	naming             metadata.NamingStrategy  // 表命名策略，见 SetNamingStrategy
 }
 
 // NewASTParser 创建 AST 解析器
@@ -24,9 +37,16 @@ func NewASTParser() *ASTParser {
 	return &ASTParser{
 		annotationParser: NewAnnotationParser(),
 		fset:             token.NewFileSet(),
+		packages:         make(map[string]*packageScope),
 	}
 }
 
+// SetNamingStrategy 设置表命名策略，解析出的聚合根按该策略生成默认表名和组合索引名
+// 未设置时使用 metadata.DefaultNamingStrategy
+func (p *ASTParser) SetNamingStrategy(naming metadata.NamingStrategy) {
+	p.naming = naming
+}
+
 // ParseFile 解析单个 Go 文件
 // 返回：聚合根元数据列表
 func (p *ASTParser) ParseFile(filePath string) ([]*metadata.AggregateMetadata, error) {
@@ -36,6 +56,108 @@ func (p *ASTParser) ParseFile(filePath string) ([]*metadata.AggregateMetadata, e
 		return nil, fmt.Errorf("解析文件失败: %w", err)
 	}
 
+	// 嵌入字段可能引用同包其他文件中的结构体，优先使用整个包的索引
+	absDir, _ := filepath.Abs(filepath.Dir(filePath))
+	modRoot, modName := p.findGoMod(absDir)
+	scope, err := p.loadPackageScope(p.calculateImportPath(absDir), absDir, modRoot, modName)
+	if err != nil {
+		scope = newPackageScope(p.calculateImportPath(absDir), modRoot, modName, map[string]*ast.File{filePath: file})
+	}
+
+	aggregates := p.parseAggregates(file, filePath, scope)
+
+	// 类型解析模式：补充字段的完整类型信息
+	if p.resolveTypes {
+		if err := p.resolveFieldTypes(absDir, aggregates); err != nil {
+			return nil, fmt.Errorf("类型解析失败: %w", err)
+		}
+	}
+
+	if err := p.checkStrict(); err != nil {
+		return nil, err
+	}
+
+	return aggregates, nil
+}
+
+// ParseDirectory 解析目录（递归）
+func (p *ASTParser) ParseDirectory(dirPath string) ([]*metadata.AggregateMetadata, error) {
+	var allAggregates []*metadata.AggregateMetadata
+
+	// 获取绝对路径
+	absDir, err := filepath.Abs(dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("获取绝对路径失败: %w", err)
+	}
+
+	// 查找模块信息
+	modRoot, modName := p.findGoMod(absDir)
+
+	// 递归遍历目录，收集需要解析的包
+	var requests []packageRequest
+	err = filepath.WalkDir(absDir, func(currentDir string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if !d.IsDir() {
+			return nil
+		}
+
+		// 跳过 vendor、testdata、隐藏目录和排除的目录
+		relDir, _ := filepath.Rel(absDir, currentDir)
+		if p.skipDir(filepath.ToSlash(relDir)) {
+			return filepath.SkipDir
+		}
+
+		requests = append(requests, packageRequest{importPath: p.calculateImportPath(currentDir), dir: currentDir})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 并发解析所有包的文件
+	if err := p.loadPackageScopes(requests, modRoot, modName); err != nil {
+		return nil, err
+	}
+
+	// 按目录顺序提取聚合根（嵌入字段可能按需加载其他包，需要串行执行）
+	for _, request := range requests {
+		scope := p.packages[request.importPath]
+
+		// 按文件路径排序，保证解析结果顺序稳定
+		filePaths := make([]string, 0, len(scope.files))
+		for filePath := range scope.files {
+			relFile, _ := filepath.Rel(absDir, filePath)
+			if p.includeFile(filepath.ToSlash(relFile)) {
+				filePaths = append(filePaths, filePath)
+			}
+		}
+		sort.Strings(filePaths)
+
+		for _, filePath := range filePaths {
+			allAggregates = append(allAggregates, p.parseAggregates(scope.files[filePath], filePath, scope)...)
+		}
+	}
+
+	// 类型解析模式：补充字段的完整类型信息
+	if p.resolveTypes {
+		if err := p.resolveFieldTypes(absDir, allAggregates); err != nil {
+			return nil, fmt.Errorf("类型解析失败: %w", err)
+		}
+	}
+
+	if err := p.checkStrict(); err != nil {
+		return nil, err
+	}
+
+	return allAggregates, nil
+}
+
+// parseAggregates 提取文件中声明的聚合根，scope 为文件所在的包，用于展开嵌入字段和解析领域行为
+func (p *ASTParser) parseAggregates(file *ast.File, filePath string, scope *packageScope) []*metadata.AggregateMetadata {
+	p.diagnoseFile(file)
+
 	var aggregates []*metadata.AggregateMetadata
 
 	// 遍历文件中的所有声明
@@ -60,7 +182,7 @@ func (p *ASTParser) ParseFile(filePath string) ([]*metadata.AggregateMetadata, e
 			}
 
 			// 提取注释
-			comments := p.extractComments(genDecl.Doc)
+			comments := p.extractComments(typeDoc(genDecl, typeSpec))
 
 			// 解析聚合根级别注解
 			isAggregate, baseEntity, isManyToMany, refs := p.annotationParser.ParseAggregateAnnotations(comments)
@@ -74,125 +196,56 @@ func (p *ASTParser) ParseFile(filePath string) ([]*metadata.AggregateMetadata, e
 			aggregate := &metadata.AggregateMetadata{
 				Name:        typeSpec.Name.Name,
 				PackageName: file.Name.Name,
+				ImportPath:  scope.importPath,
+				ModuleName:  scope.modName,
+				ModuleRoot:  scope.modRoot,
 				FilePath:    filePath,
+				Pos:         p.fset.Position(typeSpec.Name.Pos()),
 				Struct:      structType,
 				Annotations: &metadata.AggregateAnnotations{
 					IsAggregate:  true,
 					BaseEntity:   baseEntity,
 					IsManyToMany: isManyToMany,
+					IsEntity:     p.annotationParser.ParseEntityMarker(comments),
 					Refs:         refs,
+					Context:      p.annotationParser.ParseContextAnnotation(comments),
+					Events:       p.annotationParser.ParseEventAnnotations(comments),
+					JoinTables:   p.annotationParser.ParseJoinTableAnnotations(comments),
+					Nodes:        p.annotationParser.ParseCommentAnnotations(comments),
 				},
+				TableName: p.annotationParser.ParseTableAnnotation(comments),
+				Naming:    p.naming,
 			}
+			p.locateAnnotations(aggregate.Annotations.Nodes, nil, typeDoc(genDecl, typeSpec))
 
-			// 解析字段
-			aggregate.Fields = p.parseFields(structType)
+			// 解析字段（展开嵌入的结构体）
+			aggregate.Fields = p.parseFields(structType, file, scope)
+			aggregate.RecordsEvents = embedsEventRecorder(structType, file)
 
 			// 识别 BaseEntity 字段
-			aggregate.BaseEntity = p.identifyBaseEntityFields(aggregate.Fields)
+			aggregate.BaseEntity = p.identifyBaseEntityFields(aggregate.MappedFields())
 
 			// 识别 ID 字段
-			aggregate.IDField = p.identifyIDField(aggregate.Fields)
-
-			aggregates = append(aggregates, aggregate)
-		}
-	}
+			aggregate.PrimaryKey, aggregate.IDField = p.identifyPrimaryKey(aggregate.MappedFields())
+			aggregate.IDStrategy = identifyIDStrategy(aggregate)
 
-	return aggregates, nil
-}
+			// 汇总字段和聚合根上声明的索引
+			p.parseIndexes(aggregate, comments)
 
-// ParseDirectory 解析目录（递归）
-func (p *ASTParser) ParseDirectory(dirPath string) ([]*metadata.AggregateMetadata, error) {
-	var allAggregates []*metadata.AggregateMetadata
+			// 解析领域行为（包内各文件中的方法）
+			aggregate.Behaviors = p.parseBehaviors(aggregate.Name, scope)
 
-	// 获取绝对路径
-	absDir, err := filepath.Abs(dirPath)
-	if err != nil {
-		return nil, fmt.Errorf("获取绝对路径失败: %w", err)
-	}
+			// 解析对外暴露的 API
+			aggregate.API = p.annotationParser.ParseAPIAnnotation(comments)
 
-	// 查找模块信息
-	modRoot, modName := p.findGoMod(absDir)
+			// 解析声明的查询
+			aggregate.Queries = p.parseQueries(aggregate, comments)
 
-	// 递归遍历目录，按包解析每个子目录
-	err = filepath.WalkDir(absDir, func(currentDir string, d fs.DirEntry, walkErr error) error {
-		if walkErr != nil {
-			return walkErr
-		}
-		if !d.IsDir() {
-			return nil
-		}
-
-		// 跳过隐藏目录和 vendor
-		if d.Name() == "vendor" || strings.HasPrefix(d.Name(), ".") {
-			return filepath.SkipDir
-		}
-
-		importPath := p.calculateImportPath(currentDir)
-		pkgs, parseErr := parser.ParseDir(p.fset, currentDir, func(fi os.FileInfo) bool {
-			return !strings.HasSuffix(fi.Name(), "_test.go")
-		}, parser.ParseComments)
-		if parseErr != nil {
-			return fmt.Errorf("解析目录 %s 失败: %w", currentDir, parseErr)
-		}
-
-		for _, pkg := range pkgs {
-			for filePath, file := range pkg.Files {
-				for _, decl := range file.Decls {
-					genDecl, ok := decl.(*ast.GenDecl)
-					if !ok || genDecl.Tok != token.TYPE {
-						continue
-					}
-
-					for _, spec := range genDecl.Specs {
-						typeSpec, ok := spec.(*ast.TypeSpec)
-						if !ok {
-							continue
-						}
-
-						structType, ok := typeSpec.Type.(*ast.StructType)
-						if !ok {
-							continue
-						}
-
-						comments := p.extractComments(genDecl.Doc)
-						isAggregate, baseEntity, isManyToMany, refs := p.annotationParser.ParseAggregateAnnotations(comments)
-						if !isAggregate {
-							continue
-						}
-
-						aggregate := &metadata.AggregateMetadata{
-							Name:        typeSpec.Name.Name,
-							PackageName: file.Name.Name,
-							ImportPath:  importPath,
-							ModuleName:  modName,
-							ModuleRoot:  modRoot,
-							FilePath:    filePath,
-							Struct:      structType,
-							Annotations: &metadata.AggregateAnnotations{
-								IsAggregate:  true,
-								BaseEntity:   baseEntity,
-								IsManyToMany: isManyToMany,
-								Refs:         refs,
-							},
-						}
-
-						aggregate.Fields = p.parseFields(structType)
-						aggregate.BaseEntity = p.identifyBaseEntityFields(aggregate.Fields)
-						aggregate.IDField = p.identifyIDField(aggregate.Fields)
-
-						allAggregates = append(allAggregates, aggregate)
-					}
-				}
-			}
+			aggregates = append(aggregates, aggregate)
 		}
-
-		return nil
-	})
-	if err != nil {
-		return nil, err
 	}
 
-	return allAggregates, nil
+	return aggregates
 }
 
 // calculateImportPath 计算目录的完整 import 路径
@@ -244,78 +297,273 @@ func (p *ASTParser) findGoMod(startDir string) (modRoot string, modName string)
 }
 
 // readModuleName 从 go.mod 文件中读取模块名
+// 兼容带引号的模块路径和行尾注释，如 module "example.com/app" // comment
 func (p *ASTParser) readModuleName(goModPath string) string {
-	file, err := os.Open(goModPath)
+	data, err := os.ReadFile(goModPath)
 	if err != nil {
 		return ""
 	}
-	defer file.Close()
+	return modfile.ModulePath(data)
+}
+
+// parseFields 解析结构体字段
+// 嵌入的结构体（如 framework.BaseEntity）会递归展开，其字段作为提升字段加入列表
+func (p *ASTParser) parseFields(structType *ast.StructType, file *ast.File, pkg *packageScope) []*metadata.FieldMetadata {
+	return p.collectFields(structType, file, pkg, "", make(map[*ast.StructType]bool))
+}
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if strings.HasPrefix(line, "module ") {
-			return strings.TrimSpace(strings.TrimPrefix(line, "module "))
+// collectFields 收集结构体字段
+//
+// 与 Go 的字段提升规则一致：外层直接声明的字段优先于嵌入结构体中的同名字段，
+// 同一层多个嵌入结构体的同名字段以先出现的为准。
+// embeddedIn 为当前结构体的嵌入路径，visiting 用于避免循环嵌入。
+func (p *ASTParser) collectFields(structType *ast.StructType, file *ast.File, pkg *packageScope,
+	embeddedIn string, visiting map[*ast.StructType]bool) []*metadata.FieldMetadata {
+	visiting[structType] = true
+	defer delete(visiting, structType)
+
+	// 直接声明的字段名
+	declared := make(map[string]bool)
+	for _, field := range structType.Fields.List {
+		for _, name := range field.Names {
+			declared[name.Name] = true
 		}
 	}
-	return ""
-}
 
-// parseFields 解析结构体字段
-func (p *ASTParser) parseFields(structType *ast.StructType) []*metadata.FieldMetadata {
 	var fields []*metadata.FieldMetadata
 
 	for _, field := range structType.Fields.List {
-		// 跳过匿名字段
+		// 匿名字段：展开嵌入的结构体
 		if len(field.Names) == 0 {
+			for _, promoted := range p.expandEmbedded(field, file, pkg, embeddedIn, visiting) {
+				if declared[promoted.Name] {
+					continue
+				}
+				declared[promoted.Name] = true
+				fields = append(fields, promoted)
+			}
 			continue
 		}
 
-		fieldName := field.Names[0].Name
+		fieldMeta := p.parseField(field.Names[0].Name, field)
+		fieldMeta.EmbeddedIn = embeddedIn
+		p.linkConstEnum(fieldMeta, file, pkg)
+		if fieldMeta.Annotations.IsValueObject && fieldMeta.Annotations.Strategy == metadata.ValueObjectFlatten {
+			fieldMeta.Flattened = p.flattenValueObject(fieldMeta, file, pkg, visiting)
+		} else if fieldMeta.Annotations.IsValueObject {
+			fieldMeta.ValueFields = p.valueObjectFields(fieldMeta, file, pkg, visiting)
+		}
+		fields = append(fields, fieldMeta)
+	}
+
+	return fields
+}
+
+// expandEmbedded 展开匿名字段对应的结构体
+// 无法定位定义的嵌入类型（指针嵌入、接口、模块外的类型）会被跳过
+func (p *ASTParser) expandEmbedded(field *ast.Field, file *ast.File, pkg *packageScope,
+	embeddedIn string, visiting map[*ast.StructType]bool) []*metadata.FieldMetadata {
+	name, decl := p.lookupEmbedded(field.Type, file, pkg)
+	if decl == nil || visiting[decl.structType] {
+		return nil
+	}
+
+	path := name
+	if embeddedIn != "" {
+		path = embeddedIn + "." + name
+	}
+
+	fields := p.collectFields(decl.structType, decl.file, decl.pkg, path, visiting)
+	qualifyFieldTypes(fields, decl, pkg)
+
+	return fields
+}
+
+// flattenValueObject 解析展开策略值对象的结构体字段
+//
+// 字段列名加上值对象字段的列名作为前缀，如 Address 字段的 City 映射为 address_city；
+// 标记 +soliton:ignore 的字段不映射为列，不会展开。
+// 切片、map、定长数组以及无法定位定义的类型返回 nil，由 RelationAnalyzer.ValidateValueObjects 报告。
+func (p *ASTParser) flattenValueObject(fieldMeta *metadata.FieldMetadata, file *ast.File, pkg *packageScope,
+	visiting map[*ast.StructType]bool) []*metadata.FieldMetadata {
+	if fieldMeta.IsSlice || fieldMeta.IsMap || fieldMeta.IsArray {
+		return nil
+	}
+
+	expr := fieldMeta.RawType
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	_, decl := p.lookupEmbedded(expr, file, pkg)
+	if decl == nil || visiting[decl.structType] {
+		return nil
+	}
 
-		// 提取标签
-		var tag string
-		if field.Tag != nil {
-			tag = field.Tag.Value
-			// 去除反引号
-			tag = strings.Trim(tag, "`")
+	prefix := fieldMeta.Column() + "_"
+	var fields []*metadata.FieldMetadata
+	for _, sub := range p.collectFields(decl.structType, decl.file, decl.pkg, "", visiting) {
+		if sub.Annotations.IsIgnored {
+			continue
 		}
+		sub.ColumnName = prefix + sub.Column()
+		fields = append(fields, sub)
+	}
+	qualifyFieldTypes(fields, decl, pkg)
 
-		// 解析 db 标签
-		dbTag := p.annotationParser.ParseDBTag(tag)
-
-		// 解析字段注解
-		isUnique, isRef, isRequired, isEntity, isValueObject, isIndex, enumValues, strategy :=
-			p.annotationParser.ParseFieldAnnotations(tag)
-
-		// 分析字段类型
-		fieldType, isPointer, isSlice := p.analyzeFieldType(field.Type)
-
-		fieldMeta := &metadata.FieldMetadata{
-			Name:      fieldName,
-			Type:      fieldType,
-			DBTag:     dbTag,
-			IsPointer: isPointer,
-			IsSlice:   isSlice,
-			RawType:   field.Type,
-			Annotations: &metadata.FieldAnnotations{
-				IsUnique:      isUnique,
-				IsRef:         isRef,
-				IsRequired:    isRequired,
-				IsEntity:      isEntity,
-				IsValueObject: isValueObject,
-				IsIndex:       isIndex,
-				EnumValues:    enumValues,
-				Strategy:      strategy,
-			},
+	return fields
+}
+
+// valueObjectFields 返回 JSON 策略值对象的结构体字段，map、切片和数组取元素类型
+// 找不到结构体定义（如 map[string]string、其他模块的类型）时返回 nil
+func (p *ASTParser) valueObjectFields(fieldMeta *metadata.FieldMetadata, file *ast.File, pkg *packageScope,
+	visiting map[*ast.StructType]bool) []*metadata.FieldMetadata {
+	expr := fieldMeta.RawType
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+			continue
+		case *ast.ArrayType:
+			expr = t.Elt
+			continue
+		case *ast.MapType:
+			expr = t.Value
+			continue
 		}
+		break
+	}
 
-		fields = append(fields, fieldMeta)
+	_, decl := p.lookupEmbedded(expr, file, pkg)
+	if decl == nil || visiting[decl.structType] {
+		return nil
+	}
+
+	var fields []*metadata.FieldMetadata
+	for _, sub := range p.collectFields(decl.structType, decl.file, decl.pkg, "", visiting) {
+		if !sub.Annotations.IsIgnored {
+			fields = append(fields, sub)
+		}
 	}
+	qualifyFieldTypes(fields, decl, pkg)
 
 	return fields
 }
 
+// qualifyFieldTypes 为其他包中结构体的字段类型加上包名，使其在聚合根所在包中可用
+func qualifyFieldTypes(fields []*metadata.FieldMetadata, decl *structDecl, pkg *packageScope) {
+	if decl.pkg == pkg || decl.pkg.importPath == frameworkImportPath {
+		return
+	}
+	for _, f := range fields {
+		if !strings.Contains(f.Type, ".") && ast.IsExported(f.Type) {
+			f.Type = decl.pkg.name + "." + f.Type
+		}
+	}
+}
+
+// parseField 解析单个具名字段
+func (p *ASTParser) parseField(fieldName string, field *ast.Field) *metadata.FieldMetadata {
+	// 提取标签
+	var tag string
+	if field.Tag != nil {
+		tag = field.Tag.Value
+		// 去除反引号
+		tag = strings.Trim(tag, "`")
+	}
+
+	// 解析 db 标签
+	dbTag := p.annotationParser.ParseDBTag(tag)
+	jsonTag := p.annotationParser.ParseJSONTag(tag)
+
+	// 解析字段注解：标签中的注解与字段上方/行尾注释中的注解合并
+	annotations := tag
+	comments := append(p.extractComments(field.Doc), p.extractComments(field.Comment)...)
+	if commentAnnotations := p.annotationParser.ExtractCommentAnnotations(comments); commentAnnotations != "" {
+		annotations += "\n" + commentAnnotations
+	}
+	isUnique, isRef, isRequired, isEntity, isValueObject, isIndex, enumValues, strategy :=
+		p.annotationParser.ParseFieldAnnotations(annotations)
+	_, enumNames, enumLabels := p.annotationParser.ParseEnumAnnotation(annotations)
+	columnName, columnType := p.annotationParser.ParseColumnAnnotation(annotations)
+	isID, idStrategy := p.annotationParser.ParseIDAnnotation(annotations)
+	isPK := p.annotationParser.ParsePKAnnotation(annotations)
+	validation := p.annotationParser.ParseValidationAnnotations(annotations)
+	isIgnored := p.annotationParser.ParseIgnoreAnnotation(annotations)
+	isImmutable := p.annotationParser.ParseImmutableAnnotation(annotations)
+	isFilter, isSortable := p.annotationParser.ParseFilterAnnotation(annotations)
+	sensitive := p.annotationParser.ParseSensitiveAnnotation(annotations)
+	defaultValue := p.annotationParser.ParseDefaultAnnotation(annotations)
+	refTarget, refField := p.annotationParser.ParseRefAnnotation(annotations)
+	polymorphicTypes, polymorphicTypeField := p.annotationParser.ParsePolymorphicAnnotation(annotations)
+	cascade := p.annotationParser.ParseCascadeAnnotation(annotations)
+	foreignKey := p.annotationParser.ParseForeignKeyAnnotation(annotations)
+	isOwner := p.annotationParser.ParseOwnerAnnotation(annotations)
+	isExternal := p.annotationParser.ParseExternalAnnotation(annotations)
+	uniqueName := p.annotationParser.ParseUniqueAnnotation(annotations)
+
+	// 分析字段类型
+	fieldType, isPointer, isSlice := p.analyzeFieldType(field.Type)
+	mapKeyType, mapValueType, arrayLen := p.analyzeContainerType(field.Type)
+
+	// map 和定长数组无法展开为多列，值对象默认使用 JSON 策略
+	if isValueObject && strategy == "" && (mapKeyType != "" || arrayLen != "") {
+		strategy = "json"
+	}
+
+	fieldMetadata := &metadata.FieldMetadata{
+		Name:         fieldName,
+		Type:         fieldType,
+		DBTag:        dbTag,
+		JSONTag:      jsonTag,
+		IsPointer:    isPointer,
+		IsSlice:      isSlice,
+		IsMap:        mapKeyType != "",
+		IsArray:      arrayLen != "",
+		RawType:      field.Type,
+		Pos:          p.fset.Position(field.Pos()),
+		ColumnName:   columnName,
+		ColumnType:   columnType,
+		IDStrategy:   idStrategy,
+		MapKeyType:   mapKeyType,
+		MapValueType: mapValueType,
+		ArrayLen:     arrayLen,
+		Annotations: &metadata.FieldAnnotations{
+			IsUnique:      isUnique,
+			UniqueName:    uniqueName,
+			IsRef:         isRef,
+			IsRequired:    isRequired,
+			IsEntity:      isEntity,
+			IsValueObject: isValueObject,
+			IsIndex:       isIndex,
+			IsID:          isID,
+			IsPK:          isPK,
+			IsIgnored:     isIgnored,
+			IsImmutable:   isImmutable,
+			IsFilter:      isFilter,
+			IsSortable:    isSortable,
+			Sensitive:     sensitive,
+			EnumValues:    enumValues,
+			EnumNames:     enumNames,
+			EnumLabels:    enumLabels,
+			Strategy:      strategy,
+			Default:       defaultValue,
+			RefTarget:     refTarget,
+			RefField:      refField,
+			Polymorphic:   polymorphicTypes,
+			PolymorphicBy: polymorphicTypeField,
+			Cascade:       cascade,
+			ForeignKey:    foreignKey,
+			IsOwner:       isOwner,
+			IsExternal:    isExternal,
+			Validation:    validation,
+			Nodes:         p.annotationParser.ParseAnnotations(annotations),
+		},
+	}
+	p.locateAnnotations(fieldMetadata.Annotations.Nodes, field.Tag, field.Doc, field.Comment)
+
+	return fieldMetadata
+}
+
 // analyzeFieldType 分析字段类型
 // 返回：类型名称、是否指针、是否切片
 func (p *ASTParser) analyzeFieldType(expr ast.Expr) (typeName string, isPointer bool, isSlice bool) {
@@ -328,11 +576,13 @@ func (p *ASTParser) analyzeFieldType(expr ast.Expr) (typeName string, isPointer
 		innerType, _, _ := p.analyzeFieldType(t.X)
 		return innerType, true, false
 	case *ast.ArrayType:
-		// 切片类型，如 []*OrderItem
-		if t.Len == nil { // 切片
-			innerType, isPtr, _ := p.analyzeFieldType(t.Elt)
-			return innerType, isPtr, true
-		}
+		// 切片类型，如 []*OrderItem；定长数组，如 [32]byte（长度见 analyzeContainerType）
+		// 两者都返回元素类型
+		innerType, isPtr, _ := p.analyzeFieldType(t.Elt)
+		return innerType, isPtr, t.Len == nil
+	case *ast.MapType:
+		// map 类型，如 map[string]string，返回完整类型表达式（键、值类型见 analyzeContainerType）
+		return types.ExprString(t), false, false
 	case *ast.SelectorExpr:
 		// 限定类型，如 time.Time
 		if ident, ok := t.X.(*ast.Ident); ok {
@@ -342,7 +592,26 @@ func (p *ASTParser) analyzeFieldType(expr ast.Expr) (typeName string, isPointer
 	return "unknown", false, false
 }
 
-// extractComments 提取注释文本
+// analyzeContainerType 分析 map 和定长数组类型
+// 返回：map 的键类型和值类型，如 "string"、"*Label"；定长数组的长度表达式，如 "32"、"sha256.Size"
+// 其他类型返回空字符串
+func (p *ASTParser) analyzeContainerType(expr ast.Expr) (keyType string, valueType string, arrayLen string) {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+
+	switch t := expr.(type) {
+	case *ast.MapType:
+		return types.ExprString(t.Key), types.ExprString(t.Value), ""
+	case *ast.ArrayType:
+		if t.Len != nil {
+			return "", "", types.ExprString(t.Len)
+		}
+	}
+	return "", "", ""
+}
+
+// extractComments 提取注释文本，返回去掉注释符的逐行文本（见 normalizeComments）
 func (p *ASTParser) extractComments(commentGroup *ast.CommentGroup) []string {
 	if commentGroup == nil {
 		return nil
@@ -352,7 +621,83 @@ func (p *ASTParser) extractComments(commentGroup *ast.CommentGroup) []string {
 	for _, comment := range commentGroup.List {
 		comments = append(comments, comment.Text)
 	}
-	return comments
+	return normalizeComments(comments)
+}
+
+// locateAnnotations 记录注解节点在源码中的位置
+// 按名称依次对应结构体标签和注释组中扫描到的注解，同名注解按出现顺序对应
+func (p *ASTParser) locateAnnotations(nodes metadata.AnnotationList, tag *ast.BasicLit, groups ...*ast.CommentGroup) {
+	type located struct {
+		name string
+		pos  token.Pos
+	}
+	var found []located
+	scan := func(base token.Pos, text string) {
+		tokens, _ := scanAnnotations(text)
+		for _, tok := range tokens {
+			found = append(found, located{name: tok.node.Name, pos: base + token.Pos(tok.offset)})
+		}
+	}
+
+	if tag != nil {
+		scan(tag.ValuePos, tag.Value)
+	}
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+		for _, comment := range group.List {
+			scan(comment.Slash, comment.Text)
+		}
+	}
+
+	for _, node := range nodes {
+		for i, loc := range found {
+			if loc.name == node.Name {
+				node.Pos = p.fset.Position(loc.pos)
+				found = slices.Delete(found, i, i+1)
+				break
+			}
+		}
+	}
+}
+
+// typeDoc 返回类型声明的文档注释
+// 分组声明 type ( ... ) 中写在类型上方的注释属于 TypeSpec，其余情况属于 GenDecl
+func typeDoc(genDecl *ast.GenDecl, typeSpec *ast.TypeSpec) *ast.CommentGroup {
+	if typeSpec.Doc != nil {
+		return typeSpec.Doc
+	}
+	return genDecl.Doc
+}
+
+// parseIndexes 解析聚合根级别的组合索引注解，与字段上的索引注解一并汇总到 Indexes（见 AggregateMetadata.CollectIndexes）
+// 字段是否存在由 RelationAnalyzer.ValidateIndexes 校验
+func (p *ASTParser) parseIndexes(agg *metadata.AggregateMetadata, comments []string) {
+	declared := p.annotationParser.ParseIndexAnnotations(comments)
+
+	// 索引注解与 Nodes 中的 +soliton:uniqueIndex、+soliton:index 按声明顺序一一对应
+	i := 0
+	for _, node := range agg.Annotations.Nodes {
+		if (node.Name == "uniqueIndex" || node.Name == "index") && i < len(declared) {
+			declared[i].Pos = node.Pos
+			i++
+		}
+	}
+
+	agg.CollectIndexes(declared)
+}
+
+// parseQueries 解析聚合根上声明的查询（+soliton:query），并记录各注解的位置
+// 字段是否存在由 RelationAnalyzer.ValidateQueries 校验
+func (p *ASTParser) parseQueries(agg *metadata.AggregateMetadata, comments []string) []*metadata.QueryMetadata {
+	queries := p.annotationParser.ParseQueryAnnotations(comments)
+	for i, node := range agg.Annotations.Nodes.All("query") {
+		if i < len(queries) {
+			queries[i].Pos = node.Pos
+		}
+	}
+	return queries
 }
 
 // identifyBaseEntityFields 识别 BaseEntity 字段
@@ -385,8 +730,35 @@ func (p *ASTParser) identifyBaseEntityFields(fields []*metadata.FieldMetadata) *
 	return baseEntity
 }
 
+// identifyPrimaryKey 确定组成主键的字段和单列 ID 字段
+// +soliton:pk 标记了多个字段时为复合主键（按声明顺序），没有单列 ID 字段；否则主键为识别到的 ID 字段
+func (p *ASTParser) identifyPrimaryKey(fields []*metadata.FieldMetadata) ([]*metadata.FieldMetadata, *metadata.FieldMetadata) {
+	var primaryKey []*metadata.FieldMetadata
+	for _, field := range fields {
+		if field.Annotations.IsPK {
+			primaryKey = append(primaryKey, field)
+		}
+	}
+	if len(primaryKey) > 1 {
+		return primaryKey, nil
+	}
+
+	idField := p.identifyIDField(fields)
+	if idField == nil {
+		return nil, nil
+	}
+	return []*metadata.FieldMetadata{idField}, idField
+}
+
 // identifyIDField 识别 ID 字段（根据优先级）
+// 通过 +soliton:id（或只标记了一个字段的 +soliton:pk）显式标记的字段优先于所有自动识别规则
 func (p *ASTParser) identifyIDField(fields []*metadata.FieldMetadata) *metadata.FieldMetadata {
+	for _, field := range fields {
+		if field.Annotations.IsID || field.Annotations.IsPK {
+			return field
+		}
+	}
+
 	var candidates []*struct {
 		field    *metadata.FieldMetadata
 		priority int
@@ -417,3 +789,19 @@ func (p *ASTParser) identifyIDField(fields []*metadata.FieldMetadata) *metadata.
 
 	return bestCandidate.field
 }
+
+// identifyIDStrategy 确定聚合根生效的主键策略
+// ID 字段声明了 +soliton:id(strategy=...) 时使用声明的策略，否则 string 主键为 uuid，其他为 auto；
+// 复合主键总是由调用方设置（manual）
+func identifyIDStrategy(agg *metadata.AggregateMetadata) string {
+	if agg.IsCompositeKey() {
+		return metadata.IDStrategyManual
+	}
+	if agg.IDField != nil && agg.IDField.IDStrategy != "" {
+		return agg.IDField.IDStrategy
+	}
+	if agg.IDKeyType() == "string" {
+		return metadata.IDStrategyUUID
+	}
+	return metadata.IDStrategyAuto
+}
@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestModule 在临时目录中创建模块 sample，files 为相对模块根目录的路径 -> 内容，返回模块根目录
+func writeTestModule(t testing.TB, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	files["go.mod"] = "module sample\n\ngo 1.21\n"
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+const testOrderModel = `package model
+
+// Money 金额（分）
+type Money = int64
+
+// Order 订单
+//
+// +soliton:aggregate
+type Order struct {
+	ID     int64 ` + "`db:\"id\"`" + `
+	Amount Money ` + "`db:\"amount\"`" + `
+}
+`
+
+func TestParseFileResolvesTypes(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{"domain/model/order.go": testOrderModel})
+	filePath := filepath.Join(dir, "domain", "model", "order.go")
+
+	for _, resolve := range []bool{false, true} {
+		p := NewASTParser()
+		p.SetResolveTypes(resolve)
+		aggregates, err := p.ParseFile(filePath)
+		if err != nil {
+			t.Fatalf("解析失败: %v", err)
+		}
+		if len(aggregates) != 1 {
+			t.Fatalf("应解析出 1 个聚合根，实际为 %d", len(aggregates))
+		}
+
+		amount := aggregates[0].Fields[1]
+		want := "Money"
+		if resolve {
+			want = "int64"
+		}
+		if amount.Name != "Amount" || amount.Type != want {
+			t.Errorf("SetResolveTypes(%v) 时 Amount 的类型应为 %s: %+v", resolve, want, amount)
+		}
+	}
+}
+
+func TestParseFileMatchesParseDirectory(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{"domain/model/order.go": testOrderModel})
+	modelDir := filepath.Join(dir, "domain", "model")
+
+	fromFile, err := NewASTParser().ParseFile(filepath.Join(modelDir, "order.go"))
+	if err != nil {
+		t.Fatalf("解析文件失败: %v", err)
+	}
+	fromDir, err := NewASTParser().ParseDirectory(modelDir)
+	if err != nil {
+		t.Fatalf("解析目录失败: %v", err)
+	}
+	if len(fromFile) != 1 || len(fromDir) != 1 {
+		t.Fatalf("应各解析出 1 个聚合根，实际为 %d、%d", len(fromFile), len(fromDir))
+	}
+
+	a, b := fromFile[0], fromDir[0]
+	if a.Name != b.Name || a.ImportPath != b.ImportPath || a.ModuleName != b.ModuleName || a.ModuleRoot != b.ModuleRoot ||
+		a.TableName != b.TableName || len(a.Fields) != len(b.Fields) || a.IDField.Name != b.IDField.Name {
+		t.Errorf("ParseFile 与 ParseDirectory 的结果不一致:\n%+v\n%+v", a, b)
+	}
+}
@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"soliton/pkg/metadata"
+	"sort"
+	"strings"
+)
+
+// generatedMarker EntityGenerator 追加到领域模型文件中的生成代码标记，标记之后的方法不属于领域行为
+const generatedMarker = "// ========== 以下代码由 soliton 自动生成"
+
+// parseBehaviors 解析聚合根的领域行为
+//
+// 收集包内所有文件中接收者为聚合根（T 或 *T）的导出方法，按文件路径和声明顺序排列。
+// 生成的文件以及生成器追加到模型文件末尾的方法（GetID、SetID 等）会被跳过。
+func (p *ASTParser) parseBehaviors(aggregateName string, pkg *packageScope) []*metadata.BehaviorMetadata {
+	filePaths := make([]string, 0, len(pkg.files))
+	for filePath := range pkg.files {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Strings(filePaths)
+
+	var behaviors []*metadata.BehaviorMetadata
+	for _, filePath := range filePaths {
+		file := pkg.files[filePath]
+		if ast.IsGenerated(file) {
+			continue
+		}
+		marker := generatedStart(file)
+
+		for _, decl := range file.Decls {
+			funcDecl, ok := decl.(*ast.FuncDecl)
+			if !ok || funcDecl.Recv == nil || !funcDecl.Name.IsExported() {
+				continue
+			}
+			if marker.IsValid() && funcDecl.Pos() > marker {
+				continue
+			}
+
+			receiver, pointer := receiverType(funcDecl.Recv)
+			if receiver != aggregateName {
+				continue
+			}
+
+			comments := p.extractComments(funcDecl.Doc)
+			isCommand, commandName := p.annotationParser.ParseCommandAnnotation(comments)
+			behavior := &metadata.BehaviorMetadata{
+				Name:            funcDecl.Name.Name,
+				Comment:         behaviorComment(funcDecl.Name.Name, comments),
+				Params:          paramList(funcDecl.Type.Params),
+				Results:         paramList(funcDecl.Type.Results),
+				PointerReceiver: pointer,
+				IsCommand:       isCommand,
+				CommandName:     commandName,
+				Nodes:           p.annotationParser.ParseCommentAnnotations(comments),
+			}
+			p.locateAnnotations(behavior.Nodes, nil, funcDecl.Doc)
+			behaviors = append(behaviors, behavior)
+		}
+	}
+
+	return behaviors
+}
+
+// generatedStart 返回文件中生成代码标记的位置，没有标记时返回 token.NoPos
+func generatedStart(file *ast.File) token.Pos {
+	for _, group := range file.Comments {
+		for _, comment := range group.List {
+			if strings.HasPrefix(comment.Text, generatedMarker) {
+				return comment.Pos()
+			}
+		}
+	}
+	return token.NoPos
+}
+
+// receiverType 返回方法接收者的类型名以及是否为指针接收者
+func receiverType(recv *ast.FieldList) (name string, pointer bool) {
+	if len(recv.List) == 0 {
+		return "", false
+	}
+
+	expr := recv.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr, pointer = star.X, true
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name, pointer
+	}
+	return "", pointer
+}
+
+// paramList 将参数或返回值列表展开为逐个的参数，如 (a, b int) 展开为两个参数
+func paramList(fields *ast.FieldList) []*metadata.ParamMetadata {
+	if fields == nil {
+		return nil
+	}
+
+	var params []*metadata.ParamMetadata
+	for _, field := range fields.List {
+		typeExpr := types.ExprString(field.Type)
+		if len(field.Names) == 0 {
+			params = append(params, &metadata.ParamMetadata{Type: typeExpr})
+			continue
+		}
+		for _, name := range field.Names {
+			params = append(params, &metadata.ParamMetadata{Name: name.Name, Type: typeExpr})
+		}
+	}
+	return params
+}
+
+// behaviorComment 返回方法注释的说明文字，去掉注解行和开头的方法名，如 "Pay 支付订单" 为 "支付订单"
+// comments 为 extractComments 规范化后的注释行
+func behaviorComment(name string, comments []string) string {
+	var lines []string
+	for _, line := range comments {
+		if strings.HasPrefix(line, annotationPrefix) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	lines[0] = strings.TrimSpace(strings.TrimPrefix(lines[0], name+" "))
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
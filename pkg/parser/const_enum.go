@@ -0,0 +1,163 @@
+package parser
+
+import (
+	"errors"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"slices"
+	"soliton/pkg/metadata"
+	"sort"
+)
+
+// collectConstEnums 识别包内由 const 块定义的枚举
+//
+// 形如下面的写法会被识别为枚举 OrderStatus，值为 ["PENDING", "PAID"]：
+//
+//	type OrderStatus string
+//
+//	const (
+//		OrderStatusPending OrderStatus = "PENDING"
+//		OrderStatusPaid    OrderStatus = "PAID"
+//	)
+//
+// 底层类型为字符串或整数的命名类型都支持，整数常量可使用 iota。
+// 只对当前包做类型检查，导入的包不会被加载，因此依赖其他包的常量表达式会被忽略。
+func (p *ASTParser) collectConstEnums(scope *packageScope) map[string]*metadata.EnumMetadata {
+	filePaths := make([]string, 0, len(scope.files))
+	for filePath := range scope.files {
+		filePaths = append(filePaths, filePath)
+	}
+	sort.Strings(filePaths)
+
+	files := make([]*ast.File, 0, len(filePaths))
+	for _, filePath := range filePaths {
+		files = append(files, scope.files[filePath])
+	}
+
+	conf := types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			return nil, errors.New("不加载导入的包")
+		}),
+		Error: func(error) {}, // 忽略类型错误，只关心常量
+	}
+	pkg, _ := conf.Check(scope.importPath, p.fset, files, nil)
+	if pkg == nil {
+		return nil
+	}
+
+	// 按声明顺序（文件路径、文件内偏移）收集常量
+	// 文件是并发解析的，不同文件的 token.Pos 大小与文件顺序无关，需要比较完整位置
+	var consts []*types.Const
+	for _, name := range pkg.Scope().Names() {
+		if c, ok := pkg.Scope().Lookup(name).(*types.Const); ok {
+			consts = append(consts, c)
+		}
+	}
+	sort.Slice(consts, func(i, j int) bool {
+		a, b := p.fset.Position(consts[i].Pos()), p.fset.Position(consts[j].Pos())
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Offset < b.Offset
+	})
+
+	enums := make(map[string]*metadata.EnumMetadata)
+	for _, c := range consts {
+		named, ok := c.Type().(*types.Named)
+		if !ok || named.Obj().Pkg() != pkg {
+			continue
+		}
+		basic, ok := named.Underlying().(*types.Basic)
+		if !ok || basic.Info()&(types.IsString|types.IsInteger) == 0 || c.Val().Kind() == constant.Unknown {
+			continue
+		}
+
+		value := c.Val().ExactString()
+		if c.Val().Kind() == constant.String {
+			value = constant.StringVal(c.Val())
+		}
+
+		typeName := named.Obj().Name()
+		enum := enums[typeName]
+		if enum == nil {
+			enum = &metadata.EnumMetadata{
+				Name:       typeName,
+				GoType:     basic.Name(),
+				ImportPath: scope.importPath,
+			}
+			enums[typeName] = enum
+		}
+
+		// 同值的别名常量只保留第一个
+		if slices.Contains(enum.Values, value) {
+			continue
+		}
+		enum.Values = append(enum.Values, value)
+		enum.Constants = append(enum.Constants, c.Name())
+	}
+
+	return enums
+}
+
+// importerFunc 将函数适配为 types.Importer
+type importerFunc func(path string) (*types.Package, error)
+
+// Import 导入包
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
+
+// linkConstEnum 字段类型为 const 块定义的枚举时，以常量值作为字段的枚举值，并记录枚举的底层类型（如 "string"）
+// 整数枚举以常量值为编码、常量名为名称；已通过 +soliton:enum 声明枚举值的字段保持不变；
+// 指针字段只记录底层类型，切片等字段不关联
+func (p *ASTParser) linkConstEnum(field *metadata.FieldMetadata, file *ast.File, pkg *packageScope) {
+	if field.IsSlice || field.IsMap || field.IsArray {
+		return
+	}
+
+	scope, typeName := pkg, field.Type
+	if sel, ok := field.RawType.(*ast.SelectorExpr); ok {
+		pkgIdent, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return
+		}
+		scope, typeName = p.importedScope(file, pkg, pkgIdent.Name), sel.Sel.Name
+	}
+	if scope == nil {
+		return
+	}
+
+	enum, ok := scope.enums[typeName]
+	if !ok {
+		return
+	}
+	if field.UnderlyingType == "" {
+		field.UnderlyingType = enum.GoType
+	}
+	if len(field.Annotations.EnumValues) > 0 || field.IsPointer {
+		return
+	}
+	field.Annotations.EnumValues = enum.Values
+	field.Annotations.EnumType = enum.Name
+	if enum.IsInt() {
+		field.Annotations.EnumNames = enum.Constants
+	}
+}
+
+// Enums 返回已解析的包中由 const 块定义的枚举（按包路径和名称排序）
+func (p *ASTParser) Enums() []*metadata.EnumMetadata {
+	var enums []*metadata.EnumMetadata
+	for _, scope := range p.packages {
+		for _, enum := range scope.enums {
+			enums = append(enums, enum)
+		}
+	}
+	sort.Slice(enums, func(i, j int) bool {
+		if enums[i].ImportPath != enums[j].ImportPath {
+			return enums[i].ImportPath < enums[j].ImportPath
+		}
+		return enums[i].Name < enums[j].Name
+	})
+	return enums
+}
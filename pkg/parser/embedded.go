@@ -0,0 +1,256 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"soliton/pkg/metadata"
+	"strconv"
+	"strings"
+)
+
+// frameworkImportPath soliton 框架包的 import 路径
+const frameworkImportPath = "soliton/pkg/framework"
+
+// frameworkSource 框架中可供聚合根嵌入的基础实体定义
+//
+// 框架包位于生成器自身的模块中，模型所在模块里没有它的源码，
+// 因此在这里保留一份字段定义，需要与 pkg/framework/entity.go 保持一致。
+const frameworkSource = `package framework
+
+import "time"
+
+type BaseEntity struct {
+	ID        int64      ` + "`db:\"id\"`" + `
+	CreatedAt time.Time  ` + "`db:\"created_at\"`" + `
+	UpdatedAt time.Time  ` + "`db:\"updated_at\"`" + `
+	Version   int        ` + "`db:\"version\"`" + `
+	DeletedAt *time.Time ` + "`db:\"deleted_at\"`" + `
+}
+
+type BaseEntityUUID struct {
+	ID        string     ` + "`db:\"id\"`" + `
+	CreatedAt time.Time  ` + "`db:\"created_at\"`" + `
+	UpdatedAt time.Time  ` + "`db:\"updated_at\"`" + `
+	Version   int        ` + "`db:\"version\"`" + `
+	DeletedAt *time.Time ` + "`db:\"deleted_at\"`" + `
+}
+`
+
+// packageScope 一个包的解析结果，用于查找嵌入的结构体
+type packageScope struct {
+	name       string                            // 包名
+	importPath string                            // 完整 import 路径
+	modRoot    string                            // 所在模块根目录
+	modName    string                            // 所在模块名
+	files      map[string]*ast.File              // 文件路径 -> 语法树
+	structs    map[string]*structDecl            // 结构体名 -> 定义
+	enums      map[string]*metadata.EnumMetadata // 类型名 -> 由 const 块定义的枚举
+}
+
+// structDecl 结构体定义及其所在文件
+type structDecl struct {
+	structType *ast.StructType
+	file       *ast.File
+	pkg        *packageScope
+}
+
+// newPackageScope 根据包内文件建立结构体索引
+func newPackageScope(importPath, modRoot, modName string, files map[string]*ast.File) *packageScope {
+	scope := &packageScope{
+		importPath: importPath,
+		modRoot:    modRoot,
+		modName:    modName,
+		files:      files,
+		structs:    make(map[string]*structDecl),
+	}
+
+	for _, file := range files {
+		scope.name = file.Name.Name
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range genDecl.Specs {
+				typeSpec, ok := spec.(*ast.TypeSpec)
+				if !ok {
+					continue
+				}
+				if structType, ok := typeSpec.Type.(*ast.StructType); ok {
+					scope.structs[typeSpec.Name.Name] = &structDecl{
+						structType: structType,
+						file:       file,
+						pkg:        scope,
+					}
+				}
+			}
+		}
+	}
+
+	return scope
+}
+
+// packageRequest 待解析的包
+type packageRequest struct {
+	importPath string
+	dir        string
+}
+
+// loadPackageScope 解析目录中的包（跳过测试文件），结果按 import 路径缓存
+func (p *ASTParser) loadPackageScope(importPath, dir, modRoot, modName string) (*packageScope, error) {
+	if err := p.loadPackageScopes([]packageRequest{{importPath: importPath, dir: dir}}, modRoot, modName); err != nil {
+		return nil, err
+	}
+	return p.packages[importPath], nil
+}
+
+// loadPackageScopes 并发解析多个包，结果按 import 路径缓存，已缓存的包直接跳过
+//
+// 所有包的文件先放进同一个协程池解析，再并发建立各包的结构体索引和 const 枚举，
+// 最后按请求顺序写入缓存。解析出错时返回按文件路径排序的第一个错误，与串行解析的结果一致。
+func (p *ASTParser) loadPackageScopes(requests []packageRequest, modRoot, modName string) error {
+	var pending []packageRequest
+	var filePaths []string
+	dirFiles := make(map[string][]string)
+	for _, request := range requests {
+		if _, ok := p.packages[request.importPath]; ok {
+			continue
+		}
+		if _, ok := dirFiles[request.dir]; ok {
+			continue
+		}
+
+		paths, err := p.listGoFiles(request.dir)
+		if err != nil {
+			return fmt.Errorf("解析目录 %s 失败: %w", request.dir, err)
+		}
+		pending = append(pending, request)
+		dirFiles[request.dir] = paths
+		filePaths = append(filePaths, paths...)
+	}
+
+	parsed, err := p.parseFiles(filePaths)
+	if err != nil {
+		return err
+	}
+
+	// 类型检查识别 const 枚举的耗时与解析文件相当，同样并发执行
+	scopes := make([]*packageScope, len(pending))
+	parallel(len(pending), func(i int) {
+		files := make(map[string]*ast.File)
+		for _, filePath := range dirFiles[pending[i].dir] {
+			files[filePath] = parsed[filePath]
+		}
+		scope := newPackageScope(pending[i].importPath, modRoot, modName, files)
+		scope.enums = p.collectConstEnums(scope)
+		scopes[i] = scope
+	})
+
+	for i, request := range pending {
+		p.packages[request.importPath] = scopes[i]
+	}
+	return nil
+}
+
+// frameworkScope 返回框架基础实体的包信息
+func (p *ASTParser) frameworkScope() *packageScope {
+	if scope, ok := p.packages[frameworkImportPath]; ok {
+		return scope
+	}
+
+	file, err := parser.ParseFile(p.fset, "framework/entity.go", frameworkSource, 0)
+	if err != nil {
+		// 内置源码固定不变，解析失败属于程序错误
+		panic(err)
+	}
+
+	scope := newPackageScope(frameworkImportPath, "", "", map[string]*ast.File{"framework/entity.go": file})
+	p.packages[frameworkImportPath] = scope
+	return scope
+}
+
+// lookupEmbedded 查找嵌入字段对应的结构体定义，也用于查找展开策略值对象的类型定义
+//
+// 支持的写法：
+//   - 同包结构体：Audit
+//   - 框架基础实体：framework.BaseEntity、framework.BaseEntityUUID
+//   - 同一模块内其他包的结构体：shared.Audit
+//
+// 指针嵌入（*Audit）、泛型实例化和模块外的类型无法在语法层面展开，返回 nil。
+func (p *ASTParser) lookupEmbedded(expr ast.Expr, file *ast.File, pkg *packageScope) (string, *structDecl) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name, pkg.structs[t.Name]
+	case *ast.SelectorExpr:
+		pkgIdent, ok := t.X.(*ast.Ident)
+		if !ok {
+			return "", nil
+		}
+
+		scope := p.importedScope(file, pkg, pkgIdent.Name)
+		if scope == nil {
+			return t.Sel.Name, nil
+		}
+		return t.Sel.Name, scope.structs[t.Sel.Name]
+	}
+
+	return "", nil
+}
+
+// embedsEventRecorder 判断结构体是否直接嵌入了 framework.EventRecorder
+// EventRecorder 不在 frameworkSource 中，展开嵌入字段时被跳过，不会映射为列
+func embedsEventRecorder(structType *ast.StructType, file *ast.File) bool {
+	for _, field := range structType.Fields.List {
+		selector, ok := field.Type.(*ast.SelectorExpr)
+		if len(field.Names) != 0 || !ok || selector.Sel.Name != "EventRecorder" {
+			continue
+		}
+		if pkgIdent, ok := selector.X.(*ast.Ident); ok && importPathOf(file, pkgIdent.Name) == frameworkImportPath {
+			return true
+		}
+	}
+	return false
+}
+
+// importedScope 返回文件中以 pkgName 导入的包的解析结果
+// 只能解析框架包和同一模块内的包，其他包返回 nil
+func (p *ASTParser) importedScope(file *ast.File, pkg *packageScope, pkgName string) *packageScope {
+	importPath := importPathOf(file, pkgName)
+	switch {
+	case importPath == "":
+		return nil
+	case importPath == frameworkImportPath:
+		return p.frameworkScope()
+	case pkg.modName != "" && strings.HasPrefix(importPath, pkg.modName+"/"):
+		dir := filepath.Join(pkg.modRoot, filepath.FromSlash(strings.TrimPrefix(importPath, pkg.modName+"/")))
+		loaded, err := p.loadPackageScope(importPath, dir, pkg.modRoot, pkg.modName)
+		if err != nil {
+			return nil
+		}
+		return loaded
+	}
+	return nil
+}
+
+// importPathOf 根据文件中的导入声明查找包名对应的 import 路径
+// 未使用别名时按路径最后一段匹配包名
+func importPathOf(file *ast.File, pkgName string) string {
+	for _, imp := range file.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		name := path[strings.LastIndex(path, "/")+1:]
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		if name == pkgName {
+			return path
+		}
+	}
+	return ""
+}
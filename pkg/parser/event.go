@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"go/ast"
+	"go/token"
+	"soliton/pkg/metadata"
+	"sort"
+)
+
+// Events 返回已解析的包中由专用结构体定义的领域事件（按包路径和声明顺序排列）
+//
+// 形如下面的结构体会被识别为 Order 发布的事件 OrderPlaced，结构体字段即事件携带的数据：
+//
+//	// OrderPlaced 订单已下单
+//	// +soliton:event(aggregate=Order)
+//	type OrderPlaced struct {
+//		OrderID int64
+//		Amount  float64
+//	}
+//
+// 同时标记了 +soliton:aggregate 的结构体按聚合根处理，其 +soliton:event 列出的是聚合根发布的事件。
+func (p *ASTParser) Events() []*metadata.EventMetadata {
+	// 解析字段时可能按需加载其他包，先固定要扫描的包
+	importPaths := make([]string, 0, len(p.packages))
+	for importPath := range p.packages {
+		importPaths = append(importPaths, importPath)
+	}
+	sort.Strings(importPaths)
+
+	var events []*metadata.EventMetadata
+	for _, importPath := range importPaths {
+		scope := p.packages[importPath]
+		filePaths := make([]string, 0, len(scope.files))
+		for filePath := range scope.files {
+			filePaths = append(filePaths, filePath)
+		}
+		sort.Strings(filePaths)
+
+		for _, filePath := range filePaths {
+			file := scope.files[filePath]
+			if ast.IsGenerated(file) {
+				continue
+			}
+			events = append(events, p.parseEventStructs(file, scope)...)
+		}
+	}
+	return events
+}
+
+// parseEventStructs 解析文件中标记 +soliton:event 的结构体
+func (p *ASTParser) parseEventStructs(file *ast.File, scope *packageScope) []*metadata.EventMetadata {
+	var events []*metadata.EventMetadata
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			structType, ok := typeSpec.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+
+			comments := p.extractComments(typeDoc(genDecl, typeSpec))
+			isEvent, aggregate, topic := p.annotationParser.ParseEventStructAnnotation(comments)
+			if !isEvent {
+				continue
+			}
+			if isAggregate, _, _, _ := p.annotationParser.ParseAggregateAnnotations(comments); isAggregate {
+				continue
+			}
+
+			event := &metadata.EventMetadata{
+				Name:          typeSpec.Name.Name,
+				AggregateName: aggregate,
+				TopicName:     topic,
+				Comment:       behaviorComment(typeSpec.Name.Name, comments),
+				Fields:        p.parseFields(structType, file, scope),
+				PackageName:   file.Name.Name,
+				ImportPath:    scope.importPath,
+				Pos:           p.fset.Position(typeSpec.Name.Pos()),
+			}
+			nodes := p.annotationParser.ParseCommentAnnotations(comments)
+			p.locateAnnotations(nodes, nil, typeDoc(genDecl, typeSpec))
+			if node := nodes.Get("event"); node != nil && node.Pos.IsValid() {
+				event.Pos = node.Pos
+			}
+			events = append(events, event)
+		}
+	}
+	return events
+}
@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// listGoFiles 列出目录中需要解析的 Go 源文件（跳过子目录和测试文件），按路径排序
+// 覆盖层中位于该目录的文件即使尚未写入磁盘也会列出
+func (p *ASTParser) listGoFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var filePaths []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		filePath := filepath.Join(dir, name)
+		if _, ok := p.overlay[filePath]; !ok {
+			filePaths = append(filePaths, filePath)
+		}
+	}
+	for filePath := range p.overlay {
+		if filepath.Dir(filePath) == dir {
+			filePaths = append(filePaths, filePath)
+		}
+	}
+	sort.Strings(filePaths)
+	return filePaths, nil
+}
+
+// parseFiles 使用协程池并发解析文件，返回文件路径 -> 语法树
+//
+// token.FileSet 的方法是并发安全的，所有协程共用 p.fset，位置信息与串行解析同样可用；
+// 但文件在 FileSet 中的先后顺序不再固定，比较不同文件中的位置时应使用 p.fset.Position。
+// 有文件解析失败时返回按路径排序的第一个错误。
+func (p *ASTParser) parseFiles(filePaths []string) (map[string]*ast.File, error) {
+	files := make([]*ast.File, len(filePaths))
+	errs := make([]error, len(filePaths))
+	parallel(len(filePaths), func(i int) {
+		var src any
+		if content, ok := p.overlay[filePaths[i]]; ok {
+			src = content
+		}
+		files[i], errs[i] = parser.ParseFile(p.fset, filePaths[i], src, parser.ParseComments)
+	})
+
+	result := make(map[string]*ast.File, len(filePaths))
+	var firstErr error
+	firstPath := ""
+	for i, filePath := range filePaths {
+		if errs[i] != nil {
+			if firstErr == nil || filePath < firstPath {
+				firstErr, firstPath = errs[i], filePath
+			}
+			continue
+		}
+		result[filePath] = files[i]
+	}
+	if firstErr != nil {
+		return nil, fmt.Errorf("解析目录 %s 失败: %w", filepath.Dir(firstPath), firstErr)
+	}
+	return result, nil
+}
+
+// parallel 使用不超过 GOMAXPROCS 个协程执行 fn(0) ... fn(n-1)，全部完成后返回
+// fn 只应写入下标 i 对应的结果，调用方在返回后按下标顺序合并，保证结果与串行执行一致
+func parallel(n int, fn func(i int)) {
+	workers := min(runtime.GOMAXPROCS(0), n)
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
@@ -0,0 +1,144 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"soliton/pkg/metadata"
+	"testing"
+)
+
+// writeManyFilesModule 创建包含 packages 个包、每个包 files 个文件的模型目录，返回 domain/model 目录
+//
+// 每个文件声明一个聚合根：嵌入同包另一个文件中的结构体、使用 const 枚举，并带一个拼写错误的注解用于诊断。
+func writeManyFilesModule(t testing.TB, packages, files int) string {
+	t.Helper()
+
+	sources := make(map[string]string)
+	for i := 0; i < packages; i++ {
+		pkg := fmt.Sprintf("p%02d", i)
+		dir := filepath.Join("domain", "model", pkg)
+		sources[filepath.Join(dir, "base.go")] = fmt.Sprintf(`package %s
+
+import "time"
+
+// Audit 审计字段
+type Audit struct {
+	CreatedAt time.Time `+"`db:\"created_at\"`"+`
+	UpdatedAt time.Time `+"`db:\"updated_at\"`"+`
+}
+
+// Status 状态
+type Status string
+
+const (
+	StatusDraft  Status = "DRAFT"
+	StatusActive Status = "ACTIVE"
+)
+`, pkg)
+		for j := 0; j < files; j++ {
+			sources[filepath.Join(dir, fmt.Sprintf("entity%02d.go", j))] = fmt.Sprintf(`package %[1]s
+
+// Entity%02[2]d 聚合根 %[2]d
+//
+// +soliton:aggregate
+// +soliton:context(%[1]s)
+type Entity%02[2]d struct {
+	Audit
+	ID     int64  `+"`db:\"id\"`"+`
+	// +soliton:unique
+	Code   string `+"`db:\"code\"`"+`
+	Status Status `+"`db:\"status\"`"+`
+	// +soliton:indx
+	Amount int64  `+"`db:\"amount\"`"+`
+}
+
+// Activate 激活
+func (e *Entity%02[2]d) Activate() {
+	e.Status = StatusActive
+}
+`, pkg, j)
+		}
+	}
+	return filepath.Join(writeTestModule(t, sources), "domain", "model")
+}
+
+// parseWithProcs 以 GOMAXPROCS 为 procs 解析 dir，procs 为 1 时协程池退化为串行解析
+func parseWithProcs(t testing.TB, dir string, procs int) ([]*metadata.AggregateMetadata, *ASTParser) {
+	t.Helper()
+
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(procs))
+	p := NewASTParser()
+	aggregates, err := p.ParseDirectory(dir)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	return aggregates, p
+}
+
+// parseSnapshot 返回解析结果中可比较的内容：元数据、各聚合根和字段的位置以及注解诊断
+func parseSnapshot(t testing.TB, aggregates []*metadata.AggregateMetadata, p *ASTParser) string {
+	t.Helper()
+
+	var positions []string
+	for _, agg := range aggregates {
+		positions = append(positions, agg.Name+" "+agg.Pos.String())
+		for _, field := range agg.Fields {
+			positions = append(positions, "  "+field.Name+" "+field.Pos.String())
+		}
+	}
+	var diagnostics []string
+	for _, diagnostic := range p.Diagnostics() {
+		diagnostics = append(diagnostics, diagnostic.String())
+	}
+
+	snapshot, err := json.MarshalIndent(map[string]any{
+		"aggregates":  aggregates,
+		"positions":   positions,
+		"diagnostics": diagnostics,
+	}, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(snapshot)
+}
+
+func TestParseDirectoryPooledMatchesSerial(t *testing.T) {
+	dir := writeManyFilesModule(t, 4, 8)
+
+	serial, serialParser := parseWithProcs(t, dir, 1)
+	if len(serial) != 32 {
+		t.Fatalf("应解析出 32 个聚合根，实际为 %d", len(serial))
+	}
+	if len(serialParser.Diagnostics()) != 32 {
+		t.Fatalf("应报告 32 个注解问题，实际为 %d", len(serialParser.Diagnostics()))
+	}
+	want := parseSnapshot(t, serial, serialParser)
+
+	// 多次解析以覆盖协程的不同调度顺序
+	for i := 0; i < 5; i++ {
+		pooled, pooledParser := parseWithProcs(t, dir, 8)
+		if got := parseSnapshot(t, pooled, pooledParser); got != want {
+			t.Fatalf("并发解析的结果与串行解析不一致:\n串行: %s\n并发: %s", want, got)
+		}
+	}
+}
+
+func BenchmarkParseDirectory(b *testing.B) {
+	dir := writeManyFilesModule(b, 20, 25)
+
+	for _, bench := range []struct {
+		name  string
+		procs int
+	}{
+		{"serial", 1},
+		{"pooled", runtime.NumCPU()},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				parseWithProcs(b, dir, bench.procs)
+			}
+		})
+	}
+}
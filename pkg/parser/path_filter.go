@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// SetIncludePatterns 设置包含的文件模式
+//
+// 设置后只有匹配任一模式的文件参与聚合根扫描，未设置时扫描所有 .go 文件。
+// 模式语法见 matchPattern。
+func (p *ASTParser) SetIncludePatterns(patterns []string) error {
+	if err := validatePatterns(patterns); err != nil {
+		return err
+	}
+	p.includePatterns = patterns
+	return nil
+}
+
+// SetExcludePatterns 设置排除的目录或文件模式
+//
+// 匹配的目录整体跳过，匹配的文件不参与聚合根扫描。
+// 无论是否设置，_test.go 文件、testdata、vendor 以及以 . 或 _ 开头的目录都会被跳过。
+func (p *ASTParser) SetExcludePatterns(patterns []string) error {
+	if err := validatePatterns(patterns); err != nil {
+		return err
+	}
+	p.excludePatterns = patterns
+	return nil
+}
+
+// skipDir 判断目录是否跳过
+// relPath 为相对于解析根目录的路径（使用 / 分隔），根目录本身为 "."
+func (p *ASTParser) skipDir(relPath string) bool {
+	if relPath == "." {
+		return false
+	}
+
+	name := path.Base(relPath)
+	if name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+		return true
+	}
+
+	return matchAny(p.excludePatterns, relPath)
+}
+
+// includeFile 判断文件是否参与聚合根扫描
+// relPath 为相对于解析根目录的路径（使用 / 分隔）
+func (p *ASTParser) includeFile(relPath string) bool {
+	if strings.HasSuffix(relPath, "_test.go") {
+		return false
+	}
+	if matchAny(p.excludePatterns, relPath) {
+		return false
+	}
+	if len(p.includePatterns) > 0 && !matchAny(p.includePatterns, relPath) {
+		return false
+	}
+	return true
+}
+
+// validatePatterns 校验模式语法
+func validatePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		for _, segment := range strings.Split(pattern, "/") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return fmt.Errorf("无效的匹配模式 %q: %w", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// matchAny 判断路径是否匹配任一模式
+func matchAny(patterns []string, relPath string) bool {
+	for _, pattern := range patterns {
+		if matchPattern(pattern, relPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPattern 判断相对路径是否匹配模式
+//
+// 模式语法与 path.Match 相同，另外支持：
+//   - 不含 / 的模式匹配路径的最后一段，如 "legacy" 匹配任意层级的 legacy 目录，"*_gen.go" 匹配任意目录下的文件
+//   - ** 匹配零个或多个目录层级，如 "order/**"、"**/internal/*.go"
+func matchPattern(pattern, relPath string) bool {
+	pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "./"), "/")
+	if !strings.Contains(pattern, "/") && pattern != "**" {
+		ok, _ := path.Match(pattern, path.Base(relPath))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(relPath, "/"))
+}
+
+// matchSegments 按路径段匹配，处理 ** 通配
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+
+	return len(segments) == 0
+}
@@ -0,0 +1,795 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/format"
+	goparser "go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"soliton/pkg/metadata"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaFileNames 目录中依次查找的模型定义文件名
+var SchemaFileNames = []string{"soliton.yaml", "soliton.yml", "soliton.json"}
+
+// Schema 模型定义文件（soliton.yaml / soliton.json）
+//
+// 不想在 Go 结构体上写注解时，可以用定义文件描述聚合根、字段、枚举和关系：
+//
+//	package: model
+//	enums:
+//	  - name: OrderStatus
+//	    values: [PENDING, PAID, CANCELLED]
+//	aggregates:
+//	  - name: Order
+//	    comment: 订单
+//	    context: ordering
+//	    fields:
+//	      - {name: ID, type: int64, id: true, strategy: snowflake}
+//	      - {name: OrderNo, type: string, unique: true, immutable: true}
+//	      - {name: UserID, type: int64, ref: User.ID, index: true}
+//	      - {name: Status, type: OrderStatus, default: PENDING}
+//	      - {name: CreatedAt, type: time.Time}
+//
+// 每一项都对应一个 +soliton 注解，含义与注解相同。
+type Schema struct {
+	Package      string               `yaml:"package,omitempty" json:"package,omitempty"`           // 模型包名，默认为定义文件所在目录名
+	Imports      []string             `yaml:"imports,omitempty" json:"imports,omitempty"`           // 字段类型引用的包（time 无需声明）
+	Enums        []*SchemaEnum        `yaml:"enums,omitempty" json:"enums,omitempty"`               // 枚举类型，生成 type + const 块
+	ValueObjects []*SchemaValueObject `yaml:"valueObjects,omitempty" json:"valueObjects,omitempty"` // 值对象结构体，供聚合根字段引用
+	Aggregates   []*SchemaAggregate   `yaml:"aggregates,omitempty" json:"aggregates,omitempty"`     // 聚合根
+}
+
+// SchemaEnum 枚举定义
+type SchemaEnum struct {
+	Name    string   `yaml:"name" json:"name"`                           // 类型名，如 OrderStatus
+	Comment string   `yaml:"comment,omitempty" json:"comment,omitempty"` // 注释
+	Type    string   `yaml:"type,omitempty" json:"type,omitempty"`       // 底层类型：string（默认）或 int
+	Values  []string `yaml:"values" json:"values"`                       // 枚举值；int 枚举按 iota 依次取值，这里只决定常量名
+}
+
+// SchemaValueObject 值对象定义，渲染为不带注解的普通结构体
+// 字段只使用 name、type 和 comment
+type SchemaValueObject struct {
+	Name    string         `yaml:"name" json:"name"`
+	Comment string         `yaml:"comment,omitempty" json:"comment,omitempty"`
+	Fields  []*SchemaField `yaml:"fields" json:"fields"`
+}
+
+// SchemaAggregate 聚合根定义
+type SchemaAggregate struct {
+	Name          string         `yaml:"name" json:"name"`
+	Comment       string         `yaml:"comment,omitempty" json:"comment,omitempty"`
+	Table         string         `yaml:"table,omitempty" json:"table,omitempty"`                 // +soliton:table(name=...)
+	Context       string         `yaml:"context,omitempty" json:"context,omitempty"`             // +soliton:context(...)
+	BaseEntity    string         `yaml:"baseEntity,omitempty" json:"baseEntity,omitempty"`       // +soliton:baseEntity(...)
+	ManyToMany    bool           `yaml:"manyToMany,omitempty" json:"manyToMany,omitempty"`       // +soliton:manyToMany
+	Entity        bool           `yaml:"entity,omitempty" json:"entity,omitempty"`               // +soliton:entity
+	Refs          []string       `yaml:"refs,omitempty" json:"refs,omitempty"`                   // +soliton:ref(...)
+	Events        []string       `yaml:"events,omitempty" json:"events,omitempty"`               // +soliton:event(...)
+	API           *SchemaAPI     `yaml:"api,omitempty" json:"api,omitempty"`                     // +soliton:api(...)
+	JoinTables    []*SchemaJoin  `yaml:"joinTables,omitempty" json:"joinTables,omitempty"`       // +soliton:manyToMany(table=..., left=..., right=...)
+	UniqueIndexes []*SchemaIndex `yaml:"uniqueIndexes,omitempty" json:"uniqueIndexes,omitempty"` // +soliton:uniqueIndex(...)
+	Indexes       []*SchemaIndex `yaml:"indexes,omitempty" json:"indexes,omitempty"`             // +soliton:index(fields=...)
+	Queries       []*SchemaQuery `yaml:"queries,omitempty" json:"queries,omitempty"`             // +soliton:query(...)
+	Fields        []*SchemaField `yaml:"fields" json:"fields"`
+}
+
+// SchemaAPI API 暴露配置，对应 +soliton:api(rest, path=..., ops=..., exclude=...)
+type SchemaAPI struct {
+	Protocols []string `yaml:"protocols,omitempty" json:"protocols,omitempty"`
+	Path      string   `yaml:"path,omitempty" json:"path,omitempty"`
+	Ops       []string `yaml:"ops,omitempty" json:"ops,omitempty"`
+	Exclude   []string `yaml:"exclude,omitempty" json:"exclude,omitempty"`
+}
+
+// SchemaJoin 多对多关联表配置，对应 +soliton:manyToMany(with=..., table=..., left=..., right=...)
+type SchemaJoin struct {
+	With  string `yaml:"with,omitempty" json:"with,omitempty"`
+	Table string `yaml:"table,omitempty" json:"table,omitempty"`
+	Left  string `yaml:"left,omitempty" json:"left,omitempty"`
+	Right string `yaml:"right,omitempty" json:"right,omitempty"`
+}
+
+// String 渲染为注解参数，如 table=user_roles, left=uid, right=rid
+func (j *SchemaJoin) String() string {
+	var args []string
+	for _, arg := range []struct{ key, value string }{
+		{"with", j.With}, {"table", j.Table}, {"left", j.Left}, {"right", j.Right},
+	} {
+		if arg.value != "" {
+			args = append(args, arg.key+"="+arg.value)
+		}
+	}
+	return strings.Join(args, ", ")
+}
+
+// SchemaIndex 组合索引定义
+type SchemaIndex struct {
+	Name   string   `yaml:"name" json:"name"`
+	Fields []string `yaml:"fields" json:"fields"`
+	Where  string   `yaml:"where,omitempty" json:"where,omitempty"` // 部分索引的条件，如 deleted_at IS NULL
+}
+
+// String 返回注解参数，如 name=uk_user_email, fields=TenantID,Email
+func (i *SchemaIndex) String() string {
+	var args []string
+	if i.Name != "" {
+		args = append(args, "name="+i.Name)
+	}
+	args = append(args, "fields="+strings.Join(i.Fields, ","))
+	if i.Where != "" {
+		args = append(args, `where="`+i.Where+`"`)
+	}
+	return strings.Join(args, ", ")
+}
+
+// SchemaQuery 查询定义，对应 +soliton:query(name=..., by=..., select=..., orderBy=...)
+type SchemaQuery struct {
+	Name    string   `yaml:"name" json:"name"`
+	By      []string `yaml:"by,omitempty" json:"by,omitempty"`
+	Select  []string `yaml:"select,omitempty" json:"select,omitempty"`
+	OrderBy []string `yaml:"orderBy,omitempty" json:"orderBy,omitempty"`
+	Comment string   `yaml:"comment,omitempty" json:"comment,omitempty"`
+}
+
+// String 返回注解参数，如 name=ListActiveOrders, by=Status,CreatedAt
+func (q *SchemaQuery) String() string {
+	args := []string{"name=" + q.Name}
+	for _, arg := range []struct {
+		key    string
+		values []string
+	}{
+		{"by", q.By}, {"select", q.Select}, {"orderBy", q.OrderBy},
+	} {
+		if len(arg.values) > 0 {
+			args = append(args, arg.key+"="+strings.Join(arg.values, ","))
+		}
+	}
+	if q.Comment != "" {
+		args = append(args, `comment="`+q.Comment+`"`)
+	}
+	return strings.Join(args, ", ")
+}
+
+// SchemaField 字段定义
+type SchemaField struct {
+	Name                string          `yaml:"name" json:"name"`
+	Type                string          `yaml:"type" json:"type"` // Go 类型表达式，如 int64、*string、[]*OrderItem、time.Time
+	Comment             string          `yaml:"comment,omitempty" json:"comment,omitempty"`
+	Column              string          `yaml:"column,omitempty" json:"column,omitempty"`                           // +soliton:column(name=...)
+	ColumnType          string          `yaml:"columnType,omitempty" json:"columnType,omitempty"`                   // +soliton:column(type=...)
+	ID                  bool            `yaml:"id,omitempty" json:"id,omitempty"`                                   // +soliton:id
+	PK                  bool            `yaml:"pk,omitempty" json:"pk,omitempty"`                                   // +soliton:pk，多个字段组成复合主键
+	Strategy            string          `yaml:"strategy,omitempty" json:"strategy,omitempty"`                       // +soliton:id(strategy=...)，隐含 id
+	Unique              bool            `yaml:"unique,omitempty" json:"unique,omitempty"`                           // +soliton:unique
+	Required            bool            `yaml:"required,omitempty" json:"required,omitempty"`                       // +soliton:required
+	Index               bool            `yaml:"index,omitempty" json:"index,omitempty"`                             // +soliton:index
+	Immutable           bool            `yaml:"immutable,omitempty" json:"immutable,omitempty"`                     // +soliton:immutable
+	Ignore              bool            `yaml:"ignore,omitempty" json:"ignore,omitempty"`                           // +soliton:ignore
+	Entity              bool            `yaml:"entity,omitempty" json:"entity,omitempty"`                           // +soliton:entity
+	ValueObject         bool            `yaml:"valueObject,omitempty" json:"valueObject,omitempty"`                 // +soliton:valueObject
+	ValueObjectStrategy string          `yaml:"valueObjectStrategy,omitempty" json:"valueObjectStrategy,omitempty"` // +soliton:valueObject(strategy=...)，隐含 valueObject
+	Sensitive           string          `yaml:"sensitive,omitempty" json:"sensitive,omitempty"`                     // +soliton:sensitive(strategy=...)
+	Ref                 string          `yaml:"ref,omitempty" json:"ref,omitempty"`                                 // +soliton:ref(User) 或 +soliton:ref(User.ID)
+	Polymorphic         []string        `yaml:"polymorphic,omitempty" json:"polymorphic,omitempty"`                 // +soliton:polymorphic(types=...)
+	Cascade             string          `yaml:"cascade,omitempty" json:"cascade,omitempty"`                         // +soliton:cascade(delete|nullify|restrict)
+	FK                  string          `yaml:"fk,omitempty" json:"fk,omitempty"`                                   // +soliton:fk(column=...)
+	Owner               bool            `yaml:"owner,omitempty" json:"owner,omitempty"`                             // +soliton:owner
+	External            bool            `yaml:"external,omitempty" json:"external,omitempty"`                       // +soliton:external
+	Filter              bool            `yaml:"filter,omitempty" json:"filter,omitempty"`                           // +soliton:filter
+	Sortable            bool            `yaml:"sortable,omitempty" json:"sortable,omitempty"`                       // +soliton:filter(sort)，隐含 filter
+	Enum                []string        `yaml:"enum,omitempty" json:"enum,omitempty"`                               // +soliton:enum(...)
+	Default             string          `yaml:"default,omitempty" json:"default,omitempty"`                         // +soliton:default(...)
+	Validate            *SchemaValidate `yaml:"validate,omitempty" json:"validate,omitempty"`                       // 校验规则
+}
+
+// SchemaValidate 字段校验规则定义
+type SchemaValidate struct {
+	Min       *float64 `yaml:"min,omitempty" json:"min,omitempty"`             // +soliton:validate(min=...)
+	Max       *float64 `yaml:"max,omitempty" json:"max,omitempty"`             // +soliton:validate(max=...)
+	MinLength *int     `yaml:"minLength,omitempty" json:"minLength,omitempty"` // +soliton:length(min=...)
+	MaxLength *int     `yaml:"maxLength,omitempty" json:"maxLength,omitempty"` // +soliton:length(max=...)
+	Pattern   string   `yaml:"pattern,omitempty" json:"pattern,omitempty"`     // +soliton:pattern(...)
+	Email     bool     `yaml:"email,omitempty" json:"email,omitempty"`         // +soliton:email
+}
+
+// FindSchemaFile 查找模型定义文件
+// path 是 .yaml / .yml / .json / .proto 文件时直接返回；是目录且包含 SchemaFileNames 中的文件时返回该文件；否则返回空
+func FindSchemaFile(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	if !info.IsDir() {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml", ".json", ".proto":
+			return path
+		}
+		return ""
+	}
+	for _, name := range SchemaFileNames {
+		candidate := filepath.Join(path, name)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// LoadSchema 读取模型定义文件，按扩展名选择 JSON、YAML 或 Protobuf 格式，不认识的键视为错误
+func LoadSchema(schemaPath string) (*Schema, error) {
+	if strings.EqualFold(filepath.Ext(schemaPath), ".proto") {
+		schema, err := loadProtoSchema(schemaPath)
+		if err != nil {
+			return nil, err
+		}
+		if err := schema.validate(); err != nil {
+			return nil, fmt.Errorf("模型定义文件 %s 有误: %w", schemaPath, err)
+		}
+		return schema, nil
+	}
+
+	data, err := os.ReadFile(schemaPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取模型定义文件失败: %w", err)
+	}
+
+	schema := &Schema{}
+	if strings.EqualFold(filepath.Ext(schemaPath), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(schema)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(schema)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析模型定义文件 %s 失败: %w", schemaPath, err)
+	}
+
+	if err := schema.validate(); err != nil {
+		return nil, fmt.Errorf("模型定义文件 %s 有误: %w", schemaPath, err)
+	}
+	return schema, nil
+}
+
+// validate 检查名称和类型是否合法，注解层面的问题（如引用不存在的聚合根）留给关系分析报告
+func (s *Schema) validate() error {
+	if s.Package != "" && !token.IsIdentifier(s.Package) {
+		return fmt.Errorf("包名 %q 不是合法的标识符", s.Package)
+	}
+
+	names := make(map[string]bool)
+	for _, enum := range s.Enums {
+		if !token.IsIdentifier(enum.Name) || !token.IsExported(enum.Name) {
+			return fmt.Errorf("枚举名 %q 必须是导出的标识符", enum.Name)
+		}
+		if names[enum.Name] {
+			return fmt.Errorf("类型 %s 重复定义", enum.Name)
+		}
+		names[enum.Name] = true
+		if enum.Type != "" && enum.Type != "string" && enum.Type != "int" {
+			return fmt.Errorf("枚举 %s 的类型 %q 不受支持，只能是 string 或 int", enum.Name, enum.Type)
+		}
+		if len(enum.Values) == 0 {
+			return fmt.Errorf("枚举 %s 没有声明值", enum.Name)
+		}
+	}
+
+	for _, vo := range s.ValueObjects {
+		if !token.IsIdentifier(vo.Name) || !token.IsExported(vo.Name) {
+			return fmt.Errorf("值对象名 %q 必须是导出的标识符", vo.Name)
+		}
+		if names[vo.Name] {
+			return fmt.Errorf("类型 %s 重复定义", vo.Name)
+		}
+		names[vo.Name] = true
+		if err := validateSchemaFields("值对象 "+vo.Name, vo.Fields); err != nil {
+			return err
+		}
+	}
+
+	for _, agg := range s.Aggregates {
+		if !token.IsIdentifier(agg.Name) || !token.IsExported(agg.Name) {
+			return fmt.Errorf("聚合根名 %q 必须是导出的标识符", agg.Name)
+		}
+		if names[agg.Name] {
+			return fmt.Errorf("类型 %s 重复定义", agg.Name)
+		}
+		names[agg.Name] = true
+		if err := validateSchemaFields("聚合根 "+agg.Name, agg.Fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateSchemaFields 检查字段名和类型，owner 用于错误信息，如 "聚合根 Order"
+func validateSchemaFields(owner string, fields []*SchemaField) error {
+	fieldNames := make(map[string]bool)
+	for _, field := range fields {
+		if !token.IsIdentifier(field.Name) || !token.IsExported(field.Name) {
+			return fmt.Errorf("%s 的字段名 %q 必须是导出的标识符", owner, field.Name)
+		}
+		if fieldNames[field.Name] {
+			return fmt.Errorf("%s 的字段 %s 重复定义", owner, field.Name)
+		}
+		fieldNames[field.Name] = true
+		if _, err := goparser.ParseExpr(field.Type); field.Type == "" || err != nil {
+			return fmt.Errorf("%s 的字段 %s 的类型 %q 不是合法的 Go 类型", owner, field.Name, field.Type)
+		}
+	}
+	return nil
+}
+
+// schemaHeader 由模型定义文件渲染的源文件头，每次运行都会重新生成
+const schemaHeader = "// Code generated by soliton from the model schema. DO NOT EDIT.\n\n"
+
+// Render 将模型定义渲染为带 +soliton 注解的 Go 源文件，返回 文件路径 -> 格式化后的内容
+//
+// 每个聚合根一个文件（如 order_item.go），枚举集中写入 enums.go，值对象集中写入 value_objects.go，文件都位于 dir 下。
+func (s *Schema) Render(dir string) (map[string][]byte, error) {
+	return s.render(dir, schemaHeader)
+}
+
+// RenderSkeleton 与 Render 相同，但文件头为 header（为空时不加文件头）
+// 用于生成交给用户继续维护的模型骨架，如从数据库导入的模型
+func (s *Schema) RenderSkeleton(dir, header string) (map[string][]byte, error) {
+	return s.render(dir, header)
+}
+
+// render 渲染模型定义，header 写在每个文件开头
+func (s *Schema) render(dir, header string) (map[string][]byte, error) {
+	packageName := s.Package
+	if packageName == "" {
+		packageName = filepath.Base(dir)
+	}
+	if !token.IsIdentifier(packageName) {
+		return nil, fmt.Errorf("目录名 %q 不能作为包名，请在模型定义中声明 package", packageName)
+	}
+
+	// 包名 -> import 路径
+	imports := map[string]string{"time": "time"}
+	for _, importPath := range s.Imports {
+		imports[importPackageName(importPath)] = importPath
+	}
+
+	files := make(map[string][]byte)
+	for _, agg := range s.Aggregates {
+		var body strings.Builder
+		s.renderAggregate(&body, agg)
+		used, err := usedImports(imports, "聚合根 "+agg.Name, agg.Fields)
+		if err != nil {
+			return nil, err
+		}
+
+		filePath := filepath.Join(dir, schemaSnakeCase(agg.Name)+".go")
+		content, err := renderSchemaFile(header, packageName, used, body.String())
+		if err != nil {
+			return nil, fmt.Errorf("渲染聚合根 %s 失败: %w", agg.Name, err)
+		}
+		files[filePath] = content
+	}
+
+	if len(s.Enums) > 0 {
+		var body strings.Builder
+		for _, enum := range s.Enums {
+			s.renderEnum(&body, enum)
+		}
+		content, err := renderSchemaFile(header, packageName, nil, body.String())
+		if err != nil {
+			return nil, fmt.Errorf("渲染枚举失败: %w", err)
+		}
+		files[filepath.Join(dir, "enums.go")] = content
+	}
+
+	if len(s.ValueObjects) > 0 {
+		var body strings.Builder
+		used := make(map[string]bool)
+		for _, vo := range s.ValueObjects {
+			s.renderValueObject(&body, vo)
+			voImports, err := usedImports(imports, "值对象 "+vo.Name, vo.Fields)
+			if err != nil {
+				return nil, err
+			}
+			for importPath := range voImports {
+				used[importPath] = true
+			}
+		}
+		content, err := renderSchemaFile(header, packageName, used, body.String())
+		if err != nil {
+			return nil, fmt.Errorf("渲染值对象失败: %w", err)
+		}
+		files[filepath.Join(dir, "value_objects.go")] = content
+	}
+
+	return files, nil
+}
+
+// usedImports 返回字段类型引用的 import 路径，imports 为 包名 -> import 路径
+func usedImports(imports map[string]string, owner string, fields []*SchemaField) (map[string]bool, error) {
+	used := make(map[string]bool)
+	for _, field := range fields {
+		expr, _ := goparser.ParseExpr(field.Type)
+		for _, pkgName := range referencedPackages(expr) {
+			if _, ok := imports[pkgName]; !ok {
+				return nil, fmt.Errorf("%s 的字段 %s 引用了未在 imports 中声明的包 %s", owner, field.Name, pkgName)
+			}
+			used[imports[pkgName]] = true
+		}
+	}
+	return used, nil
+}
+
+// renderSchemaFile 拼接文件头、import 和声明，并用 gofmt 格式化
+func renderSchemaFile(header, packageName string, imports map[string]bool, body string) ([]byte, error) {
+	var sb strings.Builder
+	sb.WriteString(header)
+	sb.WriteString(fmt.Sprintf("package %s\n\n", packageName))
+
+	if len(imports) > 0 {
+		paths := make([]string, 0, len(imports))
+		for importPath := range imports {
+			paths = append(paths, importPath)
+		}
+		sort.Strings(paths)
+
+		sb.WriteString("import (\n")
+		for _, importPath := range paths {
+			sb.WriteString(fmt.Sprintf("\t%q\n", importPath))
+		}
+		sb.WriteString(")\n\n")
+	}
+
+	sb.WriteString(body)
+	return format.Source([]byte(sb.String()))
+}
+
+// renderAggregate 渲染聚合根结构体，聚合根和字段的定义项转为对应的注解注释
+func (s *Schema) renderAggregate(sb *strings.Builder, agg *SchemaAggregate) {
+	comment := agg.Comment
+	if comment == "" {
+		comment = "聚合根"
+	}
+	writeSchemaComment(sb, "", agg.Name+" "+comment)
+	sb.WriteString("//\n")
+	sb.WriteString("// +soliton:aggregate\n")
+	if agg.Context != "" {
+		sb.WriteString(fmt.Sprintf("// +soliton:context(%s)\n", agg.Context))
+	}
+	if agg.Table != "" {
+		sb.WriteString(fmt.Sprintf("// +soliton:table(name=%s)\n", agg.Table))
+	}
+	if len(agg.Events) > 0 {
+		sb.WriteString(fmt.Sprintf("// +soliton:event(%s)\n", strings.Join(agg.Events, ", ")))
+	}
+	if api := agg.API; api != nil {
+		args := slices.Clone(api.Protocols)
+		if api.Path != "" {
+			args = append(args, "path="+api.Path)
+		}
+		if len(api.Ops) > 0 {
+			args = append(args, "ops="+strings.Join(api.Ops, ","))
+		}
+		if len(api.Exclude) > 0 {
+			args = append(args, "exclude="+strings.Join(api.Exclude, ","))
+		}
+		if len(args) == 0 {
+			sb.WriteString("// +soliton:api\n")
+		} else {
+			sb.WriteString(fmt.Sprintf("// +soliton:api(%s)\n", strings.Join(args, ", ")))
+		}
+	}
+	if agg.BaseEntity != "" {
+		sb.WriteString(fmt.Sprintf("// +soliton:baseEntity(%s)\n", agg.BaseEntity))
+	}
+	if agg.ManyToMany {
+		sb.WriteString("// +soliton:manyToMany\n")
+	}
+	if agg.Entity {
+		sb.WriteString("// +soliton:entity\n")
+	}
+	for _, ref := range agg.Refs {
+		sb.WriteString(fmt.Sprintf("// +soliton:ref(%s)\n", ref))
+	}
+	for _, join := range agg.JoinTables {
+		if args := join.String(); args != "" {
+			sb.WriteString(fmt.Sprintf("// +soliton:manyToMany(%s)\n", args))
+		}
+	}
+	for _, index := range agg.UniqueIndexes {
+		sb.WriteString(fmt.Sprintf("// +soliton:uniqueIndex(%s)\n", index))
+	}
+	for _, index := range agg.Indexes {
+		sb.WriteString(fmt.Sprintf("// +soliton:index(%s)\n", index))
+	}
+	for _, query := range agg.Queries {
+		sb.WriteString(fmt.Sprintf("// +soliton:query(%s)\n", query))
+	}
+
+	sb.WriteString(fmt.Sprintf("type %s struct {\n", agg.Name))
+	for _, field := range agg.Fields {
+		if field.Comment != "" {
+			writeSchemaComment(sb, "\t", field.Comment)
+		}
+		for _, annotation := range field.annotations() {
+			sb.WriteString(fmt.Sprintf("\t// %s\n", annotation))
+		}
+		sb.WriteString(fmt.Sprintf("\t%s %s\n", field.Name, field.Type))
+	}
+	sb.WriteString("}\n")
+}
+
+// renderValueObject 渲染值对象结构体
+func (s *Schema) renderValueObject(sb *strings.Builder, vo *SchemaValueObject) {
+	comment := vo.Comment
+	if comment == "" {
+		comment = "值对象"
+	}
+	writeSchemaComment(sb, "", vo.Name+" "+comment)
+	sb.WriteString(fmt.Sprintf("type %s struct {\n", vo.Name))
+	for _, field := range vo.Fields {
+		if field.Comment != "" {
+			writeSchemaComment(sb, "\t", field.Comment)
+		}
+		sb.WriteString(fmt.Sprintf("\t%s %s\n", field.Name, field.Type))
+	}
+	sb.WriteString("}\n\n")
+}
+
+// writeSchemaComment 写出注释，多行注释逐行加上 // 前缀
+func writeSchemaComment(sb *strings.Builder, indent, comment string) {
+	for _, line := range strings.Split(comment, "\n") {
+		if line = strings.TrimRight(line, " \t\r"); line == "" {
+			sb.WriteString(indent + "//\n")
+		} else {
+			sb.WriteString(fmt.Sprintf("%s// %s\n", indent, line))
+		}
+	}
+}
+
+// annotations 返回字段定义对应的注解，每个注解单独一行
+func (f *SchemaField) annotations() []string {
+	var annotations []string
+	switch {
+	case f.Strategy != "":
+		annotations = append(annotations, fmt.Sprintf("+soliton:id(strategy=%s)", f.Strategy))
+	case f.ID:
+		annotations = append(annotations, "+soliton:id")
+	}
+
+	var columnOptions []string
+	if f.Column != "" {
+		columnOptions = append(columnOptions, "name="+f.Column)
+	}
+	if f.ColumnType != "" {
+		columnOptions = append(columnOptions, "type="+f.ColumnType)
+	}
+	if len(columnOptions) > 0 {
+		annotations = append(annotations, fmt.Sprintf("+soliton:column(%s)", strings.Join(columnOptions, ", ")))
+	}
+
+	flags := []struct {
+		set        bool
+		annotation string
+	}{
+		{f.PK, "+soliton:pk"},
+		{f.Unique, "+soliton:unique"},
+		{f.Required, "+soliton:required"},
+		{f.Index, "+soliton:index"},
+		{f.Immutable, "+soliton:immutable"},
+		{f.Ignore, "+soliton:ignore"},
+		{f.Entity, "+soliton:entity"},
+	}
+	for _, flag := range flags {
+		if flag.set {
+			annotations = append(annotations, flag.annotation)
+		}
+	}
+
+	switch {
+	case f.ValueObjectStrategy != "":
+		annotations = append(annotations, fmt.Sprintf("+soliton:valueObject(strategy=%s)", f.ValueObjectStrategy))
+	case f.ValueObject:
+		annotations = append(annotations, "+soliton:valueObject")
+	}
+	if f.Sensitive != "" {
+		annotations = append(annotations, fmt.Sprintf("+soliton:sensitive(strategy=%s)", f.Sensitive))
+	}
+	if f.Ref != "" {
+		annotations = append(annotations, fmt.Sprintf("+soliton:ref(%s)", f.Ref))
+	}
+	if len(f.Polymorphic) > 0 {
+		annotations = append(annotations, fmt.Sprintf("+soliton:polymorphic(types=%s)", strings.Join(f.Polymorphic, ",")))
+	}
+	if f.Cascade != "" {
+		annotations = append(annotations, fmt.Sprintf("+soliton:cascade(%s)", f.Cascade))
+	}
+	if f.FK != "" {
+		annotations = append(annotations, fmt.Sprintf("+soliton:fk(column=%s)", f.FK))
+	}
+	if f.Owner {
+		annotations = append(annotations, "+soliton:owner")
+	}
+	if f.External {
+		annotations = append(annotations, "+soliton:external")
+	}
+	switch {
+	case f.Sortable:
+		annotations = append(annotations, "+soliton:filter(sort)")
+	case f.Filter:
+		annotations = append(annotations, "+soliton:filter")
+	}
+	if len(f.Enum) > 0 {
+		annotations = append(annotations, fmt.Sprintf("+soliton:enum(%s)", strings.Join(f.Enum, ",")))
+	}
+	if f.Default != "" {
+		annotations = append(annotations, fmt.Sprintf("+soliton:default(%s)", quoteDefault(f.Default)))
+	}
+
+	if v := f.Validate; v != nil {
+		var rangeOptions []string
+		if v.Min != nil {
+			rangeOptions = append(rangeOptions, "min="+strconv.FormatFloat(*v.Min, 'f', -1, 64))
+		}
+		if v.Max != nil {
+			rangeOptions = append(rangeOptions, "max="+strconv.FormatFloat(*v.Max, 'f', -1, 64))
+		}
+		if len(rangeOptions) > 0 {
+			annotations = append(annotations, fmt.Sprintf("+soliton:validate(%s)", strings.Join(rangeOptions, ", ")))
+		}
+
+		var lengthOptions []string
+		if v.MinLength != nil {
+			lengthOptions = append(lengthOptions, "min="+strconv.Itoa(*v.MinLength))
+		}
+		if v.MaxLength != nil {
+			lengthOptions = append(lengthOptions, "max="+strconv.Itoa(*v.MaxLength))
+		}
+		if len(lengthOptions) > 0 {
+			annotations = append(annotations, fmt.Sprintf("+soliton:length(%s)", strings.Join(lengthOptions, ", ")))
+		}
+
+		if v.Pattern != "" {
+			annotations = append(annotations, fmt.Sprintf("+soliton:pattern(%s)", v.Pattern))
+		}
+		if v.Email {
+			annotations = append(annotations, "+soliton:email")
+		}
+	}
+	return annotations
+}
+
+// quoteDefault 含有逗号、括号或首尾空白的默认值加上双引号，避免注解截断
+func quoteDefault(value string) string {
+	if strings.EqualFold(value, metadata.DefaultNow) {
+		return value
+	}
+	if strings.ContainsAny(value, ",()") || strings.TrimSpace(value) != value {
+		return `"` + value + `"`
+	}
+	return value
+}
+
+// renderEnum 渲染枚举的类型定义和 const 块，常量名为类型名加上值的驼峰形式，如 OrderStatusPending
+func (s *Schema) renderEnum(sb *strings.Builder, enum *SchemaEnum) {
+	underlying := enum.Type
+	if underlying == "" {
+		underlying = "string"
+	}
+	comment := enum.Comment
+	if comment == "" {
+		comment = "枚举"
+	}
+
+	writeSchemaComment(sb, "", enum.Name+" "+comment)
+	sb.WriteString(fmt.Sprintf("type %s %s\n\n", enum.Name, underlying))
+	sb.WriteString("const (\n")
+	for i, value := range enum.Values {
+		constant := enum.Name + schemaPascalCase(value)
+		switch {
+		case underlying == "int" && i == 0:
+			sb.WriteString(fmt.Sprintf("\t%s %s = iota\n", constant, enum.Name))
+		case underlying == "int":
+			sb.WriteString(fmt.Sprintf("\t%s\n", constant))
+		default:
+			sb.WriteString(fmt.Sprintf("\t%s %s = %q\n", constant, enum.Name, value))
+		}
+	}
+	sb.WriteString(")\n\n")
+}
+
+// referencedPackages 返回类型表达式中引用的包名，如 map[string]*decimal.Decimal -> [decimal]
+func referencedPackages(expr ast.Expr) []string {
+	var names []string
+	ast.Inspect(expr, func(node ast.Node) bool {
+		if selector, ok := node.(*ast.SelectorExpr); ok {
+			if ident, ok := selector.X.(*ast.Ident); ok {
+				names = append(names, ident.Name)
+			}
+			return false
+		}
+		return true
+	})
+	return names
+}
+
+// importPackageName 按惯例推断 import 路径对应的包名：取最后一段，
+// 忽略 /v2 等主版本后缀、gopkg.in 的 .v3 后缀和 go- 前缀，如 gopkg.in/yaml.v3 -> yaml
+func importPackageName(importPath string) string {
+	name := path.Base(importPath)
+	if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = path.Base(path.Dir(importPath))
+	}
+	name, _, _ = strings.Cut(name, ".")
+	return strings.TrimPrefix(name, "go-")
+}
+
+// schemaPascalCase 将枚举值转为驼峰形式，如 PENDING -> Pending、in_progress -> InProgress
+func schemaPascalCase(value string) string {
+	var sb strings.Builder
+	for _, word := range strings.FieldsFunc(value, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		sb.WriteString(strings.ToUpper(word[:1]))
+		sb.WriteString(strings.ToLower(word[1:]))
+	}
+	return sb.String()
+}
+
+// schemaSnakeCase 转换为蛇形命名，用于生成的文件名，如 OrderItem -> order_item
+func schemaSnakeCase(s string) string {
+	var result []rune
+	runes := []rune(s)
+	for i, r := range runes {
+		if r >= 'A' && r <= 'Z' && i > 0 &&
+			(runes[i-1] >= 'a' && runes[i-1] <= 'z' ||
+				i < len(runes)-1 && runes[i-1] >= 'A' && runes[i-1] <= 'Z' && runes[i+1] >= 'a' && runes[i+1] <= 'z') {
+			result = append(result, '_')
+		}
+		result = append(result, r)
+	}
+	return strings.ToLower(string(result))
+}
+
+// ParseSchema 解析模型定义文件
+//
+// 定义先渲染为带注解的 Go 源文件（位于定义文件所在目录），再按 ParseDirectory 解析，
+// 得到的元数据与直接在结构体上写注解完全一致。渲染结果只保存在内存中，
+// 调用方通过 SchemaFiles 取得后写出，后续生成的代码才能引用这些类型。
+func (p *ASTParser) ParseSchema(schemaPath string) ([]*metadata.AggregateMetadata, error) {
+	schema, err := LoadSchema(schemaPath)
+	if err != nil {
+		return nil, err
+	}
+
+	absDir, err := filepath.Abs(filepath.Dir(schemaPath))
+	if err != nil {
+		return nil, fmt.Errorf("获取绝对路径失败: %w", err)
+	}
+	files, err := schema.Render(absDir)
+	if err != nil {
+		return nil, fmt.Errorf("渲染模型定义失败: %w", err)
+	}
+	p.overlay = files
+
+	return p.ParseDirectory(absDir)
+}
+
+// SchemaFiles 返回 ParseSchema 渲染的 Go 源文件（绝对路径 -> 内容），未解析定义文件时为空
+func (p *ASTParser) SchemaFiles() map[string][]byte {
+	return p.overlay
+}
@@ -0,0 +1,730 @@
+package parser
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/emicklei/proto"
+)
+
+// protoOptionPrefix soliton 自定义选项的前缀，选项声明见 proto/soliton/options.proto
+const protoOptionPrefix = "soliton."
+
+// protoScalarTypes Protobuf 标量类型 -> Go 类型
+var protoScalarTypes = map[string]string{
+	"double":   "float64",
+	"float":    "float32",
+	"int32":    "int32",
+	"sint32":   "int32",
+	"sfixed32": "int32",
+	"int64":    "int64",
+	"sint64":   "int64",
+	"sfixed64": "int64",
+	"uint32":   "uint32",
+	"fixed32":  "uint32",
+	"uint64":   "uint64",
+	"fixed64":  "uint64",
+	"bool":     "bool",
+	"string":   "string",
+	"bytes":    "[]byte",
+}
+
+// protoWellKnownTypes 常用的 google.protobuf 类型 -> Go 类型，包装类型映射为指针表示可空
+var protoWellKnownTypes = map[string]string{
+	"google.protobuf.Timestamp":   "time.Time",
+	"google.protobuf.Duration":    "time.Duration",
+	"google.protobuf.StringValue": "*string",
+	"google.protobuf.BoolValue":   "*bool",
+	"google.protobuf.Int32Value":  "*int32",
+	"google.protobuf.Int64Value":  "*int64",
+	"google.protobuf.UInt32Value": "*uint32",
+	"google.protobuf.UInt64Value": "*uint64",
+	"google.protobuf.FloatValue":  "*float32",
+	"google.protobuf.DoubleValue": "*float64",
+	"google.protobuf.BytesValue":  "[]byte",
+}
+
+// protoInitialisms 字段名转换时整体大写的缩写，如 user_id -> UserID
+var protoInitialisms = map[string]bool{
+	"id": true, "uid": true, "uuid": true, "url": true, "uri": true, "ip": true,
+	"api": true, "http": true, "json": true, "xml": true, "html": true, "sql": true, "sku": true,
+}
+
+// protoMessage 待转换的消息
+type protoMessage struct {
+	name      string // Go 类型名，嵌套消息为外层名加内层名，如 Order.Item -> OrderItem
+	fullName  string // 去掉包名的 proto 全名，如 Order.Item
+	message   *proto.Message
+	aggregate bool
+}
+
+// protoLoader 将 .proto 文件中的消息和枚举转换为模型定义
+//
+// 带 (soliton.aggregate) 选项（或注释中写了 +soliton:aggregate）的消息转为聚合根，
+// 其余消息转为值对象，枚举转为字符串枚举。同目录下 import 的 .proto 一并加载，
+// google/ 和 soliton/ 下的文件只提供类型和选项声明，不加载。
+type protoLoader struct {
+	root     string                   // 主文件所在目录，import 路径相对于该目录
+	loaded   map[string]bool          // 已加载的文件
+	packages []string                 // 已加载文件的 proto 包名
+	decls    map[string]string        // proto 全名 -> 声明类型：message 或 enum
+	messages []*protoMessage          // 按声明顺序排列的消息
+	byName   map[string]*protoMessage // proto 全名 -> 消息
+	enums    []*SchemaEnum
+	schema   *Schema
+}
+
+// loadProtoSchema 读取 .proto 文件并转换为模型定义
+func loadProtoSchema(protoPath string) (*Schema, error) {
+	loader := &protoLoader{
+		root:   filepath.Dir(protoPath),
+		loaded: make(map[string]bool),
+		decls:  make(map[string]string),
+		byName: make(map[string]*protoMessage),
+		schema: &Schema{},
+	}
+	if err := loader.load(protoPath, true); err != nil {
+		return nil, err
+	}
+	if err := loader.convert(); err != nil {
+		return nil, err
+	}
+	return loader.schema, nil
+}
+
+// load 解析文件并登记其中的消息和枚举，main 表示命令行指定的主文件（只从主文件读取 go_package）
+func (l *protoLoader) load(protoPath string, main bool) error {
+	absPath, err := filepath.Abs(protoPath)
+	if err != nil {
+		return fmt.Errorf("获取绝对路径失败: %w", err)
+	}
+	if l.loaded[absPath] {
+		return nil
+	}
+	l.loaded[absPath] = true
+
+	file, err := os.Open(protoPath)
+	if err != nil {
+		return fmt.Errorf("读取模型定义文件失败: %w", err)
+	}
+	defer file.Close()
+
+	protoParser := proto.NewParser(file)
+	protoParser.Filename(protoPath)
+	definition, err := protoParser.Parse()
+	if err != nil {
+		return fmt.Errorf("解析模型定义文件 %s 失败: %w", protoPath, err)
+	}
+
+	for _, element := range definition.Elements {
+		switch e := element.(type) {
+		case *proto.Package:
+			l.packages = append(l.packages, e.Name)
+		case *proto.Option:
+			if main && e.Name == "go_package" {
+				l.schema.Package = protoGoPackage(e.Constant.Source)
+			}
+		case *proto.Import:
+			if strings.HasPrefix(e.Filename, "google/") || strings.HasPrefix(e.Filename, "soliton/") {
+				continue
+			}
+			importPath := filepath.Join(l.root, filepath.FromSlash(e.Filename))
+			if _, err := os.Stat(importPath); err != nil {
+				importPath = filepath.Join(filepath.Dir(protoPath), filepath.FromSlash(e.Filename))
+			}
+			if err := l.load(importPath, false); err != nil {
+				return err
+			}
+		case *proto.Message:
+			if err := l.declareMessage(e, ""); err != nil {
+				return err
+			}
+		case *proto.Enum:
+			if err := l.declareEnum(e, ""); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// declareMessage 登记消息及其嵌套的消息和枚举，scope 为外层消息的 proto 全名
+func (l *protoLoader) declareMessage(message *proto.Message, scope string) error {
+	if message.IsExtend {
+		return nil
+	}
+	fullName := protoJoinName(scope, message.Name)
+	if _, ok := l.decls[fullName]; ok {
+		return fmt.Errorf("%s: 类型 %s 重复定义", message.Position, fullName)
+	}
+	l.decls[fullName] = "message"
+
+	msg := &protoMessage{
+		name:     strings.ReplaceAll(fullName, ".", ""),
+		fullName: fullName,
+		message:  message,
+	}
+	l.messages = append(l.messages, msg)
+	l.byName[fullName] = msg
+
+	for _, element := range message.Elements {
+		switch e := element.(type) {
+		case *proto.Message:
+			if err := l.declareMessage(e, fullName); err != nil {
+				return err
+			}
+		case *proto.Enum:
+			if err := l.declareEnum(e, fullName); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// declareEnum 登记枚举并转换为枚举定义
+//
+// 按 Protobuf 惯例，枚举值带有枚举名前缀且 0 值为 XXX_UNSPECIFIED：
+// 转换时去掉前缀并跳过 UNSPECIFIED，如 ORDER_STATUS_PAID -> PAID。
+func (l *protoLoader) declareEnum(enum *proto.Enum, scope string) error {
+	fullName := protoJoinName(scope, enum.Name)
+	if _, ok := l.decls[fullName]; ok {
+		return fmt.Errorf("%s: 类型 %s 重复定义", enum.Position, fullName)
+	}
+	l.decls[fullName] = "enum"
+
+	prefix := strings.ToUpper(schemaSnakeCase(enum.Name)) + "_"
+	schemaEnum := &SchemaEnum{
+		Name:    strings.ReplaceAll(fullName, ".", ""),
+		Comment: trimTypeName(protoComment(enum.Comment, nil), enum.Name),
+	}
+	for _, element := range enum.Elements {
+		field, ok := element.(*proto.EnumField)
+		if !ok {
+			continue
+		}
+		value := strings.TrimPrefix(field.Name, prefix)
+		if field.Integer == 0 && value == "UNSPECIFIED" {
+			continue
+		}
+		schemaEnum.Values = append(schemaEnum.Values, value)
+	}
+	l.enums = append(l.enums, schemaEnum)
+	return nil
+}
+
+// convert 在所有类型登记完成后转换消息，字段类型可以引用其他文件或后面声明的类型
+func (l *protoLoader) convert() error {
+	l.schema.Enums = l.enums
+
+	for _, msg := range l.messages {
+		if err := l.markAggregate(msg); err != nil {
+			return err
+		}
+	}
+
+	for _, msg := range l.messages {
+		comment := protoComment(msg.message.Comment, nil)
+		if msg.aggregate {
+			agg, err := l.convertAggregate(msg, comment)
+			if err != nil {
+				return err
+			}
+			l.schema.Aggregates = append(l.schema.Aggregates, agg)
+			continue
+		}
+
+		fields, err := l.convertFields(msg)
+		if err != nil {
+			return err
+		}
+		vo := &SchemaValueObject{Name: msg.name, Comment: trimTypeName(comment, msg.message.Name)}
+		for _, field := range fields {
+			vo.Fields = append(vo.Fields, &SchemaField{Name: field.Name, Type: field.Type, Comment: field.Comment})
+		}
+		l.schema.ValueObjects = append(l.schema.ValueObjects, vo)
+	}
+	return nil
+}
+
+// markAggregate 根据 (soliton.aggregate) 选项或 +soliton:aggregate 注释判断消息是否为聚合根
+func (l *protoLoader) markAggregate(msg *protoMessage) error {
+	for _, element := range msg.message.Elements {
+		option, ok := element.(*proto.Option)
+		if !ok || protoOptionName(option) != "aggregate" {
+			continue
+		}
+		aggregate, err := protoBool(option)
+		if err != nil {
+			return err
+		}
+		msg.aggregate = aggregate
+		return nil
+	}
+	if msg.message.Comment != nil {
+		for _, line := range msg.message.Comment.Lines {
+			if strings.TrimSpace(line) == "+soliton:aggregate" {
+				msg.aggregate = true
+			}
+		}
+	}
+	return nil
+}
+
+// convertAggregate 将消息转换为聚合根定义
+func (l *protoLoader) convertAggregate(msg *protoMessage, comment string) (*SchemaAggregate, error) {
+	agg := &SchemaAggregate{Name: msg.name, Comment: trimTypeName(comment, msg.message.Name)}
+
+	for _, element := range msg.message.Elements {
+		option, ok := element.(*proto.Option)
+		if !ok {
+			continue
+		}
+		name := protoOptionName(option)
+		if name == "" {
+			continue
+		}
+
+		var err error
+		switch name {
+		case "aggregate":
+		case "table":
+			agg.Table = option.Constant.Source
+		case "context":
+			agg.Context = option.Constant.Source
+		case "base_entity":
+			agg.BaseEntity = option.Constant.Source
+		case "many_to_many":
+			agg.ManyToMany, err = protoBool(option)
+		case "is_entity":
+			agg.Entity, err = protoBool(option)
+		case "refs":
+			agg.Refs = append(agg.Refs, protoStrings(option)...)
+		case "join_table":
+			for _, value := range protoStrings(option) {
+				agg.JoinTables = append(agg.JoinTables, parseProtoJoinTable(value))
+			}
+		case "unique_index":
+			for _, value := range protoStrings(option) {
+				agg.UniqueIndexes = append(agg.UniqueIndexes, parseProtoUniqueIndex(value))
+			}
+		default:
+			err = fmt.Errorf("%s: 消息 %s 不支持选项 (%s%s)", option.Position, msg.fullName, protoOptionPrefix, name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fields, err := l.convertFields(msg)
+	if err != nil {
+		return nil, err
+	}
+	agg.Fields = fields
+	return agg, nil
+}
+
+// convertFields 转换消息的普通字段、map 字段和 oneof 字段，oneof 中的字段都视为可空
+func (l *protoLoader) convertFields(msg *protoMessage) ([]*SchemaField, error) {
+	var fields []*SchemaField
+	for _, element := range msg.message.Elements {
+		var field *SchemaField
+		var err error
+		switch e := element.(type) {
+		case *proto.NormalField:
+			field, err = l.convertField(msg, e.Field, e.Repeated, e.Optional, "", protoComment(e.Comment, e.InlineComment))
+		case *proto.MapField:
+			field, err = l.convertField(msg, e.Field, false, false, e.KeyType, protoComment(e.Comment, e.InlineComment))
+		case *proto.Oneof:
+			oneofFields, err := l.convertOneof(msg, e)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, oneofFields...)
+			continue
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// convertOneof 转换 oneof 中的字段
+// oneof 内字段的行尾注释会被解析为下一个字段的前置注释，这里按行号归还给所在行的字段
+func (l *protoLoader) convertOneof(msg *protoMessage, oneof *proto.Oneof) ([]*SchemaField, error) {
+	var members []*proto.Field
+	for _, element := range oneof.Elements {
+		if member, ok := element.(*proto.OneOfField); ok {
+			members = append(members, member.Field)
+		}
+	}
+
+	var fields []*SchemaField
+	for i, member := range members {
+		leading, inline := member.Comment, member.InlineComment
+		if leading != nil && i > 0 && leading.Position.Line == members[i-1].Position.Line {
+			leading = nil
+		}
+		if i+1 < len(members) {
+			if next := members[i+1].Comment; next != nil && next.Position.Line == member.Position.Line {
+				inline = next
+			}
+		}
+		field, err := l.convertField(msg, member, false, true, "", protoComment(leading, inline))
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// convertField 转换单个字段，mapKey 不为空时为 map 字段
+//
+// 字段名由蛇形转为驼峰（如 user_id -> UserID），转换后的列名与原字段名不一致时记录 column。
+// 引用聚合根的字段为指针或指针切片；值对象、重复字段和 map 字段自动标记为值对象，整体序列化存储。
+func (l *protoLoader) convertField(msg *protoMessage, field *proto.Field, repeated, optional bool, mapKey, comment string) (*SchemaField, error) {
+	schemaField := &SchemaField{Name: protoFieldName(field.Name), Comment: comment}
+	if schemaSnakeCase(schemaField.Name) != field.Name && field.Name == strings.ToLower(field.Name) {
+		schemaField.Column = field.Name
+	}
+	if err := l.applyFieldOptions(msg, field, schemaField); err != nil {
+		return nil, err
+	}
+
+	goType, kind, err := l.resolveType(field.Type, msg.fullName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: 字段 %s.%s %w", field.Position, msg.fullName, field.Name, err)
+	}
+
+	// 引用聚合根或标记为实体的消息使用指针，与手写模型的惯例一致
+	pointer := kind == "aggregate" || kind == "message" && schemaField.Entity
+	switch {
+	case mapKey != "":
+		keyType, ok := protoScalarTypes[mapKey]
+		if !ok || keyType == "[]byte" {
+			return nil, fmt.Errorf("%s: 字段 %s.%s 的 map 键类型 %s 不受支持", field.Position, msg.fullName, field.Name, mapKey)
+		}
+		if pointer {
+			goType = "*" + goType
+		}
+		schemaField.Type = fmt.Sprintf("map[%s]%s", keyType, goType)
+	case repeated && pointer:
+		schemaField.Type = "[]*" + goType
+	case repeated:
+		schemaField.Type = "[]" + goType
+	case pointer || optional && !strings.HasPrefix(goType, "*") && !strings.HasPrefix(goType, "[]"):
+		schemaField.Type = "*" + goType
+	default:
+		schemaField.Type = goType
+	}
+
+	implied := mapKey != "" || repeated && kind != "aggregate" && !schemaField.Entity || kind == "message" && !schemaField.Entity
+	if implied && !schemaField.Ignore && !schemaField.ValueObject && schemaField.ValueObjectStrategy == "" && schemaField.Type != "[]byte" {
+		schemaField.ValueObject = true
+	}
+	return schemaField, nil
+}
+
+// applyFieldOptions 将字段的 soliton 选项转换为字段定义
+func (l *protoLoader) applyFieldOptions(msg *protoMessage, field *proto.Field, schemaField *SchemaField) error {
+	validate := &SchemaValidate{}
+	for _, option := range field.Options {
+		name := protoOptionName(option)
+		if name == "" {
+			continue
+		}
+
+		var err error
+		switch name {
+		case "id":
+			schemaField.ID, err = protoBool(option)
+		case "pk":
+			schemaField.PK, err = protoBool(option)
+		case "strategy":
+			schemaField.Strategy = option.Constant.Source
+		case "column":
+			schemaField.Column = option.Constant.Source
+		case "column_type":
+			schemaField.ColumnType = option.Constant.Source
+		case "unique":
+			schemaField.Unique, err = protoBool(option)
+		case "required":
+			schemaField.Required, err = protoBool(option)
+		case "index":
+			schemaField.Index, err = protoBool(option)
+		case "immutable":
+			schemaField.Immutable, err = protoBool(option)
+		case "ignore":
+			schemaField.Ignore, err = protoBool(option)
+		case "entity":
+			schemaField.Entity, err = protoBool(option)
+		case "value_object":
+			schemaField.ValueObject, err = protoBool(option)
+		case "value_object_strategy":
+			schemaField.ValueObjectStrategy = option.Constant.Source
+		case "sensitive":
+			schemaField.Sensitive = option.Constant.Source
+		case "ref":
+			schemaField.Ref = option.Constant.Source
+		case "polymorphic":
+			for _, value := range protoStrings(option) {
+				for _, item := range strings.Split(value, ",") {
+					if item = strings.TrimSpace(item); item != "" {
+						schemaField.Polymorphic = append(schemaField.Polymorphic, item)
+					}
+				}
+			}
+		case "cascade":
+			schemaField.Cascade = option.Constant.Source
+		case "fk":
+			schemaField.FK = option.Constant.Source
+		case "owner":
+			schemaField.Owner, err = protoBool(option)
+		case "external":
+			schemaField.External, err = protoBool(option)
+		case "filter":
+			schemaField.Filter, err = protoBool(option)
+		case "sortable":
+			schemaField.Sortable, err = protoBool(option)
+		case "enum":
+			for _, value := range protoStrings(option) {
+				for _, item := range strings.Split(value, ",") {
+					if item = strings.TrimSpace(item); item != "" {
+						schemaField.Enum = append(schemaField.Enum, item)
+					}
+				}
+			}
+		case "default":
+			schemaField.Default = option.Constant.Source
+		case "min":
+			validate.Min, err = protoFloat(option)
+		case "max":
+			validate.Max, err = protoFloat(option)
+		case "min_length":
+			validate.MinLength, err = protoInt(option)
+		case "max_length":
+			validate.MaxLength, err = protoInt(option)
+		case "pattern":
+			validate.Pattern = option.Constant.Source
+		case "email":
+			validate.Email, err = protoBool(option)
+		default:
+			err = fmt.Errorf("%s: 字段 %s.%s 不支持选项 (%s%s)", option.Position, msg.fullName, field.Name, protoOptionPrefix, name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if validate.Min != nil || validate.Max != nil || validate.MinLength != nil || validate.MaxLength != nil ||
+		validate.Pattern != "" || validate.Email {
+		schemaField.Validate = validate
+	}
+	return nil
+}
+
+// resolveType 解析字段类型，返回 Go 类型和类型种类：scalar、enum、message（值对象）或 aggregate
+//
+// 消息和枚举按 Protobuf 的作用域规则查找：先在当前消息内查找嵌套类型，再逐层向外，
+// 类型名中的包名前缀（如 shop.v1.Order 中的 shop.v1）会被忽略。
+func (l *protoLoader) resolveType(typeName, scope string) (string, string, error) {
+	if goType, ok := protoScalarTypes[typeName]; ok {
+		return goType, "scalar", nil
+	}
+	name := strings.TrimPrefix(typeName, ".")
+	if goType, ok := protoWellKnownTypes[name]; ok {
+		return goType, "scalar", nil
+	}
+	for _, pkg := range l.packages {
+		if strings.HasPrefix(name, pkg+".") {
+			name = strings.TrimPrefix(name, pkg+".")
+			break
+		}
+	}
+
+	for {
+		candidate := protoJoinName(scope, name)
+		if kind, ok := l.decls[candidate]; ok {
+			goType := strings.ReplaceAll(candidate, ".", "")
+			if kind == "message" && l.byName[candidate].aggregate {
+				kind = "aggregate"
+			}
+			return goType, kind, nil
+		}
+		if scope == "" {
+			return "", "", fmt.Errorf("的类型 %s 未定义", typeName)
+		}
+		if i := strings.LastIndex(scope, "."); i >= 0 {
+			scope = scope[:i]
+		} else {
+			scope = ""
+		}
+	}
+}
+
+// protoOptionName 返回 soliton 选项名，如 (soliton.table) -> table，其他选项返回空
+func protoOptionName(option *proto.Option) string {
+	name := strings.TrimSuffix(strings.TrimPrefix(option.Name, "("), ")")
+	if !strings.HasPrefix(name, protoOptionPrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(name, protoOptionPrefix)
+}
+
+// protoBool 读取布尔选项值
+func protoBool(option *proto.Option) (bool, error) {
+	value, err := strconv.ParseBool(option.Constant.Source)
+	if err != nil || option.Constant.IsString {
+		return false, fmt.Errorf("%s: 选项 %s 的值必须是 true 或 false", option.Position, option.Name)
+	}
+	return value, nil
+}
+
+// protoFloat 读取数值选项值
+func protoFloat(option *proto.Option) (*float64, error) {
+	value, err := strconv.ParseFloat(option.Constant.Source, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s: 选项 %s 的值 %q 不是数字", option.Position, option.Name, option.Constant.Source)
+	}
+	return &value, nil
+}
+
+// protoInt 读取整数选项值
+func protoInt(option *proto.Option) (*int, error) {
+	value, err := strconv.Atoi(option.Constant.Source)
+	if err != nil {
+		return nil, fmt.Errorf("%s: 选项 %s 的值 %q 不是整数", option.Position, option.Name, option.Constant.Source)
+	}
+	return &value, nil
+}
+
+// protoStrings 读取字符串或字符串数组选项值
+func protoStrings(option *proto.Option) []string {
+	if len(option.Constant.Array) == 0 {
+		return []string{option.Constant.Source}
+	}
+	values := make([]string, 0, len(option.Constant.Array))
+	for _, item := range option.Constant.Array {
+		values = append(values, item.Source)
+	}
+	return values
+}
+
+// parseProtoUniqueIndex 解析 (soliton.unique_index) 的值，格式与注解参数相同，
+// 如 "name=uk_user_email, fields=UserID,Email"，也可以只写字段列表 "UserID,Email"
+func parseProtoUniqueIndex(value string) *SchemaIndex {
+	index := &SchemaIndex{}
+	fieldList := value
+	if i := strings.Index(value, "fields="); i >= 0 {
+		fieldList = value[i+len("fields="):]
+		for _, part := range strings.Split(value[:i], ",") {
+			if key, name, ok := strings.Cut(strings.TrimSpace(part), "="); ok && strings.TrimSpace(key) == "name" {
+				index.Name = strings.TrimSpace(name)
+			}
+		}
+	}
+	for _, field := range strings.Split(fieldList, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			index.Fields = append(index.Fields, field)
+		}
+	}
+	return index
+}
+
+// parseProtoJoinTable 解析 join_table 选项的值，如 "table=user_roles, left=uid, right=rid"
+func parseProtoJoinTable(value string) *SchemaJoin {
+	join := &SchemaJoin{}
+	for _, part := range strings.Split(value, ",") {
+		key, arg, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		arg = strings.TrimSpace(arg)
+		switch strings.TrimSpace(key) {
+		case "with":
+			join.With = arg
+		case "table":
+			join.Table = arg
+		case "left":
+			join.Left = arg
+		case "right":
+			join.Right = arg
+		}
+	}
+	return join
+}
+
+// protoComment 合并注释行，没有前置注释时使用行尾注释
+func protoComment(comment, inline *proto.Comment) string {
+	if comment == nil || len(comment.Lines) == 0 {
+		comment = inline
+	}
+	if comment == nil {
+		return ""
+	}
+	lines := make([]string, 0, len(comment.Lines))
+	for _, line := range comment.Lines {
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// trimTypeName 去掉注释开头的类型名和 +soliton:aggregate 行，渲染时会重新加上
+func trimTypeName(comment, name string) string {
+	lines := strings.Split(comment, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line != "+soliton:aggregate" {
+			kept = append(kept, line)
+		}
+	}
+	comment = strings.TrimSpace(strings.Join(kept, "\n"))
+	return strings.TrimSpace(strings.TrimPrefix(comment, name+" "))
+}
+
+// protoGoPackage 从 go_package 选项取得包名，如 example.com/shop/model;model -> model
+// 取不到合法包名时返回空，由渲染时使用目录名
+func protoGoPackage(goPackage string) string {
+	name := importPackageName(goPackage)
+	if _, alias, ok := strings.Cut(goPackage, ";"); ok {
+		name = alias
+	}
+	if !token.IsIdentifier(name) {
+		return ""
+	}
+	return name
+}
+
+// protoFieldName 将 Protobuf 字段名转为 Go 字段名，如 user_id -> UserID、sku_code -> SKUCode
+func protoFieldName(name string) string {
+	var sb strings.Builder
+	for _, word := range strings.Split(name, "_") {
+		if word == "" {
+			continue
+		}
+		if protoInitialisms[strings.ToLower(word)] {
+			sb.WriteString(strings.ToUpper(word))
+			continue
+		}
+		sb.WriteString(strings.ToUpper(word[:1]))
+		sb.WriteString(word[1:])
+	}
+	return sb.String()
+}
+
+// protoJoinName 拼接作用域和类型名
+func protoJoinName(scope, name string) string {
+	if scope == "" {
+		return name
+	}
+	return scope + "." + name
+}
@@ -0,0 +1,159 @@
+package parser
+
+import (
+	"fmt"
+	"go/types"
+	"soliton/pkg/metadata"
+	"strconv"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// SetResolveTypes 设置是否启用类型解析模式
+//
+// 默认只做语法解析，字段类型取自源码中的写法：类型别名、命名类型、
+// 其他包的类型只能得到未限定的名称，复杂类型会被标记为 "unknown"。
+// 启用后 ParseFile、ParseDirectory 会额外通过 go/packages 加载并类型检查模型所在的包，
+// 为每个字段填充 QualifiedType / UnderlyingType，并展开类型别名。
+//
+// 类型解析要求模型目录位于可编译的 Go 模块中。
+func (p *ASTParser) SetResolveTypes(enabled bool) {
+	p.resolveTypes = enabled
+}
+
+// resolveFieldTypes 使用 go/packages 为聚合根字段填充解析后的类型信息
+func (p *ASTParser) resolveFieldTypes(dir string, aggregates []*metadata.AggregateMetadata) error {
+	if len(aggregates) == 0 {
+		return nil
+	}
+
+	// 依赖包也从源码类型检查，不依赖编译缓存中的导出数据格式
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedSyntax |
+			packages.NeedImports | packages.NeedDeps,
+		Dir:     dir,
+		Overlay: p.overlay,
+	}
+	pkgs, err := packages.Load(cfg, "./...")
+	if err != nil {
+		return fmt.Errorf("加载包失败: %w", err)
+	}
+
+	pkgByPath := make(map[string]*types.Package, len(pkgs))
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			return fmt.Errorf("包 %s 类型检查失败: %v", pkg.PkgPath, pkg.Errors[0])
+		}
+		if pkg.Types != nil {
+			pkgByPath[pkg.PkgPath] = pkg.Types
+		}
+	}
+
+	for _, agg := range aggregates {
+		pkg := pkgByPath[agg.ImportPath]
+		if pkg == nil {
+			continue
+		}
+
+		typeName, ok := pkg.Scope().Lookup(agg.Name).(*types.TypeName)
+		if !ok {
+			continue
+		}
+		if _, ok := typeName.Type().Underlying().(*types.Struct); !ok {
+			continue
+		}
+
+		for _, field := range agg.Fields {
+			// 按选择器查找，嵌入结构体中的提升字段同样适用
+			obj, _, _ := types.LookupFieldOrMethod(typeName.Type(), false, pkg, field.Name)
+			if v, ok := obj.(*types.Var); ok && v.IsField() {
+				p.resolveField(field, v.Type(), pkg)
+			}
+		}
+
+		// 别名展开后字段类型可能变化，重新识别 ID 和 BaseEntity 字段
+		agg.BaseEntity = p.identifyBaseEntityFields(agg.MappedFields())
+		agg.PrimaryKey, agg.IDField = p.identifyPrimaryKey(agg.MappedFields())
+		agg.IDStrategy = identifyIDStrategy(agg)
+	}
+
+	return nil
+}
+
+// resolveField 根据类型检查结果填充字段类型信息
+//
+// 与 analyzeFieldType 一致，去除指针、切片和定长数组后记录元素类型：
+//   - QualifiedType：完整限定类型，如 "time.Time"、"mymodule/domain/model.OrderStatus"
+//   - UnderlyingType：命名类型的底层基础类型，如 "string"
+//
+// 语法解析无法识别（"unknown"）或经过类型别名的字段，会用解析结果覆盖 Type/IsPointer/IsSlice
+// 以及 map、定长数组信息，类型名按生成代码的写法限定：同包类型不带前缀，其他包使用包名，如 "time.Time"。
+func (p *ASTParser) resolveField(field *metadata.FieldMetadata, typ types.Type, pkg *types.Package) {
+	elem, isPointer, isSlice, arrayLen, viaAlias := unwrapFieldType(typ)
+
+	field.QualifiedType = types.TypeString(elem, nil)
+	if named, ok := elem.(*types.Named); ok {
+		if basic, ok := named.Underlying().(*types.Basic); ok {
+			field.UnderlyingType = basic.Name()
+		}
+	}
+
+	if field.Type == "unknown" || viaAlias {
+		qualifier := func(other *types.Package) string {
+			if other == pkg {
+				return ""
+			}
+			return other.Name()
+		}
+
+		field.Type = types.TypeString(elem, qualifier)
+		field.IsPointer = isPointer
+		field.IsSlice = isSlice
+		field.IsArray = arrayLen >= 0
+		field.ArrayLen = ""
+		if field.IsArray {
+			field.ArrayLen = strconv.FormatInt(arrayLen, 10)
+		}
+
+		m, isMap := types.Unalias(elem).(*types.Map)
+		field.IsMap = isMap
+		field.MapKeyType, field.MapValueType = "", ""
+		if isMap {
+			field.MapKeyType = types.TypeString(m.Key(), qualifier)
+			field.MapValueType = types.TypeString(m.Elem(), qualifier)
+		}
+
+		// 与 parseField 一致：map 和定长数组值对象默认使用 JSON 策略
+		if (field.IsMap || field.IsArray) && field.Annotations.IsValueObject && field.Annotations.Strategy == "" {
+			field.Annotations.Strategy = "json"
+		}
+	}
+}
+
+// unwrapFieldType 展开类型别名并去除指针、切片和定长数组
+// 返回：元素类型、是否指针、是否切片、定长数组长度（非数组为 -1）、是否经过类型别名
+func unwrapFieldType(typ types.Type) (elem types.Type, isPointer bool, isSlice bool, arrayLen int64, viaAlias bool) {
+	unalias := func(t types.Type) types.Type {
+		if _, ok := t.(*types.Alias); ok {
+			viaAlias = true
+		}
+		return types.Unalias(t)
+	}
+
+	arrayLen = -1
+	elem = unalias(typ)
+	switch container := elem.(type) {
+	case *types.Slice:
+		isSlice = true
+		elem = unalias(container.Elem())
+	case *types.Array:
+		arrayLen = container.Len()
+		elem = unalias(container.Elem())
+	}
+	if ptr, ok := elem.(*types.Pointer); ok {
+		isPointer = true
+		elem = unalias(ptr.Elem())
+	}
+
+	return elem, isPointer, isSlice, arrayLen, viaAlias
+}